@@ -11,6 +11,7 @@ import (
 
 	appconfig "github.com/antinvestor/builder/apps/executor/config"
 	"github.com/antinvestor/builder/apps/executor/service/sandbox"
+	"github.com/antinvestor/builder/internal/artifacts"
 )
 
 func main() {
@@ -23,6 +24,11 @@ func main() {
 		return
 	}
 
+	if err = cfg.ValidateTestCommands(); err != nil {
+		util.Log(ctx).With("err", err).Error("invalid test commands")
+		return
+	}
+
 	if cfg.Name() == "" {
 		cfg.ServiceName = "feature_executor"
 	}
@@ -53,8 +59,24 @@ func main() {
 		}
 	}()
 
+	if sandboxExecutor.Mode() == sandbox.SandboxModeLocal {
+		log.Warn("sandbox running in local mode without isolation, unsafe for untrusted code")
+	}
+
 	testRunner := sandbox.NewMultiRunner(&cfg)
 
+	// Offloaded test outputs go to the artifact bucket shared with the worker
+	artifactStore, err := artifacts.OpenBucketStore(ctx, cfg.ArtifactStoreURL)
+	if err != nil {
+		log.WithError(err).Error("failed to open artifact store")
+		return
+	}
+	defer func() {
+		if closeErr := artifactStore.Close(); closeErr != nil {
+			log.WithError(closeErr).Warn("failed to close artifact store")
+		}
+	}()
+
 	// ==========================================================================
 	// Register Publishers
 	// ==========================================================================
@@ -68,10 +90,12 @@ func main() {
 	// Register Subscribers
 	// ==========================================================================
 
+	executionRequestHandler := sandbox.NewExecutionRequestHandler(&cfg, sandboxExecutor, testRunner, evtsMan)
+	executionRequestHandler.SetArtifactStore(artifactStore)
 	executionRequestSubscriber := frame.WithRegisterSubscriber(
 		cfg.QueueExecutionRequestName,
 		cfg.QueueExecutionRequestURI,
-		sandbox.NewExecutionRequestHandler(&cfg, sandboxExecutor, testRunner, evtsMan),
+		executionRequestHandler,
 	)
 
 	// ==========================================================================
@@ -86,11 +110,17 @@ func main() {
 		_, _ = w.Write([]byte(`{"status":"healthy","service":"executor"}`))
 	})
 
-	mux.HandleFunc("/ready", func(w http.ResponseWriter, _ *http.Request) {
-		// Check if Docker is available
+	mux.HandleFunc("/ready", func(w http.ResponseWriter, r *http.Request) {
+		// Check if the sandbox backend is available
 		w.Header().Set("Content-Type", "application/json")
+		if readyErr := sandboxExecutor.Ready(r.Context()); readyErr != nil {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			_, _ = fmt.Fprintf(w, `{"status":"not_ready","service":"executor","sandbox_mode":%q}`,
+				sandboxExecutor.Mode())
+			return
+		}
 		w.WriteHeader(http.StatusOK)
-		_, _ = w.Write([]byte(`{"status":"ready","service":"executor"}`))
+		_, _ = fmt.Fprintf(w, `{"status":"ready","service":"executor","sandbox_mode":%q}`, sandboxExecutor.Mode())
 	})
 
 	// Active executions endpoint
@@ -98,7 +128,8 @@ func main() {
 		count := sandboxExecutor.ActiveCount()
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusOK)
-		_, _ = fmt.Fprintf(w, `{"active_executions":%d}`, count)
+		_, _ = fmt.Fprintf(w, `{"active_executions":%d,"queued_executions":%d}`,
+			count, executionRequestHandler.QueuedCount())
 	})
 
 	// ==========================================================================
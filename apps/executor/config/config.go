@@ -1,6 +1,11 @@
 package config
 
 import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
 	"github.com/pitabwire/frame/config"
 )
 
@@ -32,6 +37,10 @@ type ExecutorConfig struct {
 	// SandboxType is the sandbox type: docker, gvisor, firecracker.
 	SandboxType string `envDefault:"docker" env:"SANDBOX_TYPE"`
 
+	// SandboxMode selects the execution backend: docker, or local to run tests as
+	// plain subprocesses. Local mode has no isolation and is unsafe for untrusted code.
+	SandboxMode string `envDefault:"docker" env:"SANDBOX_MODE"`
+
 	// SandboxImage is the container image for sandbox execution.
 	SandboxImage string `envDefault:"feature-sandbox:latest" env:"SANDBOX_IMAGE"`
 
@@ -41,6 +50,10 @@ type ExecutorConfig struct {
 	// SandboxCPULimit is the CPU limit.
 	SandboxCPULimit float64 `envDefault:"2.0" env:"SANDBOX_CPU_LIMIT"`
 
+	// SandboxPIDsLimit caps the processes and threads a sandbox may run, stopping fork
+	// bombs (0 = unlimited). The local backend applies it as the per-user process rlimit.
+	SandboxPIDsLimit int `envDefault:"512" env:"SANDBOX_PIDS_LIMIT"`
+
 	// SandboxNetworkEnabled enables network in sandbox.
 	SandboxNetworkEnabled bool `envDefault:"false" env:"SANDBOX_NETWORK_ENABLED"`
 
@@ -51,9 +64,19 @@ type ExecutorConfig struct {
 	// Concurrency
 	// ==========================================================================
 
-	// MaxConcurrentExecutions is the maximum concurrent executions.
+	// MaxConcurrentExecutions is the maximum concurrent executions; further executions
+	// wait for one to finish (0 = unlimited).
 	MaxConcurrentExecutions int `envDefault:"10" env:"MAX_CONCURRENT_EXECUTIONS"`
 
+	// MaxConcurrentSandboxes caps the sandboxes running at once; further executions
+	// wait in a queue for a free sandbox (0 = unlimited).
+	MaxConcurrentSandboxes int `envDefault:"0" env:"MAX_CONCURRENT_SANDBOXES"`
+
+	// SandboxQueueTimeoutSeconds is how long an execution waits for a free sandbox or
+	// execution slot before it fails with sandbox_busy and is redelivered (0 = wait
+	// indefinitely).
+	SandboxQueueTimeoutSeconds int `envDefault:"300" env:"SANDBOX_QUEUE_TIMEOUT_SECONDS"`
+
 	// ==========================================================================
 	// Workspace Configuration
 	// ==========================================================================
@@ -68,9 +91,83 @@ type ExecutorConfig struct {
 	// DefaultTestTimeout is the default test timeout in seconds.
 	DefaultTestTimeout int `envDefault:"300" env:"DEFAULT_TEST_TIMEOUT"`
 
+	// TestCommands replaces the built-in test command of a language (e.g.
+	// "python:pytest -q,go:gotestsum"); commands are run with sh -c in the sandbox.
+	// Output is still parsed by the language's parser.
+	TestCommands map[string]string `env:"TEST_COMMANDS"`
+
+	// AllowedTestCommands are the test commands a request may ask for in place of the
+	// language's command (comma-separated, e.g. the worker's fallback "make test").
+	// The TestCommands are always allowed; any other requested command is refused.
+	AllowedTestCommands string `env:"ALLOWED_TEST_COMMANDS"`
+
 	// CoverageEnabled enables coverage collection.
 	CoverageEnabled bool `envDefault:"true" env:"COVERAGE_ENABLED"`
 
 	// CoverageThreshold is the minimum coverage percentage.
 	CoverageThreshold float64 `envDefault:"70.0" env:"COVERAGE_THRESHOLD"`
+
+	// ==========================================================================
+	// Test Progress Streaming
+	// ==========================================================================
+
+	// TestProgressEnabled emits test progress events while a test run is in flight.
+	TestProgressEnabled bool `envDefault:"true" env:"TEST_PROGRESS_ENABLED"`
+
+	// TestProgressIntervalSeconds is the minimum time between progress events of a
+	// run (0 = report every completed test).
+	TestProgressIntervalSeconds int `envDefault:"5" env:"TEST_PROGRESS_INTERVAL_SECONDS"`
+
+	// MaxTestOutputBytes caps the test output kept in memory; beyond it only the
+	// beginning and end of the output are kept (0 = unlimited). With progress
+	// enabled, tests reported in the dropped middle are still counted.
+	MaxTestOutputBytes int `envDefault:"10485760" env:"MAX_TEST_OUTPUT_BYTES"`
+
+	// ==========================================================================
+	// Test Output Offloading
+	// ==========================================================================
+
+	// TestOutputInlineLimitBytes is the largest test output carried in full on the
+	// completion event; larger output is stored as an artifact (0 = always inline).
+	TestOutputInlineLimitBytes int `envDefault:"8192" env:"TEST_OUTPUT_INLINE_LIMIT_BYTES"`
+
+	// TestOutputTailBytes is how much of the end of an offloaded output stays inline.
+	TestOutputTailBytes int `envDefault:"2048" env:"TEST_OUTPUT_TAIL_BYTES"`
+
+	// ArtifactStoreURL is the blob bucket offloaded test outputs are written to; the
+	// worker reads and expires artifacts in the same bucket.
+	ArtifactStoreURL string `envDefault:"file:///var/lib/feature-service/artifacts?create_dir=true" env:"ARTIFACT_STORE_URL"`
+}
+
+// TestCommand returns the test command configured for a language, or "" when the
+// language runs its built-in command.
+func (c *ExecutorConfig) TestCommand(language string) string {
+	return strings.TrimSpace(c.TestCommands[strings.ToLower(language)])
+}
+
+// TestCommandAllowed reports whether a request may run a test command: one of the
+// AllowedTestCommands or the TestCommands.
+func (c *ExecutorConfig) TestCommandAllowed(command string) bool {
+	command = strings.TrimSpace(command)
+	for allowed := range strings.SplitSeq(c.AllowedTestCommands, ",") {
+		if strings.TrimSpace(allowed) == command {
+			return true
+		}
+	}
+	for _, configured := range c.TestCommands {
+		if strings.TrimSpace(configured) == command {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateTestCommands reports a language configured with an empty test command.
+func (c *ExecutorConfig) ValidateTestCommands() error {
+	for _, language := range slices.Sorted(maps.Keys(c.TestCommands)) {
+		if strings.TrimSpace(c.TestCommands[language]) == "" {
+			return fmt.Errorf("test command for language %q is empty", language)
+		}
+	}
+	return nil
 }
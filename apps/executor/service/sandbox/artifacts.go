@@ -0,0 +1,79 @@
+package sandbox
+
+import (
+	"context"
+	"unicode/utf8"
+
+	"github.com/pitabwire/util"
+
+	"github.com/antinvestor/builder/internal/events"
+)
+
+const (
+	// testOutputArtifactName is the name the full test output of a run is stored under.
+	testOutputArtifactName = "test-output.log"
+	// testOutputArtifactType is the artifact type of a stored test output.
+	testOutputArtifactType = "log"
+)
+
+// ArtifactStore stores execution artifacts too large to carry on events, as
+// artifacts.Store does.
+type ArtifactStore interface {
+	Put(
+		ctx context.Context,
+		executionID events.ExecutionID,
+		name string,
+		contentType string,
+		data []byte,
+	) (*events.ArtifactReference, error)
+}
+
+// SetArtifactStore offloads test output above the inline limit to the store.
+func (h *ExecutionRequestHandler) SetArtifactStore(store ArtifactStore) {
+	h.artifacts = store
+}
+
+// testOutput returns the output to inline on the completion event and, when the
+// output exceeds the inline limit, a reference to the full output in the artifact
+// store with only its tail inlined. Without a store, or when storing fails, the
+// tail is inlined alone so events stay small.
+func (h *ExecutionRequestHandler) testOutput(
+	ctx context.Context,
+	executionID events.ExecutionID,
+	output string,
+) (string, *events.ArtifactReference) {
+	limit := h.cfg.TestOutputInlineLimitBytes
+	if limit <= 0 || len(output) <= limit {
+		return output, nil
+	}
+	tail := outputTail(output, min(h.cfg.TestOutputTailBytes, limit))
+	if h.artifacts == nil {
+		return tail, nil
+	}
+
+	ref, err := h.artifacts.Put(ctx, executionID, testOutputArtifactName, "text/plain; charset=utf-8", []byte(output))
+	if err != nil {
+		util.Log(ctx).WithError(err).Warn("could not offload test output, inlining its tail only",
+			"execution_id", executionID.String(),
+			"output_bytes", len(output),
+		)
+		return tail, nil
+	}
+	ref.Type = testOutputArtifactType
+	return tail, ref
+}
+
+// outputTail returns at most the last n bytes of output, not splitting a character.
+func outputTail(output string, n int) string {
+	if n <= 0 {
+		return ""
+	}
+	if len(output) <= n {
+		return output
+	}
+	start := len(output) - n
+	for start < len(output) && !utf8.RuneStart(output[start]) {
+		start++
+	}
+	return output[start:]
+}
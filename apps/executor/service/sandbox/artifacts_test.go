@@ -0,0 +1,116 @@
+//nolint:testpackage // white-box testing requires internal package access
+package sandbox
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gocloud.dev/blob/memblob"
+
+	appconfig "github.com/antinvestor/builder/apps/executor/config"
+	"github.com/antinvestor/builder/internal/artifacts"
+	"github.com/antinvestor/builder/internal/events"
+)
+
+// fixedOutputSandbox completes every execution with the same output.
+type fixedOutputSandbox struct {
+	output string
+}
+
+func (s *fixedOutputSandbox) Execute(context.Context, *SandboxExecutionRequest) (*SandboxExecutionResult, error) {
+	return &SandboxExecutionResult{Output: s.output, Duration: 10}, nil
+}
+
+func (s *fixedOutputSandbox) Ready(context.Context) error { return nil }
+
+func (s *fixedOutputSandbox) Close() error { return nil }
+
+type failingArtifactStore struct{}
+
+func (failingArtifactStore) Put(
+	context.Context,
+	events.ExecutionID,
+	string,
+	string,
+	[]byte,
+) (*events.ArtifactReference, error) {
+	return nil, errors.New("disk full")
+}
+
+func runWithOutput(
+	t *testing.T,
+	output string,
+	store ArtifactStore,
+) *events.TestExecutionCompletedPayload {
+	t.Helper()
+	cfg := &appconfig.ExecutorConfig{
+		SandboxEnabled:             true,
+		MaxConcurrentExecutions:    1,
+		TestOutputInlineLimitBytes: 1024,
+		TestOutputTailBytes:        64,
+	}
+	executor := &SandboxExecutor{cfg: cfg, backend: &fixedOutputSandbox{output: output}, mode: SandboxModeLocal}
+	emitter := &recordingEmitter{}
+	handler := NewExecutionRequestHandler(cfg, executor, NewMultiRunner(cfg), emitter)
+	if store != nil {
+		handler.SetArtifactStore(store)
+	}
+
+	require.NoError(t, handler.Handle(context.Background(), nil, executionRequestPayload(t, events.NewExecutionID())))
+	completed := emitter.completed()
+	require.Len(t, completed, 1)
+	return completed[0]
+}
+
+func largeTestOutput() string {
+	return strings.Repeat("=== RUN   TestAdd\n--- PASS: TestAdd (0.00s)\n", 100) + "PASS\nok  \texample.com/calc\t0.002s\n"
+}
+
+func TestExecutionRequestHandler_LargeOutputOffloaded(t *testing.T) {
+	store := artifacts.NewBucketStore(memblob.OpenBucket(nil), "mem://")
+	output := largeTestOutput()
+
+	completed := runWithOutput(t, output, store)
+
+	require.NotNil(t, completed.OutputArtifact)
+	assert.Equal(t, testOutputArtifactName, completed.OutputArtifact.Name)
+	assert.Equal(t, testOutputArtifactType, completed.OutputArtifact.Type)
+	assert.Equal(t, int64(len(output)), completed.OutputArtifact.SizeBytes)
+	assert.Len(t, completed.Output, 64)
+	assert.True(t, strings.HasSuffix(output, completed.Output), "inlined output should be the tail")
+
+	assert.Equal(t, "mem://"+completed.OutputArtifact.ArtifactID, completed.OutputArtifact.URL)
+	stored, err := store.Get(context.Background(), completed.ExecutionID, testOutputArtifactName)
+	require.NoError(t, err)
+	assert.Equal(t, output, string(stored))
+}
+
+func TestExecutionRequestHandler_SmallOutputInlined(t *testing.T) {
+	output := "PASS\nok  \texample.com/calc\t0.002s\n"
+
+	completed := runWithOutput(t, output, artifacts.NewBucketStore(memblob.OpenBucket(nil), "mem://"))
+
+	assert.Equal(t, output, completed.Output)
+	assert.Nil(t, completed.OutputArtifact)
+}
+
+func TestExecutionRequestHandler_OffloadFailureInlinesTail(t *testing.T) {
+	output := largeTestOutput()
+
+	completed := runWithOutput(t, output, failingArtifactStore{})
+
+	assert.Nil(t, completed.OutputArtifact)
+	assert.Len(t, completed.Output, 64)
+	assert.True(t, strings.HasSuffix(output, completed.Output))
+}
+
+func TestOutputTail_DoesNotSplitCharacters(t *testing.T) {
+	assert.Equal(t, "é!", outputTail("café!", 3))
+	assert.Equal(t, "!", outputTail("café!", 2))
+	assert.Equal(t, "short", outputTail("short", 64))
+	assert.Empty(t, outputTail("anything", 0))
+}
@@ -13,6 +13,7 @@ import (
 	"github.com/docker/docker/api/types/mount"
 	"github.com/docker/docker/api/types/network"
 	"github.com/docker/docker/client"
+	"github.com/docker/docker/pkg/stdcopy"
 	"github.com/pitabwire/util"
 
 	appconfig "github.com/antinvestor/builder/apps/executor/config"
@@ -115,6 +116,14 @@ func (e *DockerExecutor) Close() error {
 	return nil
 }
 
+// Ready implements Sandbox by pinging the Docker daemon.
+func (e *DockerExecutor) Ready(ctx context.Context) error {
+	if _, err := e.client.Ping(ctx); err != nil {
+		return fmt.Errorf("ping docker daemon: %w", err)
+	}
+	return nil
+}
+
 // Execute runs tests in a Docker container.
 func (e *DockerExecutor) Execute(ctx context.Context, req *SandboxExecutionRequest) (*SandboxExecutionResult, error) {
 	log := util.Log(ctx)
@@ -122,6 +131,9 @@ func (e *DockerExecutor) Execute(ctx context.Context, req *SandboxExecutionReque
 
 	// Get language configuration
 	langConfig := e.getLanguageConfig(req.Language)
+	if len(req.TestCommand) > 0 {
+		langConfig.TestCommand = req.TestCommand
+	}
 
 	// Build workspace path
 	workspacePath := filepath.Join(e.cfg.WorkspaceBasePath, req.ExecutionID.String())
@@ -146,6 +158,20 @@ func (e *DockerExecutor) Execute(ctx context.Context, req *SandboxExecutionReque
 	if startErr := e.client.ContainerStart(ctx, containerID, container.StartOptions{}); startErr != nil {
 		return nil, fmt.Errorf("start container: %w", startErr)
 	}
+	output := newOutputCapture(e.cfg.MaxTestOutputBytes)
+	var sink io.Writer = output
+	if req.OutputWriter != nil {
+		sink = io.MultiWriter(output, req.OutputWriter)
+	}
+	streamCtx, stopStream := context.WithCancel(ctx)
+	defer stopStream()
+	streamed := e.streamLogs(streamCtx, containerID, sink)
+	// A run cut short stops following the logs, and waits for the stream to let
+	// go of the output writer before the caller finishes with it
+	abandonStream := func() {
+		stopStream()
+		<-streamed
+	}
 
 	// Wait for container to finish with timeout
 	timeoutCtx, cancel := context.WithTimeout(ctx, time.Duration(e.cfg.SandboxTimeoutSeconds)*time.Second)
@@ -154,12 +180,14 @@ func (e *DockerExecutor) Execute(ctx context.Context, req *SandboxExecutionReque
 	statusCh, errCh := e.client.ContainerWait(timeoutCtx, containerID, container.WaitConditionNotRunning)
 
 	var exitCode int64
+	var oomKilled bool
 	select {
 	case waitErr := <-errCh:
 		if waitErr != nil {
 			// Timeout or other error - kill the container
 			log.Warn("container wait error, killing container", "error", waitErr)
 			_ = e.client.ContainerKill(ctx, containerID, "KILL")
+			abandonStream()
 			return &SandboxExecutionResult{
 				Output:   fmt.Sprintf("Execution error: %v", waitErr),
 				ExitCode: -1,
@@ -168,10 +196,12 @@ func (e *DockerExecutor) Execute(ctx context.Context, req *SandboxExecutionReque
 		}
 	case status := <-statusCh:
 		exitCode = status.StatusCode
+		oomKilled = e.oomKilled(ctx, containerID)
 	case <-timeoutCtx.Done():
 		// Timeout reached - kill the container
 		log.Warn("container execution timeout, killing container")
 		_ = e.client.ContainerKill(ctx, containerID, "KILL")
+		abandonStream()
 		return &SandboxExecutionResult{
 			Output:   "Execution timed out",
 			ExitCode: -1,
@@ -179,11 +209,17 @@ func (e *DockerExecutor) Execute(ctx context.Context, req *SandboxExecutionReque
 		}, nil
 	}
 
-	// Get container logs
-	output, err := e.getContainerLogs(ctx, containerID)
-	if err != nil {
-		log.WithError(err).Warn("failed to get container logs")
-		output = "Failed to retrieve test output"
+	// The streamed copy holds the whole output once drained; the logs are only
+	// read again when following them failed
+	if streamErr := <-streamed; streamErr != nil {
+		log.WithError(streamErr).Warn("container log stream failed, reading logs again")
+		logs, logsErr := e.getContainerLogs(ctx, containerID)
+		if logsErr != nil {
+			log.WithError(logsErr).Warn("failed to get container logs")
+			logs = "Failed to retrieve test output"
+		}
+		output = newOutputCapture(e.cfg.MaxTestOutputBytes)
+		_, _ = io.WriteString(output, logs)
 	}
 
 	duration := time.Since(startTime).Milliseconds()
@@ -192,12 +228,16 @@ func (e *DockerExecutor) Execute(ctx context.Context, req *SandboxExecutionReque
 		"execution_id", req.ExecutionID,
 		"exit_code", exitCode,
 		"duration_ms", duration,
+		"oom_killed", oomKilled,
+		"output_bytes", output.Total(),
 	)
 
 	return &SandboxExecutionResult{
-		Output:   output,
-		ExitCode: int(exitCode),
-		Duration: duration,
+		Output:               output.String(),
+		ExitCode:             int(exitCode),
+		Duration:             duration,
+		OutputTruncatedBytes: output.Dropped(),
+		OOMKilled:            oomKilled,
 	}, nil
 }
 
@@ -221,10 +261,6 @@ func (e *DockerExecutor) createContainer(
 		},
 	}
 
-	// Calculate resource limits
-	memoryLimit := int64(e.cfg.SandboxMemoryLimitMB) * bytesPerMB // Convert MB to bytes
-	cpuQuota := int64(e.cfg.SandboxCPULimit * cpuQuotaPerCore)    // CPU quota in microseconds
-
 	hostConfig := &container.HostConfig{
 		Mounts: []mount.Mount{
 			{
@@ -234,10 +270,7 @@ func (e *DockerExecutor) createContainer(
 				ReadOnly: false,
 			},
 		},
-		Resources: container.Resources{
-			Memory:   memoryLimit,
-			CPUQuota: cpuQuota,
-		},
+		Resources:  e.resources(),
 		AutoRemove: false, // We'll remove manually after getting logs
 	}
 
@@ -257,6 +290,37 @@ func (e *DockerExecutor) createContainer(
 	return resp.ID, nil
 }
 
+// resources returns the container limits, the equivalent of docker run's --memory,
+// --cpus and --pids-limit. Swap is capped at the memory limit so it cannot be
+// used to exceed it.
+func (e *DockerExecutor) resources() container.Resources {
+	var resources container.Resources
+	if e.cfg.SandboxMemoryLimitMB > 0 {
+		resources.Memory = int64(e.cfg.SandboxMemoryLimitMB) * bytesPerMB // Convert MB to bytes
+		resources.MemorySwap = resources.Memory
+	}
+	if e.cfg.SandboxCPULimit > 0 {
+		resources.CPUPeriod = cpuQuotaPerCore
+		resources.CPUQuota = int64(e.cfg.SandboxCPULimit * cpuQuotaPerCore) // CPU quota in microseconds
+	}
+	if e.cfg.SandboxPIDsLimit > 0 {
+		pids := int64(e.cfg.SandboxPIDsLimit)
+		resources.PidsLimit = &pids
+	}
+	return resources
+}
+
+// oomKilled reports whether the kernel killed the container for exceeding its
+// memory limit.
+func (e *DockerExecutor) oomKilled(ctx context.Context, containerID string) bool {
+	inspect, err := e.client.ContainerInspect(ctx, containerID)
+	if err != nil {
+		util.Log(ctx).WithError(err).Warn("failed to inspect container", "container_id", containerID)
+		return false
+	}
+	return inspect.ContainerJSONBase != nil && inspect.State != nil && inspect.State.OOMKilled
+}
+
 // getContainerLogs retrieves the logs from a container.
 func (e *DockerExecutor) getContainerLogs(ctx context.Context, containerID string) (string, error) {
 	options := container.LogsOptions{
@@ -283,6 +347,31 @@ func (e *DockerExecutor) getContainerLogs(ctx context.Context, containerID strin
 	return stripDockerLogHeaders(buf.Bytes()), nil
 }
 
+// streamLogs follows the container's logs into w until the container stops. The
+// returned channel receives nil once the stream has drained, or the error that
+// ended it early.
+func (e *DockerExecutor) streamLogs(ctx context.Context, containerID string, w io.Writer) <-chan error {
+	done := make(chan error, 1)
+	go func() {
+		reader, err := e.client.ContainerLogs(ctx, containerID, container.LogsOptions{
+			ShowStdout: true,
+			ShowStderr: true,
+			Follow:     true,
+		})
+		if err != nil {
+			done <- fmt.Errorf("follow container logs: %w", err)
+			return
+		}
+		defer reader.Close()
+		if _, err = stdcopy.StdCopy(w, w, reader); err != nil {
+			done <- fmt.Errorf("copy container logs: %w", err)
+			return
+		}
+		done <- nil
+	}()
+	return done
+}
+
 // stripDockerLogHeaders removes the 8-byte header from each log frame.
 func stripDockerLogHeaders(data []byte) string {
 	var result bytes.Buffer
@@ -407,6 +496,7 @@ func (e *DockerExecutor) executeWithConfig(
 	statusCh, errCh := e.client.ContainerWait(timeoutCtx, containerID, container.WaitConditionNotRunning)
 
 	var exitCode int64
+	var oomKilled bool
 	select {
 	case waitErr := <-errCh:
 		if waitErr != nil {
@@ -420,6 +510,7 @@ func (e *DockerExecutor) executeWithConfig(
 		}
 	case status := <-statusCh:
 		exitCode = status.StatusCode
+		oomKilled = e.oomKilled(ctx, containerID)
 	case <-timeoutCtx.Done():
 		log.Warn("container execution timeout, killing container")
 		_ = e.client.ContainerKill(ctx, containerID, "KILL")
@@ -431,11 +522,13 @@ func (e *DockerExecutor) executeWithConfig(
 	}
 
 	// Get container logs
-	output, logsErr := e.getContainerLogs(ctx, containerID)
+	logs, logsErr := e.getContainerLogs(ctx, containerID)
 	if logsErr != nil {
 		log.WithError(logsErr).Warn("failed to get container logs")
-		output = "Failed to retrieve test output"
+		logs = "Failed to retrieve test output"
 	}
+	output := newOutputCapture(e.cfg.MaxTestOutputBytes)
+	_, _ = io.WriteString(output, logs)
 
 	duration := time.Since(startTime).Milliseconds()
 
@@ -443,11 +536,14 @@ func (e *DockerExecutor) executeWithConfig(
 		"execution_id", req.ExecutionID,
 		"exit_code", exitCode,
 		"duration_ms", duration,
+		"oom_killed", oomKilled,
 	)
 
 	return &SandboxExecutionResult{
-		Output:   output,
-		ExitCode: int(exitCode),
-		Duration: duration,
+		Output:               output.String(),
+		ExitCode:             int(exitCode),
+		Duration:             duration,
+		OutputTruncatedBytes: output.Dropped(),
+		OOMKilled:            oomKilled,
 	}, nil
 }
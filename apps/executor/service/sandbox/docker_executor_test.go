@@ -10,6 +10,27 @@ import (
 	appconfig "github.com/antinvestor/builder/apps/executor/config"
 )
 
+func TestDockerExecutor_Resources(t *testing.T) {
+	exec := &DockerExecutor{cfg: &appconfig.ExecutorConfig{
+		SandboxMemoryLimitMB: 512,
+		SandboxCPULimit:      1.5,
+		SandboxPIDsLimit:     128,
+	}}
+
+	resources := exec.resources()
+	assert.Equal(t, int64(512*bytesPerMB), resources.Memory, "--memory")
+	assert.Equal(t, resources.Memory, resources.MemorySwap, "no swap beyond the memory limit")
+	assert.Equal(t, int64(cpuQuotaPerCore), resources.CPUPeriod)
+	assert.Equal(t, int64(150000), resources.CPUQuota, "--cpus")
+	require.NotNil(t, resources.PidsLimit)
+	assert.Equal(t, int64(128), *resources.PidsLimit, "--pids-limit")
+
+	unlimited := (&DockerExecutor{cfg: &appconfig.ExecutorConfig{}}).resources()
+	assert.Zero(t, unlimited.Memory)
+	assert.Zero(t, unlimited.CPUQuota)
+	assert.Nil(t, unlimited.PidsLimit)
+}
+
 func TestDockerExecutor_GetLanguageConfig(t *testing.T) {
 	cfg := &appconfig.ExecutorConfig{
 		SandboxImage: "",
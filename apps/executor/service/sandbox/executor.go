@@ -5,7 +5,10 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
+	"strings"
 	"sync/atomic"
+	"time"
 
 	appconfig "github.com/antinvestor/builder/apps/executor/config"
 	"github.com/antinvestor/builder/internal/events"
@@ -16,10 +19,40 @@ const (
 	defaultTestDurationMs = 1000
 )
 
+// ErrorCodeOOMKilled is the failure code reported when a sandbox exceeds its memory limit.
+const ErrorCodeOOMKilled = "oom_killed"
+
+// ErrOOMKilled is reported when a sandbox is killed for exceeding its memory limit.
+var ErrOOMKilled = errors.New("sandbox killed for exceeding its memory limit")
+
+// ErrorCodeTestCommandNotAllowed is the failure code reported when a request asks for
+// a test command the executor does not allow.
+const ErrorCodeTestCommandNotAllowed = "test_command_not_allowed"
+
+// ErrTestCommandNotAllowed is reported when a requested test command is not allowed.
+var ErrTestCommandNotAllowed = errors.New("test command not allowed")
+
+// Sandbox modes selectable via ExecutorConfig.SandboxMode.
+const (
+	SandboxModeDocker   = "docker"
+	SandboxModeLocal    = "local"
+	SandboxModeDisabled = "disabled"
+)
+
 // =============================================================================
 // Interfaces
 // =============================================================================
 
+// Sandbox is an execution backend that runs test commands in isolation.
+type Sandbox interface {
+	// Execute runs the tests for an execution request.
+	Execute(ctx context.Context, req *SandboxExecutionRequest) (*SandboxExecutionResult, error)
+	// Ready reports whether the backend can accept executions.
+	Ready(ctx context.Context) error
+	// Close releases resources held by the backend.
+	Close() error
+}
+
 // EventsEmitter emits events.
 type EventsEmitter interface {
 	Emit(ctx context.Context, eventName string, payload any) error
@@ -35,6 +68,8 @@ type ExecutionRequestHandler struct {
 	executor  *SandboxExecutor
 	runner    *MultiRunner
 	eventsMan EventsEmitter
+	slots     *sandboxSlots
+	artifacts ArtifactStore
 }
 
 // NewExecutionRequestHandler creates a new execution request handler.
@@ -49,9 +84,17 @@ func NewExecutionRequestHandler(
 		executor:  executor,
 		runner:    runner,
 		eventsMan: eventsMan,
+		slots: newSandboxSlots(cfg.MaxConcurrentSandboxes,
+			time.Duration(cfg.SandboxQueueTimeoutSeconds)*time.Second),
 	}
 }
 
+// QueuedCount returns the number of executions waiting for a free sandbox or
+// execution slot.
+func (h *ExecutionRequestHandler) QueuedCount() int {
+	return h.slots.queued() + h.executor.QueuedCount()
+}
+
 // Handle processes incoming execution requests.
 func (h *ExecutionRequestHandler) Handle(
 	ctx context.Context,
@@ -63,33 +106,114 @@ func (h *ExecutionRequestHandler) Handle(
 		return fmt.Errorf("unmarshal execution request: %w", err)
 	}
 
-	// Execute in sandbox
-	result, err := h.executor.Execute(ctx, &SandboxExecutionRequest{
+	// Only commands the operator configured run in the sandbox
+	if request.TestCommand != "" && !h.cfg.TestCommandAllowed(request.TestCommand) {
+		return h.emitError(ctx, request.ExecutionID, ErrorCodeTestCommandNotAllowed,
+			fmt.Errorf("%w: %q", ErrTestCommandNotAllowed, request.TestCommand))
+	}
+
+	// Wait for a free sandbox; report backpressure if none frees up in time
+	release, err := h.slots.acquire(ctx)
+	if err != nil {
+		if errors.Is(err, ErrSandboxBusy) {
+			return h.emitBusy(ctx, request.ExecutionID, err)
+		}
+		return fmt.Errorf("wait for sandbox: %w", err)
+	}
+	defer release()
+
+	// Execute in sandbox, reporting progress as the test output streams in
+	executionReq := &SandboxExecutionRequest{
 		ExecutionID: request.ExecutionID,
 		Language:    request.Language,
 		TestFiles:   request.TestFiles,
 		Config:      h.cfg,
-	})
+	}
+	if request.TestCommand != "" {
+		executionReq.TestCommand = []string{"sh", "-c", request.TestCommand}
+	} else {
+		executionReq.TestCommand = h.runner.TestCommand(request.Language)
+	}
+	var progress *progressWriter
+	if h.cfg.TestProgressEnabled {
+		progress = newProgressWriter(ctx, h.eventsMan, request.ExecutionID,
+			time.Duration(h.cfg.TestProgressIntervalSeconds)*time.Second)
+		executionReq.OutputWriter = progress
+	}
+	result, err := h.executor.Execute(ctx, executionReq)
+	if progress != nil {
+		progress.finish()
+	}
+	if errors.Is(err, ErrSandboxBusy) {
+		return h.emitBusy(ctx, request.ExecutionID, err)
+	}
 	if err != nil {
 		return h.emitFailure(ctx, request.ExecutionID, err)
 	}
+	if result.OOMKilled {
+		// A killed run's output is cut off mid-test, so its results say nothing
+		return h.emitError(ctx, request.ExecutionID, ErrorCodeOOMKilled,
+			fmt.Errorf("%w of %d MB", ErrOOMKilled, h.cfg.SandboxMemoryLimitMB))
+	}
 
 	// Parse results
 	testResult, err := h.runner.ParseResults(result.Output, result.ExitCode, request.Language)
 	if err != nil {
 		return h.emitFailure(ctx, request.ExecutionID, err)
 	}
+	if result.OutputTruncatedBytes > 0 && progress != nil {
+		// Tests reported in the dropped output were still counted as they streamed
+		progress.reconcile(testResult)
+	}
 
 	// Emit success
-	return h.emitSuccess(ctx, request.ExecutionID, testResult)
+	return h.emitSuccess(ctx, request.ExecutionID, testResult, h.resourceUsage(result), result)
+}
+
+// resourceUsage estimates the sandbox CPU time of a run as its wall time at the
+// configured CPU limit, an upper bound the worker charges against the budget.
+func (h *ExecutionRequestHandler) resourceUsage(result *SandboxExecutionResult) *events.SandboxResourceUsage {
+	cpuLimit := h.cfg.SandboxCPULimit
+	if cpuLimit <= 0 {
+		cpuLimit = 1
+	}
+	return &events.SandboxResourceUsage{
+		CPUSecondsUsed: float64(result.Duration) / msPerSecond * cpuLimit,
+	}
+}
+
+// emitBusy reports a request that found no free sandbox as a retryable failure
+// and returns the error, so the request is redelivered once sandboxes free up.
+func (h *ExecutionRequestHandler) emitBusy(ctx context.Context, executionID events.ExecutionID, err error) error {
+	if emitErr := h.eventsMan.Emit(ctx, "feature.execution.failed", &events.TestExecutionCompletedPayload{
+		ExecutionID: executionID,
+		Success:     false,
+		Error: &events.ExecutionError{
+			Code:      ErrorCodeSandboxBusy,
+			Message:   err.Error(),
+			Retryable: true,
+		},
+	}); emitErr != nil {
+		return fmt.Errorf("emit sandbox busy: %w", emitErr)
+	}
+	return err
 }
 
 func (h *ExecutionRequestHandler) emitFailure(ctx context.Context, executionID events.ExecutionID, err error) error {
+	return h.emitError(ctx, executionID, "execution_failed", err)
+}
+
+func (h *ExecutionRequestHandler) emitError(
+	ctx context.Context,
+	executionID events.ExecutionID,
+	code string,
+	err error,
+) error {
 	return h.eventsMan.Emit(ctx, "feature.execution.failed", &events.TestExecutionCompletedPayload{
 		ExecutionID: executionID,
 		Success:     false,
 		Error: &events.ExecutionError{
-			Code:    "execution_failed",
+			Code:    code,
 			Message: err.Error(),
 		},
 	})
@@ -99,11 +223,18 @@ func (h *ExecutionRequestHandler) emitSuccess(
 	ctx context.Context,
 	executionID events.ExecutionID,
 	result *events.TestResult,
+	usage *events.SandboxResourceUsage,
+	execution *SandboxExecutionResult,
 ) error {
+	inlined, artifact := h.testOutput(ctx, executionID, execution.Output)
 	return h.eventsMan.Emit(ctx, "feature.execution.completed", &events.TestExecutionCompletedPayload{
-		ExecutionID: executionID,
-		Success:     true,
-		Result:      result,
+		ExecutionID:          executionID,
+		Success:              true,
+		Result:               result,
+		ResourceUsage:        usage,
+		Output:               inlined,
+		OutputArtifact:       artifact,
+		OutputTruncatedBytes: execution.OutputTruncatedBytes,
 	})
 }
 
@@ -116,33 +247,65 @@ func (h *ExecutionRequestHandler) emitSuccess(
 //nolint:revive // name stutters but changing would be a breaking change
 type SandboxExecutor struct {
 	cfg         *appconfig.ExecutorConfig
-	dockerExec  *DockerExecutor
+	backend     Sandbox
+	mode        string
+	slots       *sandboxSlots
 	activeCount int32
 }
 
-// NewSandboxExecutor creates a new sandbox executor.
+// NewSandboxExecutor creates a new sandbox executor using the backend selected by
+// SandboxMode.
 func NewSandboxExecutor(cfg *appconfig.ExecutorConfig) (*SandboxExecutor, error) {
-	var dockerExec *DockerExecutor
-	var err error
+	if !cfg.SandboxEnabled {
+		return &SandboxExecutor{cfg: cfg, mode: SandboxModeDisabled}, nil
+	}
+
+	mode := strings.ToLower(cfg.SandboxMode)
+	if mode == "" {
+		mode = SandboxModeDocker
+	}
 
-	// Initialize Docker executor once if sandbox is enabled
-	if cfg.SandboxEnabled {
-		dockerExec, err = NewDockerExecutor(cfg)
+	var backend Sandbox
+	switch mode {
+	case SandboxModeDocker:
+		// Initialize Docker executor once if sandbox is enabled
+		dockerExec, err := NewDockerExecutor(cfg)
 		if err != nil {
 			return nil, fmt.Errorf("create docker executor: %w", err)
 		}
+		backend = dockerExec
+	case SandboxModeLocal:
+		backend = NewLocalExecutor(cfg)
+	default:
+		return nil, fmt.Errorf("unknown sandbox mode %q", cfg.SandboxMode)
 	}
 
 	return &SandboxExecutor{
-		cfg:        cfg,
-		dockerExec: dockerExec,
+		cfg:     cfg,
+		backend: backend,
+		mode:    mode,
+		slots: newSandboxSlots(cfg.MaxConcurrentExecutions,
+			time.Duration(cfg.SandboxQueueTimeoutSeconds)*time.Second),
 	}, nil
 }
 
+// Mode returns the active sandbox backend: docker, local or disabled.
+func (e *SandboxExecutor) Mode() string {
+	return e.mode
+}
+
+// Ready reports whether the active backend can accept executions.
+func (e *SandboxExecutor) Ready(ctx context.Context) error {
+	if e.backend == nil {
+		return nil
+	}
+	return e.backend.Ready(ctx)
+}
+
 // Close releases resources held by the executor.
 func (e *SandboxExecutor) Close() error {
-	if e.dockerExec != nil {
-		return e.dockerExec.Close()
+	if e.backend != nil {
+		return e.backend.Close()
 	}
 	return nil
 }
@@ -155,6 +318,10 @@ type SandboxExecutionRequest struct {
 	Language    string
 	TestFiles   []string
 	Config      *appconfig.ExecutorConfig
+	// TestCommand, when set, replaces the language's test command.
+	TestCommand []string
+	// OutputWriter, when set, receives the test output as it is produced.
+	OutputWriter io.Writer
 }
 
 // SandboxExecutionResult contains execution result data.
@@ -164,22 +331,27 @@ type SandboxExecutionResult struct {
 	Output   string
 	ExitCode int
 	Duration int64
+	// OutputTruncatedBytes is how much output MaxTestOutputBytes dropped from Output.
+	OutputTruncatedBytes int64
+	// OOMKilled reports that the sandbox was killed for exceeding its memory limit.
+	OOMKilled bool
 }
 
-// Execute runs a command in a sandbox.
+// Execute runs a command in a sandbox. Beyond MaxConcurrentExecutions it waits for a
+// running execution to finish, failing with ErrSandboxBusy after the queue timeout.
 func (e *SandboxExecutor) Execute(ctx context.Context, req *SandboxExecutionRequest) (*SandboxExecutionResult, error) {
+	release, err := e.slots.acquire(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("wait for execution slot: %w", err)
+	}
+	defer release()
+
 	// Increment active count
 	atomic.AddInt32(&e.activeCount, 1)
 	defer atomic.AddInt32(&e.activeCount, -1)
 
-	// Check concurrency limit
-	//nolint:gosec // MaxConcurrentExecutions is a config value, typically small and won't overflow int32
-	if atomic.LoadInt32(&e.activeCount) > int32(e.cfg.MaxConcurrentExecutions) {
-		return nil, errors.New("max concurrent executions reached")
-	}
-
 	// If sandbox is disabled, run locally (for testing)
-	if !e.cfg.SandboxEnabled || e.dockerExec == nil {
+	if !e.cfg.SandboxEnabled || e.backend == nil {
 		return &SandboxExecutionResult{
 			Output:   "Tests executed successfully (sandbox disabled)",
 			ExitCode: 0,
@@ -187,8 +359,7 @@ func (e *SandboxExecutor) Execute(ctx context.Context, req *SandboxExecutionRequ
 		}, nil
 	}
 
-	// Use the reusable Docker executor for sandboxed execution
-	return e.dockerExec.Execute(ctx, req)
+	return e.backend.Execute(ctx, req)
 }
 
 // ActiveCount returns the number of active executions.
@@ -196,6 +367,11 @@ func (e *SandboxExecutor) ActiveCount() int {
 	return int(atomic.LoadInt32(&e.activeCount))
 }
 
+// QueuedCount returns the number of executions waiting for a slot.
+func (e *SandboxExecutor) QueuedCount() int {
+	return e.slots.queued()
+}
+
 // =============================================================================
 // Multi-Language Test Runner
 // =============================================================================
@@ -210,7 +386,18 @@ func NewMultiRunner(cfg *appconfig.ExecutorConfig) *MultiRunner {
 	return &MultiRunner{cfg: cfg}
 }
 
-// ParseResults parses test output into structured results.
+// TestCommand returns the command configured for a language in TestCommands, or
+// nil when the sandbox runs the language's built-in command.
+func (r *MultiRunner) TestCommand(language string) []string {
+	command := r.cfg.TestCommand(language)
+	if command == "" {
+		return nil
+	}
+	return []string{"sh", "-c", command}
+}
+
+// ParseResults parses test output into structured results. The parser is chosen
+// by language, whatever command produced the output.
 func (r *MultiRunner) ParseResults(output string, exitCode int, language string) (*events.TestResult, error) {
 	// Use the comprehensive result parser
 	parser := NewTestResultParser(r.cfg.CoverageThreshold)
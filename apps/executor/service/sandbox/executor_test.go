@@ -0,0 +1,109 @@
+//nolint:testpackage // white-box testing requires internal package access
+package sandbox
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	appconfig "github.com/antinvestor/builder/apps/executor/config"
+	"github.com/antinvestor/builder/internal/events"
+)
+
+// oomSandbox reports a run the kernel killed for exceeding its memory limit.
+type oomSandbox struct{}
+
+func (oomSandbox) Execute(context.Context, *SandboxExecutionRequest) (*SandboxExecutionResult, error) {
+	return &SandboxExecutionResult{
+		Output:    "=== RUN   TestLoad\n",
+		ExitCode:  137,
+		Duration:  10,
+		OOMKilled: true,
+	}, nil
+}
+
+func (oomSandbox) Ready(context.Context) error { return nil }
+
+func (oomSandbox) Close() error { return nil }
+
+func TestExecutionRequestHandler_OOMKilledReportsResourceLimit(t *testing.T) {
+	cfg := &appconfig.ExecutorConfig{SandboxEnabled: true, MaxConcurrentExecutions: 1, SandboxMemoryLimitMB: 256}
+	executor := &SandboxExecutor{cfg: cfg, backend: oomSandbox{}, mode: SandboxModeDocker}
+	emitter := &recordingEmitter{}
+	handler := NewExecutionRequestHandler(cfg, executor, NewMultiRunner(cfg), emitter)
+
+	payload, err := json.Marshal(&events.TestExecutionRequestedPayload{ExecutionID: events.NewExecutionID()})
+	require.NoError(t, err)
+	require.NoError(t, handler.Handle(context.Background(), nil, payload))
+
+	completed := emitter.completed()
+	require.Len(t, completed, 1)
+	assert.False(t, completed[0].Success)
+	assert.Nil(t, completed[0].Result)
+	require.NotNil(t, completed[0].Error)
+	assert.Equal(t, ErrorCodeOOMKilled, completed[0].Error.Code)
+	assert.Contains(t, completed[0].Error.Message, "memory limit of 256 MB")
+}
+
+// pytestSandbox records the command it was asked to run and prints pytest output.
+type pytestSandbox struct {
+	commands [][]string
+}
+
+func (s *pytestSandbox) Execute(_ context.Context, req *SandboxExecutionRequest) (*SandboxExecutionResult, error) {
+	s.commands = append(s.commands, req.TestCommand)
+	return &SandboxExecutionResult{
+		Output: "PASSED tests/test_orders.py::test_export\nFAILED tests/test_orders.py::test_refund\n" +
+			"1 passed, 1 failed in 0.42s\n",
+		ExitCode: 1,
+		Duration: 420,
+	}, nil
+}
+
+func (*pytestSandbox) Ready(context.Context) error { return nil }
+
+func (*pytestSandbox) Close() error { return nil }
+
+func TestExecutionRequestHandler_ConfiguredTestCommand(t *testing.T) {
+	cfg := &appconfig.ExecutorConfig{
+		SandboxEnabled:          true,
+		MaxConcurrentExecutions: 1,
+		TestCommands:            map[string]string{"python": "pytest -q"},
+	}
+	backend := &pytestSandbox{}
+	executor := &SandboxExecutor{cfg: cfg, backend: backend, mode: SandboxModeDocker}
+	emitter := &recordingEmitter{}
+	handler := NewExecutionRequestHandler(cfg, executor, NewMultiRunner(cfg), emitter)
+
+	for _, language := range []string{"python", "go"} {
+		payload, err := json.Marshal(&events.TestExecutionRequestedPayload{
+			ExecutionID: events.NewExecutionID(),
+			Language:    language,
+		})
+		require.NoError(t, err)
+		require.NoError(t, handler.Handle(context.Background(), nil, payload))
+	}
+
+	require.Len(t, backend.commands, 2)
+	assert.Equal(t, []string{"sh", "-c", "pytest -q"}, backend.commands[0])
+	assert.Nil(t, backend.commands[1], "languages without a configured command run their built-in one")
+
+	// The custom command's output is still parsed as pytest output
+	completed := emitter.completed()
+	require.Len(t, completed, 2)
+	require.NotNil(t, completed[0].Result)
+	assert.Equal(t, 2, completed[0].Result.TotalTests)
+	assert.Equal(t, 1, completed[0].Result.PassedTests)
+	assert.Equal(t, 1, completed[0].Result.FailedTests)
+}
+
+func TestExecutorConfig_ValidateTestCommands(t *testing.T) {
+	cfg := &appconfig.ExecutorConfig{TestCommands: map[string]string{"go": "gotestsum", "python": "  "}}
+	require.ErrorContains(t, cfg.ValidateTestCommands(), `"python"`)
+
+	cfg.TestCommands["python"] = "pytest -q"
+	require.NoError(t, cfg.ValidateTestCommands())
+}
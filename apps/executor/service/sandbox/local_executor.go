@@ -0,0 +1,220 @@
+package sandbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pitabwire/util"
+
+	appconfig "github.com/antinvestor/builder/apps/executor/config"
+	"github.com/antinvestor/builder/internal/events"
+)
+
+// localWaitDelay bounds how long output pipes are drained after a command is killed.
+const localWaitDelay = 5 * time.Second
+
+// rlimitScript applies the resource limits passed as $1 (CPU seconds), $2
+// (virtual memory KB, 0 = unlimited) and $3 (processes, 0 = unlimited) before
+// replacing the shell with the command. The process limit is -u in bash and -p in dash.
+const rlimitScript = `cpu="$1"; mem="$2"; pids="$3"; shift 3
+ulimit -t "$cpu" || exit 126
+if [ "$mem" -gt 0 ]; then ulimit -v "$mem" || exit 126; fi
+if [ "$pids" -gt 0 ]; then ulimit -u "$pids" 2>/dev/null || ulimit -p "$pids" || exit 126; fi
+exec "$@"`
+
+// LocalExecutor runs test commands as local subprocesses in a temporary copy of
+// the workspace, bounded by rlimits and the sandbox timeout.
+//
+// UNSAFE for untrusted code: there is no filesystem, network or process isolation.
+// It exists for development and CI environments without Docker.
+type LocalExecutor struct {
+	cfg *appconfig.ExecutorConfig
+}
+
+// NewLocalExecutor creates a subprocess-based executor.
+func NewLocalExecutor(cfg *appconfig.ExecutorConfig) *LocalExecutor {
+	return &LocalExecutor{cfg: cfg}
+}
+
+// Close implements Sandbox.
+func (e *LocalExecutor) Close() error {
+	return nil
+}
+
+// Ready implements Sandbox; the local backend only needs a shell.
+func (e *LocalExecutor) Ready(_ context.Context) error {
+	if _, err := exec.LookPath("sh"); err != nil {
+		return fmt.Errorf("local sandbox unavailable: %w", err)
+	}
+	return nil
+}
+
+// Execute implements Sandbox by running the request's or the language's test command locally.
+func (e *LocalExecutor) Execute(ctx context.Context, req *SandboxExecutionRequest) (*SandboxExecutionResult, error) {
+	workspacePath := filepath.Join(e.cfg.WorkspaceBasePath, req.ExecutionID.String())
+	return e.run(ctx, req.ExecutionID, req.Language, workspacePath, req.TestCommand, req.OutputWriter)
+}
+
+// ExecuteWithWorkspace runs testCommand, or the language's default test command,
+// against a temporary copy of workspacePath.
+func (e *LocalExecutor) ExecuteWithWorkspace(
+	ctx context.Context,
+	executionID events.ExecutionID,
+	language string,
+	workspacePath string,
+	testCommand []string,
+) (*SandboxExecutionResult, error) {
+	return e.run(ctx, executionID, language, workspacePath, testCommand, nil)
+}
+
+// run executes the test command, copying its output to stream as it is produced
+// when stream is set.
+func (e *LocalExecutor) run(
+	ctx context.Context,
+	executionID events.ExecutionID,
+	language string,
+	workspacePath string,
+	testCommand []string,
+	stream io.Writer,
+) (*SandboxExecutionResult, error) {
+	log := util.Log(ctx)
+	startTime := time.Now()
+
+	langConfig := e.getLanguageConfig(language)
+	if len(testCommand) > 0 {
+		langConfig.TestCommand = testCommand
+	}
+
+	workDir, err := os.MkdirTemp("", "sandbox-"+executionID.String()+"-")
+	if err != nil {
+		return nil, fmt.Errorf("create sandbox workdir: %w", err)
+	}
+	defer func() {
+		if removeErr := os.RemoveAll(workDir); removeErr != nil {
+			log.WithError(removeErr).Warn("failed to remove sandbox workdir", "workdir", workDir)
+		}
+	}()
+
+	if copyErr := copyWorkspace(workspacePath, workDir); copyErr != nil {
+		return nil, fmt.Errorf("copy workspace: %w", copyErr)
+	}
+
+	log.Warn("starting local sandbox execution without isolation",
+		"execution_id", executionID,
+		"language", language,
+		"workdir", workDir,
+	)
+
+	timeoutCtx, cancel := context.WithTimeout(ctx, time.Duration(e.cfg.SandboxTimeoutSeconds)*time.Second)
+	defer cancel()
+
+	cmd := exec.CommandContext(timeoutCtx, "sh", e.commandArgs(langConfig.TestCommand)...)
+	cmd.Dir = workDir
+	cmd.Env = localEnv(workDir, langConfig.Env)
+	cmd.WaitDelay = localWaitDelay
+
+	output := newOutputCapture(e.cfg.MaxTestOutputBytes)
+	var sink io.Writer = output
+	if stream != nil {
+		sink = io.MultiWriter(output, stream)
+	}
+	cmd.Stdout = sink
+	cmd.Stderr = sink
+
+	runErr := cmd.Run()
+	duration := time.Since(startTime).Milliseconds()
+
+	if timeoutCtx.Err() != nil && ctx.Err() == nil {
+		log.Warn("local sandbox execution timeout", "execution_id", executionID)
+		return &SandboxExecutionResult{
+			Output:   "Execution timed out",
+			ExitCode: -1,
+			Duration: duration,
+		}, nil
+	}
+
+	exitCode := 0
+	if runErr != nil {
+		var exitErr *exec.ExitError
+		if !errors.As(runErr, &exitErr) {
+			return nil, fmt.Errorf("run test command: %w", runErr)
+		}
+		exitCode = exitErr.ExitCode()
+	}
+
+	log.Info("local sandbox execution completed",
+		"execution_id", executionID,
+		"exit_code", exitCode,
+		"duration_ms", duration,
+		"output_bytes", output.Total(),
+	)
+
+	return &SandboxExecutionResult{
+		Output:               output.String(),
+		ExitCode:             exitCode,
+		Duration:             duration,
+		OutputTruncatedBytes: output.Dropped(),
+	}, nil
+}
+
+// getLanguageConfig returns the configuration for a language, defaulting to Go.
+func (e *LocalExecutor) getLanguageConfig(language string) *languageConfig {
+	config, ok := defaultLanguageConfigs[strings.ToLower(language)]
+	if !ok {
+		config = defaultLanguageConfigs["go"]
+	}
+	return &config
+}
+
+// commandArgs returns the sh arguments that run testCommand under the rlimits.
+func (e *LocalExecutor) commandArgs(testCommand []string) []string {
+	return append([]string{"-c", rlimitScript, "sh", e.cpuLimitSeconds(), e.memoryLimitKB(), e.processLimit()},
+		testCommand...)
+}
+
+// cpuLimitSeconds is the CPU time rlimit: the timeout at the configured CPU limit.
+func (e *LocalExecutor) cpuLimitSeconds() string {
+	cpuLimit := math.Max(e.cfg.SandboxCPULimit, 1)
+	seconds := math.Ceil(float64(e.cfg.SandboxTimeoutSeconds) * cpuLimit)
+	return strconv.Itoa(max(int(seconds), 1))
+}
+
+// memoryLimitKB is the virtual memory rlimit in KB (0 = unlimited).
+func (e *LocalExecutor) memoryLimitKB() string {
+	return strconv.Itoa(max(e.cfg.SandboxMemoryLimitMB, 0) * bytesPerKB)
+}
+
+// processLimit is the process rlimit (0 = unlimited). It counts every process of
+// the executor's user, not only the sandbox's.
+func (e *LocalExecutor) processLimit() string {
+	return strconv.Itoa(max(e.cfg.SandboxPIDsLimit, 0))
+}
+
+// localEnv builds a minimal environment confined to the workdir.
+func localEnv(workDir string, langEnv []string) []string {
+	env := []string{
+		"PATH=" + os.Getenv("PATH"),
+		"HOME=" + workDir,
+		"TMPDIR=" + workDir,
+		"CI=true",
+	}
+	return append(env, langEnv...)
+}
+
+// copyWorkspace copies the workspace into the workdir. A missing workspace
+// leaves the workdir empty.
+func copyWorkspace(workspacePath, workDir string) error {
+	if _, err := os.Stat(workspacePath); errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return os.CopyFS(workDir, os.DirFS(workspacePath))
+}
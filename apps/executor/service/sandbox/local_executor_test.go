@@ -0,0 +1,248 @@
+//nolint:testpackage // white-box testing requires internal package access
+package sandbox
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	appconfig "github.com/antinvestor/builder/apps/executor/config"
+	"github.com/antinvestor/builder/internal/events"
+)
+
+func newLocalConfig(t *testing.T) *appconfig.ExecutorConfig {
+	t.Helper()
+	return &appconfig.ExecutorConfig{
+		SandboxEnabled:          true,
+		SandboxMode:             SandboxModeLocal,
+		SandboxTimeoutSeconds:   10,
+		SandboxCPULimit:         1,
+		SandboxMemoryLimitMB:    512,
+		MaxConcurrentExecutions: 2,
+		WorkspaceBasePath:       t.TempDir(),
+	}
+}
+
+func TestLocalExecutor_RunsCommandAndResultsParse(t *testing.T) {
+	cfg := newLocalConfig(t)
+	executor := NewLocalExecutor(cfg)
+	require.NoError(t, executor.Ready(context.Background()))
+
+	output := "=== RUN   TestAdd\n--- PASS: TestAdd (0.00s)\n" +
+		"=== RUN   TestSub\n--- PASS: TestSub (0.00s)\n" +
+		"PASS\ncoverage: 85.0% of statements\nok  \texample.com/calc\t0.002s\n"
+	result, err := executor.ExecuteWithWorkspace(context.Background(), events.NewExecutionID(), "go",
+		filepath.Join(cfg.WorkspaceBasePath, "missing"), []string{"printf", "%s", output})
+	require.NoError(t, err)
+	assert.Equal(t, 0, result.ExitCode)
+	assert.Equal(t, output, result.Output)
+
+	testResult, err := NewMultiRunner(cfg).ParseResults(result.Output, result.ExitCode, "go")
+	require.NoError(t, err)
+	assert.True(t, testResult.Success)
+	assert.Equal(t, 2, testResult.TotalTests)
+	assert.Equal(t, 2, testResult.PassedTests)
+	assert.InDelta(t, 85.0, testResult.Coverage, 0.01)
+}
+
+func TestLocalExecutor_NonZeroExitCode(t *testing.T) {
+	cfg := newLocalConfig(t)
+
+	result, err := NewLocalExecutor(cfg).ExecuteWithWorkspace(context.Background(), events.NewExecutionID(),
+		"go", "", []string{"sh", "-c", "echo failing; exit 3"})
+	require.NoError(t, err)
+	assert.Equal(t, 3, result.ExitCode)
+	assert.Equal(t, "failing\n", result.Output)
+}
+
+func TestLocalExecutor_RunsInCopyOfWorkspace(t *testing.T) {
+	cfg := newLocalConfig(t)
+	execID := events.NewExecutionID()
+	workspace := filepath.Join(cfg.WorkspaceBasePath, execID.String())
+	require.NoError(t, os.MkdirAll(workspace, 0o750))
+	require.NoError(t, os.WriteFile(filepath.Join(workspace, "input.txt"), []byte("from workspace"), 0o600))
+
+	result, err := NewLocalExecutor(cfg).ExecuteWithWorkspace(context.Background(), execID, "go", workspace,
+		[]string{"sh", "-c", "cat input.txt && touch created.txt"})
+	require.NoError(t, err)
+	assert.Equal(t, 0, result.ExitCode)
+	assert.Equal(t, "from workspace", result.Output)
+
+	// The workspace itself is left untouched
+	assert.NoFileExists(t, filepath.Join(workspace, "created.txt"))
+}
+
+func TestLocalExecutor_Timeout(t *testing.T) {
+	cfg := newLocalConfig(t)
+	cfg.SandboxTimeoutSeconds = 1
+
+	result, err := NewLocalExecutor(cfg).ExecuteWithWorkspace(context.Background(), events.NewExecutionID(),
+		"go", "", []string{"sleep", "5"})
+	require.NoError(t, err)
+	assert.Equal(t, -1, result.ExitCode)
+	assert.Equal(t, "Execution timed out", result.Output)
+}
+
+func TestSandboxExecutor_SelectsBackend(t *testing.T) {
+	cfg := newLocalConfig(t)
+
+	executor, err := NewSandboxExecutor(cfg)
+	require.NoError(t, err)
+	assert.Equal(t, SandboxModeLocal, executor.Mode())
+	require.NoError(t, executor.Ready(context.Background()))
+
+	disabled, err := NewSandboxExecutor(&appconfig.ExecutorConfig{SandboxMode: SandboxModeLocal})
+	require.NoError(t, err)
+	assert.Equal(t, SandboxModeDisabled, disabled.Mode())
+
+	cfg.SandboxMode = "vm"
+	_, err = NewSandboxExecutor(cfg)
+	require.Error(t, err)
+}
+
+func TestLocalExecutor_StreamsOutput(t *testing.T) {
+	cfg := newLocalConfig(t)
+
+	var streamed bytes.Buffer
+	result, err := NewLocalExecutor(cfg).run(context.Background(), events.NewExecutionID(), "go", "",
+		[]string{"sh", "-c", "echo one; echo two >&2"}, &streamed)
+	require.NoError(t, err)
+	assert.Equal(t, "one\ntwo\n", result.Output)
+	assert.Equal(t, result.Output, streamed.String())
+}
+
+func TestLocalExecutor_CapsStoredOutput(t *testing.T) {
+	cfg := newLocalConfig(t)
+	cfg.MaxTestOutputBytes = 1024
+
+	// 200 lines of 26 bytes, written one at a time
+	script := `for i in $(seq 100 299); do echo "--- PASS: Test$i (0.00s)"; done`
+	var streamed bytes.Buffer
+	result, err := NewLocalExecutor(cfg).run(context.Background(), events.NewExecutionID(), "go", "",
+		[]string{"sh", "-c", script}, &streamed)
+	require.NoError(t, err)
+
+	assert.Equal(t, 200*26, streamed.Len(), "the stream receives every line")
+	assert.Equal(t, int64(200*26-1024), result.OutputTruncatedBytes)
+	assert.True(t, strings.HasPrefix(result.Output, "--- PASS: Test100 (0.00s)\n"))
+	assert.True(t, strings.HasSuffix(result.Output, "--- PASS: Test299 (0.00s)\n"))
+	assert.Contains(t, result.Output, "bytes of output truncated")
+}
+
+func TestExecutionRequestHandler_CountsTestsInTruncatedOutput(t *testing.T) {
+	cfg := newLocalConfig(t)
+	cfg.MaxTestOutputBytes = 512
+	cfg.TestProgressEnabled = true
+	cfg.TestProgressIntervalSeconds = 60
+	cfg.TestCommands = map[string]string{
+		"go": `for i in $(seq 100 199); do echo "--- PASS: Test$i (0.00s)"; done; ` +
+			`echo "--- FAIL: TestLast (0.00s)"; exit 1`,
+	}
+	executor := &SandboxExecutor{cfg: cfg, backend: NewLocalExecutor(cfg), mode: SandboxModeLocal}
+	emitter := &eventLog{}
+	handler := NewExecutionRequestHandler(cfg, executor, NewMultiRunner(cfg), emitter)
+
+	payload, err := json.Marshal(&events.TestExecutionRequestedPayload{
+		ExecutionID: events.NewExecutionID(),
+		Language:    "go",
+	})
+	require.NoError(t, err)
+	require.NoError(t, handler.Handle(context.Background(), nil, payload))
+
+	var completed *events.TestExecutionCompletedPayload
+	for _, p := range emitter.payloads {
+		if c, ok := p.(*events.TestExecutionCompletedPayload); ok {
+			completed = c
+		}
+	}
+	require.NotNil(t, completed)
+	assert.Positive(t, completed.OutputTruncatedBytes)
+	assert.LessOrEqual(t, len(completed.Output), 512+64, "stored output stays near the cap")
+	assert.Equal(t, 101, completed.Result.TotalTests)
+	assert.Equal(t, 100, completed.Result.PassedTests)
+	assert.Equal(t, 1, completed.Result.FailedTests)
+	assert.False(t, completed.Result.Success)
+}
+
+func TestLocalExecutor_AppliesResourceLimits(t *testing.T) {
+	if _, err := os.Stat("/proc/self/limits"); err != nil {
+		t.Skip("process limits are not readable on this platform")
+	}
+	cfg := newLocalConfig(t)
+	cfg.SandboxMemoryLimitMB = 256
+	cfg.SandboxPIDsLimit = 64
+	executor := NewLocalExecutor(cfg)
+
+	assert.Equal(t, []string{"-c", rlimitScript, "sh", "10", "262144", "64", "go", "test"},
+		executor.commandArgs([]string{"go", "test"}))
+
+	result, err := executor.ExecuteWithWorkspace(context.Background(), events.NewExecutionID(), "go", "",
+		[]string{"grep", "-E", "Max (cpu time|address space|processes)", "/proc/self/limits"})
+	require.NoError(t, err)
+	require.Equal(t, 0, result.ExitCode, result.Output)
+
+	limits := make(map[string]string)
+	for line := range strings.Lines(result.Output) {
+		fields := strings.Fields(line)
+		require.GreaterOrEqual(t, len(fields), 4, line)
+		limits[strings.Join(fields[:len(fields)-3], " ")] = fields[len(fields)-3]
+	}
+	assert.Equal(t, "10", limits["Max cpu time"])
+	assert.Equal(t, strconv.Itoa(256*bytesPerMB), limits["Max address space"])
+	assert.Equal(t, "64", limits["Max processes"])
+}
+
+func TestExecutionRequestHandler_RunsRequestedTestCommand(t *testing.T) {
+	cfg := newLocalConfig(t)
+	cfg.AllowedTestCommands = "make test, echo fallback ran && exit 0"
+	execID := events.NewExecutionID()
+	require.NoError(t, os.MkdirAll(filepath.Join(cfg.WorkspaceBasePath, execID.String()), 0o750))
+	executor := &SandboxExecutor{cfg: cfg, backend: NewLocalExecutor(cfg), mode: SandboxModeLocal}
+	emitter := &recordingEmitter{}
+	handler := NewExecutionRequestHandler(cfg, executor, NewMultiRunner(cfg), emitter)
+
+	payload, err := json.Marshal(&events.TestExecutionRequestedPayload{
+		ExecutionID: execID,
+		TestCommand: "echo fallback ran && exit 0",
+	})
+	require.NoError(t, err)
+	require.NoError(t, handler.Handle(context.Background(), nil, payload))
+
+	completed := emitter.completed()
+	require.Len(t, completed, 1)
+	assert.True(t, completed[0].Success)
+	assert.Equal(t, "fallback ran\n", completed[0].Output)
+}
+
+func TestExecutionRequestHandler_RefusesTestCommandNotAllowed(t *testing.T) {
+	cfg := newLocalConfig(t)
+	cfg.AllowedTestCommands = "make test"
+	execID := events.NewExecutionID()
+	workspace := filepath.Join(cfg.WorkspaceBasePath, execID.String())
+	require.NoError(t, os.MkdirAll(workspace, 0o750))
+	executor := &SandboxExecutor{cfg: cfg, backend: NewLocalExecutor(cfg), mode: SandboxModeLocal}
+	emitter := &recordingEmitter{}
+	handler := NewExecutionRequestHandler(cfg, executor, NewMultiRunner(cfg), emitter)
+
+	payload, err := json.Marshal(&events.TestExecutionRequestedPayload{
+		ExecutionID: execID,
+		TestCommand: "touch pwned",
+	})
+	require.NoError(t, err)
+	require.NoError(t, handler.Handle(context.Background(), nil, payload))
+
+	completed := emitter.completed()
+	require.Len(t, completed, 1)
+	assert.False(t, completed[0].Success)
+	require.NotNil(t, completed[0].Error)
+	assert.Equal(t, ErrorCodeTestCommandNotAllowed, completed[0].Error.Code)
+	assert.NoFileExists(t, filepath.Join(workspace, "pwned"))
+}
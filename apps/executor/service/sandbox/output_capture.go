@@ -0,0 +1,112 @@
+package sandbox
+
+import (
+	"fmt"
+	"sync"
+	"unicode/utf8"
+)
+
+// outputTruncatedMarker stands in for the middle of a truncated output.
+const outputTruncatedMarker = "\n... [%d bytes of output truncated] ...\n"
+
+// outputCapture collects command output up to a byte limit. Past the limit it
+// keeps the first and the last half of the limit: the head shows how the run
+// started and the tail holds the summary lines the parsers read. Dropped bytes
+// are still counted.
+type outputCapture struct {
+	limit int
+
+	mu    sync.Mutex
+	head  []byte
+	tail  []byte
+	total int64
+}
+
+// newOutputCapture creates a capture keeping at most limit bytes (0 = unlimited).
+func newOutputCapture(limit int) *outputCapture {
+	return &outputCapture{limit: max(limit, 0)}
+}
+
+// Write implements io.Writer. It never fails, so a long run is never aborted for
+// producing too much output.
+func (c *outputCapture) Write(p []byte) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	written := len(p)
+	c.total += int64(written)
+	if c.limit == 0 {
+		c.head = append(c.head, p...)
+		return written, nil
+	}
+
+	if room := c.headLimit() - len(c.head); room > 0 {
+		n := min(room, len(p))
+		c.head = append(c.head, p[:n]...)
+		p = p[n:]
+	}
+
+	tailLimit := c.limit / 2 //nolint:mnd // half of the limit
+	switch {
+	case len(p) == 0:
+	case len(p) >= tailLimit:
+		c.tail = append(c.tail[:0], p[len(p)-tailLimit:]...)
+	default:
+		c.tail = append(c.tail, p...)
+		// Compact only when the tail doubles, so appends stay amortized
+		if len(c.tail) > 2*tailLimit {
+			c.tail = append(c.tail[:0], c.tail[len(c.tail)-tailLimit:]...)
+		}
+	}
+	return written, nil
+}
+
+// headLimit is the share of the limit kept from the beginning of the output.
+func (c *outputCapture) headLimit() int {
+	return c.limit - c.limit/2
+}
+
+// String returns the kept output, marking where bytes were dropped.
+func (c *outputCapture) String() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	dropped := c.dropped()
+	if dropped == 0 {
+		return string(c.head) + string(c.tail)
+	}
+
+	head := c.head
+	// Do not end the head inside a character
+	for i := len(head) - 1; i >= 0 && i >= len(head)-utf8.UTFMax; i-- {
+		if utf8.RuneStart(head[i]) {
+			if !utf8.FullRune(head[i:]) {
+				head = head[:i]
+			}
+			break
+		}
+	}
+	tail := outputTail(string(c.tail), c.limit/2) //nolint:mnd // half of the limit
+	return string(head) + fmt.Sprintf(outputTruncatedMarker, dropped) + tail
+}
+
+// Total returns the number of bytes written, including dropped ones.
+func (c *outputCapture) Total() int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.total
+}
+
+// Dropped returns the number of bytes written but not kept.
+func (c *outputCapture) Dropped() int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.dropped()
+}
+
+func (c *outputCapture) dropped() int64 {
+	if c.limit == 0 {
+		return 0
+	}
+	return max(c.total-int64(c.limit), 0)
+}
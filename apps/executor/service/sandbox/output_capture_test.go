@@ -0,0 +1,46 @@
+//nolint:testpackage // white-box testing requires internal package access
+package sandbox
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOutputCapture_KeepsHeadAndTail(t *testing.T) {
+	capture := newOutputCapture(10)
+	for _, chunk := range []string{"abc", "defgh", "ijklmnop", "qrstu", "vwxyz"} {
+		n, err := capture.Write([]byte(chunk))
+		assert.NoError(t, err)
+		assert.Equal(t, len(chunk), n)
+	}
+
+	assert.Equal(t, int64(26), capture.Total())
+	assert.Equal(t, int64(16), capture.Dropped())
+	assert.Equal(t, "abcde\n... [16 bytes of output truncated] ...\nvwxyz", capture.String())
+}
+
+func TestOutputCapture_WithinLimitOrUnlimited(t *testing.T) {
+	within := newOutputCapture(10)
+	_, _ = within.Write([]byte("abcdefghij"))
+	assert.Equal(t, "abcdefghij", within.String())
+	assert.Zero(t, within.Dropped())
+
+	unlimited := newOutputCapture(0)
+	output := strings.Repeat("line\n", 1000)
+	_, _ = unlimited.Write([]byte(output))
+	assert.Equal(t, output, unlimited.String())
+	assert.Zero(t, unlimited.Dropped())
+}
+
+func TestOutputCapture_DoesNotSplitCharacters(t *testing.T) {
+	capture := newOutputCapture(8)
+	_, _ = capture.Write([]byte(strings.Repeat("é", 20)))
+
+	kept := capture.String()
+	assert.True(t, utf8.ValidString(kept))
+	assert.True(t, strings.HasPrefix(kept, "éé\n"))
+	assert.True(t, strings.HasSuffix(kept, "\néé"))
+}
@@ -4,6 +4,8 @@ import (
 	"bufio"
 	"encoding/json"
 	"encoding/xml"
+	"errors"
+	"io"
 	"regexp"
 	"strconv"
 	"strings"
@@ -18,6 +20,7 @@ const (
 	LanguagePython = "python"
 	LanguageNode   = "node"
 	LanguageJava   = "java"
+	LanguageRust   = "rust"
 )
 
 // Test status constants.
@@ -57,6 +60,18 @@ var (
 	)
 	jestTimeRe     = regexp.MustCompile(`Time:\s*([0-9.]+)s`)
 	jestCoverageRe = regexp.MustCompile(`All files\s*\|\s*([0-9.]+)`)
+
+	// cargo test output patterns.
+	cargoTestLineRe = regexp.MustCompile(`^test (\S+(?: - .+?)?) \.\.\. (ok|FAILED|ignored)\b`)
+	cargoSummaryRe  = regexp.MustCompile(
+		`test result: \w+\. (\d+) passed; (\d+) failed; (\d+) ignored;.*finished in ([0-9.]+)s`,
+	)
+	cargoFailureHeaderRe = regexp.MustCompile(`^---- (\S+(?: - .+?)?) std(?:out|err) ----$`)
+
+	// cargo nextest output patterns.
+	nextestLineRe    = regexp.MustCompile(`^\s*(PASS|FAIL|SKIP|TIMEOUT)\s+\[\s*([0-9.]*)s?\s*\]\s+(\S+)\s+(\S+)`)
+	nextestSummaryRe = regexp.MustCompile(`^\s*Summary \[\s*([0-9.]+)s\]\s+(\d+) tests? run: (.*)$`)
+	nextestCountRe   = regexp.MustCompile(`(\d+) (passed|failed|skipped|timed out)`)
 )
 
 // TestResultParser parses test output from various frameworks.
@@ -88,6 +103,8 @@ func (p *TestResultParser) ParseTestOutput(
 		result = p.parseJestOutput(output)
 	case LanguageJava:
 		result = p.parseJUnitXML(output)
+	case LanguageRust:
+		result = p.parseCargoTestOutput(output)
 	default:
 		// Fallback to generic parsing
 		result = p.parseGenericOutput(output, exitCode)
@@ -98,6 +115,8 @@ func (p *TestResultParser) ParseTestOutput(
 		result.Success = false
 	}
 
+	result.BuildWarnings = ParseBuildWarnings(output)
+
 	return result, nil
 }
 
@@ -467,22 +486,18 @@ func (p *TestResultParser) calculateJestCoverage(jestRes *jestResult) float64 {
 // Java JUnit XML Parser
 // =============================================================================
 
-// junitTestSuites represents the root element of JUnit XML.
-type junitTestSuites struct {
-	XMLName    xml.Name         `xml:"testsuites"`
-	TestSuites []junitTestSuite `xml:"testsuite"`
-}
-
-// junitTestSuite represents a single test suite.
+// junitTestSuite represents a single test suite. Gradle and some Surefire
+// reporters nest suites, so a suite may hold further suites.
 type junitTestSuite struct {
-	XMLName   xml.Name        `xml:"testsuite"`
-	Name      string          `xml:"name,attr"`
-	Tests     int             `xml:"tests,attr"`
-	Errors    int             `xml:"errors,attr"`
-	Failures  int             `xml:"failures,attr"`
-	Skipped   int             `xml:"skipped,attr"`
-	Time      float64         `xml:"time,attr"`
-	TestCases []junitTestCase `xml:"testcase"`
+	XMLName   xml.Name         `xml:"testsuite"`
+	Name      string           `xml:"name,attr"`
+	Tests     int              `xml:"tests,attr"`
+	Errors    int              `xml:"errors,attr"`
+	Failures  int              `xml:"failures,attr"`
+	Skipped   int              `xml:"skipped,attr"`
+	Time      float64          `xml:"time,attr"`
+	Suites    []junitTestSuite `xml:"testsuite"`
+	TestCases []junitTestCase  `xml:"testcase"`
 }
 
 // junitTestCase represents a single test case.
@@ -492,55 +507,109 @@ type junitTestCase struct {
 	ClassName string        `xml:"classname,attr"`
 	Time      float64       `xml:"time,attr"`
 	Failure   *junitFailure `xml:"failure"`
-	Error     *junitError   `xml:"error"`
+	Error     *junitFailure `xml:"error"`
 	Skipped   *junitSkipped `xml:"skipped"`
 }
 
+// junitFailure is the body of a failure or error element. A failure is a failed
+// assertion and an error an unexpected exception; both fail the test case.
 type junitFailure struct {
 	Message string `xml:"message,attr"`
 	Type    string `xml:"type,attr"`
 	Content string `xml:",chardata"`
 }
 
-type junitError struct {
-	Message string `xml:"message,attr"`
-	Type    string `xml:"type,attr"`
-	Content string `xml:",chardata"`
-}
-
 type junitSkipped struct {
 	Message string `xml:"message,attr"`
 }
 
+// parseJUnitXML parses JUnit XML reports. Every top-level testsuite element is
+// read, whether it is a document root, wrapped in a testsuites root, or one of
+// several reports printed one after another, and any build output around the
+// reports is ignored. JUnit XML has no coverage, so coverage is left zero.
 func (p *TestResultParser) parseJUnitXML(output string) *events.TestResult {
 	result := &events.TestResult{
 		TestCases: []events.TestCaseResult{},
 	}
 
-	// Try parsing as testsuites (multiple suites)
-	var suites junitTestSuites
-	if err := xml.Unmarshal([]byte(output), &suites); err == nil && len(suites.TestSuites) > 0 {
-		for _, suite := range suites.TestSuites {
+	start := strings.Index(output, "<")
+	if start < 0 {
+		return p.parseGenericOutput(output, 1) // Assume failure if can't parse
+	}
+
+	var found bool
+	decoder := xml.NewDecoder(strings.NewReader(output[start:]))
+	for {
+		token, err := decoder.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			// Fallback to generic parsing
+			return p.parseGenericOutput(output, 1) // Assume failure if can't parse
+		}
+
+		element, ok := token.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		switch element.Name.Local {
+		case "testsuites":
+			// Descend into the root; its suites are decoded as they are reached
+			found = true
+		case "testsuite":
+			var suite junitTestSuite
+			if err = decoder.DecodeElement(&suite, &element); err != nil {
+				return p.parseGenericOutput(output, 1) // Assume failure if can't parse
+			}
+			result.DurationMs += junitSuiteDurationMs(&suite)
 			p.processJUnitSuite(&suite, result)
+			found = true
+		default:
+			if err = decoder.Skip(); err != nil {
+				return p.parseGenericOutput(output, 1) // Assume failure if can't parse
+			}
 		}
-		result.Success = result.FailedTests == 0
-		return result
 	}
 
-	// Try parsing as single testsuite
-	var suite junitTestSuite
-	if err := xml.Unmarshal([]byte(output), &suite); err == nil {
-		p.processJUnitSuite(&suite, result)
-		result.Success = result.FailedTests == 0
-		return result
+	if !found {
+		return p.parseGenericOutput(output, 1) // Assume failure if can't parse
 	}
+	result.Success = result.FailedTests == 0
+	return result
+}
 
-	// Fallback to generic parsing
-	return p.parseGenericOutput(output, 1) // Assume failure if can't parse
+// junitSuiteDurationMs returns a suite's duration. A nested suite's time is part
+// of its parent's, so children are only summed when the parent has no time.
+func junitSuiteDurationMs(suite *junitTestSuite) int64 {
+	if suite.Time > 0 {
+		return int64(suite.Time * msPerSecond)
+	}
+	var duration int64
+	for i := range suite.Suites {
+		duration += junitSuiteDurationMs(&suite.Suites[i])
+	}
+	for _, tc := range suite.TestCases {
+		duration += int64(tc.Time * msPerSecond)
+	}
+	return duration
 }
 
+// processJUnitSuite adds a suite's test cases, and those of its nested suites, to
+// the result. A suite that lists no test cases contributes its declared totals.
 func (p *TestResultParser) processJUnitSuite(suite *junitTestSuite, result *events.TestResult) {
-	result.DurationMs += int64(suite.Time * msPerSecond)
+	for i := range suite.Suites {
+		p.processJUnitSuite(&suite.Suites[i], result)
+	}
+
+	if len(suite.TestCases) == 0 && len(suite.Suites) == 0 {
+		failed := suite.Failures + suite.Errors
+		result.TotalTests += suite.Tests
+		result.FailedTests += failed
+		result.SkippedTests += suite.Skipped
+		result.PassedTests += max(suite.Tests-failed-suite.Skipped, 0)
+		return
+	}
 
 	for _, tc := range suite.TestCases {
 		testCase := events.TestCaseResult{
@@ -548,6 +617,9 @@ func (p *TestResultParser) processJUnitSuite(suite *junitTestSuite, result *even
 			Suite:      tc.ClassName,
 			DurationMs: int64(tc.Time * msPerSecond),
 		}
+		if testCase.Suite == "" {
+			testCase.Suite = suite.Name
+		}
 
 		switch {
 		case tc.Skipped != nil:
@@ -555,17 +627,11 @@ func (p *TestResultParser) processJUnitSuite(suite *junitTestSuite, result *even
 			result.SkippedTests++
 		case tc.Failure != nil:
 			testCase.Status = statusFailed
-			testCase.Error = tc.Failure.Message
-			if tc.Failure.Content != "" {
-				testCase.Output = tc.Failure.Content
-			}
+			testCase.Error, testCase.Output = junitFailureDetail(tc.Failure)
 			result.FailedTests++
 		case tc.Error != nil:
 			testCase.Status = statusFailed
-			testCase.Error = tc.Error.Message
-			if tc.Error.Content != "" {
-				testCase.Output = tc.Error.Content
-			}
+			testCase.Error, testCase.Output = junitFailureDetail(tc.Error)
 			result.FailedTests++
 		default:
 			testCase.Status = statusPassed
@@ -577,6 +643,174 @@ func (p *TestResultParser) processJUnitSuite(suite *junitTestSuite, result *even
 	}
 }
 
+// junitFailureDetail returns the error summary and output of a failure or error.
+// The summary is the message, or the exception type when there is none; the
+// output is the stack trace, or the message when the element has no body.
+func junitFailureDetail(failure *junitFailure) (string, string) {
+	summary := failure.Message
+	if summary == "" {
+		summary = failure.Type
+	}
+	output := strings.TrimSpace(failure.Content)
+	if output == "" {
+		output = failure.Message
+	}
+	return summary, output
+}
+
+// =============================================================================
+// Rust cargo test Parser
+// =============================================================================
+
+// parseCargoTestOutput parses cargo test and cargo nextest output. Test cases are
+// named by their module path, as in utils::tests::adds, and ignored tests are
+// skipped. The totals come from the summary lines, summed over every test binary,
+// and fall back to counting the test cases when no summary was printed.
+func (p *TestResultParser) parseCargoTestOutput(output string) *events.TestResult {
+	result := &events.TestResult{
+		TestCases: []events.TestCaseResult{},
+	}
+
+	var summarized bool
+	failureOutput := make(map[string]*strings.Builder)
+	var capturing *strings.Builder
+
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		// Failure output: everything between "---- name stdout ----" and the next
+		// header or the "failures:" list
+		if match := cargoFailureHeaderRe.FindStringSubmatch(line); match != nil {
+			capturing = &strings.Builder{}
+			failureOutput[match[1]] = capturing
+			continue
+		}
+		if strings.TrimSpace(line) == "failures:" {
+			capturing = nil
+			continue
+		}
+		if capturing != nil {
+			capturing.WriteString(line)
+			capturing.WriteString("\n")
+			continue
+		}
+
+		// test utils::tests::adds ... ok
+		if match := cargoTestLineRe.FindStringSubmatch(line); match != nil {
+			result.TestCases = append(result.TestCases, cargoTestCase(match[1], cargoTestStatus(match[2]), 0))
+			continue
+		}
+
+		// test result: ok. 3 passed; 0 failed; 1 ignored; 0 measured; 0 filtered out; finished in 0.01s
+		if match := cargoSummaryRe.FindStringSubmatch(line); match != nil {
+			passed, _ := strconv.Atoi(match[1])
+			failed, _ := strconv.Atoi(match[2])
+			ignored, _ := strconv.Atoi(match[3])
+			duration, _ := strconv.ParseFloat(match[4], 64)
+			result.PassedTests += passed
+			result.FailedTests += failed
+			result.SkippedTests += ignored
+			result.DurationMs += int64(duration * msPerSecond)
+			summarized = true
+			continue
+		}
+
+		// PASS [   0.004s] mycrate utils::tests::adds (the binary id precedes the test path)
+		if match := nextestLineRe.FindStringSubmatch(line); match != nil {
+			duration, _ := strconv.ParseFloat(match[2], 64)
+			result.TestCases = append(result.TestCases,
+				cargoTestCase(match[4], nextestStatus(match[1]), int64(duration*msPerSecond)))
+			continue
+		}
+
+		// Summary [   0.006s] 4 tests run: 3 passed, 1 failed, 1 skipped
+		if match := nextestSummaryRe.FindStringSubmatch(line); match != nil {
+			p.applyNextestSummary(match, result)
+			summarized = true
+		}
+	}
+
+	for i := range result.TestCases {
+		if failure, ok := failureOutput[result.TestCases[i].Name]; ok {
+			result.TestCases[i].Output = strings.TrimSpace(failure.String())
+		}
+	}
+
+	if !summarized {
+		for _, tc := range result.TestCases {
+			switch tc.Status {
+			case statusPassed:
+				result.PassedTests++
+			case statusFailed:
+				result.FailedTests++
+			case statusSkipped:
+				result.SkippedTests++
+			}
+		}
+	}
+
+	result.TotalTests = result.PassedTests + result.FailedTests + result.SkippedTests
+	result.Success = result.FailedTests == 0
+	return result
+}
+
+// applyNextestSummary sets the totals and duration from a nextest summary line.
+// Nextest counts skipped tests outside the tests run, and timed-out tests as failed.
+func (p *TestResultParser) applyNextestSummary(match []string, result *events.TestResult) {
+	duration, _ := strconv.ParseFloat(match[1], 64)
+	result.DurationMs = int64(duration * msPerSecond)
+	result.PassedTests, result.FailedTests, result.SkippedTests = 0, 0, 0
+
+	for _, count := range nextestCountRe.FindAllStringSubmatch(match[3], -1) {
+		n, _ := strconv.Atoi(count[1])
+		switch count[2] {
+		case statusPassed:
+			result.PassedTests += n
+		case statusFailed, "timed out":
+			result.FailedTests += n
+		case statusSkipped:
+			result.SkippedTests += n
+		}
+	}
+}
+
+// cargoTestCase builds a test case for a test path, with the module path up to the
+// final segment as its suite.
+func cargoTestCase(name, status string, durationMs int64) events.TestCaseResult {
+	tc := events.TestCaseResult{
+		Name:       name,
+		Status:     status,
+		DurationMs: durationMs,
+	}
+	if idx := strings.LastIndex(name, "::"); idx > 0 {
+		tc.Suite = name[:idx]
+	}
+	return tc
+}
+
+func cargoTestStatus(status string) string {
+	switch status {
+	case "ok":
+		return statusPassed
+	case "ignored":
+		return statusSkipped
+	default:
+		return statusFailed
+	}
+}
+
+func nextestStatus(status string) string {
+	switch status {
+	case "PASS":
+		return statusPassed
+	case "SKIP":
+		return statusSkipped
+	default:
+		return statusFailed
+	}
+}
+
 // =============================================================================
 // Generic Parser (Fallback)
 // =============================================================================
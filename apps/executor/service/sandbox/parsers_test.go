@@ -281,6 +281,208 @@ func TestParseJUnitXML(t *testing.T) {
 	}
 }
 
+func TestParseJUnitXML_MultipleSuites(t *testing.T) {
+	parser := sandbox.NewTestResultParser(70.0)
+
+	output := `[INFO] Running com.example.OrderTest
+<?xml version="1.0" encoding="UTF-8"?>
+<testsuites name="shop" tests="6" failures="1" errors="1" time="3.5">
+  <testsuite name="com.example.OrderTest" tests="3" failures="1" time="1.25">
+    <testcase name="createsOrder" classname="com.example.OrderTest" time="0.5"/>
+    <testcase name="rejectsEmptyCart" classname="com.example.OrderTest" time="0.25">
+      <failure message="expected 400 but was 200" type="org.opentest4j.AssertionFailedError"/>
+    </testcase>
+    <testcase name="refundsOrder" classname="com.example.OrderTest" time="0.5">
+      <skipped message="refunds disabled"/>
+    </testcase>
+  </testsuite>
+  <testsuite name="com.example.CartTest" tests="2" errors="1" time="2.25">
+    <testcase name="addsItem" classname="com.example.CartTest" time="0.75"/>
+    <testcase name="removesItem" classname="com.example.CartTest" time="1.5">
+      <error type="java.lang.NullPointerException">java.lang.NullPointerException
+	at com.example.Cart.remove(Cart.java:42)</error>
+    </testcase>
+  </testsuite>
+  <testsuite name="com.example.integration" time="0">
+    <testsuite name="com.example.integration.CheckoutIT" tests="1" time="4"/>
+  </testsuite>
+</testsuites>
+[INFO] BUILD FAILURE`
+
+	result, err := parser.ParseTestOutput(sandbox.LanguageJava, output, 1)
+	require.NoError(t, err)
+
+	assert.Equal(t, 6, result.TotalTests)
+	assert.Equal(t, 3, result.PassedTests)
+	assert.Equal(t, 2, result.FailedTests)
+	assert.Equal(t, 1, result.SkippedTests)
+	assert.False(t, result.Success)
+	assert.Equal(t, int64(7500), result.DurationMs)
+	assert.Zero(t, result.Coverage)
+
+	require.Len(t, result.TestCases, 5)
+	byName := make(map[string]int)
+	for i, tc := range result.TestCases {
+		byName[tc.Name] = i
+	}
+
+	failure := result.TestCases[byName["rejectsEmptyCart"]]
+	assert.Equal(t, "failed", failure.Status)
+	assert.Equal(t, int64(250), failure.DurationMs)
+	assert.Equal(t, "expected 400 but was 200", failure.Error)
+	assert.Equal(t, "expected 400 but was 200", failure.Output)
+
+	errored := result.TestCases[byName["removesItem"]]
+	assert.Equal(t, "failed", errored.Status)
+	assert.Equal(t, "com.example.CartTest", errored.Suite)
+	assert.Equal(t, "java.lang.NullPointerException", errored.Error)
+	assert.Contains(t, errored.Output, "Cart.java:42")
+
+	assert.Equal(t, "skipped", result.TestCases[byName["refundsOrder"]].Status)
+}
+
+func TestParseJUnitXML_MalformedFallsBack(t *testing.T) {
+	parser := sandbox.NewTestResultParser(70.0)
+
+	for name, output := range map[string]string{
+		"unclosed suite": `<testsuite name="Broken" tests="1"><testcase name="one">`,
+		"no xml":         "BUILD FAILED: compilation error",
+		"unrelated root": `<html><body>error</body></html>`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			result, err := parser.ParseTestOutput(sandbox.LanguageJava, output, 1)
+			require.NoError(t, err)
+
+			assert.Equal(t, 1, result.TotalTests)
+			assert.Equal(t, 1, result.FailedTests)
+			assert.False(t, result.Success)
+			require.Len(t, result.TestCases, 1)
+			assert.Equal(t, output, result.TestCases[0].Output)
+		})
+	}
+}
+
+const cargoFailingOutput = `   Compiling shop v0.1.0 (/workspace)
+    Finished test [unoptimized + debuginfo] target(s) in 1.20s
+     Running unittests src/lib.rs (target/debug/deps/shop-1a2b3c)
+
+running 3 tests
+test cart::tests::adds_item ... ok
+test cart::tests::removes_item ... FAILED
+test cart::tests::slow_checkout ... ignored, needs a database
+
+failures:
+
+---- cart::tests::removes_item stdout ----
+thread 'cart::tests::removes_item' panicked at src/cart.rs:42:9:
+assertion ` + "`left == right`" + ` failed
+  left: 1
+ right: 0
+
+failures:
+    cart::tests::removes_item
+
+test result: FAILED. 1 passed; 1 failed; 1 ignored; 0 measured; 0 filtered out; finished in 0.25s
+`
+
+func TestParseCargoTestOutput(t *testing.T) {
+	parser := sandbox.NewTestResultParser(70.0)
+
+	tests := []struct {
+		name         string
+		output       string
+		exitCode     int
+		wantTotal    int
+		wantPassed   int
+		wantFailed   int
+		wantSkipped  int
+		wantDuration int64
+		wantSuccess  bool
+	}{
+		{
+			name: "passing run across two test binaries",
+			output: `running 2 tests
+test cart::tests::adds_item ... ok
+test orders::tests::totals ... ok
+
+test result: ok. 2 passed; 0 failed; 0 ignored; 0 measured; 0 filtered out; finished in 0.50s
+
+   Doc-tests shop
+
+running 1 test
+test src/lib.rs - cart::Cart::add (line 12) ... ok
+
+test result: ok. 1 passed; 0 failed; 0 ignored; 0 measured; 0 filtered out; finished in 1.25s
+`,
+			exitCode:     0,
+			wantTotal:    3,
+			wantPassed:   3,
+			wantDuration: 1750,
+			wantSuccess:  true,
+		},
+		{
+			name:         "run with one failure",
+			output:       cargoFailingOutput,
+			exitCode:     101,
+			wantTotal:    3,
+			wantPassed:   1,
+			wantFailed:   1,
+			wantSkipped:  1,
+			wantDuration: 250,
+		},
+		{
+			name: "nextest summary",
+			output: `    Starting 4 tests across 2 binaries (1 test skipped)
+        PASS [   0.004s] shop cart::tests::adds_item
+        FAIL [   0.010s] shop cart::tests::removes_item
+        PASS [   0.120s] shop::integration checkout::places_order
+        PASS [   1.002s] shop::integration checkout::refunds_order
+------------
+     Summary [   1.150s] 4 tests run: 3 passed, 1 failed, 1 skipped
+`,
+			exitCode:     100,
+			wantTotal:    5,
+			wantPassed:   3,
+			wantFailed:   1,
+			wantSkipped:  1,
+			wantDuration: 1150,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := parser.ParseTestOutput(sandbox.LanguageRust, tt.output, tt.exitCode)
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.wantTotal, result.TotalTests, "TotalTests")
+			assert.Equal(t, tt.wantPassed, result.PassedTests, "PassedTests")
+			assert.Equal(t, tt.wantFailed, result.FailedTests, "FailedTests")
+			assert.Equal(t, tt.wantSkipped, result.SkippedTests, "SkippedTests")
+			assert.Equal(t, tt.wantDuration, result.DurationMs, "DurationMs")
+			assert.Equal(t, tt.wantSuccess, result.Success, "Success")
+		})
+	}
+}
+
+func TestParseCargoTestOutput_TestCases(t *testing.T) {
+	parser := sandbox.NewTestResultParser(70.0)
+
+	result, err := parser.ParseTestOutput(sandbox.LanguageRust, cargoFailingOutput, 101)
+	require.NoError(t, err)
+
+	require.Len(t, result.TestCases, 3)
+	assert.Equal(t, "cart::tests::adds_item", result.TestCases[0].Name)
+	assert.Equal(t, "cart::tests", result.TestCases[0].Suite)
+	assert.Equal(t, "passed", result.TestCases[0].Status)
+
+	failed := result.TestCases[1]
+	assert.Equal(t, "failed", failed.Status)
+	assert.Contains(t, failed.Output, "panicked at src/cart.rs:42:9")
+	assert.NotContains(t, failed.Output, "failures:")
+
+	assert.Equal(t, "skipped", result.TestCases[2].Status)
+}
+
 func TestCoverageValidation(t *testing.T) {
 	parser := sandbox.NewTestResultParser(70.0)
 
@@ -0,0 +1,191 @@
+package sandbox
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/pitabwire/util"
+
+	"github.com/antinvestor/builder/internal/events"
+)
+
+// maxProgressFailures bounds the failing test names carried on a progress event.
+const maxProgressFailures = 20
+
+// pytestVerboseRe matches a test outcome line of pytest -v, e.g.
+// tests/test_calc.py::test_add PASSED [ 50%].
+var pytestVerboseRe = regexp.MustCompile(`^(\S+::\S+)\s+(PASSED|FAILED|ERROR|SKIPPED)`)
+
+// progressWriter receives test output as the sandbox produces it, counts the test
+// outcomes it reports and emits them as TestExecutionProgress events, at most once
+// per interval. The worker sees a long run advance instead of waiting for the end.
+type progressWriter struct {
+	ctx       context.Context
+	eventsMan EventsEmitter
+	interval  time.Duration
+	now       func() time.Time
+
+	mu       sync.Mutex
+	partial  []byte
+	progress events.TestProgressPayload
+	lastSent time.Time
+	unsent   bool
+}
+
+func newProgressWriter(
+	ctx context.Context,
+	eventsMan EventsEmitter,
+	executionID events.ExecutionID,
+	interval time.Duration,
+) *progressWriter {
+	return &progressWriter{
+		ctx:       ctx,
+		eventsMan: eventsMan,
+		interval:  interval,
+		now:       time.Now,
+		progress:  events.TestProgressPayload{ExecutionID: executionID},
+	}
+}
+
+// Write implements io.Writer. It never fails, so progress reporting cannot break a run.
+func (w *progressWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.partial = append(w.partial, p...)
+	for {
+		end := bytes.IndexByte(w.partial, '\n')
+		if end < 0 {
+			break
+		}
+		w.record(string(w.partial[:end]))
+		w.partial = w.partial[end+1:]
+	}
+
+	if w.unsent && w.now().Sub(w.lastSent) >= w.interval {
+		w.emit()
+	}
+	return len(p), nil
+}
+
+// finish counts a trailing unterminated line and emits any progress not yet reported.
+func (w *progressWriter) finish() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if len(w.partial) > 0 {
+		w.record(string(w.partial))
+		w.partial = nil
+	}
+	if w.unsent {
+		w.emit()
+	}
+}
+
+// record counts the test outcome reported by an output line, if any.
+func (w *progressWriter) record(line string) {
+	name, status := testOutcome(line)
+	if status == "" {
+		return
+	}
+
+	w.progress.TestsCompleted++
+	switch status {
+	case statusPassed:
+		w.progress.TestsPassed++
+	case statusFailed:
+		w.progress.TestsFailed++
+		if len(w.progress.Failures) < maxProgressFailures {
+			w.progress.Failures = append(w.progress.Failures, name)
+		}
+	case statusSkipped:
+		w.progress.TestsSkipped++
+	}
+	w.unsent = true
+}
+
+// reconcile raises the totals of a result parsed from truncated output to the
+// outcomes counted while the output streamed, so tests reported only in the
+// dropped part of the output are not lost.
+func (w *progressWriter) reconcile(result *events.TestResult) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	result.PassedTests = max(result.PassedTests, w.progress.TestsPassed)
+	result.FailedTests = max(result.FailedTests, w.progress.TestsFailed)
+	result.SkippedTests = max(result.SkippedTests, w.progress.TestsSkipped)
+	result.TotalTests = max(result.TotalTests, w.progress.TestsCompleted,
+		result.PassedTests+result.FailedTests+result.SkippedTests)
+	if result.FailedTests > 0 {
+		result.Success = false
+	}
+}
+
+func (w *progressWriter) emit() {
+	payload := w.progress
+	payload.Failures = append([]string(nil), w.progress.Failures...)
+	payload.ReportedAt = w.now()
+
+	if err := w.eventsMan.Emit(w.ctx, string(events.TestExecutionProgress), &payload); err != nil {
+		util.Log(w.ctx).WithError(err).Warn("failed to emit test progress",
+			"execution_id", payload.ExecutionID.String())
+	}
+	w.lastSent = payload.ReportedAt
+	w.unsent = false
+}
+
+// testOutcome returns the test name and status reported by a line of go test
+// (plain or -json), pytest -v, cargo test or cargo nextest output, or an empty
+// status for any other line.
+func testOutcome(line string) (string, string) {
+	if len(line) > 0 && line[0] == '{' {
+		var event goTestEvent
+		if err := json.Unmarshal([]byte(line), &event); err == nil {
+			if event.Test == "" {
+				return "", ""
+			}
+			switch event.Action {
+			case "pass":
+				return event.Test, statusPassed
+			case "fail":
+				return event.Test, statusFailed
+			case "skip":
+				return event.Test, statusSkipped
+			}
+			return "", ""
+		}
+	}
+
+	if match := goTestPassRe.FindStringSubmatch(line); match != nil {
+		return match[1], statusPassed
+	}
+	if match := goTestFailRe.FindStringSubmatch(line); match != nil {
+		return match[1], statusFailed
+	}
+	if match := goTestSkipRe.FindStringSubmatch(line); match != nil {
+		return match[1], statusSkipped
+	}
+
+	if match := pytestVerboseRe.FindStringSubmatch(line); match != nil {
+		switch match[2] {
+		case "PASSED":
+			return match[1], statusPassed
+		case "SKIPPED":
+			return match[1], statusSkipped
+		default:
+			return match[1], statusFailed
+		}
+	}
+
+	if match := cargoTestLineRe.FindStringSubmatch(line); match != nil {
+		return match[1], cargoTestStatus(match[2])
+	}
+	if match := nextestLineRe.FindStringSubmatch(line); match != nil {
+		return match[4], nextestStatus(match[1])
+	}
+	return "", ""
+}
@@ -0,0 +1,178 @@
+//nolint:testpackage // white-box testing requires internal package access
+package sandbox
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	appconfig "github.com/antinvestor/builder/apps/executor/config"
+	"github.com/antinvestor/builder/internal/events"
+)
+
+// streamingSandbox writes its output to the request's output writer one line at a time.
+type streamingSandbox struct {
+	output string
+}
+
+func (s *streamingSandbox) Execute(_ context.Context, req *SandboxExecutionRequest) (*SandboxExecutionResult, error) {
+	for line := range strings.SplitAfterSeq(s.output, "\n") {
+		if req.OutputWriter != nil {
+			_, _ = req.OutputWriter.Write([]byte(line))
+		}
+	}
+	return &SandboxExecutionResult{Output: s.output, ExitCode: 1, Duration: 10}, nil
+}
+
+func (s *streamingSandbox) Ready(context.Context) error { return nil }
+
+func (s *streamingSandbox) Close() error { return nil }
+
+// eventLog records every emitted event in order.
+type eventLog struct {
+	mu       sync.Mutex
+	names    []string
+	payloads []any
+}
+
+func (l *eventLog) Emit(_ context.Context, eventName string, payload any) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.names = append(l.names, eventName)
+	l.payloads = append(l.payloads, payload)
+	return nil
+}
+
+func (l *eventLog) progress() []*events.TestProgressPayload {
+	var progress []*events.TestProgressPayload
+	for _, payload := range l.payloads {
+		if p, ok := payload.(*events.TestProgressPayload); ok {
+			progress = append(progress, p)
+		}
+	}
+	return progress
+}
+
+const multiTestOutput = "=== RUN   TestAdd\n--- PASS: TestAdd (0.00s)\n" +
+	"=== RUN   TestSub\n--- FAIL: TestSub (0.01s)\n" +
+	"=== RUN   TestDiv\n--- SKIP: TestDiv (0.00s)\n" +
+	"FAIL\nexit status 1\nFAIL\texample.com/calc\t0.012s"
+
+func TestExecutionRequestHandler_StreamsTestProgress(t *testing.T) {
+	cfg := &appconfig.ExecutorConfig{
+		SandboxEnabled:          true,
+		MaxConcurrentExecutions: 1,
+		TestProgressEnabled:     true,
+	}
+	executor := &SandboxExecutor{cfg: cfg, backend: &streamingSandbox{output: multiTestOutput}, mode: SandboxModeLocal}
+	emitter := &eventLog{}
+	handler := NewExecutionRequestHandler(cfg, executor, NewMultiRunner(cfg), emitter)
+
+	executionID := events.NewExecutionID()
+	payload, err := json.Marshal(&events.TestExecutionRequestedPayload{ExecutionID: executionID, Language: "go"})
+	require.NoError(t, err)
+	require.NoError(t, handler.Handle(context.Background(), nil, payload))
+
+	// One progress event per completed test, then the final result
+	require.Len(t, emitter.names, 4)
+	assert.Equal(t, []string{
+		string(events.TestExecutionProgress),
+		string(events.TestExecutionProgress),
+		string(events.TestExecutionProgress),
+		"feature.execution.completed",
+	}, emitter.names)
+
+	progress := emitter.progress()
+	assert.Equal(t, []int{1, 2, 3}, []int{
+		progress[0].TestsCompleted, progress[1].TestsCompleted, progress[2].TestsCompleted,
+	})
+	assert.Equal(t, []string{"TestSub"}, progress[2].Failures)
+
+	completed, ok := emitter.payloads[3].(*events.TestExecutionCompletedPayload)
+	require.True(t, ok)
+	last := progress[2]
+	assert.Equal(t, executionID, last.ExecutionID)
+	assert.Equal(t, completed.Result.TotalTests, last.TestsCompleted)
+	assert.Equal(t, completed.Result.PassedTests, last.TestsPassed)
+	assert.Equal(t, completed.Result.FailedTests, last.TestsFailed)
+	assert.Equal(t, completed.Result.SkippedTests, last.TestsSkipped)
+}
+
+func TestExecutionRequestHandler_ProgressDisabled(t *testing.T) {
+	cfg := &appconfig.ExecutorConfig{SandboxEnabled: true, MaxConcurrentExecutions: 1}
+	executor := &SandboxExecutor{cfg: cfg, backend: &streamingSandbox{output: multiTestOutput}, mode: SandboxModeLocal}
+	emitter := &eventLog{}
+	handler := NewExecutionRequestHandler(cfg, executor, NewMultiRunner(cfg), emitter)
+
+	payload, err := json.Marshal(&events.TestExecutionRequestedPayload{ExecutionID: events.NewExecutionID()})
+	require.NoError(t, err)
+	require.NoError(t, handler.Handle(context.Background(), nil, payload))
+	assert.Equal(t, []string{"feature.execution.completed"}, emitter.names)
+}
+
+func TestProgressWriter_ReportsAtMostOncePerInterval(t *testing.T) {
+	emitter := &eventLog{}
+	writer := newProgressWriter(context.Background(), emitter, events.NewExecutionID(), 10*time.Second)
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	writer.now = func() time.Time { return now }
+
+	// Output arrives in chunks that split lines
+	_, _ = writer.Write([]byte("--- PASS: TestA (0.00s)\n--- PA"))
+	_, _ = writer.Write([]byte("SS: TestB (0.00s)\n"))
+	now = now.Add(5 * time.Second)
+	_, _ = writer.Write([]byte("--- FAIL: TestC (0.00s)\n"))
+	require.Len(t, emitter.progress(), 1)
+	assert.Equal(t, 1, emitter.progress()[0].TestsCompleted)
+
+	now = now.Add(5 * time.Second)
+	_, _ = writer.Write([]byte("--- PASS: TestD (0.00s)"))
+	require.Len(t, emitter.progress(), 2)
+	assert.Equal(t, 3, emitter.progress()[1].TestsCompleted)
+
+	// The unterminated last line is counted when the run finishes
+	writer.finish()
+	progress := emitter.progress()
+	require.Len(t, progress, 3)
+	assert.Equal(t, 4, progress[2].TestsCompleted)
+	assert.Equal(t, 3, progress[2].TestsPassed)
+	assert.Equal(t, []string{"TestC"}, progress[2].Failures)
+
+	// Nothing new to report
+	writer.finish()
+	assert.Len(t, emitter.progress(), 3)
+}
+
+func TestTestOutcome(t *testing.T) {
+	tests := []struct {
+		line       string
+		wantName   string
+		wantStatus string
+	}{
+		{"--- PASS: TestAdd (0.00s)", "TestAdd", statusPassed},
+		{"    --- FAIL: TestAdd/negative (0.00s)", "TestAdd/negative", statusFailed},
+		{`{"Action":"pass","Package":"calc","Test":"TestAdd","Elapsed":0.1}`, "TestAdd", statusPassed},
+		{`{"Action":"skip","Package":"calc","Test":"TestDiv"}`, "TestDiv", statusSkipped},
+		{`{"Action":"pass","Package":"calc","Elapsed":0.2}`, "", ""},
+		{`{"Action":"output","Package":"calc","Test":"TestAdd","Output":"--- PASS: TestAdd (0.00s)\n"}`, "", ""},
+		{"tests/test_calc.py::test_add PASSED                    [ 50%]", "tests/test_calc.py::test_add", statusPassed},
+		{"tests/test_calc.py::test_div ERROR                     [100%]", "tests/test_calc.py::test_div", statusFailed},
+		{"test cart::tests::adds_item ... ok", "cart::tests::adds_item", statusPassed},
+		{"test cart::tests::slow ... ignored, needs a database", "cart::tests::slow", statusSkipped},
+		{"        FAIL [   0.010s] shop cart::tests::removes_item", "cart::tests::removes_item", statusFailed},
+		{"=== RUN   TestAdd", "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			name, status := testOutcome(tt.line)
+			assert.Equal(t, tt.wantName, name)
+			assert.Equal(t, tt.wantStatus, status)
+		})
+	}
+}
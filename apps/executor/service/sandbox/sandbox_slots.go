@@ -0,0 +1,75 @@
+package sandbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+// ErrorCodeSandboxBusy is the failure code reported when no sandbox frees up in time.
+const ErrorCodeSandboxBusy = "sandbox_busy"
+
+// ErrSandboxBusy is returned when all sandboxes stayed in use for the queue timeout.
+var ErrSandboxBusy = errors.New("all sandboxes are busy")
+
+// sandboxSlots limits the number of sandboxes running at once. Executions beyond
+// the limit wait, in arrival order, until a sandbox is released.
+type sandboxSlots struct {
+	slots   chan struct{}
+	timeout time.Duration
+	waiting atomic.Int32
+}
+
+// newSandboxSlots returns nil, meaning unlimited, when limit is not positive.
+func newSandboxSlots(limit int, timeout time.Duration) *sandboxSlots {
+	if limit <= 0 {
+		return nil
+	}
+	return &sandboxSlots{slots: make(chan struct{}, limit), timeout: timeout}
+}
+
+// acquire waits for a free sandbox and returns the function that releases it.
+func (s *sandboxSlots) acquire(ctx context.Context) (func(), error) {
+	if s == nil {
+		return func() {}, nil
+	}
+
+	select {
+	case s.slots <- struct{}{}:
+		return s.release, nil
+	default:
+	}
+
+	s.waiting.Add(1)
+	defer s.waiting.Add(-1)
+
+	var timeout <-chan time.Time
+	if s.timeout > 0 {
+		timer := time.NewTimer(s.timeout)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
+	select {
+	case s.slots <- struct{}{}:
+		return s.release, nil
+	case <-timeout:
+		return nil, fmt.Errorf("%w: %d sandboxes in use for %s", ErrSandboxBusy, cap(s.slots), s.timeout)
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (s *sandboxSlots) release() {
+	<-s.slots
+}
+
+// queued returns the number of executions waiting for a sandbox.
+func (s *sandboxSlots) queued() int {
+	if s == nil {
+		return 0
+	}
+	return int(s.waiting.Load())
+}
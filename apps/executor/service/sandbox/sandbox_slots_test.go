@@ -0,0 +1,264 @@
+//nolint:testpackage // white-box testing requires internal package access
+package sandbox
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	appconfig "github.com/antinvestor/builder/apps/executor/config"
+	"github.com/antinvestor/builder/internal/events"
+)
+
+// blockingSandbox holds every execution until it is released.
+type blockingSandbox struct {
+	started chan events.ExecutionID
+	release chan struct{}
+}
+
+func newBlockingSandbox() *blockingSandbox {
+	return &blockingSandbox{started: make(chan events.ExecutionID, 8), release: make(chan struct{})}
+}
+
+func (s *blockingSandbox) Execute(ctx context.Context, req *SandboxExecutionRequest) (*SandboxExecutionResult, error) {
+	s.started <- req.ExecutionID
+	select {
+	case <-s.release:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	return &SandboxExecutionResult{Output: "PASS\nok  \texample.com/calc\t0.002s\n", Duration: 10}, nil
+}
+
+func (s *blockingSandbox) Ready(context.Context) error { return nil }
+
+func (s *blockingSandbox) Close() error { return nil }
+
+type recordingEmitter struct {
+	mu       sync.Mutex
+	payloads []*events.TestExecutionCompletedPayload
+}
+
+func (e *recordingEmitter) Emit(_ context.Context, _ string, payload any) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if completed, ok := payload.(*events.TestExecutionCompletedPayload); ok {
+		e.payloads = append(e.payloads, completed)
+	}
+	return nil
+}
+
+func (e *recordingEmitter) completed() []*events.TestExecutionCompletedPayload {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return append([]*events.TestExecutionCompletedPayload(nil), e.payloads...)
+}
+
+func newSlotsTestHandler(
+	t *testing.T,
+	maxSandboxes int,
+) (*ExecutionRequestHandler, *blockingSandbox, *recordingEmitter) {
+	t.Helper()
+	cfg := &appconfig.ExecutorConfig{
+		SandboxEnabled:          true,
+		MaxConcurrentExecutions: 10,
+		MaxConcurrentSandboxes:  maxSandboxes,
+	}
+	backend := newBlockingSandbox()
+	executor := &SandboxExecutor{cfg: cfg, backend: backend, mode: SandboxModeLocal}
+	emitter := &recordingEmitter{}
+	return NewExecutionRequestHandler(cfg, executor, NewMultiRunner(cfg), emitter), backend, emitter
+}
+
+func executionRequestPayload(t *testing.T, executionID events.ExecutionID) []byte {
+	t.Helper()
+	payload, err := json.Marshal(&events.TestExecutionRequestedPayload{ExecutionID: executionID, Language: "go"})
+	require.NoError(t, err)
+	return payload
+}
+
+func TestExecutionRequestHandler_ExecutionBeyondLimitWaitsForFreeSandbox(t *testing.T) {
+	handler, backend, emitter := newSlotsTestHandler(t, 2)
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	for range 2 {
+		wg.Go(func() {
+			assert.NoError(t, handler.Handle(ctx, nil, executionRequestPayload(t, events.NewExecutionID())))
+		})
+	}
+	<-backend.started
+	<-backend.started
+
+	// The third execution queues instead of starting a third sandbox
+	third := events.NewExecutionID()
+	wg.Go(func() {
+		assert.NoError(t, handler.Handle(ctx, nil, executionRequestPayload(t, third)))
+	})
+	require.Eventually(t, func() bool { return handler.QueuedCount() == 1 }, time.Second, time.Millisecond)
+	select {
+	case id := <-backend.started:
+		t.Fatalf("execution %s started while both sandboxes were in use", id)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	// Finishing one execution lets the queued one proceed
+	backend.release <- struct{}{}
+	select {
+	case id := <-backend.started:
+		assert.Equal(t, third, id)
+	case <-time.After(time.Second):
+		t.Fatal("queued execution did not start after a sandbox was released")
+	}
+	assert.Zero(t, handler.QueuedCount())
+
+	close(backend.release)
+	wg.Wait()
+
+	completed := emitter.completed()
+	require.Len(t, completed, 3)
+	for _, payload := range completed {
+		assert.True(t, payload.Success)
+	}
+}
+
+func TestExecutionRequestHandler_SaturatedTooLongReportsSandboxBusy(t *testing.T) {
+	handler, backend, emitter := newSlotsTestHandler(t, 1)
+	handler.slots.timeout = 20 * time.Millisecond
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	wg.Go(func() {
+		assert.NoError(t, handler.Handle(ctx, nil, executionRequestPayload(t, events.NewExecutionID())))
+	})
+	<-backend.started
+
+	// The request fails so that it is redelivered once a sandbox frees up
+	busy := events.NewExecutionID()
+	require.ErrorIs(t, handler.Handle(ctx, nil, executionRequestPayload(t, busy)), ErrSandboxBusy)
+
+	completed := emitter.completed()
+	require.Len(t, completed, 1)
+	assert.Equal(t, busy, completed[0].ExecutionID)
+	assert.False(t, completed[0].Success)
+	require.NotNil(t, completed[0].Error)
+	assert.Equal(t, ErrorCodeSandboxBusy, completed[0].Error.Code)
+	assert.True(t, completed[0].Error.Retryable)
+
+	close(backend.release)
+	wg.Wait()
+}
+
+func newLimitedExecutor(limit int, timeout time.Duration) (*SandboxExecutor, *blockingSandbox) {
+	cfg := &appconfig.ExecutorConfig{SandboxEnabled: true, MaxConcurrentExecutions: limit}
+	backend := newBlockingSandbox()
+	return &SandboxExecutor{
+		cfg:     cfg,
+		backend: backend,
+		mode:    SandboxModeLocal,
+		slots:   newSandboxSlots(limit, timeout),
+	}, backend
+}
+
+func TestSandboxExecutor_ExecutionBeyondLimitWaits(t *testing.T) {
+	executor, backend := newLimitedExecutor(2, time.Minute)
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	for range 3 {
+		wg.Go(func() {
+			_, err := executor.Execute(ctx, &SandboxExecutionRequest{ExecutionID: events.NewExecutionID()})
+			assert.NoError(t, err)
+		})
+	}
+	<-backend.started
+	<-backend.started
+
+	require.Eventually(t, func() bool { return executor.QueuedCount() == 1 }, time.Second, time.Millisecond)
+	assert.Equal(t, 2, executor.ActiveCount())
+	select {
+	case <-backend.started:
+		t.Fatal("third execution started while both slots were in use")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	backend.release <- struct{}{}
+	select {
+	case <-backend.started:
+	case <-time.After(time.Second):
+		t.Fatal("queued execution did not start after a slot was released")
+	}
+	assert.Zero(t, executor.QueuedCount())
+
+	close(backend.release)
+	wg.Wait()
+	assert.Zero(t, executor.ActiveCount())
+}
+
+func TestSandboxExecutor_CancellationUnblocksWaitingExecution(t *testing.T) {
+	executor, backend := newLimitedExecutor(1, 0)
+
+	var wg sync.WaitGroup
+	wg.Go(func() {
+		_, err := executor.Execute(context.Background(), &SandboxExecutionRequest{ExecutionID: events.NewExecutionID()})
+		assert.NoError(t, err)
+	})
+	<-backend.started
+
+	ctx, cancel := context.WithCancel(context.Background())
+	waited := make(chan error, 1)
+	go func() {
+		_, err := executor.Execute(ctx, &SandboxExecutionRequest{ExecutionID: events.NewExecutionID()})
+		waited <- err
+	}()
+	require.Eventually(t, func() bool { return executor.QueuedCount() == 1 }, time.Second, time.Millisecond)
+
+	cancel()
+	select {
+	case err := <-waited:
+		require.ErrorIs(t, err, context.Canceled)
+	case <-time.After(time.Second):
+		t.Fatal("cancelling the context did not unblock the waiting execution")
+	}
+	assert.Zero(t, executor.QueuedCount())
+
+	close(backend.release)
+	wg.Wait()
+}
+
+func TestExecutionRequestHandler_ExecutionSlotTimeoutIsRetryable(t *testing.T) {
+	executor, backend := newLimitedExecutor(1, 20*time.Millisecond)
+	emitter := &recordingEmitter{}
+	handler := NewExecutionRequestHandler(executor.cfg, executor, NewMultiRunner(executor.cfg), emitter)
+
+	var wg sync.WaitGroup
+	wg.Go(func() {
+		assert.NoError(t, handler.Handle(context.Background(), nil, executionRequestPayload(t, events.NewExecutionID())))
+	})
+	<-backend.started
+
+	err := handler.Handle(context.Background(), nil, executionRequestPayload(t, events.NewExecutionID()))
+	require.ErrorIs(t, err, ErrSandboxBusy)
+	completed := emitter.completed()
+	require.Len(t, completed, 1)
+	assert.Equal(t, ErrorCodeSandboxBusy, completed[0].Error.Code)
+	assert.True(t, completed[0].Error.Retryable)
+
+	close(backend.release)
+	wg.Wait()
+}
+
+func TestSandboxSlots_UnlimitedNeverWaits(t *testing.T) {
+	slots := newSandboxSlots(0, time.Second)
+	assert.Nil(t, slots)
+
+	release, err := slots.acquire(context.Background())
+	require.NoError(t, err)
+	release()
+	assert.Zero(t, slots.queued())
+}
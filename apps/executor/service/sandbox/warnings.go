@@ -0,0 +1,95 @@
+package sandbox
+
+import (
+	"bufio"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/antinvestor/builder/internal/events"
+)
+
+// Pre-compiled regular expressions for parsing build warnings.
+var (
+	// gcc, clang and javac: "file.c:10:5: warning: msg" or "Foo.java:12: warning: msg".
+	compilerWarningRe = regexp.MustCompile(`^(\S+?):(\d+):(?:(\d+):)?\s*warning:\s*(.+)$`)
+
+	// go vet and the Go compiler: "./pkg/file.go:12:5: msg", optionally prefixed with "vet: ".
+	goDiagnosticRe = regexp.MustCompile(`^(?:vet:\s+)?(\S+\.go):(\d+):(\d+):\s+(.+)$`)
+
+	// Python warnings module: "file.py:10: DeprecationWarning: msg".
+	pythonWarningRe = regexp.MustCompile(`^(\S+\.py):(\d+):\s+(\w*Warning):\s+(.+)$`)
+
+	// rustc reports the message and location on consecutive lines.
+	rustWarningRe  = regexp.MustCompile(`^warning:\s+(.+)$`)
+	rustLocationRe = regexp.MustCompile(`^\s*-->\s+(\S+?):(\d+):(\d+)$`)
+)
+
+// ParseBuildWarnings extracts individual compiler, vet and interpreter warnings
+// from build or test output. Each distinct warning becomes a low severity
+// maintainability issue carrying the reported file and line. Go reports vet and
+// compiler diagnostics in the same format, so every such diagnostic is captured.
+func ParseBuildWarnings(output string) []events.ReviewIssue {
+	var issues []events.ReviewIssue
+	seen := make(map[string]bool)
+
+	add := func(source, file, line, message, raw string) {
+		lineNum, _ := strconv.Atoi(line)
+		message = strings.TrimSpace(message)
+
+		key := fmt.Sprintf("%s:%d:%s", file, lineNum, message)
+		if seen[key] {
+			return
+		}
+		seen[key] = true
+
+		issues = append(issues, events.ReviewIssue{
+			ID:          fmt.Sprintf("build-warning-%s-%d-%d", file, lineNum, len(issues)+1),
+			Type:        events.ReviewIssueTypeMaintainability,
+			Severity:    events.ReviewIssueSeverityLow,
+			FilePath:    strings.TrimPrefix(file, "./"),
+			LineStart:   lineNum,
+			LineEnd:     lineNum,
+			Title:       source + " warning",
+			Description: message,
+			Suggestion:  "Resolve the warning reported by the build",
+			CodeSnippet: strings.TrimSpace(raw),
+		})
+	}
+
+	var pendingRust, pendingRaw string
+
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+
+		if pendingRust != "" {
+			if m := rustLocationRe.FindStringSubmatch(line); m != nil {
+				add("Rust", m[1], m[2], pendingRust, pendingRaw)
+			}
+			// A rustc warning header without a location (e.g. the summary line) is dropped.
+			pendingRust, pendingRaw = "", ""
+		}
+
+		switch {
+		case strings.HasPrefix(line, "{"):
+			// JSON test events (go test -json, jest --json) are not diagnostics.
+			continue
+		case compilerWarningRe.MatchString(line):
+			m := compilerWarningRe.FindStringSubmatch(line)
+			add("Compiler", m[1], m[2], m[4], line)
+		case goDiagnosticRe.MatchString(line):
+			m := goDiagnosticRe.FindStringSubmatch(line)
+			add("Go vet", m[1], m[2], m[4], line)
+		case pythonWarningRe.MatchString(line):
+			m := pythonWarningRe.FindStringSubmatch(line)
+			add("Python", m[1], m[2], m[3]+": "+m[4], line)
+		case rustWarningRe.MatchString(line):
+			pendingRust = rustWarningRe.FindStringSubmatch(line)[1]
+			pendingRaw = line
+		}
+	}
+
+	return issues
+}
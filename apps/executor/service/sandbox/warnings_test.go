@@ -0,0 +1,123 @@
+package sandbox_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/antinvestor/builder/apps/executor/service/sandbox"
+	"github.com/antinvestor/builder/internal/events"
+)
+
+func TestParseBuildWarnings(t *testing.T) {
+	tests := []struct {
+		name      string
+		output    string
+		wantFile  string
+		wantLine  int
+		wantTitle string
+		wantDesc  string
+	}{
+		{
+			name: "go vet diagnostic",
+			output: `# example.com/pkg
+./pkg/handler.go:42:3: fmt.Printf format %d has arg name of wrong type string`,
+			wantFile:  "pkg/handler.go",
+			wantLine:  42,
+			wantTitle: "Go vet warning",
+			wantDesc:  "fmt.Printf format %d has arg name of wrong type string",
+		},
+		{
+			name:      "go vet with prefix",
+			output:    `vet: internal/store.go:7:2: unreachable code`,
+			wantFile:  "internal/store.go",
+			wantLine:  7,
+			wantTitle: "Go vet warning",
+			wantDesc:  "unreachable code",
+		},
+		{
+			name:      "gcc warning",
+			output:    `src/main.c:10:5: warning: unused variable 'count' [-Wunused-variable]`,
+			wantFile:  "src/main.c",
+			wantLine:  10,
+			wantTitle: "Compiler warning",
+			wantDesc:  "unused variable 'count' [-Wunused-variable]",
+		},
+		{
+			name:      "javac warning",
+			output:    `src/main/java/App.java:12: warning: [deprecation] getYear() in Date has been deprecated`,
+			wantFile:  "src/main/java/App.java",
+			wantLine:  12,
+			wantTitle: "Compiler warning",
+			wantDesc:  "[deprecation] getYear() in Date has been deprecated",
+		},
+		{
+			name: "rustc warning",
+			output: "warning: unused variable: `x`\n" +
+				" --> src/main.rs:2:9\n" +
+				"  |\n" +
+				"2 |     let x = 5;\n" +
+				"warning: `demo` (bin \"demo\") generated 1 warning",
+			wantFile:  "src/main.rs",
+			wantLine:  2,
+			wantTitle: "Rust warning",
+			wantDesc:  "unused variable: `x`",
+		},
+		{
+			name:      "python warning",
+			output:    `app/utils.py:10: DeprecationWarning: the imp module is deprecated`,
+			wantFile:  "app/utils.py",
+			wantLine:  10,
+			wantTitle: "Python warning",
+			wantDesc:  "DeprecationWarning: the imp module is deprecated",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			issues := sandbox.ParseBuildWarnings(tt.output)
+			require.Len(t, issues, 1)
+
+			issue := issues[0]
+			assert.Equal(t, tt.wantFile, issue.FilePath)
+			assert.Equal(t, tt.wantLine, issue.LineStart)
+			assert.Equal(t, tt.wantTitle, issue.Title)
+			assert.Equal(t, tt.wantDesc, issue.Description)
+			assert.Equal(t, events.ReviewIssueTypeMaintainability, issue.Type)
+			assert.Equal(t, events.ReviewIssueSeverityLow, issue.Severity)
+			assert.NotEmpty(t, issue.ID)
+		})
+	}
+}
+
+func TestParseBuildWarnings_IgnoresTestLogsAndDuplicates(t *testing.T) {
+	output := `=== RUN   TestOne
+    one_test.go:25: expected 1, got 2
+--- FAIL: TestOne (0.00s)
+{"Action":"output","Output":"./a.go:1:1: not a diagnostic"}
+src/lib.c:3:1: warning: implicit declaration of function 'foo'
+src/lib.c:3:1: warning: implicit declaration of function 'foo'
+src/lib.c:4:1: warning: implicit declaration of function 'bar'`
+
+	issues := sandbox.ParseBuildWarnings(output)
+
+	require.Len(t, issues, 2)
+	assert.Equal(t, 3, issues[0].LineStart)
+	assert.Equal(t, 4, issues[1].LineStart)
+}
+
+func TestParseTestOutput_AttachesBuildWarnings(t *testing.T) {
+	parser := sandbox.NewTestResultParser(0)
+
+	output := `# example.com/pkg
+./pkg/handler.go:42:3: fmt.Printf format %d has arg name of wrong type string
+--- PASS: TestOne (0.10s)
+PASS
+ok  	example.com/pkg	0.100s`
+
+	result, err := parser.ParseTestOutput(sandbox.LanguageGo, output, 0)
+	require.NoError(t, err)
+	require.Len(t, result.BuildWarnings, 1)
+	assert.Equal(t, "pkg/handler.go", result.BuildWarnings[0].FilePath)
+}
@@ -3,13 +3,19 @@ package main
 import (
 	"context"
 	"net/http"
+	"time"
 
 	"github.com/pitabwire/frame"
 	"github.com/pitabwire/frame/config"
+	"github.com/pitabwire/frame/datastore"
 	"github.com/pitabwire/util"
 
 	appconfig "github.com/antinvestor/builder/apps/gateway/config"
+	"github.com/antinvestor/builder/apps/gateway/handlers"
 	"github.com/antinvestor/builder/apps/gateway/middleware"
+	"github.com/antinvestor/builder/apps/gateway/routing"
+	"github.com/antinvestor/builder/internal/executions"
+	"github.com/antinvestor/builder/internal/httpauth"
 )
 
 func main() {
@@ -26,11 +32,12 @@ func main() {
 		cfg.ServiceName = "feature_gateway"
 	}
 
-	// Create service with Frame - minimal dependencies
+	// Create service with Frame - the datastore serves feature status reads
 	ctx, svc := frame.NewServiceWithContext(
 		ctx,
 		frame.WithConfig(&cfg),
 		frame.WithRegisterServerOauth2Client(),
+		frame.WithDatastore(),
 	)
 	defer svc.Stop(ctx)
 	log := svc.Log(ctx)
@@ -38,20 +45,44 @@ func main() {
 	// Get queue manager for publishing
 	qMan := svc.QueueManager()
 
+	// Executions are written by the worker and read here for status queries
+	dbPool := svc.DatastoreManager().GetPool(ctx, datastore.DefaultPoolName)
+	executionRepo := executions.NewExecutionRepository(ctx, dbPool)
+
 	// Setup Security and Middleware
 	securityMan := svc.SecurityManager()
 	authenticator := securityMan.GetAuthenticator(ctx)
-	authMiddleware := middleware.NewAuthMiddleware(authenticator)
+	authMiddleware := httpauth.NewAuthMiddleware(authenticator)
 
-	rateLimiter := middleware.NewRateLimiter(
+	rateLimitStore, err := middleware.NewRateLimitStore(ctx, cfg.RateLimitBackend, cfg.RateLimitRedisURL)
+	if err != nil {
+		log.WithError(err).Fatal("could not create rate limit store")
+	}
+	rateLimiter := middleware.NewRateLimiterWithStore(
 		cfg.RateLimitRequestsPerMinute,
 		cfg.RateLimitBurstSize,
+		rateLimitStore,
 	)
+	rateLimiter.SetFailOpen(cfg.RateLimitFailOpen)
 	defer rateLimiter.Stop()
 
+	// Requests are also limited per connection IP before authentication, over the same store
+	preAuthLimiter := middleware.NewIPRateLimiterWithStore(
+		cfg.PreAuthRateLimitRequestsPerMinute,
+		cfg.PreAuthRateLimitBurstSize,
+		rateLimitStore,
+	)
+	preAuthLimiter.SetFailOpen(cfg.RateLimitFailOpen)
+
 	log.Info("rate limiter configured",
 		"requests_per_minute", cfg.RateLimitRequestsPerMinute,
 		"burst_size", cfg.RateLimitBurstSize,
+		"backend", cfg.RateLimitBackend,
+		"fail_open", cfg.RateLimitFailOpen,
+	)
+
+	timeoutMiddleware := middleware.NewTimeoutMiddleware(
+		time.Duration(cfg.RequestTimeoutSeconds) * time.Second,
 	)
 
 	// Register Publishers
@@ -60,13 +91,21 @@ func main() {
 		cfg.QueueFeatureRequestURI,
 	)
 
-	// Setup HTTP Handlers and Routes
-	mux := setupRoutes(log, authMiddleware, rateLimiter)
+	priorityFeatureRequestPublisher := frame.WithRegisterPublisher(
+		cfg.QueuePriorityFeatureRequestName,
+		cfg.QueuePriorityFeatureRequestURI,
+	)
 
-	_ = qMan // Will be used in feature endpoint for publishing
+	// Route high-priority and fast-lane categories to the priority queue
+	router := routing.NewQueueRouter(&cfg)
+
+	// Setup HTTP Handlers and Routes
+	features := handlers.NewFeatureHandler(&cfg, qMan, router)
+	status := handlers.NewStatusHandler(&cfg, executionRepo)
+	mux := setupRoutes(log, authMiddleware, preAuthLimiter, rateLimiter, timeoutMiddleware, features, status)
 
 	// Initialize and Run Service
-	svc.Init(ctx, frame.WithHTTPHandler(mux), featureRequestPublisher)
+	svc.Init(ctx, frame.WithHTTPHandler(mux), featureRequestPublisher, priorityFeatureRequestPublisher)
 
 	log.Info("Starting feature gateway service...")
 	if err = svc.Run(ctx, ""); err != nil {
@@ -76,8 +115,12 @@ func main() {
 
 func setupRoutes(
 	log *util.LogEntry,
-	authMiddleware *middleware.AuthMiddleware,
+	authMiddleware *httpauth.AuthMiddleware,
+	preAuthLimiter *middleware.RateLimiter,
 	rateLimiter *middleware.RateLimiter,
+	timeoutMiddleware *middleware.TimeoutMiddleware,
+	features http.Handler,
+	status http.Handler,
 ) *http.ServeMux {
 	mux := http.NewServeMux()
 
@@ -85,10 +128,26 @@ func setupRoutes(
 	mux.Handle("/health", healthHandler(log))
 	mux.Handle("/ready", readyHandler(log))
 
-	// Feature endpoint - requires auth and rate limiting
+	// Feature endpoint - requires auth and rate limiting, bounded by the request timeout.
+	// A per-IP limit runs before auth so credentials cannot be tried without limit;
+	// the per-user quota runs after it.
 	mux.Handle("/api/v1/features",
-		rateLimiter.Middleware(
-			authMiddleware.Middleware(featureHandler(log)),
+		preAuthLimiter.Middleware(
+			authMiddleware.Middleware(
+				rateLimiter.Middleware(
+					timeoutMiddleware.Middleware(features),
+				),
+			),
+		),
+	)
+
+	// Feature status endpoint - not bounded by the request timeout, which would
+	// cut off status streams
+	mux.Handle("/api/v1/features/{id}",
+		preAuthLimiter.Middleware(
+			authMiddleware.Middleware(
+				rateLimiter.Middleware(status),
+			),
 		),
 	)
 
@@ -114,33 +173,3 @@ func readyHandler(log *util.LogEntry) http.Handler {
 		}
 	})
 }
-
-func featureHandler(log *util.LogEntry) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		if r.Method != http.MethodPost {
-			w.Header().Set("Content-Type", "application/json")
-			w.WriteHeader(http.StatusMethodNotAllowed)
-			_, _ = w.Write([]byte(`{"error":"method_not_allowed","message":"Only POST method is allowed"}`))
-			return
-		}
-
-		// Get authenticated user
-		claims := middleware.GetUserFromContext(r.Context())
-		userID := ""
-		if claims != nil {
-			userID, _ = claims.GetSubject()
-		}
-
-		log.Info("feature request received",
-			"user_id", userID,
-			"path", r.URL.Path,
-		)
-
-		// TODO: Parse request and publish to queue
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusAccepted)
-		if _, writeErr := w.Write([]byte(`{"status":"accepted","message":"Feature request queued"}`)); writeErr != nil {
-			log.WithError(writeErr).Error("failed to write feature response")
-		}
-	})
-}
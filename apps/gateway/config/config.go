@@ -20,6 +20,15 @@ type GatewayConfig struct {
 	// QueueFeatureRequestURI is the URI of the feature request queue.
 	QueueFeatureRequestURI string `envDefault:"mem://feature.requests" env:"QUEUE_FEATURE_REQUEST_URI"`
 
+	// QueuePriorityFeatureRequestName is the name of the fast-lane feature request queue.
+	QueuePriorityFeatureRequestName string `envDefault:"feature.requests.priority" env:"QUEUE_PRIORITY_FEATURE_REQUEST_NAME"`
+
+	// QueuePriorityFeatureRequestURI is the URI of the fast-lane feature request queue.
+	QueuePriorityFeatureRequestURI string `envDefault:"mem://feature.requests.priority" env:"QUEUE_PRIORITY_FEATURE_REQUEST_URI"`
+
+	// PriorityQueueCategories are feature categories always routed to the fast lane (comma-separated).
+	PriorityQueueCategories string `envDefault:"bugfix,hotfix,security" env:"PRIORITY_QUEUE_CATEGORIES"`
+
 	// ==========================================================================
 	// Feature Result Queue (incoming from workers)
 	// ==========================================================================
@@ -40,6 +49,38 @@ type GatewayConfig struct {
 	// RateLimitBurstSize is the burst size for rate limiting.
 	RateLimitBurstSize int `envDefault:"10" env:"RATE_LIMIT_BURST_SIZE"`
 
+	// RateLimitBackend stores client quotas: "memory" (per process) or "redis"
+	// (shared across replicas and restarts).
+	RateLimitBackend string `envDefault:"memory" env:"RATE_LIMIT_BACKEND"`
+
+	// RateLimitRedisURL is the Redis connection string for the redis backend.
+	RateLimitRedisURL string `envDefault:"" env:"RATE_LIMIT_REDIS_URL"`
+
+	// RateLimitFailOpen allows requests while the rate limit store is unavailable.
+	// By default they are rejected, so a store outage does not lift every quota.
+	RateLimitFailOpen bool `envDefault:"false" env:"RATE_LIMIT_FAIL_OPEN"`
+
+	// PreAuthRateLimitRequestsPerMinute limits requests per minute per connection IP before
+	// authentication, so invalid credentials cannot be tried without limit.
+	PreAuthRateLimitRequestsPerMinute int `envDefault:"300" env:"PRE_AUTH_RATE_LIMIT_REQUESTS_PER_MINUTE"`
+
+	// PreAuthRateLimitBurstSize is the burst size of the pre-authentication limit.
+	PreAuthRateLimitBurstSize int `envDefault:"50" env:"PRE_AUTH_RATE_LIMIT_BURST_SIZE"`
+
+	// ==========================================================================
+	// Request Timeout
+	// ==========================================================================
+
+	// RequestTimeoutSeconds bounds the total time to handle a feature submission (0 disables).
+	RequestTimeoutSeconds int `envDefault:"30" env:"REQUEST_TIMEOUT_SECONDS"`
+
+	// ==========================================================================
+	// Feature Status
+	// ==========================================================================
+
+	// StatusStreamPollIntervalMs is how often a feature status stream re-reads the execution.
+	StatusStreamPollIntervalMs int `envDefault:"2000" env:"STATUS_STREAM_POLL_INTERVAL_MS"`
+
 	// ==========================================================================
 	// Request Validation
 	// ==========================================================================
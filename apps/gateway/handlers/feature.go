@@ -0,0 +1,226 @@
+// Package handlers implements the gateway's HTTP API.
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/pitabwire/frame/queue"
+	"github.com/pitabwire/util"
+
+	appconfig "github.com/antinvestor/builder/apps/gateway/config"
+	"github.com/antinvestor/builder/apps/gateway/routing"
+	"github.com/antinvestor/builder/internal/events"
+	"github.com/antinvestor/builder/internal/httpauth"
+)
+
+// featureRequestSource identifies executions requested through the gateway.
+const featureRequestSource = "api"
+
+// ErrInvalidFeatureRequest is returned when a feature request fails validation.
+var ErrInvalidFeatureRequest = errors.New("invalid feature request")
+
+// FeatureRequest is the body of a feature submission.
+type FeatureRequest struct {
+	Title              string   `json:"title"`
+	Description        string   `json:"description"`
+	AcceptanceCriteria []string `json:"acceptance_criteria,omitempty"`
+	RepositoryURL      string   `json:"repository_url"`
+	TargetBranch       string   `json:"target_branch,omitempty"`
+	PathHints          []string `json:"path_hints,omitempty"`
+
+	// Priority and Category select the queue the request is published to.
+	Priority string `json:"priority,omitempty"`
+	Category string `json:"category,omitempty"`
+}
+
+// Validate checks the required fields and that the repository is hosted on one
+// of allowedHosts (comma-separated; empty allows any host).
+func (r *FeatureRequest) Validate(allowedHosts string) error {
+	if strings.TrimSpace(r.Title) == "" {
+		return fmt.Errorf("%w: title is required", ErrInvalidFeatureRequest)
+	}
+	if strings.TrimSpace(r.Description) == "" {
+		return fmt.Errorf("%w: description is required", ErrInvalidFeatureRequest)
+	}
+	if strings.TrimSpace(r.RepositoryURL) == "" {
+		return fmt.Errorf("%w: repository_url is required", ErrInvalidFeatureRequest)
+	}
+
+	repoURL, err := url.Parse(strings.TrimSpace(r.RepositoryURL))
+	if err != nil || repoURL.Hostname() == "" {
+		return fmt.Errorf("%w: repository_url must be an absolute URL", ErrInvalidFeatureRequest)
+	}
+	if strings.TrimSpace(allowedHosts) == "" {
+		return nil
+	}
+	for host := range strings.SplitSeq(allowedHosts, ",") {
+		if strings.EqualFold(strings.TrimSpace(host), repoURL.Hostname()) {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: repository host %s is not allowed", ErrInvalidFeatureRequest, repoURL.Hostname())
+}
+
+// FeatureHandler accepts feature submissions and publishes their execution
+// initialization to the queue selected by the router.
+type FeatureHandler struct {
+	cfg    *appconfig.GatewayConfig
+	queue  queue.Manager
+	router *routing.QueueRouter
+}
+
+// NewFeatureHandler creates a new feature handler.
+func NewFeatureHandler(
+	cfg *appconfig.GatewayConfig,
+	qMan queue.Manager,
+	router *routing.QueueRouter,
+) *FeatureHandler {
+	return &FeatureHandler{
+		cfg:    cfg,
+		queue:  qMan,
+		router: router,
+	}
+}
+
+// ServeHTTP handles POST /api/v1/features.
+func (h *FeatureHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	log := util.Log(ctx)
+
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "method_not_allowed", "Only POST method is allowed")
+		return
+	}
+
+	// Get authenticated user
+	claims := httpauth.GetUserFromContext(ctx)
+	userID := ""
+	if claims != nil {
+		userID, _ = claims.GetSubject()
+	}
+
+	log.Info("feature request received",
+		"user_id", userID,
+		"path", r.URL.Path,
+	)
+
+	if h.cfg.MaxSpecificationSize > 0 {
+		r.Body = http.MaxBytesReader(w, r.Body, int64(h.cfg.MaxSpecificationSize))
+	}
+	var request FeatureRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			writeError(w, http.StatusRequestEntityTooLarge, "request_too_large",
+				fmt.Sprintf("Feature request exceeds %d bytes", maxBytesErr.Limit))
+			return
+		}
+		writeError(w, http.StatusBadRequest, "invalid_request", "Request body must be a JSON feature request")
+		return
+	}
+	if err := request.Validate(h.cfg.AllowedRepositoryHosts); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_request", err.Error())
+		return
+	}
+
+	payload := request.initialization(userID)
+	queueName := h.router.Route(routing.Priority(request.Priority), request.Category)
+	if err := h.publish(ctx, queueName, payload); err != nil {
+		log.WithError(err).Error("failed to publish feature request", "queue", queueName)
+		writeError(w, http.StatusBadGateway, "queue_unavailable", "Feature request could not be queued")
+		return
+	}
+
+	log.Info("published feature request",
+		"execution_id", payload.ExecutionID.String(),
+		"user_id", userID,
+		"queue", queueName,
+	)
+
+	writeJSON(w, http.StatusAccepted, map[string]string{
+		"status":       "accepted",
+		"execution_id": payload.ExecutionID.String(),
+		"message":      "Feature request queued",
+	})
+}
+
+// initialization builds the execution initialization requested by userID.
+func (r *FeatureRequest) initialization(userID string) *events.FeatureExecutionInitializedPayload {
+	return &events.FeatureExecutionInitializedPayload{
+		ExecutionID: events.NewExecutionID(),
+		Spec: events.FeatureSpecification{
+			Title:              strings.TrimSpace(r.Title),
+			Description:        strings.TrimSpace(r.Description),
+			AcceptanceCriteria: r.AcceptanceCriteria,
+			PathHints:          r.PathHints,
+		},
+		Repository: events.RepositoryContext{
+			RemoteURL:    strings.TrimSpace(r.RepositoryURL),
+			TargetBranch: strings.TrimSpace(r.TargetBranch),
+		},
+		Request: events.RequestMetadata{
+			RequestedBy:   userID,
+			RequestedAt:   time.Now(),
+			RequestSource: featureRequestSource,
+			Priority:      eventPriority(routing.Priority(r.Priority)),
+		},
+	}
+}
+
+func (h *FeatureHandler) publish(
+	ctx context.Context,
+	queueName string,
+	payload *events.FeatureExecutionInitializedPayload,
+) error {
+	data, marshalErr := json.Marshal(payload)
+	if marshalErr != nil {
+		return fmt.Errorf("marshal feature request: %w", marshalErr)
+	}
+
+	publisher, pubErr := h.queue.GetPublisher(queueName)
+	if pubErr != nil {
+		return fmt.Errorf("get publisher %s: %w", queueName, pubErr)
+	}
+
+	if publishErr := publisher.Publish(ctx, data); publishErr != nil {
+		return fmt.Errorf("publish feature request: %w", publishErr)
+	}
+	return nil
+}
+
+// eventPriority converts a requested priority to an event priority.
+func eventPriority(priority routing.Priority) events.Priority {
+	switch routing.Priority(strings.ToLower(strings.TrimSpace(string(priority)))) {
+	case routing.PriorityLow:
+		return events.PriorityLow
+	case routing.PriorityNormal:
+		return events.PriorityNormal
+	case routing.PriorityHigh:
+		return events.PriorityHigh
+	case routing.PriorityCritical:
+		return events.PriorityCritical
+	default:
+		return events.PriorityUnspecified
+	}
+}
+
+// writeError writes an error response in the gateway's error format.
+func writeError(w http.ResponseWriter, status int, code, message string) {
+	writeJSON(w, status, map[string]string{
+		"error":   code,
+		"message": message,
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
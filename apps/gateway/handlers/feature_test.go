@@ -0,0 +1,192 @@
+package handlers_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/pitabwire/frame/queue"
+	"github.com/pitabwire/frame/security"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	appconfig "github.com/antinvestor/builder/apps/gateway/config"
+	"github.com/antinvestor/builder/apps/gateway/handlers"
+	"github.com/antinvestor/builder/apps/gateway/routing"
+	"github.com/antinvestor/builder/internal/events"
+)
+
+// recordingQueue records the payloads published to each queue, or fails every
+// publish with publishErr.
+type recordingQueue struct {
+	queue.Manager
+
+	publishErr error
+	published  map[string][][]byte
+}
+
+type recordingPublisher struct {
+	queue.Publisher
+
+	name  string
+	queue *recordingQueue
+}
+
+func (q *recordingQueue) GetPublisher(reference string) (queue.Publisher, error) {
+	return &recordingPublisher{name: reference, queue: q}, nil
+}
+
+func (p *recordingPublisher) Publish(_ context.Context, payload any, _ ...map[string]string) error {
+	if p.queue.publishErr != nil {
+		return p.queue.publishErr
+	}
+	data, ok := payload.([]byte)
+	if !ok {
+		return nil
+	}
+	p.queue.published[p.name] = append(p.queue.published[p.name], data)
+	return nil
+}
+
+func newFeatureHandler(qMan *recordingQueue) *handlers.FeatureHandler {
+	cfg := &appconfig.GatewayConfig{
+		QueueFeatureRequestName:         "feature.requests",
+		QueuePriorityFeatureRequestName: "feature.requests.priority",
+		PriorityQueueCategories:         "bugfix",
+		MaxSpecificationSize:            4096,
+		AllowedRepositoryHosts:          "github.com",
+	}
+	return handlers.NewFeatureHandler(cfg, qMan, routing.NewQueueRouter(cfg))
+}
+
+func submitFeature(handler http.Handler, userID string, body string) *httptest.ResponseRecorder {
+	claims := &security.AuthenticationClaims{}
+	claims.Subject = userID
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/features", strings.NewReader(body))
+	req = req.WithContext(claims.ClaimsToContext(req.Context()))
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec
+}
+
+const validFeature = `{
+	"title": "Order export",
+	"description": "Export orders as CSV",
+	"acceptance_criteria": ["Include totals"],
+	"repository_url": "https://github.com/example/orders.git",
+	"target_branch": "develop",
+	"path_hints": ["internal/orders"]
+}`
+
+func TestFeatureHandler_PublishesInitialization(t *testing.T) {
+	qMan := &recordingQueue{published: map[string][][]byte{}}
+
+	rec := submitFeature(newFeatureHandler(qMan), "user-1", validFeature)
+
+	require.Equal(t, http.StatusAccepted, rec.Code)
+	var response map[string]string
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+	assert.Equal(t, "accepted", response["status"])
+
+	require.Len(t, qMan.published["feature.requests"], 1)
+	var published events.FeatureExecutionInitializedPayload
+	require.NoError(t, json.Unmarshal(qMan.published["feature.requests"][0], &published))
+	assert.Equal(t, response["execution_id"], published.ExecutionID.String())
+	assert.Equal(t, events.FeatureSpecification{
+		Title:              "Order export",
+		Description:        "Export orders as CSV",
+		AcceptanceCriteria: []string{"Include totals"},
+		PathHints:          []string{"internal/orders"},
+	}, published.Spec)
+	assert.Equal(t, "https://github.com/example/orders.git", published.Repository.RemoteURL)
+	assert.Equal(t, "develop", published.Repository.TargetBranch)
+	assert.Equal(t, "user-1", published.Request.RequestedBy)
+	assert.Equal(t, "api", published.Request.RequestSource)
+}
+
+func TestFeatureHandler_RoutesElevatedRequestsToPriorityQueue(t *testing.T) {
+	qMan := &recordingQueue{published: map[string][][]byte{}}
+
+	body := strings.Replace(validFeature, `"title"`, `"priority": "high", "title"`, 1)
+	rec := submitFeature(newFeatureHandler(qMan), "user-1", body)
+
+	require.Equal(t, http.StatusAccepted, rec.Code)
+	require.Len(t, qMan.published["feature.requests.priority"], 1)
+	var published events.FeatureExecutionInitializedPayload
+	require.NoError(t, json.Unmarshal(qMan.published["feature.requests.priority"][0], &published))
+	assert.Equal(t, events.PriorityHigh, published.Request.Priority)
+}
+
+func TestFeatureHandler_RejectsInvalidRequests(t *testing.T) {
+	tests := []struct {
+		name        string
+		body        string
+		wantStatus  int
+		wantMessage string
+	}{
+		{
+			name:        "missing title",
+			body:        strings.Replace(validFeature, `"Order export"`, `""`, 1),
+			wantStatus:  http.StatusBadRequest,
+			wantMessage: "title is required",
+		},
+		{
+			name:        "missing repository",
+			body:        `{"title": "Order export", "description": "Export orders as CSV"}`,
+			wantStatus:  http.StatusBadRequest,
+			wantMessage: "repository_url is required",
+		},
+		{
+			name:        "repository host not allowed",
+			body:        strings.Replace(validFeature, "github.com", "git.example.com", 1),
+			wantStatus:  http.StatusBadRequest,
+			wantMessage: "git.example.com is not allowed",
+		},
+		{
+			name:        "malformed JSON",
+			body:        `{"title":`,
+			wantStatus:  http.StatusBadRequest,
+			wantMessage: "must be a JSON feature request",
+		},
+		{
+			name:        "too large",
+			body:        strings.Replace(validFeature, "Export orders as CSV", strings.Repeat("a", 5000), 1),
+			wantStatus:  http.StatusRequestEntityTooLarge,
+			wantMessage: "exceeds 4096 bytes",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			qMan := &recordingQueue{published: map[string][][]byte{}}
+
+			rec := submitFeature(newFeatureHandler(qMan), "user-1", tt.body)
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			assert.Contains(t, rec.Body.String(), tt.wantMessage)
+			assert.Empty(t, qMan.published)
+		})
+	}
+}
+
+func TestFeatureHandler_PublishFailureReturnsBadGateway(t *testing.T) {
+	qMan := &recordingQueue{publishErr: errors.New("queue unavailable"), published: map[string][][]byte{}}
+
+	rec := submitFeature(newFeatureHandler(qMan), "user-1", validFeature)
+
+	assert.Equal(t, http.StatusBadGateway, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"error":"queue_unavailable"`)
+}
+
+func TestFeatureHandler_OnlyAcceptsPost(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/features", nil)
+	rec := httptest.NewRecorder()
+	newFeatureHandler(&recordingQueue{}).ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+}
@@ -0,0 +1,208 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/pitabwire/util"
+
+	appconfig "github.com/antinvestor/builder/apps/gateway/config"
+	"github.com/antinvestor/builder/internal/events"
+	"github.com/antinvestor/builder/internal/executions"
+	"github.com/antinvestor/builder/internal/httpauth"
+)
+
+// eventStreamType is the media type clients accept to follow status transitions.
+const eventStreamType = "text/event-stream"
+
+// defaultStatusPollInterval is how often a status stream re-reads the execution
+// when no interval is configured.
+const defaultStatusPollInterval = 2 * time.Second
+
+// ExecutionReader reads execution state; the shared execution repository, which
+// the worker writes, satisfies it.
+type ExecutionReader interface {
+	GetByID(ctx context.Context, id string) (*executions.Execution, error)
+}
+
+// FeatureStatus is the progress of a submitted feature.
+type FeatureStatus struct {
+	ExecutionID  string    `json:"execution_id"`
+	Status       string    `json:"status"`
+	Phase        string    `json:"phase,omitempty"`
+	LastEvent    string    `json:"last_event,omitempty"`
+	TargetBranch string    `json:"target_branch,omitempty"`
+	BranchName   string    `json:"branch_name,omitempty"`
+	Error        string    `json:"error,omitempty"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// StatusHandler serves the progress of the features a user submitted.
+type StatusHandler struct {
+	executions   ExecutionReader
+	pollInterval time.Duration
+}
+
+// NewStatusHandler creates a new status handler.
+func NewStatusHandler(cfg *appconfig.GatewayConfig, reader ExecutionReader) *StatusHandler {
+	pollInterval := time.Duration(cfg.StatusStreamPollIntervalMs) * time.Millisecond
+	if pollInterval <= 0 {
+		pollInterval = defaultStatusPollInterval
+	}
+	return &StatusHandler{
+		executions:   reader,
+		pollInterval: pollInterval,
+	}
+}
+
+// ServeHTTP handles GET /api/v1/features/{id}. Clients accepting text/event-stream
+// receive each status transition until the execution finishes.
+func (h *StatusHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method_not_allowed", "Only GET method is allowed")
+		return
+	}
+
+	executionID, err := events.ParseExecutionID(r.PathValue("id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_request", "Invalid feature id")
+		return
+	}
+
+	execution, ok := h.load(ctx, w, executionID.String())
+	if !ok {
+		return
+	}
+
+	// Get authenticated user
+	claims := httpauth.GetUserFromContext(ctx)
+	userID := ""
+	if claims != nil {
+		userID, _ = claims.GetSubject()
+	}
+	if !isRequester(execution, userID) {
+		util.Log(ctx).Warn("feature status denied",
+			"user_id", userID,
+			"execution_id", execution.ID,
+		)
+		writeError(w, http.StatusForbidden, "forbidden", "Feature was requested by another user")
+		return
+	}
+
+	if strings.Contains(r.Header.Get("Accept"), eventStreamType) {
+		h.stream(ctx, w, execution)
+		return
+	}
+	writeJSON(w, http.StatusOK, featureStatus(execution))
+}
+
+// load reads an execution, writing the error response when it cannot.
+func (h *StatusHandler) load(ctx context.Context, w http.ResponseWriter, id string) (*executions.Execution, bool) {
+	execution, err := h.executions.GetByID(ctx, id)
+	switch {
+	case errors.Is(err, executions.ErrExecutionNotFound):
+		writeError(w, http.StatusNotFound, "not_found", "Feature not found")
+		return nil, false
+	case err != nil:
+		util.Log(ctx).WithError(err).Error("failed to load execution", "execution_id", id)
+		writeError(w, http.StatusInternalServerError, "internal_error", "Feature status could not be loaded")
+		return nil, false
+	}
+	return execution, true
+}
+
+// stream writes a server-sent "status" event whenever the execution changes,
+// until it finishes or the client goes away.
+func (h *StatusHandler) stream(ctx context.Context, w http.ResponseWriter, execution *executions.Execution) {
+	log := util.Log(ctx)
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusNotAcceptable, "not_acceptable", "Streaming is not supported")
+		return
+	}
+
+	w.Header().Set("Content-Type", eventStreamType)
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+
+	ticker := time.NewTicker(h.pollInterval)
+	defer ticker.Stop()
+
+	id := execution.ID
+	var last []byte
+	for {
+		data, err := json.Marshal(featureStatus(execution))
+		if err != nil {
+			log.WithError(err).Error("failed to encode feature status")
+			return
+		}
+		if !bytes.Equal(data, last) {
+			if _, err = fmt.Fprintf(w, "event: status\ndata: %s\n\n", data); err != nil {
+				return
+			}
+			flusher.Flush()
+			last = data
+		}
+		if isFinished(execution.Status) {
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		execution, err = h.executions.GetByID(ctx, id)
+		if err != nil {
+			if ctx.Err() == nil {
+				log.WithError(err).Error("failed to reload execution", "execution_id", id)
+			}
+			return
+		}
+	}
+}
+
+// isRequester reports whether userID requested the execution, first or as a
+// duplicate that was attached to it.
+func isRequester(execution *executions.Execution, userID string) bool {
+	if userID == "" {
+		return false
+	}
+	return execution.RequestedBy == userID || slices.Contains(execution.Requesters, userID)
+}
+
+// isFinished reports whether an execution status is final.
+func isFinished(status executions.ExecutionStatus) bool {
+	switch status {
+	case executions.ExecutionStatusCompleted, executions.ExecutionStatusFailed, executions.ExecutionStatusAborted:
+		return true
+	case executions.ExecutionStatusPending, executions.ExecutionStatusRunning:
+		return false
+	default:
+		return false
+	}
+}
+
+func featureStatus(execution *executions.Execution) FeatureStatus {
+	return FeatureStatus{
+		ExecutionID:  execution.ID,
+		Status:       string(execution.Status),
+		Phase:        execution.Phase,
+		LastEvent:    execution.LastEvent,
+		TargetBranch: execution.Branch,
+		BranchName:   execution.FeatureBranch,
+		Error:        execution.ErrorMessage,
+		UpdatedAt:    execution.UpdatedAt,
+	}
+}
@@ -0,0 +1,127 @@
+package handlers_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/pitabwire/frame/security"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	appconfig "github.com/antinvestor/builder/apps/gateway/config"
+	"github.com/antinvestor/builder/apps/gateway/handlers"
+	"github.com/antinvestor/builder/internal/events"
+	"github.com/antinvestor/builder/internal/executions"
+)
+
+func newStoredFeature(t *testing.T, repo executions.ExecutionRepository, requestedBy string) string {
+	t.Helper()
+	id := events.NewExecutionID().String()
+	require.NoError(t, repo.Create(context.Background(), &executions.Execution{
+		ID:            id,
+		Branch:        "main",
+		Status:        executions.ExecutionStatusRunning,
+		RequestedBy:   requestedBy,
+		Requesters:    []string{"user-3"},
+		Phase:         string(events.ExecutionPhaseGeneration),
+		LastEvent:     string(events.PatchGenerationCompleted),
+		FeatureBranch: "feature/order-export",
+	}))
+	return id
+}
+
+func getStatus(handler http.Handler, userID, id, accept string) *httptest.ResponseRecorder {
+	claims := &security.AuthenticationClaims{}
+	claims.Subject = userID
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/features/"+id, nil)
+	req = req.WithContext(claims.ClaimsToContext(req.Context()))
+	req.SetPathValue("id", id)
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestStatusHandler_OwnerReadsStatus(t *testing.T) {
+	repo := executions.NewMemoryExecutionRepository()
+	id := newStoredFeature(t, repo, "user-1")
+	handler := handlers.NewStatusHandler(&appconfig.GatewayConfig{}, repo)
+
+	for _, userID := range []string{"user-1", "user-3"} {
+		rec := getStatus(handler, userID, id, "")
+
+		require.Equal(t, http.StatusOK, rec.Code, userID)
+		var status handlers.FeatureStatus
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &status))
+		assert.Equal(t, id, status.ExecutionID)
+		assert.Equal(t, "running", status.Status)
+		assert.Equal(t, string(events.ExecutionPhaseGeneration), status.Phase)
+		assert.Equal(t, string(events.PatchGenerationCompleted), status.LastEvent)
+		assert.Equal(t, "feature/order-export", status.BranchName)
+	}
+}
+
+func TestStatusHandler_OtherUserDenied(t *testing.T) {
+	repo := executions.NewMemoryExecutionRepository()
+	id := newStoredFeature(t, repo, "user-1")
+
+	rec := getStatus(handlers.NewStatusHandler(&appconfig.GatewayConfig{}, repo), "user-2", id, "")
+
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	assert.NotContains(t, rec.Body.String(), "feature/order-export")
+}
+
+func TestStatusHandler_UnknownFeature(t *testing.T) {
+	handler := handlers.NewStatusHandler(&appconfig.GatewayConfig{}, executions.NewMemoryExecutionRepository())
+
+	assert.Equal(t, http.StatusNotFound, getStatus(handler, "user-1", events.NewExecutionID().String(), "").Code)
+	assert.Equal(t, http.StatusBadRequest, getStatus(handler, "user-1", "not-an-id", "").Code)
+}
+
+// advancingReader moves the execution on to the next status on every read.
+type advancingReader struct {
+	execution *executions.Execution
+	statuses  []executions.ExecutionStatus
+}
+
+func (r *advancingReader) GetByID(_ context.Context, _ string) (*executions.Execution, error) {
+	execution := *r.execution
+	if len(r.statuses) > 0 {
+		execution.Status, r.statuses = r.statuses[0], r.statuses[1:]
+	}
+	return &execution, nil
+}
+
+func TestStatusHandler_StreamsTransitions(t *testing.T) {
+	reader := &advancingReader{
+		execution: &executions.Execution{ID: events.NewExecutionID().String(), RequestedBy: "user-1"},
+		statuses: []executions.ExecutionStatus{
+			executions.ExecutionStatusPending,
+			executions.ExecutionStatusRunning,
+			executions.ExecutionStatusRunning,
+			executions.ExecutionStatusCompleted,
+		},
+	}
+	handler := handlers.NewStatusHandler(&appconfig.GatewayConfig{StatusStreamPollIntervalMs: 1}, reader)
+
+	rec := getStatus(handler, "user-1", reader.execution.ID, "text/event-stream")
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "text/event-stream", rec.Header().Get("Content-Type"))
+	var statuses []string
+	for block := range strings.SplitSeq(strings.TrimSpace(rec.Body.String()), "\n\n") {
+		data, ok := strings.CutPrefix(block, "event: status\ndata: ")
+		require.True(t, ok, block)
+		var status handlers.FeatureStatus
+		require.NoError(t, json.Unmarshal([]byte(data), &status))
+		statuses = append(statuses, status.Status)
+	}
+	assert.Equal(t, []string{"pending", "running", "completed"}, statuses)
+}
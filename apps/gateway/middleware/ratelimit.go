@@ -1,16 +1,18 @@
 package middleware
 
 import (
+	"context"
 	"encoding/json"
+	"math"
 	"net"
 	"net/http"
 	"strconv"
 	"strings"
-	"sync"
 	"time"
 
 	"github.com/pitabwire/util"
-	"golang.org/x/time/rate"
+
+	"github.com/antinvestor/builder/internal/httpauth"
 )
 
 const (
@@ -19,109 +21,121 @@ const (
 	apiKeyHeader       = "X-Api-Key" //nolint:gosec // This is a header name, not a credential
 	xForwardedForHdr   = "X-Forwarded-For"
 	staleClientMinutes = 10
+	// maxRetryAfter caps the advertised retry delay, e.g. when the rate is zero.
+	maxRetryAfter = time.Hour
+	// unavailableRetryAfter is the retry delay advertised while the store is unavailable.
+	unavailableRetryAfter = 1
 )
 
-// RateLimiter is a token bucket rate limiter that tracks clients by IP.
+// Quota headers set on every rate-limited response.
+const (
+	rateLimitLimitHdr     = "X-RateLimit-Limit"
+	rateLimitRemainingHdr = "X-RateLimit-Remaining"
+	rateLimitResetHdr     = "X-RateLimit-Reset"
+)
+
+// RateLimiter is a token bucket rate limiter that tracks clients by user,
+// API key or IP, keeping their buckets in a RateLimitStore.
 type RateLimiter struct {
-	clients     map[string]*clientLimiter
-	mu          sync.RWMutex
-	ratePerMin  int
-	burstSize   int
-	cleanupTick time.Duration
-	stopCleanup chan struct{}
+	store      RateLimitStore
+	ratePerMin int
+	burstSize  int
+	clientKey  func(*http.Request) string
+	failOpen   bool
 }
 
-// clientLimiter tracks a client's rate limiter and last access time.
-type clientLimiter struct {
-	limiter    *rate.Limiter
-	lastAccess time.Time
+// NewRateLimiter creates a new rate limiter that keeps its buckets in memory.
+func NewRateLimiter(requestsPerMinute, burstSize int) *RateLimiter {
+	return NewRateLimiterWithStore(requestsPerMinute, burstSize, NewMemoryRateLimitStore())
 }
 
-// NewRateLimiter creates a new rate limiter.
-func NewRateLimiter(requestsPerMinute, burstSize int) *RateLimiter {
-	rl := &RateLimiter{
-		clients:     make(map[string]*clientLimiter),
-		ratePerMin:  requestsPerMinute,
-		burstSize:   burstSize,
-		cleanupTick: cleanupInterval,
-		stopCleanup: make(chan struct{}),
+// NewRateLimiterWithStore creates a new rate limiter that keeps its buckets in store.
+func NewRateLimiterWithStore(requestsPerMinute, burstSize int, store RateLimitStore) *RateLimiter {
+	return &RateLimiter{
+		store:      store,
+		ratePerMin: requestsPerMinute,
+		burstSize:  burstSize,
+		clientKey:  getClientID,
 	}
+}
 
-	// Start cleanup goroutine to remove stale entries
-	go rl.cleanupLoop()
-
+// NewIPRateLimiterWithStore creates a rate limiter that tracks clients by the
+// remote address of their connection only, for use in front of authentication.
+// X-Forwarded-For is ignored, since an unauthenticated client could send a new
+// value with every request. Its buckets are kept apart from those of a limiter
+// over the same store.
+func NewIPRateLimiterWithStore(requestsPerMinute, burstSize int, store RateLimitStore) *RateLimiter {
+	rl := NewRateLimiterWithStore(requestsPerMinute, burstSize, store)
+	rl.clientKey = func(r *http.Request) string {
+		return "preauth:" + getRemoteIP(r)
+	}
 	return rl
 }
 
-// Stop stops the rate limiter's cleanup goroutine.
-func (rl *RateLimiter) Stop() {
-	close(rl.stopCleanup)
+// SetFailOpen allows requests while the store is unavailable instead of rejecting them.
+func (rl *RateLimiter) SetFailOpen(failOpen bool) {
+	rl.failOpen = failOpen
 }
 
-// getClientLimiter retrieves or creates a rate limiter for a client.
-func (rl *RateLimiter) getClientLimiter(clientID string) *rate.Limiter {
-	rl.mu.Lock()
-	defer rl.mu.Unlock()
-
-	if client, exists := rl.clients[clientID]; exists {
-		client.lastAccess = time.Now()
-		return client.limiter
-	}
+// Stop releases the rate limiter's store.
+func (rl *RateLimiter) Stop() {
+	_ = rl.store.Close()
+}
 
+// limit returns the token bucket applied to each client.
+func (rl *RateLimiter) limit() RateLimit {
 	// Calculate rate as requests per second
-	ratePerSec := float64(rl.ratePerMin) / secondsPerMinute
-	limiter := rate.NewLimiter(rate.Limit(ratePerSec), rl.burstSize)
-
-	rl.clients[clientID] = &clientLimiter{
-		limiter:    limiter,
-		lastAccess: time.Now(),
+	return RateLimit{
+		PerSecond: float64(rl.ratePerMin) / secondsPerMinute,
+		Burst:     rl.burstSize,
 	}
-
-	return limiter
 }
 
-// cleanupLoop periodically removes stale client limiters.
-func (rl *RateLimiter) cleanupLoop() {
-	ticker := time.NewTicker(rl.cleanupTick)
-	defer ticker.Stop()
-
-	for {
-		select {
-		case <-ticker.C:
-			rl.cleanup()
-		case <-rl.stopCleanup:
-			return
-		}
+// take takes a token for a client. When the store fails the error is returned
+// and the request rejected, unless the limiter fails open.
+func (rl *RateLimiter) take(ctx context.Context, clientID string) (RateLimitDecision, error) {
+	decision, err := rl.store.Take(ctx, clientID, rl.limit())
+	if err == nil {
+		return decision, nil
 	}
-}
-
-// cleanup removes client limiters that haven't been accessed recently.
-func (rl *RateLimiter) cleanup() {
-	rl.mu.Lock()
-	defer rl.mu.Unlock()
-
-	staleThreshold := time.Now().Add(-staleClientMinutes * time.Minute)
-	for clientID, client := range rl.clients {
-		if client.lastAccess.Before(staleThreshold) {
-			delete(rl.clients, clientID)
-		}
+	if rl.failOpen {
+		util.Log(ctx).WithError(err).Warn("rate limit store unavailable, allowing request",
+			"client_id", clientID,
+		)
+		return RateLimitDecision{Allowed: true}, nil
 	}
+	util.Log(ctx).WithError(err).Error("rate limit store unavailable, rejecting request",
+		"client_id", clientID,
+	)
+	return RateLimitDecision{}, err
 }
 
 // Allow checks if a request from the given client is allowed.
 func (rl *RateLimiter) Allow(clientID string) bool {
-	limiter := rl.getClientLimiter(clientID)
-	return limiter.Allow()
+	decision, err := rl.take(context.Background(), clientID)
+	return err == nil && decision.Allowed
 }
 
 // getClientID extracts a unique identifier for the client.
-// Uses X-Forwarded-For if behind a proxy, otherwise the remote address.
+// Uses the authenticated user, then the API key, then X-Forwarded-For if behind
+// a proxy, otherwise the remote address.
 func getClientID(r *http.Request) string {
+	// Authenticated requests are limited per user, whichever replica serves them
+	if userID := httpauth.UserIDFromContext(r.Context()); userID != "" {
+		return "user:" + userID
+	}
+
 	// Check for X-Api-Key header first
 	if apiKey := r.Header.Get(apiKeyHeader); apiKey != "" {
 		return "apikey:" + apiKey
 	}
 
+	return getClientIP(r)
+}
+
+// getClientIP identifies the client by IP, from X-Forwarded-For if behind a
+// proxy, otherwise the remote address.
+func getClientIP(r *http.Request) string {
 	// Check for X-Forwarded-For (behind proxy/load balancer)
 	if xff := r.Header.Get(xForwardedForHdr); xff != "" {
 		// X-Forwarded-For can contain multiple IPs (client, proxy1, proxy2), use the first one
@@ -134,7 +148,11 @@ func getClientID(r *http.Request) string {
 		return "ip:" + firstIP
 	}
 
-	// Use remote address
+	return getRemoteIP(r)
+}
+
+// getRemoteIP identifies the client by the remote address of its connection.
+func getRemoteIP(r *http.Request) string {
 	host, _, err := net.SplitHostPort(r.RemoteAddr)
 	if err != nil {
 		return "ip:" + r.RemoteAddr
@@ -142,24 +160,20 @@ func getClientID(r *http.Request) string {
 	return "ip:" + host
 }
 
-// calculateRetryAfter calculates when the client can retry.
-func (rl *RateLimiter) calculateRetryAfter(clientID string) int {
-	rl.mu.RLock()
-	defer rl.mu.RUnlock()
-
-	client, exists := rl.clients[clientID]
-	if !exists {
-		return 1
-	}
-
-	reservation := client.limiter.Reserve()
-	delay := reservation.Delay()
-	reservation.Cancel()
+// writeHeaders tells the client its quota: the bucket size, the tokens left
+// after this request and the seconds until the bucket is full again.
+func (rl *RateLimiter) writeHeaders(w http.ResponseWriter, decision RateLimitDecision) {
+	w.Header().Set(rateLimitLimitHdr, strconv.Itoa(rl.burstSize))
+	w.Header().Set(rateLimitRemainingHdr, strconv.Itoa(decision.Remaining))
+	w.Header().Set(rateLimitResetHdr, strconv.Itoa(int(math.Ceil(decision.Reset.Seconds()))))
+}
 
+// retryAfterSeconds rounds a retry delay up to whole seconds, at least one.
+func retryAfterSeconds(delay time.Duration) int {
 	if delay <= 0 {
 		return 1
 	}
-	return int(delay.Seconds()) + 1
+	return int(min(delay, maxRetryAfter).Seconds()) + 1
 }
 
 // Middleware creates an HTTP middleware that applies rate limiting.
@@ -168,10 +182,23 @@ func (rl *RateLimiter) Middleware(next http.Handler) http.Handler {
 		ctx := r.Context()
 		log := util.Log(ctx)
 
-		clientID := getClientID(r)
+		clientID := rl.clientKey(r)
 
-		if !rl.Allow(clientID) {
-			retryAfter := rl.calculateRetryAfter(clientID)
+		decision, err := rl.take(ctx, clientID)
+		if err != nil {
+			w.Header().Set("Content-Type", "application/json")
+			w.Header().Set("Retry-After", strconv.Itoa(unavailableRetryAfter))
+			w.WriteHeader(http.StatusServiceUnavailable)
+			_ = json.NewEncoder(w).Encode(map[string]any{
+				"error":       "rate_limit_unavailable",
+				"message":     "Rate limiting is temporarily unavailable. Please retry shortly.",
+				"retry_after": unavailableRetryAfter,
+			})
+			return
+		}
+		rl.writeHeaders(w, decision)
+		if !decision.Allowed {
+			retryAfter := retryAfterSeconds(decision.RetryAfter)
 
 			log.Warn("rate limit exceeded",
 				"client_id", clientID,
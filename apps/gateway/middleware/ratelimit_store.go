@@ -0,0 +1,269 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"golang.org/x/time/rate"
+)
+
+// Rate limit store backends.
+const (
+	RateLimitBackendMemory = "memory"
+	RateLimitBackendRedis  = "redis"
+)
+
+// rateLimitKeyPrefix namespaces rate limit buckets in Redis.
+const rateLimitKeyPrefix = "ratelimit:"
+
+// RateLimit is the token bucket applied to each client.
+type RateLimit struct {
+	// PerSecond is the rate at which the bucket refills.
+	PerSecond float64
+	// Burst is the bucket capacity.
+	Burst int
+}
+
+// RateLimitDecision is the outcome of taking a token from a client's bucket.
+type RateLimitDecision struct {
+	Allowed bool
+	// Remaining is the number of whole tokens left after the decision.
+	Remaining int
+	// Reset is how long until the bucket is full again.
+	Reset time.Duration
+	// RetryAfter is how long until a token is available when not allowed.
+	RetryAfter time.Duration
+}
+
+// newRateLimitDecision describes a bucket holding tokens after a take.
+func newRateLimitDecision(allowed bool, tokens float64, limit RateLimit) RateLimitDecision {
+	decision := RateLimitDecision{
+		Allowed:   allowed,
+		Remaining: max(0, int(math.Floor(tokens))),
+	}
+	if limit.PerSecond <= 0 {
+		if !allowed {
+			decision.RetryAfter = time.Duration(math.MaxInt64)
+		}
+		return decision
+	}
+	decision.Reset = tokenWait(float64(limit.Burst)-tokens, limit.PerSecond)
+	if !allowed {
+		decision.RetryAfter = tokenWait(1-tokens, limit.PerSecond)
+	}
+	return decision
+}
+
+// tokenWait returns how long a bucket refilling at perSecond takes to gain tokens.
+func tokenWait(tokens, perSecond float64) time.Duration {
+	if tokens <= 0 {
+		return 0
+	}
+	return time.Duration(tokens / perSecond * float64(time.Second))
+}
+
+// RateLimitStore holds the token buckets of rate-limited clients. Limiters
+// sharing a store enforce a single quota per client.
+type RateLimitStore interface {
+	// Take removes a token from the client's bucket, creating it full when absent.
+	Take(ctx context.Context, clientID string, limit RateLimit) (RateLimitDecision, error)
+
+	// Close releases the store's resources.
+	Close() error
+}
+
+// NewRateLimitStore creates the store for a backend; redisURL is required for
+// the Redis backend.
+func NewRateLimitStore(ctx context.Context, backend, redisURL string) (RateLimitStore, error) {
+	switch backend {
+	case "", RateLimitBackendMemory:
+		return NewMemoryRateLimitStore(), nil
+	case RateLimitBackendRedis:
+		if redisURL == "" {
+			return nil, errors.New("redis URL required when using redis rate limit backend")
+		}
+		opts, err := redis.ParseURL(redisURL)
+		if err != nil {
+			return nil, fmt.Errorf("parse redis URL: %w", err)
+		}
+		client := redis.NewClient(opts)
+		if pingErr := client.Ping(ctx).Err(); pingErr != nil {
+			_ = client.Close()
+			return nil, fmt.Errorf("redis ping: %w", pingErr)
+		}
+		return NewRedisRateLimitStore(client), nil
+	default:
+		return nil, fmt.Errorf("unknown rate limit backend %q", backend)
+	}
+}
+
+// MemoryRateLimitStore keeps token buckets in process memory.
+type MemoryRateLimitStore struct {
+	clients     map[string]*clientLimiter
+	mu          sync.RWMutex
+	cleanupTick time.Duration
+	stopCleanup chan struct{}
+	stopOnce    sync.Once
+}
+
+// clientLimiter tracks a client's rate limiter and last access time.
+type clientLimiter struct {
+	limiter    *rate.Limiter
+	lastAccess time.Time
+}
+
+// NewMemoryRateLimitStore creates a new in-memory rate limit store.
+func NewMemoryRateLimitStore() *MemoryRateLimitStore {
+	s := &MemoryRateLimitStore{
+		clients:     make(map[string]*clientLimiter),
+		cleanupTick: cleanupInterval,
+		stopCleanup: make(chan struct{}),
+	}
+
+	// Start cleanup goroutine to remove stale entries
+	go s.cleanupLoop()
+
+	return s
+}
+
+// Take removes a token from the client's bucket.
+func (s *MemoryRateLimitStore) Take(_ context.Context, clientID string, limit RateLimit) (RateLimitDecision, error) {
+	limiter := s.getClientLimiter(clientID, limit)
+
+	now := time.Now()
+	allowed := limiter.AllowN(now, 1)
+	return newRateLimitDecision(allowed, limiter.TokensAt(now), limit), nil
+}
+
+// Close stops the store's cleanup goroutine.
+func (s *MemoryRateLimitStore) Close() error {
+	s.stopOnce.Do(func() { close(s.stopCleanup) })
+	return nil
+}
+
+// getClientLimiter retrieves or creates a rate limiter for a client.
+func (s *MemoryRateLimitStore) getClientLimiter(clientID string, limit RateLimit) *rate.Limiter {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if client, exists := s.clients[clientID]; exists {
+		client.lastAccess = time.Now()
+		return client.limiter
+	}
+
+	limiter := rate.NewLimiter(rate.Limit(limit.PerSecond), limit.Burst)
+	s.clients[clientID] = &clientLimiter{
+		limiter:    limiter,
+		lastAccess: time.Now(),
+	}
+
+	return limiter
+}
+
+// cleanupLoop periodically removes stale client limiters.
+func (s *MemoryRateLimitStore) cleanupLoop() {
+	ticker := time.NewTicker(s.cleanupTick)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			s.cleanup()
+		case <-s.stopCleanup:
+			return
+		}
+	}
+}
+
+// cleanup removes client limiters that haven't been accessed recently.
+func (s *MemoryRateLimitStore) cleanup() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	staleThreshold := time.Now().Add(-staleClientMinutes * time.Minute)
+	for clientID, client := range s.clients {
+		if client.lastAccess.Before(staleThreshold) {
+			delete(s.clients, clientID)
+		}
+	}
+}
+
+// takeTokenScript refills a bucket for the time elapsed since it was last
+// touched, by the Redis server clock so replicas agree, then takes a token.
+// It returns whether the token was taken and the tokens left, as a string to
+// keep the fraction.
+var takeTokenScript = redis.NewScript(`
+local rate = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local ttl = tonumber(ARGV[3])
+
+local time = redis.call("TIME")
+local now = tonumber(time[1]) * 1000000 + tonumber(time[2])
+
+local state = redis.call("HMGET", KEYS[1], "tokens", "ts")
+local tokens = tonumber(state[1])
+local ts = tonumber(state[2])
+if tokens == nil or ts == nil then
+	tokens = burst
+	ts = now
+end
+
+tokens = math.min(burst, tokens + math.max(0, now - ts) * rate / 1000000)
+local allowed = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+end
+
+redis.call("HSET", KEYS[1], "tokens", tostring(tokens), "ts", now)
+redis.call("PEXPIRE", KEYS[1], ttl)
+return {allowed, tostring(tokens)}
+`)
+
+// RedisRateLimitStore keeps token buckets in Redis so that gateway replicas
+// and restarts share each client's quota.
+type RedisRateLimitStore struct {
+	client *redis.Client
+}
+
+// NewRedisRateLimitStore creates a new Redis-backed rate limit store.
+func NewRedisRateLimitStore(client *redis.Client) *RedisRateLimitStore {
+	return &RedisRateLimitStore{client: client}
+}
+
+// Take removes a token from the client's bucket.
+func (s *RedisRateLimitStore) Take(ctx context.Context, clientID string, limit RateLimit) (RateLimitDecision, error) {
+	// A bucket idle for as long as it takes to refill is full, so it can expire
+	ttl := staleClientMinutes * time.Minute
+	if limit.PerSecond > 0 {
+		ttl = max(ttl, time.Duration(float64(limit.Burst)/limit.PerSecond*float64(time.Second)))
+	}
+
+	result, err := takeTokenScript.Run(ctx, s.client, []string{rateLimitKeyPrefix + clientID},
+		limit.PerSecond, limit.Burst, ttl.Milliseconds()).Slice()
+	if err != nil {
+		return RateLimitDecision{}, fmt.Errorf("take rate limit token: %w", err)
+	}
+	if len(result) != 2 { //nolint:mnd // the script returns {allowed, tokens}
+		return RateLimitDecision{}, fmt.Errorf("take rate limit token: unexpected result %v", result)
+	}
+
+	allowed, _ := result[0].(int64)
+	tokensText, _ := result[1].(string)
+	tokens, err := strconv.ParseFloat(tokensText, 64)
+	if err != nil {
+		return RateLimitDecision{}, fmt.Errorf("parse rate limit tokens: %w", err)
+	}
+	return newRateLimitDecision(allowed == 1, tokens, limit), nil
+}
+
+// Close closes the Redis client.
+func (s *RedisRateLimitStore) Close() error {
+	return s.client.Close()
+}
@@ -2,11 +2,16 @@
 package middleware
 
 import (
+	"context"
+	"errors"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"strconv"
 	"testing"
 	"time"
 
+	"github.com/pitabwire/frame/security"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -18,7 +23,7 @@ func TestNewRateLimiter(t *testing.T) {
 	assert.NotNil(t, rl)
 	assert.Equal(t, 60, rl.ratePerMin)
 	assert.Equal(t, 10, rl.burstSize)
-	assert.NotNil(t, rl.clients)
+	assert.IsType(t, &MemoryRateLimitStore{}, rl.store)
 }
 
 func TestRateLimiter_Allow(t *testing.T) {
@@ -202,55 +207,285 @@ func TestGetClientID(t *testing.T) {
 	}
 }
 
-func TestRateLimiter_Cleanup(t *testing.T) {
-	rl := NewRateLimiter(60, 10)
-	defer rl.Stop()
+func TestMemoryRateLimitStore_Cleanup(t *testing.T) {
+	store := NewMemoryRateLimitStore()
+	defer store.Close()
 
 	// Add a client
-	rl.Allow("test-client")
+	_, err := store.Take(context.Background(), "test-client", RateLimit{PerSecond: 1, Burst: 10})
+	require.NoError(t, err)
 
 	// Verify client exists
-	rl.mu.RLock()
-	_, exists := rl.clients["test-client"]
-	rl.mu.RUnlock()
+	store.mu.RLock()
+	_, exists := store.clients["test-client"]
+	store.mu.RUnlock()
 	require.True(t, exists)
 
 	// Set last access to old time
-	rl.mu.Lock()
-	if client, ok := rl.clients["test-client"]; ok {
+	store.mu.Lock()
+	if client, ok := store.clients["test-client"]; ok {
 		client.lastAccess = time.Now().Add(-15 * time.Minute)
 	}
-	rl.mu.Unlock()
+	store.mu.Unlock()
 
 	// Trigger cleanup
-	rl.cleanup()
+	store.cleanup()
 
 	// Verify client was removed
-	rl.mu.RLock()
-	_, exists = rl.clients["test-client"]
-	rl.mu.RUnlock()
+	store.mu.RLock()
+	_, exists = store.clients["test-client"]
+	store.mu.RUnlock()
 	assert.False(t, exists, "stale client should be removed")
 }
 
-func TestRateLimiter_CalculateRetryAfter(t *testing.T) {
-	rl := NewRateLimiter(60, 1)
-	defer rl.Stop()
+func TestMemoryRateLimitStore_RetryAfter(t *testing.T) {
+	store := NewMemoryRateLimitStore()
+	defer store.Close()
 
-	clientID := "test-client"
+	limit := RateLimit{PerSecond: 1, Burst: 1}
 
 	// Exhaust the burst
-	rl.Allow(clientID)
-	rl.Allow(clientID)
+	decision, err := store.Take(context.Background(), "test-client", limit)
+	require.NoError(t, err)
+	require.True(t, decision.Allowed)
+
+	decision, err = store.Take(context.Background(), "test-client", limit)
+	require.NoError(t, err)
+	assert.False(t, decision.Allowed)
+	assert.Greater(t, decision.RetryAfter, time.Duration(0))
+	assert.LessOrEqual(t, decision.RetryAfter, time.Second)
+}
 
-	retryAfter := rl.calculateRetryAfter(clientID)
-	assert.GreaterOrEqual(t, retryAfter, 1, "retry-after should be at least 1 second")
+func TestRetryAfterSeconds(t *testing.T) {
+	assert.Equal(t, 1, retryAfterSeconds(0), "retry-after should be at least 1 second")
+	assert.Equal(t, 1, retryAfterSeconds(500*time.Millisecond))
+	assert.Equal(t, 3, retryAfterSeconds(2*time.Second))
+	assert.Equal(t, 3601, retryAfterSeconds(time.Duration(1<<62)))
 }
 
-func TestRateLimiter_CalculateRetryAfter_UnknownClient(t *testing.T) {
-	rl := NewRateLimiter(60, 10)
+// testRateLimitStores returns the stores to run shared-quota tests against;
+// Redis is skipped when no server is reachable.
+func testRateLimitStores(t *testing.T) map[string]func(t *testing.T) RateLimitStore {
+	t.Helper()
+
+	return map[string]func(t *testing.T) RateLimitStore{
+		RateLimitBackendMemory: func(_ *testing.T) RateLimitStore {
+			return NewMemoryRateLimitStore()
+		},
+		RateLimitBackendRedis: func(t *testing.T) RateLimitStore {
+			url := os.Getenv("REDIS_URL")
+			if url == "" {
+				url = "redis://localhost:6379"
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+			defer cancel()
+
+			store, err := NewRateLimitStore(ctx, RateLimitBackendRedis, url)
+			if err != nil {
+				t.Skipf("redis not available: %v", err)
+			}
+			return store
+		},
+	}
+}
+
+func TestRateLimiter_SharedStoreEnforcesCombinedQuota(t *testing.T) {
+	for name, newStore := range testRateLimitStores(t) {
+		t.Run(name, func(t *testing.T) {
+			store := newStore(t)
+			defer store.Close()
+
+			// Two gateway replicas sharing one store
+			first := NewRateLimiterWithStore(1, 4, store)
+			second := NewRateLimiterWithStore(1, 4, store)
+
+			clientID := "user:shared-" + strconv.FormatInt(time.Now().UnixNano(), 10)
+			allowed := 0
+			for i := range 8 {
+				limiter := first
+				if i%2 == 1 {
+					limiter = second
+				}
+				if limiter.Allow(clientID) {
+					allowed++
+				}
+			}
+
+			assert.Equal(t, 4, allowed, "replicas should share a single burst")
+		})
+	}
+}
+
+func TestRateLimiter_RestartKeepsQuota(t *testing.T) {
+	store := NewMemoryRateLimitStore()
+	defer store.Close()
+
+	before := NewRateLimiterWithStore(60, 2, store)
+	assert.True(t, before.Allow("user:restart"))
+	assert.True(t, before.Allow("user:restart"))
+
+	// A new limiter over the same store continues the client's bucket
+	after := NewRateLimiterWithStore(60, 2, store)
+	assert.False(t, after.Allow("user:restart"))
+}
+
+// failingStore fails every take.
+type failingStore struct{}
+
+func (failingStore) Take(context.Context, string, RateLimit) (RateLimitDecision, error) {
+	return RateLimitDecision{}, errors.New("store unavailable")
+}
+
+func (failingStore) Close() error { return nil }
+
+func TestRateLimiter_RejectsWhenStoreFails(t *testing.T) {
+	rl := NewRateLimiterWithStore(60, 1, failingStore{})
 	defer rl.Stop()
 
-	// Unknown client should return 1
-	retryAfter := rl.calculateRetryAfter("unknown-client")
-	assert.Equal(t, 1, retryAfter)
+	assert.False(t, rl.Allow("client"))
+
+	handler := rl.Middleware(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/test", nil))
+
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	assert.Equal(t, "1", rec.Header().Get("Retry-After"))
+}
+
+func TestRateLimiter_AllowsWhenStoreFailsOpen(t *testing.T) {
+	rl := NewRateLimiterWithStore(60, 1, failingStore{})
+	rl.SetFailOpen(true)
+	defer rl.Stop()
+
+	for range 3 {
+		assert.True(t, rl.Allow("client"))
+	}
+}
+
+func TestIPRateLimiter_IgnoresAPIKeys(t *testing.T) {
+	rl := NewIPRateLimiterWithStore(60, 1, NewMemoryRateLimitStore())
+	defer rl.Stop()
+
+	handler := rl.Middleware(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	// Rotating API keys does not buy an unauthenticated client more requests
+	var codes []int
+	for _, key := range []string{"key-1", "key-2"} {
+		req := httptest.NewRequest(http.MethodGet, "/test", nil)
+		req.RemoteAddr = "203.0.113.7:1234"
+		req.Header.Set("X-Api-Key", key)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		codes = append(codes, rec.Code)
+	}
+	assert.Equal(t, []int{http.StatusOK, http.StatusTooManyRequests}, codes)
+}
+
+func TestIPRateLimiter_IgnoresForwardedFor(t *testing.T) {
+	rl := NewIPRateLimiterWithStore(60, 2, NewMemoryRateLimitStore())
+	defer rl.Stop()
+
+	handler := rl.Middleware(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	// Rotating X-Forwarded-For does not give one connection a fresh bucket
+	var codes []int
+	for _, forwarded := range []string{"10.0.0.1", "10.0.0.2", "10.0.0.3, 192.168.1.1"} {
+		req := httptest.NewRequest(http.MethodGet, "/test", nil)
+		req.RemoteAddr = "203.0.113.7:1234"
+		req.Header.Set("X-Forwarded-For", forwarded)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		codes = append(codes, rec.Code)
+	}
+	assert.Equal(t, []int{http.StatusOK, http.StatusOK, http.StatusTooManyRequests}, codes)
+}
+
+func TestGetClientID_AuthenticatedUser(t *testing.T) {
+	claims := &security.AuthenticationClaims{}
+	claims.Subject = "user-1"
+
+	req := httptest.NewRequest(http.MethodGet, "/test", nil)
+	req.Header.Set("X-Api-Key", "my-api-key")
+	req = req.WithContext(claims.ClaimsToContext(req.Context()))
+
+	assert.Equal(t, "user:user-1", getClientID(req))
+}
+
+func TestNewRateLimitStore_Backends(t *testing.T) {
+	store, err := NewRateLimitStore(context.Background(), RateLimitBackendMemory, "")
+	require.NoError(t, err)
+	defer store.Close()
+	assert.IsType(t, &MemoryRateLimitStore{}, store)
+
+	_, err = NewRateLimitStore(context.Background(), RateLimitBackendRedis, "")
+	require.Error(t, err)
+
+	_, err = NewRateLimitStore(context.Background(), "memcached", "")
+	require.Error(t, err)
+}
+
+func TestRateLimiter_MiddlewareSetsQuotaHeaders(t *testing.T) {
+	rl := NewRateLimiter(60, 3)
+	defer rl.Stop()
+
+	middleware := rl.Middleware(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	serve := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/test", nil)
+		req.RemoteAddr = "192.168.1.1:12345"
+		rr := httptest.NewRecorder()
+		middleware.ServeHTTP(rr, req)
+		return rr
+	}
+
+	// Remaining counts down with each request in the burst
+	for _, remaining := range []string{"2", "1", "0"} {
+		rr := serve()
+		require.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, "3", rr.Header().Get("X-RateLimit-Limit"))
+		assert.Equal(t, remaining, rr.Header().Get("X-RateLimit-Remaining"))
+		assert.NotEmpty(t, rr.Header().Get("X-RateLimit-Reset"))
+		assert.Empty(t, rr.Header().Get("Retry-After"))
+	}
+
+	rr := serve()
+	require.Equal(t, http.StatusTooManyRequests, rr.Code)
+	assert.Equal(t, "3", rr.Header().Get("X-RateLimit-Limit"))
+	assert.Equal(t, "0", rr.Header().Get("X-RateLimit-Remaining"))
+	assert.Equal(t, "3", rr.Header().Get("X-RateLimit-Reset"), "an empty bucket refills 3 tokens in 3 seconds")
+	assert.Equal(t, "1", rr.Header().Get("Retry-After"))
+}
+
+func TestRateLimiter_MiddlewareKeysQuotaHeadersByUser(t *testing.T) {
+	rl := NewRateLimiter(60, 2)
+	defer rl.Stop()
+
+	middleware := rl.Middleware(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	serve := func(userID string) *httptest.ResponseRecorder {
+		claims := &security.AuthenticationClaims{}
+		claims.Subject = userID
+
+		req := httptest.NewRequest(http.MethodGet, "/test", nil)
+		req.RemoteAddr = "192.168.1.1:12345"
+		req = req.WithContext(claims.ClaimsToContext(req.Context()))
+		rr := httptest.NewRecorder()
+		middleware.ServeHTTP(rr, req)
+		return rr
+	}
+
+	assert.Equal(t, "1", serve("user-1").Header().Get("X-RateLimit-Remaining"))
+	// Another user behind the same address has its own bucket
+	assert.Equal(t, "1", serve("user-2").Header().Get("X-RateLimit-Remaining"))
+	assert.Equal(t, "0", serve("user-1").Header().Get("X-RateLimit-Remaining"))
 }
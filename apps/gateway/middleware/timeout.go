@@ -0,0 +1,152 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/pitabwire/util"
+)
+
+// ErrRequestTimeout is the cancellation cause of a request context that exceeded
+// the gateway request timeout. Handlers can detect it with context.Cause.
+var ErrRequestTimeout = errors.New("request timeout exceeded")
+
+// TimeoutMiddleware bounds the total time a handler may take to respond.
+type TimeoutMiddleware struct {
+	timeout time.Duration
+}
+
+// NewTimeoutMiddleware creates a timeout middleware. A non-positive timeout disables it.
+func NewTimeoutMiddleware(timeout time.Duration) *TimeoutMiddleware {
+	return &TimeoutMiddleware{timeout: timeout}
+}
+
+// Middleware runs the next handler with a deadline. When the deadline passes, the
+// request context is cancelled with ErrRequestTimeout, so in-flight publishing is
+// abandoned, and the client receives a 504 response. Anything the handler writes
+// after the deadline is discarded.
+func (tm *TimeoutMiddleware) Middleware(next http.Handler) http.Handler {
+	if tm.timeout <= 0 {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeoutCause(r.Context(), tm.timeout, ErrRequestTimeout)
+		defer cancel()
+
+		tw := &timeoutWriter{header: make(http.Header)}
+		done := make(chan struct{})
+		panicCh := make(chan any, 1)
+
+		go func() {
+			defer func() {
+				if p := recover(); p != nil {
+					panicCh <- p
+				}
+			}()
+			next.ServeHTTP(tw, r.WithContext(ctx))
+			close(done)
+		}()
+
+		select {
+		case p := <-panicCh:
+			panic(p)
+
+		case <-done:
+			tw.flushTo(w)
+
+		case <-ctx.Done():
+			select {
+			case <-done:
+				// The handler finished just as the deadline passed; its response wins.
+				tw.flushTo(w)
+				return
+			default:
+			}
+			tw.expire()
+
+			util.Log(r.Context()).Warn("request timed out",
+				"path", r.URL.Path,
+				"timeout", tm.timeout.String(),
+			)
+
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusGatewayTimeout)
+			_ = json.NewEncoder(w).Encode(map[string]any{
+				"error":           "request_timeout",
+				"message":         "The request took too long to process. Please retry.",
+				"timeout_seconds": tm.timeout.Seconds(),
+			})
+		}
+	})
+}
+
+// timeoutWriter buffers a handler's response until it completes, so a response
+// can be replaced by a timeout error if the deadline passes first.
+type timeoutWriter struct {
+	mu          sync.Mutex
+	header      http.Header
+	buf         bytes.Buffer
+	code        int
+	wroteHeader bool
+	timedOut    bool
+}
+
+// Header implements http.ResponseWriter.
+func (tw *timeoutWriter) Header() http.Header {
+	return tw.header
+}
+
+// WriteHeader implements http.ResponseWriter.
+func (tw *timeoutWriter) WriteHeader(code int) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+
+	if tw.timedOut || tw.wroteHeader {
+		return
+	}
+	tw.wroteHeader = true
+	tw.code = code
+}
+
+// Write implements http.ResponseWriter.
+func (tw *timeoutWriter) Write(data []byte) (int, error) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+
+	if tw.timedOut {
+		return 0, http.ErrHandlerTimeout
+	}
+	if !tw.wroteHeader {
+		tw.wroteHeader = true
+		tw.code = http.StatusOK
+	}
+	return tw.buf.Write(data)
+}
+
+// expire marks the response as timed out so later handler writes are rejected.
+func (tw *timeoutWriter) expire() {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	tw.timedOut = true
+}
+
+// flushTo writes the buffered response to the client.
+func (tw *timeoutWriter) flushTo(w http.ResponseWriter) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+
+	for key, values := range tw.header {
+		w.Header()[key] = values
+	}
+	if !tw.wroteHeader {
+		tw.code = http.StatusOK
+	}
+	w.WriteHeader(tw.code)
+	_, _ = w.Write(tw.buf.Bytes())
+}
@@ -0,0 +1,110 @@
+//nolint:testpackage // Tests share the package with the middleware under test
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// slowPublisher simulates a downstream publish that only returns when its context ends.
+type slowPublisher struct {
+	cancelled chan error
+}
+
+func (p *slowPublisher) Publish(ctx context.Context) error {
+	<-ctx.Done()
+	p.cancelled <- context.Cause(ctx)
+	return ctx.Err()
+}
+
+func TestTimeoutMiddleware_SlowPublisherReturns504(t *testing.T) {
+	publisher := &slowPublisher{cancelled: make(chan error, 1)}
+	tm := NewTimeoutMiddleware(50 * time.Millisecond)
+
+	handler := tm.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := publisher.Publish(r.Context()); err != nil {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+		w.WriteHeader(http.StatusAccepted)
+	}))
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/features", nil)
+	rr := httptest.NewRecorder()
+
+	handler.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusGatewayTimeout, rr.Code)
+	assert.Equal(t, "application/json", rr.Header().Get("Content-Type"))
+
+	var body map[string]any
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &body))
+	assert.Equal(t, "request_timeout", body["error"])
+
+	select {
+	case cause := <-publisher.cancelled:
+		require.ErrorIs(t, cause, ErrRequestTimeout)
+	case <-time.After(time.Second):
+		t.Fatal("publish context was not cancelled")
+	}
+}
+
+func TestTimeoutMiddleware_FastHandlerPassesThrough(t *testing.T) {
+	tm := NewTimeoutMiddleware(time.Second)
+
+	handler := tm.Middleware(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("X-Execution-Id", "exec-123")
+		w.WriteHeader(http.StatusAccepted)
+		_, _ = w.Write([]byte(`{"status":"accepted"}`))
+	}))
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/features", nil)
+	rr := httptest.NewRecorder()
+
+	handler.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusAccepted, rr.Code)
+	assert.Equal(t, "exec-123", rr.Header().Get("X-Execution-Id"))
+	assert.JSONEq(t, `{"status":"accepted"}`, rr.Body.String())
+}
+
+func TestTimeoutMiddleware_DiscardsWritesAfterTimeout(t *testing.T) {
+	tm := NewTimeoutMiddleware(20 * time.Millisecond)
+	writeErr := make(chan error, 1)
+
+	handler := tm.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+		time.Sleep(10 * time.Millisecond)
+		_, err := w.Write([]byte("late"))
+		writeErr <- err
+	}))
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/features", nil)
+	rr := httptest.NewRecorder()
+
+	handler.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusGatewayTimeout, rr.Code)
+	assert.NotContains(t, rr.Body.String(), "late")
+	require.ErrorIs(t, <-writeErr, http.ErrHandlerTimeout)
+}
+
+func TestTimeoutMiddleware_DisabledWithZeroTimeout(t *testing.T) {
+	tm := NewTimeoutMiddleware(0)
+	next := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	handler := tm.Middleware(next)
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+}
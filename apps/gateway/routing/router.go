@@ -0,0 +1,76 @@
+// Package routing selects the queue a feature request is published to.
+package routing
+
+import (
+	"strings"
+
+	appconfig "github.com/antinvestor/builder/apps/gateway/config"
+)
+
+// Priority is the requested scheduling priority of a feature request.
+type Priority string
+
+// Supported priorities.
+const (
+	PriorityLow      Priority = "low"
+	PriorityNormal   Priority = "normal"
+	PriorityHigh     Priority = "high"
+	PriorityCritical Priority = "critical"
+)
+
+// IsElevated reports whether the priority qualifies for the fast lane.
+func (p Priority) IsElevated() bool {
+	switch Priority(strings.ToLower(strings.TrimSpace(string(p)))) {
+	case PriorityHigh, PriorityCritical:
+		return true
+	case PriorityLow, PriorityNormal:
+		return false
+	default:
+		return false
+	}
+}
+
+// QueueRouter maps a feature request's priority and category to a queue name.
+type QueueRouter struct {
+	defaultQueue       string
+	priorityQueue      string
+	priorityCategories map[string]bool
+}
+
+// NewQueueRouter creates a router from the gateway queue configuration.
+func NewQueueRouter(cfg *appconfig.GatewayConfig) *QueueRouter {
+	categories := make(map[string]bool)
+	for category := range strings.SplitSeq(cfg.PriorityQueueCategories, ",") {
+		if normalized := normalizeCategory(category); normalized != "" {
+			categories[normalized] = true
+		}
+	}
+
+	return &QueueRouter{
+		defaultQueue:       cfg.QueueFeatureRequestName,
+		priorityQueue:      cfg.QueuePriorityFeatureRequestName,
+		priorityCategories: categories,
+	}
+}
+
+// Route returns the name of the queue a request with the given priority and
+// category is published to. Elevated priorities and fast-lane categories go to
+// the priority queue; everything else goes to the default queue.
+func (r *QueueRouter) Route(priority Priority, category string) string {
+	if r.priorityQueue == "" {
+		return r.defaultQueue
+	}
+
+	if priority.IsElevated() || r.priorityCategories[normalizeCategory(category)] {
+		return r.priorityQueue
+	}
+
+	return r.defaultQueue
+}
+
+// normalizeCategory canonicalizes a category so "Bug-Fix" and "bugfix" match.
+func normalizeCategory(category string) string {
+	normalized := strings.ToLower(strings.TrimSpace(category))
+	normalized = strings.ReplaceAll(normalized, "-", "")
+	return strings.ReplaceAll(normalized, "_", "")
+}
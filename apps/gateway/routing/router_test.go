@@ -0,0 +1,62 @@
+//nolint:testpackage // Tests require access to internal router state
+package routing
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	appconfig "github.com/antinvestor/builder/apps/gateway/config"
+)
+
+func testConfig() *appconfig.GatewayConfig {
+	return &appconfig.GatewayConfig{
+		QueueFeatureRequestName:         "feature.requests",
+		QueuePriorityFeatureRequestName: "feature.requests.priority",
+		PriorityQueueCategories:         "bugfix, Hotfix",
+	}
+}
+
+func TestNewQueueRouter(t *testing.T) {
+	router := NewQueueRouter(testConfig())
+
+	require.NotNil(t, router)
+	assert.Equal(t, "feature.requests", router.defaultQueue)
+	assert.Equal(t, "feature.requests.priority", router.priorityQueue)
+	assert.True(t, router.priorityCategories["bugfix"])
+	assert.True(t, router.priorityCategories["hotfix"])
+}
+
+func TestQueueRouter_HighPriorityRoutesToPriorityQueue(t *testing.T) {
+	router := NewQueueRouter(testConfig())
+
+	assert.Equal(t, "feature.requests.priority", router.Route(PriorityHigh, "feature"))
+	assert.Equal(t, "feature.requests.priority", router.Route(PriorityCritical, ""))
+	assert.Equal(t, "feature.requests.priority", router.Route("HIGH", ""))
+}
+
+func TestQueueRouter_CategoryRoutesToPriorityQueue(t *testing.T) {
+	router := NewQueueRouter(testConfig())
+
+	assert.Equal(t, "feature.requests.priority", router.Route(PriorityNormal, "bugfix"))
+	assert.Equal(t, "feature.requests.priority", router.Route(PriorityLow, "Bug-Fix"))
+	assert.Equal(t, "feature.requests.priority", router.Route("", "hot_fix"))
+}
+
+func TestQueueRouter_DefaultQueue(t *testing.T) {
+	router := NewQueueRouter(testConfig())
+
+	assert.Equal(t, "feature.requests", router.Route(PriorityNormal, "feature"))
+	assert.Equal(t, "feature.requests", router.Route(PriorityLow, ""))
+	assert.Equal(t, "feature.requests", router.Route("", ""))
+	assert.Equal(t, "feature.requests", router.Route("unknown", "refactor"))
+}
+
+func TestQueueRouter_NoPriorityQueueConfigured(t *testing.T) {
+	cfg := testConfig()
+	cfg.QueuePriorityFeatureRequestName = ""
+	router := NewQueueRouter(cfg)
+
+	assert.Equal(t, "feature.requests", router.Route(PriorityHigh, "bugfix"))
+}
@@ -7,10 +7,13 @@ import (
 
 	"github.com/pitabwire/frame"
 	"github.com/pitabwire/frame/config"
+	"github.com/pitabwire/frame/datastore"
 	"github.com/pitabwire/util"
 
 	appconfig "github.com/antinvestor/builder/apps/reviewer/config"
 	"github.com/antinvestor/builder/apps/reviewer/service/review"
+	"github.com/antinvestor/builder/internal/executions"
+	"github.com/antinvestor/builder/internal/httpauth"
 )
 
 //nolint:funlen // service bootstrap requires setup of all components
@@ -28,11 +31,13 @@ func main() {
 		cfg.ServiceName = "feature_reviewer"
 	}
 
-	// Create service with Frame - minimal dependencies
-	ctx, svc := frame.NewServiceWithContext(
-		ctx,
-		frame.WithConfig(&cfg),
-	)
+	// Create service with Frame - minimal dependencies; the execution database is
+	// only read to enumerate the executions a kill switch affects
+	serviceOpts := []frame.Option{frame.WithConfig(&cfg)}
+	if cfg.KillSwitchTrackExecutions {
+		serviceOpts = append(serviceOpts, frame.WithDatastore())
+	}
+	ctx, svc := frame.NewServiceWithContext(ctx, serviceOpts...)
 	defer svc.Stop(ctx)
 	log := svc.Log(ctx)
 
@@ -44,16 +49,75 @@ func main() {
 	// Setup Review Components
 	// ==========================================================================
 
-	securityAnalyzer := review.NewPatternSecurityAnalyzer(&cfg)
+	securityAnalyzer, err := review.LoadPatternSecurityAnalyzer(ctx, &cfg)
+	if err != nil {
+		log.WithError(err).Fatal("could not load security patterns")
+	}
+	baselineStore := review.NewMemoryBaselineStore()
+	securityAnalyzer.SetBaselineStore(baselineStore)
+	var dependencyAnalyzer *review.DependencyAnalyzer
+	if !cfg.DisableDependency {
+		dependencyAnalyzer, err = review.NewDependencyAnalyzer(&cfg)
+		if err != nil {
+			log.WithError(err).Fatal("could not load dependency advisories")
+		}
+		securityAnalyzer.SetDependencyAnalyzer(dependencyAnalyzer)
+	}
+
+	// A candidate pattern set runs next to the stable one on a sampled fraction
+	// of executions before it replaces it
+	var reviewSecurity review.SecurityAnalyzer = securityAnalyzer
+	canaryStore := review.NewMemoryCanaryStore()
+	if cfg.CanaryPatternsPath != "" && cfg.AnalyzerCanaryFraction > 0 {
+		candidateAnalyzer, candidateErr := review.LoadPatternSecurityAnalyzer(ctx, &cfg)
+		if candidateErr == nil {
+			candidateErr = candidateAnalyzer.LoadPatterns(ctx, cfg.CanaryPatternsPath)
+		}
+		if candidateErr != nil {
+			log.WithError(candidateErr).Fatal("could not load canary security patterns")
+		}
+		candidateAnalyzer.SetBaselineStore(baselineStore)
+		if dependencyAnalyzer != nil {
+			candidateAnalyzer.SetDependencyAnalyzer(dependencyAnalyzer)
+		}
+		reviewSecurity = review.NewCanarySecurityAnalyzer(&cfg, securityAnalyzer, candidateAnalyzer, canaryStore)
+	}
+
 	architectureAnalyzer := review.NewPatternArchitectureAnalyzer(&cfg)
+	if cfg.LayerRulesPath != "" {
+		layerRules, rulesErr := review.LoadLayerRules(cfg.LayerRulesPath)
+		if rulesErr != nil {
+			log.WithError(rulesErr).Fatal("could not load layer rules")
+		}
+		architectureAnalyzer.SetLayerRules(layerRules)
+	}
 	decisionEngine := review.NewThresholdDecisionEngine(&cfg)
+	if cfg.ApprovalWindowsEnabled {
+		approvalWindowPolicy, windowErr := review.NewApprovalWindowPolicy(&cfg)
+		if windowErr != nil {
+			log.WithError(windowErr).Fatal("could not load approval windows")
+		}
+		decisionEngine.SetApprovalWindowPolicy(approvalWindowPolicy)
+	}
 	killSwitchService := review.NewPersistentKillSwitchService(&cfg, evtsMan)
+	killSwitchService.SetQueueManager(qMan)
+	if cfg.KillSwitchTrackExecutions {
+		dbPool := svc.DatastoreManager().GetPool(ctx, datastore.DefaultPoolName)
+		killSwitchService.SetExecutionLister(executions.NewExecutionRepository(ctx, dbPool))
+	}
+	if cfg.KillSwitchStatePath != "" {
+		if storeErr := killSwitchService.SetStore(ctx,
+			review.NewFileKillSwitchStore(cfg.KillSwitchStatePath)); storeErr != nil {
+			log.WithError(storeErr).Fatal("could not restore kill switch state")
+		}
+	}
 
-	_ = securityAnalyzer
-	_ = architectureAnalyzer
-	_ = decisionEngine
-	_ = killSwitchService
-	_ = qMan
+	// Hot-reload decision thresholds when a thresholds file is configured
+	if cfg.ThresholdsFilePath != "" {
+		thresholdsReloader := review.NewThresholdsReloader(&cfg)
+		thresholdsReloader.Start(ctx)
+		defer thresholdsReloader.Stop()
+	}
 
 	// ==========================================================================
 	// Register Publishers
@@ -73,17 +137,37 @@ func main() {
 	// Register Subscribers
 	// ==========================================================================
 
+	requestHandler := review.NewRequestHandler(
+		&cfg,
+		reviewSecurity,
+		architectureAnalyzer,
+		decisionEngine,
+		killSwitchService,
+		evtsMan,
+	)
+	requestHandler.SetQueueManager(qMan)
+
+	// Custom analyzers run alongside the built-in ones; register org-specific
+	// analyzers here with analyzerRegistry.Register
+	analyzerRegistry := review.NewDefaultAnalyzerRegistry(&cfg)
+	requestHandler.SetAnalyzerRegistry(analyzerRegistry)
+
+	// Shadow mode evaluates alternative thresholds without affecting decisions
+	shadowStore := review.NewMemoryShadowStore()
+	if cfg.ShadowModeEnabled {
+		shadowThresholds, shadowErr := review.LoadThresholdsFile(cfg.ShadowThresholdsFilePath)
+		if shadowErr != nil {
+			log.WithError(shadowErr).Error("shadow mode disabled: could not load shadow thresholds")
+		} else {
+			requestHandler.SetShadowEvaluator(review.NewShadowEvaluator(
+				review.NewThresholdDecisionEngine(&cfg), shadowThresholds, shadowStore))
+		}
+	}
+
 	reviewRequestSubscriber := frame.WithRegisterSubscriber(
 		cfg.QueueReviewRequestName,
 		cfg.QueueReviewRequestURI,
-		review.NewRequestHandler(
-			&cfg,
-			securityAnalyzer,
-			architectureAnalyzer,
-			decisionEngine,
-			killSwitchService,
-			evtsMan,
-		),
+		requestHandler,
 	)
 
 	// ==========================================================================
@@ -118,6 +202,24 @@ func main() {
 		}
 	})
 
+	// Kill switch activation and deactivation, restricted to authenticated callers.
+	authMiddleware := httpauth.NewAuthMiddleware(svc.SecurityManager().GetAuthenticator(ctx))
+	mux.Handle("/api/v1/killswitch", authMiddleware.Middleware(
+		review.NewKillSwitchHTTPHandler(killSwitchService)))
+
+	// Shadow decisions that diverged from the primary decision.
+	mux.Handle("/api/v1/shadow/divergences", authMiddleware.Middleware(
+		review.NewShadowDivergencesHTTPHandler(shadowStore)))
+
+	// Canary findings that diverged from the stable pattern set's.
+	mux.Handle("/api/v1/canary/divergences", authMiddleware.Middleware(
+		review.NewCanaryDivergencesHTTPHandler(canaryStore)))
+
+	// Baseline scan endpoint to record a repository's pre-existing findings, restricted to admins.
+	mux.Handle("/api/v1/baseline", authMiddleware.RequireRole(cfg.AdminRole, review.NewBaselineHTTPHandler(
+		review.NewBaselineService(securityAnalyzer, baselineStore),
+	)))
+
 	// ==========================================================================
 	// Initialize Service
 	// ==========================================================================
@@ -1,11 +1,69 @@
 package config
 
 import (
+	"strings"
+	"sync/atomic"
+
 	"github.com/pitabwire/frame/config"
 
 	"github.com/antinvestor/builder/internal/events"
 )
 
+// SecretAction is how the decision engine treats a detected secret.
+type SecretAction string
+
+// Secret policy actions.
+const (
+	SecretActionBlock  SecretAction = "block"
+	SecretActionWarn   SecretAction = "warn"
+	SecretActionIgnore SecretAction = "ignore"
+)
+
+// ScrutinyAction is how the decision engine handles changes to scrutinized config files.
+type ScrutinyAction string
+
+// Config scrutiny actions.
+const (
+	ScrutinyActionManualReview ScrutinyAction = "manual_review"
+	ScrutinyActionElevateRisk  ScrutinyAction = "elevate_risk"
+)
+
+// MigrationAction is how the decision engine handles destructive database migrations.
+type MigrationAction string
+
+// Destructive migration actions.
+const (
+	MigrationActionManualReview MigrationAction = "manual_review"
+	MigrationActionIterate      MigrationAction = "iterate"
+)
+
+// LayerRules describes the architecture layers of a repository for the
+// dependency and layering checks.
+type LayerRules struct {
+	// Layers assign files to layers; the first layer with a matching path wins.
+	Layers []Layer `json:"layers"`
+
+	// Forbidden are the dependencies between layers that are not allowed.
+	Forbidden []LayerEdge `json:"forbidden"`
+
+	// Order lists layers from outermost to innermost. A layer depending on one
+	// listed before it is a reverse flow.
+	Order []string `json:"order"`
+}
+
+// Layer names an architecture layer and the paths of its files. A path is a
+// glob ("pkg/*/api/**") or a directory prefix ("pkg/api").
+type Layer struct {
+	Name  string   `json:"name"`
+	Paths []string `json:"paths"`
+}
+
+// LayerEdge is a dependency of the From layer on the To layer.
+type LayerEdge struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
 // ReviewerConfig defines configuration for the reviewer service.
 // The reviewer handles security analysis, architecture review,
 // risk scoring, and control decisions (iterate/abort/complete).
@@ -56,6 +114,205 @@ type ReviewerConfig struct {
 	// MaxIterations is the maximum iterations before abort.
 	MaxIterations int `envDefault:"3" env:"MAX_ITERATIONS"`
 
+	// MaxBuildWarnings is the maximum build warnings allowed (0 = unlimited).
+	MaxBuildWarnings int `envDefault:"0" env:"MAX_BUILD_WARNINGS"`
+
+	// NoCoverageRegression iterates when coverage drops below the baseline coverage
+	// by more than CoverageRegressionTolerance, even when above the absolute minimum.
+	// Test results without a baseline coverage are not checked and get a warning.
+	NoCoverageRegression bool `envDefault:"false" env:"NO_COVERAGE_REGRESSION"`
+
+	// CoverageRegressionTolerance is the coverage drop allowed, in percentage points.
+	CoverageRegressionTolerance float64 `envDefault:"0.5" env:"COVERAGE_REGRESSION_TOLERANCE"`
+
+	// MaxSkippedTestRatio is the share of skipped tests (0-1) above which a passing test
+	// run adds test risk, since skipped tests prove nothing (0 = ignore skipped tests).
+	MaxSkippedTestRatio float64 `envDefault:"0.2" env:"MAX_SKIPPED_TEST_RATIO"`
+
+	// SlowTestThresholdMs is the average duration per test above which a test run adds
+	// test risk, as slow suites are often flaky (0 = ignore test duration).
+	SlowTestThresholdMs int64 `envDefault:"2000" env:"SLOW_TEST_THRESHOLD_MS"`
+
+	// SecurityWeight, ArchitectureWeight, TestWeight and QualityWeight weight the
+	// component risk scores averaged into the overall risk score. Weights are
+	// relative and need not sum to 1; a zero weight leaves that component out.
+	// Security covers the security assessment score, architecture the architecture
+	// assessment score, test the failing, coverage and weak-evidence risk, and
+	// quality the breaking changes.
+	SecurityWeight     float64 `envDefault:"1" env:"SECURITY_WEIGHT"`
+	ArchitectureWeight float64 `envDefault:"1" env:"ARCHITECTURE_WEIGHT"`
+	TestWeight         float64 `envDefault:"1" env:"TEST_WEIGHT"`
+	QualityWeight      float64 `envDefault:"1" env:"QUALITY_WEIGHT"`
+
+	// ThresholdsFilePath is an optional JSON file with review thresholds that is
+	// watched and hot-reloaded without restarting the service.
+	ThresholdsFilePath string `env:"THRESHOLDS_FILE_PATH"`
+
+	// ThresholdsReloadIntervalSeconds is how often the thresholds file is checked for changes.
+	ThresholdsReloadIntervalSeconds int `envDefault:"30" env:"THRESHOLDS_RELOAD_INTERVAL_SECONDS"`
+
+	// ShadowModeEnabled evaluates every review a second time with the shadow thresholds
+	// and records where it diverges; the primary decision still governs the pipeline.
+	ShadowModeEnabled bool `envDefault:"false" env:"SHADOW_MODE_ENABLED"`
+
+	// ShadowThresholdsFilePath is the JSON thresholds file used by shadow decisions.
+	ShadowThresholdsFilePath string `env:"SHADOW_THRESHOLDS_FILE_PATH"`
+
+	// reloadedThresholds holds thresholds applied at runtime; it takes precedence when set.
+	reloadedThresholds atomic.Pointer[events.ReviewThresholds]
+
+	// ==========================================================================
+	// Analyzer Canary
+	// ==========================================================================
+
+	// AnalyzerCanaryFraction is the fraction of executions (0 to 1) whose review also
+	// runs the candidate pattern set next to the stable one. The stable findings still
+	// decide the review; divergent candidate findings are recorded before full rollout.
+	AnalyzerCanaryFraction float64 `envDefault:"0" env:"ANALYZER_CANARY_FRACTION"`
+
+	// CanaryPatternsPath is the candidate pattern set, a file in the CustomPatternsPath
+	// format whose patterns are added to the stable ones, replacing those of the same
+	// name. The canary runs only when it is set.
+	CanaryPatternsPath string `env:"CANARY_PATTERNS_PATH"`
+
+	// ==========================================================================
+	// Review Size Limits
+	// ==========================================================================
+
+	// MaxReviewFiles is the maximum number of files analyzed per review (0 = unlimited).
+	// Larger reviews are sampled, marked partial and sent to manual review.
+	MaxReviewFiles int `envDefault:"200" env:"MAX_REVIEW_FILES"`
+
+	// MaxReviewBytes is the maximum total diff size in bytes analyzed per review (0 = unlimited).
+	MaxReviewBytes int `envDefault:"2097152" env:"MAX_REVIEW_BYTES"`
+
+	// ==========================================================================
+	// Finding Throttling
+	// ==========================================================================
+
+	// MaxFindingsPerType caps how many findings of one type an analyzer lists
+	// (0 = unlimited). Findings over the cap are summarized as "+N more".
+	MaxFindingsPerType int `envDefault:"20" env:"MAX_FINDINGS_PER_TYPE"`
+
+	// FindingTypeCaps overrides the cap per finding type (e.g. "sql_injection:5,aws_access_key:50").
+	FindingTypeCaps map[string]int `env:"FINDING_TYPE_CAPS"`
+
+	// ==========================================================================
+	// Environment Gating
+	// ==========================================================================
+
+	// ProductionBranches are branches treated as production targets (comma-separated).
+	// Other branches are treated as staging.
+	ProductionBranches string `envDefault:"main,master,production,prod" env:"PRODUCTION_BRANCHES"`
+
+	// Environment profiles override the review thresholds for changes targeting
+	// production or staging. Each is unset (0) by default, inheriting the configured
+	// threshold, so a profile applies only where the operator sets one.
+
+	// ProductionMaxRiskScore overrides MaxRiskScore for production targets (0 = inherit).
+	ProductionMaxRiskScore int `env:"PRODUCTION_MAX_RISK_SCORE"`
+
+	// ProductionMaxSecurityRiskScore overrides MaxSecurityRiskScore for production targets (0 = inherit).
+	ProductionMaxSecurityRiskScore int `env:"PRODUCTION_MAX_SECURITY_RISK_SCORE"`
+
+	// ProductionMaxHighIssues overrides MaxHighIssues for production targets (0 = inherit).
+	ProductionMaxHighIssues int `env:"PRODUCTION_MAX_HIGH_ISSUES"`
+
+	// StagingMaxRiskScore overrides MaxRiskScore for staging targets (0 = inherit).
+	StagingMaxRiskScore int `env:"STAGING_MAX_RISK_SCORE"`
+
+	// StagingMaxSecurityRiskScore overrides MaxSecurityRiskScore for staging targets (0 = inherit).
+	StagingMaxSecurityRiskScore int `env:"STAGING_MAX_SECURITY_RISK_SCORE"`
+
+	// StagingMaxHighIssues overrides MaxHighIssues for staging targets (0 = inherit).
+	StagingMaxHighIssues int `env:"STAGING_MAX_HIGH_ISSUES"`
+
+	// ==========================================================================
+	// Approval Windows
+	// ==========================================================================
+
+	// ApprovalWindowsEnabled restricts automated approvals to the configured windows.
+	// Approvals outside every window are downgraded to manual review.
+	ApprovalWindowsEnabled bool `envDefault:"false" env:"APPROVAL_WINDOWS_ENABLED"`
+
+	// ApprovalWindows are the windows in which changes may be approved automatically,
+	// comma-separated, e.g. "mon-fri 09:00-17:00,sat 10:00-12:00".
+	ApprovalWindows string `envDefault:"mon-fri 09:00-17:00" env:"APPROVAL_WINDOWS"`
+
+	// ApprovalWindowTimezone is the IANA timezone the windows are expressed in.
+	ApprovalWindowTimezone string `envDefault:"UTC" env:"APPROVAL_WINDOW_TIMEZONE"`
+
+	// ApprovalWindowLowRiskMaxScore lets changes at or below this risk score approve
+	// outside the windows (0 = no override).
+	ApprovalWindowLowRiskMaxScore int `envDefault:"0" env:"APPROVAL_WINDOW_LOW_RISK_MAX_SCORE"`
+
+	// ==========================================================================
+	// Config File Scrutiny
+	// ==========================================================================
+
+	// ScrutinizedPaths are path globs of high-impact files such as CI configs, Dockerfiles
+	// and deployment manifests, e.g. ".github/workflows/**,Dockerfile,deploy/**,*.tf"
+	// (comma-separated, empty = disabled). "**" matches any number of directories; a
+	// pattern without a slash matches the file name at any depth.
+	ScrutinizedPaths string `envDefault:"" env:"SCRUTINIZED_PATHS"`
+
+	// ScrutinizedPathAction is how changes to scrutinized files are handled:
+	// "manual_review" routes the review to a human, "elevate_risk" raises the risk score.
+	ScrutinizedPathAction string `envDefault:"manual_review" env:"SCRUTINIZED_PATH_ACTION"`
+
+	// ScrutinizedPathRiskScore is added to the overall risk score under "elevate_risk".
+	ScrutinizedPathRiskScore int `envDefault:"30" env:"SCRUTINIZED_PATH_RISK_SCORE"`
+
+	// ==========================================================================
+	// Vendored Dependencies
+	// ==========================================================================
+
+	// VendoredPaths are path globs of vendored third-party code, left out of the architecture
+	// and custom analyzers but still scanned for secrets and security issues
+	// (comma-separated, empty = none).
+	VendoredPaths string `envDefault:"**/vendor/**,**/node_modules/**,**/third_party/**" env:"VENDORED_PATHS"`
+
+	// ReviewIgnorePaths are path globs of files the reviewer never analyzes or reports, e.g.
+	// generated docs or test fixtures (comma-separated, empty = none). A change whose files
+	// are all ignored goes to manual review.
+	ReviewIgnorePaths string `envDefault:"" env:"REVIEW_IGNORE_PATHS"`
+
+	// ==========================================================================
+	// Database Migrations
+	// ==========================================================================
+
+	// MigrationPaths are path globs of database migration files, checked for destructive
+	// operations such as dropped tables and columns (comma-separated, empty = disabled).
+	MigrationPaths string `envDefault:"**/migrations/**,**/migrate/**" env:"MIGRATION_PATHS"`
+
+	// DestructiveMigrationAction is how destructive migration operations are handled:
+	// "manual_review" routes the review to a human, "iterate" blocks on high severity
+	// operations like any other finding.
+	DestructiveMigrationAction string `envDefault:"manual_review" env:"DESTRUCTIVE_MIGRATION_ACTION"`
+
+	// ==========================================================================
+	// Analyzer Toggles
+	// ==========================================================================
+
+	// DisableSecurity skips the security analyzer. A disabled analyzer's findings
+	// and risk are left out of the decision.
+	DisableSecurity bool `envDefault:"false" env:"DISABLE_SECURITY"`
+
+	// DisableArchitecture skips the architecture analyzer, e.g. for scripting repositories.
+	DisableArchitecture bool `envDefault:"false" env:"DISABLE_ARCHITECTURE"`
+
+	// DisableDependency stops reviewing the dependency vulnerabilities reported with
+	// the security assessment.
+	DisableDependency bool `envDefault:"false" env:"DISABLE_DEPENDENCY"`
+
+	// EnableDeadCode flags unexported Go functions and variables a change adds but never uses.
+	EnableDeadCode bool `envDefault:"true" env:"ENABLE_DEAD_CODE"`
+
+	// StrictMode routes a review to manual review when any analyzer errors. Otherwise
+	// the review is decided on the remaining analyzers' results with a warning, unless
+	// the security analyzer, which also scans for secrets, is the one that failed.
+	StrictMode bool `envDefault:"true" env:"STRICT_MODE"`
+
 	// ==========================================================================
 	// Security Configuration
 	// ==========================================================================
@@ -64,11 +321,59 @@ type ReviewerConfig struct {
 	RequireSecurityApproval bool `envDefault:"true" env:"REQUIRE_SECURITY_APPROVAL"`
 
 	// BlockOnSecrets blocks any code containing detected secrets.
+	// It is the default for secret types without an entry in SecretPolicies.
 	BlockOnSecrets bool `envDefault:"true" env:"BLOCK_ON_SECRETS"`
 
+	// SecretPolicies sets the action per secret type: "block", "warn" or "ignore"
+	// (e.g. "private_key:block,aws_secret_key:block,password:warn").
+	SecretPolicies map[string]string `env:"SECRET_POLICIES"`
+
+	// SecretSeverities sets the issue severity of blocked secrets per type
+	// (e.g. "jwt_token:high"); blocked secrets default to critical.
+	SecretSeverities map[string]string `env:"SECRET_SEVERITIES"`
+
+	// SecretEntropyBase64Threshold and SecretEntropyHexThreshold are the Shannon entropy,
+	// in bits per character, above which a base64 or hex string literal is reported as a
+	// high_entropy_string secret (0 = do not check that encoding).
+	SecretEntropyBase64Threshold float64 `envDefault:"4.5" env:"SECRET_ENTROPY_BASE64_THRESHOLD"`
+	SecretEntropyHexThreshold    float64 `envDefault:"3.0" env:"SECRET_ENTROPY_HEX_THRESHOLD"`
+
+	// SecretEntropyMinLength is the shortest string literal checked for entropy.
+	SecretEntropyMinLength int `envDefault:"20" env:"SECRET_ENTROPY_MIN_LENGTH"`
+
+	// CustomPatternsPath is a YAML or JSON file of security and secret patterns added to
+	// the built-in ones; a custom pattern replaces the built-in pattern of the same name.
+	// An invalid pattern stops the service at startup.
+	CustomPatternsPath string `env:"CUSTOM_PATTERNS_PATH"`
+
+	// DependencyAdvisoriesPath is a YAML or JSON file of dependency vulnerability advisories
+	// matched in addition to the bundled ones.
+	DependencyAdvisoriesPath string `env:"DEPENDENCY_ADVISORIES_PATH"`
+
+	// AnalyzeDiffOnly reports security findings only on the lines a patch changes (plus a
+	// few lines of context) instead of the whole file. Findings elsewhere are reported as
+	// regressions only when they are absent from the file's baseline.
+	AnalyzeDiffOnly bool `envDefault:"true" env:"ANALYZE_DIFF_ONLY"`
+
 	// AllowBreakingChanges allows breaking changes (not recommended).
 	AllowBreakingChanges bool `envDefault:"false" env:"ALLOW_BREAKING_CHANGES"`
 
+	// ==========================================================================
+	// Architecture Configuration
+	// ==========================================================================
+
+	// LayerRulesPath is an optional JSON file of the repository's architecture layers
+	// (see LayerRules). Without it the built-in folder conventions are used.
+	LayerRulesPath string `env:"LAYER_RULES_PATH"`
+
+	// ==========================================================================
+	// API Access
+	// ==========================================================================
+
+	// AdminRole is the role callers need to change how reviews are gated, such as
+	// recording a repository baseline.
+	AdminRole string `envDefault:"admin" env:"ADMIN_ROLE"`
+
 	// ==========================================================================
 	// Kill Switch Configuration
 	// ==========================================================================
@@ -76,6 +381,18 @@ type ReviewerConfig struct {
 	// KillSwitchEnabled enables the kill switch system.
 	KillSwitchEnabled bool `envDefault:"true" env:"KILL_SWITCH_ENABLED"`
 
+	// KillSwitchStatePath is an optional JSON file kill switch state is persisted
+	// to, so activated switches survive restarts. Without it state is in memory.
+	KillSwitchStatePath string `env:"KILL_SWITCH_STATE_PATH"`
+
+	// KillSwitchRollbackAll asks control event consumers to roll back all changes
+	// of the executions an activation affects.
+	KillSwitchRollbackAll bool `envDefault:"false" env:"KILL_SWITCH_ROLLBACK_ALL"`
+
+	// KillSwitchTrackExecutions lists the in-flight executions an activation affects
+	// from the execution database, which the reviewer then connects to.
+	KillSwitchTrackExecutions bool `envDefault:"false" env:"KILL_SWITCH_TRACK_EXECUTIONS"`
+
 	// ErrorRateThreshold is the error rate threshold for auto-triggering.
 	ErrorRateThreshold float64 `envDefault:"0.5" env:"ERROR_RATE_THRESHOLD"`
 
@@ -87,7 +404,12 @@ type ReviewerConfig struct {
 }
 
 // GetReviewThresholds returns the configured review thresholds.
+// Thresholds applied via SetReviewThresholds take precedence over static configuration.
+// The returned value is a snapshot, so callers see a consistent set for one decision.
 func (c *ReviewerConfig) GetReviewThresholds() events.ReviewThresholds {
+	if reloaded := c.reloadedThresholds.Load(); reloaded != nil {
+		return *reloaded
+	}
 	if c.ReviewThresholds.MaxRiskScore == 0 {
 		return events.ReviewThresholds{
 			MaxRiskScore:             c.MaxRiskScore,
@@ -97,7 +419,149 @@ func (c *ReviewerConfig) GetReviewThresholds() events.ReviewThresholds {
 			MaxHighIssues:            c.MaxHighIssues,
 			MaxBreakingChanges:       c.MaxBreakingChanges,
 			MaxIterations:            c.MaxIterations,
+			MaxBuildWarnings:         c.MaxBuildWarnings,
+
+			NoCoverageRegression:        c.NoCoverageRegression,
+			CoverageRegressionTolerance: c.CoverageRegressionTolerance,
 		}
 	}
 	return c.ReviewThresholds
 }
+
+// SetReviewThresholds atomically replaces the thresholds used for subsequent decisions.
+func (c *ReviewerConfig) SetReviewThresholds(thresholds events.ReviewThresholds) {
+	c.reloadedThresholds.Store(&thresholds)
+}
+
+// ResolveTargetEnvironment returns the environment a change to branch targets:
+// production for the configured production branches and staging for any other
+// named branch. It is derived from operator configuration only, never from the
+// requester.
+func (c *ReviewerConfig) ResolveTargetEnvironment(branch string) events.TargetEnvironment {
+	branch = strings.TrimPrefix(strings.TrimSpace(branch), "refs/heads/")
+	if branch == "" {
+		return events.TargetEnvironmentUnspecified
+	}
+
+	for production := range strings.SplitSeq(c.ProductionBranches, ",") {
+		if strings.TrimSpace(production) == branch {
+			return events.TargetEnvironmentProduction
+		}
+	}
+
+	return events.TargetEnvironmentStaging
+}
+
+// GetEnvironmentThresholds returns the review thresholds for a target environment:
+// the current thresholds with the environment's profile overrides applied.
+// An unspecified environment uses the current thresholds unchanged.
+func (c *ReviewerConfig) GetEnvironmentThresholds(env events.TargetEnvironment) events.ReviewThresholds {
+	thresholds := c.GetReviewThresholds()
+
+	switch env {
+	case events.TargetEnvironmentProduction:
+		applyThresholdOverrides(&thresholds,
+			c.ProductionMaxRiskScore, c.ProductionMaxSecurityRiskScore, c.ProductionMaxHighIssues)
+	case events.TargetEnvironmentStaging:
+		applyThresholdOverrides(&thresholds,
+			c.StagingMaxRiskScore, c.StagingMaxSecurityRiskScore, c.StagingMaxHighIssues)
+	case events.TargetEnvironmentUnspecified:
+	}
+
+	return thresholds
+}
+
+// applyThresholdOverrides applies the non-zero values of an environment profile.
+func applyThresholdOverrides(thresholds *events.ReviewThresholds, maxRisk, maxSecurityRisk, maxHigh int) {
+	if maxRisk > 0 {
+		thresholds.MaxRiskScore = maxRisk
+	}
+	if maxSecurityRisk > 0 {
+		thresholds.MaxSecurityRiskScore = maxSecurityRisk
+	}
+	if maxHigh > 0 {
+		thresholds.MaxHighIssues = maxHigh
+	}
+}
+
+// SecretAction returns the configured action for a secret type. Types without a
+// valid policy block when BlockOnSecrets is set and warn otherwise.
+func (c *ReviewerConfig) SecretAction(secretType string) SecretAction {
+	switch action := SecretAction(strings.ToLower(strings.TrimSpace(c.SecretPolicies[secretType]))); action {
+	case SecretActionBlock, SecretActionWarn, SecretActionIgnore:
+		return action
+	}
+	if c.BlockOnSecrets {
+		return SecretActionBlock
+	}
+	return SecretActionWarn
+}
+
+// ScrutinizedPathPatterns returns the configured scrutinized path globs.
+func (c *ReviewerConfig) ScrutinizedPathPatterns() []string {
+	return splitPatterns(c.ScrutinizedPaths)
+}
+
+// MigrationPathPatterns returns the configured migration path globs.
+func (c *ReviewerConfig) MigrationPathPatterns() []string {
+	return splitPatterns(c.MigrationPaths)
+}
+
+// ReviewIgnorePathPatterns returns the configured review ignore path globs.
+func (c *ReviewerConfig) ReviewIgnorePathPatterns() []string {
+	return splitPatterns(c.ReviewIgnorePaths)
+}
+
+// VendoredPathPatterns returns the configured vendored path globs.
+func (c *ReviewerConfig) VendoredPathPatterns() []string {
+	return splitPatterns(c.VendoredPaths)
+}
+
+// splitPatterns splits a comma-separated list of path globs, dropping empty entries.
+func splitPatterns(value string) []string {
+	var patterns []string
+	for pattern := range strings.SplitSeq(value, ",") {
+		if pattern = strings.TrimSpace(pattern); pattern != "" {
+			patterns = append(patterns, pattern)
+		}
+	}
+	return patterns
+}
+
+// ScrutinyAction returns the configured action for scrutinized files, defaulting
+// to manual review when unset or invalid.
+func (c *ReviewerConfig) ScrutinyAction() ScrutinyAction {
+	if ScrutinyAction(strings.ToLower(strings.TrimSpace(c.ScrutinizedPathAction))) == ScrutinyActionElevateRisk {
+		return ScrutinyActionElevateRisk
+	}
+	return ScrutinyActionManualReview
+}
+
+// MigrationAction returns the configured action for destructive migrations,
+// defaulting to manual review when unset or invalid.
+func (c *ReviewerConfig) MigrationAction() MigrationAction {
+	if MigrationAction(strings.ToLower(strings.TrimSpace(c.DestructiveMigrationAction))) == MigrationActionIterate {
+		return MigrationActionIterate
+	}
+	return MigrationActionManualReview
+}
+
+// FindingCap returns how many findings of a type are listed before the rest are
+// summarized, with 0 meaning unlimited.
+func (c *ReviewerConfig) FindingCap(findingType string) int {
+	if limit, ok := c.FindingTypeCaps[findingType]; ok {
+		return max(limit, 0)
+	}
+	return max(c.MaxFindingsPerType, 0)
+}
+
+// SecretSeverity returns the issue severity for a blocked secret type.
+func (c *ReviewerConfig) SecretSeverity(secretType string) events.ReviewIssueSeverity {
+	severity := events.ReviewIssueSeverity(strings.ToLower(strings.TrimSpace(c.SecretSeverities[secretType])))
+	switch severity {
+	case events.ReviewIssueSeverityInfo, events.ReviewIssueSeverityLow, events.ReviewIssueSeverityMedium,
+		events.ReviewIssueSeverityHigh, events.ReviewIssueSeverityCritical:
+		return severity
+	}
+	return events.ReviewIssueSeverityCritical
+}
@@ -0,0 +1,57 @@
+package review
+
+import (
+	"time"
+
+	"github.com/antinvestor/builder/internal/events"
+)
+
+// Names of the built-in analyzers in the analyzer metrics.
+const (
+	analyzerNameSecurity     = "security"
+	analyzerNameArchitecture = "architecture"
+	analyzerNameCustom       = "custom"
+)
+
+// newAnalyzerMetrics records an analyzer run that started at started.
+func newAnalyzerMetrics(name string, started time.Time, findingCount int) events.AnalyzerMetrics {
+	return events.AnalyzerMetrics{
+		Analyzer:     name,
+		DurationMS:   time.Since(started).Milliseconds(),
+		FindingCount: findingCount,
+	}
+}
+
+// securityFindingCount counts the findings of a security assessment, including
+// those throttled out of its lists.
+func securityFindingCount(assessment *events.SecurityAssessment) int {
+	if assessment == nil {
+		return 0
+	}
+	return omittedFindings(assessment.ThrottledFindings) +
+		len(assessment.VulnerabilitiesFound) +
+		len(assessment.SecretsDetected) +
+		len(assessment.DependencyVulnerabilities) +
+		len(assessment.SecurityRegressions) +
+		len(assessment.InsecurePatterns) +
+		len(assessment.AuthorizationIssues) +
+		len(assessment.DataHandlingIssues) +
+		len(assessment.ComplianceIssues)
+}
+
+// architectureFindingCount counts the findings of an architecture assessment,
+// including those throttled out of its lists. Interface changes and
+// recommendations are informational and not counted.
+func architectureFindingCount(assessment *events.ArchitectureAssessment) int {
+	if assessment == nil {
+		return 0
+	}
+	return omittedFindings(assessment.ThrottledFindings) +
+		len(assessment.BreakingChanges) +
+		len(assessment.DependencyViolations) +
+		len(assessment.LayeringViolations) +
+		len(assessment.CircularDependencies) +
+		len(assessment.PatternViolations) +
+		len(assessment.TestRegressions) +
+		len(assessment.APIContractViolations)
+}
@@ -0,0 +1,91 @@
+//nolint:testpackage // white-box testing requires internal package access
+package review
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	appconfig "github.com/antinvestor/builder/apps/reviewer/config"
+	"github.com/antinvestor/builder/internal/events"
+)
+
+func TestRequestHandler_RecordsAnalyzerMetrics(t *testing.T) {
+	cfg := &appconfig.ReviewerConfig{
+		MaxRiskScore:         50,
+		MaxSecurityRiskScore: 30,
+		MaxHighIssues:        2,
+		MaxIterations:        3,
+	}
+	emitter := &mockEventsEmitter{}
+	handler := NewRequestHandler(
+		cfg,
+		NewPatternSecurityAnalyzer(cfg),
+		NewPatternArchitectureAnalyzer(cfg),
+		NewThresholdDecisionEngine(cfg),
+		NewDefaultKillSwitchService(cfg, emitter),
+		emitter,
+	)
+	conventions := &conventionAnalyzer{issues: []events.ReviewIssue{
+		newConventionIssue(events.ReviewIssueSeverityLow),
+		newConventionIssue(events.ReviewIssueSeverityMedium),
+	}}
+	registry := NewAnalyzerRegistry()
+	registry.Register(conventions)
+	handler.SetAnalyzerRegistry(registry)
+
+	payload, err := json.Marshal(&events.ComprehensiveReviewRequestedPayload{
+		ExecutionID: events.NewExecutionID(),
+		TestResults: newPassingTestResult(),
+		Patches: []events.PatchReference{{
+			FilePath:    "internal/api/handler.go",
+			ChangeType:  "modify",
+			DiffContent: "+func Handle() {}",
+			LinesAdded:  1,
+		}},
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, handler.Handle(context.Background(), nil, payload))
+
+	require.Len(t, emitter.emittedEvents, 1)
+	completed, ok := emitter.emittedEvents[0].payload.(*events.ComprehensiveReviewCompletedPayload)
+	require.True(t, ok)
+
+	require.Len(t, completed.AnalyzerMetrics, 3)
+	names := make([]string, len(completed.AnalyzerMetrics))
+	for i, metrics := range completed.AnalyzerMetrics {
+		names[i] = metrics.Analyzer
+		assert.GreaterOrEqual(t, metrics.DurationMS, int64(0), "analyzer %s", metrics.Analyzer)
+	}
+	assert.Equal(t, []string{analyzerNameSecurity, analyzerNameArchitecture, "conventions"}, names)
+
+	assert.Equal(t, 2, completed.AnalyzerMetrics[2].FindingCount)
+}
+
+func TestNewAnalyzerMetrics_DurationInMilliseconds(t *testing.T) {
+	metrics := newAnalyzerMetrics(analyzerNameSecurity, time.Now().Add(-1500*time.Millisecond), 4)
+
+	assert.GreaterOrEqual(t, metrics.DurationMS, int64(1500))
+	assert.Less(t, metrics.DurationMS, int64(2500))
+	assert.Equal(t, 4, metrics.FindingCount)
+}
+
+func TestFindingCounts(t *testing.T) {
+	assert.Zero(t, securityFindingCount(nil))
+	assert.Zero(t, architectureFindingCount(nil))
+
+	assert.Equal(t, 3, securityFindingCount(&events.SecurityAssessment{
+		VulnerabilitiesFound: []events.Vulnerability{{}},
+		SecretsDetected:      []events.SecretFinding{{}, {}},
+	}))
+	assert.Equal(t, 2, architectureFindingCount(&events.ArchitectureAssessment{
+		BreakingChanges:  []events.BreakingChange{{}},
+		TestRegressions:  []events.TestRegression{{}},
+		InterfaceChanges: []events.InterfaceChange{{}},
+	}))
+}
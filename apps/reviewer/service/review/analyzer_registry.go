@@ -0,0 +1,75 @@
+package review
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/pitabwire/util"
+
+	appconfig "github.com/antinvestor/builder/apps/reviewer/config"
+	"github.com/antinvestor/builder/internal/events"
+)
+
+// AnalyzerRegistry holds the custom analyzers run on every review.
+type AnalyzerRegistry struct {
+	mu        sync.RWMutex
+	analyzers []Analyzer
+}
+
+// NewAnalyzerRegistry creates an empty analyzer registry.
+func NewAnalyzerRegistry() *AnalyzerRegistry {
+	return &AnalyzerRegistry{}
+}
+
+// NewDefaultAnalyzerRegistry creates a registry holding the built-in pluggable
+// analyzers that cfg enables. Custom analyzers are registered next to them.
+func NewDefaultAnalyzerRegistry(cfg *appconfig.ReviewerConfig) *AnalyzerRegistry {
+	registry := NewAnalyzerRegistry()
+	if cfg == nil {
+		return registry
+	}
+	if cfg.EnableDeadCode {
+		registry.Register(NewDeadCodeAnalyzer())
+	}
+	if migrationPaths := cfg.MigrationPathPatterns(); len(migrationPaths) > 0 {
+		registry.Register(NewMigrationAnalyzer(migrationPaths))
+	}
+	return registry
+}
+
+// Register adds an analyzer; analyzers run in registration order.
+func (r *AnalyzerRegistry) Register(analyzer Analyzer) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.analyzers = append(r.analyzers, analyzer)
+}
+
+// Analyzers returns the registered analyzers.
+func (r *AnalyzerRegistry) Analyzers() []Analyzer {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return append([]Analyzer(nil), r.analyzers...)
+}
+
+// Run runs every registered analyzer and merges the issues they report,
+// along with the duration and issue count of each analyzer.
+func (r *AnalyzerRegistry) Run(
+	ctx context.Context,
+	req *AnalysisRequest,
+) ([]events.ReviewIssue, []events.AnalyzerMetrics, error) {
+	var issues []events.ReviewIssue
+	var metrics []events.AnalyzerMetrics
+	for _, analyzer := range r.Analyzers() {
+		started := time.Now()
+		found, err := analyzer.Analyze(ctx, req)
+		if err != nil {
+			return nil, nil, fmt.Errorf("analyzer %s: %w", analyzer.Name(), err)
+		}
+		util.Log(ctx).Debug("custom analyzer completed", "analyzer", analyzer.Name(), "issues", len(found))
+		issues = append(issues, found...)
+		metrics = append(metrics, newAnalyzerMetrics(analyzer.Name(), started, len(found)))
+	}
+	return issues, metrics, nil
+}
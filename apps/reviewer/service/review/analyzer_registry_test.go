@@ -0,0 +1,131 @@
+//nolint:testpackage // white-box testing requires internal package access
+package review
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	appconfig "github.com/antinvestor/builder/apps/reviewer/config"
+	"github.com/antinvestor/builder/internal/events"
+)
+
+// conventionAnalyzer reports a fixed set of issues and records the requests it analyzed.
+type conventionAnalyzer struct {
+	issues   []events.ReviewIssue
+	err      error
+	requests []*AnalysisRequest
+}
+
+func (a *conventionAnalyzer) Name() string { return "conventions" }
+
+func (a *conventionAnalyzer) Analyze(_ context.Context, req *AnalysisRequest) ([]events.ReviewIssue, error) {
+	a.requests = append(a.requests, req)
+	return a.issues, a.err
+}
+
+func newConventionIssue(severity events.ReviewIssueSeverity) events.ReviewIssue {
+	return events.ReviewIssue{
+		ID:       "conventions-handler",
+		Type:     events.ReviewIssueTypeStyle,
+		Severity: severity,
+		FilePath: "internal/api/handler.go",
+		Title:    "Handler does not follow the org naming convention",
+	}
+}
+
+func TestAnalyzerRegistry_MergesIssuesInRegistrationOrder(t *testing.T) {
+	first := &conventionAnalyzer{issues: []events.ReviewIssue{newConventionIssue(events.ReviewIssueSeverityLow)}}
+	second := &conventionAnalyzer{issues: []events.ReviewIssue{newConventionIssue(events.ReviewIssueSeverityHigh)}}
+	registry := NewAnalyzerRegistry()
+	registry.Register(first)
+	registry.Register(second)
+
+	issues, _, err := registry.Run(context.Background(), &AnalysisRequest{RepositoryID: "repo"})
+	require.NoError(t, err)
+
+	require.Len(t, issues, 2)
+	assert.Equal(t, events.ReviewIssueSeverityLow, issues[0].Severity)
+	assert.Equal(t, events.ReviewIssueSeverityHigh, issues[1].Severity)
+	assert.Len(t, registry.Analyzers(), 2)
+}
+
+func TestAnalyzerRegistry_WrapsAnalyzerErrors(t *testing.T) {
+	errBroken := errors.New("rules unavailable")
+	registry := NewAnalyzerRegistry()
+	registry.Register(&conventionAnalyzer{err: errBroken})
+
+	_, _, err := registry.Run(context.Background(), &AnalysisRequest{})
+	require.ErrorIs(t, err, errBroken)
+	assert.Contains(t, err.Error(), "conventions")
+}
+
+func TestThresholdDecisionEngine_CustomIssues(t *testing.T) {
+	engine := newTestDecisionEngine()
+
+	req := newCleanDecisionRequest()
+	req.CustomIssues = []events.ReviewIssue{newConventionIssue(events.ReviewIssueSeverityMedium)}
+	result, err := engine.MakeDecision(context.Background(), req)
+	require.NoError(t, err)
+	assert.Equal(t, events.ControlDecisionApproveWithWarnings, result.Decision)
+	assert.Empty(t, result.BlockingIssues)
+
+	// The engine allows two high severity issues
+	req = newCleanDecisionRequest()
+	req.CustomIssues = []events.ReviewIssue{
+		newConventionIssue(events.ReviewIssueSeverityHigh),
+		newConventionIssue(events.ReviewIssueSeverityHigh),
+	}
+	result, err = engine.MakeDecision(context.Background(), req)
+	require.NoError(t, err)
+	assert.Equal(t, events.ControlDecisionApproveWithWarnings, result.Decision)
+	assert.Empty(t, result.BlockingIssues)
+
+	req.CustomIssues = append(req.CustomIssues, newConventionIssue(events.ReviewIssueSeverityHigh))
+	result, err = engine.MakeDecision(context.Background(), req)
+	require.NoError(t, err)
+	assert.Equal(t, events.ControlDecisionIterate, result.Decision)
+	assert.Contains(t, result.Rationale, "custom analyzer issues require attention")
+	assert.Contains(t, result.Rationale, "3 high severity issues (max: 2)")
+	assert.Equal(t, req.CustomIssues, result.BlockingIssues)
+}
+
+func TestNewDefaultAnalyzerRegistry_RegistersEnabledBuiltIns(t *testing.T) {
+	registry := NewDefaultAnalyzerRegistry(&appconfig.ReviewerConfig{
+		EnableDeadCode: true,
+		MigrationPaths: "**/migrations/**",
+	})
+	assert.Len(t, registry.Analyzers(), 2)
+
+	assert.Empty(t, NewDefaultAnalyzerRegistry(&appconfig.ReviewerConfig{}).Analyzers())
+}
+
+func TestRequestHandler_CustomAnalyzerIssuesFlowIntoDecision(t *testing.T) {
+	high := newConventionIssue(events.ReviewIssueSeverityHigh)
+	analyzer := &conventionAnalyzer{issues: []events.ReviewIssue{high, high, high}}
+	registry := NewAnalyzerRegistry()
+	registry.Register(analyzer)
+
+	handler, emitter := newShadowTestHandler(nil)
+	handler.SetAnalyzerRegistry(registry)
+	handler.SetContentFetcher(staticContentFetcher{
+		contents: map[string]string{"internal/api/handler.go": "package api\n\nfunc Handle() {}\n"},
+	})
+
+	require.NoError(t, handler.Handle(context.Background(), nil, shadowReviewPayload(t, events.NewExecutionID())))
+
+	require.Len(t, analyzer.requests, 1)
+	assert.Equal(t, "go", analyzer.requests[0].Language)
+	require.Len(t, analyzer.requests[0].Patches, 1)
+	assert.Equal(t, "internal/api/handler.go", analyzer.requests[0].Patches[0].FilePath)
+	assert.Equal(t, "package api\n\nfunc Handle() {}\n", analyzer.requests[0].FileContents["internal/api/handler.go"])
+
+	require.Len(t, emitter.emittedEvents, 1)
+	completed, ok := emitter.emittedEvents[0].payload.(*events.ComprehensiveReviewCompletedPayload)
+	require.True(t, ok)
+	assert.Equal(t, events.ControlDecisionIterate, completed.Decision)
+	assert.Contains(t, completed.BlockingIssues, analyzer.issues[0])
+}
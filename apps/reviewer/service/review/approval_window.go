@@ -0,0 +1,180 @@
+package review
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	appconfig "github.com/antinvestor/builder/apps/reviewer/config"
+	"github.com/antinvestor/builder/internal/events"
+)
+
+// ErrInvalidApprovalWindow is returned when an approval window cannot be parsed.
+var ErrInvalidApprovalWindow = errors.New("invalid approval window")
+
+const minutesPerDay = 24 * 60
+
+// weekdayNames maps the abbreviations accepted in window specs to weekdays.
+var weekdayNames = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+// ApprovalWindow is a recurring weekly period in which automated approvals are allowed.
+// A window whose end is not after its start runs past midnight into the next day.
+type ApprovalWindow struct {
+	days  [7]bool
+	start int // minutes after midnight
+	end   int // minutes after midnight
+}
+
+// Contains reports whether t, already in the window's timezone, falls inside the window.
+func (w ApprovalWindow) Contains(t time.Time) bool {
+	minute := t.Hour()*60 + t.Minute()
+	if w.start < w.end {
+		return w.days[t.Weekday()] && minute >= w.start && minute < w.end
+	}
+	// Overnight window: the late part belongs to today, the early part to yesterday
+	yesterday := (t.Weekday() + 6) % 7
+	return (w.days[t.Weekday()] && minute >= w.start) || (w.days[yesterday] && minute < w.end)
+}
+
+// ParseApprovalWindows parses comma-separated windows such as "mon-fri 09:00-17:00".
+// Days are a single weekday or an inclusive range; times are 24-hour HH:MM.
+func ParseApprovalWindows(spec string) ([]ApprovalWindow, error) {
+	var windows []ApprovalWindow
+	for entry := range strings.SplitSeq(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		window, err := parseApprovalWindow(entry)
+		if err != nil {
+			return nil, err
+		}
+		windows = append(windows, window)
+	}
+	if len(windows) == 0 {
+		return nil, fmt.Errorf("%w: no windows configured", ErrInvalidApprovalWindow)
+	}
+	return windows, nil
+}
+
+func parseApprovalWindow(entry string) (ApprovalWindow, error) {
+	fields := strings.Fields(strings.ToLower(entry))
+	if len(fields) != 2 {
+		return ApprovalWindow{}, fmt.Errorf("%w: %q: expected \"<days> <HH:MM>-<HH:MM>\"",
+			ErrInvalidApprovalWindow, entry)
+	}
+
+	var window ApprovalWindow
+	firstDay, lastDay, isRange := strings.Cut(fields[0], "-")
+	first, ok := weekdayNames[firstDay]
+	if !ok {
+		return ApprovalWindow{}, fmt.Errorf("%w: %q: unknown day %q", ErrInvalidApprovalWindow, entry, firstDay)
+	}
+	last := first
+	if isRange {
+		if last, ok = weekdayNames[lastDay]; !ok {
+			return ApprovalWindow{}, fmt.Errorf("%w: %q: unknown day %q", ErrInvalidApprovalWindow, entry, lastDay)
+		}
+	}
+	for day := first; ; day = (day + 1) % 7 {
+		window.days[day] = true
+		if day == last {
+			break
+		}
+	}
+
+	startText, endText, ok := strings.Cut(fields[1], "-")
+	if !ok {
+		return ApprovalWindow{}, fmt.Errorf("%w: %q: expected a time range", ErrInvalidApprovalWindow, entry)
+	}
+	var err error
+	if window.start, err = parseClockMinutes(startText); err != nil {
+		return ApprovalWindow{}, fmt.Errorf("%w: %q: %w", ErrInvalidApprovalWindow, entry, err)
+	}
+	if window.end, err = parseClockMinutes(endText); err != nil {
+		return ApprovalWindow{}, fmt.Errorf("%w: %q: %w", ErrInvalidApprovalWindow, entry, err)
+	}
+	return window, nil
+}
+
+// parseClockMinutes converts HH:MM into minutes after midnight; "24:00" is end of day.
+func parseClockMinutes(text string) (int, error) {
+	if text == "24:00" {
+		return minutesPerDay, nil
+	}
+	t, err := time.Parse("15:04", text)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time %q", text)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// ApprovalWindowPolicy downgrades automated approvals made outside the configured
+// windows to manual review. Low-risk changes may be exempted.
+type ApprovalWindowPolicy struct {
+	windows         []ApprovalWindow
+	location        *time.Location
+	lowRiskMaxScore int
+	now             func() time.Time
+}
+
+// NewApprovalWindowPolicy builds the policy from configuration.
+func NewApprovalWindowPolicy(cfg *appconfig.ReviewerConfig) (*ApprovalWindowPolicy, error) {
+	windows, err := ParseApprovalWindows(cfg.ApprovalWindows)
+	if err != nil {
+		return nil, err
+	}
+	location, err := time.LoadLocation(cfg.ApprovalWindowTimezone)
+	if err != nil {
+		return nil, fmt.Errorf("%w: timezone %q: %w", ErrInvalidApprovalWindow, cfg.ApprovalWindowTimezone, err)
+	}
+	return &ApprovalWindowPolicy{
+		windows:         windows,
+		location:        location,
+		lowRiskMaxScore: cfg.ApprovalWindowLowRiskMaxScore,
+		now:             time.Now,
+	}, nil
+}
+
+// InWindow reports whether t falls inside any approval window.
+func (p *ApprovalWindowPolicy) InWindow(t time.Time) bool {
+	local := t.In(p.location)
+	for _, window := range p.windows {
+		if window.Contains(local) {
+			return true
+		}
+	}
+	return false
+}
+
+// Apply converts an approval reached outside every window into manual review,
+// unless the change is low risk enough to be exempt.
+func (p *ApprovalWindowPolicy) Apply(result *DecisionResult) {
+	if result.Decision != events.ControlDecisionApprove &&
+		result.Decision != events.ControlDecisionApproveWithWarnings {
+		return
+	}
+	now := p.now()
+	if p.InWindow(now) {
+		return
+	}
+	if p.lowRiskMaxScore > 0 && result.RiskAssessment.OverallRiskScore <= p.lowRiskMaxScore {
+		result.Warnings = append(result.Warnings, fmt.Sprintf(
+			"Approved outside approval windows: risk score %d is within the low-risk override (max: %d)",
+			result.RiskAssessment.OverallRiskScore, p.lowRiskMaxScore))
+		return
+	}
+
+	result.Decision = events.ControlDecisionManualReview
+	result.Rationale = fmt.Sprintf("Manual review required: automated approval is not allowed at %s (%s)",
+		now.In(p.location).Format("Mon 15:04 MST"), result.Rationale)
+}
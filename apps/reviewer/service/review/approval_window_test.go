@@ -0,0 +1,130 @@
+package review //nolint:testpackage // white-box testing requires internal access
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	appconfig "github.com/antinvestor/builder/apps/reviewer/config"
+	"github.com/antinvestor/builder/internal/events"
+)
+
+func newWindowedDecisionEngine(t *testing.T, at time.Time, lowRiskMaxScore int) *ThresholdDecisionEngine {
+	t.Helper()
+	policy, err := NewApprovalWindowPolicy(&appconfig.ReviewerConfig{
+		ApprovalWindows:               "mon-fri 09:00-17:00",
+		ApprovalWindowTimezone:        "Africa/Nairobi",
+		ApprovalWindowLowRiskMaxScore: lowRiskMaxScore,
+	})
+	require.NoError(t, err)
+	policy.now = func() time.Time { return at }
+
+	engine := newTestDecisionEngine()
+	engine.SetApprovalWindowPolicy(policy)
+	return engine
+}
+
+func newCleanDecisionRequest() *DecisionRequest {
+	return &DecisionRequest{
+		ExecutionID:            events.NewExecutionID(),
+		SecurityAssessment:     newCleanSecurityAssessment(),
+		ArchitectureAssessment: newCleanArchitectureAssessment(),
+		TestResult:             newPassingTestResult(),
+	}
+}
+
+func TestApprovalWindowPolicy_SameChangeInAndOutOfWindow(t *testing.T) {
+	nairobi, err := time.LoadLocation("Africa/Nairobi")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name string
+		at   time.Time
+		want events.ControlDecision
+	}{
+		{
+			name: "Wednesday morning",
+			at:   time.Date(2026, time.October, 14, 10, 30, 0, 0, nairobi),
+			want: events.ControlDecisionApprove,
+		},
+		{
+			name: "Wednesday night",
+			at:   time.Date(2026, time.October, 14, 22, 0, 0, 0, nairobi),
+			want: events.ControlDecisionManualReview,
+		},
+		{
+			name: "Saturday morning",
+			at:   time.Date(2026, time.October, 17, 10, 30, 0, 0, nairobi),
+			want: events.ControlDecisionManualReview,
+		},
+		{
+			name: "in window when expressed in UTC",
+			at:   time.Date(2026, time.October, 14, 6, 30, 0, 0, time.UTC),
+			want: events.ControlDecisionApprove,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			engine := newWindowedDecisionEngine(t, tt.at, 0)
+
+			result, err := engine.MakeDecision(context.Background(), newCleanDecisionRequest())
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, result.Decision)
+			if tt.want == events.ControlDecisionManualReview {
+				assert.Contains(t, result.Rationale, "automated approval is not allowed")
+				require.NotEmpty(t, result.NextActions)
+			}
+		})
+	}
+}
+
+func TestApprovalWindowPolicy_LowRiskOverride(t *testing.T) {
+	saturday := time.Date(2026, time.October, 17, 10, 30, 0, 0, time.UTC)
+	engine := newWindowedDecisionEngine(t, saturday, maxScore)
+
+	result, err := engine.MakeDecision(context.Background(), newCleanDecisionRequest())
+
+	require.NoError(t, err)
+	assert.Equal(t, events.ControlDecisionApprove, result.Decision)
+	require.Len(t, result.Warnings, 1)
+	assert.Contains(t, result.Warnings[0], "low-risk override")
+}
+
+func TestApprovalWindowPolicy_DoesNotChangeOtherDecisions(t *testing.T) {
+	saturday := time.Date(2026, time.October, 17, 10, 30, 0, 0, time.UTC)
+	engine := newWindowedDecisionEngine(t, saturday, 0)
+	req := newCleanDecisionRequest()
+	req.TestResult = &events.TestResult{TotalTests: 10, PassedTests: 8, FailedTests: 2}
+
+	result, err := engine.MakeDecision(context.Background(), req)
+
+	require.NoError(t, err)
+	assert.Equal(t, events.ControlDecisionIterate, result.Decision)
+}
+
+func TestParseApprovalWindows(t *testing.T) {
+	windows, err := ParseApprovalWindows("mon-fri 09:00-17:00, sat 22:00-02:00")
+	require.NoError(t, err)
+	require.Len(t, windows, 2)
+
+	at := func(day, hour int) time.Time {
+		// 2026-10-12 is a Monday
+		return time.Date(2026, time.October, 12+day, hour, 0, 0, 0, time.UTC)
+	}
+	assert.True(t, windows[0].Contains(at(0, 9)))
+	assert.False(t, windows[0].Contains(at(0, 17)))
+	assert.False(t, windows[0].Contains(at(5, 10)))
+	assert.True(t, windows[1].Contains(at(5, 23)))
+	assert.True(t, windows[1].Contains(at(6, 1)))
+	assert.False(t, windows[1].Contains(at(6, 3)))
+
+	for _, spec := range []string{"", "weekdays 09:00-17:00", "mon 9-17", "mon-fri 09:00-25:00"} {
+		_, err := ParseApprovalWindows(spec)
+		require.ErrorIs(t, err, ErrInvalidApprovalWindow, spec)
+	}
+}
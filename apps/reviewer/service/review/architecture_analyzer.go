@@ -3,7 +3,9 @@ package review
 import (
 	"context"
 	"regexp"
+	"slices"
 	"strings"
+	"unicode"
 
 	"github.com/pitabwire/util"
 
@@ -31,11 +33,16 @@ const (
 	patternViolationsThreshold    = 5
 	reviewScoreThreshold          = 50
 	largeFunctionLinesThreshold   = 50
+
+	// docSimilarityThreshold is the word-overlap ratio below which a doc
+	// comment rewrite is considered a material change.
+	docSimilarityThreshold = 0.6
 )
 
 // PatternArchitectureAnalyzer implements ArchitectureAnalyzer using pattern matching.
 type PatternArchitectureAnalyzer struct {
-	cfg *appconfig.ReviewerConfig
+	cfg        *appconfig.ReviewerConfig
+	layerRules *appconfig.LayerRules
 }
 
 // NewPatternArchitectureAnalyzer creates a new pattern-based architecture analyzer.
@@ -64,6 +71,7 @@ func (a *PatternArchitectureAnalyzer) Analyze(
 		InterfaceChanges:           []events.InterfaceChange{},
 		CircularDependencies:       []events.CircularDependency{},
 		PatternViolations:          []events.PatternViolation{},
+		TestRegressions:            []events.TestRegression{},
 		APIContractViolations:      []events.APIContractViolation{},
 		Recommendations:            []events.ArchitectureRecommendation{},
 		RequiresArchitectureReview: false,
@@ -73,7 +81,8 @@ func (a *PatternArchitectureAnalyzer) Analyze(
 	breakingChanges := a.detectBreakingChanges(req.Patches, req.BaselineContents, req.FileContents)
 	assessment.BreakingChanges = breakingChanges
 
-	// Detect dependency violations
+	// Detect dependency violations. Language is resolved per file so polyglot
+	// changes are analyzed with the right rules; req.Language is only a fallback.
 	depViolations := a.detectDependencyViolations(req.FileContents, req.Language)
 	assessment.DependencyViolations = depViolations
 
@@ -89,6 +98,13 @@ func (a *PatternArchitectureAnalyzer) Analyze(
 	patternViolations := a.detectPatternViolations(req.FileContents, req.Language)
 	assessment.PatternViolations = patternViolations
 
+	// Detect removed or disabled tests
+	testRegressions := detectTestRegressions(req.Patches, req.BaselineContents, req.FileContents)
+	assessment.TestRegressions = testRegressions
+
+	// Detect import cycles passing through the changed files
+	assessment.CircularDependencies = a.changedCircularDependencies(req)
+
 	// Generate recommendations
 	recommendations := a.generateRecommendations(assessment)
 	assessment.Recommendations = recommendations
@@ -104,12 +120,20 @@ func (a *PatternArchitectureAnalyzer) Analyze(
 		assessment,
 	)
 
+	// Cap findings per type so one systemic issue does not flood the report
+	if a.cfg != nil {
+		throttleArchitectureFindings(assessment, a.cfg.FindingCap)
+	}
+
 	log.Info("architecture analysis complete",
 		"score", assessment.OverallArchitectureScore,
 		"breaking_changes", len(assessment.BreakingChanges),
 		"dep_violations", len(assessment.DependencyViolations),
 		"layer_violations", len(assessment.LayeringViolations),
 		"pattern_violations", len(assessment.PatternViolations),
+		"test_regressions", len(assessment.TestRegressions),
+		"circular_dependencies", len(assessment.CircularDependencies),
+		"throttled", omittedFindings(assessment.ThrottledFindings),
 		"status", assessment.ArchitectureStatus,
 	)
 
@@ -182,6 +206,10 @@ func (a *PatternArchitectureAnalyzer) detectBreakingChanges(
 			// Check for removed struct fields or interface methods
 			removedFields := detectRemovedFields(baselineContent, currentContent, filePath)
 			changes = append(changes, removedFields...)
+
+			// Check for documented behavior changes on otherwise unchanged symbols
+			docChanges := detectDocCommentChanges(baselineContent, currentContent, filePath, baselineFuncs, currentFuncs)
+			changes = append(changes, docChanges...)
 		}
 	}
 
@@ -191,8 +219,12 @@ func (a *PatternArchitectureAnalyzer) detectBreakingChanges(
 // detectDependencyViolations detects dependency rule violations.
 func (a *PatternArchitectureAnalyzer) detectDependencyViolations(
 	files map[string]string,
-	language string,
+	fallbackLanguage string,
 ) []events.DependencyViolation {
+	if a.layerRules != nil {
+		return a.configuredDependencyViolations(files, fallbackLanguage)
+	}
+
 	var violations []events.DependencyViolation
 
 	// Define forbidden dependencies based on common architecture rules
@@ -220,7 +252,7 @@ func (a *PatternArchitectureAnalyzer) detectDependencyViolations(
 			continue
 		}
 
-		imports := extractImports(content, language)
+		imports := extractImports(content, languageForFile(filePath, fallbackLanguage))
 		for _, imp := range imports {
 			for _, forbiddenPkg := range forbidden {
 				if strings.Contains(strings.ToLower(imp), forbiddenPkg) {
@@ -245,8 +277,12 @@ func (a *PatternArchitectureAnalyzer) detectDependencyViolations(
 // detectLayeringViolations detects architectural layer violations.
 func (a *PatternArchitectureAnalyzer) detectLayeringViolations(
 	files map[string]string,
-	language string,
+	fallbackLanguage string,
 ) []events.LayeringViolation {
+	if a.layerRules != nil {
+		return a.configuredLayeringViolations(files, fallbackLanguage)
+	}
+
 	var violations []events.LayeringViolation
 
 	// Standard layer order (from outer to inner):
@@ -277,7 +313,7 @@ func (a *PatternArchitectureAnalyzer) detectLayeringViolations(
 			continue
 		}
 
-		imports := extractImports(content, language)
+		imports := extractImports(content, languageForFile(filePath, fallbackLanguage))
 		for _, imp := range imports {
 			for targetLayer, targetOrder := range layerOrder {
 				if strings.Contains(strings.ToLower(imp), targetLayer) {
@@ -366,11 +402,13 @@ func (a *PatternArchitectureAnalyzer) detectInterfaceChanges(
 // detectPatternViolations detects design pattern violations.
 func (a *PatternArchitectureAnalyzer) detectPatternViolations(
 	files map[string]string,
-	language string,
+	fallbackLanguage string,
 ) []events.PatternViolation {
 	var violations []events.PatternViolation
 
 	for filePath, content := range files {
+		language := languageForFile(filePath, fallbackLanguage)
+
 		// Check for common anti-patterns
 
 		// God object: file with too many responsibilities
@@ -475,6 +513,21 @@ func (a *PatternArchitectureAnalyzer) generateRecommendations(
 		}
 	}
 
+	// Recommend restoring weakened tests
+	if len(assessment.TestRegressions) > 0 {
+		affected := make([]string, 0, len(assessment.TestRegressions))
+		for _, regression := range assessment.TestRegressions {
+			affected = append(affected, regression.FilePath)
+		}
+		recommendations = append(recommendations, events.ArchitectureRecommendation{
+			Category:       "Testing",
+			Recommendation: "Restore removed or skipped tests",
+			Rationale:      "Deleting or skipping tests hides failures instead of fixing them",
+			Priority:       "high",
+			AffectedFiles:  slices.Compact(affected),
+		})
+	}
+
 	// Recommend addressing pattern violations
 	if len(assessment.PatternViolations) > patternViolationsThreshold {
 		recommendations = append(recommendations, events.ArchitectureRecommendation{
@@ -546,6 +599,11 @@ func (a *PatternArchitectureAnalyzer) calculateArchitectureScore(assessment *eve
 		score -= 5
 	}
 
+	// Deduct for removed or disabled tests
+	for range assessment.TestRegressions {
+		score -= 15
+	}
+
 	// Deduct for circular dependencies
 	for range assessment.CircularDependencies {
 		score -= 20
@@ -571,7 +629,8 @@ func (a *PatternArchitectureAnalyzer) determineArchitectureStatus(
 	}
 
 	// Check for serious violations
-	if len(assessment.BreakingChanges) > 0 || len(assessment.CircularDependencies) > 0 {
+	if len(assessment.BreakingChanges) > 0 || len(assessment.CircularDependencies) > 0 ||
+		len(assessment.TestRegressions) > 0 {
 		return events.ArchitectureStatusViolations
 	}
 
@@ -629,8 +688,11 @@ func isExportedFile(_, content string) bool {
 func extractFunctionSignatures(content, filePath string) map[string]string {
 	signatures := make(map[string]string)
 
-	// Go function pattern
+	// Go functions are parsed; the regex is a fallback for source that does not parse
 	if strings.HasSuffix(filePath, ".go") {
+		if parsed, ok := goFunctionSignatures(content); ok {
+			return parsed
+		}
 		funcPattern := regexp.MustCompile(`func\s+(?:\([^)]+\)\s+)?(\w+)\s*\([^)]*\)(?:\s*\([^)]*\)|[^{]*)?`)
 		matches := funcPattern.FindAllStringSubmatch(content, -1)
 		for _, match := range matches {
@@ -664,9 +726,14 @@ func extractFunctionSignatures(content, filePath string) map[string]string {
 }
 
 func isExportedSymbol(name, filePath string) bool {
-	// Go: capitalized names are exported
+	// Go: capitalized names are exported; a Type.Method needs both exported
 	if strings.HasSuffix(filePath, ".go") {
-		return len(name) > 0 && name[0] >= 'A' && name[0] <= 'Z'
+		for part := range strings.SplitSeq(name, ".") {
+			if len(part) == 0 || part[0] < 'A' || part[0] > 'Z' {
+				return false
+			}
+		}
+		return true
 	}
 	// JavaScript/TypeScript: assume exported if in the signatures (they're already filtered for export)
 	return true
@@ -706,6 +773,130 @@ func detectRemovedFields(baseline, current, filePath string) []events.BreakingCh
 	return changes
 }
 
+// detectDocCommentChanges flags exported Go symbols whose doc comment changed
+// materially while their signature did not. Signature changes are reported
+// separately, so only the documented contract is compared here.
+func detectDocCommentChanges(
+	baseline, current, filePath string,
+	baselineFuncs, currentFuncs map[string]string,
+) []events.BreakingChange {
+	var changes []events.BreakingChange
+
+	if !strings.HasSuffix(filePath, ".go") {
+		return changes
+	}
+
+	baselineDocs := extractDocComments(baseline)
+	currentDocs := extractDocComments(current)
+
+	for symbol, baseDoc := range baselineDocs {
+		currentDoc, exists := currentDocs[symbol]
+		if !exists || !isExportedSymbol(symbol, filePath) {
+			continue
+		}
+
+		if baselineFuncs[symbol] != currentFuncs[symbol] {
+			continue
+		}
+
+		if !isMaterialDocChange(baseDoc, currentDoc) {
+			continue
+		}
+
+		changes = append(changes, events.BreakingChange{
+			ChangeType:    events.BreakingChangeChangedBehavior,
+			Description:   "Documented behavior of " + symbol + " changed",
+			FilePath:      filePath,
+			Symbol:        symbol,
+			Impact:        "Callers relying on the documented behavior may break",
+			MigrationPath: "Review the doc comment change and confirm callers are unaffected",
+			Severity:      events.ReviewIssueSeverityMedium,
+		})
+	}
+
+	return changes
+}
+
+// extractDocComments returns the doc comment of each top-level Go function and type.
+// Methods are keyed Type.Method, as in extractFunctionSignatures.
+func extractDocComments(content string) map[string]string {
+	docs := make(map[string]string)
+	declPattern := regexp.MustCompile(`^(?:func\s+(?:\((?:\w+\s+)?\*?(\w+)[^)]*\)\s*)?|type\s+)(\w+)`)
+
+	var block []string
+	for line := range strings.SplitSeq(content, "\n") {
+		trimmed := strings.TrimSpace(line)
+
+		if text, isComment := strings.CutPrefix(trimmed, "//"); isComment {
+			block = append(block, strings.TrimSpace(text))
+			continue
+		}
+
+		if match := declPattern.FindStringSubmatch(trimmed); match != nil && len(block) > 0 {
+			symbol := match[2]
+			if match[1] != "" {
+				symbol = match[1] + "." + symbol
+			}
+			docs[symbol] = strings.Join(block, " ")
+		}
+		block = nil
+	}
+
+	return docs
+}
+
+// docContractWords are words whose appearance or disappearance changes the
+// documented contract of a symbol even when the rest of the comment is reworded.
+var docContractWords = map[string]bool{
+	"nil": true, "error": true, "panic": true, "deprecated": true, "must": true,
+	"never": true, "always": true, "not": true, "safe": true, "block": true,
+	"default": true, "ignore": true, "ignored": true, "empty": true, "zero": true,
+}
+
+// isMaterialDocChange reports whether a doc comment rewrite changes its meaning
+// rather than its wording: contract words changed, or most of the text was replaced.
+func isMaterialDocChange(baseline, current string) bool {
+	baseWords := docWords(baseline)
+	currentWords := docWords(current)
+
+	for word := range docContractWords {
+		if baseWords[word] != currentWords[word] {
+			return true
+		}
+	}
+
+	// Parameter and return names mentioned in the doc count as regular words, so
+	// dropping them lowers the overlap.
+	shared := 0
+	for word := range baseWords {
+		if currentWords[word] {
+			shared++
+		}
+	}
+
+	union := len(baseWords) + len(currentWords) - shared
+	if union == 0 {
+		return false
+	}
+
+	return float64(shared)/float64(union) < docSimilarityThreshold
+}
+
+// docWords returns the normalized word set of a doc comment.
+func docWords(doc string) map[string]bool {
+	words := make(map[string]bool)
+	for _, field := range strings.FieldsFunc(strings.ToLower(doc), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_'
+	}) {
+		// Fold simple plurals so "returns"/"return" and "errors"/"error" match.
+		if !docContractWords[field] && len(field) > 3 && strings.HasSuffix(field, "s") && !strings.HasSuffix(field, "ss") {
+			field = strings.TrimSuffix(field, "s")
+		}
+		words[field] = true
+	}
+	return words
+}
+
 func extractStructFields(content string) map[string]map[string]bool {
 	structs := make(map[string]map[string]bool)
 
@@ -758,7 +949,7 @@ func extractImports(content, language string) []string {
 	var imports []string
 
 	switch language {
-	case "go":
+	case langGo:
 		importPattern := regexp.MustCompile(`import\s*\(([^)]+)\)|import\s+"([^"]+)"`)
 		matches := importPattern.FindAllStringSubmatch(content, -1)
 		for _, match := range matches {
@@ -152,6 +152,138 @@ func TestPatternArchitectureAnalyzer_BreakingChanges(t *testing.T) {
 	}
 }
 
+func TestPatternArchitectureAnalyzer_DocCommentBehaviorChanges(t *testing.T) {
+	analyzer := NewPatternArchitectureAnalyzer(nil)
+
+	tests := []struct {
+		name         string
+		baseline     string
+		current      string
+		wantBreaking int
+		wantSymbol   string
+	}{
+		{
+			name: "flags materially changed doc on exported function",
+			baseline: `
+				package service
+				// Lookup returns the order for id, or nil if no order exists.
+				func Lookup(id string) *Order {
+					return nil
+				}
+			`,
+			current: `
+				package service
+				// Lookup returns the order for id. It panics when the order is missing.
+				func Lookup(id string) *Order {
+					return nil
+				}
+			`,
+			wantBreaking: 1,
+			wantSymbol:   "Lookup",
+		},
+		{
+			name: "flags rewritten doc dropping documented parameters",
+			baseline: `
+				package service
+				// Transfer moves amount from source to target using the given currency.
+				type Transfer struct{}
+			`,
+			current: `
+				package service
+				// Transfer schedules a settlement batch for later reconciliation.
+				type Transfer struct{}
+			`,
+			wantBreaking: 1,
+			wantSymbol:   "Transfer",
+		},
+		{
+			name: "ignores wording fix",
+			baseline: `
+				package service
+				// Lookup returns the order for the given id.
+				func Lookup(id string) *Order {
+					return nil
+				}
+			`,
+			current: `
+				package service
+				// Lookup returns the order for a given id.
+				func Lookup(id string) *Order {
+					return nil
+				}
+			`,
+			wantBreaking: 0,
+		},
+		{
+			name: "ignores unexported function",
+			baseline: `
+				package service
+				// lookup returns nil when missing.
+				func lookup(id string) *Order {
+					return nil
+				}
+			`,
+			current: `
+				package service
+				// lookup panics when missing.
+				func lookup(id string) *Order {
+					return nil
+				}
+			`,
+			wantBreaking: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := &ArchitectureAnalysisRequest{
+				FileContents:     map[string]string{"service.go": tt.current},
+				BaselineContents: map[string]string{"service.go": tt.baseline},
+				Language:         "go",
+			}
+
+			assessment, err := analyzer.Analyze(context.Background(), req)
+			require.NoError(t, err)
+
+			require.Len(t, assessment.BreakingChanges, tt.wantBreaking)
+			if tt.wantBreaking > 0 {
+				bc := assessment.BreakingChanges[0]
+				require.Equal(t, events.BreakingChangeChangedBehavior, bc.ChangeType)
+				require.Equal(t, tt.wantSymbol, bc.Symbol)
+				require.True(t, assessment.RequiresArchitectureReview)
+			}
+		})
+	}
+}
+
+func TestPatternArchitectureAnalyzer_DocChangeWithSignatureChange(t *testing.T) {
+	analyzer := NewPatternArchitectureAnalyzer(nil)
+
+	req := &ArchitectureAnalysisRequest{
+		BaselineContents: map[string]string{"service.go": `
+			package service
+			// Process handles the order and returns nil on success.
+			func Process(order Order) error {
+				return nil
+			}
+		`},
+		FileContents: map[string]string{"service.go": `
+			package service
+			// Process never fails; errors are logged.
+			func Process(order Order, opts Options) {
+			}
+		`},
+		Language: "go",
+	}
+
+	assessment, err := analyzer.Analyze(context.Background(), req)
+	require.NoError(t, err)
+
+	// The signature change is reported once; the doc change is not duplicated.
+	require.Len(t, assessment.BreakingChanges, 1)
+	require.Equal(t, events.BreakingChangeChangedSignature, assessment.BreakingChanges[0].ChangeType)
+}
+
 func TestPatternArchitectureAnalyzer_DependencyViolations(t *testing.T) {
 	analyzer := NewPatternArchitectureAnalyzer(nil)
 
@@ -600,3 +732,44 @@ type GiantClass struct {}
 	}
 	return builder.String()
 }
+
+func TestPatternArchitectureAnalyzer_MixedLanguageRepository(t *testing.T) {
+	analyzer := NewPatternArchitectureAnalyzer(nil)
+
+	req := &ArchitectureAnalysisRequest{
+		FileContents: map[string]string{
+			"app/handlers/user_handler.go": `package handlers
+
+import (
+	"github.com/project/repository"
+)
+`,
+			"web/handlers/user.js": `import { findUser } from '../repository/user';
+
+export function getUser(id) {
+	return findUser(id);
+}
+`,
+			"api/handlers/user.py": `from app.repository import users
+
+def get_user(user_id):
+    return users.find(user_id)
+`,
+		},
+		// A single request-level language must not hide violations in other languages.
+		Language: "go",
+	}
+
+	assessment, err := analyzer.Analyze(context.Background(), req)
+	require.NoError(t, err)
+
+	violationFiles := make(map[string]bool)
+	for _, dv := range assessment.DependencyViolations {
+		violationFiles[dv.FilePath] = true
+	}
+
+	require.Len(t, assessment.DependencyViolations, 3)
+	require.True(t, violationFiles["app/handlers/user_handler.go"], "expected Go import rules to apply")
+	require.True(t, violationFiles["web/handlers/user.js"], "expected JavaScript import rules to apply")
+	require.True(t, violationFiles["api/handlers/user.py"], "expected Python import rules to apply")
+}
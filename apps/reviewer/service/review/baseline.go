@@ -0,0 +1,185 @@
+package review
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pitabwire/util"
+
+	"github.com/antinvestor/builder/internal/events"
+)
+
+// maxBaselineRequestBytes bounds the size of a baseline scan request body.
+const maxBaselineRequestBytes = 50 * 1024 * 1024
+
+// ErrMissingRepositoryID is returned when a baseline scan has no repository identifier.
+var ErrMissingRepositoryID = errors.New("repository_id is required")
+
+// BaselineStore stores the fingerprints of a repository's pre-existing findings.
+type BaselineStore interface {
+	// Save replaces the baseline fingerprints for a repository.
+	Save(ctx context.Context, repositoryID string, fingerprints []string) error
+
+	// Contains reports whether a fingerprint is part of the repository baseline.
+	Contains(ctx context.Context, repositoryID, fingerprint string) bool
+}
+
+// MemoryBaselineStore is an in-memory BaselineStore.
+type MemoryBaselineStore struct {
+	mu        sync.RWMutex
+	baselines map[string]map[string]bool
+}
+
+// NewMemoryBaselineStore creates an empty in-memory baseline store.
+func NewMemoryBaselineStore() *MemoryBaselineStore {
+	return &MemoryBaselineStore{baselines: make(map[string]map[string]bool)}
+}
+
+// Save implements BaselineStore.
+func (s *MemoryBaselineStore) Save(_ context.Context, repositoryID string, fingerprints []string) error {
+	set := make(map[string]bool, len(fingerprints))
+	for _, fp := range fingerprints {
+		set[fp] = true
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.baselines[repositoryID] = set
+	return nil
+}
+
+// Contains implements BaselineStore.
+func (s *MemoryBaselineStore) Contains(_ context.Context, repositoryID, fingerprint string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.baselines[repositoryID][fingerprint]
+}
+
+// findingFingerprint identifies a finding independently of its line number, so a
+// baselined finding is still recognized after unrelated lines shift it.
+func findingFingerprint(filePath, kind, code string) string {
+	normalized := strings.Join(strings.Fields(code), " ")
+	hash := sha256.Sum256([]byte(filePath + "\x00" + kind + "\x00" + normalized))
+	return hex.EncodeToString(hash[:16])
+}
+
+// patternFingerprint returns the fingerprint of an insecure pattern finding.
+func patternFingerprint(p events.InsecurePattern) string {
+	return findingFingerprint(p.FilePath, string(p.PatternType), p.CodeSnippet)
+}
+
+// assessmentFingerprints returns the sorted, de-duplicated fingerprints of the
+// insecure pattern findings. Secrets are never baselined: a committed secret stays
+// exposed however long it has been there, so it is reported on every review.
+func assessmentFingerprints(assessment *events.SecurityAssessment) []string {
+	seen := make(map[string]bool)
+	for _, p := range assessment.InsecurePatterns {
+		seen[patternFingerprint(p)] = true
+	}
+
+	fingerprints := make([]string, 0, len(seen))
+	for fp := range seen {
+		fingerprints = append(fingerprints, fp)
+	}
+	sort.Strings(fingerprints)
+	return fingerprints
+}
+
+// BaselineScanRequest is the request body for a baseline scan.
+type BaselineScanRequest struct {
+	RepositoryID string            `json:"repository_id"`
+	Files        map[string]string `json:"files"`
+}
+
+// BaselineScanResult is the response body of a baseline scan.
+type BaselineScanResult struct {
+	RepositoryID string    `json:"repository_id"`
+	FindingCount int       `json:"finding_count"`
+	Fingerprints []string  `json:"fingerprints"`
+	ScannedFiles int       `json:"scanned_files"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// BaselineService establishes a repository's current findings as its baseline.
+type BaselineService struct {
+	analyzer *PatternSecurityAnalyzer
+	store    BaselineStore
+}
+
+// NewBaselineService creates a baseline service using the analyzer's rules.
+func NewBaselineService(analyzer *PatternSecurityAnalyzer, store BaselineStore) *BaselineService {
+	return &BaselineService{analyzer: analyzer, store: store}
+}
+
+// Scan analyzes the repository contents and stores the resulting fingerprint set.
+func (s *BaselineService) Scan(ctx context.Context, req *BaselineScanRequest) (*BaselineScanResult, error) {
+	if req.RepositoryID == "" {
+		return nil, ErrMissingRepositoryID
+	}
+
+	// Scan without a repository so an existing baseline does not hide findings.
+	assessment, err := s.analyzer.Analyze(ctx, &SecurityAnalysisRequest{FileContents: req.Files})
+	if err != nil {
+		return nil, fmt.Errorf("baseline analysis: %w", err)
+	}
+
+	fingerprints := assessmentFingerprints(assessment)
+	if saveErr := s.store.Save(ctx, req.RepositoryID, fingerprints); saveErr != nil {
+		return nil, fmt.Errorf("save baseline: %w", saveErr)
+	}
+
+	util.Log(ctx).Info("repository baseline established",
+		"repository_id", req.RepositoryID,
+		"files", len(req.Files),
+		"findings", len(fingerprints),
+	)
+
+	return &BaselineScanResult{
+		RepositoryID: req.RepositoryID,
+		FindingCount: len(fingerprints),
+		Fingerprints: fingerprints,
+		ScannedFiles: len(req.Files),
+		CreatedAt:    time.Now(),
+	}, nil
+}
+
+// NewBaselineHTTPHandler returns the handler for POST /api/v1/baseline.
+func NewBaselineHTTPHandler(service *BaselineService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		var req BaselineScanRequest
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBaselineRequestBytes)).Decode(&req); err != nil {
+			http.Error(w, "invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		result, err := service.Scan(r.Context(), &req)
+		if err != nil {
+			if errors.Is(err, ErrMissingRepositoryID) {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		if encodeErr := json.NewEncoder(w).Encode(result); encodeErr != nil {
+			util.Log(r.Context()).WithError(encodeErr).Error("failed to encode baseline result")
+		}
+	}
+}
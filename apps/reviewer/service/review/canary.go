@@ -0,0 +1,228 @@
+package review
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/pitabwire/util"
+
+	appconfig "github.com/antinvestor/builder/apps/reviewer/config"
+	"github.com/antinvestor/builder/internal/events"
+)
+
+// CanaryResult records how a candidate pattern set's findings compared with the
+// stable set's for one sampled review.
+type CanaryResult struct {
+	ExecutionID    events.ExecutionID `json:"execution_id"`
+	StableFindings int                `json:"stable_findings"`
+	CanaryFindings int                `json:"canary_findings"`
+	// Added are findings only the candidate reported.
+	Added []string `json:"added,omitempty"`
+	// Removed are findings only the stable set reported.
+	Removed    []string  `json:"removed,omitempty"`
+	Diverged   bool      `json:"diverged"`
+	Error      string    `json:"error,omitempty"`
+	RecordedAt time.Time `json:"recorded_at"`
+}
+
+// CanaryStore stores canary results for review before a full rollout.
+type CanaryStore interface {
+	// Record stores a canary result.
+	Record(ctx context.Context, result CanaryResult) error
+}
+
+// maxCanaryResults bounds the results a MemoryCanaryStore keeps; the oldest are
+// dropped first.
+const maxCanaryResults = 1000
+
+// MemoryCanaryStore is an in-memory CanaryStore keeping the most recent results.
+type MemoryCanaryStore struct {
+	mu      sync.RWMutex
+	results []CanaryResult
+}
+
+// NewMemoryCanaryStore creates an empty in-memory canary store.
+func NewMemoryCanaryStore() *MemoryCanaryStore {
+	return &MemoryCanaryStore{}
+}
+
+// Record implements CanaryStore.
+func (s *MemoryCanaryStore) Record(_ context.Context, result CanaryResult) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.results) >= maxCanaryResults {
+		s.results = slices.Delete(s.results, 0, len(s.results)-maxCanaryResults+1)
+	}
+	s.results = append(s.results, result)
+	return nil
+}
+
+// Results returns the kept canary results in recording order.
+func (s *MemoryCanaryStore) Results() []CanaryResult {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return append([]CanaryResult(nil), s.results...)
+}
+
+// Divergences returns the canary results whose findings differ from the stable set's.
+func (s *MemoryCanaryStore) Divergences() []CanaryResult {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var divergences []CanaryResult
+	for _, r := range s.results {
+		if r.Diverged {
+			divergences = append(divergences, r)
+		}
+	}
+	return divergences
+}
+
+// CanarySecurityAnalyzer rolls out a new pattern set gradually: the stable analyzer
+// decides every review, and on a sampled fraction of executions the candidate also
+// runs so its findings can be compared before it replaces the stable set.
+type CanarySecurityAnalyzer struct {
+	stable    SecurityAnalyzer
+	candidate SecurityAnalyzer
+	fraction  float64
+	store     CanaryStore
+	now       func() time.Time
+}
+
+// NewCanarySecurityAnalyzer creates a canary running candidate on the configured
+// fraction of executions.
+func NewCanarySecurityAnalyzer(
+	cfg *appconfig.ReviewerConfig,
+	stable SecurityAnalyzer,
+	candidate SecurityAnalyzer,
+	store CanaryStore,
+) *CanarySecurityAnalyzer {
+	return &CanarySecurityAnalyzer{
+		stable:    stable,
+		candidate: candidate,
+		fraction:  cfg.AnalyzerCanaryFraction,
+		store:     store,
+		now:       time.Now,
+	}
+}
+
+// Analyze implements SecurityAnalyzer, returning the stable assessment. Candidate
+// failures are logged and recorded, never returned.
+func (a *CanarySecurityAnalyzer) Analyze(
+	ctx context.Context,
+	req *SecurityAnalysisRequest,
+) (*events.SecurityAssessment, error) {
+	assessment, err := a.stable.Analyze(ctx, req)
+	if err != nil || !canarySampled(req.ExecutionID, a.fraction) {
+		return assessment, err
+	}
+
+	log := util.Log(ctx)
+	stableFindings := securityFindingKeys(assessment)
+	result := CanaryResult{
+		ExecutionID:    req.ExecutionID,
+		StableFindings: len(stableFindings),
+		RecordedAt:     a.now(),
+	}
+
+	candidate, candidateErr := a.candidate.Analyze(ctx, req)
+	if candidateErr != nil {
+		result.Error = candidateErr.Error()
+		log.WithError(candidateErr).Warn("canary analysis failed", "execution_id", req.ExecutionID.String())
+	} else {
+		canaryFindings := securityFindingKeys(candidate)
+		result.CanaryFindings = len(canaryFindings)
+		result.Added = missingFrom(canaryFindings, stableFindings)
+		result.Removed = missingFrom(stableFindings, canaryFindings)
+		result.Diverged = len(result.Added) > 0 || len(result.Removed) > 0
+
+		log.Info("canary analysis evaluated",
+			"execution_id", req.ExecutionID.String(),
+			"stable_findings", result.StableFindings,
+			"canary_findings", result.CanaryFindings,
+			"diverged", result.Diverged,
+		)
+	}
+
+	if recordErr := a.store.Record(ctx, result); recordErr != nil {
+		log.WithError(recordErr).Error("failed to record canary result")
+	}
+	return assessment, nil
+}
+
+// canarySampled reports whether an execution falls in the canary fraction. Sampling
+// hashes the execution ID, so every iteration of an execution gets the same answer.
+// Execution IDs are sequential, so a hash that mixes every input byte is needed.
+func canarySampled(executionID events.ExecutionID, fraction float64) bool {
+	if fraction <= 0 {
+		return false
+	}
+	if fraction >= 1 {
+		return true
+	}
+	sum := sha256.Sum256([]byte(executionID.String()))
+	return float64(binary.BigEndian.Uint64(sum[:8]))/math.MaxUint64 < fraction
+}
+
+// securityFindingKeys identifies the findings of an assessment by type and location, sorted.
+func securityFindingKeys(assessment *events.SecurityAssessment) []string {
+	if assessment == nil {
+		return nil
+	}
+	var keys []string
+	for _, v := range assessment.VulnerabilitiesFound {
+		keys = append(keys, fmt.Sprintf("vulnerability:%s:%s:%d", v.Type, v.FilePath, v.LineStart))
+	}
+	for _, s := range assessment.SecretsDetected {
+		keys = append(keys, fmt.Sprintf("secret:%s:%s:%d", s.Type, s.FilePath, s.LineNumber))
+	}
+	for _, p := range assessment.InsecurePatterns {
+		keys = append(keys, fmt.Sprintf("pattern:%s:%s:%d", p.PatternType, p.FilePath, p.LineStart))
+	}
+	for _, d := range assessment.DependencyVulnerabilities {
+		keys = append(keys, fmt.Sprintf("dependency:%s@%s", d.Package, d.Version))
+	}
+	slices.Sort(keys)
+	return slices.Compact(keys)
+}
+
+// missingFrom returns the keys in a that are not in the sorted keys b.
+func missingFrom(a, b []string) []string {
+	var missing []string
+	for _, key := range a {
+		if _, found := slices.BinarySearch(b, key); !found {
+			missing = append(missing, key)
+		}
+	}
+	return missing
+}
+
+// NewCanaryDivergencesHTTPHandler returns the handler for GET /api/v1/canary/divergences
+// listing the canary results whose findings differed from the stable set's.
+func NewCanaryDivergencesHTTPHandler(store *MemoryCanaryStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		divergences := store.Divergences()
+		if divergences == nil {
+			divergences = []CanaryResult{}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		if err := json.NewEncoder(w).Encode(divergences); err != nil {
+			util.Log(r.Context()).WithError(err).Error("failed to encode canary divergences")
+		}
+	}
+}
@@ -0,0 +1,127 @@
+//nolint:testpackage // white-box testing requires internal package access
+package review
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	appconfig "github.com/antinvestor/builder/apps/reviewer/config"
+	"github.com/antinvestor/builder/internal/events"
+)
+
+// fixedSecurityAnalyzer reports the same assessment or error on every run and counts its runs.
+type fixedSecurityAnalyzer struct {
+	assessment *events.SecurityAssessment
+	err        error
+	runs       int
+}
+
+func (a *fixedSecurityAnalyzer) Analyze(context.Context, *SecurityAnalysisRequest) (*events.SecurityAssessment, error) {
+	a.runs++
+	return a.assessment, a.err
+}
+
+func assessmentWithVulnerabilities(vulns ...events.Vulnerability) *events.SecurityAssessment {
+	assessment := newCleanSecurityAssessment()
+	assessment.VulnerabilitiesFound = vulns
+	return assessment
+}
+
+func TestCanarySampled_FractionControlsRate(t *testing.T) {
+	const executions = 4000
+	for _, fraction := range []float64{0.1, 0.5, 0.9} {
+		sampled := 0
+		for range executions {
+			if canarySampled(events.NewExecutionID(), fraction) {
+				sampled++
+			}
+		}
+		assert.InDelta(t, fraction, float64(sampled)/executions, 0.05, "fraction %.1f", fraction)
+	}
+
+	execID := events.NewExecutionID()
+	assert.False(t, canarySampled(execID, 0))
+	assert.True(t, canarySampled(execID, 1))
+	assert.Equal(t, canarySampled(execID, 0.5), canarySampled(execID, 0.5))
+}
+
+func TestCanarySecurityAnalyzer_RecordsDivergences(t *testing.T) {
+	injection := events.Vulnerability{Type: events.VulnerabilityTypeInjection, FilePath: "db.go", LineStart: 12}
+	xss := events.Vulnerability{Type: events.VulnerabilityTypeXSS, FilePath: "view.js", LineStart: 3}
+	stable := &fixedSecurityAnalyzer{assessment: assessmentWithVulnerabilities(injection)}
+	candidate := &fixedSecurityAnalyzer{assessment: assessmentWithVulnerabilities(xss)}
+	store := NewMemoryCanaryStore()
+
+	canary := NewCanarySecurityAnalyzer(&appconfig.ReviewerConfig{AnalyzerCanaryFraction: 1}, stable, candidate, store)
+	execID := events.NewExecutionID()
+	assessment, err := canary.Analyze(context.Background(), &SecurityAnalysisRequest{ExecutionID: execID})
+	require.NoError(t, err)
+
+	// The stable findings decide the review
+	assert.Same(t, stable.assessment, assessment)
+	assert.Equal(t, 1, candidate.runs)
+
+	divergences := store.Divergences()
+	require.Len(t, divergences, 1)
+	assert.Equal(t, execID, divergences[0].ExecutionID)
+	assert.Equal(t, []string{"vulnerability:xss:view.js:3"}, divergences[0].Added)
+	assert.Equal(t, []string{"vulnerability:injection:db.go:12"}, divergences[0].Removed)
+}
+
+func TestCanarySecurityAnalyzer_MatchingFindingsDoNotDiverge(t *testing.T) {
+	finding := events.Vulnerability{Type: events.VulnerabilityTypeXSS, FilePath: "view.js", LineStart: 3}
+	store := NewMemoryCanaryStore()
+	canary := NewCanarySecurityAnalyzer(&appconfig.ReviewerConfig{AnalyzerCanaryFraction: 1},
+		&fixedSecurityAnalyzer{assessment: assessmentWithVulnerabilities(finding)},
+		&fixedSecurityAnalyzer{assessment: assessmentWithVulnerabilities(finding)},
+		store)
+
+	_, err := canary.Analyze(context.Background(), &SecurityAnalysisRequest{ExecutionID: events.NewExecutionID()})
+	require.NoError(t, err)
+	require.Len(t, store.Results(), 1)
+	assert.Empty(t, store.Divergences())
+}
+
+func TestCanarySecurityAnalyzer_UnsampledSkipsCandidate(t *testing.T) {
+	candidate := &fixedSecurityAnalyzer{assessment: newCleanSecurityAssessment()}
+	store := NewMemoryCanaryStore()
+	canary := NewCanarySecurityAnalyzer(&appconfig.ReviewerConfig{},
+		&fixedSecurityAnalyzer{assessment: newCleanSecurityAssessment()}, candidate, store)
+
+	_, err := canary.Analyze(context.Background(), &SecurityAnalysisRequest{ExecutionID: events.NewExecutionID()})
+	require.NoError(t, err)
+	assert.Zero(t, candidate.runs)
+	assert.Empty(t, store.Results())
+}
+
+func TestCanarySecurityAnalyzer_CandidateFailureIsRecorded(t *testing.T) {
+	stable := &fixedSecurityAnalyzer{assessment: newCleanSecurityAssessment()}
+	store := NewMemoryCanaryStore()
+	canary := NewCanarySecurityAnalyzer(&appconfig.ReviewerConfig{AnalyzerCanaryFraction: 1},
+		stable, &fixedSecurityAnalyzer{err: errors.New("invalid pattern")}, store)
+
+	assessment, err := canary.Analyze(context.Background(), &SecurityAnalysisRequest{ExecutionID: events.NewExecutionID()})
+	require.NoError(t, err)
+	assert.Same(t, stable.assessment, assessment)
+
+	results := store.Results()
+	require.Len(t, results, 1)
+	assert.Equal(t, "invalid pattern", results[0].Error)
+	assert.False(t, results[0].Diverged)
+}
+
+func TestMemoryCanaryStore_KeepsMostRecentResults(t *testing.T) {
+	store := NewMemoryCanaryStore()
+	for i := range maxCanaryResults + 10 {
+		require.NoError(t, store.Record(context.Background(), CanaryResult{StableFindings: i}))
+	}
+
+	results := store.Results()
+	require.Len(t, results, maxCanaryResults)
+	assert.Equal(t, 10, results[0].StableFindings, "the oldest results are dropped first")
+	assert.Equal(t, maxCanaryResults+9, results[len(results)-1].StableFindings)
+}
@@ -0,0 +1,266 @@
+package review
+
+import (
+	"maps"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/antinvestor/builder/internal/events"
+)
+
+// scriptExtensions are tried, in order, when resolving an extensionless
+// JavaScript or TypeScript import to a file.
+var scriptExtensions = []string{".ts", ".tsx", ".js", ".jsx", "/index.ts", "/index.js"}
+
+// changedCircularDependencies detects the import cycles of the change applied to
+// the baseline, so a cycle is found even when only one of its files changed.
+func (a *PatternArchitectureAnalyzer) changedCircularDependencies(
+	req *ArchitectureAnalysisRequest,
+) []events.CircularDependency {
+	current := make(map[string]string, len(req.BaselineContents)+len(req.FileContents))
+	maps.Copy(current, req.BaselineContents)
+	maps.Copy(current, req.FileContents)
+	for _, patch := range req.Patches {
+		if patch.Action == events.FileActionDelete {
+			delete(current, patch.FilePath)
+		}
+	}
+
+	return attributeCircularDependencies(
+		a.detectCircularDependencies(current, req.Language),
+		a.detectCircularDependencies(req.BaselineContents, req.Language),
+		changedFiles(req),
+		importGraph(req.BaselineContents, req.Language),
+	)
+}
+
+// detectCircularDependencies finds the import cycles between the given files.
+// Each strongly connected component of the import graph is reported once, as the
+// path from its first file (in sort order) back to itself. A file importing
+// itself is not a cycle.
+func (a *PatternArchitectureAnalyzer) detectCircularDependencies(
+	files map[string]string,
+	language string,
+) []events.CircularDependency {
+	graph := importGraph(files, language)
+
+	var cycles []events.CircularDependency
+	for _, component := range stronglyConnectedComponents(graph) {
+		if len(component) < 2 { //nolint:mnd // a cycle needs two files
+			continue
+		}
+		members := make(map[string]bool, len(component))
+		for _, file := range component {
+			members[file] = true
+		}
+		start := slices.Min(component)
+		cycles = append(cycles, events.CircularDependency{Cycle: cyclePath(graph, members, start)})
+	}
+	return cycles
+}
+
+// importGraph maps each file to the files it imports, resolved within files and
+// sorted. Imports of packages outside files are dropped.
+func importGraph(files map[string]string, fallbackLanguage string) map[string][]string {
+	graph := make(map[string][]string, len(files))
+	for filePath, content := range files {
+		language := languageForFile(filePath, fallbackLanguage)
+		var deps []string
+		for _, imp := range extractImports(content, language) {
+			for _, target := range resolveImport(filePath, imp, language, files) {
+				if target != filePath && !slices.Contains(deps, target) {
+					deps = append(deps, target)
+				}
+			}
+		}
+		slices.Sort(deps)
+		graph[filePath] = deps
+	}
+	return graph
+}
+
+// resolveImport returns the files in files that an import of filePath refers to.
+// A Go import refers to every file of the package directory it names.
+func resolveImport(filePath, imp, language string, files map[string]string) []string {
+	var targets []string
+	switch language {
+	case langGo:
+		for candidate := range files {
+			dir := path.Dir(candidate)
+			if dir != "." && (imp == dir || strings.HasSuffix(imp, "/"+dir)) {
+				targets = append(targets, candidate)
+			}
+		}
+	case langJavaScript, langTypeScript:
+		if !strings.HasPrefix(imp, ".") {
+			return nil
+		}
+		base := path.Join(path.Dir(filePath), imp)
+		if _, ok := files[base]; ok {
+			return []string{base}
+		}
+		for _, ext := range scriptExtensions {
+			if _, ok := files[base+ext]; ok {
+				return []string{base + ext}
+			}
+		}
+	case langPython:
+		module := strings.TrimLeft(imp, ".")
+		if module == "" {
+			return nil
+		}
+		modulePath := strings.ReplaceAll(module, ".", "/")
+		if dots := len(imp) - len(module); dots > 0 {
+			// Relative imports climb one directory per dot after the first
+			dir := path.Dir(filePath)
+			for range dots - 1 {
+				dir = path.Dir(dir)
+			}
+			modulePath = path.Join(dir, modulePath)
+		}
+		for candidate := range files {
+			for _, suffix := range []string{modulePath + ".py", modulePath + "/__init__.py"} {
+				if candidate == suffix || strings.HasSuffix(candidate, "/"+suffix) {
+					targets = append(targets, candidate)
+				}
+			}
+		}
+	}
+	return targets
+}
+
+// stronglyConnectedComponents returns the strongly connected components of the
+// import graph using Tarjan's algorithm, visiting files in sort order.
+func stronglyConnectedComponents(graph map[string][]string) [][]string {
+	index := make(map[string]int, len(graph))
+	lowLink := make(map[string]int, len(graph))
+	onStack := make(map[string]bool, len(graph))
+	var stack []string
+	var components [][]string
+
+	var visit func(file string)
+	visit = func(file string) {
+		index[file] = len(index)
+		lowLink[file] = index[file]
+		stack = append(stack, file)
+		onStack[file] = true
+
+		for _, dep := range graph[file] {
+			if _, seen := index[dep]; !seen {
+				visit(dep)
+				lowLink[file] = min(lowLink[file], lowLink[dep])
+			} else if onStack[dep] {
+				lowLink[file] = min(lowLink[file], index[dep])
+			}
+		}
+
+		if lowLink[file] != index[file] {
+			return
+		}
+		var component []string
+		for {
+			top := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			onStack[top] = false
+			component = append(component, top)
+			if top == file {
+				break
+			}
+		}
+		components = append(components, component)
+	}
+
+	for _, file := range slices.Sorted(maps.Keys(graph)) {
+		if _, seen := index[file]; !seen {
+			visit(file)
+		}
+	}
+	return components
+}
+
+// cyclePath returns a path through the component's members from start back to
+// start, following the first import that leads back, so A -> B -> C -> A.
+func cyclePath(graph map[string][]string, members map[string]bool, start string) []string {
+	visited := map[string]bool{start: true}
+	var walk func(file string, trail []string) []string
+	walk = func(file string, trail []string) []string {
+		for _, dep := range graph[file] {
+			if !members[dep] {
+				continue
+			}
+			if dep == start {
+				return append(trail, start)
+			}
+			if visited[dep] {
+				continue
+			}
+			visited[dep] = true
+			if found := walk(dep, append(trail, dep)); found != nil {
+				return found
+			}
+		}
+		return nil
+	}
+	return walk(start, []string{start})
+}
+
+// attributeCircularDependencies keeps the cycles that pass through a changed
+// file, marking those absent from the baseline as new. IntroducedBy is the
+// changed file whose import closes the loop: the first changed file in the cycle
+// whose import of the next file is not in its baseline, or else the first
+// changed file in the cycle.
+func attributeCircularDependencies(
+	cycles, baselineCycles []events.CircularDependency,
+	changed map[string]bool,
+	baselineGraph map[string][]string,
+) []events.CircularDependency {
+	existing := make(map[string]bool, len(baselineCycles))
+	for _, cycle := range baselineCycles {
+		existing[cycleKey(cycle.Cycle)] = true
+	}
+
+	attributed := []events.CircularDependency{}
+	for _, cycle := range cycles {
+		for i, file := range cycle.Cycle[:len(cycle.Cycle)-1] {
+			if !changed[file] {
+				continue
+			}
+			if cycle.IntroducedBy == "" {
+				cycle.IntroducedBy = file
+			}
+			if !slices.Contains(baselineGraph[file], cycle.Cycle[i+1]) {
+				cycle.IntroducedBy = file
+				break
+			}
+		}
+		if cycle.IntroducedBy == "" {
+			continue
+		}
+		cycle.IsNew = !existing[cycleKey(cycle.Cycle)]
+		attributed = append(attributed, cycle)
+	}
+	return attributed
+}
+
+// cycleKey identifies a cycle by the files in it, regardless of where it starts.
+func cycleKey(cycle []string) string {
+	files := slices.Clone(cycle[:len(cycle)-1])
+	slices.Sort(files)
+	return strings.Join(files, "\x00")
+}
+
+// changedFiles returns the files a change touches: the patched files and any
+// file whose content differs from its baseline.
+func changedFiles(req *ArchitectureAnalysisRequest) map[string]bool {
+	changed := make(map[string]bool, len(req.Patches)+len(req.FileContents))
+	for _, patch := range req.Patches {
+		changed[patch.FilePath] = true
+	}
+	for filePath, content := range req.FileContents {
+		if baseline, ok := req.BaselineContents[filePath]; !ok || baseline != content {
+			changed[filePath] = true
+		}
+	}
+	return changed
+}
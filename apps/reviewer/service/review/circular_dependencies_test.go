@@ -0,0 +1,146 @@
+package review //nolint:testpackage // white-box testing requires internal access
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/antinvestor/builder/internal/events"
+)
+
+func goFile(pkg string, imports ...string) string {
+	content := "package " + pkg + "\n\nimport (\n"
+	for _, imp := range imports {
+		content += "\t\"" + imp + "\"\n"
+	}
+	return content + ")\n"
+}
+
+func TestPatternArchitectureAnalyzer_CircularDependencies(t *testing.T) {
+	analyzer := NewPatternArchitectureAnalyzer(nil)
+
+	tests := []struct {
+		name  string
+		files map[string]string
+		want  [][]string
+	}{
+		{
+			name: "two-node cycle",
+			files: map[string]string{
+				"orders/orders.go":   goFile("orders", "github.com/acme/shop/billing"),
+				"billing/billing.go": goFile("billing", "github.com/acme/shop/orders"),
+			},
+			want: [][]string{{"billing/billing.go", "orders/orders.go", "billing/billing.go"}},
+		},
+		{
+			name: "three-node cycle",
+			files: map[string]string{
+				"src/a.ts": "import { b } from './b';\n",
+				"src/b.ts": "import { c } from './c';\n",
+				"src/c.ts": "import { a } from './a';\n",
+			},
+			want: [][]string{{"src/a.ts", "src/b.ts", "src/c.ts", "src/a.ts"}},
+		},
+		{
+			name: "self-referential import ignored",
+			files: map[string]string{
+				"orders/orders.go": goFile("orders", "github.com/acme/shop/orders"),
+				"pkg/util.py":      "from pkg.util import helper\n",
+			},
+		},
+		{
+			name: "acyclic imports",
+			files: map[string]string{
+				"orders/orders.go":   goFile("orders", "github.com/acme/shop/billing"),
+				"billing/billing.go": goFile("billing", "fmt"),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got [][]string
+			for _, cycle := range analyzer.detectCircularDependencies(tt.files, "") {
+				got = append(got, cycle.Cycle)
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestPatternArchitectureAnalyzer_CircularDependencyIntroducedByChange(t *testing.T) {
+	analyzer := NewPatternArchitectureAnalyzer(nil)
+
+	// Only billing.go changed; orders.go is unchanged and imports billing already
+	assessment, err := analyzer.Analyze(context.Background(), &ArchitectureAnalysisRequest{
+		Patches: []events.Patch{{FilePath: "billing/billing.go", Action: events.FileActionModify}},
+		FileContents: map[string]string{
+			"billing/billing.go": goFile("billing", "github.com/acme/shop/orders"),
+		},
+		BaselineContents: map[string]string{
+			"orders/orders.go":   goFile("orders", "github.com/acme/shop/billing"),
+			"billing/billing.go": goFile("billing", "fmt"),
+		},
+	})
+	require.NoError(t, err)
+
+	require.Len(t, assessment.CircularDependencies, 1)
+	cycle := assessment.CircularDependencies[0]
+	assert.Equal(t, []string{"billing/billing.go", "orders/orders.go", "billing/billing.go"}, cycle.Cycle)
+	assert.Equal(t, "billing/billing.go", cycle.IntroducedBy)
+	assert.True(t, cycle.IsNew)
+	assert.True(t, assessment.RequiresArchitectureReview)
+	assert.Equal(t, "Circular dependencies introduced", assessment.ArchitectureReviewReason)
+}
+
+func TestPatternArchitectureAnalyzer_CircularDependencyClosedByLaterFile(t *testing.T) {
+	analyzer := NewPatternArchitectureAnalyzer(nil)
+
+	// Both files changed, but only orders' import of billing is new
+	assessment, err := analyzer.Analyze(context.Background(), &ArchitectureAnalysisRequest{
+		FileContents: map[string]string{
+			"billing/billing.go": goFile("billing", "github.com/acme/shop/orders", "fmt"),
+			"orders/orders.go":   goFile("orders", "github.com/acme/shop/billing"),
+		},
+		BaselineContents: map[string]string{
+			"billing/billing.go": goFile("billing", "github.com/acme/shop/orders"),
+			"orders/orders.go":   goFile("orders"),
+		},
+	})
+	require.NoError(t, err)
+
+	require.Len(t, assessment.CircularDependencies, 1)
+	assert.Equal(t, "orders/orders.go", assessment.CircularDependencies[0].IntroducedBy)
+	assert.True(t, assessment.CircularDependencies[0].IsNew)
+}
+
+func TestPatternArchitectureAnalyzer_ExistingCircularDependency(t *testing.T) {
+	analyzer := NewPatternArchitectureAnalyzer(nil)
+	baseline := map[string]string{
+		"orders/orders.go":   goFile("orders", "github.com/acme/shop/billing"),
+		"billing/billing.go": goFile("billing", "github.com/acme/shop/orders"),
+		"audit/audit.go":     goFile("audit"),
+	}
+
+	// A change touching the cycle reports it as existing
+	assessment, err := analyzer.Analyze(context.Background(), &ArchitectureAnalysisRequest{
+		FileContents: map[string]string{
+			"orders/orders.go": goFile("orders", "github.com/acme/shop/billing", "fmt"),
+		},
+		BaselineContents: baseline,
+	})
+	require.NoError(t, err)
+	require.Len(t, assessment.CircularDependencies, 1)
+	assert.False(t, assessment.CircularDependencies[0].IsNew)
+	assert.Equal(t, "orders/orders.go", assessment.CircularDependencies[0].IntroducedBy)
+
+	// A change outside the cycle does not report it
+	assessment, err = analyzer.Analyze(context.Background(), &ArchitectureAnalysisRequest{
+		FileContents:     map[string]string{"audit/audit.go": goFile("audit", "fmt")},
+		BaselineContents: baseline,
+	})
+	require.NoError(t, err)
+	assert.Empty(t, assessment.CircularDependencies)
+}
@@ -0,0 +1,74 @@
+package review
+
+import (
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/antinvestor/builder/internal/events"
+)
+
+// matchingFiles returns the changed files matching any of the path globs, in
+// patch order.
+func matchingFiles(patches []events.Patch, patterns []string) []string {
+	var files []string
+	for _, patch := range patches {
+		for _, pattern := range patterns {
+			if matchPathGlob(pattern, patch.FilePath) {
+				files = append(files, patch.FilePath)
+				break
+			}
+		}
+	}
+	return files
+}
+
+// withoutPaths returns the patches whose files match none of the path globs.
+func withoutPaths(patches []events.Patch, patterns []string) []events.Patch {
+	if len(patterns) == 0 {
+		return patches
+	}
+	kept := make([]events.Patch, 0, len(patches))
+	for _, patch := range patches {
+		if !slices.ContainsFunc(patterns, func(pattern string) bool {
+			return matchPathGlob(pattern, patch.FilePath)
+		}) {
+			kept = append(kept, patch)
+		}
+	}
+	return kept
+}
+
+// matchPathGlob reports whether a slash-separated file path matches a glob.
+// Segments use path.Match syntax and "**" matches zero or more directories.
+// A pattern without a slash matches the file name at any depth.
+func matchPathGlob(pattern, filePath string) bool {
+	filePath = strings.TrimPrefix(path.Clean(filePath), "/")
+	pattern = strings.TrimPrefix(pattern, "/")
+	if !strings.Contains(pattern, "/") {
+		matched, _ := path.Match(pattern, path.Base(filePath))
+		return matched
+	}
+	return matchSegments(strings.Split(pattern, "/"), strings.Split(filePath, "/"))
+}
+
+func matchSegments(pattern, segments []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for skip := range len(segments) + 1 {
+				if matchSegments(pattern[1:], segments[skip:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(segments) == 0 {
+			return false
+		}
+		if matched, _ := path.Match(pattern[0], segments[0]); !matched {
+			return false
+		}
+		pattern, segments = pattern[1:], segments[1:]
+	}
+	return len(segments) == 0
+}
@@ -0,0 +1,182 @@
+package review
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/antinvestor/builder/internal/events"
+)
+
+// analyzerNameDeadCode names the dead code analyzer in issue IDs and metrics.
+const analyzerNameDeadCode = "dead_code"
+
+var (
+	// goPrivateDeclaration captures a top-level unexported function (no receiver) or variable.
+	goPrivateDeclaration = regexp.MustCompile(`^(func|var)\s+([a-z_]\w*)\b`)
+	// hunkNewStart captures the first new-file line number of a hunk header.
+	hunkNewStart = regexp.MustCompile(`^@@ -\d+(?:,\d+)? \+(\d+)`)
+)
+
+// DeadCodeAnalyzer flags unexported Go functions and variables added by a change
+// that nothing in their package references. It only reasons about the changed
+// files, so it stays conservative: exported symbols, methods and anything whose
+// name appears anywhere else in the package's changes are never flagged. A new
+// unexported symbol cannot be used by files the change did not touch.
+type DeadCodeAnalyzer struct{}
+
+// NewDeadCodeAnalyzer creates a dead code analyzer.
+func NewDeadCodeAnalyzer() *DeadCodeAnalyzer {
+	return &DeadCodeAnalyzer{}
+}
+
+// Name implements Analyzer.
+func (a *DeadCodeAnalyzer) Name() string {
+	return analyzerNameDeadCode
+}
+
+// deadCodeCandidate is a declaration added by a change.
+type deadCodeCandidate struct {
+	kind     string
+	name     string
+	filePath string
+	line     int
+	text     string
+}
+
+// packageChanges collects the Go changes of one package directory.
+type packageChanges struct {
+	candidates []deadCodeCandidate
+	// removed are names declared on removed lines; they existed before the change.
+	removed map[string]bool
+	// text holds every other line of the changes, searched for references.
+	text strings.Builder
+}
+
+// Analyze implements Analyzer.
+func (a *DeadCodeAnalyzer) Analyze(_ context.Context, req *AnalysisRequest) ([]events.ReviewIssue, error) {
+	packages := make(map[string]*packageChanges)
+	var order []string
+
+	for _, patch := range req.Patches {
+		if patch.Action == events.FileActionDelete || path.Ext(patch.FilePath) != ".go" {
+			continue
+		}
+		dir := path.Dir(patch.FilePath)
+		pkg, ok := packages[dir]
+		if !ok {
+			pkg = &packageChanges{removed: make(map[string]bool)}
+			packages[dir] = pkg
+			order = append(order, dir)
+		}
+		pkg.collect(patch, req.FileContents[patch.FilePath])
+	}
+
+	var issues []events.ReviewIssue
+	for _, dir := range order {
+		pkg := packages[dir]
+		text := pkg.text.String()
+		for _, c := range pkg.candidates {
+			if pkg.removed[c.name] || isReferenced(c.name, text) {
+				continue
+			}
+			issues = append(issues, deadCodeIssue(c))
+		}
+	}
+	return issues, nil
+}
+
+// collect sorts the lines of a patch into declaration candidates, names
+// declared before the change and reference text.
+func (p *packageChanges) collect(patch events.Patch, content string) {
+	// Without a diff, a created file's content is all new
+	if patch.DiffContent == "" && patch.Action == events.FileActionCreate {
+		for i, line := range strings.Split(content, "\n") {
+			p.addLine(patch.FilePath, i+1, line)
+		}
+		return
+	}
+
+	// The full content, when known, covers references outside the diff hunks
+	for line := range strings.SplitSeq(content, "\n") {
+		p.addText(line)
+	}
+
+	newLine := 0
+	for line := range strings.SplitSeq(patch.DiffContent, "\n") {
+		switch {
+		case strings.HasPrefix(line, "+++"), strings.HasPrefix(line, "---"):
+			continue
+		case strings.HasPrefix(line, "@@"):
+			if match := hunkNewStart.FindStringSubmatch(line); match != nil {
+				newLine, _ = strconv.Atoi(match[1])
+			}
+			// The enclosing declaration git prints after the header is a reference too
+			p.addText(line)
+		case strings.HasPrefix(line, "+"):
+			p.addLine(patch.FilePath, newLine, line[1:])
+			newLine++
+		case strings.HasPrefix(line, "-"):
+			if match := goPrivateDeclaration.FindStringSubmatch(line[1:]); match != nil {
+				p.removed[match[2]] = true
+			}
+		default:
+			p.addText(strings.TrimPrefix(line, " "))
+			newLine++
+		}
+	}
+}
+
+// addLine records an added line as a candidate declaration or as reference text.
+func (p *packageChanges) addLine(filePath string, lineNumber int, line string) {
+	match := goPrivateDeclaration.FindStringSubmatch(line)
+	if match != nil && match[2] != "init" && match[2] != "main" && match[2] != "_" {
+		p.candidates = append(p.candidates, deadCodeCandidate{
+			kind:     match[1],
+			name:     match[2],
+			filePath: filePath,
+			line:     lineNumber,
+			text:     strings.TrimSpace(line),
+		})
+	}
+	p.addText(line)
+}
+
+// addText adds a line to the reference text. The name a line declares is left
+// out, so a declaration does not count as a reference to itself; the rest, e.g. a
+// variable's initializer, can reference other names.
+func (p *packageChanges) addText(line string) {
+	if match := goPrivateDeclaration.FindStringSubmatch(line); match != nil {
+		line = line[len(match[0]):]
+	}
+	p.text.WriteString(line)
+	p.text.WriteByte('\n')
+}
+
+// isReferenced reports whether name appears as a word in text. Comments and
+// strings count, so names used through go:linkname or reflection are not flagged.
+func isReferenced(name, text string) bool {
+	return regexp.MustCompile(`\b` + regexp.QuoteMeta(name) + `\b`).MatchString(text)
+}
+
+func deadCodeIssue(c deadCodeCandidate) events.ReviewIssue {
+	kind := "function"
+	if c.kind == "var" {
+		kind = "variable"
+	}
+	return events.ReviewIssue{
+		ID:          fmt.Sprintf("%s:%s:%s", analyzerNameDeadCode, c.filePath, c.name),
+		Type:        events.ReviewIssueTypeDeadCode,
+		Severity:    events.ReviewIssueSeverityLow,
+		FilePath:    c.filePath,
+		LineStart:   c.line,
+		LineEnd:     c.line,
+		Title:       fmt.Sprintf("Unused %s %s", kind, c.name),
+		Description: fmt.Sprintf("The new unexported %s %s is not referenced anywhere in its package.", kind, c.name),
+		Suggestion:  "Remove it, or add the code that uses it.",
+		CodeSnippet: c.text,
+	}
+}
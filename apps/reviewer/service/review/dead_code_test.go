@@ -0,0 +1,128 @@
+//nolint:testpackage // white-box testing requires internal package access
+package review
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/antinvestor/builder/internal/events"
+)
+
+func analyzeDeadCode(t *testing.T, patches ...events.Patch) []events.ReviewIssue {
+	t.Helper()
+	issues, err := NewDeadCodeAnalyzer().Analyze(context.Background(), &AnalysisRequest{Patches: patches})
+	require.NoError(t, err)
+	return issues
+}
+
+func TestDeadCodeAnalyzer_FlagsUnreferencedPrivateFunction(t *testing.T) {
+	issues := analyzeDeadCode(t, events.Patch{
+		FilePath: "calc/calc.go",
+		Action:   events.FileActionModify,
+		DiffContent: "--- a/calc/calc.go\n+++ b/calc/calc.go\n" +
+			"@@ -3,3 +3,9 @@ package calc\n" +
+			" func Add(a, b int) int {\n" +
+			" \treturn a + b\n" +
+			" }\n" +
+			"+\n" +
+			"+func double(n int) int {\n" +
+			"+\treturn n * 2\n" +
+			"+}\n" +
+			"+\n" +
+			"+var defaultScale = 10\n",
+	})
+
+	require.Len(t, issues, 2)
+	assert.Equal(t, events.ReviewIssueTypeDeadCode, issues[0].Type)
+	assert.Equal(t, events.ReviewIssueSeverityLow, issues[0].Severity)
+	assert.Equal(t, "dead_code:calc/calc.go:double", issues[0].ID)
+	assert.Equal(t, "Unused function double", issues[0].Title)
+	assert.Equal(t, 7, issues[0].LineStart)
+	assert.Equal(t, "Unused variable defaultScale", issues[1].Title)
+	assert.Equal(t, 11, issues[1].LineStart)
+}
+
+func TestDeadCodeAnalyzer_IgnoresUsedFunction(t *testing.T) {
+	// double is used from another changed file of the same package
+	issues := analyzeDeadCode(t,
+		events.Patch{
+			FilePath:    "calc/double.go",
+			Action:      events.FileActionCreate,
+			DiffContent: "@@ -0,0 +1,5 @@\n+package calc\n+\n+func double(n int) int {\n+\treturn n * 2\n+}\n",
+		},
+		events.Patch{
+			FilePath:    "calc/calc.go",
+			Action:      events.FileActionModify,
+			DiffContent: "@@ -3,3 +3,3 @@ package calc\n func Twice(n int) int {\n-\treturn n + n\n+\treturn double(n)\n }\n",
+		},
+	)
+	assert.Empty(t, issues)
+}
+
+func TestDeadCodeAnalyzer_Conservative(t *testing.T) {
+	tests := []struct {
+		name  string
+		patch events.Patch
+	}{
+		{
+			name: "exported function",
+			patch: events.Patch{FilePath: "calc/calc.go", Action: events.FileActionModify,
+				DiffContent: "@@ -1,1 +1,3 @@\n package calc\n+\n+func Double(n int) int { return n * 2 }\n"},
+		},
+		{
+			name: "method",
+			patch: events.Patch{FilePath: "calc/calc.go", Action: events.FileActionModify,
+				DiffContent: "@@ -1,1 +1,3 @@\n package calc\n+\n+func (c calc) double(n int) int { return n * 2 }\n"},
+		},
+		{
+			name: "declaration that existed before",
+			patch: events.Patch{FilePath: "calc/calc.go", Action: events.FileActionModify,
+				DiffContent: "@@ -1,2 +1,2 @@\n package calc\n-func double(n int) int { return n + n }\n" +
+					"+func double(n int) int { return n * 2 }\n"},
+		},
+		{
+			name: "name used through linkname",
+			patch: events.Patch{FilePath: "calc/calc.go", Action: events.FileActionModify,
+				DiffContent: "@@ -1,1 +1,4 @@\n package calc\n+\n+//go:linkname double runtime.double\n" +
+					"+func double(n int) int\n"},
+		},
+		{
+			name: "init function",
+			patch: events.Patch{FilePath: "calc/calc.go", Action: events.FileActionModify,
+				DiffContent: "@@ -1,1 +1,3 @@\n package calc\n+\n+func init() {}\n"},
+		},
+		{
+			name: "non-Go file",
+			patch: events.Patch{FilePath: "calc/calc.py", Action: events.FileActionModify,
+				DiffContent: "@@ -1,1 +1,3 @@\n import os\n+\n+def double(n): return n * 2\n"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Empty(t, analyzeDeadCode(t, tt.patch))
+		})
+	}
+}
+
+func TestDeadCodeAnalyzer_UsesFullContents(t *testing.T) {
+	patch := events.Patch{
+		FilePath:    "calc/calc.go",
+		Action:      events.FileActionModify,
+		DiffContent: "@@ -8,1 +8,3 @@\n }\n+\n+func double(n int) int { return n * 2 }\n",
+	}
+
+	// The call sits outside the diff hunk but in the file's current content
+	issues, err := NewDeadCodeAnalyzer().Analyze(context.Background(), &AnalysisRequest{
+		Patches: []events.Patch{patch},
+		FileContents: map[string]string{
+			"calc/calc.go": "package calc\n\nfunc Twice(n int) int {\n\treturn double(n)\n}\n\n" +
+				"func double(n int) int { return n * 2 }\n",
+		},
+	})
+	require.NoError(t, err)
+	assert.Empty(t, issues)
+}
@@ -1,8 +1,10 @@
 package review
 
 import (
+	"cmp"
 	"context"
 	"fmt"
+	"slices"
 	"strings"
 
 	"github.com/pitabwire/util"
@@ -22,7 +24,8 @@ const (
 
 // ThresholdDecisionEngine implements comprehensive threshold-based decision making.
 type ThresholdDecisionEngine struct {
-	cfg *appconfig.ReviewerConfig
+	cfg            *appconfig.ReviewerConfig
+	approvalWindow *ApprovalWindowPolicy
 }
 
 // NewThresholdDecisionEngine creates a new threshold-based decision engine.
@@ -30,6 +33,11 @@ func NewThresholdDecisionEngine(cfg *appconfig.ReviewerConfig) *ThresholdDecisio
 	return &ThresholdDecisionEngine{cfg: cfg}
 }
 
+// SetApprovalWindowPolicy restricts automated approvals to the policy's windows.
+func (e *ThresholdDecisionEngine) SetApprovalWindowPolicy(policy *ApprovalWindowPolicy) {
+	e.approvalWindow = policy
+}
+
 // MakeDecision makes a comprehensive control decision based on thresholds.
 func (e *ThresholdDecisionEngine) MakeDecision(ctx context.Context, req *DecisionRequest) (*DecisionResult, error) {
 	log := util.Log(ctx)
@@ -68,6 +76,37 @@ func (e *ThresholdDecisionEngine) MakeDecision(ctx context.Context, req *Decisio
 	// Evaluate test results
 	testPassing := e.evaluateTestResults(req, thresholds, result)
 
+	// Evaluate build warnings
+	warningIssues, warningsBlocking := e.evaluateBuildWarnings(req, thresholds, result)
+	result.BlockingIssues = append(result.BlockingIssues, warningIssues...)
+
+	// Evaluate issues reported by custom analyzers
+	customIssues, customBlocking := e.evaluateCustomIssues(req, thresholds, result)
+	result.BlockingIssues = append(result.BlockingIssues, customIssues...)
+
+	// Surface acceptance criteria the generator could not confirm
+	e.evaluateAcceptanceAssessment(req, result)
+
+	// Note files left out of an oversized review
+	if len(req.UnreviewedFiles) > 0 {
+		result.Warnings = append(result.Warnings, fmt.Sprintf(
+			"Partial review: %d files exceeded the review size limits and were not analyzed",
+			len(req.UnreviewedFiles)))
+	}
+
+	// Note analyzers whose findings are missing
+	if len(req.FailedAnalyzers) > 0 {
+		result.Warnings = append(result.Warnings, fmt.Sprintf(
+			"Incomplete review: analyzers failed: %s", strings.Join(req.FailedAnalyzers, ", ")))
+	}
+
+	// Note high-impact config changes
+	if len(req.ScrutinizedFiles) > 0 {
+		result.Warnings = append(result.Warnings, fmt.Sprintf(
+			"Changes to high-impact config files require extra scrutiny: %s",
+			strings.Join(req.ScrutinizedFiles, ", ")))
+	}
+
 	// Calculate risk assessment
 	result.RiskAssessment = e.calculateRiskAssessment(req, thresholds)
 
@@ -81,6 +120,8 @@ func (e *ThresholdDecisionEngine) MakeDecision(ctx context.Context, req *Decisio
 		securityBlocking,
 		archBlocking,
 		testPassing,
+		warningsBlocking,
+		customBlocking,
 		criticalCount,
 		highCount,
 		result,
@@ -89,6 +130,12 @@ func (e *ThresholdDecisionEngine) MakeDecision(ctx context.Context, req *Decisio
 	result.Decision = decision
 	result.Rationale = rationale
 
+	// Outside the approval windows, approvals wait for a human
+	if e.approvalWindow != nil {
+		e.approvalWindow.Apply(result)
+		decision = result.Decision
+	}
+
 	// Generate next actions
 	result.NextActions = e.generateNextActions(result, req)
 
@@ -108,11 +155,11 @@ func (e *ThresholdDecisionEngine) MakeDecision(ctx context.Context, req *Decisio
 }
 
 func (e *ThresholdDecisionEngine) getThresholds(req *DecisionRequest) events.ReviewThresholds {
-	// Use request thresholds if provided, otherwise use config
+	// Use request thresholds if provided, otherwise the config profile for the target environment
 	if req.Thresholds.MaxRiskScore > 0 {
 		return req.Thresholds
 	}
-	return e.cfg.GetReviewThresholds()
+	return e.cfg.GetEnvironmentThresholds(req.TargetEnvironment)
 }
 
 func (e *ThresholdDecisionEngine) evaluateIterationCount(
@@ -144,22 +191,16 @@ func (e *ThresholdDecisionEngine) evaluateSecurityAssessment(
 
 	sec := req.SecurityAssessment
 
-	// Check for secrets if blocking is enabled
-	if e.cfg.BlockOnSecrets && len(sec.SecretsDetected) > 0 {
+	// Check for secrets according to the per-type secret policy
+	secretIssues, warnedSecrets := e.evaluateSecrets(sec.SecretsDetected)
+	if len(secretIssues) > 0 {
 		hasBlocking = true
-		for _, secret := range sec.SecretsDetected {
-			blockingIssues = append(blockingIssues, events.ReviewIssue{
-				ID:          fmt.Sprintf("secret-%s-%d", secret.FilePath, secret.LineNumber),
-				Type:        events.ReviewIssueTypeSecurity,
-				Severity:    events.ReviewIssueSeverityCritical,
-				FilePath:    secret.FilePath,
-				LineStart:   secret.LineNumber,
-				Title:       fmt.Sprintf("Secret detected: %s", secret.Type),
-				Description: secret.Description,
-				Suggestion:  "Remove the secret and use environment variables or a secrets manager",
-			})
-		}
-		result.Warnings = append(result.Warnings, fmt.Sprintf("%d secrets detected in code", len(sec.SecretsDetected)))
+		blockingIssues = append(blockingIssues, secretIssues...)
+		result.Warnings = append(result.Warnings, fmt.Sprintf("%d secrets detected in code", len(secretIssues)))
+	}
+	for _, secret := range warnedSecrets {
+		result.Warnings = append(result.Warnings,
+			fmt.Sprintf("Secret detected: %s in %s:%d", secret.Type, secret.FilePath, secret.LineNumber))
 	}
 
 	// Check vulnerabilities by severity
@@ -180,6 +221,22 @@ func (e *ThresholdDecisionEngine) evaluateSecurityAssessment(
 		}
 	}
 
+	// Critically vulnerable dependencies block like critical vulnerabilities
+	for _, dep := range sec.DependencyVulnerabilities {
+		if dep.Severity == events.VulnerabilitySeverityCritical {
+			hasBlocking = true
+			blockingIssues = append(blockingIssues, dependencyIssue(dep))
+		}
+	}
+
+	// Findings over their type's cap block as one summary issue per type
+	for _, throttled := range sec.ThrottledFindings {
+		if issue, ok := e.throttledBlockingIssue(throttled); ok {
+			hasBlocking = true
+			blockingIssues = append(blockingIssues, issue)
+		}
+	}
+
 	// Check security score against threshold
 	securityRiskScore := maxScore - sec.OverallSecurityScore
 	if thresholds.MaxSecurityRiskScore > 0 && securityRiskScore > thresholds.MaxSecurityRiskScore {
@@ -198,6 +255,74 @@ func (e *ThresholdDecisionEngine) evaluateSecurityAssessment(
 	return blockingIssues, hasBlocking
 }
 
+// evaluateSecrets applies the secret policy: blocked secrets become issues with the
+// configured severity, warned secrets are returned for reporting and ignored ones dropped.
+func (e *ThresholdDecisionEngine) evaluateSecrets(
+	secrets []events.SecretFinding,
+) ([]events.ReviewIssue, []events.SecretFinding) {
+	var blocking []events.ReviewIssue
+	var warned []events.SecretFinding
+
+	for _, secret := range secrets {
+		switch e.cfg.SecretAction(secret.Type) {
+		case appconfig.SecretActionBlock:
+			blocking = append(blocking, events.ReviewIssue{
+				ID:          fmt.Sprintf("secret-%s-%d", secret.FilePath, secret.LineNumber),
+				Type:        events.ReviewIssueTypeSecurity,
+				Severity:    e.cfg.SecretSeverity(secret.Type),
+				FilePath:    secret.FilePath,
+				LineStart:   secret.LineNumber,
+				Title:       fmt.Sprintf("Secret detected: %s", secret.Type),
+				Description: secret.Description,
+				Suggestion:  "Remove the secret and use environment variables or a secrets manager",
+			})
+		case appconfig.SecretActionWarn:
+			warned = append(warned, secret)
+		case appconfig.SecretActionIgnore:
+		}
+	}
+
+	return blocking, warned
+}
+
+// throttledSeverity returns the severity that throttled security findings count at
+// in decisions. Only vulnerabilities and secrets count.
+func (e *ThresholdDecisionEngine) throttledSeverity(
+	throttled events.ThrottledFindings,
+) (events.ReviewIssueSeverity, bool) {
+	switch throttled.Category {
+	case findingCategoryVulnerability:
+		return events.ReviewIssueSeverity(throttled.Severity), true
+	case findingCategorySecret:
+		return e.cfg.SecretSeverity(throttled.Type), true
+	}
+	return "", false
+}
+
+// throttledBlockingIssue summarizes throttled findings that would each have been a
+// blocking issue: critical vulnerabilities and blocked secrets.
+func (e *ThresholdDecisionEngine) throttledBlockingIssue(
+	throttled events.ThrottledFindings,
+) (events.ReviewIssue, bool) {
+	severity, ok := e.throttledSeverity(throttled)
+	switch {
+	case !ok:
+		return events.ReviewIssue{}, false
+	case throttled.Category == findingCategoryVulnerability && severity != events.ReviewIssueSeverityCritical:
+		return events.ReviewIssue{}, false
+	case throttled.Category == findingCategorySecret && e.cfg.SecretAction(throttled.Type) != appconfig.SecretActionBlock:
+		return events.ReviewIssue{}, false
+	}
+	return events.ReviewIssue{
+		ID:       fmt.Sprintf("throttled-%s-%s", throttled.Category, throttled.Type),
+		Type:     events.ReviewIssueTypeSecurity,
+		Severity: severity,
+		Title:    throttledSummary(throttled),
+		Description: fmt.Sprintf("%d more %s findings were not listed individually after the first %d",
+			throttled.Omitted, throttled.Type, throttled.Reported),
+	}, true
+}
+
 func (e *ThresholdDecisionEngine) evaluateArchitectureAssessment(
 	req *DecisionRequest,
 	thresholds events.ReviewThresholds,
@@ -231,6 +356,24 @@ func (e *ThresholdDecisionEngine) evaluateArchitectureAssessment(
 				len(arch.BreakingChanges), thresholds.MaxBreakingChanges))
 	}
 
+	// Removed or skipped tests are never acceptable ways to pass review
+	if len(arch.TestRegressions) > 0 {
+		hasBlocking = true
+		for _, regression := range arch.TestRegressions {
+			blockingIssues = append(blockingIssues, events.ReviewIssue{
+				ID:          fmt.Sprintf("test-%s-%s-%s", regression.RegressionType, regression.FilePath, regression.TestName),
+				Type:        events.ReviewIssueTypeBug,
+				Severity:    regression.Severity,
+				FilePath:    regression.FilePath,
+				Title:       fmt.Sprintf("Test regression: %s", regression.RegressionType),
+				Description: regression.Description,
+				Suggestion:  "Restore the test and fix the code it covers",
+			})
+		}
+		result.Warnings = append(result.Warnings,
+			fmt.Sprintf("%d tests removed or disabled", len(arch.TestRegressions)))
+	}
+
 	// Check architecture score against threshold
 	archRiskScore := maxScore - arch.OverallArchitectureScore
 	if thresholds.MaxArchitectureRiskScore > 0 && archRiskScore > thresholds.MaxArchitectureRiskScore {
@@ -275,6 +418,16 @@ func (e *ThresholdDecisionEngine) evaluateTestResults(
 		return false
 	}
 
+	if len(testResult.QuarantinedTests) > 0 {
+		result.Warnings = append(result.Warnings,
+			"Flaky tests failed and were quarantined: "+strings.Join(testResult.QuarantinedTests, ", "))
+	}
+
+	// Skipped or slow tests are weak evidence; they add risk but do not fail the run
+	for _, signal := range e.testSignals(testResult) {
+		result.Warnings = append(result.Warnings, signal.warning)
+	}
+
 	// Check coverage threshold if configured
 	if thresholds.MinTestCoverage > 0 && testResult.Coverage < thresholds.MinTestCoverage {
 		result.Warnings = append(result.Warnings,
@@ -283,9 +436,130 @@ func (e *ThresholdDecisionEngine) evaluateTestResults(
 		return false
 	}
 
+	// Check coverage against the baseline if regressions are not allowed; a result
+	// without a baseline is not checked, and says so
+	if thresholds.NoCoverageRegression && testResult.BaselineCoverage <= 0 {
+		result.Warnings = append(result.Warnings,
+			"Coverage regression not checked: the test result has no baseline coverage")
+	}
+	if drop, regressed := coverageRegression(testResult, thresholds); regressed {
+		result.Warnings = append(result.Warnings,
+			fmt.Sprintf("Test coverage regressed by %.1f points from baseline %.1f%% to %.1f%% (tolerance %.1f)",
+				drop, testResult.BaselineCoverage, testResult.Coverage, thresholds.CoverageRegressionTolerance))
+		return false
+	}
+
 	return true
 }
 
+// coverageRegression returns how far coverage dropped below the baseline and whether
+// the drop exceeds the tolerance under the NoCoverageRegression policy. A result
+// without a baseline cannot regress.
+func coverageRegression(testResult *events.TestResult, thresholds events.ReviewThresholds) (float64, bool) {
+	if !thresholds.NoCoverageRegression || testResult.BaselineCoverage <= 0 {
+		return 0, false
+	}
+	drop := testResult.BaselineCoverage - testResult.Coverage
+	return drop, drop > thresholds.CoverageRegressionTolerance
+}
+
+// evaluateBuildWarnings surfaces warnings reported while building the tests. When
+// their count exceeds the configured maximum, they become blocking issues so the
+// next iteration can address them by file and line.
+func (e *ThresholdDecisionEngine) evaluateBuildWarnings(
+	req *DecisionRequest,
+	thresholds events.ReviewThresholds,
+	result *DecisionResult,
+) ([]events.ReviewIssue, bool) {
+	if req.TestResult == nil || len(req.TestResult.BuildWarnings) == 0 {
+		return nil, false
+	}
+
+	warnings := req.TestResult.BuildWarnings
+	if thresholds.MaxBuildWarnings > 0 && len(warnings) > thresholds.MaxBuildWarnings {
+		result.Warnings = append(result.Warnings,
+			fmt.Sprintf("Build warnings (%d) exceed threshold (%d)",
+				len(warnings), thresholds.MaxBuildWarnings))
+		return warnings, true
+	}
+
+	result.Warnings = append(result.Warnings, fmt.Sprintf("%d build warnings reported", len(warnings)))
+	return nil, false
+}
+
+// evaluateCustomIssues blocks on critical issues reported by custom analyzers,
+// and on their high severity issues once they exceed the high issue limit; the
+// rest are surfaced as warnings. Destructive migrations always block, and those
+// left to a human block their files without asking for an iteration.
+func (e *ThresholdDecisionEngine) evaluateCustomIssues(
+	req *DecisionRequest,
+	thresholds events.ReviewThresholds,
+	result *DecisionResult,
+) ([]events.ReviewIssue, bool) {
+	highCount := 0
+	for _, issue := range req.CustomIssues {
+		if issue.Severity == events.ReviewIssueSeverityHigh && !e.needsMigrationReview(issue) {
+			highCount++
+		}
+	}
+
+	var blockingIssues []events.ReviewIssue
+	blocking := false
+	for _, issue := range req.CustomIssues {
+		if e.needsMigrationReview(issue) {
+			blockingIssues = append(blockingIssues, issue)
+			result.Warnings = append(result.Warnings, fmt.Sprintf(
+				"Destructive migration requires manual review: %s: %s", issue.FilePath, issue.Title))
+			continue
+		}
+		switch issue.Severity {
+		case events.ReviewIssueSeverityCritical:
+			blockingIssues = append(blockingIssues, issue)
+			blocking = true
+		case events.ReviewIssueSeverityHigh:
+			if highCount > thresholds.MaxHighIssues || issue.Type == events.ReviewIssueTypeMigration {
+				blockingIssues = append(blockingIssues, issue)
+				blocking = true
+				continue
+			}
+			result.Warnings = append(result.Warnings, fmt.Sprintf("%s: %s", issue.FilePath, issue.Title))
+		case events.ReviewIssueSeverityInfo, events.ReviewIssueSeverityLow, events.ReviewIssueSeverityMedium:
+			result.Warnings = append(result.Warnings, fmt.Sprintf("%s: %s", issue.FilePath, issue.Title))
+		}
+	}
+	return blockingIssues, blocking
+}
+
+// needsMigrationReview reports whether an issue is a destructive migration routed
+// to manual review by policy.
+func (e *ThresholdDecisionEngine) needsMigrationReview(issue events.ReviewIssue) bool {
+	return issue.Type == events.ReviewIssueTypeMigration &&
+		e.cfg.MigrationAction() == appconfig.MigrationActionManualReview
+}
+
+// migrationReviewFiles returns the files with destructive migrations routed to manual review.
+func (e *ThresholdDecisionEngine) migrationReviewFiles(req *DecisionRequest) []string {
+	var files []string
+	for _, issue := range req.CustomIssues {
+		if e.needsMigrationReview(issue) && !slices.Contains(files, issue.FilePath) {
+			files = append(files, issue.FilePath)
+		}
+	}
+	return files
+}
+
+// evaluateAcceptanceAssessment warns about acceptance criteria the generator
+// self-assessed as unmet, so an otherwise clean review is approved with warnings.
+func (e *ThresholdDecisionEngine) evaluateAcceptanceAssessment(req *DecisionRequest, result *DecisionResult) {
+	for _, criterion := range req.AcceptanceAssessment.UnmetCriteria() {
+		warning := "Acceptance criterion self-assessed as unmet: " + criterion.Criterion
+		if criterion.Rationale != "" {
+			warning += " (" + criterion.Rationale + ")"
+		}
+		result.Warnings = append(result.Warnings, warning)
+	}
+}
+
 func (e *ThresholdDecisionEngine) calculateRiskAssessment(
 	req *DecisionRequest,
 	thresholds events.ReviewThresholds,
@@ -296,15 +570,15 @@ func (e *ThresholdDecisionEngine) calculateRiskAssessment(
 		AcceptanceThreshold: thresholds.MaxRiskScore,
 	}
 
-	var totalScore int
-	var factorCount int
+	weights := e.riskWeights()
+	var weightedScore, totalWeight float64
 
 	// Security risk
 	if req.SecurityAssessment != nil {
 		secRisk := maxScore - req.SecurityAssessment.OverallSecurityScore
 		ra.SecurityRiskScore = secRisk
-		totalScore += secRisk
-		factorCount++
+		weightedScore += float64(secRisk) * weights.security
+		totalWeight += weights.security
 
 		if secRisk > 0 {
 			ra.RiskFactors = append(ra.RiskFactors, events.RiskFactor{
@@ -319,8 +593,8 @@ func (e *ThresholdDecisionEngine) calculateRiskAssessment(
 	if req.ArchitectureAssessment != nil {
 		archRisk := maxScore - req.ArchitectureAssessment.OverallArchitectureScore
 		ra.ArchitectureRiskScore = archRisk
-		totalScore += archRisk
-		factorCount++
+		weightedScore += float64(archRisk) * weights.architecture
+		totalWeight += weights.architecture
 
 		if archRisk > 0 {
 			ra.RiskFactors = append(ra.RiskFactors, events.RiskFactor{
@@ -330,14 +604,15 @@ func (e *ThresholdDecisionEngine) calculateRiskAssessment(
 			})
 		}
 
-		// Add breaking change risk
+		// Breaking changes are the quality component
 		if len(req.ArchitectureAssessment.BreakingChanges) > 0 {
 			bcRisk := len(req.ArchitectureAssessment.BreakingChanges) * breakingChangeRiskPer
 			if bcRisk > maxScore {
 				bcRisk = maxScore
 			}
-			totalScore += bcRisk
-			factorCount++
+			ra.QualityRiskScore = bcRisk
+			weightedScore += float64(bcRisk) * weights.quality
+			totalWeight += weights.quality
 			ra.RiskFactors = append(ra.RiskFactors, events.RiskFactor{
 				Category:     events.RiskCategoryBreakingChange,
 				Factor:       fmt.Sprintf("%d breaking changes", len(req.ArchitectureAssessment.BreakingChanges)),
@@ -370,13 +645,42 @@ func (e *ThresholdDecisionEngine) calculateRiskAssessment(
 				})
 			}
 		}
-		totalScore += ra.TestRiskScore
-		factorCount++
+		if drop, regressed := coverageRegression(req.TestResult, thresholds); regressed && req.TestResult.Success {
+			ra.TestRiskScore = max(ra.TestRiskScore, int(drop))
+			ra.RiskFactors = append(ra.RiskFactors, events.RiskFactor{
+				Category: events.RiskCategoryTestCoverage,
+				Factor: fmt.Sprintf("Coverage regressed %.1f points from baseline %.1f%%",
+					drop, req.TestResult.BaselineCoverage),
+				Contribution: int(drop),
+			})
+		}
+		for _, signal := range e.testSignals(req.TestResult) {
+			ra.TestRiskScore = min(ra.TestRiskScore+signal.risk, maxScore)
+			ra.RiskFactors = append(ra.RiskFactors, events.RiskFactor{
+				Category:     events.RiskCategoryTestCoverage,
+				Factor:       signal.factor,
+				Contribution: signal.risk,
+			})
+		}
+		weightedScore += float64(ra.TestRiskScore) * weights.test
+		totalWeight += weights.test
 	}
 
-	// Calculate overall score (average of component scores)
-	if factorCount > 0 {
-		ra.OverallRiskScore = totalScore / factorCount
+	// Calculate overall score (weighted mean of component scores)
+	if totalWeight > 0 {
+		ra.OverallRiskScore = int(weightedScore / totalWeight)
+	}
+
+	// Config file risk is added on top, so it cannot be averaged away
+	if len(req.ScrutinizedFiles) > 0 && e.cfg.ScrutinyAction() == appconfig.ScrutinyActionElevateRisk &&
+		e.cfg.ScrutinizedPathRiskScore > 0 {
+		ra.OverallRiskScore = min(ra.OverallRiskScore+e.cfg.ScrutinizedPathRiskScore, maxScore)
+		ra.RiskFactors = append(ra.RiskFactors, events.RiskFactor{
+			Category:      events.RiskCategoryConfiguration,
+			Factor:        fmt.Sprintf("%d high-impact config files changed", len(req.ScrutinizedFiles)),
+			Contribution:  e.cfg.ScrutinizedPathRiskScore,
+			AffectedFiles: req.ScrutinizedFiles,
+		})
 	}
 
 	// Determine risk level
@@ -388,6 +692,29 @@ func (e *ThresholdDecisionEngine) calculateRiskAssessment(
 	return ra
 }
 
+// riskWeights holds the relative weight of each component in the overall risk score.
+type riskWeights struct {
+	security     float64
+	architecture float64
+	test         float64
+	quality      float64
+}
+
+// riskWeights returns the configured component weights, ignoring negative ones.
+// When no component has a positive weight every component is weighted equally.
+func (e *ThresholdDecisionEngine) riskWeights() riskWeights {
+	weights := riskWeights{
+		security:     max(e.cfg.SecurityWeight, 0),
+		architecture: max(e.cfg.ArchitectureWeight, 0),
+		test:         max(e.cfg.TestWeight, 0),
+		quality:      max(e.cfg.QualityWeight, 0),
+	}
+	if weights.security+weights.architecture+weights.test+weights.quality == 0 {
+		return riskWeights{security: 1, architecture: 1, test: 1, quality: 1}
+	}
+	return weights
+}
+
 func (e *ThresholdDecisionEngine) calculateRiskLevel(score int) events.RiskLevel {
 	switch {
 	case score >= riskLevelCriticalMin:
@@ -401,6 +728,27 @@ func (e *ThresholdDecisionEngine) calculateRiskLevel(score int) events.RiskLevel
 	}
 }
 
+// dependencyIssue describes a vulnerable dependency as a review issue.
+func dependencyIssue(dep events.DependencyVulnerability) events.ReviewIssue {
+	filePath := ""
+	if len(dep.DependencyPath) > 0 {
+		filePath = dep.DependencyPath[0]
+	}
+	suggestion := "Remove the dependency or pin an unaffected version"
+	if dep.FixedVersion != "" {
+		suggestion = fmt.Sprintf("Upgrade %s to %s or later", dep.Package, dep.FixedVersion)
+	}
+	return events.ReviewIssue{
+		ID:          fmt.Sprintf("dep-%s-%s", dep.Package, cmp.Or(dep.CVE, dep.Version)),
+		Type:        events.ReviewIssueTypeSecurity,
+		Severity:    events.ReviewIssueSeverityCritical,
+		FilePath:    filePath,
+		Title:       fmt.Sprintf("%s %s: %s", dep.Package, dep.Version, dep.Title),
+		Description: dep.Description,
+		Suggestion:  suggestion,
+	}
+}
+
 func (e *ThresholdDecisionEngine) countIssuesBySeverity(req *DecisionRequest) (int, int) {
 	var criticalCount, highCount int
 
@@ -416,8 +764,36 @@ func (e *ThresholdDecisionEngine) countIssuesBySeverity(req *DecisionRequest) (i
 				// Lower severities don't affect critical/high counts
 			}
 		}
-		// Secrets are considered critical
-		criticalCount += len(req.SecurityAssessment.SecretsDetected)
+		for _, dep := range req.SecurityAssessment.DependencyVulnerabilities {
+			switch dep.Severity {
+			case events.VulnerabilitySeverityCritical:
+				criticalCount++
+			case events.VulnerabilitySeverityHigh:
+				highCount++
+			case events.VulnerabilitySeverityLow, events.VulnerabilitySeverityMedium:
+			}
+		}
+		// Secrets count at their configured severity, whatever their action
+		for _, secret := range req.SecurityAssessment.SecretsDetected {
+			switch e.cfg.SecretSeverity(secret.Type) {
+			case events.ReviewIssueSeverityCritical:
+				criticalCount++
+			case events.ReviewIssueSeverityHigh:
+				highCount++
+			case events.ReviewIssueSeverityInfo, events.ReviewIssueSeverityLow, events.ReviewIssueSeverityMedium:
+			}
+		}
+		// Findings left out by throttling still count
+		for _, throttled := range req.SecurityAssessment.ThrottledFindings {
+			severity, _ := e.throttledSeverity(throttled)
+			switch severity {
+			case events.ReviewIssueSeverityCritical:
+				criticalCount += throttled.Omitted
+			case events.ReviewIssueSeverityHigh:
+				highCount += throttled.Omitted
+			case events.ReviewIssueSeverityInfo, events.ReviewIssueSeverityLow, events.ReviewIssueSeverityMedium:
+			}
+		}
 	}
 
 	// Count from architecture assessment
@@ -432,17 +808,42 @@ func (e *ThresholdDecisionEngine) countIssuesBySeverity(req *DecisionRequest) (i
 				// Lower severities don't affect critical/high counts
 			}
 		}
+		for _, regression := range req.ArchitectureAssessment.TestRegressions {
+			switch regression.Severity {
+			case events.ReviewIssueSeverityCritical:
+				criticalCount++
+			case events.ReviewIssueSeverityHigh:
+				highCount++
+			case events.ReviewIssueSeverityInfo, events.ReviewIssueSeverityLow, events.ReviewIssueSeverityMedium:
+			}
+		}
+	}
+
+	// Count from custom analyzers; migrations a human reviews do not count against the limits
+	for _, issue := range req.CustomIssues {
+		if e.needsMigrationReview(issue) {
+			continue
+		}
+		switch issue.Severity {
+		case events.ReviewIssueSeverityCritical:
+			criticalCount++
+		case events.ReviewIssueSeverityHigh:
+			highCount++
+		case events.ReviewIssueSeverityInfo, events.ReviewIssueSeverityLow, events.ReviewIssueSeverityMedium:
+		}
 	}
 
 	return criticalCount, highCount
 }
 
 func (e *ThresholdDecisionEngine) determineDecision(
-	_ *DecisionRequest,
+	req *DecisionRequest,
 	thresholds events.ReviewThresholds,
 	securityBlocking bool,
 	archBlocking bool,
 	testPassing bool,
+	warningsBlocking bool,
+	customBlocking bool,
 	criticalCount int,
 	highCount int,
 	result *DecisionResult,
@@ -485,8 +886,50 @@ func (e *ThresholdDecisionEngine) determineDecision(
 		reasons = append(reasons, "tests are not passing")
 	}
 
+	// Build warnings over threshold
+	if warningsBlocking {
+		reasons = append(reasons, "build warnings exceed threshold")
+	}
+
+	// Custom analyzer blocking
+	if customBlocking {
+		reasons = append(reasons, "custom analyzer issues require attention")
+	}
+
 	// Determine final decision
 	if len(reasons) == 0 {
+		// Strict mode does not approve on the results of a subset of the analyzers, and
+		// no review approves without the security and secret scan
+		if len(req.FailedAnalyzers) > 0 && (e.cfg.StrictMode || slices.Contains(req.FailedAnalyzers, analyzerNameSecurity)) {
+			return events.ControlDecisionManualReview, fmt.Sprintf(
+				"Manual review required: analyzers failed: %s", strings.Join(req.FailedAnalyzers, ", "))
+		}
+
+		// A review that analyzed nothing cannot approve the change
+		if req.AllFilesIgnored {
+			return events.ControlDecisionManualReview,
+				"Manual review required: every changed file is ignored for review"
+		}
+
+		// A partial review cannot approve files it did not analyze
+		if len(req.UnreviewedFiles) > 0 {
+			return events.ControlDecisionManualReview, fmt.Sprintf(
+				"Manual review required: partial review, %d files not analyzed", len(req.UnreviewedFiles))
+		}
+
+		// High-impact config changes are approved by a human
+		if len(req.ScrutinizedFiles) > 0 && e.cfg.ScrutinyAction() == appconfig.ScrutinyActionManualReview {
+			return events.ControlDecisionManualReview, fmt.Sprintf(
+				"Manual review required: high-impact config files changed: %s",
+				strings.Join(req.ScrutinizedFiles, ", "))
+		}
+
+		// Destructive migrations are approved by a human
+		if files := e.migrationReviewFiles(req); len(files) > 0 {
+			return events.ControlDecisionManualReview, fmt.Sprintf(
+				"Manual review required: destructive migrations in: %s", strings.Join(files, ", "))
+		}
+
 		// All checks passed
 		if len(result.Warnings) > 0 {
 			return events.ControlDecisionApproveWithWarnings,
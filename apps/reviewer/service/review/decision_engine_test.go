@@ -3,6 +3,10 @@ package review
 
 import (
 	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -190,6 +194,107 @@ func TestThresholdDecisionEngine_SecretsDetected_Blocking(t *testing.T) {
 	assert.Equal(t, events.ReviewIssueSeverityCritical, result.BlockingIssues[0].Severity)
 }
 
+func newSecretPolicyEngine() *ThresholdDecisionEngine {
+	cfg := &appconfig.ReviewerConfig{
+		MaxRiskScore:         50,
+		MaxSecurityRiskScore: 30,
+		MaxHighIssues:        2,
+		MaxIterations:        3,
+		BlockOnSecrets:       true,
+		SecretPolicies: map[string]string{
+			"private_key":    "block",
+			"aws_secret_key": "block",
+			"password":       "warn",
+			"jwt_token":      "ignore",
+		},
+		SecretSeverities: map[string]string{"aws_secret_key": "high", "password": "medium", "jwt_token": "low"},
+	}
+	return NewThresholdDecisionEngine(cfg)
+}
+
+func newSecretDecisionRequest(secrets ...events.SecretFinding) *DecisionRequest {
+	secAssessment := newCleanSecurityAssessment()
+	secAssessment.SecretsDetected = secrets
+	return &DecisionRequest{
+		ExecutionID:            events.NewExecutionID(),
+		SecurityAssessment:     secAssessment,
+		ArchitectureAssessment: newCleanArchitectureAssessment(),
+		TestResult:             newPassingTestResult(),
+	}
+}
+
+func TestThresholdDecisionEngine_SecretPolicy_PrivateKeyBlocks(t *testing.T) {
+	engine := newSecretPolicyEngine()
+
+	result, err := engine.MakeDecision(context.Background(), newSecretDecisionRequest(events.SecretFinding{
+		Type: "private_key", FilePath: "deploy/id_rsa", LineNumber: 1, Description: "Private key detected",
+	}))
+
+	require.NoError(t, err)
+	assert.Equal(t, events.ControlDecisionAbort, result.Decision)
+	require.Len(t, result.BlockingIssues, 1)
+	assert.Equal(t, events.ReviewIssueSeverityCritical, result.BlockingIssues[0].Severity)
+	assert.Equal(t, "deploy/id_rsa", result.BlockingIssues[0].FilePath)
+}
+
+func TestThresholdDecisionEngine_SecretPolicy_PasswordWarns(t *testing.T) {
+	engine := newSecretPolicyEngine()
+
+	result, err := engine.MakeDecision(context.Background(), newSecretDecisionRequest(events.SecretFinding{
+		Type: "password", FilePath: "config/dev.go", LineNumber: 12, Description: "Hardcoded password",
+	}))
+
+	require.NoError(t, err)
+	assert.Equal(t, events.ControlDecisionApproveWithWarnings, result.Decision)
+	assert.Empty(t, result.BlockingIssues)
+	require.Len(t, result.Warnings, 1)
+	assert.Contains(t, result.Warnings[0], "password in config/dev.go:12")
+}
+
+func TestThresholdDecisionEngine_SecretPolicy_SeverityAndIgnore(t *testing.T) {
+	engine := newSecretPolicyEngine()
+
+	result, err := engine.MakeDecision(context.Background(), newSecretDecisionRequest(
+		events.SecretFinding{Type: "aws_secret_key", FilePath: "infra/keys.go", LineNumber: 3},
+		events.SecretFinding{Type: "jwt_token", FilePath: "testdata/token.txt", LineNumber: 1},
+	))
+
+	require.NoError(t, err)
+	// A high-severity secret iterates instead of aborting; the ignored token is dropped
+	assert.Equal(t, events.ControlDecisionIterate, result.Decision)
+	require.Len(t, result.BlockingIssues, 1)
+	assert.Equal(t, events.ReviewIssueSeverityHigh, result.BlockingIssues[0].Severity)
+	assert.Equal(t, "infra/keys.go", result.BlockingIssues[0].FilePath)
+}
+
+func TestThresholdDecisionEngine_SecretPolicy_WarnedSecretsCountBySeverity(t *testing.T) {
+	engine := newSecretPolicyEngine()
+	engine.cfg.SecretSeverities = map[string]string{"password": "critical"}
+
+	result, err := engine.MakeDecision(context.Background(), newSecretDecisionRequest(events.SecretFinding{
+		Type: "password", FilePath: "config/prod.go", LineNumber: 4, Description: "Hardcoded password",
+	}))
+
+	require.NoError(t, err)
+	// A warned secret blocks nothing itself but still counts toward the critical limit
+	assert.Empty(t, result.BlockingIssues)
+	assert.Equal(t, events.ControlDecisionAbort, result.Decision)
+}
+
+func TestThresholdDecisionEngine_SecretPolicy_UnlistedTypeFollowsBlockOnSecrets(t *testing.T) {
+	cfg := &appconfig.ReviewerConfig{
+		MaxRiskScore:   50,
+		MaxIterations:  3,
+		BlockOnSecrets: false,
+	}
+
+	assert.Equal(t, appconfig.SecretActionWarn, cfg.SecretAction("api_key"))
+	cfg.BlockOnSecrets = true
+	assert.Equal(t, appconfig.SecretActionBlock, cfg.SecretAction("api_key"))
+	cfg.SecretPolicies = map[string]string{"api_key": "bogus"}
+	assert.Equal(t, appconfig.SecretActionBlock, cfg.SecretAction("api_key"))
+}
+
 func TestThresholdDecisionEngine_HighRiskScore_Iterate(t *testing.T) {
 	// Config without security approval requirement to allow iterate instead of manual review
 	cfg := &appconfig.ReviewerConfig{
@@ -285,6 +390,27 @@ func TestThresholdDecisionEngine_TestsFailing_Iterate(t *testing.T) {
 	assert.Contains(t, result.Rationale, "tests are not passing")
 }
 
+func TestThresholdDecisionEngine_QuarantinedFlakyTests_Warn(t *testing.T) {
+	engine := newTestDecisionEngine()
+
+	testResult := newPassingTestResult()
+	testResult.FailedTests = 1
+	testResult.QuarantinedTests = []string{"TestFlaky"}
+
+	req := &DecisionRequest{
+		ExecutionID:            events.NewExecutionID(),
+		SecurityAssessment:     newCleanSecurityAssessment(),
+		ArchitectureAssessment: newCleanArchitectureAssessment(),
+		TestResult:             testResult,
+	}
+
+	result, err := engine.MakeDecision(context.Background(), req)
+
+	require.NoError(t, err)
+	assert.NotEqual(t, events.ControlDecisionIterate, result.Decision)
+	assert.Contains(t, result.Warnings, "Flaky tests failed and were quarantined: TestFlaky")
+}
+
 func TestThresholdDecisionEngine_LowCoverage_Iterate(t *testing.T) {
 	cfg := &appconfig.ReviewerConfig{
 		MaxRiskScore:  50,
@@ -324,6 +450,149 @@ func TestThresholdDecisionEngine_LowCoverage_Iterate(t *testing.T) {
 	assert.Contains(t, result.Rationale, "tests are not passing")
 }
 
+func newCoverageRegressionRequest(baseline, coverage float64) *DecisionRequest {
+	thresholds := events.ReviewThresholds{
+		MaxRiskScore:                50,
+		MinTestCoverage:             70.0,
+		MaxCriticalIssues:           0,
+		MaxHighIssues:               2,
+		MaxIterations:               3,
+		NoCoverageRegression:        true,
+		CoverageRegressionTolerance: 1.0,
+	}
+	testResult := newPassingTestResult()
+	testResult.BaselineCoverage = baseline
+	testResult.Coverage = coverage
+
+	req := newCleanDecisionRequest()
+	req.TestResult = testResult
+	req.Thresholds = thresholds
+	return req
+}
+
+func TestThresholdDecisionEngine_CoverageRegressionAboveThreshold_Iterate(t *testing.T) {
+	engine := newTestDecisionEngine()
+
+	// 82% is above the 70% minimum but 3 points below the 85% baseline
+	result, err := engine.MakeDecision(context.Background(), newCoverageRegressionRequest(85.0, 82.0))
+
+	require.NoError(t, err)
+	assert.Equal(t, events.ControlDecisionIterate, result.Decision)
+	assert.Contains(t, result.Rationale, "tests are not passing")
+	assert.Contains(t, strings.Join(result.Warnings, "\n"), "regressed by 3.0 points from baseline 85.0%")
+
+	var regression *events.RiskFactor
+	for i, factor := range result.RiskAssessment.RiskFactors {
+		if factor.Category == events.RiskCategoryTestCoverage {
+			regression = &result.RiskAssessment.RiskFactors[i]
+		}
+	}
+	require.NotNil(t, regression)
+	assert.Equal(t, 3, regression.Contribution)
+}
+
+func TestThresholdDecisionEngine_CoverageDropWithinTolerance_Approve(t *testing.T) {
+	engine := newTestDecisionEngine()
+
+	result, err := engine.MakeDecision(context.Background(), newCoverageRegressionRequest(85.0, 84.5))
+
+	require.NoError(t, err)
+	assert.Equal(t, events.ControlDecisionApprove, result.Decision)
+}
+
+func TestThresholdDecisionEngine_CoverageRegressionPolicyDisabled_Approve(t *testing.T) {
+	engine := newTestDecisionEngine()
+	req := newCoverageRegressionRequest(85.0, 82.0)
+	req.Thresholds.NoCoverageRegression = false
+
+	result, err := engine.MakeDecision(context.Background(), req)
+
+	require.NoError(t, err)
+	assert.Equal(t, events.ControlDecisionApprove, result.Decision)
+}
+
+func TestThresholdDecisionEngine_CoverageRegressionWithoutBaseline_Approve(t *testing.T) {
+	engine := newTestDecisionEngine()
+
+	result, err := engine.MakeDecision(context.Background(), newCoverageRegressionRequest(0, 82.0))
+
+	require.NoError(t, err)
+	assert.Equal(t, events.ControlDecisionApproveWithWarnings, result.Decision)
+	assert.Contains(t, result.Warnings, "Coverage regression not checked: the test result has no baseline coverage")
+}
+
+func newBuildWarnings(count int) []events.ReviewIssue {
+	warnings := make([]events.ReviewIssue, 0, count)
+	for i := range count {
+		warnings = append(warnings, events.ReviewIssue{
+			ID:          fmt.Sprintf("build-warning-pkg/handler.go-%d", i+1),
+			Type:        events.ReviewIssueTypeMaintainability,
+			Severity:    events.ReviewIssueSeverityLow,
+			FilePath:    "pkg/handler.go",
+			LineStart:   i + 1,
+			LineEnd:     i + 1,
+			Title:       "Go vet warning",
+			Description: "unreachable code",
+		})
+	}
+	return warnings
+}
+
+func TestThresholdDecisionEngine_ExcessiveBuildWarnings_Iterate(t *testing.T) {
+	cfg := &appconfig.ReviewerConfig{
+		MaxRiskScore:     50,
+		MaxHighIssues:    2,
+		MaxIterations:    3,
+		MaxBuildWarnings: 3,
+	}
+	engine := NewThresholdDecisionEngine(cfg)
+
+	testResult := newPassingTestResult()
+	testResult.BuildWarnings = newBuildWarnings(4)
+
+	req := &DecisionRequest{
+		ExecutionID:            events.NewExecutionID(),
+		SecurityAssessment:     newCleanSecurityAssessment(),
+		ArchitectureAssessment: newCleanArchitectureAssessment(),
+		TestResult:             testResult,
+	}
+
+	result, err := engine.MakeDecision(context.Background(), req)
+
+	require.NoError(t, err)
+	assert.Equal(t, events.ControlDecisionIterate, result.Decision)
+	assert.Contains(t, result.Rationale, "build warnings exceed threshold")
+	assert.Len(t, result.BlockingIssues, 4)
+	assert.Equal(t, "pkg/handler.go", result.BlockingIssues[0].FilePath)
+}
+
+func TestThresholdDecisionEngine_BuildWarningsWithinThreshold_ApproveWithWarnings(t *testing.T) {
+	cfg := &appconfig.ReviewerConfig{
+		MaxRiskScore:     50,
+		MaxHighIssues:    2,
+		MaxIterations:    3,
+		MaxBuildWarnings: 3,
+	}
+	engine := NewThresholdDecisionEngine(cfg)
+
+	testResult := newPassingTestResult()
+	testResult.BuildWarnings = newBuildWarnings(2)
+
+	req := &DecisionRequest{
+		ExecutionID:            events.NewExecutionID(),
+		SecurityAssessment:     newCleanSecurityAssessment(),
+		ArchitectureAssessment: newCleanArchitectureAssessment(),
+		TestResult:             testResult,
+	}
+
+	result, err := engine.MakeDecision(context.Background(), req)
+
+	require.NoError(t, err)
+	assert.Equal(t, events.ControlDecisionApproveWithWarnings, result.Decision)
+	assert.Empty(t, result.BlockingIssues)
+	assert.Contains(t, result.Warnings, "2 build warnings reported")
+}
+
 func TestThresholdDecisionEngine_SecurityReviewRequired_ManualReview(t *testing.T) {
 	engine := newTestDecisionEngine()
 	ctx := context.Background()
@@ -481,6 +750,88 @@ func TestThresholdDecisionEngine_RiskAssessment_Calculated(t *testing.T) {
 	assert.Equal(t, 20, result.RiskAssessment.ArchitectureRiskScore)
 }
 
+func newWeightedRiskRequest() *DecisionRequest {
+	secAssessment := newCleanSecurityAssessment()
+	secAssessment.OverallSecurityScore = 10 // Security risk = 90
+
+	return &DecisionRequest{
+		ExecutionID:            events.NewExecutionID(),
+		SecurityAssessment:     secAssessment,
+		ArchitectureAssessment: newCleanArchitectureAssessment(),
+		TestResult:             newPassingTestResult(),
+	}
+}
+
+func TestThresholdDecisionEngine_RiskWeights(t *testing.T) {
+	tests := []struct {
+		name      string
+		weights   [4]float64 // security, architecture, test, quality
+		wantScore int
+	}{
+		{name: "unset weights are equal", wantScore: 30},
+		{name: "equal weights", weights: [4]float64{1, 1, 1, 1}, wantScore: 30},
+		{name: "weights need not sum to one", weights: [4]float64{0.5, 0.5, 0.5, 0.5}, wantScore: 30},
+		{name: "doubled security weight", weights: [4]float64{2, 1, 1, 1}, wantScore: 45},
+		{name: "zero weight drops a component", weights: [4]float64{1, 0, 0, 1}, wantScore: 90},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			engine := newTestDecisionEngine()
+			engine.cfg.SecurityWeight = tt.weights[0]
+			engine.cfg.ArchitectureWeight = tt.weights[1]
+			engine.cfg.TestWeight = tt.weights[2]
+			engine.cfg.QualityWeight = tt.weights[3]
+
+			req := newWeightedRiskRequest()
+			ra := engine.calculateRiskAssessment(req, engine.getThresholds(req))
+
+			assert.Equal(t, 90, ra.SecurityRiskScore)
+			assert.Equal(t, tt.wantScore, ra.OverallRiskScore)
+		})
+	}
+}
+
+func TestThresholdDecisionEngine_DoubledSecurityWeight_RaisesDominantRisk(t *testing.T) {
+	engine := newTestDecisionEngine()
+	req := newWeightedRiskRequest()
+
+	result, err := engine.MakeDecision(context.Background(), req)
+	require.NoError(t, err)
+
+	engine.cfg.SecurityWeight = 2
+	engine.cfg.ArchitectureWeight = 1
+	engine.cfg.TestWeight = 1
+	engine.cfg.QualityWeight = 1
+	weighted, err := engine.MakeDecision(context.Background(), req)
+	require.NoError(t, err)
+
+	assert.Greater(t, weighted.RiskAssessment.OverallRiskScore, result.RiskAssessment.OverallRiskScore)
+	assert.Equal(t, weighted.RiskAssessment.SecurityRiskScore, result.RiskAssessment.SecurityRiskScore)
+}
+
+func TestThresholdDecisionEngine_BreakingChangesAreQualityRisk(t *testing.T) {
+	engine := newTestDecisionEngine()
+	engine.cfg.SecurityWeight = 1
+	engine.cfg.ArchitectureWeight = 1
+	engine.cfg.TestWeight = 1
+	engine.cfg.QualityWeight = 2
+
+	req := &DecisionRequest{
+		ExecutionID:            events.NewExecutionID(),
+		SecurityAssessment:     newCleanSecurityAssessment(),
+		ArchitectureAssessment: newCleanArchitectureAssessment(),
+		TestResult:             newPassingTestResult(),
+	}
+	req.ArchitectureAssessment.BreakingChanges = []events.BreakingChange{{Symbol: "A"}, {Symbol: "B"}}
+
+	ra := engine.calculateRiskAssessment(req, engine.getThresholds(req))
+
+	assert.Equal(t, 40, ra.QualityRiskScore)
+	// (0 + 0 + 0 + 40*2) / 5
+	assert.Equal(t, 16, ra.OverallRiskScore)
+}
+
 func TestThresholdDecisionEngine_RiskLevel_Calculated(t *testing.T) {
 	tests := []struct {
 		name          string
@@ -675,3 +1026,206 @@ func TestThresholdDecisionEngine_BlockingIssues_Populated(t *testing.T) {
 	assert.True(t, hasSecret, "Secret should be in blocking issues")
 	assert.True(t, hasVuln, "Critical vulnerability should be in blocking issues")
 }
+
+func TestThresholdDecisionEngine_ThresholdHotReload_AppliesToNextDecision(t *testing.T) {
+	cfg := &appconfig.ReviewerConfig{
+		MaxRiskScore:             50,
+		MaxSecurityRiskScore:     30,
+		MaxArchitectureRiskScore: 40,
+		MaxHighIssues:            2,
+		MaxIterations:            3,
+		RequireSecurityApproval:  true,
+		BlockOnSecrets:           true,
+	}
+	engine := NewThresholdDecisionEngine(cfg)
+	ctx := context.Background()
+
+	newRequest := func() *DecisionRequest {
+		return &DecisionRequest{
+			ExecutionID:            events.NewExecutionID(),
+			SecurityAssessment:     newCleanSecurityAssessment(),
+			ArchitectureAssessment: newCleanArchitectureAssessment(),
+			TestResult:             newPassingTestResult(),
+			IterationNumber:        2,
+		}
+	}
+
+	result, err := engine.MakeDecision(ctx, newRequest())
+	require.NoError(t, err)
+	assert.Equal(t, events.ControlDecisionApprove, result.Decision)
+
+	// Tighten the iteration limit at runtime via the thresholds file.
+	path := filepath.Join(t.TempDir(), "thresholds.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{
+		"max_risk_score": 50,
+		"max_security_risk_score": 30,
+		"max_architecture_risk_score": 40,
+		"max_high_issues": 2,
+		"max_iterations": 2
+	}`), 0o600))
+	cfg.ThresholdsFilePath = path
+
+	reloaded, err := NewThresholdsReloader(cfg).Reload(ctx)
+	require.NoError(t, err)
+	require.True(t, reloaded)
+
+	result, err = engine.MakeDecision(ctx, newRequest())
+	require.NoError(t, err)
+	assert.Equal(t, events.ControlDecisionAbort, result.Decision)
+	assert.Contains(t, result.Rationale, "Maximum iterations (2) reached")
+}
+
+func TestThresholdsReloader_InvalidFileKeepsCurrentThresholds(t *testing.T) {
+	cfg := &appconfig.ReviewerConfig{MaxRiskScore: 50, MaxIterations: 3}
+	path := filepath.Join(t.TempDir(), "thresholds.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"max_iterations": 1}`), 0o600))
+	cfg.ThresholdsFilePath = path
+
+	reloaded, err := NewThresholdsReloader(cfg).Reload(context.Background())
+
+	require.ErrorIs(t, err, ErrInvalidThresholds)
+	assert.False(t, reloaded)
+	assert.Equal(t, 3, cfg.GetReviewThresholds().MaxIterations)
+}
+
+func TestThresholdsReloader_UnchangedFileIsNotReapplied(t *testing.T) {
+	cfg := &appconfig.ReviewerConfig{}
+	path := filepath.Join(t.TempDir(), "thresholds.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"max_risk_score": 40}`), 0o600))
+	cfg.ThresholdsFilePath = path
+
+	reloader := NewThresholdsReloader(cfg)
+	ctx := context.Background()
+
+	reloaded, err := reloader.Reload(ctx)
+	require.NoError(t, err)
+	assert.True(t, reloaded)
+
+	reloaded, err = reloader.Reload(ctx)
+	require.NoError(t, err)
+	assert.False(t, reloaded)
+	assert.Equal(t, 40, cfg.GetReviewThresholds().MaxRiskScore)
+}
+
+func newEnvironmentGatedConfig() *appconfig.ReviewerConfig {
+	return &appconfig.ReviewerConfig{
+		MaxRiskScore:                   50,
+		MaxSecurityRiskScore:           30,
+		MaxArchitectureRiskScore:       40,
+		MaxHighIssues:                  2,
+		MaxIterations:                  3,
+		BlockOnSecrets:                 true,
+		ProductionBranches:             "main,prod",
+		ProductionMaxRiskScore:         30,
+		ProductionMaxSecurityRiskScore: 20,
+		ProductionMaxHighIssues:        1,
+		StagingMaxRiskScore:            70,
+		StagingMaxSecurityRiskScore:    50,
+		StagingMaxHighIssues:           5,
+	}
+}
+
+func TestThresholdDecisionEngine_TargetEnvironment_SameFindingsDifferentDecisions(t *testing.T) {
+	cfg := newEnvironmentGatedConfig()
+	engine := NewThresholdDecisionEngine(cfg)
+	ctx := context.Background()
+
+	newRequest := func(branch string) *DecisionRequest {
+		secAssessment := newCleanSecurityAssessment()
+		secAssessment.OverallSecurityScore = 60 // Risk = 40: above production, within staging
+
+		return &DecisionRequest{
+			ExecutionID:            events.NewExecutionID(),
+			SecurityAssessment:     secAssessment,
+			ArchitectureAssessment: newCleanArchitectureAssessment(),
+			TestResult:             newPassingTestResult(),
+			TargetEnvironment:      cfg.ResolveTargetEnvironment(branch),
+		}
+	}
+
+	prodResult, err := engine.MakeDecision(ctx, newRequest("main"))
+	require.NoError(t, err)
+	assert.Equal(t, events.ControlDecisionIterate, prodResult.Decision)
+	assert.Contains(t, prodResult.Rationale, "security issues")
+
+	stagingResult, err := engine.MakeDecision(ctx, newRequest("feature/checkout"))
+	require.NoError(t, err)
+	assert.Equal(t, events.ControlDecisionApprove, stagingResult.Decision)
+}
+
+func TestReviewerConfig_ResolveTargetEnvironment(t *testing.T) {
+	cfg := newEnvironmentGatedConfig()
+
+	tests := []struct {
+		name   string
+		branch string
+		want   events.TargetEnvironment
+	}{
+		{"production branch", "main", events.TargetEnvironmentProduction},
+		{"production ref", "refs/heads/prod", events.TargetEnvironmentProduction},
+		{"feature branch", "feature/login", events.TargetEnvironmentStaging},
+		{"no branch", "", events.TargetEnvironmentUnspecified},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, cfg.ResolveTargetEnvironment(tt.branch))
+		})
+	}
+}
+
+func TestReviewerConfig_GetEnvironmentThresholds(t *testing.T) {
+	cfg := newEnvironmentGatedConfig()
+
+	base := cfg.GetEnvironmentThresholds(events.TargetEnvironmentUnspecified)
+	assert.Equal(t, 50, base.MaxRiskScore)
+	assert.Equal(t, 2, base.MaxHighIssues)
+
+	prod := cfg.GetEnvironmentThresholds(events.TargetEnvironmentProduction)
+	assert.Equal(t, 30, prod.MaxRiskScore)
+	assert.Equal(t, 20, prod.MaxSecurityRiskScore)
+	assert.Equal(t, 1, prod.MaxHighIssues)
+	assert.Equal(t, 40, prod.MaxArchitectureRiskScore, "unset overrides inherit the base value")
+
+	staging := cfg.GetEnvironmentThresholds(events.TargetEnvironmentStaging)
+	assert.Equal(t, 70, staging.MaxRiskScore)
+	assert.Equal(t, 50, staging.MaxSecurityRiskScore)
+	assert.Equal(t, 5, staging.MaxHighIssues)
+}
+
+func TestReviewerConfig_EnvironmentThresholdsWithoutProfiles(t *testing.T) {
+	cfg := &appconfig.ReviewerConfig{MaxRiskScore: 50, MaxSecurityRiskScore: 30, MaxHighIssues: 2}
+	cfg.SetReviewThresholds(events.ReviewThresholds{MaxRiskScore: 40, MaxSecurityRiskScore: 25, MaxHighIssues: 1})
+
+	for _, env := range []events.TargetEnvironment{events.TargetEnvironmentProduction, events.TargetEnvironmentStaging} {
+		thresholds := cfg.GetEnvironmentThresholds(env)
+		assert.Equal(t, 40, thresholds.MaxRiskScore, env)
+		assert.Equal(t, 25, thresholds.MaxSecurityRiskScore, env)
+		assert.Equal(t, 1, thresholds.MaxHighIssues, env)
+	}
+}
+
+func TestThresholdDecisionEngine_UnmetAcceptanceCriteria_ApproveWithWarnings(t *testing.T) {
+	engine := newTestDecisionEngine()
+
+	req := &DecisionRequest{
+		ExecutionID:            events.NewExecutionID(),
+		SecurityAssessment:     newCleanSecurityAssessment(),
+		ArchitectureAssessment: newCleanArchitectureAssessment(),
+		TestResult:             newPassingTestResult(),
+		AcceptanceAssessment: &events.AcceptanceSelfAssessment{
+			Criteria: []events.AcceptanceCriterionAssessment{
+				{Criterion: "Returns all users", Satisfied: true},
+				{Criterion: "Supports pagination", Satisfied: false, Rationale: "no page parameter"},
+			},
+		},
+	}
+
+	result, err := engine.MakeDecision(context.Background(), req)
+
+	require.NoError(t, err)
+	assert.Equal(t, events.ControlDecisionApproveWithWarnings, result.Decision)
+	require.Len(t, result.Warnings, 1)
+	assert.Contains(t, result.Warnings[0], "Supports pagination")
+	assert.Contains(t, result.Warnings[0], "no page parameter")
+}
@@ -0,0 +1,405 @@
+package review
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	appconfig "github.com/antinvestor/builder/apps/reviewer/config"
+	"github.com/antinvestor/builder/internal/events"
+)
+
+// Dependency ecosystems with a manifest the dependency analyzer reads.
+const (
+	ecosystemGo   = "go"
+	ecosystemNPM  = "npm"
+	ecosystemPyPI = "pypi"
+)
+
+// Advisory describes a range of versions of a package with a known vulnerability.
+type Advisory struct {
+	// Ecosystem is go, npm or pypi.
+	Ecosystem string `yaml:"ecosystem"`
+	Package   string `yaml:"package"`
+	// Introduced is the first affected version (empty = every version before FixedVersion).
+	Introduced string `yaml:"introduced"`
+	// FixedVersion is the first unaffected version (empty = no fix released).
+	FixedVersion string                       `yaml:"fixed_version"`
+	CVE          string                       `yaml:"cve"`
+	Severity     events.VulnerabilitySeverity `yaml:"severity"`
+	CVSS         float64                      `yaml:"cvss"`
+	Title        string                       `yaml:"title"`
+	Description  string                       `yaml:"description"`
+}
+
+// affects reports whether version is in the advisory's affected range.
+func (a Advisory) affects(version string) bool {
+	if a.Introduced != "" && compareVersions(version, a.Introduced) < 0 {
+		return false
+	}
+	return a.FixedVersion == "" || compareVersions(version, a.FixedVersion) < 0
+}
+
+// advisoriesFile is the YAML (or JSON) layout of an advisories file.
+type advisoriesFile struct {
+	Advisories []Advisory `yaml:"advisories"`
+}
+
+// DependencyAnalyzer flags vulnerable versions of the dependencies a change
+// introduces or upgrades in go.mod, go.sum, package-lock.json and requirements.txt.
+// Dependencies already pinned at the same version in the baseline are not flagged.
+type DependencyAnalyzer struct {
+	advisories map[string][]Advisory
+}
+
+// NewDependencyAnalyzer creates a dependency analyzer matching the bundled
+// advisories, plus those of cfg.DependencyAdvisoriesPath when set.
+func NewDependencyAnalyzer(cfg *appconfig.ReviewerConfig) (*DependencyAnalyzer, error) {
+	advisories := defaultAdvisories()
+	if cfg != nil && cfg.DependencyAdvisoriesPath != "" {
+		custom, err := LoadAdvisoriesFromFile(cfg.DependencyAdvisoriesPath)
+		if err != nil {
+			return nil, err
+		}
+		advisories = append(advisories, custom...)
+	}
+
+	analyzer := &DependencyAnalyzer{advisories: make(map[string][]Advisory)}
+	for _, advisory := range advisories {
+		key := dependencyKey(advisory.Ecosystem, advisory.Package)
+		analyzer.advisories[key] = append(analyzer.advisories[key], advisory)
+	}
+	return analyzer, nil
+}
+
+// LoadAdvisoriesFromFile reads vulnerability advisories from a YAML or JSON file.
+func LoadAdvisoriesFromFile(filePath string) ([]Advisory, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("read advisories file: %w", err)
+	}
+	var file advisoriesFile
+	if err = yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("parse advisories file: %w", err)
+	}
+	for i, advisory := range file.Advisories {
+		if advisory.Ecosystem == "" || advisory.Package == "" {
+			return nil, fmt.Errorf("advisory %d: ecosystem and package are required", i)
+		}
+		file.Advisories[i].Ecosystem = strings.ToLower(advisory.Ecosystem)
+		file.Advisories[i].Severity = events.VulnerabilitySeverity(strings.ToLower(string(advisory.Severity)))
+	}
+	return file.Advisories, nil
+}
+
+// Analyze returns the vulnerable dependencies that the changed manifests in
+// contents pin at a version absent from their baseline.
+func (a *DependencyAnalyzer) Analyze(contents, baseline map[string]string) []events.DependencyVulnerability {
+	var found []events.DependencyVulnerability
+	reported := make(map[string]bool)
+	for filePath, content := range contents {
+		ecosystem, parse := manifestParser(filePath)
+		if parse == nil {
+			continue
+		}
+		before := parse(baseline[filePath])
+		for name, versions := range parse(content) {
+			for version := range versions {
+				if before[name][version] {
+					continue
+				}
+				for _, advisory := range a.advisories[dependencyKey(ecosystem, name)] {
+					id := strings.Join([]string{ecosystem, name, version, advisory.CVE}, "|")
+					if reported[id] || !advisory.affects(version) {
+						continue
+					}
+					reported[id] = true
+					found = append(found, events.DependencyVulnerability{
+						Package:        name,
+						Version:        version,
+						Severity:       advisory.Severity,
+						CVE:            advisory.CVE,
+						CVSS:           advisory.CVSS,
+						Title:          advisory.Title,
+						Description:    advisory.Description,
+						FixedVersion:   advisory.FixedVersion,
+						DependencyPath: []string{filePath},
+					})
+				}
+			}
+		}
+	}
+	slices.SortFunc(found, func(x, y events.DependencyVulnerability) int {
+		return cmp.Or(strings.Compare(x.Package, y.Package), strings.Compare(x.Version, y.Version),
+			strings.Compare(x.CVE, y.CVE))
+	})
+	return found
+}
+
+// dependencies maps each dependency name to the versions a manifest pins.
+type dependencies map[string]map[string]bool
+
+func (d dependencies) add(name, version string) {
+	if name == "" || version == "" {
+		return
+	}
+	if d[name] == nil {
+		d[name] = make(map[string]bool)
+	}
+	d[name][version] = true
+}
+
+// manifestParser returns the ecosystem and parser of a dependency manifest, or
+// a nil parser for other files.
+func manifestParser(filePath string) (string, func(string) dependencies) {
+	switch path.Base(filePath) {
+	case "go.mod":
+		return ecosystemGo, parseGoMod
+	case "go.sum":
+		return ecosystemGo, parseGoSum
+	case "package-lock.json":
+		return ecosystemNPM, parsePackageLock
+	case "requirements.txt":
+		return ecosystemPyPI, parseRequirements
+	default:
+		return "", nil
+	}
+}
+
+// dependencyKey identifies a package within its ecosystem. Python package names
+// are case-insensitive and treat '_' and '.' as '-'.
+func dependencyKey(ecosystem, name string) string {
+	ecosystem = strings.ToLower(ecosystem)
+	if ecosystem == ecosystemPyPI {
+		name = strings.NewReplacer("_", "-", ".", "-").Replace(strings.ToLower(name))
+	}
+	return ecosystem + ":" + name
+}
+
+// parseGoMod reads the required module versions of a go.mod file.
+func parseGoMod(content string) dependencies {
+	deps := make(dependencies)
+	inRequire := false
+	for line := range strings.SplitSeq(content, "\n") {
+		line, _, _ = strings.Cut(line, "//")
+		fields := strings.Fields(line)
+		switch {
+		case len(fields) == 0:
+			continue
+		case inRequire && fields[0] == ")":
+			inRequire = false
+		case inRequire && len(fields) >= 2:
+			deps.add(fields[0], fields[1])
+		case fields[0] == "require" && len(fields) >= 2 && fields[1] == "(":
+			inRequire = true
+		case fields[0] == "require" && len(fields) >= 3:
+			deps.add(fields[1], fields[2])
+		}
+	}
+	return deps
+}
+
+// parseGoSum reads the module versions checksummed by a go.sum file.
+func parseGoSum(content string) dependencies {
+	deps := make(dependencies)
+	for line := range strings.SplitSeq(content, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 3 || strings.HasSuffix(fields[1], "/go.mod") {
+			continue
+		}
+		deps.add(fields[0], fields[1])
+	}
+	return deps
+}
+
+// packageLock is the part of a package-lock.json the analyzer reads: packages in
+// lockfile v2 and v3, dependencies in v1.
+type packageLock struct {
+	Packages     map[string]struct{ Version string } `json:"packages"`
+	Dependencies map[string]lockDependency           `json:"dependencies"`
+}
+
+type lockDependency struct {
+	Version      string                    `json:"version"`
+	Dependencies map[string]lockDependency `json:"dependencies"`
+}
+
+// parsePackageLock reads the installed package versions of a package-lock.json.
+func parsePackageLock(content string) dependencies {
+	deps := make(dependencies)
+	var lock packageLock
+	if content == "" || json.Unmarshal([]byte(content), &lock) != nil {
+		return deps
+	}
+	for key, pkg := range lock.Packages {
+		if _, name, ok := strings.Cut(key, "node_modules/"); ok {
+			// Nested installs are keyed node_modules/a/node_modules/b
+			if i := strings.LastIndex(name, "node_modules/"); i >= 0 {
+				name = name[i+len("node_modules/"):]
+			}
+			deps.add(name, pkg.Version)
+		}
+	}
+	var walk func(map[string]lockDependency)
+	walk = func(nested map[string]lockDependency) {
+		for name, dep := range nested {
+			deps.add(name, dep.Version)
+			walk(dep.Dependencies)
+		}
+	}
+	walk(lock.Dependencies)
+	return deps
+}
+
+// parseRequirements reads the pinned (==) versions of a requirements.txt file.
+func parseRequirements(content string) dependencies {
+	deps := make(dependencies)
+	for line := range strings.SplitSeq(content, "\n") {
+		line, _, _ = strings.Cut(line, "#")
+		line, _, _ = strings.Cut(line, ";")
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "-") {
+			continue
+		}
+		name, version, ok := strings.Cut(line, "==")
+		if !ok {
+			continue
+		}
+		name, _, _ = strings.Cut(name, "[")
+		deps.add(strings.TrimSpace(name), strings.TrimSpace(version))
+	}
+	return deps
+}
+
+// compareVersions compares two dotted versions numerically, ignoring a leading
+// "v" and build metadata. A version with a pre-release suffix (1.2.0-rc1,
+// 1.2.0rc1) orders before the release.
+func compareVersions(a, b string) int {
+	aRelease, aPre := splitVersion(a)
+	bRelease, bPre := splitVersion(b)
+	for i := range max(len(aRelease), len(bRelease)) {
+		var x, y int
+		if i < len(aRelease) {
+			x = aRelease[i]
+		}
+		if i < len(bRelease) {
+			y = bRelease[i]
+		}
+		if x != y {
+			if x < y {
+				return -1
+			}
+			return 1
+		}
+	}
+	switch {
+	case aPre == bPre:
+		return 0
+	case aPre == "":
+		return 1
+	case bPre == "":
+		return -1
+	default:
+		return strings.Compare(aPre, bPre)
+	}
+}
+
+// splitVersion splits a version into its numeric release segments and its
+// pre-release suffix.
+func splitVersion(version string) ([]int, string) {
+	version = strings.TrimPrefix(strings.TrimSpace(version), "v")
+	version, _, _ = strings.Cut(version, "+")
+
+	var release []int
+	rest := version
+	for rest != "" {
+		end := 0
+		for end < len(rest) && rest[end] >= '0' && rest[end] <= '9' {
+			end++
+		}
+		if end == 0 {
+			break
+		}
+		n, _ := strconv.Atoi(rest[:end])
+		release = append(release, n)
+		rest = rest[end:]
+		if !strings.HasPrefix(rest, ".") {
+			break
+		}
+		rest = rest[1:]
+	}
+	return release, strings.TrimLeft(rest, "-.")
+}
+
+// defaultAdvisories returns the bundled advisories of widely used packages.
+func defaultAdvisories() []Advisory {
+	return []Advisory{
+		{
+			Ecosystem:    ecosystemGo,
+			Package:      "golang.org/x/crypto",
+			FixedVersion: "v0.31.0",
+			CVE:          "CVE-2024-45337",
+			Severity:     events.VulnerabilitySeverityCritical,
+			CVSS:         9.1,
+			Title:        "Authorization bypass in golang.org/x/crypto/ssh",
+			Description:  "Misuse of ServerConfig.PublicKeyCallback may cause an authorization bypass.",
+		},
+		{
+			Ecosystem:    ecosystemGo,
+			Package:      "golang.org/x/net",
+			FixedVersion: "v0.23.0",
+			CVE:          "CVE-2023-45288",
+			Severity:     events.VulnerabilitySeverityHigh,
+			CVSS:         7.5,
+			Title:        "HTTP/2 CONTINUATION flood in golang.org/x/net/http2",
+			Description:  "An attacker can make the server read an unbounded number of header frames.",
+		},
+		{
+			Ecosystem:    ecosystemNPM,
+			Package:      "minimist",
+			FixedVersion: "1.2.6",
+			CVE:          "CVE-2021-44906",
+			Severity:     events.VulnerabilitySeverityCritical,
+			CVSS:         9.8,
+			Title:        "Prototype pollution in minimist",
+			Description:  "Crafted arguments can add or modify Object.prototype properties.",
+		},
+		{
+			Ecosystem:    ecosystemNPM,
+			Package:      "lodash",
+			FixedVersion: "4.17.21",
+			CVE:          "CVE-2021-23337",
+			Severity:     events.VulnerabilitySeverityHigh,
+			CVSS:         7.2,
+			Title:        "Command injection in lodash template",
+			Description:  "The template function allows command injection through the variable option.",
+		},
+		{
+			Ecosystem:    ecosystemPyPI,
+			Package:      "pyyaml",
+			FixedVersion: "5.4",
+			CVE:          "CVE-2020-14343",
+			Severity:     events.VulnerabilitySeverityCritical,
+			CVSS:         9.8,
+			Title:        "Arbitrary code execution in PyYAML full_load",
+			Description:  "Processing untrusted YAML with full_load or FullLoader can execute arbitrary code.",
+		},
+		{
+			Ecosystem:    ecosystemPyPI,
+			Package:      "requests",
+			FixedVersion: "2.31.0",
+			CVE:          "CVE-2023-32681",
+			Severity:     events.VulnerabilitySeverityMedium,
+			CVSS:         6.1,
+			Title:        "Proxy-Authorization header leak in requests",
+			Description:  "Proxy credentials are sent to the destination server when redirected to HTTPS.",
+		},
+	}
+}
@@ -0,0 +1,162 @@
+//nolint:testpackage // white-box testing requires internal package access
+package review
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	appconfig "github.com/antinvestor/builder/apps/reviewer/config"
+	"github.com/antinvestor/builder/internal/events"
+)
+
+func newTestDependencyAnalyzer(t *testing.T) *DependencyAnalyzer {
+	t.Helper()
+	analyzer, err := NewDependencyAnalyzer(&appconfig.ReviewerConfig{})
+	require.NoError(t, err)
+	return analyzer
+}
+
+func vulnerablePackages(vulns []events.DependencyVulnerability) []string {
+	packages := make([]string, len(vulns))
+	for i, v := range vulns {
+		packages[i] = v.Package + "@" + v.Version
+	}
+	return packages
+}
+
+func TestDependencyAnalyzer_FlagsVulnerablePinnedVersions(t *testing.T) {
+	analyzer := newTestDependencyAnalyzer(t)
+
+	found := analyzer.Analyze(map[string]string{
+		"go.mod": "module example.com/app\n\ngo 1.22\n\nrequire (\n" +
+			"\tgolang.org/x/crypto v0.17.0\n\tgolang.org/x/net v0.25.0 // indirect\n)\n",
+		"web/package-lock.json": `{"lockfileVersion": 3, "packages": {
+			"": {"name": "web"},
+			"node_modules/lodash": {"version": "4.17.21"},
+			"node_modules/mkdirp/node_modules/minimist": {"version": "1.2.5"}
+		}}`,
+		"requirements.txt": "PyYAML==5.3.1  # config loading\nrequests[socks]==2.31.0\nflask>=2.0\n",
+		"README.md":        "golang.org/x/crypto v0.1.0\n",
+	}, nil)
+
+	assert.Equal(t, []string{"PyYAML@5.3.1", "golang.org/x/crypto@v0.17.0", "minimist@1.2.5"},
+		vulnerablePackages(found))
+	crypto := found[1]
+	assert.Equal(t, "CVE-2024-45337", crypto.CVE)
+	assert.Equal(t, events.VulnerabilitySeverityCritical, crypto.Severity)
+	assert.Equal(t, "v0.31.0", crypto.FixedVersion)
+	assert.Equal(t, []string{"go.mod"}, crypto.DependencyPath)
+}
+
+func TestDependencyAnalyzer_PatchedVersionIsNotFlagged(t *testing.T) {
+	analyzer := newTestDependencyAnalyzer(t)
+
+	found := analyzer.Analyze(map[string]string{
+		"requirements.txt": "pyyaml==6.0.1\n",
+		"go.sum": "golang.org/x/crypto v0.31.0 h1:abc=\n" +
+			"golang.org/x/crypto v0.17.0/go.mod h1:def=\n",
+	}, nil)
+
+	assert.Empty(t, found)
+}
+
+func TestDependencyAnalyzer_OnlyFlagsIntroducedOrUpgradedVersions(t *testing.T) {
+	analyzer := newTestDependencyAnalyzer(t)
+	baseline := map[string]string{
+		"requirements.txt": "pyyaml==5.3.1\nrequests==2.20.0\n",
+	}
+
+	// pyyaml was already pinned; requests is upgraded to a still-vulnerable version
+	found := analyzer.Analyze(map[string]string{
+		"requirements.txt": "pyyaml==5.3.1\nrequests==2.28.0\n",
+	}, baseline)
+
+	assert.Equal(t, []string{"requests@2.28.0"}, vulnerablePackages(found))
+}
+
+func TestDependencyAnalyzer_CustomAdvisories(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "advisories.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`advisories:
+  - ecosystem: NPM
+    package: left-pad
+    introduced: 1.1.0
+    fixed_version: 1.3.0
+    cve: CVE-2099-0001
+    severity: HIGH
+    title: Padding overflow
+`), 0o600))
+	analyzer, err := NewDependencyAnalyzer(&appconfig.ReviewerConfig{DependencyAdvisoriesPath: path})
+	require.NoError(t, err)
+
+	lock := func(version string) map[string]string {
+		data, marshalErr := json.Marshal(map[string]any{
+			"dependencies": map[string]any{"left-pad": map[string]string{"version": version}},
+		})
+		require.NoError(t, marshalErr)
+		return map[string]string{"package-lock.json": string(data)}
+	}
+
+	assert.Empty(t, analyzer.Analyze(lock("1.0.0"), nil), "before the affected range")
+	found := analyzer.Analyze(lock("1.2.0"), nil)
+	require.Len(t, found, 1)
+	assert.Equal(t, events.VulnerabilitySeverityHigh, found[0].Severity)
+	assert.Empty(t, analyzer.Analyze(lock("1.3.0"), nil), "fixed")
+
+	_, err = NewDependencyAnalyzer(&appconfig.ReviewerConfig{DependencyAdvisoriesPath: filepath.Join(t.TempDir(), "x")})
+	require.Error(t, err)
+}
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{a: "v0.17.0", b: "v0.31.0", want: -1},
+		{a: "1.2.10", b: "1.2.9", want: 1},
+		{a: "5.4", b: "5.4.0", want: 0},
+		{a: "v1.2.0-rc.1", b: "v1.2.0", want: -1},
+		{a: "2.0rc1", b: "2.0", want: -1},
+		{a: "v2.1.0+incompatible", b: "v2.1.0", want: 0},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, compareVersions(tt.a, tt.b), "%s vs %s", tt.a, tt.b)
+	}
+}
+
+func TestRequestHandler_VulnerableDependencyAborts(t *testing.T) {
+	cfg := &appconfig.ReviewerConfig{}
+	security := NewPatternSecurityAnalyzer(cfg)
+	security.SetDependencyAnalyzer(newTestDependencyAnalyzer(t))
+
+	emitter := &mockEventsEmitter{}
+	handler := NewRequestHandler(cfg, security, stubArchitectureAnalyzer{},
+		NewThresholdDecisionEngine(cfg), NewDefaultKillSwitchService(cfg, emitter), emitter)
+	payload, err := json.Marshal(&events.ComprehensiveReviewRequestedPayload{
+		ExecutionID: events.NewExecutionID(),
+		TestResults: newPassingTestResult(),
+		Patches: []events.PatchReference{{
+			FilePath:    "requirements.txt",
+			ChangeType:  "modify",
+			OldContent:  "flask==3.0.0\n",
+			DiffContent: "@@ -1 +1,2 @@\n flask==3.0.0\n+pyyaml==5.3.1\n",
+		}},
+	})
+	require.NoError(t, err)
+	require.NoError(t, handler.Handle(context.Background(), nil, payload))
+
+	require.Len(t, emitter.emittedEvents, 1)
+	completed, ok := emitter.emittedEvents[0].payload.(*events.ComprehensiveReviewCompletedPayload)
+	require.True(t, ok)
+	assert.Equal(t, events.ControlDecisionAbort, completed.Decision)
+	require.Len(t, completed.SecurityAssessment.DependencyVulnerabilities, 1)
+	assert.Equal(t, "CVE-2020-14343", completed.SecurityAssessment.DependencyVulnerabilities[0].CVE)
+	require.NotEmpty(t, completed.BlockingIssues)
+	assert.Equal(t, "dep-pyyaml-CVE-2020-14343", completed.BlockingIssues[0].ID)
+	assert.Equal(t, "requirements.txt", completed.BlockingIssues[0].FilePath)
+}
@@ -0,0 +1,259 @@
+package review
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/antinvestor/builder/internal/events"
+)
+
+// hunkRange matches a unified diff hunk header, capturing the old start and
+// count and the new start and count; an omitted count is 1.
+var hunkRange = regexp.MustCompile(`^@@ -(\d+)(?:,(\d+))? \+(\d+)(?:,(\d+))? @@`)
+
+// errMalformedDiff is returned for a diff that is not a well-formed unified diff.
+var errMalformedDiff = errors.New("malformed diff")
+
+// noNewlineMarker follows a diff line that has no newline at the end of the file.
+const noNewlineMarker = `\`
+
+// diffHunk is one hunk of a unified diff.
+type diffHunk struct {
+	oldStart, oldCount int
+	newStart, newCount int
+	// lines are the hunk's lines, each prefixed by ' ', '+', '-' or '\'.
+	lines []string
+}
+
+// DiffContentFetcher reconstructs the reviewed files from the patches themselves,
+// so the reviewer needs no access to the worker's workspace. A file's contents
+// are its diff applied to the baseline; when the diff has no changes, is
+// malformed or does not apply, the patch's new content is used as is.
+type DiffContentFetcher struct{}
+
+// NewDiffContentFetcher creates a new diff-based content fetcher.
+func NewDiffContentFetcher() *DiffContentFetcher {
+	return &DiffContentFetcher{}
+}
+
+// FetchContents implements ContentFetcher. Files whose contents cannot be
+// reconstructed are left out, so they are analyzed from the diff alone.
+func (f *DiffContentFetcher) FetchContents(
+	_ context.Context,
+	_ *events.ComprehensiveReviewRequestedPayload,
+	patches []events.Patch,
+) (map[string]string, map[string]string, error) {
+	contents := make(map[string]string, len(patches))
+	baseline := make(map[string]string, len(patches))
+	for _, patch := range patches {
+		// A malformed diff has no hunks, leaving the patch's new content
+		hunks, _ := parseHunks(patch.DiffContent)
+
+		if old, ok := baselineContent(patch, hunks); ok {
+			baseline[patch.FilePath] = old
+		}
+		if patch.Action == events.FileActionDelete {
+			continue
+		}
+		if content, ok := reconstructContent(patch, hunks, baseline); ok {
+			contents[patch.FilePath] = content
+		}
+	}
+	return contents, baseline, nil
+}
+
+// baselineContent returns a file before its change: the patch's old content, or
+// for a deleted file, the lines its diff removes.
+func baselineContent(patch events.Patch, hunks []diffHunk) (string, bool) {
+	if patch.OldContent != "" {
+		return patch.OldContent, true
+	}
+	if patch.Action != events.FileActionDelete || len(hunks) != 1 || hunks[0].newCount != 0 {
+		return "", false
+	}
+	return applyHunks("", reverseHunks(hunks))
+}
+
+// reconstructContent returns a file after its change.
+func reconstructContent(patch events.Patch, hunks []diffHunk, baseline map[string]string) (string, bool) {
+	if hasChanges(hunks) {
+		old, known := baseline[patch.FilePath]
+		if known || patch.Action == events.FileActionCreate || createsFile(hunks) {
+			if content, ok := applyHunks(old, hunks); ok {
+				return content, true
+			}
+		}
+	}
+	return patch.NewContent, patch.NewContent != ""
+}
+
+// parseHunks parses the hunks of a unified diff, skipping its file headers.
+func parseHunks(diff string) ([]diffHunk, error) {
+	var hunks []diffHunk
+	for line := range strings.SplitSeq(strings.TrimSuffix(diff, "\n"), "\n") {
+		if match := hunkRange.FindStringSubmatch(line); match != nil {
+			hunks = append(hunks, diffHunk{
+				oldStart: atoiOr(match[1], 0),
+				oldCount: atoiOr(match[2], 1),
+				newStart: atoiOr(match[3], 0),
+				newCount: atoiOr(match[4], 1),
+			})
+			continue
+		}
+		if len(hunks) == 0 {
+			// Headers such as "diff --git", "index", "---" and "+++"
+			continue
+		}
+		hunk := &hunks[len(hunks)-1]
+		switch {
+		case line == "":
+			// An empty context line whose leading space was stripped
+			hunk.lines = append(hunk.lines, " ")
+		case strings.ContainsAny(line[:1], " +-"+noNewlineMarker):
+			hunk.lines = append(hunk.lines, line)
+		default:
+			return nil, fmt.Errorf("%w: unexpected line %q", errMalformedDiff, line)
+		}
+	}
+	if len(hunks) == 0 {
+		return nil, fmt.Errorf("%w: no hunks", errMalformedDiff)
+	}
+
+	for _, hunk := range hunks {
+		oldLines, newLines := 0, 0
+		for _, line := range hunk.lines {
+			switch line[0] {
+			case ' ':
+				oldLines++
+				newLines++
+			case '-':
+				oldLines++
+			case '+':
+				newLines++
+			}
+		}
+		if oldLines != hunk.oldCount || newLines != hunk.newCount {
+			return nil, fmt.Errorf("%w: hunk at line %d has the wrong line count",
+				errMalformedDiff, hunk.oldStart)
+		}
+	}
+	return hunks, nil
+}
+
+// atoiOr parses a hunk header number, returning fallback when it is omitted.
+func atoiOr(value string, fallback int) int {
+	if value == "" {
+		return fallback
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return fallback
+	}
+	return n
+}
+
+// hasChanges reports whether hunks add or remove any line.
+func hasChanges(hunks []diffHunk) bool {
+	for _, hunk := range hunks {
+		for _, line := range hunk.lines {
+			if line[0] == '+' || line[0] == '-' {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// createsFile reports whether hunks create a file from nothing.
+func createsFile(hunks []diffHunk) bool {
+	return len(hunks) == 1 && hunks[0].oldStart == 0 && hunks[0].oldCount == 0
+}
+
+// reverseHunks swaps the sides of hunks, turning a diff into its inverse.
+func reverseHunks(hunks []diffHunk) []diffHunk {
+	reversed := make([]diffHunk, len(hunks))
+	for i, hunk := range hunks {
+		lines := make([]string, len(hunk.lines))
+		for j, line := range hunk.lines {
+			switch line[0] {
+			case '+':
+				line = "-" + line[1:]
+			case '-':
+				line = "+" + line[1:]
+			}
+			lines[j] = line
+		}
+		reversed[i] = diffHunk{
+			oldStart: hunk.newStart,
+			oldCount: hunk.newCount,
+			newStart: hunk.oldStart,
+			newCount: hunk.oldCount,
+			lines:    lines,
+		}
+	}
+	return reversed
+}
+
+// applyHunks applies hunks to base, failing when a context or removed line does
+// not match the base.
+func applyHunks(base string, hunks []diffHunk) (string, bool) {
+	var baseLines []string
+	if base != "" {
+		baseLines = strings.Split(strings.TrimSuffix(base, "\n"), "\n")
+	}
+
+	var out []string
+	next := 0
+	noNewline := false
+	for _, hunk := range hunks {
+		// A hunk replacing no lines inserts after its start line
+		start := hunk.oldStart - 1
+		if hunk.oldCount == 0 {
+			start = hunk.oldStart
+		}
+		if start < next || start > len(baseLines) {
+			return "", false
+		}
+		out = append(out, baseLines[next:start]...)
+		next = start
+
+		previous := byte(0)
+		for _, line := range hunk.lines {
+			text := line[1:]
+			switch line[0] {
+			case ' ', '-':
+				if next >= len(baseLines) || baseLines[next] != text {
+					return "", false
+				}
+				next++
+				if line[0] == ' ' {
+					out = append(out, text)
+				}
+			case '+':
+				out = append(out, text)
+			case noNewlineMarker[0]:
+				// The marker after a removed line describes the old file only
+				noNewline = noNewline || previous != '-'
+			}
+			previous = line[0]
+		}
+	}
+
+	trailingNewline := !noNewline
+	if next < len(baseLines) {
+		out = append(out, baseLines[next:]...)
+		trailingNewline = strings.HasSuffix(base, "\n")
+	}
+	if len(out) == 0 {
+		return "", true
+	}
+	content := strings.Join(out, "\n")
+	if trailingNewline {
+		content += "\n"
+	}
+	return content, true
+}
@@ -0,0 +1,106 @@
+//nolint:testpackage // white-box testing requires internal package access
+package review
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/antinvestor/builder/internal/events"
+)
+
+const handlerBaseline = `package api
+
+func Handle() error {
+	return nil
+}
+`
+
+func fetchPatchContents(t *testing.T, patches ...events.Patch) (map[string]string, map[string]string) {
+	t.Helper()
+	contents, baseline, err := NewDiffContentFetcher().FetchContents(context.Background(), nil, patches)
+	require.NoError(t, err)
+	return contents, baseline
+}
+
+func TestDiffContentFetcher_ReconstructsAddModifyDelete(t *testing.T) {
+	contents, baseline := fetchPatchContents(t,
+		events.Patch{
+			FilePath: "api/auth.go",
+			Action:   events.FileActionCreate,
+			DiffContent: "--- /dev/null\n+++ b/api/auth.go\n@@ -0,0 +1,3 @@\n" +
+				"+package api\n+\n+const token = \"secret\"\n",
+		},
+		events.Patch{
+			FilePath:   "api/handler.go",
+			Action:     events.FileActionModify,
+			OldContent: handlerBaseline,
+			DiffContent: "--- a/api/handler.go\n+++ b/api/handler.go\n@@ -2,4 +2,5 @@\n" +
+				" \n func Handle() error {\n-\treturn nil\n+\tlog.Println(\"handled\")\n+\treturn run()\n }\n",
+		},
+		events.Patch{
+			FilePath:    "api/legacy.go",
+			Action:      events.FileActionDelete,
+			DiffContent: "--- a/api/legacy.go\n+++ /dev/null\n@@ -1,2 +0,0 @@\n-package api\n-\n",
+		},
+	)
+
+	assert.Equal(t, map[string]string{
+		"api/auth.go": "package api\n\nconst token = \"secret\"\n",
+		"api/handler.go": "package api\n\nfunc Handle() error {\n" +
+			"\tlog.Println(\"handled\")\n\treturn run()\n}\n",
+	}, contents)
+	assert.Equal(t, map[string]string{
+		"api/handler.go": handlerBaseline,
+		"api/legacy.go":  "package api\n\n",
+	}, baseline)
+}
+
+func TestDiffContentFetcher_MissingNewlineAtEndOfFile(t *testing.T) {
+	contents, _ := fetchPatchContents(t, events.Patch{
+		FilePath:    "VERSION",
+		Action:      events.FileActionModify,
+		OldContent:  "1.0.0\n",
+		DiffContent: "@@ -1 +1 @@\n-1.0.0\n+1.1.0\n\\ No newline at end of file\n",
+	})
+
+	assert.Equal(t, "1.1.0", contents["VERSION"])
+}
+
+func TestDiffContentFetcher_FallsBackToNewContent(t *testing.T) {
+	tests := []struct {
+		name string
+		diff string
+	}{
+		{name: "context only", diff: "@@ -1,2 +1,2 @@\n package api\n \n"},
+		{name: "malformed", diff: "+func Handle() error {"},
+		{name: "wrong line count", diff: "@@ -1,5 +1,5 @@\n-package api\n+package handlers\n"},
+		{name: "does not apply", diff: "@@ -1 +1 @@\n-package other\n+package handlers\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			contents, _ := fetchPatchContents(t, events.Patch{
+				FilePath:    "api/handler.go",
+				Action:      events.FileActionModify,
+				OldContent:  handlerBaseline,
+				NewContent:  "package handlers\n",
+				DiffContent: tt.diff,
+			})
+
+			assert.Equal(t, "package handlers\n", contents["api/handler.go"])
+		})
+	}
+}
+
+func TestDiffContentFetcher_ModifyWithoutBaselineUsesDiffOnly(t *testing.T) {
+	contents, baseline := fetchPatchContents(t, events.Patch{
+		FilePath:    "api/handler.go",
+		Action:      events.FileActionModify,
+		DiffContent: "@@ -3 +3 @@\n-\treturn nil\n+\treturn run()\n",
+	})
+
+	assert.Empty(t, contents)
+	assert.Empty(t, baseline)
+}
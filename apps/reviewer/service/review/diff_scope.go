@@ -0,0 +1,216 @@
+package review
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/antinvestor/builder/internal/events"
+)
+
+// diffContextLines is how many lines either side of a changed line a
+// diff-scoped analysis still inspects, so a finding spanning a hunk edge is kept.
+const diffContextLines = 3
+
+// lineScope is the set of new-file lines a diff-scoped analysis inspects.
+// The zero value covers no lines.
+type lineScope struct {
+	all   bool
+	lines map[int]bool
+}
+
+// covers reports whether any line from start to end is in scope.
+func (s lineScope) covers(start, end int) bool {
+	if s.all {
+		return true
+	}
+	for line := start; line <= end; line++ {
+		if s.lines[line] {
+			return true
+		}
+	}
+	return false
+}
+
+// diffScopes maps each changed file to the lines a diff-scoped analysis
+// inspects. A nil diffScopes analyzes whole files.
+type diffScopes map[string]lineScope
+
+// forFile returns the scope of a file; a file with no patch has no changed lines.
+func (d diffScopes) forFile(filePath string) lineScope {
+	if d == nil {
+		return lineScope{all: true}
+	}
+	return d[filePath]
+}
+
+// diffScopes returns the lines to analyze per file, or nil to analyze whole
+// files because diff-only analysis is disabled or the request has no patches.
+func (a *PatternSecurityAnalyzer) diffScopes(req *SecurityAnalysisRequest) diffScopes {
+	if len(req.Patches) == 0 || (a.cfg != nil && !a.cfg.AnalyzeDiffOnly) {
+		return nil
+	}
+
+	scopes := make(diffScopes, len(req.Patches))
+	for _, patch := range req.Patches {
+		if patch.Action == events.FileActionDelete {
+			continue
+		}
+		scopes[patch.FilePath] = changedLines(patch.DiffContent, diffContextLines)
+	}
+	return scopes
+}
+
+// changedLines returns the new-file lines a unified diff adds, widened by
+// context lines either side. A removal marks the line that now follows it. A
+// patch without a diff, such as a created file, is in scope as a whole.
+func changedLines(diff string, context int) lineScope {
+	if strings.TrimSpace(diff) == "" {
+		return lineScope{all: true}
+	}
+
+	scope := lineScope{lines: make(map[int]bool)}
+	mark := func(line int) {
+		for l := max(line-context, 1); l <= line+context; l++ {
+			scope.lines[l] = true
+		}
+	}
+
+	newLine := 0
+	for line := range strings.SplitSeq(diff, "\n") {
+		switch {
+		case strings.HasPrefix(line, "+++"), strings.HasPrefix(line, "---"):
+			continue
+		case strings.HasPrefix(line, "@@"):
+			if match := hunkNewStart.FindStringSubmatch(line); match != nil {
+				newLine, _ = strconv.Atoi(match[1])
+			}
+		case strings.HasPrefix(line, "+"):
+			mark(newLine)
+			newLine++
+		case strings.HasPrefix(line, "-"):
+			mark(newLine)
+		case strings.HasPrefix(line, `\`):
+			// "\ No newline at end of file" is not a line of either side
+			continue
+		default:
+			newLine++
+		}
+	}
+	return scope
+}
+
+// splitPatterns separates the patterns on in-scope lines from the rest.
+func (s lineScope) splitPatterns(
+	patterns []events.InsecurePattern,
+) ([]events.InsecurePattern, []events.InsecurePattern) {
+	var inside, outside []events.InsecurePattern
+	for _, p := range patterns {
+		if s.covers(p.LineStart, p.LineEnd) {
+			inside = append(inside, p)
+		} else {
+			outside = append(outside, p)
+		}
+	}
+	return inside, outside
+}
+
+// splitSecrets separates the secrets on in-scope lines from the rest.
+func (s lineScope) splitSecrets(
+	secrets []events.SecretFinding,
+) ([]events.SecretFinding, []events.SecretFinding) {
+	var inside, outside []events.SecretFinding
+	for _, secret := range secrets {
+		if s.covers(secret.LineNumber, secret.LineNumber) {
+			inside = append(inside, secret)
+		} else {
+			outside = append(outside, secret)
+		}
+	}
+	return inside, outside
+}
+
+// introducedPatternRegressions reports the patterns outside the changed lines
+// that the file's baseline does not contain, which a diff-scoped analysis would
+// otherwise miss. Without a baseline the patterns are taken to be pre-existing.
+func (a *PatternSecurityAnalyzer) introducedPatternRegressions(
+	filePath, language string,
+	baselineContents map[string]string,
+	outside []events.InsecurePattern,
+) []events.SecurityRegression {
+	baseline, ok := baselineContents[filePath]
+	if !ok || len(outside) == 0 {
+		return nil
+	}
+
+	existing := make(map[string]bool)
+	baselinePatterns, _ := a.findSecurityPatterns(filePath, baseline, language)
+	for _, p := range baselinePatterns {
+		existing[string(p.PatternType)+"\x00"+strings.TrimSpace(p.CodeSnippet)] = true
+	}
+
+	var regressions []events.SecurityRegression
+	for _, p := range outside {
+		if existing[string(p.PatternType)+"\x00"+strings.TrimSpace(p.CodeSnippet)] {
+			continue
+		}
+		regressions = append(regressions, events.SecurityRegression{
+			RegressionType: patternTypeToRegressionType(p.PatternType),
+			Description:    p.Description + " (not in the baseline, outside the changed lines)",
+			FilePath:       filePath,
+			LineNumber:     p.LineStart,
+			Severity:       p.Severity,
+			Current:        p.CodeSnippet,
+		})
+	}
+	return regressions
+}
+
+// introducedSecretRegressions reports the secrets outside the changed lines that
+// the file's baseline does not contain.
+func (a *PatternSecurityAnalyzer) introducedSecretRegressions(
+	filePath string,
+	baselineContents map[string]string,
+	outside []events.SecretFinding,
+) []events.SecurityRegression {
+	baseline, ok := baselineContents[filePath]
+	if !ok || len(outside) == 0 {
+		return nil
+	}
+
+	existing := make(map[string]bool)
+	baselineSecrets, _ := a.findSecrets(filePath, baseline)
+	for _, s := range baselineSecrets {
+		existing[s.Type+"\x00"+s.Redacted] = true
+	}
+
+	var regressions []events.SecurityRegression
+	for _, s := range outside {
+		if existing[s.Type+"\x00"+s.Redacted] {
+			continue
+		}
+		regressions = append(regressions, events.SecurityRegression{
+			RegressionType: events.SecurityRegressionExposedData,
+			Description:    s.Description + " (not in the baseline, outside the changed lines)",
+			FilePath:       filePath,
+			LineNumber:     s.LineNumber,
+			Severity:       events.VulnerabilitySeverityCritical,
+			Current:        s.Redacted,
+		})
+	}
+	return regressions
+}
+
+func patternTypeToRegressionType(pt events.InsecurePatternType) events.SecurityRegressionType {
+	switch pt { //nolint:exhaustive // default handles remaining pattern types
+	case events.InsecurePatternWeakCrypto, events.InsecurePatternInsecureTLS:
+		return events.SecurityRegressionRemovedEncryption
+	case events.InsecurePatternMissingAuth:
+		return events.SecurityRegressionWeakenedAuth
+	case events.InsecurePatternMissingValidation:
+		return events.SecurityRegressionRemovedValidation
+	case events.InsecurePatternHardcodedCreds, events.InsecurePatternLogSensitiveData:
+		return events.SecurityRegressionExposedData
+	default:
+		return events.SecurityRegressionInsecureDefault
+	}
+}
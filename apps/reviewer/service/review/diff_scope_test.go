@@ -0,0 +1,138 @@
+package review //nolint:testpackage // white-box testing requires internal access
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	appconfig "github.com/antinvestor/builder/apps/reviewer/config"
+	"github.com/antinvestor/builder/internal/events"
+)
+
+const insecureTLSLine = "var tlsConfig = &tls.Config{InsecureSkipVerify: true}"
+
+// storeFile is a 20-line Go file with an insecure TLS config on line 3, which the
+// change does not touch, and a weak hash on line 15, which the change adds.
+func storeFile(tlsLine string) string {
+	lines := []string{"package store", "", tlsLine}
+	for i := 4; i <= 20; i++ {
+		lines = append(lines, fmt.Sprintf("var f%d = %d", i, i))
+	}
+	lines[14] = "var digest = md5.Sum(data)"
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// storePatch adds line 15 of storeFile.
+var storePatch = events.Patch{
+	FilePath:    "internal/store/store.go",
+	Action:      events.FileActionModify,
+	DiffContent: "@@ -14,2 +14,3 @@\n var f14 = 14\n+var digest = md5.Sum(data)\n var f16 = 16\n",
+}
+
+func patternTypes(patterns []events.InsecurePattern) []events.InsecurePatternType {
+	var types []events.InsecurePatternType
+	for _, p := range patterns {
+		types = append(types, p.PatternType)
+	}
+	return types
+}
+
+func TestPatternSecurityAnalyzer_DiffOnlyReportsChangedLines(t *testing.T) {
+	analyzer := NewPatternSecurityAnalyzer(nil)
+
+	assessment, err := analyzer.Analyze(context.Background(), &SecurityAnalysisRequest{
+		Patches: []events.Patch{
+			storePatch,
+			{FilePath: "internal/client/client.go", Action: events.FileActionCreate},
+		},
+		FileContents: map[string]string{
+			"internal/store/store.go":   storeFile(insecureTLSLine),
+			"internal/client/client.go": "package client\n\n" + insecureTLSLine + "\n",
+			"internal/other/other.go":   "package other\n\n" + insecureTLSLine + "\n",
+		},
+	})
+	require.NoError(t, err)
+
+	byFile := make(map[string][]events.InsecurePattern)
+	for _, p := range assessment.InsecurePatterns {
+		byFile[p.FilePath] = append(byFile[p.FilePath], p)
+	}
+
+	// The untouched TLS config is suppressed; the added weak hash keeps its absolute line
+	require.Len(t, byFile["internal/store/store.go"], 1)
+	assert.Equal(t, events.InsecurePatternWeakCrypto, byFile["internal/store/store.go"][0].PatternType)
+	assert.Equal(t, 15, byFile["internal/store/store.go"][0].LineStart)
+	// A created file is analyzed in full, a file without a patch not at all
+	assert.Equal(t, []events.InsecurePatternType{events.InsecurePatternInsecureTLS},
+		patternTypes(byFile["internal/client/client.go"]))
+	assert.Empty(t, byFile["internal/other/other.go"])
+	assert.Empty(t, assessment.SecurityRegressions, "no baseline shows the TLS config is new")
+}
+
+func TestPatternSecurityAnalyzer_DiffOnlyDisabled(t *testing.T) {
+	analyzer := NewPatternSecurityAnalyzer(&appconfig.ReviewerConfig{AnalyzeDiffOnly: false})
+
+	assessment, err := analyzer.Analyze(context.Background(), &SecurityAnalysisRequest{
+		Patches:      []events.Patch{storePatch},
+		FileContents: map[string]string{"internal/store/store.go": storeFile(insecureTLSLine)},
+	})
+	require.NoError(t, err)
+
+	assert.ElementsMatch(t,
+		[]events.InsecurePatternType{events.InsecurePatternInsecureTLS, events.InsecurePatternWeakCrypto},
+		patternTypes(assessment.InsecurePatterns))
+}
+
+func TestPatternSecurityAnalyzer_DiffOnlyRegressionOutsideHunks(t *testing.T) {
+	analyzer := NewPatternSecurityAnalyzer(&appconfig.ReviewerConfig{AnalyzeDiffOnly: true})
+	current := storeFile(insecureTLSLine)
+
+	// The baseline had a secure TLS config, so the insecure one is new despite the diff
+	assessment, err := analyzer.Analyze(context.Background(), &SecurityAnalysisRequest{
+		Patches:          []events.Patch{storePatch},
+		FileContents:     map[string]string{"internal/store/store.go": current},
+		BaselineContents: map[string]string{"internal/store/store.go": storeFile("var tlsConfig = &tls.Config{}")},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, []events.InsecurePatternType{events.InsecurePatternWeakCrypto},
+		patternTypes(assessment.InsecurePatterns))
+	require.Len(t, assessment.SecurityRegressions, 1)
+	regression := assessment.SecurityRegressions[0]
+	assert.Equal(t, events.SecurityRegressionRemovedEncryption, regression.RegressionType)
+	assert.Equal(t, 3, regression.LineNumber)
+	assert.Contains(t, regression.Current, "InsecureSkipVerify")
+
+	// A baseline with the same finding shows it pre-exists
+	assessment, err = analyzer.Analyze(context.Background(), &SecurityAnalysisRequest{
+		Patches:          []events.Patch{storePatch},
+		FileContents:     map[string]string{"internal/store/store.go": current},
+		BaselineContents: map[string]string{"internal/store/store.go": storeFile(insecureTLSLine)},
+	})
+	require.NoError(t, err)
+	assert.Empty(t, assessment.SecurityRegressions)
+}
+
+func TestChangedLines(t *testing.T) {
+	diff := "--- a/f.go\n+++ b/f.go\n" +
+		"@@ -10,3 +10,3 @@\n ctx\n-old\n+new\n ctx\n" +
+		"@@ -40,3 +40,2 @@\n ctx\n-gone\n ctx\n" +
+		"\\ No newline at end of file\n"
+
+	scope := changedLines(diff, 1)
+
+	var lines []int
+	for line := 1; line <= 50; line++ {
+		if scope.covers(line, line) {
+			lines = append(lines, line)
+		}
+	}
+	// Line 11 is replaced; the removal after line 40 marks line 41
+	assert.Equal(t, []int{10, 11, 12, 40, 41, 42}, lines)
+	assert.True(t, scope.covers(1, 10), "a range overlapping the scope is covered")
+	assert.True(t, changedLines("", 1).covers(1, 1000), "a patch without a diff covers the whole file")
+}
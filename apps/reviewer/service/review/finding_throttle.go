@@ -0,0 +1,127 @@
+package review
+
+import (
+	"fmt"
+
+	"github.com/antinvestor/builder/internal/events"
+)
+
+// Categories of throttled findings, naming the assessment list they come from.
+const (
+	findingCategoryInsecurePattern     = "insecure_pattern"
+	findingCategoryVulnerability       = "vulnerability"
+	findingCategorySecret              = "secret"
+	findingCategoryDependencyViolation = "dependency_violation"
+	findingCategoryLayeringViolation   = "layering_violation"
+	findingCategoryPatternViolation    = "pattern_violation"
+)
+
+// findingKey groups findings for throttling. Findings with a severity are capped
+// per type and severity, so the omitted counts stay usable for decisions.
+type findingKey struct {
+	findingType string
+	severity    string
+}
+
+// throttleFindings keeps the first findings of each type up to the type's cap, in
+// their original order, and summarizes the rest. A cap of 0 keeps every finding.
+func throttleFindings[T any](
+	category string,
+	findings []T,
+	keyOf func(T) findingKey,
+	capFor func(findingType string) int,
+) ([]T, []events.ThrottledFindings) {
+	counts := make(map[findingKey]int)
+	var order []findingKey
+	kept := findings[:0:0]
+	for _, finding := range findings {
+		key := keyOf(finding)
+		if counts[key] == 0 {
+			order = append(order, key)
+		}
+		counts[key]++
+		if limit := capFor(key.findingType); limit == 0 || counts[key] <= limit {
+			kept = append(kept, finding)
+		}
+	}
+
+	var throttled []events.ThrottledFindings
+	for _, key := range order {
+		limit := capFor(key.findingType)
+		if limit == 0 || counts[key] <= limit {
+			continue
+		}
+		throttled = append(throttled, events.ThrottledFindings{
+			Category: category,
+			Type:     key.findingType,
+			Severity: key.severity,
+			Reported: limit,
+			Omitted:  counts[key] - limit,
+		})
+	}
+	return kept, throttled
+}
+
+// throttledSummary describes omitted findings, e.g. "+12 more sql_injection findings".
+func throttledSummary(t events.ThrottledFindings) string {
+	return fmt.Sprintf("+%d more %s findings", t.Omitted, t.Type)
+}
+
+// omittedFindings counts the findings left out of a report.
+func omittedFindings(throttled []events.ThrottledFindings) int {
+	var omitted int
+	for _, t := range throttled {
+		omitted += t.Omitted
+	}
+	return omitted
+}
+
+// throttleSecurityFindings caps the security assessment lists. It runs after
+// scoring, so scores and statuses still reflect every finding.
+func throttleSecurityFindings(assessment *events.SecurityAssessment, capFor func(string) int) {
+	var throttled, more []events.ThrottledFindings
+
+	assessment.InsecurePatterns, more = throttleFindings(findingCategoryInsecurePattern, assessment.InsecurePatterns,
+		func(p events.InsecurePattern) findingKey { return findingKey{findingType: string(p.PatternType)} }, capFor)
+	throttled = append(throttled, more...)
+
+	assessment.VulnerabilitiesFound, more = throttleFindings(findingCategoryVulnerability,
+		assessment.VulnerabilitiesFound,
+		func(v events.Vulnerability) findingKey {
+			return findingKey{findingType: string(v.Type), severity: string(v.Severity)}
+		}, capFor)
+	throttled = append(throttled, more...)
+
+	assessment.SecretsDetected, more = throttleFindings(findingCategorySecret, assessment.SecretsDetected,
+		func(s events.SecretFinding) findingKey { return findingKey{findingType: s.Type} }, capFor)
+	throttled = append(throttled, more...)
+
+	assessment.ThrottledFindings = throttled
+}
+
+// throttleArchitectureFindings caps the architecture violation lists. Breaking
+// changes and test regressions are always listed, as decisions count them.
+func throttleArchitectureFindings(assessment *events.ArchitectureAssessment, capFor func(string) int) {
+	var throttled, more []events.ThrottledFindings
+
+	assessment.DependencyViolations, more = throttleFindings(findingCategoryDependencyViolation,
+		assessment.DependencyViolations,
+		func(v events.DependencyViolation) findingKey {
+			return findingKey{findingType: string(v.ViolationType), severity: string(v.Severity)}
+		}, capFor)
+	throttled = append(throttled, more...)
+
+	assessment.LayeringViolations, more = throttleFindings(findingCategoryLayeringViolation,
+		assessment.LayeringViolations,
+		func(v events.LayeringViolation) findingKey {
+			return findingKey{findingType: string(v.ViolationType), severity: string(v.Severity)}
+		}, capFor)
+	throttled = append(throttled, more...)
+
+	assessment.PatternViolations, more = throttleFindings(findingCategoryPatternViolation,
+		assessment.PatternViolations,
+		func(v events.PatternViolation) findingKey { return findingKey{findingType: v.ViolationType} }, capFor)
+	throttled = append(throttled, more...)
+
+	assessment.ThrottledFindings = throttled
+}
@@ -0,0 +1,144 @@
+//nolint:testpackage // white-box testing requires internal package access
+package review
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	appconfig "github.com/antinvestor/builder/apps/reviewer/config"
+	"github.com/antinvestor/builder/internal/events"
+)
+
+// repeatedSQLInjection returns a Go file with n identical SQL injection findings.
+func repeatedSQLInjection(n int) string {
+	var sb strings.Builder
+	sb.WriteString("package db\n\nfunc GetUsers(id string) {\n")
+	for i := range n {
+		fmt.Fprintf(&sb, "\tq%d := \"SELECT * FROM users WHERE id = '\" + id + \"'\"\n\tdb.Query(q%d)\n", i, i)
+	}
+	sb.WriteString("}\n")
+	return sb.String()
+}
+
+func TestThrottleFindings_SummarizesOverCapFindings(t *testing.T) {
+	findings := []events.SecretFinding{
+		{Type: "password", LineNumber: 1},
+		{Type: "password", LineNumber: 2},
+		{Type: "aws_access_key", LineNumber: 3},
+		{Type: "password", LineNumber: 4},
+		{Type: "password", LineNumber: 5},
+	}
+
+	kept, throttled := throttleFindings(findingCategorySecret, findings,
+		func(s events.SecretFinding) findingKey { return findingKey{findingType: s.Type} },
+		func(string) int { return 2 })
+
+	require.Len(t, kept, 3)
+	assert.Equal(t, []int{1, 2, 3}, []int{kept[0].LineNumber, kept[1].LineNumber, kept[2].LineNumber})
+	require.Len(t, throttled, 1)
+	assert.Equal(t, events.ThrottledFindings{
+		Category: findingCategorySecret,
+		Type:     "password",
+		Reported: 2,
+		Omitted:  2,
+	}, throttled[0])
+	assert.Equal(t, "+2 more password findings", throttledSummary(throttled[0]))
+}
+
+func TestThrottleFindings_ZeroCapKeepsEverything(t *testing.T) {
+	findings := []events.SecretFinding{{Type: "password"}, {Type: "password"}, {Type: "password"}}
+
+	kept, throttled := throttleFindings(findingCategorySecret, findings,
+		func(s events.SecretFinding) findingKey { return findingKey{findingType: s.Type} },
+		func(string) int { return 0 })
+
+	assert.Len(t, kept, 3)
+	assert.Empty(t, throttled)
+}
+
+func TestReviewerConfig_FindingCapOverrides(t *testing.T) {
+	cfg := &appconfig.ReviewerConfig{
+		MaxFindingsPerType: 20,
+		FindingTypeCaps:    map[string]int{"sql_injection": 5, "password": 0},
+	}
+
+	assert.Equal(t, 5, cfg.FindingCap("sql_injection"))
+	assert.Equal(t, 0, cfg.FindingCap("password"), "an override of 0 lists every finding")
+	assert.Equal(t, 20, cfg.FindingCap("xss"))
+}
+
+func TestPatternSecurityAnalyzer_ThrottlesRepeatedFindings(t *testing.T) {
+	analyzer := NewPatternSecurityAnalyzer(&appconfig.ReviewerConfig{MaxFindingsPerType: 3})
+	unthrottled := NewPatternSecurityAnalyzer(nil)
+	req := &SecurityAnalysisRequest{
+		FileContents: map[string]string{"db/users.go": repeatedSQLInjection(30)},
+		Language:     langGo,
+	}
+
+	full, err := unthrottled.Analyze(context.Background(), req)
+	require.NoError(t, err)
+	require.Len(t, full.InsecurePatterns, 30)
+
+	assessment, err := analyzer.Analyze(context.Background(), req)
+	require.NoError(t, err)
+
+	assert.Len(t, assessment.InsecurePatterns, 3)
+	assert.Len(t, assessment.VulnerabilitiesFound, 3)
+	assert.Equal(t, full.OverallSecurityScore, assessment.OverallSecurityScore,
+		"scores reflect every finding, not just the listed ones")
+
+	var patterns *events.ThrottledFindings
+	for i := range assessment.ThrottledFindings {
+		if assessment.ThrottledFindings[i].Category == findingCategoryInsecurePattern {
+			patterns = &assessment.ThrottledFindings[i]
+		}
+	}
+	require.NotNil(t, patterns)
+	assert.Equal(t, string(events.InsecurePatternSQLInjection), patterns.Type)
+	assert.Equal(t, 3, patterns.Reported)
+	assert.Equal(t, 27, patterns.Omitted)
+	assert.Equal(t, "+27 more sql_injection findings", throttledSummary(*patterns))
+	assert.Equal(t, securityFindingCount(full), securityFindingCount(assessment))
+}
+
+func TestThresholdDecisionEngine_ThrottledFindingsStillBlock(t *testing.T) {
+	engine := newTestDecisionEngine()
+	req := newCleanDecisionRequest()
+	req.SecurityAssessment.VulnerabilitiesFound = []events.Vulnerability{{
+		ID:       "vuln-1",
+		Type:     events.VulnerabilityTypeInjection,
+		Severity: events.VulnerabilitySeverityHigh,
+		FilePath: "db/users.go",
+	}}
+	req.SecurityAssessment.ThrottledFindings = []events.ThrottledFindings{
+		{
+			Category: findingCategoryVulnerability,
+			Type:     string(events.VulnerabilityTypeInjection),
+			Severity: string(events.VulnerabilitySeverityHigh),
+			Reported: 1,
+			Omitted:  9,
+		},
+		{Category: findingCategorySecret, Type: "private_key", Reported: 20, Omitted: 4},
+	}
+
+	result, err := engine.MakeDecision(context.Background(), req)
+	require.NoError(t, err)
+
+	criticalCount, highCount := engine.countIssuesBySeverity(req)
+	assert.Equal(t, 4, criticalCount, "omitted blocked secrets count at their severity")
+	assert.Equal(t, 10, highCount, "omitted vulnerabilities count at their severity")
+	assert.NotEqual(t, events.ControlDecisionApprove, result.Decision)
+	var summaries []string
+	for _, issue := range result.BlockingIssues {
+		if strings.HasPrefix(issue.ID, "throttled-") {
+			summaries = append(summaries, issue.Title)
+		}
+	}
+	assert.Equal(t, []string{"+4 more private_key findings"}, summaries,
+		"over-cap findings are summarized rather than listed")
+}
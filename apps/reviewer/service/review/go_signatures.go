@@ -0,0 +1,94 @@
+package review
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"strings"
+)
+
+// goFunctionSignatures returns the canonical signature of every function and
+// method declared in Go source, keyed by function name or by Type.Method. The
+// canonical form keeps the receiver, type parameter and parameter types while
+// dropping parameter names and formatting, so func(a, b int) and
+// func(a int, b int) compare equal. It reports false when content does not parse.
+func goFunctionSignatures(content string) (map[string]string, bool) {
+	file, err := parser.ParseFile(token.NewFileSet(), "", content, parser.SkipObjectResolution)
+	if err != nil {
+		return nil, false
+	}
+
+	signatures := make(map[string]string)
+	for _, decl := range file.Decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if !ok {
+			continue
+		}
+
+		key := fn.Name.Name
+		var sig strings.Builder
+		sig.WriteString("func ")
+		if fn.Recv != nil && len(fn.Recv.List) > 0 {
+			recv := fn.Recv.List[0].Type
+			key = receiverTypeName(recv) + "." + key
+			sig.WriteString("(" + types.ExprString(recv) + ") ")
+		}
+		sig.WriteString(fn.Name.Name)
+		if fn.Type.TypeParams != nil {
+			sig.WriteString("[" + canonicalFields(fn.Type.TypeParams, true) + "]")
+		}
+		sig.WriteString("(" + canonicalFields(fn.Type.Params, false) + ")")
+		if results := canonicalFields(fn.Type.Results, false); results != "" {
+			sig.WriteString(" (" + results + ")")
+		}
+		signatures[key] = sig.String()
+	}
+	return signatures, true
+}
+
+// canonicalFields lists one entry per name of a field list, so grouped and
+// ungrouped declarations match. Names are kept only when withNames is set.
+func canonicalFields(fields *ast.FieldList, withNames bool) string {
+	if fields == nil {
+		return ""
+	}
+
+	var parts []string
+	for _, field := range fields.List {
+		typ := types.ExprString(field.Type)
+		if len(field.Names) == 0 {
+			parts = append(parts, typ)
+			continue
+		}
+		for _, name := range field.Names {
+			if withNames {
+				parts = append(parts, name.Name+" "+typ)
+			} else {
+				parts = append(parts, typ)
+			}
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// receiverTypeName returns the type name of a method receiver, without pointer
+// or type arguments: *Store[T] is Store.
+func receiverTypeName(expr ast.Expr) string {
+	for {
+		switch e := expr.(type) {
+		case *ast.StarExpr:
+			expr = e.X
+		case *ast.IndexExpr:
+			expr = e.X
+		case *ast.IndexListExpr:
+			expr = e.X
+		case *ast.ParenExpr:
+			expr = e.X
+		case *ast.Ident:
+			return e.Name
+		default:
+			return types.ExprString(expr)
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package review //nolint:testpackage // white-box testing requires internal access
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/antinvestor/builder/internal/events"
+)
+
+func TestGoFunctionSignatures(t *testing.T) {
+	signatures, ok := goFunctionSignatures(`package store
+
+func Map[K comparable, V any](items map[K]V, fn func(V) V) map[K]V { return nil }
+
+func (s *Store[T]) Get(ctx context.Context, id string) (T, bool, error) { return s.zero, false, nil }
+
+func (Store[T]) Len() int { return 0 }
+`)
+	require.True(t, ok)
+
+	assert.Equal(t, map[string]string{
+		"Map":       "func Map[K comparable, V any](map[K]V, func(V) V) (map[K]V)",
+		"Store.Get": "func (*Store[T]) Get(context.Context, string) (T, bool, error)",
+		"Store.Len": "func (Store[T]) Len() (int)",
+	}, signatures)
+
+	_, ok = goFunctionSignatures("func Broken(")
+	assert.False(t, ok)
+}
+
+func TestGoFunctionSignatures_FormattingInsensitive(t *testing.T) {
+	grouped, ok := goFunctionSignatures("package p\n\nfunc Sum(a, b int) (total int, err error) { return }\n")
+	require.True(t, ok)
+	reformatted, ok := goFunctionSignatures(`package p
+
+func Sum(
+	a int,
+	b int,
+) (int, error) {
+	return 0, nil
+}
+`)
+	require.True(t, ok)
+
+	assert.Equal(t, grouped, reformatted)
+}
+
+func TestPatternArchitectureAnalyzer_GoSignatureChanges(t *testing.T) {
+	analyzer := NewPatternArchitectureAnalyzer(nil)
+	baseline := `package store
+
+func (s *Store) Get(ctx context.Context, id string) (*Item, error) { return nil, nil }
+
+func (s *store) Put(item *Item) error { return nil }
+
+func Filter[T any](items []T, keep func(T) bool) []T { return nil }
+`
+
+	tests := []struct {
+		name        string
+		current     string
+		wantSymbols []string
+	}{
+		{
+			name: "reformatting is not a breaking change",
+			current: `package store
+
+func (s *Store) Get(
+	ctx context.Context,
+	id string,
+) (*Item, error) {
+	return nil, nil
+}
+
+func (s *store) Put(item *Item) error { return nil }
+
+func Filter[T any](
+	items []T,
+	keep func(T) bool,
+) []T {
+	return nil
+}
+`,
+		},
+		{
+			name: "changed results and type parameters are reported",
+			current: `package store
+
+func (s *Store) Get(ctx context.Context, id string) (*Item, bool, error) { return nil, false, nil }
+
+func (s *store) Put(item *Item, force bool) error { return nil }
+
+func Filter[T comparable](items []T, keep func(T) bool) []T { return nil }
+`,
+			wantSymbols: []string{"Filter", "Store.Get"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assessment, err := analyzer.Analyze(context.Background(), &ArchitectureAnalysisRequest{
+				FileContents:     map[string]string{"store/store.go": tt.current},
+				BaselineContents: map[string]string{"store/store.go": baseline},
+			})
+			require.NoError(t, err)
+
+			var symbols []string
+			for _, change := range assessment.BreakingChanges {
+				if change.ChangeType == events.BreakingChangeChangedSignature {
+					symbols = append(symbols, change.Symbol)
+				}
+			}
+			assert.ElementsMatch(t, tt.wantSymbols, symbols, "methods of unexported types are not API")
+		})
+	}
+}
@@ -4,6 +4,10 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"slices"
+	"time"
+
+	"github.com/pitabwire/util"
 
 	appconfig "github.com/antinvestor/builder/apps/reviewer/config"
 	"github.com/antinvestor/builder/internal/events"
@@ -28,6 +32,10 @@ type RequestHandler struct {
 	decisionEngine       DecisionEngine
 	killSwitchService    KillSwitchService
 	eventsMan            EventsEmitter
+	queueMan             QueueManager
+	contents             ContentFetcher
+	shadowEvaluator      *ShadowEvaluator
+	analyzers            *AnalyzerRegistry
 }
 
 // NewRequestHandler creates a new review request handler.
@@ -46,9 +54,32 @@ func NewRequestHandler(
 		decisionEngine:       decisionEngine,
 		killSwitchService:    killSwitchService,
 		eventsMan:            eventsMan,
+		contents:             NewDiffContentFetcher(),
 	}
 }
 
+// SetShadowEvaluator enables shadow decisions; without one only the primary decision runs.
+func (h *RequestHandler) SetShadowEvaluator(evaluator *ShadowEvaluator) {
+	h.shadowEvaluator = evaluator
+}
+
+// SetQueueManager publishes review results to QueueReviewResultName; without one
+// results are only emitted as events.
+func (h *RequestHandler) SetQueueManager(queueMan QueueManager) {
+	h.queueMan = queueMan
+}
+
+// SetContentFetcher replaces how the file contents the analyzers inspect are
+// obtained; by default they are reconstructed from the patches.
+func (h *RequestHandler) SetContentFetcher(fetcher ContentFetcher) {
+	h.contents = fetcher
+}
+
+// SetAnalyzerRegistry runs the registry's custom analyzers in addition to the built-in ones.
+func (h *RequestHandler) SetAnalyzerRegistry(registry *AnalyzerRegistry) {
+	h.analyzers = registry
+}
+
 // Handle processes incoming review request messages.
 func (h *RequestHandler) Handle(
 	ctx context.Context,
@@ -60,36 +91,120 @@ func (h *RequestHandler) Handle(
 		return fmt.Errorf("unmarshal review request: %w", err)
 	}
 
-	// Check kill switch first
-	active, reason, _ := h.killSwitchService.IsActive(ctx, request.ExecutionID, "")
-	if active {
-		return h.emitAbort(ctx, request.ExecutionID, string(reason))
+	// An active kill switch aborts the review before anything is analyzed
+	killSwitchActive, reason, scope := h.killSwitchService.IsActive(ctx, request.ExecutionID, repositoryID(&request))
+	if killSwitchActive {
+		util.Log(ctx).Warn("kill switch active, aborting review",
+			"execution_id", request.ExecutionID.String(),
+			"reason", string(reason),
+			"scope", string(scope),
+		)
+		return h.abortReview(ctx, &request, string(reason))
 	}
 
-	// Convert PatchReferences to Patches for analysis
-	patches := convertPatchReferences(request.Patches)
+	// Convert PatchReferences to Patches for analysis. Files the operator ignores for
+	// review are dropped here, so they are never analyzed, reported or counted.
+	changed := convertPatchReferences(request.Patches)
+	patches := withoutPaths(changed, h.cfg.ReviewIgnorePathPatterns())
+	if ignored := len(changed) - len(patches); ignored > 0 {
+		util.Log(ctx).Debug("ignoring files excluded from review",
+			"execution_id", request.ExecutionID.String(),
+			"ignored_files", ignored,
+		)
+	}
 
-	// Run security analysis
-	securityAssessment, err := h.securityAnalyzer.Analyze(ctx, &SecurityAnalysisRequest{
-		Patches:  patches,
-		Language: h.detectLanguage(patches),
-	})
-	if err != nil {
-		return fmt.Errorf("security analysis failed: %w", err)
+	// Oversized changes are sampled so the analyzers are not overwhelmed
+	sample := sampleForReview(patches, h.cfg.MaxReviewFiles, h.cfg.MaxReviewBytes)
+	if sample.Partial() {
+		util.Log(ctx).Warn("review exceeds size limits, analyzing a sample",
+			"execution_id", request.ExecutionID.String(),
+			"files", len(patches),
+			"sampled_files", len(sample.Patches),
+		)
 	}
 
-	// Run architecture analysis
-	architectureAssessment, err := h.architectureAnalyzer.Analyze(ctx, &ArchitectureAnalysisRequest{
-		Patches:  patches,
-		Language: h.detectLanguage(patches),
-	})
-	if err != nil {
-		return fmt.Errorf("architecture analysis failed: %w", err)
+	// Vendored third-party code is not the change author's, so it is only scanned
+	// for secrets and security issues and skips the other analyzers
+	vendored := matchingFiles(sample.Patches, h.cfg.VendoredPathPatterns())
+	analyzed := withoutPaths(sample.Patches, h.cfg.VendoredPathPatterns())
+	if len(vendored) > 0 {
+		util.Log(ctx).Debug("skipping vendored files",
+			"execution_id", request.ExecutionID.String(),
+			"vendored_files", len(vendored),
+		)
+	}
+
+	contents, baseline := h.fetchContents(ctx, &request, sample.Patches)
+
+	// Run the enabled built-in analyzers; a disabled or failed analyzer leaves its
+	// assessment nil, so the decision engine excludes its risk
+	var analyzerMetrics []events.AnalyzerMetrics
+	var failedAnalyzers []string
+	var securityAssessment *events.SecurityAssessment
+	var err error
+	if !h.cfg.DisableSecurity {
+		started := time.Now()
+		securityAssessment, err = h.securityAnalyzer.Analyze(ctx, &SecurityAnalysisRequest{
+			ExecutionID:      request.ExecutionID,
+			Patches:          sample.Patches,
+			FileContents:     contents,
+			BaselineContents: baseline,
+			RepositoryID:     repositoryID(&request),
+			Language:         h.detectLanguage(sample.Patches),
+		})
+		if err != nil {
+			failedAnalyzers = append(failedAnalyzers, analyzerFailed(ctx, &request, analyzerNameSecurity, err))
+			securityAssessment = nil
+		} else {
+			if h.cfg.DisableDependency && securityAssessment != nil {
+				securityAssessment.DependencyVulnerabilities = nil
+			}
+			analyzerMetrics = append(analyzerMetrics,
+				newAnalyzerMetrics(analyzerNameSecurity, started, securityFindingCount(securityAssessment)))
+		}
+	}
+
+	var architectureAssessment *events.ArchitectureAssessment
+	if !h.cfg.DisableArchitecture {
+		started := time.Now()
+		architectureAssessment, err = h.architectureAnalyzer.Analyze(ctx, &ArchitectureAnalysisRequest{
+			Patches:          analyzed,
+			FileContents:     contents,
+			BaselineContents: baseline,
+			Language:         h.detectLanguage(analyzed),
+		})
+		if err != nil {
+			failedAnalyzers = append(failedAnalyzers, analyzerFailed(ctx, &request, analyzerNameArchitecture, err))
+			architectureAssessment = nil
+		} else {
+			analyzerMetrics = append(analyzerMetrics,
+				newAnalyzerMetrics(analyzerNameArchitecture, started, architectureFindingCount(architectureAssessment)))
+		}
+	}
+
+	// Run custom analyzers
+	var customIssues []events.ReviewIssue
+	if h.analyzers != nil {
+		var customMetrics []events.AnalyzerMetrics
+		customIssues, customMetrics, err = h.analyzers.Run(ctx, &AnalysisRequest{
+			Patches:      analyzed,
+			FileContents: contents,
+			RepositoryID: repositoryID(&request),
+			Language:     h.detectLanguage(analyzed),
+		})
+		if err != nil {
+			failedAnalyzers = append(failedAnalyzers, analyzerFailed(ctx, &request, analyzerNameCustom, err))
+		} else {
+			analyzerMetrics = append(analyzerMetrics, customMetrics...)
+		}
 	}
 
 	// Make decision
-	thresholds := h.cfg.GetReviewThresholds()
-	decision, err := h.decisionEngine.MakeDecision(ctx, &DecisionRequest{
+	targetEnv := h.targetEnvironment(&request)
+	thresholds := h.cfg.GetEnvironmentThresholds(targetEnv)
+	// Scrutiny is a policy on everything changed, so ignoring a file for review does not exempt it
+	scrutinized := matchingFiles(changed, h.cfg.ScrutinizedPathPatterns())
+	decisionReq := &DecisionRequest{
 		ExecutionID:            request.ExecutionID,
 		ReviewPhase:            request.ReviewPhase,
 		SecurityAssessment:     securityAssessment,
@@ -97,13 +212,96 @@ func (h *RequestHandler) Handle(
 		TestResult:             request.TestResults,
 		IterationNumber:        h.getIterationNumber(&request),
 		Thresholds:             thresholds,
-	})
+		TargetEnvironment:      targetEnv,
+		AcceptanceAssessment:   acceptanceAssessment(&request),
+		UnreviewedFiles:        sample.Unreviewed,
+		ScrutinizedFiles:       scrutinized,
+		AllFilesIgnored:        len(changed) > 0 && len(patches) == 0,
+		CustomIssues:           customIssues,
+		FailedAnalyzers:        failedAnalyzers,
+	}
+	decision, err := h.decisionEngine.MakeDecision(ctx, decisionReq)
 	if err != nil {
 		return fmt.Errorf("decision making failed: %w", err)
 	}
 
-	// Emit result event
-	return h.emitDecision(ctx, request.ExecutionID, decision)
+	// Shadow decisions are recorded for comparison only; the primary decision is emitted
+	if h.shadowEvaluator != nil {
+		h.shadowEvaluator.Evaluate(ctx, decisionReq, decision)
+	}
+
+	// Emit and publish the result
+	completed := &events.ComprehensiveReviewCompletedPayload{
+		ExecutionID:       request.ExecutionID,
+		StepID:            request.StepID,
+		ReviewID:          events.NewEventID().String(),
+		Decision:          decision.Decision,
+		RiskAssessment:    decision.RiskAssessment,
+		Issues:            reviewIssues(decision.BlockingIssues, customIssues),
+		BlockingIssues:    decision.BlockingIssues,
+		DecisionRationale: decision.Rationale,
+		NextActions:       decision.NextActions,
+		FileStatuses:      buildFileStatuses(patches, decision.BlockingIssues, sample.Unreviewed, vendored),
+		Partial:           sample.Partial(),
+		UnreviewedFiles:   sample.Unreviewed,
+		ScrutinizedFiles:  decisionReq.ScrutinizedFiles,
+		AnalyzerMetrics:   analyzerMetrics,
+		CompletedAt:       time.Now(),
+	}
+	if securityAssessment != nil {
+		completed.SecurityAssessment = *securityAssessment
+	}
+	if architectureAssessment != nil {
+		completed.ArchitectureAssessment = *architectureAssessment
+	}
+	return h.emitDecision(ctx, completed)
+}
+
+// fetchContents returns the contents of the reviewed files, or none when fetching
+// fails, in which case the analyzers use the diffs.
+func (h *RequestHandler) fetchContents(
+	ctx context.Context,
+	request *events.ComprehensiveReviewRequestedPayload,
+	patches []events.Patch,
+) (map[string]string, map[string]string) {
+	if h.contents == nil {
+		return nil, nil
+	}
+	contents, baseline, err := h.contents.FetchContents(ctx, request, patches)
+	if err != nil {
+		util.Log(ctx).WithError(err).Warn("failed to fetch file contents, reviewing the diffs only",
+			"execution_id", request.ExecutionID.String(),
+		)
+		return nil, nil
+	}
+	return contents, baseline
+}
+
+// reviewIssues returns every issue of a review: the blocking issues followed by
+// the non-blocking custom analyzer findings.
+func reviewIssues(blocking, custom []events.ReviewIssue) []events.ReviewIssue {
+	issues := slices.Clone(blocking)
+	for _, issue := range custom {
+		if !slices.ContainsFunc(blocking, func(b events.ReviewIssue) bool { return b.ID == issue.ID }) {
+			issues = append(issues, issue)
+		}
+	}
+	return issues
+}
+
+// analyzerFailed logs an analyzer error and returns the analyzer's name. The review
+// continues without the analyzer; strict mode sends it to manual review instead.
+func analyzerFailed(
+	ctx context.Context,
+	request *events.ComprehensiveReviewRequestedPayload,
+	analyzer string,
+	err error,
+) string {
+	util.Log(ctx).WithError(err).Warn("analyzer failed, reviewing without its findings",
+		"execution_id", request.ExecutionID.String(),
+		"analyzer", analyzer,
+	)
+	return analyzer
 }
 
 func convertPatchReferences(refs []events.PatchReference) []events.Patch {
@@ -112,6 +310,8 @@ func convertPatchReferences(refs []events.PatchReference) []events.Patch {
 		patches[i] = events.Patch{
 			FilePath:     ref.FilePath,
 			Action:       events.FileAction(ref.ChangeType),
+			OldContent:   ref.OldContent,
+			NewContent:   ref.NewContent,
 			DiffContent:  ref.DiffContent,
 			LinesAdded:   ref.LinesAdded,
 			LinesRemoved: ref.LinesRemoved,
@@ -142,6 +342,34 @@ func (h *RequestHandler) detectLanguage(patches []events.Patch) string {
 	return langUnknown
 }
 
+// repositoryID returns the repository identifier of a review request, falling back to the remote URL.
+func repositoryID(request *events.ComprehensiveReviewRequestedPayload) string {
+	if request.Context == nil || request.Context.RepositoryContext == nil {
+		return ""
+	}
+	if request.Context.RepositoryContext.RepositoryID != "" {
+		return request.Context.RepositoryContext.RepositoryID
+	}
+	return request.Context.RepositoryContext.RemoteURL
+}
+
+// targetEnvironment resolves the environment the reviewed change targets.
+func (h *RequestHandler) targetEnvironment(request *events.ComprehensiveReviewRequestedPayload) events.TargetEnvironment {
+	if request.Context == nil || request.Context.RepositoryContext == nil {
+		return events.TargetEnvironmentUnspecified
+	}
+	repo := request.Context.RepositoryContext
+	return h.cfg.ResolveTargetEnvironment(repo.TargetBranch)
+}
+
+// acceptanceAssessment returns the generator's acceptance self-assessment, if one was attached.
+func acceptanceAssessment(request *events.ComprehensiveReviewRequestedPayload) *events.AcceptanceSelfAssessment {
+	if request.Context == nil {
+		return nil
+	}
+	return request.Context.AcceptanceAssessment
+}
+
 func (h *RequestHandler) getIterationNumber(request *events.ComprehensiveReviewRequestedPayload) int {
 	if request.Context != nil {
 		return request.Context.IterationNumber
@@ -149,31 +377,101 @@ func (h *RequestHandler) getIterationNumber(request *events.ComprehensiveReviewR
 	return 0
 }
 
-func (h *RequestHandler) emitAbort(ctx context.Context, executionID events.ExecutionID, reason string) error {
-	return h.eventsMan.Emit(ctx, "feature.review.abort", &events.FeatureAbortRequestedPayload{
-		ExecutionID:      executionID,
+// abortReview requests an abort with rollback of an execution stopped by the kill
+// switch and publishes the abort decision, without analyzing the change.
+func (h *RequestHandler) abortReview(
+	ctx context.Context,
+	request *events.ComprehensiveReviewRequestedPayload,
+	reason string,
+) error {
+	reviewID := events.NewEventID().String()
+	if err := h.eventsMan.Emit(ctx, "feature.review.abort", &events.FeatureAbortRequestedPayload{
+		ExecutionID:      request.ExecutionID,
+		ReviewID:         reviewID,
 		AbortReason:      events.AbortReasonKillSwitch,
 		AbortDetails:     reason,
 		RollbackRequired: true,
-	})
-}
+		RequestedAt:      time.Now(),
+	}); err != nil {
+		return fmt.Errorf("emit review abort: %w", err)
+	}
 
-func (h *RequestHandler) emitDecision(
-	ctx context.Context,
-	executionID events.ExecutionID,
-	decision *DecisionResult,
-) error {
-	eventName := "feature.review.completed"
-	return h.eventsMan.Emit(ctx, eventName, &events.ComprehensiveReviewCompletedPayload{
-		ExecutionID:       executionID,
+	decision, err := h.decisionEngine.MakeDecision(ctx, &DecisionRequest{
+		ExecutionID:      request.ExecutionID,
+		ReviewPhase:      request.ReviewPhase,
+		TestResult:       request.TestResults,
+		IterationNumber:  h.getIterationNumber(request),
+		KillSwitchActive: true,
+	})
+	if err != nil {
+		return fmt.Errorf("decision making failed: %w", err)
+	}
+	return h.publishResult(ctx, &events.ComprehensiveReviewCompletedPayload{
+		ExecutionID:       request.ExecutionID,
+		StepID:            request.StepID,
+		ReviewID:          reviewID,
 		Decision:          decision.Decision,
 		RiskAssessment:    decision.RiskAssessment,
 		BlockingIssues:    decision.BlockingIssues,
 		DecisionRationale: decision.Rationale,
 		NextActions:       decision.NextActions,
+		CompletedAt:       time.Now(),
 	})
 }
 
+// emitDecision emits the review result and publishes it to the review result queue.
+func (h *RequestHandler) emitDecision(
+	ctx context.Context,
+	completed *events.ComprehensiveReviewCompletedPayload,
+) error {
+	if err := h.eventsMan.Emit(ctx, "feature.review.completed", completed); err != nil {
+		return fmt.Errorf("emit review result: %w", err)
+	}
+	return h.publishResult(ctx, completed)
+}
+
+// publishResult publishes a review result to the review result queue the worker consumes.
+func (h *RequestHandler) publishResult(
+	ctx context.Context,
+	completed *events.ComprehensiveReviewCompletedPayload,
+) error {
+	if h.queueMan == nil {
+		return nil
+	}
+	if err := h.queueMan.Publish(ctx, h.cfg.QueueReviewResultName, completed); err != nil {
+		return fmt.Errorf("publish review result: %w", err)
+	}
+	return nil
+}
+
+// buildFileStatuses marks each reviewed file as flagged when a blocking issue
+// points at it and clean otherwise, so the worker can deliver the clean subset.
+// Files left out of a partial review are marked unreviewed, and vendored files
+// without a blocking issue skipped.
+func buildFileStatuses(
+	patches []events.Patch,
+	blockingIssues []events.ReviewIssue,
+	unreviewed []string,
+	vendored []string,
+) map[string]events.FileReviewStatus {
+	statuses := make(map[string]events.FileReviewStatus, len(patches))
+	for _, patch := range patches {
+		statuses[patch.FilePath] = events.FileReviewStatusClean
+	}
+	for _, file := range vendored {
+		statuses[file] = events.FileReviewStatusSkipped
+	}
+	for _, issue := range blockingIssues {
+		if issue.FilePath != "" {
+			statuses[issue.FilePath] = events.FileReviewStatusFlagged
+		}
+	}
+	for _, file := range unreviewed {
+		statuses[file] = events.FileReviewStatusUnreviewed
+	}
+	return statuses
+}
+
 func getFileExtension(path string) string {
 	for i := len(path) - 1; i >= 0; i-- {
 		if path[i] == '.' {
@@ -0,0 +1,387 @@
+//nolint:testpackage // white-box testing requires internal package access
+package review
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"maps"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	appconfig "github.com/antinvestor/builder/apps/reviewer/config"
+	"github.com/antinvestor/builder/internal/events"
+)
+
+// riskySecurityAnalyzer reports a low-scoring assessment with a vulnerable
+// dependency and counts its runs.
+type riskySecurityAnalyzer struct {
+	runs int
+}
+
+func (a *riskySecurityAnalyzer) Analyze(context.Context, *SecurityAnalysisRequest) (*events.SecurityAssessment, error) {
+	a.runs++
+	assessment := newCleanSecurityAssessment()
+	assessment.OverallSecurityScore = 60
+	assessment.DependencyVulnerabilities = []events.DependencyVulnerability{{}}
+	return assessment, nil
+}
+
+// riskyArchitectureAnalyzer reports a low-scoring assessment and counts its runs.
+type riskyArchitectureAnalyzer struct {
+	runs int
+}
+
+func (a *riskyArchitectureAnalyzer) Analyze(
+	context.Context,
+	*ArchitectureAnalysisRequest,
+) (*events.ArchitectureAssessment, error) {
+	a.runs++
+	assessment := newCleanArchitectureAssessment()
+	assessment.OverallArchitectureScore = 40
+	return assessment, nil
+}
+
+func handleToggledReview(
+	t *testing.T,
+	cfg *appconfig.ReviewerConfig,
+	security SecurityAnalyzer,
+	architecture ArchitectureAnalyzer,
+) *events.ComprehensiveReviewCompletedPayload {
+	t.Helper()
+	cfg.MaxRiskScore = 50
+	cfg.MaxSecurityRiskScore = 50
+	cfg.MaxHighIssues = 2
+	cfg.MaxIterations = 3
+
+	emitter := &mockEventsEmitter{}
+	handler := NewRequestHandler(
+		cfg,
+		security,
+		architecture,
+		NewThresholdDecisionEngine(cfg),
+		NewDefaultKillSwitchService(cfg, emitter),
+		emitter,
+	)
+	payload, err := json.Marshal(&events.ComprehensiveReviewRequestedPayload{
+		ExecutionID: events.NewExecutionID(),
+		TestResults: newPassingTestResult(),
+		Patches: []events.PatchReference{
+			{FilePath: "scripts/deploy.py", ChangeType: "modify", DiffContent: "+print('deploy')"},
+		},
+	})
+	require.NoError(t, err)
+	require.NoError(t, handler.Handle(context.Background(), nil, payload))
+
+	require.Len(t, emitter.emittedEvents, 1)
+	completed, ok := emitter.emittedEvents[0].payload.(*events.ComprehensiveReviewCompletedPayload)
+	require.True(t, ok)
+	return completed
+}
+
+func riskCategories(assessment events.RiskAssessment) []events.RiskCategory {
+	categories := make([]events.RiskCategory, len(assessment.RiskFactors))
+	for i, factor := range assessment.RiskFactors {
+		categories[i] = factor.Category
+	}
+	return categories
+}
+
+func metricNames(metrics []events.AnalyzerMetrics) []string {
+	names := make([]string, len(metrics))
+	for i, m := range metrics {
+		names[i] = m.Analyzer
+	}
+	return names
+}
+
+func TestRequestHandler_DisabledArchitectureAnalyzerIsSkipped(t *testing.T) {
+	security := &riskySecurityAnalyzer{}
+	architecture := &riskyArchitectureAnalyzer{}
+	completed := handleToggledReview(t, &appconfig.ReviewerConfig{
+		DisableArchitecture: true,
+	}, security, architecture)
+
+	assert.Equal(t, 1, security.runs)
+	assert.Zero(t, architecture.runs)
+	assert.Equal(t, []string{analyzerNameSecurity}, metricNames(completed.AnalyzerMetrics))
+
+	assert.Zero(t, completed.RiskAssessment.ArchitectureRiskScore)
+	assert.NotContains(t, riskCategories(completed.RiskAssessment), events.RiskCategoryArchitecture)
+	assert.Contains(t, riskCategories(completed.RiskAssessment), events.RiskCategorySecurity)
+	// The average covers security and tests only: (40 + 0) / 2
+	assert.Equal(t, 20, completed.RiskAssessment.OverallRiskScore)
+}
+
+func TestRequestHandler_DisabledSecurityAnalyzerIsSkipped(t *testing.T) {
+	security := &riskySecurityAnalyzer{}
+	architecture := &riskyArchitectureAnalyzer{}
+	completed := handleToggledReview(t, &appconfig.ReviewerConfig{
+		DisableSecurity: true,
+	}, security, architecture)
+
+	assert.Zero(t, security.runs)
+	assert.Equal(t, 1, architecture.runs)
+	assert.Equal(t, []string{analyzerNameArchitecture}, metricNames(completed.AnalyzerMetrics))
+
+	assert.Zero(t, completed.RiskAssessment.SecurityRiskScore)
+	assert.NotContains(t, riskCategories(completed.RiskAssessment), events.RiskCategorySecurity)
+	// The average covers architecture and tests only: (60 + 0) / 2
+	assert.Equal(t, 30, completed.RiskAssessment.OverallRiskScore)
+}
+
+func TestRequestHandler_DisabledDependencyReviewDropsFindings(t *testing.T) {
+	enabled := handleToggledReview(t, &appconfig.ReviewerConfig{
+		DisableArchitecture: true,
+	}, &riskySecurityAnalyzer{}, &riskyArchitectureAnalyzer{})
+	require.Len(t, enabled.AnalyzerMetrics, 1)
+	assert.Equal(t, 1, enabled.AnalyzerMetrics[0].FindingCount)
+
+	disabled := handleToggledReview(t, &appconfig.ReviewerConfig{
+		DisableArchitecture: true,
+		DisableDependency:   true,
+	}, &riskySecurityAnalyzer{}, &riskyArchitectureAnalyzer{})
+	require.Len(t, disabled.AnalyzerMetrics, 1)
+	assert.Zero(t, disabled.AnalyzerMetrics[0].FindingCount)
+}
+
+// failingArchitectureAnalyzer always errors.
+type failingArchitectureAnalyzer struct{}
+
+func (failingArchitectureAnalyzer) Analyze(
+	context.Context,
+	*ArchitectureAnalysisRequest,
+) (*events.ArchitectureAssessment, error) {
+	return nil, errors.New("parser crashed")
+}
+
+func TestRequestHandler_FailedAnalyzerUnderStrictModeRequiresManualReview(t *testing.T) {
+	completed := handleToggledReview(t, &appconfig.ReviewerConfig{
+		StrictMode: true,
+	}, &fixedSecurityAnalyzer{assessment: newCleanSecurityAssessment()}, failingArchitectureAnalyzer{})
+
+	assert.Equal(t, events.ControlDecisionManualReview, completed.Decision)
+	assert.Contains(t, completed.DecisionRationale, "analyzers failed: architecture")
+}
+
+func TestRequestHandler_FailedAnalyzerProceedsWithWarning(t *testing.T) {
+	completed := handleToggledReview(t, &appconfig.ReviewerConfig{}, &fixedSecurityAnalyzer{assessment: newCleanSecurityAssessment()}, failingArchitectureAnalyzer{})
+
+	assert.Equal(t, events.ControlDecisionApproveWithWarnings, completed.Decision)
+	assert.Equal(t, []string{analyzerNameSecurity}, metricNames(completed.AnalyzerMetrics))
+	assert.NotContains(t, riskCategories(completed.RiskAssessment), events.RiskCategoryArchitecture)
+}
+
+// failingSecurityAnalyzer always errors.
+type failingSecurityAnalyzer struct{}
+
+func (failingSecurityAnalyzer) Analyze(context.Context, *SecurityAnalysisRequest) (*events.SecurityAssessment, error) {
+	return nil, errors.New("scanner crashed")
+}
+
+func TestRequestHandler_FailedSecurityAnalyzerRequiresManualReview(t *testing.T) {
+	completed := handleToggledReview(t, &appconfig.ReviewerConfig{}, failingSecurityAnalyzer{}, &riskyArchitectureAnalyzer{})
+
+	assert.Equal(t, events.ControlDecisionManualReview, completed.Decision)
+	assert.Contains(t, completed.DecisionRationale, "analyzers failed: security")
+}
+
+// recordingQueueManager records published messages.
+type recordingQueueManager struct {
+	queues   []string
+	payloads []any
+	headers  []map[string]string
+}
+
+func (m *recordingQueueManager) Publish(
+	_ context.Context,
+	queueName string,
+	payload any,
+	headers ...map[string]string,
+) error {
+	merged := map[string]string{}
+	for _, h := range headers {
+		maps.Copy(merged, h)
+	}
+	m.queues = append(m.queues, queueName)
+	m.payloads = append(m.payloads, payload)
+	m.headers = append(m.headers, merged)
+	return nil
+}
+
+// staticContentFetcher returns fixed file contents.
+type staticContentFetcher struct {
+	contents map[string]string
+	baseline map[string]string
+}
+
+func (f staticContentFetcher) FetchContents(
+	context.Context,
+	*events.ComprehensiveReviewRequestedPayload,
+	[]events.Patch,
+) (map[string]string, map[string]string, error) {
+	return f.contents, f.baseline, nil
+}
+
+// capturingSecurityAnalyzer records the request it analyzed.
+type capturingSecurityAnalyzer struct {
+	fixedSecurityAnalyzer
+
+	request *SecurityAnalysisRequest
+}
+
+func (a *capturingSecurityAnalyzer) Analyze(
+	ctx context.Context,
+	req *SecurityAnalysisRequest,
+) (*events.SecurityAssessment, error) {
+	a.request = req
+	return a.fixedSecurityAnalyzer.Analyze(ctx, req)
+}
+
+func publishReview(
+	t *testing.T,
+	killSwitch KillSwitchService,
+	security SecurityAnalyzer,
+	fetcher ContentFetcher,
+) *events.ComprehensiveReviewCompletedPayload {
+	t.Helper()
+	return publishRepositoryReview(t, killSwitch, security, fetcher, "")
+}
+
+// publishRepositoryReview handles a review of a change to repositoryID.
+func publishRepositoryReview(
+	t *testing.T,
+	killSwitch KillSwitchService,
+	security SecurityAnalyzer,
+	fetcher ContentFetcher,
+	repositoryID string,
+) *events.ComprehensiveReviewCompletedPayload {
+	t.Helper()
+	completed, emitter := handleRepositoryReview(t, killSwitch, security, fetcher, repositoryID)
+	require.Len(t, emitter.emittedEvents, 1)
+	assert.Same(t, completed, emitter.emittedEvents[0].payload)
+	return completed
+}
+
+// handleRepositoryReview handles a review of a change to repositoryID and returns
+// the published result with the events emitted on the way.
+func handleRepositoryReview(
+	t *testing.T,
+	killSwitch KillSwitchService,
+	security SecurityAnalyzer,
+	fetcher ContentFetcher,
+	repositoryID string,
+) (*events.ComprehensiveReviewCompletedPayload, *mockEventsEmitter) {
+	t.Helper()
+	cfg := &appconfig.ReviewerConfig{
+		QueueReviewResultName: "feature.review.results",
+		MaxRiskScore:          50,
+		MaxSecurityRiskScore:  50,
+		MaxHighIssues:         2,
+		MaxIterations:         3,
+	}
+	emitter := &mockEventsEmitter{}
+	if killSwitch == nil {
+		killSwitch = NewDefaultKillSwitchService(cfg, emitter)
+	}
+	queueMan := &recordingQueueManager{}
+	handler := NewRequestHandler(cfg, security,
+		stubArchitectureAnalyzer{},
+		NewThresholdDecisionEngine(cfg), killSwitch, emitter)
+	handler.SetQueueManager(queueMan)
+	if fetcher != nil {
+		handler.SetContentFetcher(fetcher)
+	}
+
+	request := &events.ComprehensiveReviewRequestedPayload{
+		ExecutionID: events.NewExecutionID(),
+		TestResults: newPassingTestResult(),
+		Patches: []events.PatchReference{
+			{FilePath: "db/query.go", ChangeType: "modify", DiffContent: "+rows, err := db.Query(sql)"},
+		},
+	}
+	if repositoryID != "" {
+		request.Context = &events.ReviewContext{
+			RepositoryContext: &events.RepositoryContext{RepositoryID: repositoryID},
+		}
+	}
+	payload, err := json.Marshal(request)
+	require.NoError(t, err)
+	require.NoError(t, handler.Handle(context.Background(), nil, payload))
+
+	require.Equal(t, []string{"feature.review.results"}, queueMan.queues)
+	completed, ok := queueMan.payloads[0].(*events.ComprehensiveReviewCompletedPayload)
+	require.True(t, ok)
+	return completed, emitter
+}
+
+func TestRequestHandler_CriticalVulnerabilityPublishesAbort(t *testing.T) {
+	security := &capturingSecurityAnalyzer{fixedSecurityAnalyzer: fixedSecurityAnalyzer{
+		assessment: assessmentWithVulnerabilities(events.Vulnerability{
+			ID:        "sqli-1",
+			Type:      events.VulnerabilityTypeInjection,
+			Severity:  events.VulnerabilitySeverityCritical,
+			FilePath:  "db/query.go",
+			LineStart: 12,
+			Title:     "SQL injection",
+		}),
+	}}
+
+	completed := publishReview(t, nil, security, staticContentFetcher{
+		contents: map[string]string{"db/query.go": "package db\n"},
+		baseline: map[string]string{"db/query.go": "package db\n\n// old\n"},
+	})
+
+	assert.Equal(t, events.ControlDecisionAbort, completed.Decision)
+	assert.NotEmpty(t, completed.ReviewID)
+	assert.False(t, completed.CompletedAt.IsZero())
+	require.Len(t, completed.SecurityAssessment.VulnerabilitiesFound, 1)
+	require.NotEmpty(t, completed.BlockingIssues)
+	assert.Equal(t, "sqli-1", completed.BlockingIssues[0].ID)
+	assert.Equal(t, completed.BlockingIssues, completed.Issues)
+	assert.Equal(t, events.FileReviewStatusFlagged, completed.FileStatuses["db/query.go"])
+
+	// The analyzers receive the fetched file contents
+	require.NotNil(t, security.request)
+	assert.Equal(t, "package db\n", security.request.FileContents["db/query.go"])
+	assert.Equal(t, "package db\n\n// old\n", security.request.BaselineContents["db/query.go"])
+}
+
+func TestRequestHandler_KillSwitchAbortsBeforeAnalysis(t *testing.T) {
+	killSwitch, _ := newTestKillSwitchService()
+	require.NoError(t, killSwitch.ActivateGlobal(context.Background(),
+		events.KillSwitchReasonManual, "oncall", "incident"))
+	security := &capturingSecurityAnalyzer{}
+
+	completed, emitter := handleRepositoryReview(t, killSwitch, security, nil, "")
+
+	assert.Equal(t, events.ControlDecisionAbort, completed.Decision)
+	assert.Contains(t, completed.DecisionRationale, string(events.AbortReasonKillSwitch))
+	assert.Nil(t, security.request, "nothing is analyzed while the kill switch is active")
+
+	require.Len(t, emitter.emittedEvents, 1)
+	assert.Equal(t, "feature.review.abort", emitter.emittedEvents[0].name)
+	abort, ok := emitter.emittedEvents[0].payload.(*events.FeatureAbortRequestedPayload)
+	require.True(t, ok)
+	assert.Equal(t, completed.ExecutionID, abort.ExecutionID)
+	assert.Equal(t, completed.ReviewID, abort.ReviewID)
+	assert.Equal(t, events.AbortReasonKillSwitch, abort.AbortReason)
+	assert.True(t, abort.RollbackRequired)
+}
+
+func TestRequestHandler_RepositoryKillSwitchAbortsOnlyThatRepository(t *testing.T) {
+	killSwitch, _ := newTestKillSwitchService()
+	require.NoError(t, killSwitch.ActivateForRepository(context.Background(),
+		"https://github.com/acme/noisy.git", events.KillSwitchReasonAnomalyDetected, "oncall", "runaway"))
+
+	completed, _ := handleRepositoryReview(t, killSwitch, stubSecurityAnalyzer{}, nil,
+		"https://github.com/acme/noisy")
+	assert.Equal(t, events.ControlDecisionAbort, completed.Decision)
+	assert.Contains(t, completed.DecisionRationale, string(events.AbortReasonKillSwitch))
+
+	completed = publishRepositoryReview(t, killSwitch, stubSecurityAnalyzer{}, nil,
+		"https://github.com/acme/quiet")
+	assert.NotEqual(t, events.ControlDecisionAbort, completed.Decision)
+}
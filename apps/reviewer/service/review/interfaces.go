@@ -27,6 +27,14 @@ type ArchitectureAnalyzer interface {
 	Analyze(ctx context.Context, req *ArchitectureAnalysisRequest) (*events.ArchitectureAssessment, error)
 }
 
+// Analyzer is a custom analyzer plugged into the review alongside the built-in
+// security and architecture analyzers, e.g. for org-specific conventions.
+type Analyzer interface {
+	// Name identifies the analyzer in logs and issue IDs.
+	Name() string
+	Analyze(ctx context.Context, req *AnalysisRequest) ([]events.ReviewIssue, error)
+}
+
 // DecisionEngine makes control decisions.
 type DecisionEngine interface {
 	MakeDecision(ctx context.Context, req *DecisionRequest) (*DecisionResult, error)
@@ -53,15 +61,39 @@ type EventsEmitter interface {
 	Emit(ctx context.Context, eventName string, payload any) error
 }
 
+// QueueManager publishes messages to queues.
+type QueueManager interface {
+	Publish(ctx context.Context, queueName string, payload any, headers ...map[string]string) error
+}
+
+// ContentFetcher supplies the contents of the files changed by a review request.
+type ContentFetcher interface {
+	// FetchContents returns the reviewed files' contents after the change and
+	// their baseline contents before it, keyed by file path.
+	FetchContents(
+		ctx context.Context,
+		request *events.ComprehensiveReviewRequestedPayload,
+		patches []events.Patch,
+	) (contents, baseline map[string]string, err error)
+}
+
 // =============================================================================
 // Request/Response Types
 // =============================================================================
 
 // SecurityAnalysisRequest contains data for security analysis.
 type SecurityAnalysisRequest struct {
+	// ExecutionID identifies the reviewed execution, e.g. for canary sampling.
+	ExecutionID  events.ExecutionID
 	Patches      []events.Patch
 	FileContents map[string]string
-	Language     string
+	// BaselineContents are the files before the change, used to tell whether a
+	// finding outside the changed lines was introduced by it.
+	BaselineContents map[string]string
+	// RepositoryID selects the baseline of pre-existing findings to suppress.
+	RepositoryID string
+	// Language is a fallback for files whose language cannot be detected from the extension.
+	Language string
 }
 
 // ArchitectureAnalysisRequest contains data for architecture analysis.
@@ -69,7 +101,17 @@ type ArchitectureAnalysisRequest struct {
 	Patches          []events.Patch
 	FileContents     map[string]string
 	BaselineContents map[string]string
-	Language         string
+	// Language is a fallback for files whose language cannot be detected from the extension.
+	Language string
+}
+
+// AnalysisRequest contains data for a custom analyzer.
+type AnalysisRequest struct {
+	Patches      []events.Patch
+	FileContents map[string]string
+	RepositoryID string
+	// Language is a fallback for files whose language cannot be detected from the extension.
+	Language string
 }
 
 // DecisionRequest contains data for making a control decision.
@@ -81,7 +123,17 @@ type DecisionRequest struct {
 	TestResult             *events.TestResult
 	IterationNumber        int
 	Thresholds             events.ReviewThresholds
-	KillSwitchActive       bool
+	TargetEnvironment      events.TargetEnvironment
+	AcceptanceAssessment   *events.AcceptanceSelfAssessment
+	UnreviewedFiles        []string
+	ScrutinizedFiles       []string
+	CustomIssues           []events.ReviewIssue
+	// AllFilesIgnored is true when review ignore rules left out every changed file,
+	// so nothing was analyzed.
+	AllFilesIgnored bool
+	// FailedAnalyzers are the analyzers that errored, so their findings are missing.
+	FailedAnalyzers  []string
+	KillSwitchActive bool
 }
 
 // DecisionResult contains the decision outcome.
@@ -2,6 +2,9 @@ package review
 
 import (
 	"context"
+	"fmt"
+	"maps"
+	"strings"
 	"sync"
 	"time"
 
@@ -9,6 +12,7 @@ import (
 
 	appconfig "github.com/antinvestor/builder/apps/reviewer/config"
 	"github.com/antinvestor/builder/internal/events"
+	"github.com/antinvestor/builder/internal/executions"
 )
 
 // =============================================================================
@@ -38,11 +42,14 @@ type FailureMetrics struct {
 // Persistent Kill Switch Service
 // =============================================================================
 
-// PersistentKillSwitchService implements KillSwitchService with in-memory state.
-// For production, this should be backed by a database or Redis.
+// PersistentKillSwitchService implements KillSwitchService with in-memory state,
+// written through to a KillSwitchStore when one is set so switches survive restarts.
 type PersistentKillSwitchService struct {
 	cfg       *appconfig.ReviewerConfig
 	eventsMan EventsEmitter
+	queueMan  QueueManager
+	store     KillSwitchStore
+	lister    ActiveExecutionLister
 
 	mu sync.RWMutex
 
@@ -90,6 +97,66 @@ func NewPersistentKillSwitchService(
 	}
 }
 
+// ActiveExecutionLister lists the in-flight executions a kill switch affects,
+// such as the shared execution repository.
+type ActiveExecutionLister interface {
+	ListActive(ctx context.Context) ([]*executions.Execution, error)
+}
+
+// SetQueueManager sets the queue manager kill switch control events are
+// published with, to the control events queue.
+func (s *PersistentKillSwitchService) SetQueueManager(queueMan QueueManager) {
+	s.queueMan = queueMan
+}
+
+// SetExecutionLister sets the lister activations enumerate their affected
+// executions from.
+func (s *PersistentKillSwitchService) SetExecutionLister(lister ActiveExecutionLister) {
+	s.lister = lister
+}
+
+// SetStore sets the store kill switch state is persisted to, restoring the
+// switches it holds.
+func (s *PersistentKillSwitchService) SetStore(ctx context.Context, store KillSwitchStore) error {
+	snapshot, err := store.Load(ctx)
+	if err != nil {
+		return fmt.Errorf("load kill switch state: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.store = store
+	s.globalState = snapshot.Global
+	s.repositoryStates = make(map[string]KillSwitchState, len(snapshot.Repositories))
+	maps.Copy(s.repositoryStates, snapshot.Repositories)
+	s.executionStates = make(map[string]KillSwitchState, len(snapshot.Executions))
+	maps.Copy(s.executionStates, snapshot.Executions)
+	return nil
+}
+
+// persistLocked saves the switches to the store; s.mu must be held.
+func (s *PersistentKillSwitchService) persistLocked(ctx context.Context) error {
+	if s.store == nil {
+		return nil
+	}
+	snapshot := &KillSwitchSnapshot{
+		Global:       s.globalState,
+		Repositories: maps.Clone(s.repositoryStates),
+		Executions:   maps.Clone(s.executionStates),
+	}
+	if err := s.store.Save(ctx, snapshot); err != nil {
+		return fmt.Errorf("persist kill switch state: %w", err)
+	}
+	return nil
+}
+
+// repositoryKey normalizes a repository identifier or remote URL, so a switch
+// activated for "https://host/org/repo.git" also matches "https://host/org/repo".
+func repositoryKey(repositoryID string) string {
+	key := strings.TrimSuffix(strings.TrimSpace(repositoryID), "/")
+	return strings.TrimSuffix(key, ".git")
+}
+
 // =============================================================================
 // KillSwitchService Interface Implementation
 // =============================================================================
@@ -122,7 +189,7 @@ func (s *PersistentKillSwitchService) IsActive(
 
 	// Check repository-scoped kill switch
 	if repositoryID != "" {
-		if state, exists := s.repositoryStates[repositoryID]; exists && state.Active {
+		if state, exists := s.repositoryStates[repositoryKey(repositoryID)]; exists && state.Active {
 			log.Debug("repository kill switch is active",
 				"reason", state.Reason,
 				"repository_id", repositoryID,
@@ -208,6 +275,7 @@ func (s *PersistentKillSwitchService) ActivateGlobal(
 		Actor:     activatedBy,
 		Details:   details,
 	})
+	persistErr := s.persistLocked(ctx)
 	s.mu.Unlock()
 
 	log.Info("global kill switch activated",
@@ -216,20 +284,17 @@ func (s *PersistentKillSwitchService) ActivateGlobal(
 		"details", details,
 	)
 
-	// Emit event
-	if s.eventsMan != nil {
-		if err := s.eventsMan.Emit(ctx, "feature.kill_switch.activated", &events.KillSwitchActivatedPayload{
-			Scope:       events.KillSwitchScopeGlobal,
-			Reason:      reason,
-			ActivatedBy: activatedBy,
-			Details:     details,
-			ActivatedAt: now,
-		}); err != nil {
-			log.Warn("failed to emit kill_switch.activated event", "err", err)
-		}
-	}
+	s.publishControlEvent(ctx, events.KillSwitchActivated, &events.KillSwitchActivatedPayload{
+		Scope:              events.KillSwitchScopeGlobal,
+		Reason:             reason,
+		ActivatedBy:        activatedBy,
+		Details:            details,
+		AffectedExecutions: s.affectedExecutions(ctx, ""),
+		RollbackAll:        s.cfg.KillSwitchRollbackAll,
+		ActivatedAt:        now,
+	})
 
-	return nil
+	return persistErr
 }
 
 // DeactivateGlobal deactivates the global kill switch.
@@ -255,6 +320,7 @@ func (s *PersistentKillSwitchService) DeactivateGlobal(
 		Actor:     deactivatedBy,
 		Details:   reason,
 	})
+	persistErr := s.persistLocked(ctx)
 	s.mu.Unlock()
 
 	log.Info("global kill switch deactivated",
@@ -262,19 +328,14 @@ func (s *PersistentKillSwitchService) DeactivateGlobal(
 		"reason", reason,
 	)
 
-	// Emit event
-	if s.eventsMan != nil {
-		if err := s.eventsMan.Emit(ctx, "feature.kill_switch.deactivated", &events.KillSwitchDeactivatedPayload{
-			Scope:         events.KillSwitchScopeGlobal,
-			DeactivatedBy: deactivatedBy,
-			Reason:        reason,
-			DeactivatedAt: now,
-		}); err != nil {
-			log.Warn("failed to emit kill_switch.deactivated event", "err", err)
-		}
-	}
+	s.publishControlEvent(ctx, events.KillSwitchDeactivated, &events.KillSwitchDeactivatedPayload{
+		Scope:         events.KillSwitchScopeGlobal,
+		DeactivatedBy: deactivatedBy,
+		Reason:        reason,
+		DeactivatedAt: now,
+	})
 
-	return nil
+	return persistErr
 }
 
 // =============================================================================
@@ -282,6 +343,7 @@ func (s *PersistentKillSwitchService) DeactivateGlobal(
 // =============================================================================
 
 // ActivateForRepository activates the kill switch for a specific repository.
+// repositoryID is the repository ID or remote URL review requests carry.
 func (s *PersistentKillSwitchService) ActivateForRepository(
 	ctx context.Context,
 	repositoryID string,
@@ -290,6 +352,7 @@ func (s *PersistentKillSwitchService) ActivateForRepository(
 ) error {
 	log := util.Log(ctx)
 	now := time.Now()
+	repositoryID = repositoryKey(repositoryID)
 
 	s.mu.Lock()
 	s.repositoryStates[repositoryID] = KillSwitchState{
@@ -310,6 +373,7 @@ func (s *PersistentKillSwitchService) ActivateForRepository(
 		Actor:     activatedBy,
 		Details:   details,
 	})
+	persistErr := s.persistLocked(ctx)
 	s.mu.Unlock()
 
 	log.Info("repository kill switch activated",
@@ -318,20 +382,18 @@ func (s *PersistentKillSwitchService) ActivateForRepository(
 		"activated_by", activatedBy,
 	)
 
-	// Emit event (note: API doesn't have RepositoryID field, so we put it in Details)
-	if s.eventsMan != nil {
-		if err := s.eventsMan.Emit(ctx, "feature.kill_switch.activated", &events.KillSwitchActivatedPayload{
-			Scope:       events.KillSwitchScopeRepository,
-			Reason:      reason,
-			ActivatedBy: activatedBy,
-			Details:     "repository:" + repositoryID + "; " + details,
-			ActivatedAt: now,
-		}); err != nil {
-			log.Warn("failed to emit kill_switch.activated event", "err", err)
-		}
-	}
+	s.publishControlEvent(ctx, events.KillSwitchActivated, &events.KillSwitchActivatedPayload{
+		Scope:              events.KillSwitchScopeRepository,
+		RepositoryID:       repositoryID,
+		Reason:             reason,
+		ActivatedBy:        activatedBy,
+		Details:            details,
+		AffectedExecutions: s.affectedExecutions(ctx, repositoryID),
+		RollbackAll:        s.cfg.KillSwitchRollbackAll,
+		ActivatedAt:        now,
+	})
 
-	return nil
+	return persistErr
 }
 
 // DeactivateForRepository deactivates the kill switch for a specific repository.
@@ -342,6 +404,7 @@ func (s *PersistentKillSwitchService) DeactivateForRepository(
 ) error {
 	log := util.Log(ctx)
 	now := time.Now()
+	repositoryID = repositoryKey(repositoryID)
 
 	s.mu.Lock()
 	delete(s.repositoryStates, repositoryID)
@@ -355,6 +418,7 @@ func (s *PersistentKillSwitchService) DeactivateForRepository(
 		Actor:     deactivatedBy,
 		Details:   reasonStr,
 	})
+	persistErr := s.persistLocked(ctx)
 	s.mu.Unlock()
 
 	log.Info("repository kill switch deactivated",
@@ -362,19 +426,15 @@ func (s *PersistentKillSwitchService) DeactivateForRepository(
 		"deactivated_by", deactivatedBy,
 	)
 
-	// Emit event (note: API doesn't have RepositoryID field, so we put it in Reason)
-	if s.eventsMan != nil {
-		if err := s.eventsMan.Emit(ctx, "feature.kill_switch.deactivated", &events.KillSwitchDeactivatedPayload{
-			Scope:         events.KillSwitchScopeRepository,
-			DeactivatedBy: deactivatedBy,
-			Reason:        "repository:" + repositoryID + "; " + reasonStr,
-			DeactivatedAt: now,
-		}); err != nil {
-			log.Warn("failed to emit kill_switch.deactivated event", "err", err)
-		}
-	}
+	s.publishControlEvent(ctx, events.KillSwitchDeactivated, &events.KillSwitchDeactivatedPayload{
+		Scope:         events.KillSwitchScopeRepository,
+		RepositoryID:  repositoryID,
+		DeactivatedBy: deactivatedBy,
+		Reason:        reasonStr,
+		DeactivatedAt: now,
+	})
 
-	return nil
+	return persistErr
 }
 
 // ActivateForExecution activates the kill switch for a specific execution.
@@ -407,6 +467,7 @@ func (s *PersistentKillSwitchService) ActivateForExecution(
 		Actor:     activatedBy,
 		Details:   details,
 	})
+	persistErr := s.persistLocked(ctx)
 	s.mu.Unlock()
 
 	log.Info("execution kill switch activated",
@@ -415,21 +476,17 @@ func (s *PersistentKillSwitchService) ActivateForExecution(
 		"activated_by", activatedBy,
 	)
 
-	// Emit event
-	if s.eventsMan != nil {
-		if err := s.eventsMan.Emit(ctx, "feature.kill_switch.activated", &events.KillSwitchActivatedPayload{
-			Scope:       events.KillSwitchScopeFeature,
-			ExecutionID: executionID,
-			Reason:      reason,
-			ActivatedBy: activatedBy,
-			Details:     details,
-			ActivatedAt: now,
-		}); err != nil {
-			log.Warn("failed to emit kill_switch.activated event", "err", err)
-		}
-	}
+	s.publishControlEvent(ctx, events.KillSwitchActivated, &events.KillSwitchActivatedPayload{
+		Scope:       events.KillSwitchScopeFeature,
+		ExecutionID: executionID,
+		Reason:      reason,
+		ActivatedBy: activatedBy,
+		Details:     details,
+		RollbackAll: s.cfg.KillSwitchRollbackAll,
+		ActivatedAt: now,
+	})
 
-	return nil
+	return persistErr
 }
 
 // DeactivateForExecution deactivates the kill switch for a specific execution.
@@ -454,6 +511,7 @@ func (s *PersistentKillSwitchService) DeactivateForExecution(
 		Actor:     deactivatedBy,
 		Details:   reason,
 	})
+	persistErr := s.persistLocked(ctx)
 	s.mu.Unlock()
 
 	log.Info("execution kill switch deactivated",
@@ -461,20 +519,67 @@ func (s *PersistentKillSwitchService) DeactivateForExecution(
 		"deactivated_by", deactivatedBy,
 	)
 
-	// Emit event
+	s.publishControlEvent(ctx, events.KillSwitchDeactivated, &events.KillSwitchDeactivatedPayload{
+		Scope:         events.KillSwitchScopeFeature,
+		ExecutionID:   executionID,
+		DeactivatedBy: deactivatedBy,
+		Reason:        reason,
+		DeactivatedAt: now,
+	})
+
+	return persistErr
+}
+
+// publishControlEvent emits a kill switch event and publishes it to the control
+// events queue, whose consumers roll back the affected in-flight work. The event
+// type travels in the HeaderEventType header so consumers need not infer it.
+func (s *PersistentKillSwitchService) publishControlEvent(
+	ctx context.Context,
+	eventType events.EventType,
+	payload any,
+) {
+	log := util.Log(ctx)
 	if s.eventsMan != nil {
-		if err := s.eventsMan.Emit(ctx, "feature.kill_switch.deactivated", &events.KillSwitchDeactivatedPayload{
-			Scope:         events.KillSwitchScopeFeature,
-			ExecutionID:   executionID,
-			DeactivatedBy: deactivatedBy,
-			Reason:        reason,
-			DeactivatedAt: now,
+		if err := s.eventsMan.Emit(ctx, string(eventType), payload); err != nil {
+			log.Warn("failed to emit kill switch event", "event", eventType, "err", err)
+		}
+	}
+	if s.queueMan != nil {
+		if err := s.queueMan.Publish(ctx, s.cfg.QueueControlEventsName, payload, map[string]string{
+			events.HeaderEventType: string(eventType),
 		}); err != nil {
-			log.Warn("failed to emit kill_switch.deactivated event", "err", err)
+			log.WithError(err).Error("failed to publish kill switch control event", "event", eventType)
 		}
 	}
+}
 
-	return nil
+// affectedExecutions returns the in-flight executions of a repository, or of all
+// repositories when repositoryID is empty. Without a lister there are none.
+func (s *PersistentKillSwitchService) affectedExecutions(
+	ctx context.Context,
+	repositoryID string,
+) []events.ExecutionID {
+	if s.lister == nil {
+		return nil
+	}
+	active, err := s.lister.ListActive(ctx)
+	if err != nil {
+		util.Log(ctx).WithError(err).Warn("failed to list executions affected by kill switch")
+		return nil
+	}
+
+	var affected []events.ExecutionID
+	for _, execution := range active {
+		if repositoryID != "" && repositoryKey(execution.RepositoryURL) != repositoryID {
+			continue
+		}
+		executionID, parseErr := events.ParseExecutionID(execution.ID)
+		if parseErr != nil {
+			continue
+		}
+		affected = append(affected, executionID)
+	}
+	return affected
 }
 
 // =============================================================================
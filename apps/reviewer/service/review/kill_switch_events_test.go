@@ -0,0 +1,112 @@
+//nolint:testpackage // white-box testing requires internal package access
+package review
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/antinvestor/builder/internal/events"
+	"github.com/antinvestor/builder/internal/executions"
+)
+
+// newControlEventsKillSwitch returns a kill switch service publishing to a
+// recording queue manager and listing executions from repo.
+func newControlEventsKillSwitch(
+	repo *executions.MemoryExecutionRepository,
+	rollbackAll bool,
+) (*PersistentKillSwitchService, *recordingQueueManager) {
+	svc, _ := newTestKillSwitchService()
+	svc.cfg.QueueControlEventsName = "feature.control"
+	svc.cfg.KillSwitchRollbackAll = rollbackAll
+	queueMan := &recordingQueueManager{}
+	svc.SetQueueManager(queueMan)
+	svc.SetExecutionLister(repo)
+	return svc, queueMan
+}
+
+// storeExecution stores an execution of repositoryURL with the given status.
+func storeExecution(
+	t *testing.T,
+	repo *executions.MemoryExecutionRepository,
+	repositoryURL string,
+	status executions.ExecutionStatus,
+) events.ExecutionID {
+	t.Helper()
+	executionID := events.NewExecutionID()
+	require.NoError(t, repo.Create(context.Background(), &executions.Execution{
+		ID:            executionID.String(),
+		RepositoryURL: repositoryURL,
+		Status:        status,
+	}))
+	return executionID
+}
+
+func TestKillSwitchControlEvents_GlobalActivationListsAffectedExecutions(t *testing.T) {
+	repo := executions.NewMemoryExecutionRepository()
+	running := storeExecution(t, repo, "https://github.com/acme/api", executions.ExecutionStatusRunning)
+	pending := storeExecution(t, repo, "https://github.com/acme/web", executions.ExecutionStatusPending)
+	storeExecution(t, repo, "https://github.com/acme/api", executions.ExecutionStatusCompleted)
+	svc, queueMan := newControlEventsKillSwitch(repo, true)
+
+	require.NoError(t, svc.ActivateGlobal(context.Background(),
+		events.KillSwitchReasonSecurityBreach, "oncall", "credential leak"))
+
+	require.Equal(t, []string{"feature.control"}, queueMan.queues)
+	assert.Equal(t, string(events.KillSwitchActivated), queueMan.headers[0][events.HeaderEventType])
+	activated, ok := queueMan.payloads[0].(*events.KillSwitchActivatedPayload)
+	require.True(t, ok)
+	assert.Equal(t, events.KillSwitchScopeGlobal, activated.Scope)
+	assert.Equal(t, events.KillSwitchReasonSecurityBreach, activated.Reason)
+	assert.Equal(t, "oncall", activated.ActivatedBy)
+	assert.Equal(t, "credential leak", activated.Details)
+	assert.True(t, activated.RollbackAll)
+	assert.Equal(t, []events.ExecutionID{running, pending}, activated.AffectedExecutions)
+}
+
+func TestKillSwitchControlEvents_RepositoryActivation(t *testing.T) {
+	repo := executions.NewMemoryExecutionRepository()
+	noisy := storeExecution(t, repo, "https://github.com/acme/noisy.git", executions.ExecutionStatusRunning)
+	storeExecution(t, repo, "https://github.com/acme/quiet", executions.ExecutionStatusRunning)
+	svc, queueMan := newControlEventsKillSwitch(repo, false)
+
+	require.NoError(t, svc.ActivateForRepository(context.Background(), "https://github.com/acme/noisy",
+		events.KillSwitchReasonAnomalyDetected, "oncall", "runaway"))
+
+	require.Len(t, queueMan.payloads, 1)
+	activated, ok := queueMan.payloads[0].(*events.KillSwitchActivatedPayload)
+	require.True(t, ok)
+	assert.Equal(t, events.KillSwitchScopeRepository, activated.Scope)
+	assert.Equal(t, "https://github.com/acme/noisy", activated.RepositoryID)
+	assert.Equal(t, "runaway", activated.Details)
+	assert.False(t, activated.RollbackAll)
+	assert.Equal(t, []events.ExecutionID{noisy}, activated.AffectedExecutions)
+}
+
+func TestKillSwitchControlEvents_Deactivation(t *testing.T) {
+	svc, queueMan := newControlEventsKillSwitch(executions.NewMemoryExecutionRepository(), false)
+	ctx := context.Background()
+
+	require.NoError(t, svc.ActivateForRepository(ctx, "repo-1", events.KillSwitchReasonManual, "oncall", ""))
+	require.NoError(t, svc.DeactivateForRepository(ctx, "repo-1", "oncall", "resolved"))
+	require.NoError(t, svc.ActivateGlobal(ctx, events.KillSwitchReasonManual, "oncall", ""))
+	require.NoError(t, svc.DeactivateGlobal(ctx, "oncall", "resolved"))
+
+	require.Len(t, queueMan.payloads, 4)
+	for i, eventType := range []events.EventType{
+		events.KillSwitchActivated, events.KillSwitchDeactivated, events.KillSwitchActivated, events.KillSwitchDeactivated,
+	} {
+		assert.Equal(t, string(eventType), queueMan.headers[i][events.HeaderEventType])
+	}
+	repoDeactivated, ok := queueMan.payloads[1].(*events.KillSwitchDeactivatedPayload)
+	require.True(t, ok)
+	assert.Equal(t, events.KillSwitchScopeRepository, repoDeactivated.Scope)
+	assert.Equal(t, "repo-1", repoDeactivated.RepositoryID)
+	assert.Equal(t, "resolved", repoDeactivated.Reason)
+	globalDeactivated, ok := queueMan.payloads[3].(*events.KillSwitchDeactivatedPayload)
+	require.True(t, ok)
+	assert.Equal(t, events.KillSwitchScopeGlobal, globalDeactivated.Scope)
+	assert.Equal(t, "oncall", globalDeactivated.DeactivatedBy)
+}
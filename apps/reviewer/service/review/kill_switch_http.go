@@ -0,0 +1,100 @@
+package review
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/pitabwire/frame/security"
+	"github.com/pitabwire/util"
+
+	"github.com/antinvestor/builder/internal/events"
+)
+
+// maxKillSwitchRequestBytes bounds the size of a kill switch request body.
+const maxKillSwitchRequestBytes = 64 * 1024
+
+// KillSwitchRequest activates or deactivates a global or repository kill switch.
+type KillSwitchRequest struct {
+	// Scope is global or repository.
+	Scope events.KillSwitchScope `json:"scope"`
+	// RepositoryID is the repository ID or remote URL of a repository switch.
+	RepositoryID string `json:"repository_id,omitempty"`
+	// Reason categorizes an activation; it defaults to manual.
+	Reason events.KillSwitchReason `json:"reason,omitempty"`
+	// Details explains the activation or deactivation.
+	Details string `json:"details,omitempty"`
+}
+
+// NewKillSwitchHTTPHandler returns the handler for /api/v1/killswitch: POST
+// activates a switch and DELETE deactivates it, both returning the resulting
+// status. The handler must be served behind authentication; the authenticated
+// subject is recorded as the actor.
+func NewKillSwitchHTTPHandler(service *PersistentKillSwitchService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost && r.Method != http.MethodDelete {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		var req KillSwitchRequest
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxKillSwitchRequestBytes)).Decode(&req); err != nil {
+			http.Error(w, "invalid request body", http.StatusBadRequest)
+			return
+		}
+		if req.Scope == events.KillSwitchScopeRepository && repositoryKey(req.RepositoryID) == "" {
+			http.Error(w, ErrMissingRepositoryID.Error(), http.StatusBadRequest)
+			return
+		}
+
+		ctx := r.Context()
+		actor := requestActor(r)
+		reason := req.Reason
+		if reason == "" {
+			reason = events.KillSwitchReasonManual
+		}
+
+		var err error
+		switch {
+		case req.Scope == events.KillSwitchScopeGlobal && r.Method == http.MethodPost:
+			err = service.ActivateGlobal(ctx, reason, actor, req.Details)
+		case req.Scope == events.KillSwitchScopeGlobal:
+			err = service.DeactivateGlobal(ctx, actor, req.Details)
+		case req.Scope == events.KillSwitchScopeRepository && r.Method == http.MethodPost:
+			err = service.ActivateForRepository(ctx, req.RepositoryID, reason, actor, req.Details)
+		case req.Scope == events.KillSwitchScopeRepository:
+			err = service.DeactivateForRepository(ctx, req.RepositoryID, actor, req.Details)
+		default:
+			http.Error(w, fmt.Sprintf("unsupported scope %q", req.Scope), http.StatusBadRequest)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		status, err := service.GetStatus(ctx)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		if encodeErr := json.NewEncoder(w).Encode(status); encodeErr != nil {
+			util.Log(ctx).WithError(encodeErr).Error("failed to encode kill switch status")
+		}
+	}
+}
+
+// requestActor returns the authenticated subject of a request, or "api" when
+// the request carries no claims.
+func requestActor(r *http.Request) string {
+	claims := security.ClaimsFromContext(r.Context())
+	if claims == nil {
+		return "api"
+	}
+	if subject, err := claims.GetSubject(); err == nil && subject != "" {
+		return subject
+	}
+	return "api"
+}
@@ -0,0 +1,69 @@
+//nolint:testpackage // white-box testing requires internal package access
+package review
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/antinvestor/builder/internal/events"
+)
+
+func TestKillSwitchHTTPHandler_RepositoryScope(t *testing.T) {
+	svc, _ := newTestKillSwitchService()
+	handler := NewKillSwitchHTTPHandler(svc)
+
+	serve := func(method, body string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, httptest.NewRequest(method, "/api/v1/killswitch", strings.NewReader(body)))
+		return rr
+	}
+
+	rr := serve(http.MethodPost, `{"scope":"repository","repository_id":"repo-1","details":"flaky"}`)
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), `"repo-1":true`)
+
+	active, reason, _ := svc.IsActive(context.Background(), events.NewExecutionID(), "repo-1")
+	assert.True(t, active)
+	assert.Equal(t, events.KillSwitchReasonManual, reason)
+	history := svc.GetActivationHistory(context.Background())
+	require.Len(t, history, 1)
+	assert.Equal(t, "api", history[0].Actor)
+
+	rr = serve(http.MethodDelete, `{"scope":"repository","repository_id":"repo-1"}`)
+	require.Equal(t, http.StatusOK, rr.Code)
+	active, _, _ = svc.IsActive(context.Background(), events.NewExecutionID(), "repo-1")
+	assert.False(t, active)
+}
+
+func TestKillSwitchHTTPHandler_RejectsInvalidRequests(t *testing.T) {
+	handler := NewKillSwitchHTTPHandler(func() *PersistentKillSwitchService {
+		svc, _ := newTestKillSwitchService()
+		return svc
+	}())
+
+	tests := []struct {
+		name   string
+		method string
+		body   string
+		code   int
+	}{
+		{name: "wrong method", method: http.MethodGet, body: "", code: http.StatusMethodNotAllowed},
+		{name: "malformed body", method: http.MethodPost, body: "{", code: http.StatusBadRequest},
+		{name: "missing repository", method: http.MethodPost, body: `{"scope":"repository"}`,
+			code: http.StatusBadRequest},
+		{name: "unknown scope", method: http.MethodPost, body: `{"scope":"feature"}`, code: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, httptest.NewRequest(tt.method, "/api/v1/killswitch", strings.NewReader(tt.body)))
+			assert.Equal(t, tt.code, rr.Code)
+		})
+	}
+}
@@ -0,0 +1,83 @@
+package review
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// KillSwitchSnapshot is the persisted state of the kill switches.
+type KillSwitchSnapshot struct {
+	Global       KillSwitchState            `json:"global"`
+	Repositories map[string]KillSwitchState `json:"repositories,omitempty"`
+	Executions   map[string]KillSwitchState `json:"executions,omitempty"`
+}
+
+// KillSwitchStore persists kill switch state so it survives restarts.
+type KillSwitchStore interface {
+	// Load returns the stored state, or an empty snapshot when none is stored.
+	Load(ctx context.Context) (*KillSwitchSnapshot, error)
+	// Save replaces the stored state.
+	Save(ctx context.Context, snapshot *KillSwitchSnapshot) error
+}
+
+// FileKillSwitchStore stores kill switch state as a JSON file.
+type FileKillSwitchStore struct {
+	path string
+}
+
+// NewFileKillSwitchStore creates a kill switch store writing to path.
+func NewFileKillSwitchStore(path string) *FileKillSwitchStore {
+	return &FileKillSwitchStore{path: path}
+}
+
+// Load implements KillSwitchStore.
+func (s *FileKillSwitchStore) Load(_ context.Context) (*KillSwitchSnapshot, error) {
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return &KillSwitchSnapshot{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read kill switch state: %w", err)
+	}
+
+	var snapshot KillSwitchSnapshot
+	if err = json.Unmarshal(data, &snapshot); err != nil {
+		return nil, fmt.Errorf("decode kill switch state: %w", err)
+	}
+	return &snapshot, nil
+}
+
+// Save implements KillSwitchStore. The state is written to a temporary file and
+// renamed into place, so a crash mid-write leaves the previous state intact.
+func (s *FileKillSwitchStore) Save(_ context.Context, snapshot *KillSwitchSnapshot) error {
+	data, err := json.MarshalIndent(snapshot, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode kill switch state: %w", err)
+	}
+
+	dir := filepath.Dir(s.path)
+	if err = os.MkdirAll(dir, 0o750); err != nil {
+		return fmt.Errorf("create kill switch state directory: %w", err)
+	}
+	tmp, err := os.CreateTemp(dir, filepath.Base(s.path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("create kill switch state file: %w", err)
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+
+	if _, err = tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("write kill switch state: %w", err)
+	}
+	if err = tmp.Close(); err != nil {
+		return fmt.Errorf("write kill switch state: %w", err)
+	}
+	if err = os.Rename(tmp.Name(), s.path); err != nil {
+		return fmt.Errorf("replace kill switch state: %w", err)
+	}
+	return nil
+}
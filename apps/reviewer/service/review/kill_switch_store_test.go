@@ -0,0 +1,51 @@
+//nolint:testpackage // white-box testing requires internal package access
+package review
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/antinvestor/builder/internal/events"
+)
+
+func TestPersistentKillSwitchService_StateSurvivesRestart(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "state", "kill-switch.json")
+
+	svc, _ := newTestKillSwitchService()
+	require.NoError(t, svc.SetStore(ctx, NewFileKillSwitchStore(path)))
+	require.NoError(t, svc.ActivateForRepository(ctx, "repo-noisy",
+		events.KillSwitchReasonAnomalyDetected, "oncall", "runaway"))
+	require.NoError(t, svc.ActivateForRepository(ctx, "repo-fixed",
+		events.KillSwitchReasonManual, "oncall", ""))
+	require.NoError(t, svc.DeactivateForRepository(ctx, "repo-fixed", "oncall", "resolved"))
+
+	restarted, _ := newTestKillSwitchService()
+	require.NoError(t, restarted.SetStore(ctx, NewFileKillSwitchStore(path)))
+
+	active, reason, scope := restarted.IsActive(ctx, events.NewExecutionID(), "repo-noisy")
+	assert.True(t, active)
+	assert.Equal(t, events.KillSwitchReasonAnomalyDetected, reason)
+	assert.Equal(t, events.KillSwitchScopeRepository, scope)
+
+	active, _, _ = restarted.IsActive(ctx, events.NewExecutionID(), "repo-fixed")
+	assert.False(t, active)
+
+	status, err := restarted.GetStatus(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]bool{"repo-noisy": true}, status.RepositorySwitches)
+}
+
+func TestFileKillSwitchStore_LoadMissingFile(t *testing.T) {
+	store := NewFileKillSwitchStore(filepath.Join(t.TempDir(), "missing.json"))
+
+	snapshot, err := store.Load(context.Background())
+
+	require.NoError(t, err)
+	assert.False(t, snapshot.Global.Active)
+	assert.Empty(t, snapshot.Repositories)
+}
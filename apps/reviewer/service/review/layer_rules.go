@@ -0,0 +1,182 @@
+package review
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"slices"
+	"strings"
+
+	appconfig "github.com/antinvestor/builder/apps/reviewer/config"
+	"github.com/antinvestor/builder/internal/events"
+)
+
+// ErrInvalidLayerRules indicates layer rules that reference undefined layers.
+var ErrInvalidLayerRules = errors.New("invalid layer rules")
+
+// LoadLayerRules reads and validates a JSON layer rules file.
+func LoadLayerRules(filePath string) (*appconfig.LayerRules, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("read layer rules file: %w", err)
+	}
+
+	var rules appconfig.LayerRules
+	if err = json.Unmarshal(data, &rules); err != nil {
+		return nil, fmt.Errorf("parse layer rules file: %w", err)
+	}
+	if err = validateLayerRules(&rules); err != nil {
+		return nil, err
+	}
+	return &rules, nil
+}
+
+// validateLayerRules rejects edges and orderings naming a layer that has no paths.
+func validateLayerRules(rules *appconfig.LayerRules) error {
+	defined := make(map[string]bool, len(rules.Layers))
+	for _, layer := range rules.Layers {
+		if layer.Name == "" || len(layer.Paths) == 0 {
+			return fmt.Errorf("%w: every layer needs a name and paths", ErrInvalidLayerRules)
+		}
+		defined[layer.Name] = true
+	}
+
+	referenced := slices.Clone(rules.Order)
+	for _, edge := range rules.Forbidden {
+		referenced = append(referenced, edge.From, edge.To)
+	}
+	for _, name := range referenced {
+		if !defined[name] {
+			return fmt.Errorf("%w: layer %q is not defined", ErrInvalidLayerRules, name)
+		}
+	}
+	return nil
+}
+
+// SetLayerRules replaces the built-in folder conventions with a repository's
+// own layers. Nil restores the built-in rules.
+func (a *PatternArchitectureAnalyzer) SetLayerRules(rules *appconfig.LayerRules) {
+	a.layerRules = rules
+}
+
+// configuredDependencyViolations reports imports along a forbidden layer edge.
+func (a *PatternArchitectureAnalyzer) configuredDependencyViolations(
+	files map[string]string,
+	fallbackLanguage string,
+) []events.DependencyViolation {
+	var violations []events.DependencyViolation
+	for filePath, content := range files {
+		source := fileLayer(a.layerRules, filePath)
+		if source == "" {
+			continue
+		}
+
+		language := languageForFile(filePath, fallbackLanguage)
+		for _, imp := range extractImports(content, language) {
+			target := importLayer(a.layerRules, filePath, imp, language)
+			if target == "" || target == source ||
+				!slices.Contains(a.layerRules.Forbidden, appconfig.LayerEdge{From: source, To: target}) {
+				continue
+			}
+			violations = append(violations, events.DependencyViolation{
+				ViolationType: events.DependencyViolationForbidden,
+				FromModule:    source,
+				ToModule:      target,
+				FilePath:      filePath,
+				LineNumber:    findImportLineNumber(content, imp),
+				Rule:          source + " layer should not depend on " + target,
+				Severity:      events.ReviewIssueSeverityMedium,
+			})
+		}
+	}
+	return violations
+}
+
+// configuredLayeringViolations reports imports of a layer ordered outside the
+// importing one.
+func (a *PatternArchitectureAnalyzer) configuredLayeringViolations(
+	files map[string]string,
+	fallbackLanguage string,
+) []events.LayeringViolation {
+	var violations []events.LayeringViolation
+	for filePath, content := range files {
+		source := fileLayer(a.layerRules, filePath)
+		sourceOrder := slices.Index(a.layerRules.Order, source)
+		if sourceOrder < 0 {
+			continue
+		}
+
+		language := languageForFile(filePath, fallbackLanguage)
+		for _, imp := range extractImports(content, language) {
+			target := importLayer(a.layerRules, filePath, imp, language)
+			if targetOrder := slices.Index(a.layerRules.Order, target); targetOrder < 0 || targetOrder >= sourceOrder {
+				continue
+			}
+			violations = append(violations, events.LayeringViolation{
+				ViolationType: events.LayeringViolationReverseFlow,
+				Description:   "Inner layer depends on outer layer",
+				SourceLayer:   source,
+				TargetLayer:   target,
+				FilePath:      filePath,
+				Severity:      events.ReviewIssueSeverityMedium,
+			})
+		}
+	}
+	return violations
+}
+
+// fileLayer returns the first layer with a path matching the file, or "".
+func fileLayer(rules *appconfig.LayerRules, filePath string) string {
+	for _, layer := range rules.Layers {
+		for _, pattern := range layer.Paths {
+			if matchLayerPath(pattern, filePath) {
+				return layer.Name
+			}
+		}
+	}
+	return ""
+}
+
+// importLayer returns the layer an import refers to, or "". A relative script or
+// Python import is resolved against the importing file; otherwise each trailing
+// part of the import path is tried, so github.com/acme/shop/pkg/store is in the
+// layer of pkg/store.
+func importLayer(rules *appconfig.LayerRules, filePath, imp, language string) string {
+	target := imp
+	switch language {
+	case langJavaScript, langTypeScript:
+		if strings.HasPrefix(imp, ".") {
+			target = path.Join(path.Dir(filePath), imp)
+		}
+	case langPython:
+		module := strings.TrimLeft(imp, ".")
+		target = strings.ReplaceAll(module, ".", "/")
+		if dots := len(imp) - len(module); dots > 0 {
+			// Relative imports climb one directory per dot after the first
+			dir := path.Dir(filePath)
+			for range dots - 1 {
+				dir = path.Dir(dir)
+			}
+			target = path.Join(dir, target)
+		}
+	}
+
+	segments := strings.Split(strings.TrimPrefix(path.Clean(target), "/"), "/")
+	for i := range segments {
+		if layer := fileLayer(rules, strings.Join(segments[i:], "/")); layer != "" {
+			return layer
+		}
+	}
+	return ""
+}
+
+// matchLayerPath reports whether a path is under a layer path, which is a glob
+// when it contains a wildcard and a directory prefix otherwise.
+func matchLayerPath(pattern, filePath string) bool {
+	if !strings.ContainsAny(pattern, "*?[") {
+		pattern = strings.TrimSuffix(pattern, "/") + "/**"
+	}
+	return matchPathGlob(pattern, filePath)
+}
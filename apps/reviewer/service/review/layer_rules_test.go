@@ -0,0 +1,98 @@
+package review //nolint:testpackage // white-box testing requires internal access
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	appconfig "github.com/antinvestor/builder/apps/reviewer/config"
+)
+
+const layerRulesJSON = `{
+	"layers": [
+		{"name": "api", "paths": ["pkg/api"]},
+		{"name": "service", "paths": ["internal/app/**"]},
+		{"name": "store", "paths": ["pkg/store", "pkg/adapters/*/store/**"]}
+	],
+	"forbidden": [{"from": "api", "to": "store"}],
+	"order": ["api", "service", "store"]
+}`
+
+func loadTestLayerRules(t *testing.T) *appconfig.LayerRules {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "layers.json")
+	require.NoError(t, os.WriteFile(path, []byte(layerRulesJSON), 0o600))
+	rules, err := LoadLayerRules(path)
+	require.NoError(t, err)
+	return rules
+}
+
+func TestPatternArchitectureAnalyzer_CustomLayerRules(t *testing.T) {
+	analyzer := NewPatternArchitectureAnalyzer(nil)
+	analyzer.SetLayerRules(loadTestLayerRules(t))
+
+	files := map[string]string{
+		"pkg/api/orders.go": goFile("api",
+			"github.com/acme/shop/internal/app/orders", "github.com/acme/shop/pkg/store"),
+		"pkg/adapters/pg/store/orders.go": goFile("store", "github.com/acme/shop/pkg/api"),
+		"internal/app/orders/service.go":  goFile("orders", "github.com/acme/shop/pkg/store"),
+		// Built-in conventions no longer apply
+		"internal/handlers/orders.go": goFile("handlers", "github.com/acme/shop/internal/repository"),
+	}
+
+	assessment, err := analyzer.Analyze(context.Background(), &ArchitectureAnalysisRequest{FileContents: files})
+	require.NoError(t, err)
+
+	require.Len(t, assessment.DependencyViolations, 1)
+	violation := assessment.DependencyViolations[0]
+	assert.Equal(t, "pkg/api/orders.go", violation.FilePath)
+	assert.Equal(t, "api", violation.FromModule)
+	assert.Equal(t, "store", violation.ToModule)
+	assert.Equal(t, 5, violation.LineNumber)
+
+	require.Len(t, assessment.LayeringViolations, 1)
+	assert.Equal(t, "pkg/adapters/pg/store/orders.go", assessment.LayeringViolations[0].FilePath)
+	assert.Equal(t, "store", assessment.LayeringViolations[0].SourceLayer)
+	assert.Equal(t, "api", assessment.LayeringViolations[0].TargetLayer)
+}
+
+func TestPatternArchitectureAnalyzer_BuiltInLayerRulesWithoutConfig(t *testing.T) {
+	analyzer := NewPatternArchitectureAnalyzer(nil)
+
+	violations := analyzer.detectDependencyViolations(map[string]string{
+		"internal/handlers/orders.go": goFile("handlers", "github.com/acme/shop/internal/repository"),
+		"pkg/api/orders.go":           goFile("api", "github.com/acme/shop/pkg/store"),
+	}, "")
+
+	require.Len(t, violations, 1)
+	assert.Equal(t, "internal/handlers/orders.go", violations[0].FilePath)
+}
+
+func TestImportLayer_RelativeImports(t *testing.T) {
+	rules := loadTestLayerRules(t)
+
+	assert.Equal(t, "store", importLayer(rules, "pkg/api/orders.ts", "../store/orders", langTypeScript))
+	assert.Equal(t, "store", importLayer(rules, "pkg/api/orders.py", "..store.orders", langPython))
+	assert.Equal(t, "service", importLayer(rules, "pkg/api/orders.py", "internal.app.orders", langPython))
+	assert.Empty(t, importLayer(rules, "pkg/api/orders.go", "fmt", langGo))
+}
+
+func TestLoadLayerRules_Invalid(t *testing.T) {
+	for name, content := range map[string]string{
+		"undefined forbidden layer": `{"layers": [{"name": "api", "paths": ["pkg/api"]}],
+			"forbidden": [{"from": "api", "to": "store"}]}`,
+		"undefined ordered layer": `{"layers": [{"name": "api", "paths": ["pkg/api"]}], "order": ["api", "db"]}`,
+		"layer without paths":     `{"layers": [{"name": "api"}]}`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "layers.json")
+			require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+			_, err := LoadLayerRules(path)
+			require.ErrorIs(t, err, ErrInvalidLayerRules)
+		})
+	}
+}
@@ -0,0 +1,238 @@
+package review
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/antinvestor/builder/internal/events"
+)
+
+// analyzerNameMigration names the migration analyzer in issue IDs and metrics.
+const analyzerNameMigration = "migration"
+
+var (
+	// downMigrationName matches rollback migrations, which are expected to drop what
+	// their up migration created.
+	downMigrationName = regexp.MustCompile(`(?i)(^|[._-])down([._-]|$)`)
+	// sqlLineComment matches a SQL comment running to the end of the line.
+	sqlLineComment = regexp.MustCompile(`--.*$`)
+	// createdTable captures the table a CREATE TABLE statement creates.
+	createdTable = regexp.MustCompile(`\bCREATE TABLE (?:IF NOT EXISTS )?([\w."]+)`)
+	// indexedTable captures the table a CREATE INDEX statement indexes.
+	indexedTable = regexp.MustCompile(`\bON (?:ONLY )?([\w."]+)`)
+)
+
+// migrationRule is a destructive operation a migration statement can perform.
+type migrationRule struct {
+	id       string
+	pattern  *regexp.Regexp
+	except   *regexp.Regexp
+	severity events.ReviewIssueSeverity
+	title    string
+	risk     string
+	remedy   string
+	// lockOnly rules are harmless on a table the same migration creates, as it has no rows yet.
+	lockOnly bool
+}
+
+// migrationRules are matched against whole statements, upper-cased with their
+// whitespace collapsed.
+var migrationRules = []migrationRule{
+	{
+		id:       "drop_table",
+		pattern:  regexp.MustCompile(`\bDROP TABLE\b`),
+		severity: events.ReviewIssueSeverityHigh,
+		title:    "Migration drops a table",
+		risk:     "Dropping a table deletes its data irrecoverably and breaks code still reading it.",
+		remedy:   "Stop using the table first and drop it in a later release, after a backup.",
+	},
+	{
+		id:       "drop_column",
+		pattern:  regexp.MustCompile(`\bDROP COLUMN\b`),
+		severity: events.ReviewIssueSeverityHigh,
+		title:    "Migration drops a column",
+		risk: "Dropping a column deletes its data irrecoverably and breaks running instances " +
+			"that still read or write it.",
+		remedy: "Stop using the column first and drop it in a later release, after a backup.",
+	},
+	{
+		id:       "not_null_without_default",
+		pattern:  regexp.MustCompile(`\bADD (COLUMN )?[^,]*\bNOT NULL\b`),
+		except:   regexp.MustCompile(`\bDEFAULT\b|\bADD CONSTRAINT\b`),
+		severity: events.ReviewIssueSeverityHigh,
+		title:    "NOT NULL column added without a default",
+		risk:     "Adding a NOT NULL column without a default fails on tables that already have rows.",
+		remedy:   "Add a default, or add the column as nullable, backfill it and then set NOT NULL.",
+	},
+	{
+		id:       "index_not_concurrent",
+		pattern:  regexp.MustCompile(`\bCREATE (UNIQUE )?INDEX\b`),
+		except:   regexp.MustCompile(`\bCONCURRENTLY\b`),
+		severity: events.ReviewIssueSeverityMedium,
+		title:    "Index created without CONCURRENTLY",
+		risk:     "Building an index without CONCURRENTLY locks the table against writes until it completes.",
+		remedy:   "Use CREATE INDEX CONCURRENTLY, outside a transaction.",
+		lockOnly: true,
+	},
+}
+
+// MigrationAnalyzer flags destructive operations added to database migration
+// files: dropped tables and columns, NOT NULL columns without a default and
+// index builds that lock the table. Only the statements a change adds are
+// checked, and down migrations are skipped.
+type MigrationAnalyzer struct {
+	patterns []string
+}
+
+// NewMigrationAnalyzer creates a migration analyzer for files matching the given path globs.
+func NewMigrationAnalyzer(patterns []string) *MigrationAnalyzer {
+	return &MigrationAnalyzer{patterns: patterns}
+}
+
+// Name implements Analyzer.
+func (a *MigrationAnalyzer) Name() string {
+	return analyzerNameMigration
+}
+
+// Analyze implements Analyzer.
+func (a *MigrationAnalyzer) Analyze(_ context.Context, req *AnalysisRequest) ([]events.ReviewIssue, error) {
+	var issues []events.ReviewIssue
+	for _, patch := range req.Patches {
+		if !a.isMigration(patch) {
+			continue
+		}
+		statements := addedStatements(patch)
+		created := createdTables(statements)
+		for _, stmt := range statements {
+			for _, rule := range migrationRules {
+				if rule.matches(stmt, created) {
+					issues = append(issues, migrationIssue(patch.FilePath, stmt, rule))
+				}
+			}
+		}
+	}
+	return issues, nil
+}
+
+// matches reports whether a statement performs the rule's operation.
+func (r migrationRule) matches(stmt sqlStatement, created map[string]bool) bool {
+	if !r.pattern.MatchString(stmt.text) || (r.except != nil && r.except.MatchString(stmt.text)) {
+		return false
+	}
+	if r.lockOnly {
+		if match := indexedTable.FindStringSubmatch(stmt.text); match != nil && created[match[1]] {
+			return false
+		}
+	}
+	return true
+}
+
+// createdTables returns the tables created by the statements.
+func createdTables(statements []sqlStatement) map[string]bool {
+	created := make(map[string]bool)
+	for _, stmt := range statements {
+		if match := createdTable.FindStringSubmatch(stmt.text); match != nil {
+			created[match[1]] = true
+		}
+	}
+	return created
+}
+
+// isMigration reports whether a patch adds to an up migration.
+func (a *MigrationAnalyzer) isMigration(patch events.Patch) bool {
+	if patch.Action == events.FileActionDelete || downMigrationName.MatchString(path.Base(patch.FilePath)) {
+		return false
+	}
+	return slices.ContainsFunc(a.patterns, func(pattern string) bool {
+		return matchPathGlob(pattern, patch.FilePath)
+	})
+}
+
+// sqlStatement is a statement added by a change, normalized for matching.
+type sqlStatement struct {
+	text string
+	line int
+}
+
+// addedStatements splits the lines a patch adds into statements. Without a diff,
+// a created file's content is all new.
+func addedStatements(patch events.Patch) []sqlStatement {
+	var statements []sqlStatement
+	var current strings.Builder
+	start := 0
+	add := func(lineNumber int, line string) {
+		line = strings.TrimSpace(sqlLineComment.ReplaceAllString(line, ""))
+		for line != "" {
+			if current.Len() == 0 {
+				start = lineNumber
+			}
+			before, after, terminated := strings.Cut(line, ";")
+			current.WriteString(before)
+			current.WriteByte(' ')
+			if !terminated {
+				return
+			}
+			if text := normalizeStatement(current.String()); text != "" {
+				statements = append(statements, sqlStatement{text: text, line: start})
+			}
+			current.Reset()
+			line = strings.TrimSpace(after)
+		}
+	}
+
+	if patch.DiffContent == "" {
+		if patch.Action == events.FileActionCreate {
+			for i, line := range strings.Split(patch.NewContent, "\n") {
+				add(i+1, line)
+			}
+		}
+	} else {
+		newLine := 0
+		for line := range strings.SplitSeq(patch.DiffContent, "\n") {
+			switch {
+			case strings.HasPrefix(line, "+++"), strings.HasPrefix(line, "---"):
+			case strings.HasPrefix(line, "@@"):
+				if match := hunkNewStart.FindStringSubmatch(line); match != nil {
+					newLine, _ = strconv.Atoi(match[1])
+				}
+			case strings.HasPrefix(line, "+"):
+				add(newLine, line[1:])
+				newLine++
+			case strings.HasPrefix(line, "-"):
+			default:
+				newLine++
+			}
+		}
+	}
+
+	// A final statement may omit its terminator
+	if text := normalizeStatement(current.String()); text != "" {
+		statements = append(statements, sqlStatement{text: text, line: start})
+	}
+	return statements
+}
+
+// normalizeStatement upper-cases a statement and collapses its whitespace.
+func normalizeStatement(stmt string) string {
+	return strings.Join(strings.Fields(strings.ToUpper(stmt)), " ")
+}
+
+func migrationIssue(filePath string, stmt sqlStatement, rule migrationRule) events.ReviewIssue {
+	return events.ReviewIssue{
+		ID:          fmt.Sprintf("%s:%s:%d:%s", analyzerNameMigration, filePath, stmt.line, rule.id),
+		Type:        events.ReviewIssueTypeMigration,
+		Severity:    rule.severity,
+		FilePath:    filePath,
+		LineStart:   stmt.line,
+		LineEnd:     stmt.line,
+		Title:       rule.title,
+		Description: rule.risk,
+		Suggestion:  rule.remedy,
+		CodeSnippet: stmt.text,
+	}
+}
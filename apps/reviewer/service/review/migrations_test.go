@@ -0,0 +1,143 @@
+//nolint:testpackage // white-box testing requires internal package access
+package review
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	appconfig "github.com/antinvestor/builder/apps/reviewer/config"
+	"github.com/antinvestor/builder/internal/events"
+)
+
+const testMigrationPaths = "**/migrations/**"
+
+func analyzeMigrations(t *testing.T, patches ...events.Patch) []events.ReviewIssue {
+	t.Helper()
+	cfg := &appconfig.ReviewerConfig{MigrationPaths: testMigrationPaths}
+	issues, err := NewMigrationAnalyzer(cfg.MigrationPathPatterns()).Analyze(
+		context.Background(), &AnalysisRequest{Patches: patches})
+	require.NoError(t, err)
+	return issues
+}
+
+func TestMigrationAnalyzer_FlagsDropColumn(t *testing.T) {
+	issues := analyzeMigrations(t, events.Patch{
+		FilePath: "db/migrations/0007_drop_legacy_up.sql",
+		Action:   events.FileActionModify,
+		DiffContent: "--- a/db/migrations/0007_drop_legacy_up.sql\n+++ b/db/migrations/0007_drop_legacy_up.sql\n" +
+			"@@ -1,2 +1,4 @@\n" +
+			" -- Migration: Drop legacy columns\n" +
+			" \n" +
+			"+ALTER TABLE users\n" +
+			"+    DROP COLUMN legacy_token;\n",
+	})
+
+	require.Len(t, issues, 1)
+	assert.Equal(t, events.ReviewIssueTypeMigration, issues[0].Type)
+	assert.Equal(t, events.ReviewIssueSeverityHigh, issues[0].Severity)
+	assert.Equal(t, "migration:db/migrations/0007_drop_legacy_up.sql:3:drop_column", issues[0].ID)
+	assert.Equal(t, "Migration drops a column", issues[0].Title)
+	assert.Equal(t, 3, issues[0].LineStart)
+	assert.Equal(t, "ALTER TABLE USERS DROP COLUMN LEGACY_TOKEN", issues[0].CodeSnippet)
+}
+
+func TestMigrationAnalyzer_FlagsNonConcurrentIndex(t *testing.T) {
+	issues := analyzeMigrations(t, events.Patch{
+		FilePath: "migrations/0008_orders_index.sql",
+		Action:   events.FileActionCreate,
+		NewContent: "CREATE INDEX idx_orders_customer ON orders (customer_id);\n" +
+			"CREATE INDEX CONCURRENTLY idx_orders_status ON orders (status);\n",
+	})
+
+	require.Len(t, issues, 1)
+	assert.Equal(t, events.ReviewIssueSeverityMedium, issues[0].Severity)
+	assert.Equal(t, "Index created without CONCURRENTLY", issues[0].Title)
+	assert.Equal(t, 1, issues[0].LineStart)
+}
+
+func TestMigrationAnalyzer_Rules(t *testing.T) {
+	tests := []struct {
+		name      string
+		content   string
+		wantTitle string
+	}{
+		{name: "drop table", content: "DROP TABLE IF EXISTS sessions;", wantTitle: "Migration drops a table"},
+		{
+			name:      "not null without default",
+			content:   "ALTER TABLE users ADD COLUMN tenant_id UUID NOT NULL;",
+			wantTitle: "NOT NULL column added without a default",
+		},
+		{name: "not null with default", content: "ALTER TABLE users ADD COLUMN active BOOLEAN NOT NULL DEFAULT true;"},
+		{name: "nullable column", content: "ALTER TABLE users ADD COLUMN IF NOT EXISTS nickname TEXT;"},
+		{name: "index on new table", content: "CREATE TABLE tags (id TEXT);\nCREATE INDEX idx_tags_id ON tags (id);"},
+		{name: "commented out", content: "-- DROP TABLE sessions;"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			issues := analyzeMigrations(t, events.Patch{
+				FilePath:   "migrations/0009_change.sql",
+				Action:     events.FileActionCreate,
+				NewContent: tt.content,
+			})
+			if tt.wantTitle == "" {
+				assert.Empty(t, issues)
+				return
+			}
+			require.Len(t, issues, 1)
+			assert.Equal(t, tt.wantTitle, issues[0].Title)
+		})
+	}
+}
+
+func TestMigrationAnalyzer_SkipsDownAndOtherFiles(t *testing.T) {
+	assert.Empty(t, analyzeMigrations(t,
+		events.Patch{
+			FilePath:   "migrations/0001/005_execution_llm_models_down.sql",
+			Action:     events.FileActionCreate,
+			NewContent: "ALTER TABLE executions DROP COLUMN IF EXISTS llm_models;\n",
+		},
+		events.Patch{
+			FilePath:   "scripts/cleanup.sql",
+			Action:     events.FileActionCreate,
+			NewContent: "DROP TABLE scratch;\n",
+		},
+	))
+}
+
+func migrationDecisionRequest() *DecisionRequest {
+	req := newCleanDecisionRequest()
+	req.CustomIssues = []events.ReviewIssue{{
+		ID:       "migration:migrations/0007_up.sql:1:drop_column",
+		Type:     events.ReviewIssueTypeMigration,
+		Severity: events.ReviewIssueSeverityHigh,
+		FilePath: "migrations/0007_up.sql",
+		Title:    "Migration drops a column",
+	}}
+	return req
+}
+
+func TestThresholdDecisionEngine_DestructiveMigration_ManualReview(t *testing.T) {
+	engine := newTestDecisionEngine()
+
+	result, err := engine.MakeDecision(context.Background(), migrationDecisionRequest())
+	require.NoError(t, err)
+
+	assert.Equal(t, events.ControlDecisionManualReview, result.Decision)
+	assert.Contains(t, result.Rationale, "destructive migrations in: migrations/0007_up.sql")
+	require.Len(t, result.BlockingIssues, 1)
+	assert.Equal(t, events.ReviewIssueTypeMigration, result.BlockingIssues[0].Type)
+}
+
+func TestThresholdDecisionEngine_DestructiveMigration_Iterate(t *testing.T) {
+	engine := newTestDecisionEngine()
+	engine.cfg.DestructiveMigrationAction = string(appconfig.MigrationActionIterate)
+
+	result, err := engine.MakeDecision(context.Background(), migrationDecisionRequest())
+	require.NoError(t, err)
+
+	assert.Equal(t, events.ControlDecisionIterate, result.Decision)
+}
@@ -0,0 +1,150 @@
+package review
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/antinvestor/builder/internal/events"
+)
+
+// ErrInvalidPattern indicates a custom pattern that cannot be used.
+var ErrInvalidPattern = errors.New("invalid custom pattern")
+
+// patternsFile is the YAML (or JSON) layout of a custom patterns file.
+type patternsFile struct {
+	SecurityPatterns []struct {
+		Name        string   `yaml:"name"`
+		Pattern     string   `yaml:"pattern"`
+		PatternType string   `yaml:"pattern_type"`
+		Severity    string   `yaml:"severity"`
+		CWE         string   `yaml:"cwe"`
+		OWASPID     string   `yaml:"owasp_id"`
+		Description string   `yaml:"description"`
+		Remediation string   `yaml:"remediation"`
+		Languages   []string `yaml:"languages"`
+	} `yaml:"security_patterns"`
+	SecretPatterns []struct {
+		Name        string `yaml:"name"`
+		Pattern     string `yaml:"pattern"`
+		SecretType  string `yaml:"secret_type"`
+		Description string `yaml:"description"`
+	} `yaml:"secret_patterns"`
+}
+
+// LoadPatternsFromFile reads security and secret patterns from a YAML or JSON
+// file. Every regex is compiled up front, so an invalid pattern is reported by
+// name instead of being dropped.
+func LoadPatternsFromFile(path string) ([]securityPattern, []secretPattern, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("read patterns file: %w", err)
+	}
+	return parsePatterns(data)
+}
+
+// parsePatterns decodes a patterns file and compiles its regexes.
+func parsePatterns(data []byte) ([]securityPattern, []secretPattern, error) {
+	var file patternsFile
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, nil, fmt.Errorf("parse patterns file: %w", err)
+	}
+
+	security := make([]securityPattern, 0, len(file.SecurityPatterns))
+	for _, p := range file.SecurityPatterns {
+		compiled, err := compilePattern(p.Name, p.Pattern)
+		if err != nil {
+			return nil, nil, err
+		}
+		if p.PatternType == "" {
+			return nil, nil, fmt.Errorf("%w: %q has no pattern_type", ErrInvalidPattern, p.Name)
+		}
+		severity, err := patternSeverity(p.Name, p.Severity)
+		if err != nil {
+			return nil, nil, err
+		}
+		security = append(security, securityPattern{
+			Name:        p.Name,
+			Pattern:     compiled,
+			PatternType: events.InsecurePatternType(p.PatternType),
+			Severity:    severity,
+			CWE:         p.CWE,
+			OWASPID:     p.OWASPID,
+			Description: p.Description,
+			Remediation: p.Remediation,
+			Languages:   p.Languages,
+		})
+	}
+
+	secrets := make([]secretPattern, 0, len(file.SecretPatterns))
+	for _, p := range file.SecretPatterns {
+		compiled, err := compilePattern(p.Name, p.Pattern)
+		if err != nil {
+			return nil, nil, err
+		}
+		if p.SecretType == "" {
+			return nil, nil, fmt.Errorf("%w: %q has no secret_type", ErrInvalidPattern, p.Name)
+		}
+		secrets = append(secrets, secretPattern{
+			Name:        p.Name,
+			Pattern:     compiled,
+			SecretType:  p.SecretType,
+			Description: p.Description,
+		})
+	}
+
+	return security, secrets, nil
+}
+
+// patternSeverity returns the severity of a custom pattern, matched
+// case-insensitively and medium when unset.
+func patternSeverity(name, severity string) (events.VulnerabilitySeverity, error) {
+	switch normalized := events.VulnerabilitySeverity(strings.ToLower(strings.TrimSpace(severity))); normalized {
+	case "":
+		return events.VulnerabilitySeverityMedium, nil
+	case events.VulnerabilitySeverityLow, events.VulnerabilitySeverityMedium,
+		events.VulnerabilitySeverityHigh, events.VulnerabilitySeverityCritical:
+		return normalized, nil
+	default:
+		return "", fmt.Errorf("%w: %q has unknown severity %q", ErrInvalidPattern, name, severity)
+	}
+}
+
+// compilePattern compiles a named pattern's regex.
+func compilePattern(name, pattern string) (*regexp.Regexp, error) {
+	if name == "" {
+		return nil, fmt.Errorf("%w: pattern %q has no name", ErrInvalidPattern, pattern)
+	}
+	if pattern == "" {
+		return nil, fmt.Errorf("%w: %q has no pattern", ErrInvalidPattern, name)
+	}
+	compiled, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %q: %w", ErrInvalidPattern, name, err)
+	}
+	return compiled, nil
+}
+
+// mergePatterns adds custom patterns to the built-in ones; a custom pattern
+// replaces the built-in pattern of the same name. The names of the replaced
+// built-in patterns are returned so the override can be reported.
+func mergePatterns[T any](builtIn, custom []T, name func(T) string) ([]T, []string) {
+	overriding := make(map[string]bool, len(custom))
+	for _, p := range custom {
+		overriding[name(p)] = true
+	}
+	merged := make([]T, 0, len(builtIn)+len(custom))
+	var replaced []string
+	for _, p := range builtIn {
+		if overriding[name(p)] {
+			replaced = append(replaced, name(p))
+			continue
+		}
+		merged = append(merged, p)
+	}
+	return append(merged, custom...), replaced
+}
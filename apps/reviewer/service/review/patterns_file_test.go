@@ -0,0 +1,140 @@
+package review //nolint:testpackage // white-box testing requires internal access
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	appconfig "github.com/antinvestor/builder/apps/reviewer/config"
+	"github.com/antinvestor/builder/internal/events"
+)
+
+const customPatternsYAML = `
+security_patterns:
+  - name: Legacy auth bypass
+    pattern: 'skipAuth\s*\(\s*\)'
+    pattern_type: missing_auth
+    severity: High
+    cwe: CWE-306
+    description: Call to the legacy auth bypass
+    languages: [go]
+secret_patterns:
+  - name: Acme service token
+    pattern: 'acme_svc_[A-Za-z0-9]{24}'
+    secret_type: acme_service_token
+    description: Acme internal service token detected
+  - name: GitHub Token
+    pattern: 'ghx_[A-Za-z0-9]{8}'
+    secret_type: github_token
+    description: Overridden GitHub token pattern
+`
+
+func writePatternsFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "patterns.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func TestLoadPatternsFromFile(t *testing.T) {
+	security, secrets, err := LoadPatternsFromFile(writePatternsFile(t, customPatternsYAML))
+	require.NoError(t, err)
+
+	require.Len(t, security, 1)
+	assert.Equal(t, events.InsecurePatternMissingAuth, security[0].PatternType)
+	assert.Equal(t, events.VulnerabilitySeverityHigh, security[0].Severity)
+	assert.Equal(t, []string{"go"}, security[0].Languages)
+	require.Len(t, secrets, 2)
+	assert.Equal(t, "acme_service_token", secrets[0].SecretType)
+	assert.True(t, secrets[0].Pattern.MatchString("acme_svc_abcdefghijklmnopqrstuvwx"))
+}
+
+func TestLoadPatternsFromFile_JSON(t *testing.T) {
+	_, secrets, err := LoadPatternsFromFile(writePatternsFile(t,
+		`{"secret_patterns": [{"name": "Acme", "pattern": "acme_[0-9]+", "secret_type": "acme"}]}`))
+	require.NoError(t, err)
+	require.Len(t, secrets, 1)
+	assert.Equal(t, "acme", secrets[0].SecretType)
+}
+
+func TestLoadPatternsFromFile_Invalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantMsg string
+	}{
+		{
+			name:    "invalid regex names the pattern",
+			content: "secret_patterns:\n  - name: Broken token\n    pattern: 'tok_[a-z'\n    secret_type: broken\n",
+			wantMsg: `"Broken token"`,
+		},
+		{
+			name:    "missing pattern type",
+			content: "security_patterns:\n  - name: Untyped\n    pattern: 'x'\n",
+			wantMsg: "no pattern_type",
+		},
+		{
+			name:    "missing name",
+			content: "secret_patterns:\n  - pattern: 'x'\n    secret_type: anon\n",
+			wantMsg: "no name",
+		},
+		{
+			name: "unknown severity",
+			content: "security_patterns:\n  - name: Severe\n    pattern: 'x'\n" +
+				"    pattern_type: missing_auth\n    severity: urgent\n",
+			wantMsg: `unknown severity "urgent"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := LoadPatternsFromFile(writePatternsFile(t, tt.content))
+			require.ErrorIs(t, err, ErrInvalidPattern)
+			assert.Contains(t, err.Error(), tt.wantMsg)
+		})
+	}
+
+	_, _, err := LoadPatternsFromFile(filepath.Join(t.TempDir(), "missing.yaml"))
+	require.Error(t, err)
+}
+
+func TestLoadPatternSecurityAnalyzer_CustomPatterns(t *testing.T) {
+	analyzer, err := LoadPatternSecurityAnalyzer(context.Background(), &appconfig.ReviewerConfig{
+		CustomPatternsPath: writePatternsFile(t, customPatternsYAML),
+	})
+	require.NoError(t, err)
+
+	assessment, err := analyzer.Analyze(context.Background(), &SecurityAnalysisRequest{
+		FileContents: map[string]string{
+			"internal/client/client.go": "package client\n\n" +
+				"func call() {\n\tskipAuth()\n}\n\n" +
+				"var svc = acme_svc_abcdefghijklmnopqrstuvwx\n",
+		},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, []events.InsecurePatternType{events.InsecurePatternMissingAuth},
+		patternTypes(assessment.InsecurePatterns))
+	require.Len(t, assessment.SecretsDetected, 1)
+	assert.Equal(t, "acme_service_token", assessment.SecretsDetected[0].Type)
+	assert.Equal(t, 7, assessment.SecretsDetected[0].LineNumber)
+
+	// The built-ins are kept, except the one the file overrides by name
+	assert.Greater(t, len(analyzer.secretPatterns), 2)
+	for _, p := range analyzer.secretPatterns {
+		if p.Name == "GitHub Token" {
+			assert.Equal(t, "Overridden GitHub token pattern", p.Description)
+		}
+	}
+}
+
+func TestLoadPatternSecurityAnalyzer_InvalidCustomPatterns(t *testing.T) {
+	_, err := LoadPatternSecurityAnalyzer(context.Background(), &appconfig.ReviewerConfig{
+		CustomPatternsPath: writePatternsFile(t, "secret_patterns:\n  - name: Bad\n    pattern: '('\n    secret_type: x\n"),
+	})
+	require.ErrorIs(t, err, ErrInvalidPattern)
+}
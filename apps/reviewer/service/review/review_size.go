@@ -0,0 +1,85 @@
+package review
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+
+	"github.com/antinvestor/builder/internal/events"
+)
+
+// sensitivePathMarkers raise a file's priority when an oversized review is sampled.
+var sensitivePathMarkers = []string{
+	"auth", "crypto", "secret", "password", "token", "credential", "security",
+	"permission", "iam", "payment", "migration", "config", ".env", "dockerfile",
+	".github/workflows",
+}
+
+// reviewSample is the subset of a change analyzed when it exceeds the review size limits.
+type reviewSample struct {
+	Patches    []events.Patch
+	Unreviewed []string
+}
+
+// Partial reports whether files were left out of the review.
+func (s *reviewSample) Partial() bool {
+	return len(s.Unreviewed) > 0
+}
+
+// sampleForReview returns all patches when they fit within maxFiles and maxBytes
+// (0 = unlimited). Otherwise it keeps the most sensitive and most changed files
+// that fit and lists the rest as unreviewed.
+func sampleForReview(patches []events.Patch, maxFiles, maxBytes int) *reviewSample {
+	totalBytes := 0
+	for i := range patches {
+		totalBytes += patchSize(&patches[i])
+	}
+	if (maxFiles <= 0 || len(patches) <= maxFiles) && (maxBytes <= 0 || totalBytes <= maxBytes) {
+		return &reviewSample{Patches: patches}
+	}
+
+	ranked := slices.Clone(patches)
+	slices.SortStableFunc(ranked, func(a, b events.Patch) int {
+		bySensitivity := cmp.Compare(pathSensitivity(b.FilePath), pathSensitivity(a.FilePath))
+		if bySensitivity != 0 {
+			return bySensitivity
+		}
+		if byChange := cmp.Compare(b.LinesAdded+b.LinesRemoved, a.LinesAdded+a.LinesRemoved); byChange != 0 {
+			return byChange
+		}
+		return cmp.Compare(a.FilePath, b.FilePath)
+	})
+
+	sample := &reviewSample{}
+	usedBytes := 0
+	for i := range ranked {
+		size := patchSize(&ranked[i])
+		overFiles := maxFiles > 0 && len(sample.Patches) >= maxFiles
+		overBytes := maxBytes > 0 && usedBytes+size > maxBytes
+		if overFiles || overBytes {
+			sample.Unreviewed = append(sample.Unreviewed, ranked[i].FilePath)
+			continue
+		}
+		sample.Patches = append(sample.Patches, ranked[i])
+		usedBytes += size
+	}
+	slices.Sort(sample.Unreviewed)
+
+	return sample
+}
+
+// pathSensitivity scores how security-relevant a file path looks.
+func pathSensitivity(path string) int {
+	lower := strings.ToLower(path)
+	score := 0
+	for _, marker := range sensitivePathMarkers {
+		if strings.Contains(lower, marker) {
+			score++
+		}
+	}
+	return score
+}
+
+func patchSize(patch *events.Patch) int {
+	return len(patch.DiffContent) + len(patch.NewContent)
+}
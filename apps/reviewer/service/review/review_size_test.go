@@ -0,0 +1,153 @@
+//nolint:testpackage // white-box testing requires internal package access
+package review
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	appconfig "github.com/antinvestor/builder/apps/reviewer/config"
+	"github.com/antinvestor/builder/internal/events"
+)
+
+// recordingSecurityAnalyzer reports a clean assessment and records the analyzed files.
+type recordingSecurityAnalyzer struct {
+	files []string
+}
+
+func (a *recordingSecurityAnalyzer) Analyze(
+	_ context.Context,
+	req *SecurityAnalysisRequest,
+) (*events.SecurityAssessment, error) {
+	for _, patch := range req.Patches {
+		a.files = append(a.files, patch.FilePath)
+	}
+	return newCleanSecurityAssessment(), nil
+}
+
+func filePaths(patches []events.Patch) []string {
+	paths := make([]string, len(patches))
+	for i, patch := range patches {
+		paths[i] = patch.FilePath
+	}
+	return paths
+}
+
+func TestSampleForReview_WithinLimits(t *testing.T) {
+	patches := []events.Patch{{FilePath: "a.go"}, {FilePath: "b.go"}}
+
+	sample := sampleForReview(patches, 2, 0)
+
+	assert.False(t, sample.Partial())
+	assert.Equal(t, patches, sample.Patches)
+}
+
+func TestSampleForReview_PrefersSensitiveAndLargestChanges(t *testing.T) {
+	patches := []events.Patch{
+		{FilePath: "docs/readme.md", LinesAdded: 500},
+		{FilePath: "internal/api/handler.go", LinesAdded: 40, LinesRemoved: 10},
+		{FilePath: "internal/auth/token.go", LinesAdded: 2},
+		{FilePath: "internal/api/util.go", LinesAdded: 3},
+		{FilePath: "deploy/config.yaml", LinesAdded: 1},
+	}
+
+	sample := sampleForReview(patches, 3, 0)
+
+	require.True(t, sample.Partial())
+	assert.Equal(t, []string{"internal/auth/token.go", "deploy/config.yaml", "docs/readme.md"},
+		filePaths(sample.Patches))
+	assert.Equal(t, []string{"internal/api/handler.go", "internal/api/util.go"}, sample.Unreviewed)
+}
+
+func TestSampleForReview_ByteLimitSkipsOversizedFiles(t *testing.T) {
+	patches := []events.Patch{
+		{FilePath: "generated.go", LinesAdded: 1000, DiffContent: strings.Repeat("x", 900)},
+		{FilePath: "service.go", LinesAdded: 20, DiffContent: strings.Repeat("x", 200)},
+		{FilePath: "model.go", LinesAdded: 10, DiffContent: strings.Repeat("x", 200)},
+	}
+
+	sample := sampleForReview(patches, 0, 500)
+
+	require.True(t, sample.Partial())
+	assert.Equal(t, []string{"service.go", "model.go"}, filePaths(sample.Patches))
+	assert.Equal(t, []string{"generated.go"}, sample.Unreviewed)
+}
+
+func TestRequestHandler_OversizedReviewIsPartialAndManual(t *testing.T) {
+	cfg := &appconfig.ReviewerConfig{
+		MaxRiskScore:         50,
+		MaxSecurityRiskScore: 30,
+		MaxHighIssues:        2,
+		MaxIterations:        3,
+		MaxReviewFiles:       2,
+	}
+	security := &recordingSecurityAnalyzer{}
+	emitter := &mockEventsEmitter{}
+	handler := NewRequestHandler(
+		cfg,
+		security,
+		stubArchitectureAnalyzer{},
+		NewThresholdDecisionEngine(cfg),
+		NewDefaultKillSwitchService(cfg, emitter),
+		emitter,
+	)
+
+	refs := []events.PatchReference{
+		{FilePath: "internal/auth/session.go", ChangeType: "modify", LinesAdded: 5},
+	}
+	for i := range 4 {
+		refs = append(refs, events.PatchReference{
+			FilePath:   fmt.Sprintf("internal/api/file%d.go", i),
+			ChangeType: "modify",
+			LinesAdded: 10 * (i + 1),
+		})
+	}
+	payload, err := json.Marshal(&events.ComprehensiveReviewRequestedPayload{
+		ExecutionID: events.NewExecutionID(),
+		TestResults: newPassingTestResult(),
+		Patches:     refs,
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, handler.Handle(context.Background(), nil, payload))
+
+	// Only the sampled files reached the analyzers
+	assert.Equal(t, []string{"internal/auth/session.go", "internal/api/file3.go"}, security.files)
+
+	require.Len(t, emitter.emittedEvents, 1)
+	completed, ok := emitter.emittedEvents[0].payload.(*events.ComprehensiveReviewCompletedPayload)
+	require.True(t, ok)
+	assert.True(t, completed.Partial)
+	assert.Equal(t, events.ControlDecisionManualReview, completed.Decision)
+	assert.Contains(t, completed.DecisionRationale, "partial review, 3 files not analyzed")
+	assert.Equal(t,
+		[]string{"internal/api/file0.go", "internal/api/file1.go", "internal/api/file2.go"},
+		completed.UnreviewedFiles,
+	)
+	assert.Equal(t, events.FileReviewStatusUnreviewed, completed.FileStatuses["internal/api/file0.go"])
+	assert.Equal(t, events.FileReviewStatusClean, completed.FileStatuses["internal/auth/session.go"])
+}
+
+func TestThresholdDecisionEngine_PartialReviewKeepsIterate(t *testing.T) {
+	engine := newTestDecisionEngine()
+	failing := newPassingTestResult()
+	failing.Success = false
+
+	result, err := engine.MakeDecision(context.Background(), &DecisionRequest{
+		ExecutionID:            events.NewExecutionID(),
+		SecurityAssessment:     newCleanSecurityAssessment(),
+		ArchitectureAssessment: newCleanArchitectureAssessment(),
+		TestResult:             failing,
+		UnreviewedFiles:        []string{"big.go"},
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, events.ControlDecisionIterate, result.Decision)
+	assert.Contains(t, result.Warnings,
+		"Partial review: 1 files exceeded the review size limits and were not analyzed")
+}
@@ -0,0 +1,120 @@
+package review
+
+import (
+	"math"
+	"regexp"
+
+	appconfig "github.com/antinvestor/builder/apps/reviewer/config"
+	"github.com/antinvestor/builder/internal/events"
+)
+
+// Entropy scanning defaults, used when the analyzer has no configuration.
+const (
+	secretTypeHighEntropy         = "high_entropy_string"
+	defaultEntropyBase64Threshold = 4.5
+	defaultEntropyHexThreshold    = 3.0
+	defaultEntropyMinLength       = 20
+)
+
+var (
+	// quotedToken captures string literals made only of base64 (standard or URL-safe)
+	// characters, which includes hex.
+	quotedToken = regexp.MustCompile("[\"'`]([A-Za-z0-9+/=_-]+)[\"'`]")
+	// hexToken matches a token made only of hex digits.
+	hexToken = regexp.MustCompile(`^[0-9a-fA-F]+$`)
+	// uuidToken matches a UUID, which is random but not a secret.
+	uuidToken = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+)
+
+// entropyPolicy decides which string literals are random enough to be secrets.
+type entropyPolicy struct {
+	base64Threshold float64
+	hexThreshold    float64
+	minLength       int
+}
+
+// entropyPolicyFromConfig builds the entropy policy, defaulting when there is no
+// configuration. A zero threshold disables the check for its encoding.
+func entropyPolicyFromConfig(cfg *appconfig.ReviewerConfig) entropyPolicy {
+	if cfg == nil {
+		return entropyPolicy{
+			base64Threshold: defaultEntropyBase64Threshold,
+			hexThreshold:    defaultEntropyHexThreshold,
+			minLength:       defaultEntropyMinLength,
+		}
+	}
+	policy := entropyPolicy{
+		base64Threshold: cfg.SecretEntropyBase64Threshold,
+		hexThreshold:    cfg.SecretEntropyHexThreshold,
+		minLength:       cfg.SecretEntropyMinLength,
+	}
+	if policy.minLength <= 0 {
+		policy.minLength = defaultEntropyMinLength
+	}
+	return policy
+}
+
+// isSecret reports whether a token is long and random enough to be a secret.
+func (p entropyPolicy) isSecret(token string) bool {
+	if len(token) < p.minLength || uuidToken.MatchString(token) {
+		return false
+	}
+	threshold := p.base64Threshold
+	if hexToken.MatchString(token) {
+		threshold = p.hexThreshold
+	}
+	return threshold > 0 && shannonEntropy(token) >= threshold
+}
+
+// findHighEntropySecrets reports string literals random enough to be secrets
+// that no vendor pattern recognised. known are the byte ranges already reported.
+func (a *PatternSecurityAnalyzer) findHighEntropySecrets(
+	filePath, content string,
+	known [][]int,
+) []events.SecretFinding {
+	var secrets []events.SecretFinding
+	for _, match := range quotedToken.FindAllStringSubmatchIndex(content, -1) {
+		start, end := match[2], match[3]
+		token := content[start:end]
+		if overlapsAny(start, end, known) || !a.entropy.isSecret(token) || isTestOrExample(filePath, token) {
+			continue
+		}
+
+		lineNumber, _ := findLineNumbers(content, start, end)
+		secrets = append(secrets, events.SecretFinding{
+			Type:        secretTypeHighEntropy,
+			FilePath:    filePath,
+			LineNumber:  lineNumber,
+			Description: "High-entropy string literal may be a hardcoded secret",
+			Redacted:    redactSecret(token),
+		})
+	}
+	return secrets
+}
+
+// shannonEntropy returns the Shannon entropy of s in bits per character.
+func shannonEntropy(s string) float64 {
+	counts := make(map[rune]int)
+	total := 0
+	for _, r := range s {
+		counts[r]++
+		total++
+	}
+
+	entropy := 0.0
+	for _, count := range counts {
+		p := float64(count) / float64(total)
+		entropy -= p * math.Log2(p)
+	}
+	return entropy
+}
+
+// overlapsAny reports whether [start, end) overlaps any of the ranges.
+func overlapsAny(start, end int, ranges [][]int) bool {
+	for _, r := range ranges {
+		if start < r[1] && r[0] < end {
+			return true
+		}
+	}
+	return false
+}
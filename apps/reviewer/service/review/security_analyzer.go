@@ -50,6 +50,9 @@ type PatternSecurityAnalyzer struct {
 	cfg              *appconfig.ReviewerConfig
 	securityPatterns []securityPattern
 	secretPatterns   []secretPattern
+	entropy          entropyPolicy
+	baseline         BaselineStore
+	dependencies     *DependencyAnalyzer
 }
 
 // NewPatternSecurityAnalyzer creates a new pattern-based security analyzer.
@@ -58,9 +61,59 @@ func NewPatternSecurityAnalyzer(cfg *appconfig.ReviewerConfig) *PatternSecurityA
 		cfg:              cfg,
 		securityPatterns: initSecurityPatterns(),
 		secretPatterns:   initSecretPatterns(),
+		entropy:          entropyPolicyFromConfig(cfg),
 	}
 }
 
+// LoadPatternSecurityAnalyzer creates a pattern-based security analyzer with the
+// patterns of cfg.CustomPatternsPath, when set, merged with the built-ins.
+func LoadPatternSecurityAnalyzer(
+	ctx context.Context,
+	cfg *appconfig.ReviewerConfig,
+) (*PatternSecurityAnalyzer, error) {
+	analyzer := NewPatternSecurityAnalyzer(cfg)
+	if cfg == nil || cfg.CustomPatternsPath == "" {
+		return analyzer, nil
+	}
+	if err := analyzer.LoadPatterns(ctx, cfg.CustomPatternsPath); err != nil {
+		return nil, err
+	}
+	return analyzer, nil
+}
+
+// LoadPatterns adds the security and secret patterns of a patterns file to the
+// analyzer's; a loaded pattern replaces the pattern of the same name, and each
+// replacement is logged so an accidental override does not go unnoticed.
+func (a *PatternSecurityAnalyzer) LoadPatterns(ctx context.Context, path string) error {
+	security, secrets, err := LoadPatternsFromFile(path)
+	if err != nil {
+		return err
+	}
+	var replacedSecurity, replacedSecrets []string
+	a.securityPatterns, replacedSecurity = mergePatterns(a.securityPatterns, security,
+		func(p securityPattern) string { return p.Name })
+	a.secretPatterns, replacedSecrets = mergePatterns(a.secretPatterns, secrets,
+		func(p secretPattern) string { return p.Name })
+	if len(replacedSecurity) > 0 || len(replacedSecrets) > 0 {
+		util.Log(ctx).Warn("custom patterns replace existing patterns",
+			"patterns_file", path,
+			"security_patterns", replacedSecurity,
+			"secret_patterns", replacedSecrets,
+		)
+	}
+	return nil
+}
+
+// SetBaselineStore sets the store of baselined findings that are suppressed in reviews.
+func (a *PatternSecurityAnalyzer) SetBaselineStore(store BaselineStore) {
+	a.baseline = store
+}
+
+// SetDependencyAnalyzer reports vulnerable dependencies the reviewed manifests introduce.
+func (a *PatternSecurityAnalyzer) SetDependencyAnalyzer(analyzer *DependencyAnalyzer) {
+	a.dependencies = analyzer
+}
+
 // initSecurityPatterns initializes security vulnerability patterns.
 //
 //nolint:funlen // pattern list definition requires many lines
@@ -502,12 +555,21 @@ func (a *PatternSecurityAnalyzer) Analyze(
 		RequiresSecurityReview: false,
 	}
 
-	// Analyze each file
+	// Analyze each file with the rules for its own language. With patches, only the
+	// changed lines are reported; findings elsewhere count only as regressions when
+	// the baseline shows they are new
+	scopes := a.diffScopes(req)
 	for filePath, content := range req.FileContents {
-		language := detectLanguage(filePath)
-
-		// Check for security patterns
-		patterns := a.findSecurityPatterns(filePath, content, language)
+		language := languageForFile(filePath, req.Language)
+		scope := scopes.forFile(filePath)
+
+		// Check for security patterns, skipping findings already in the repository baseline
+		patterns, suppressedPatterns := a.findSecurityPatterns(filePath, content, language)
+		assessment.SuppressedCount += suppressedPatterns
+		patterns = a.filterBaselinedPatterns(ctx, req.RepositoryID, patterns)
+		patterns, outsidePatterns := scope.splitPatterns(patterns)
+		assessment.SecurityRegressions = append(assessment.SecurityRegressions,
+			a.introducedPatternRegressions(filePath, language, req.BaselineContents, outsidePatterns)...)
 		assessment.InsecurePatterns = append(assessment.InsecurePatterns, patterns...)
 
 		// Check for vulnerabilities (convert patterns to vulnerabilities for critical issues)
@@ -520,7 +582,7 @@ func (a *PatternSecurityAnalyzer) Analyze(
 				vuln := events.Vulnerability{
 					ID:          generateVulnID(filePath, pattern.LineStart),
 					Type:        patternTypeToVulnType(pattern.PatternType),
-					Severity:    events.VulnerabilitySeverity(pattern.PatternType),
+					Severity:    pattern.Severity,
 					CWE:         pattern.CWE,
 					FilePath:    pattern.FilePath,
 					LineStart:   pattern.LineStart,
@@ -534,8 +596,23 @@ func (a *PatternSecurityAnalyzer) Analyze(
 		}
 
 		// Check for secrets
-		secrets := a.findSecrets(filePath, content)
+		secrets, suppressedSecrets := a.findSecrets(filePath, content)
+		assessment.SuppressedCount += suppressedSecrets
+		secrets, outsideSecrets := scope.splitSecrets(secrets)
+		assessment.SecurityRegressions = append(assessment.SecurityRegressions,
+			a.introducedSecretRegressions(filePath, req.BaselineContents, outsideSecrets)...)
 		assessment.SecretsDetected = append(assessment.SecretsDetected, secrets...)
+
+		// Check for security controls the change removes or disables
+		if baseline, ok := req.BaselineContents[filePath]; ok {
+			assessment.SecurityRegressions = appendRegressions(assessment.SecurityRegressions,
+				controlRegressions(filePath, baseline, content))
+		}
+	}
+
+	// Check the dependencies the changed manifests introduce or upgrade
+	if a.dependencies != nil {
+		assessment.DependencyVulnerabilities = a.dependencies.Analyze(req.FileContents, req.BaselineContents)
 	}
 
 	// Calculate security score
@@ -547,21 +624,35 @@ func (a *PatternSecurityAnalyzer) Analyze(
 	// Determine if security review is required
 	assessment.RequiresSecurityReview, assessment.SecurityReviewReason = a.determineSecurityReviewRequired(assessment)
 
+	// Cap findings per type so one systemic issue does not flood the report
+	if a.cfg != nil {
+		throttleSecurityFindings(assessment, a.cfg.FindingCap)
+	}
+
 	log.Info("security analysis complete",
 		"score", assessment.OverallSecurityScore,
 		"vulnerabilities", len(assessment.VulnerabilitiesFound),
 		"secrets", len(assessment.SecretsDetected),
 		"patterns", len(assessment.InsecurePatterns),
+		"regressions", len(assessment.SecurityRegressions),
+		"suppressed", assessment.SuppressedCount,
+		"diff_scoped", scopes != nil,
+		"throttled", omittedFindings(assessment.ThrottledFindings),
 		"status", assessment.SecurityStatus,
 	)
 
 	return assessment, nil
 }
 
-// findSecurityPatterns finds security patterns in the content.
-func (a *PatternSecurityAnalyzer) findSecurityPatterns(filePath, content, language string) []events.InsecurePattern {
+// findSecurityPatterns finds security patterns in the content. It also returns how
+// many matches a builder:ignore-security comment suppressed.
+func (a *PatternSecurityAnalyzer) findSecurityPatterns(
+	filePath, content, language string,
+) ([]events.InsecurePattern, int) {
 	var patterns []events.InsecurePattern
 	lines := strings.Split(content, "\n")
+	ignored := parseSuppressions(content, language)
+	suppressed := 0
 
 	for _, sp := range a.securityPatterns {
 		// Check if pattern applies to this language
@@ -579,6 +670,10 @@ func (a *PatternSecurityAnalyzer) findSecurityPatterns(filePath, content, langua
 			if isCommentOrTest(filePath, codeSnippet) {
 				continue
 			}
+			if ignored.suppresses(lineStart, string(sp.PatternType)) {
+				suppressed++
+				continue
+			}
 
 			patterns = append(patterns, events.InsecurePattern{
 				PatternType: sp.PatternType,
@@ -590,24 +685,28 @@ func (a *PatternSecurityAnalyzer) findSecurityPatterns(filePath, content, langua
 				Remediation: sp.Remediation,
 				OWASPID:     sp.OWASPID,
 				CWE:         sp.CWE,
+				Severity:    sp.Severity,
 			})
 		}
 	}
 
-	return patterns
+	return patterns, suppressed
 }
 
-// findSecrets finds potential secrets in the content.
-func (a *PatternSecurityAnalyzer) findSecrets(filePath, content string) []events.SecretFinding {
+// findSecrets finds potential secrets in the content. It also returns how many
+// a builder:ignore-security comment suppressed.
+func (a *PatternSecurityAnalyzer) findSecrets(filePath, content string) ([]events.SecretFinding, int) {
 	var secrets []events.SecretFinding
 
 	// Skip certain file types
 	if isNonCodeFile(filePath) {
-		return secrets
+		return secrets, 0
 	}
 
+	var matched [][]int
 	for _, sp := range a.secretPatterns {
 		matches := sp.Pattern.FindAllStringIndex(content, -1)
+		matched = append(matched, matches...)
 		for _, match := range matches {
 			lineNumber, _ := findLineNumbers(content, match[0], match[1])
 			matchedText := content[match[0]:match[1]]
@@ -627,7 +726,38 @@ func (a *PatternSecurityAnalyzer) findSecrets(filePath, content string) []events
 		}
 	}
 
-	return secrets
+	// Random tokens without a known vendor format
+	secrets = append(secrets, a.findHighEntropySecrets(filePath, content, matched)...)
+
+	// Drop the secrets a comment acknowledges
+	ignored := parseSuppressions(content, detectLanguage(filePath))
+	kept := secrets[:0]
+	for _, secret := range secrets {
+		if !ignored.suppresses(secret.LineNumber, secret.Type) {
+			kept = append(kept, secret)
+		}
+	}
+
+	return kept, len(secrets) - len(kept)
+}
+
+// filterBaselinedPatterns drops patterns that are part of the repository baseline.
+func (a *PatternSecurityAnalyzer) filterBaselinedPatterns(
+	ctx context.Context,
+	repositoryID string,
+	patterns []events.InsecurePattern,
+) []events.InsecurePattern {
+	if a.baseline == nil || repositoryID == "" {
+		return patterns
+	}
+
+	kept := patterns[:0]
+	for _, p := range patterns {
+		if !a.baseline.Contains(ctx, repositoryID, patternFingerprint(p)) {
+			kept = append(kept, p)
+		}
+	}
+	return kept
 }
 
 // calculateSecurityScore calculates the overall security score.
@@ -648,6 +778,20 @@ func (a *PatternSecurityAnalyzer) calculateSecurityScore(assessment *events.Secu
 		}
 	}
 
+	// Deduct for vulnerable dependencies
+	for _, dep := range assessment.DependencyVulnerabilities {
+		switch dep.Severity {
+		case events.VulnerabilitySeverityCritical:
+			score -= 30
+		case events.VulnerabilitySeverityHigh:
+			score -= 20
+		case events.VulnerabilitySeverityMedium:
+			score -= 10
+		case events.VulnerabilitySeverityLow:
+			score -= 5
+		}
+	}
+
 	// Deduct for insecure patterns
 	for _, pattern := range assessment.InsecurePatterns {
 		switch pattern.PatternType { //nolint:exhaustive // default handles remaining types
@@ -703,6 +847,12 @@ func (a *PatternSecurityAnalyzer) determineSecurityStatus(assessment *events.Sec
 		}
 	}
 
+	for _, dep := range assessment.DependencyVulnerabilities {
+		if dep.Severity == events.VulnerabilitySeverityCritical {
+			return events.SecurityStatusCritical
+		}
+	}
+
 	// Check for any secrets
 	if len(assessment.SecretsDetected) > 0 {
 		return events.SecurityStatusCritical
@@ -767,26 +917,38 @@ func detectLanguage(filePath string) string {
 	ext := strings.ToLower(filePath)
 	switch {
 	case strings.HasSuffix(ext, ".go"):
-		return "go"
+		return langGo
 	case strings.HasSuffix(ext, ".py"):
-		return "python"
-	case strings.HasSuffix(ext, ".js"):
-		return "javascript"
+		return langPython
+	case strings.HasSuffix(ext, ".js"), strings.HasSuffix(ext, ".jsx"):
+		return langJavaScript
 	case strings.HasSuffix(ext, ".ts"), strings.HasSuffix(ext, ".tsx"):
-		return "typescript"
+		return langTypeScript
 	case strings.HasSuffix(ext, ".java"):
-		return "java"
+		return langJava
 	case strings.HasSuffix(ext, ".rb"):
 		return "ruby"
 	case strings.HasSuffix(ext, ".php"):
 		return "php"
 	case strings.HasSuffix(ext, ".rs"):
-		return "rust"
+		return langRust
 	case strings.HasSuffix(ext, ".cs"):
 		return "csharp"
 	default:
-		return "unknown"
+		return langUnknown
+	}
+}
+
+// languageForFile resolves the language of a single file from its extension,
+// using fallback only when the extension is not recognized.
+func languageForFile(filePath, fallback string) string {
+	if language := detectLanguage(filePath); language != langUnknown {
+		return language
+	}
+	if fallback != "" {
+		return fallback
 	}
+	return langUnknown
 }
 
 func findLineNumbers(content string, start, end int) (int, int) {
@@ -0,0 +1,172 @@
+package review
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/antinvestor/builder/internal/events"
+)
+
+// securityControl is a safeguard whose removal or disabling, compared with a
+// file's baseline, is a security regression.
+type securityControl struct {
+	regressionType events.SecurityRegressionType
+	severity       events.VulnerabilitySeverity
+	description    string
+	// applied matches a line applying the control; removing one is a regression.
+	applied *regexp.Regexp
+	// disabled matches a line turning the control off; adding one is a regression.
+	disabled *regexp.Regexp
+}
+
+// securityControls are the controls compared against the baseline.
+//
+//nolint:gochecknoglobals // compiled once, like the security patterns
+var securityControls = []securityControl{
+	{
+		regressionType: events.SecurityRegressionRemovedEncryption,
+		severity:       events.VulnerabilitySeverityHigh,
+		description:    "TLS certificate verification",
+		applied: regexp.MustCompile(`InsecureSkipVerify\s*:\s*false|verify\s*=\s*True|` +
+			`rejectUnauthorized\s*:\s*true|ssl\.CERT_REQUIRED|check_hostname\s*=\s*True`),
+		disabled: regexp.MustCompile(`InsecureSkipVerify\s*:\s*true|verify\s*=\s*False|` +
+			`rejectUnauthorized\s*:\s*false|NODE_TLS_REJECT_UNAUTHORIZED['"]?\]?\s*=\s*['"]?0|` +
+			`ssl\.CERT_NONE|check_hostname\s*=\s*False`),
+	},
+	{
+		regressionType: events.SecurityRegressionWeakenedAuth,
+		severity:       events.VulnerabilitySeverityHigh,
+		description:    "authentication or authorization check",
+		applied: regexp.MustCompile(`(?i)\b(require|check|verify|ensure)_?(auth\w*|role|permission|token)\s*\(|` +
+			`\bauthori[sz]e\w*\s*\(|\bisAuthenticated\b|\bHasPermission\s*\(|@login_required|` +
+			`@requires_auth|@permission_required|\bjwt\.Parse\w*\s*\(`),
+		disabled: regexp.MustCompile(`(?i)\bskip_?auth\w*\s*[:=]\s*true\b|\bauth_?required\s*[:=]\s*false\b|` +
+			`@csrf_exempt|\[AllowAnonymous\]|\.permitAll\(\)`),
+	},
+	{
+		regressionType: events.SecurityRegressionRemovedValidation,
+		severity:       events.VulnerabilitySeverityMedium,
+		description:    "input validation",
+		applied: regexp.MustCompile(`(?i)\b(validate|sanitize|sanitise)\w*\s*\(|\.is_valid\(\)|` +
+			`@validates?\b|\bhtml\.EscapeString\s*\(|\bfilepath\.Clean\s*\(|\bbleach\.clean\s*\(`),
+	},
+	{
+		regressionType: events.SecurityRegressionInsecureDefault,
+		severity:       events.VulnerabilitySeverityMedium,
+		description:    "secure default",
+		disabled: regexp.MustCompile(`\bDEBUG\s*=\s*True\b|AllowAllOrigins\s*:\s*true|` +
+			`\b(Secure|HttpOnly)\s*:\s*false\b|SESSION_COOKIE_SECURE\s*=\s*False`),
+	},
+}
+
+// controlLine is a line of a file that applies or disables a control.
+type controlLine struct {
+	number int
+	text   string
+}
+
+// controlRegressions reports the controls a file's baseline applied that the
+// change removes, and the lines disabling a control that the change adds.
+// Test files and comments are not compared.
+func controlRegressions(filePath, baseline, current string) []events.SecurityRegression {
+	if isCommentOrTest(filePath, "") || isNonCodeFile(filePath) {
+		return nil
+	}
+	baselineLines := codeLines(baseline)
+	currentLines := codeLines(current)
+
+	var regressions []events.SecurityRegression
+	for _, control := range securityControls {
+		removed := linesMissingFrom(baselineLines, currentLines, control.applied)
+		for _, added := range linesMissingFrom(currentLines, baselineLines, control.disabled) {
+			// A removed line applying the control is most likely the one this replaced
+			regression := events.SecurityRegression{
+				RegressionType: control.regressionType,
+				Description:    fmt.Sprintf("Change disables %s", control.description),
+				FilePath:       filePath,
+				LineNumber:     added.number,
+				Severity:       control.severity,
+				Current:        added.text,
+			}
+			if len(removed) > 0 {
+				regression.Baseline, removed = removed[0].text, removed[1:]
+			}
+			regressions = append(regressions, regression)
+		}
+		for _, line := range removed {
+			regressions = append(regressions, events.SecurityRegression{
+				RegressionType: control.regressionType,
+				Description: fmt.Sprintf("Change removes %s from line %d of the baseline",
+					control.description, line.number),
+				FilePath: filePath,
+				Severity: control.severity,
+				Baseline: line.text,
+			})
+		}
+	}
+	return regressions
+}
+
+// codeLines returns the trimmed lines of content, numbered from 1, leaving out
+// blank and comment lines.
+func codeLines(content string) []controlLine {
+	var lines []controlLine
+	number := 0
+	for line := range strings.SplitSeq(content, "\n") {
+		number++
+		line = strings.TrimSpace(line)
+		if line == "" || isCommentOrTest("", line) {
+			continue
+		}
+		lines = append(lines, controlLine{number: number, text: line})
+	}
+	return lines
+}
+
+// linesMissingFrom returns the lines of from matching pattern that occur fewer
+// times in other, keeping the extra occurrences.
+func linesMissingFrom(from, other []controlLine, pattern *regexp.Regexp) []controlLine {
+	if pattern == nil {
+		return nil
+	}
+	remaining := make(map[string]int)
+	for _, line := range other {
+		if pattern.MatchString(line.text) {
+			remaining[line.text]++
+		}
+	}
+	var missing []controlLine
+	for _, line := range from {
+		if !pattern.MatchString(line.text) {
+			continue
+		}
+		if remaining[line.text] > 0 {
+			remaining[line.text]--
+			continue
+		}
+		missing = append(missing, line)
+	}
+	return missing
+}
+
+// appendRegressions adds regressions, skipping those at a line another
+// regression already reports.
+func appendRegressions(existing, regressions []events.SecurityRegression) []events.SecurityRegression {
+	reported := make(map[string]bool, len(existing))
+	key := func(r events.SecurityRegression) string {
+		return fmt.Sprintf("%s:%d", r.FilePath, r.LineNumber)
+	}
+	for _, r := range existing {
+		if r.LineNumber > 0 {
+			reported[key(r)] = true
+		}
+	}
+	for _, r := range regressions {
+		if r.LineNumber > 0 && reported[key(r)] {
+			continue
+		}
+		existing = append(existing, r)
+	}
+	return existing
+}
@@ -0,0 +1,99 @@
+package review //nolint:testpackage // white-box testing requires internal access
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/antinvestor/builder/internal/events"
+)
+
+func TestControlRegressions_DisabledTLSVerification(t *testing.T) {
+	baseline := "package client\n\nvar tlsConfig = &tls.Config{InsecureSkipVerify: false}\n"
+	current := "package client\n\nvar tlsConfig = &tls.Config{InsecureSkipVerify: true}\n"
+
+	regressions := controlRegressions("internal/client/client.go", baseline, current)
+
+	require.Len(t, regressions, 1)
+	assert.Equal(t, events.SecurityRegressionRemovedEncryption, regressions[0].RegressionType)
+	assert.Equal(t, events.VulnerabilitySeverityHigh, regressions[0].Severity)
+	assert.Equal(t, 3, regressions[0].LineNumber)
+	assert.Equal(t, "var tlsConfig = &tls.Config{InsecureSkipVerify: false}", regressions[0].Baseline)
+	assert.Equal(t, "var tlsConfig = &tls.Config{InsecureSkipVerify: true}", regressions[0].Current)
+}
+
+func TestControlRegressions_RemovedControls(t *testing.T) {
+	baseline := "def update(request):\n" +
+		"    check_permission(request.user)\n" +
+		"    form = validate_form(request.POST)\n" +
+		"    save(form)\n"
+	current := "def update(request):\n    save(request.POST)\n"
+
+	regressions := controlRegressions("app/views.py", baseline, current)
+
+	require.Len(t, regressions, 2)
+	assert.Equal(t, events.SecurityRegressionWeakenedAuth, regressions[0].RegressionType)
+	assert.Equal(t, "check_permission(request.user)", regressions[0].Baseline)
+	assert.Equal(t, events.SecurityRegressionRemovedValidation, regressions[1].RegressionType)
+	assert.Equal(t, "form = validate_form(request.POST)", regressions[1].Baseline)
+	for _, regression := range regressions {
+		assert.Zero(t, regression.LineNumber, "a removed line has no line in the current file")
+		assert.Empty(t, regression.Current)
+	}
+}
+
+func TestControlRegressions_AddedInsecureDefaults(t *testing.T) {
+	baseline := "import requests\n\nresp = requests.get(url)\n"
+	current := "import requests\n\nDEBUG = True\nresp = requests.get(url, verify=False)\n"
+
+	regressions := controlRegressions("app/client.py", baseline, current)
+
+	require.Len(t, regressions, 2)
+	assert.Equal(t, events.SecurityRegressionRemovedEncryption, regressions[0].RegressionType)
+	assert.Equal(t, 4, regressions[0].LineNumber)
+	assert.Empty(t, regressions[0].Baseline)
+	assert.Equal(t, events.SecurityRegressionInsecureDefault, regressions[1].RegressionType)
+	assert.Equal(t, 3, regressions[1].LineNumber)
+}
+
+func TestControlRegressions_UnchangedOrSkipped(t *testing.T) {
+	insecure := "package client\n\n// InsecureSkipVerify: false was the default\n" +
+		"var tlsConfig = &tls.Config{InsecureSkipVerify: true}\n"
+
+	// A control already disabled in the baseline is not new
+	assert.Empty(t, controlRegressions("internal/client/client.go", insecure, insecure+"var x = 1\n"))
+	// Commented-out controls and test files are not compared
+	assert.Empty(t, controlRegressions("internal/client/client.go",
+		"package client\n\n// validate(input)\n", "package client\n"))
+	assert.Empty(t, controlRegressions("internal/client/client_test.go",
+		"package client\n\nvalidate(input)\n", "package client\n"))
+}
+
+func TestPatternSecurityAnalyzer_ReportsControlRegressions(t *testing.T) {
+	analyzer := NewPatternSecurityAnalyzer(nil)
+	baseline := "package client\n\nvar tlsConfig = &tls.Config{InsecureSkipVerify: false}\n"
+	current := "package client\n\nvar tlsConfig = &tls.Config{InsecureSkipVerify: true}\n"
+
+	assessment, err := analyzer.Analyze(context.Background(), &SecurityAnalysisRequest{
+		Patches: []events.Patch{{
+			FilePath: "internal/client/client.go",
+			Action:   events.FileActionModify,
+			DiffContent: "@@ -3 +3 @@\n-var tlsConfig = &tls.Config{InsecureSkipVerify: false}\n" +
+				"+var tlsConfig = &tls.Config{InsecureSkipVerify: true}\n",
+		}},
+		FileContents:     map[string]string{"internal/client/client.go": current},
+		BaselineContents: map[string]string{"internal/client/client.go": baseline},
+	})
+	require.NoError(t, err)
+
+	require.Len(t, assessment.SecurityRegressions, 1)
+	regression := assessment.SecurityRegressions[0]
+	assert.Contains(t, []events.SecurityRegressionType{
+		events.SecurityRegressionRemovedEncryption, events.SecurityRegressionInsecureDefault,
+	}, regression.RegressionType)
+	assert.Equal(t, 3, regression.LineNumber)
+	assert.NotEmpty(t, regression.Baseline)
+	assert.NotEmpty(t, regression.Current)
+}
@@ -0,0 +1,159 @@
+package review
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/pitabwire/util"
+
+	"github.com/antinvestor/builder/internal/events"
+)
+
+// ShadowDecision records what the shadow configuration decided for a review
+// alongside the primary decision that was actually emitted.
+type ShadowDecision struct {
+	ExecutionID      events.ExecutionID     `json:"execution_id"`
+	PrimaryDecision  events.ControlDecision `json:"primary_decision"`
+	ShadowDecision   events.ControlDecision `json:"shadow_decision,omitempty"`
+	PrimaryRiskScore int                    `json:"primary_risk_score"`
+	ShadowRiskScore  int                    `json:"shadow_risk_score"`
+	Diverged         bool                   `json:"diverged"`
+	Error            string                 `json:"error,omitempty"`
+	RecordedAt       time.Time              `json:"recorded_at"`
+}
+
+// ShadowStore stores shadow decisions for later comparison.
+type ShadowStore interface {
+	// Record stores a shadow decision.
+	Record(ctx context.Context, decision ShadowDecision) error
+}
+
+// maxShadowDecisions bounds the decisions a MemoryShadowStore keeps; the oldest
+// are dropped first.
+const maxShadowDecisions = 1000
+
+// MemoryShadowStore is an in-memory ShadowStore keeping the most recent decisions.
+type MemoryShadowStore struct {
+	mu        sync.RWMutex
+	decisions []ShadowDecision
+}
+
+// NewMemoryShadowStore creates an empty in-memory shadow store.
+func NewMemoryShadowStore() *MemoryShadowStore {
+	return &MemoryShadowStore{}
+}
+
+// Record implements ShadowStore.
+func (s *MemoryShadowStore) Record(_ context.Context, decision ShadowDecision) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.decisions) >= maxShadowDecisions {
+		s.decisions = slices.Delete(s.decisions, 0, len(s.decisions)-maxShadowDecisions+1)
+	}
+	s.decisions = append(s.decisions, decision)
+	return nil
+}
+
+// Decisions returns the kept shadow decisions in recording order.
+func (s *MemoryShadowStore) Decisions() []ShadowDecision {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return append([]ShadowDecision(nil), s.decisions...)
+}
+
+// Divergences returns the shadow decisions that differ from the primary decision.
+func (s *MemoryShadowStore) Divergences() []ShadowDecision {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var divergences []ShadowDecision
+	for _, d := range s.decisions {
+		if d.Diverged {
+			divergences = append(divergences, d)
+		}
+	}
+	return divergences
+}
+
+// ShadowEvaluator runs a second decision configuration next to the primary one.
+// Its results are only logged and recorded; they never change the primary decision.
+type ShadowEvaluator struct {
+	engine     DecisionEngine
+	thresholds events.ReviewThresholds
+	store      ShadowStore
+	now        func() time.Time
+}
+
+// NewShadowEvaluator creates an evaluator applying the shadow thresholds with the given engine.
+func NewShadowEvaluator(engine DecisionEngine, thresholds events.ReviewThresholds, store ShadowStore) *ShadowEvaluator {
+	return &ShadowEvaluator{
+		engine:     engine,
+		thresholds: thresholds,
+		store:      store,
+		now:        time.Now,
+	}
+}
+
+// Evaluate decides the request with the shadow thresholds and records the outcome
+// against the primary decision. Failures are logged and recorded, never returned.
+func (s *ShadowEvaluator) Evaluate(ctx context.Context, req *DecisionRequest, primary *DecisionResult) {
+	log := util.Log(ctx)
+
+	// Decide on a copy so the shadow run cannot alter the primary request.
+	shadowReq := *req
+	shadowReq.Thresholds = s.thresholds
+
+	record := ShadowDecision{
+		ExecutionID:      req.ExecutionID,
+		PrimaryDecision:  primary.Decision,
+		PrimaryRiskScore: primary.RiskAssessment.OverallRiskScore,
+		RecordedAt:       s.now(),
+	}
+
+	shadow, err := s.engine.MakeDecision(ctx, &shadowReq)
+	if err != nil {
+		record.Error = err.Error()
+		log.WithError(err).Warn("shadow decision failed", "execution_id", req.ExecutionID.String())
+	} else {
+		record.ShadowDecision = shadow.Decision
+		record.ShadowRiskScore = shadow.RiskAssessment.OverallRiskScore
+		record.Diverged = shadow.Decision != primary.Decision
+
+		log.Info("shadow decision evaluated",
+			"execution_id", req.ExecutionID.String(),
+			"primary_decision", string(primary.Decision),
+			"shadow_decision", string(shadow.Decision),
+			"diverged", record.Diverged,
+		)
+	}
+
+	if recordErr := s.store.Record(ctx, record); recordErr != nil {
+		log.WithError(recordErr).Error("failed to record shadow decision")
+	}
+}
+
+// NewShadowDivergencesHTTPHandler returns the handler for GET /api/v1/shadow/divergences
+// listing the shadow decisions that differed from the primary decision.
+func NewShadowDivergencesHTTPHandler(store *MemoryShadowStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		divergences := store.Divergences()
+		if divergences == nil {
+			divergences = []ShadowDecision{}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		if err := json.NewEncoder(w).Encode(divergences); err != nil {
+			util.Log(r.Context()).WithError(err).Error("failed to encode shadow divergences")
+		}
+	}
+}
@@ -0,0 +1,195 @@
+//nolint:testpackage // white-box testing requires internal package access
+package review
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	appconfig "github.com/antinvestor/builder/apps/reviewer/config"
+	"github.com/antinvestor/builder/internal/events"
+)
+
+type stubSecurityAnalyzer struct{}
+
+func (stubSecurityAnalyzer) Analyze(context.Context, *SecurityAnalysisRequest) (*events.SecurityAssessment, error) {
+	return newCleanSecurityAssessment(), nil
+}
+
+type stubArchitectureAnalyzer struct{}
+
+func (stubArchitectureAnalyzer) Analyze(
+	context.Context,
+	*ArchitectureAnalysisRequest,
+) (*events.ArchitectureAssessment, error) {
+	return newCleanArchitectureAssessment(), nil
+}
+
+type failingDecisionEngine struct{}
+
+func (failingDecisionEngine) MakeDecision(context.Context, *DecisionRequest) (*DecisionResult, error) {
+	return nil, errors.New("shadow engine unavailable")
+}
+
+// newShadowTestHandler builds a handler whose primary thresholds approve the
+// passing test result with 80% coverage.
+func newShadowTestHandler(shadow *ShadowEvaluator) (*RequestHandler, *mockEventsEmitter) {
+	cfg := &appconfig.ReviewerConfig{
+		MaxRiskScore:         50,
+		MaxSecurityRiskScore: 30,
+		MaxHighIssues:        2,
+		MaxIterations:        3,
+	}
+	emitter := &mockEventsEmitter{}
+	handler := NewRequestHandler(
+		cfg,
+		stubSecurityAnalyzer{},
+		stubArchitectureAnalyzer{},
+		NewThresholdDecisionEngine(cfg),
+		NewDefaultKillSwitchService(cfg, emitter),
+		emitter,
+	)
+	if shadow != nil {
+		handler.SetShadowEvaluator(shadow)
+	}
+	return handler, emitter
+}
+
+func shadowReviewPayload(t *testing.T, executionID events.ExecutionID) []byte {
+	t.Helper()
+	payload, err := json.Marshal(&events.ComprehensiveReviewRequestedPayload{
+		ExecutionID: executionID,
+		TestResults: newPassingTestResult(),
+		Patches: []events.PatchReference{
+			{FilePath: "internal/api/handler.go", ChangeType: "modify", DiffContent: "+func Handle() {}"},
+		},
+	})
+	require.NoError(t, err)
+	return payload
+}
+
+func emittedDecision(t *testing.T, emitter *mockEventsEmitter) events.ControlDecision {
+	t.Helper()
+	require.Len(t, emitter.emittedEvents, 1)
+	completed, ok := emitter.emittedEvents[0].payload.(*events.ComprehensiveReviewCompletedPayload)
+	require.True(t, ok)
+	return completed.Decision
+}
+
+func TestRequestHandler_ShadowDivergenceRecorded(t *testing.T) {
+	cfg := &appconfig.ReviewerConfig{}
+	store := NewMemoryShadowStore()
+	strictThresholds := events.ReviewThresholds{
+		MaxRiskScore:    50,
+		MinTestCoverage: 95, // the 80% coverage result fails the shadow configuration
+		MaxHighIssues:   2,
+		MaxIterations:   3,
+	}
+	handler, emitter := newShadowTestHandler(
+		NewShadowEvaluator(NewThresholdDecisionEngine(cfg), strictThresholds, store))
+
+	executionID := events.NewExecutionID()
+	require.NoError(t, handler.Handle(context.Background(), nil, shadowReviewPayload(t, executionID)))
+
+	// The primary decision governs the emitted result
+	assert.Equal(t, events.ControlDecisionApprove, emittedDecision(t, emitter))
+
+	decisions := store.Decisions()
+	require.Len(t, decisions, 1)
+	assert.Equal(t, executionID, decisions[0].ExecutionID)
+	assert.Equal(t, events.ControlDecisionApprove, decisions[0].PrimaryDecision)
+	assert.Equal(t, events.ControlDecisionIterate, decisions[0].ShadowDecision)
+	assert.True(t, decisions[0].Diverged)
+	assert.Len(t, store.Divergences(), 1)
+}
+
+func TestRequestHandler_ShadowAgreementNotDivergent(t *testing.T) {
+	cfg := &appconfig.ReviewerConfig{}
+	store := NewMemoryShadowStore()
+	sameThresholds := events.ReviewThresholds{MaxRiskScore: 50, MaxHighIssues: 2, MaxIterations: 3}
+	handler, emitter := newShadowTestHandler(
+		NewShadowEvaluator(NewThresholdDecisionEngine(cfg), sameThresholds, store))
+
+	require.NoError(t, handler.Handle(context.Background(), nil, shadowReviewPayload(t, events.NewExecutionID())))
+
+	assert.Equal(t, events.ControlDecisionApprove, emittedDecision(t, emitter))
+	require.Len(t, store.Decisions(), 1)
+	assert.False(t, store.Decisions()[0].Diverged)
+	assert.Empty(t, store.Divergences())
+}
+
+func TestRequestHandler_ShadowFailureDoesNotAffectDecision(t *testing.T) {
+	store := NewMemoryShadowStore()
+	handler, emitter := newShadowTestHandler(
+		NewShadowEvaluator(failingDecisionEngine{}, events.ReviewThresholds{MaxRiskScore: 10}, store))
+
+	require.NoError(t, handler.Handle(context.Background(), nil, shadowReviewPayload(t, events.NewExecutionID())))
+
+	assert.Equal(t, events.ControlDecisionApprove, emittedDecision(t, emitter))
+	decisions := store.Decisions()
+	require.Len(t, decisions, 1)
+	assert.Contains(t, decisions[0].Error, "shadow engine unavailable")
+	assert.False(t, decisions[0].Diverged)
+}
+
+func TestRequestHandler_EmittedDecisionMatchesWithoutShadow(t *testing.T) {
+	withoutShadow, plainEmitter := newShadowTestHandler(nil)
+	require.NoError(t, withoutShadow.Handle(context.Background(), nil, shadowReviewPayload(t, events.NewExecutionID())))
+
+	strict := events.ReviewThresholds{MaxRiskScore: 1, MinTestCoverage: 99, MaxIterations: 3}
+	withShadow, shadowEmitter := newShadowTestHandler(
+		NewShadowEvaluator(NewThresholdDecisionEngine(&appconfig.ReviewerConfig{}), strict, NewMemoryShadowStore()))
+	require.NoError(t, withShadow.Handle(context.Background(), nil, shadowReviewPayload(t, events.NewExecutionID())))
+
+	plain, ok := plainEmitter.emittedEvents[0].payload.(*events.ComprehensiveReviewCompletedPayload)
+	require.True(t, ok)
+	shadowed, ok := shadowEmitter.emittedEvents[0].payload.(*events.ComprehensiveReviewCompletedPayload)
+	require.True(t, ok)
+	assert.Equal(t, plain.Decision, shadowed.Decision)
+	assert.Equal(t, plain.RiskAssessment, shadowed.RiskAssessment)
+	assert.Equal(t, plain.DecisionRationale, shadowed.DecisionRationale)
+}
+
+func TestShadowDivergencesHTTPHandler(t *testing.T) {
+	store := NewMemoryShadowStore()
+	_ = store.Record(context.Background(), ShadowDecision{
+		PrimaryDecision: events.ControlDecisionApprove,
+		ShadowDecision:  events.ControlDecisionApprove,
+	})
+	_ = store.Record(context.Background(), ShadowDecision{
+		PrimaryDecision: events.ControlDecisionApprove,
+		ShadowDecision:  events.ControlDecisionIterate,
+		Diverged:        true,
+	})
+
+	rec := httptest.NewRecorder()
+	NewShadowDivergencesHTTPHandler(store)(rec, httptest.NewRequest(http.MethodGet, "/api/v1/shadow/divergences", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var divergences []ShadowDecision
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &divergences))
+	require.Len(t, divergences, 1)
+	assert.Equal(t, events.ControlDecisionIterate, divergences[0].ShadowDecision)
+
+	rec = httptest.NewRecorder()
+	NewShadowDivergencesHTTPHandler(store)(rec, httptest.NewRequest(http.MethodPost, "/api/v1/shadow/divergences", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+}
+
+func TestMemoryShadowStore_KeepsMostRecentDecisions(t *testing.T) {
+	store := NewMemoryShadowStore()
+	for i := range maxShadowDecisions + 10 {
+		require.NoError(t, store.Record(context.Background(), ShadowDecision{PrimaryRiskScore: i}))
+	}
+
+	decisions := store.Decisions()
+	require.Len(t, decisions, maxShadowDecisions)
+	assert.Equal(t, 10, decisions[0].PrimaryRiskScore, "the oldest decisions are dropped first")
+	assert.Equal(t, maxShadowDecisions+9, decisions[len(decisions)-1].PrimaryRiskScore)
+}
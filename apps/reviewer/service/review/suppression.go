@@ -0,0 +1,82 @@
+package review
+
+import (
+	"regexp"
+	"strings"
+)
+
+// suppressionDirective captures the finding types named by a
+// builder:ignore-security comment; none means every type.
+var suppressionDirective = regexp.MustCompile(`builder:ignore-security\b([\w\s,]*)`)
+
+// suppressions maps a line number to the finding types suppressed on it. An
+// empty list suppresses every type.
+type suppressions map[int][]string
+
+// commentMarkers returns the line comment syntax of a language, as named by
+// detectLanguage. Both common markers are accepted when the language is unknown.
+func commentMarkers(language string) []string {
+	switch language {
+	case langPython, "ruby":
+		return []string{"#"}
+	case "php":
+		return []string{"//", "#"}
+	case langGo, langJavaScript, langTypeScript, langJava, langRust, "csharp":
+		return []string{"//"}
+	default:
+		return []string{"//", "#"}
+	}
+}
+
+// parseSuppressions finds the builder:ignore-security comments in the content.
+// A comment suppresses findings on its own line and, when it is the only thing
+// on its line, on the line that follows.
+func parseSuppressions(content, language string) suppressions {
+	if !strings.Contains(content, "builder:ignore-security") {
+		return nil
+	}
+
+	markers := commentMarkers(language)
+	found := make(suppressions)
+	for i, line := range strings.Split(content, "\n") {
+		for _, marker := range markers {
+			idx := strings.Index(line, marker)
+			if idx < 0 {
+				continue
+			}
+			match := suppressionDirective.FindStringSubmatch(line[idx+len(marker):])
+			if match == nil {
+				continue
+			}
+			types := strings.FieldsFunc(match[1], func(r rune) bool {
+				return r == ',' || r == ' ' || r == '\t'
+			})
+			lineNumber := i + 1
+			found.add(lineNumber, types)
+			if strings.TrimSpace(line[:idx]) == "" {
+				found.add(lineNumber+1, types)
+			}
+			break
+		}
+	}
+	return found
+}
+
+// add suppresses the types on a line, keeping any suppressed there already.
+func (s suppressions) add(line int, types []string) {
+	existing, ok := s[line]
+	if ok && (len(existing) == 0 || len(types) == 0) {
+		s[line] = nil
+		return
+	}
+	s[line] = append(existing, types...)
+}
+
+// suppresses reports whether a finding of the given type on the line is suppressed.
+func (s suppressions) suppresses(line int, findingType string) bool {
+	types, ok := s[line]
+	if !ok {
+		return false
+	}
+	return len(types) == 0 || containsString(types, findingType)
+}
@@ -0,0 +1,88 @@
+package review //nolint:testpackage // white-box testing requires internal access
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/antinvestor/builder/internal/events"
+)
+
+const goSQLInjection = `query := fmt.Sprintf("SELECT * FROM users WHERE id = %s", id)`
+
+func TestPatternSecurityAnalyzer_InlineSuppression(t *testing.T) {
+	analyzer := NewPatternSecurityAnalyzer(nil)
+
+	tests := []struct {
+		name           string
+		filePath       string
+		content        string
+		wantPatterns   []events.InsecurePatternType
+		wantSuppressed int
+	}{
+		{
+			name:           "same-line suppression",
+			filePath:       "internal/store/users.go",
+			content:        goSQLInjection + " // builder:ignore-security sql_injection\n",
+			wantSuppressed: 1,
+		},
+		{
+			name:           "preceding-line suppression",
+			filePath:       "internal/store/users.go",
+			content:        "\t// builder:ignore-security sql_injection\n\t" + goSQLInjection + "\n",
+			wantSuppressed: 1,
+		},
+		{
+			name:         "mismatched suppression type still reports",
+			filePath:     "internal/store/users.go",
+			content:      goSQLInjection + " // builder:ignore-security xss\n",
+			wantPatterns: []events.InsecurePatternType{events.InsecurePatternSQLInjection},
+		},
+		{
+			name:         "suppression does not reach past the next line",
+			filePath:     "internal/store/users.go",
+			content:      "// builder:ignore-security\n\n" + goSQLInjection + "\n",
+			wantPatterns: []events.InsecurePatternType{events.InsecurePatternSQLInjection},
+		},
+		{
+			name:           "Python comment syntax",
+			filePath:       "app/users.py",
+			content:        "cursor.execute(f\"SELECT * FROM users WHERE id={uid}\")  # builder:ignore-security\n",
+			wantSuppressed: 1,
+		},
+		{
+			name:         "comment syntax of another language is ignored",
+			filePath:     "app/users.py",
+			content:      "cursor.execute(f\"SELECT * FROM users WHERE id={uid}\")  // builder:ignore-security\n",
+			wantPatterns: []events.InsecurePatternType{events.InsecurePatternSQLInjection},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assessment, err := analyzer.Analyze(context.Background(), &SecurityAnalysisRequest{
+				FileContents: map[string]string{tt.filePath: tt.content},
+			})
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.wantPatterns, patternTypes(assessment.InsecurePatterns))
+			assert.Equal(t, tt.wantSuppressed, assessment.SuppressedCount)
+		})
+	}
+}
+
+func TestPatternSecurityAnalyzer_UnqualifiedSuppressionCoversSecrets(t *testing.T) {
+	analyzer := NewPatternSecurityAnalyzer(nil)
+	content := "package client\n\n" +
+		"// builder:ignore-security\n" +
+		"var sessionSeed = \"" + randomToken + "\"\n" +
+		"var otherSeed = \"" + randomToken + "\" // builder:ignore-security sql_injection\n"
+
+	secrets, suppressed := analyzer.findSecrets("internal/client/client.go", content)
+
+	require.Len(t, secrets, 1, "a suppression of another type keeps the secret")
+	assert.Equal(t, 5, secrets[0].LineNumber)
+	assert.Equal(t, 1, suppressed)
+}
@@ -0,0 +1,246 @@
+package review
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/antinvestor/builder/internal/events"
+)
+
+// testDeclarationPatterns capture the name of a test declared on a line.
+var testDeclarationPatterns = []*regexp.Regexp{
+	// Go: func TestX(t *testing.T), including suite methods
+	regexp.MustCompile(`^\s*func\s+(?:\([^)]*\)\s*)?((?:Test|Benchmark|Fuzz)\w*)\s*\(`),
+	// Python: def test_x(
+	regexp.MustCompile(`^\s*(?:async\s+)?def\s+(test\w*)\s*\(`),
+	// JavaScript/TypeScript: it("does x", ...) / test("does x", ...)
+	regexp.MustCompile("^\\s*(?:it|test)\\s*\\(\\s*['\"`]([^'\"`]+)['\"`]"),
+}
+
+// testSkipPattern matches statements and annotations that disable a test.
+var testSkipPattern = regexp.MustCompile(
+	`\bt\.Skip(?:Now|f)?\(|@pytest\.mark\.skip|\bpytest\.skip\(|@unittest\.skip|` +
+		`\b(?:it|test|describe)\.skip\(|\bx(?:it|describe|test)\(|@Disabled\b|@Ignore\b|#\[ignore\]`,
+)
+
+// isSkipAnnotation reports whether a skip marker decorates the declaration that follows it.
+func isSkipAnnotation(line string) bool {
+	trimmed := strings.TrimSpace(line)
+	return strings.HasPrefix(trimmed, "@") || strings.HasPrefix(trimmed, "#[")
+}
+
+// hunkHeaderContext extracts the enclosing declaration git prints after a hunk header.
+var hunkHeaderContext = regexp.MustCompile(`^@@[^@]*@@\s?(.*)$`)
+
+// isTestFilePath reports whether a path follows a common test file naming convention.
+func isTestFilePath(filePath string) bool {
+	base := strings.ToLower(path.Base(filePath))
+	switch {
+	case strings.HasSuffix(base, "_test.go"),
+		strings.HasPrefix(base, "test_") && strings.HasSuffix(base, ".py"),
+		strings.HasSuffix(base, "_test.py"),
+		strings.Contains(base, ".test."), strings.Contains(base, ".spec."):
+		return true
+	default:
+		return false
+	}
+}
+
+// testDeclaration returns the test declared on line, if any.
+func testDeclaration(line string) (string, bool) {
+	for _, pattern := range testDeclarationPatterns {
+		if match := pattern.FindStringSubmatch(line); match != nil {
+			return match[1], true
+		}
+	}
+	return "", false
+}
+
+// detectTestRegressions flags tests removed or newly skipped in changed test files.
+// Full contents are compared when available; otherwise the unified diff is used.
+func detectTestRegressions(patches []events.Patch, baseline, current map[string]string) []events.TestRegression {
+	var regressions []events.TestRegression
+	compared := make(map[string]bool)
+
+	for _, patch := range patches {
+		if !isTestFilePath(patch.FilePath) {
+			continue
+		}
+		before, hasBefore := baseline[patch.FilePath]
+		after, hasAfter := current[patch.FilePath]
+		if hasBefore && (hasAfter || patch.Action == events.FileActionDelete) {
+			regressions = append(regressions, compareTestContents(patch.FilePath, before, after)...)
+			compared[patch.FilePath] = true
+			continue
+		}
+		regressions = append(regressions, diffTestRegressions(patch.FilePath, patch.DiffContent)...)
+		compared[patch.FilePath] = true
+	}
+
+	// Files supplied as contents without a patch
+	for filePath, before := range baseline {
+		after, ok := current[filePath]
+		if compared[filePath] || !ok || !isTestFilePath(filePath) {
+			continue
+		}
+		regressions = append(regressions, compareTestContents(filePath, before, after)...)
+	}
+
+	return regressions
+}
+
+// compareTestContents compares the tests and skips of a file before and after a change.
+func compareTestContents(filePath, before, after string) []events.TestRegression {
+	beforeSkips := skipsByTest(before)
+	afterSkips := skipsByTest(after)
+	afterTests := declaredTests(after)
+
+	var regressions []events.TestRegression
+	for _, name := range declaredTests(before) {
+		if !slices.Contains(afterTests, name) {
+			regressions = append(regressions, removedTestRegression(filePath, name))
+		}
+	}
+	for _, name := range afterTests {
+		if afterSkips[name] > beforeSkips[name] {
+			regressions = append(regressions, skippedTestRegression(filePath, name))
+		}
+	}
+	return regressions
+}
+
+// diffTestRegressions flags test declarations removed by a diff and skips it adds.
+func diffTestRegressions(filePath, diff string) []events.TestRegression {
+	removed := make(map[string]bool)
+	var removedOrder []string
+	added := make(map[string]bool)
+	skipped := make(map[string]bool)
+	var skippedOrder []string
+	markSkipped := func(name string) {
+		if !skipped[name] {
+			skipped[name] = true
+			skippedOrder = append(skippedOrder, name)
+		}
+	}
+	enclosing := ""
+	pendingAnnotation := false
+
+	for line := range strings.SplitSeq(diff, "\n") {
+		switch {
+		case strings.HasPrefix(line, "+++"), strings.HasPrefix(line, "---"):
+			continue
+		case strings.HasPrefix(line, "@@"):
+			enclosing = ""
+			if match := hunkHeaderContext.FindStringSubmatch(line); match != nil {
+				if name, ok := testDeclaration(match[1]); ok {
+					enclosing = name
+				}
+			}
+			continue
+		}
+
+		body := line
+		marker := byte(' ')
+		if line != "" {
+			marker, body = line[0], line[1:]
+		}
+
+		if name, ok := testDeclaration(body); ok {
+			switch marker {
+			case '-':
+				if !removed[name] {
+					removed[name] = true
+					removedOrder = append(removedOrder, name)
+				}
+				continue
+			case '+':
+				added[name] = true
+			}
+			enclosing = name
+			if pendingAnnotation {
+				markSkipped(name)
+				pendingAnnotation = false
+			}
+		}
+
+		if marker == '+' && testSkipPattern.MatchString(body) {
+			if isSkipAnnotation(body) {
+				pendingAnnotation = true
+			} else {
+				markSkipped(enclosing)
+			}
+		}
+	}
+
+	var regressions []events.TestRegression
+	for _, name := range removedOrder {
+		if !added[name] {
+			regressions = append(regressions, removedTestRegression(filePath, name))
+		}
+	}
+	for _, name := range skippedOrder {
+		regressions = append(regressions, skippedTestRegression(filePath, name))
+	}
+	return regressions
+}
+
+// declaredTests returns the tests declared in content, in order.
+func declaredTests(content string) []string {
+	var names []string
+	for line := range strings.SplitSeq(content, "\n") {
+		if name, ok := testDeclaration(line); ok {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// skipsByTest counts skip markers per test. Skip annotations count towards the
+// declaration that follows them; other skips outside any test are counted under "".
+func skipsByTest(content string) map[string]int {
+	skips := make(map[string]int)
+	enclosing := ""
+	pendingAnnotations := 0
+	for line := range strings.SplitSeq(content, "\n") {
+		if name, ok := testDeclaration(line); ok {
+			enclosing = name
+			skips[name] += pendingAnnotations
+			pendingAnnotations = 0
+		}
+		if testSkipPattern.MatchString(line) {
+			if isSkipAnnotation(line) {
+				pendingAnnotations++
+			} else {
+				skips[enclosing]++
+			}
+		}
+	}
+	return skips
+}
+
+func removedTestRegression(filePath, name string) events.TestRegression {
+	return events.TestRegression{
+		RegressionType: events.TestRegressionRemoved,
+		FilePath:       filePath,
+		TestName:       name,
+		Description:    fmt.Sprintf("Test %s was removed", name),
+		Severity:       events.ReviewIssueSeverityHigh,
+	}
+}
+
+func skippedTestRegression(filePath, name string) events.TestRegression {
+	description := "A test skip was added"
+	if name != "" {
+		description = fmt.Sprintf("Test %s is now skipped", name)
+	}
+	return events.TestRegression{
+		RegressionType: events.TestRegressionSkipped,
+		FilePath:       filePath,
+		TestName:       name,
+		Description:    description,
+		Severity:       events.ReviewIssueSeverityHigh,
+	}
+}
@@ -0,0 +1,181 @@
+package review //nolint:testpackage // white-box testing requires internal access
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/antinvestor/builder/internal/events"
+)
+
+func TestDetectTestRegressions_FromDiff(t *testing.T) {
+	tests := []struct {
+		name  string
+		patch events.Patch
+		want  []events.TestRegression
+	}{
+		{
+			name: "removed Go test",
+			patch: events.Patch{
+				FilePath: "service/order_test.go",
+				Action:   events.FileActionModify,
+				DiffContent: `--- a/service/order_test.go
++++ b/service/order_test.go
+@@ -10,9 +10,0 @@ func TestCreateOrder(t *testing.T) {
+-func TestCancelOrder(t *testing.T) {
+-	require.NoError(t, cancel())
+-}
+`,
+			},
+			want: []events.TestRegression{{
+				RegressionType: events.TestRegressionRemoved,
+				FilePath:       "service/order_test.go",
+				TestName:       "TestCancelOrder",
+				Description:    "Test TestCancelOrder was removed",
+				Severity:       events.ReviewIssueSeverityHigh,
+			}},
+		},
+		{
+			name: "newly skipped Go test",
+			patch: events.Patch{
+				FilePath: "service/order_test.go",
+				Action:   events.FileActionModify,
+				DiffContent: `@@ -20,3 +20,4 @@ func TestRefundOrder(t *testing.T) {
+ 	order := newOrder()
++	t.Skip("flaky")
+ 	require.NoError(t, refund(order))
+`,
+			},
+			want: []events.TestRegression{{
+				RegressionType: events.TestRegressionSkipped,
+				FilePath:       "service/order_test.go",
+				TestName:       "TestRefundOrder",
+				Description:    "Test TestRefundOrder is now skipped",
+				Severity:       events.ReviewIssueSeverityHigh,
+			}},
+		},
+		{
+			name: "newly skipped pytest test",
+			patch: events.Patch{
+				FilePath: "tests/test_orders.py",
+				Action:   events.FileActionModify,
+				DiffContent: `@@ -4,2 +4,3 @@
++@pytest.mark.skip(reason="broken")
+ def test_refund():
+     assert refund()
+`,
+			},
+			want: []events.TestRegression{{
+				RegressionType: events.TestRegressionSkipped,
+				FilePath:       "tests/test_orders.py",
+				TestName:       "test_refund",
+				Description:    "Test test_refund is now skipped",
+				Severity:       events.ReviewIssueSeverityHigh,
+			}},
+		},
+		{
+			name: "edited test is not a regression",
+			patch: events.Patch{
+				FilePath: "service/order_test.go",
+				Action:   events.FileActionModify,
+				DiffContent: `@@ -1,3 +1,3 @@
+-func TestCreateOrder(t *testing.T) {
++func TestCreateOrder(t *testing.T) { // covers defaults
+ 	require.NoError(t, create())
+ }
+`,
+			},
+		},
+		{
+			name: "skip in non-test file is ignored",
+			patch: events.Patch{
+				FilePath:    "service/order.go",
+				Action:      events.FileActionModify,
+				DiffContent: "@@ -1,1 +1,2 @@\n+\tt.Skip()\n",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := detectTestRegressions([]events.Patch{tt.patch}, nil, nil)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestDetectTestRegressions_FromContents(t *testing.T) {
+	baseline := map[string]string{"orders.spec.ts": `
+it("creates an order", () => {
+  expect(create()).toBeTruthy();
+});
+it("refunds an order", () => {
+  expect(refund()).toBeTruthy();
+});
+`}
+	current := map[string]string{"orders.spec.ts": `
+it("creates an order", () => {
+  expect(create()).toBeTruthy();
+});
+`}
+
+	got := detectTestRegressions(nil, baseline, current)
+
+	require.Len(t, got, 1)
+	assert.Equal(t, events.TestRegressionRemoved, got[0].RegressionType)
+	assert.Equal(t, "refunds an order", got[0].TestName)
+}
+
+func TestDetectTestRegressions_DeletedTestFile(t *testing.T) {
+	patch := events.Patch{FilePath: "order_test.go", Action: events.FileActionDelete}
+	baseline := map[string]string{"order_test.go": "func TestA(t *testing.T) {}\nfunc TestB(t *testing.T) {}\n"}
+
+	got := detectTestRegressions([]events.Patch{patch}, baseline, nil)
+
+	require.Len(t, got, 2)
+	assert.Equal(t, "TestA", got[0].TestName)
+	assert.Equal(t, "TestB", got[1].TestName)
+}
+
+func TestPatternArchitectureAnalyzer_FlagsTestRegressions(t *testing.T) {
+	analyzer := NewPatternArchitectureAnalyzer(nil)
+
+	assessment, err := analyzer.Analyze(context.Background(), &ArchitectureAnalysisRequest{
+		Patches: []events.Patch{{
+			FilePath:    "order_test.go",
+			Action:      events.FileActionModify,
+			DiffContent: "@@ -1,3 +1,0 @@\n-func TestCancelOrder(t *testing.T) {\n-}\n",
+		}},
+	})
+
+	require.NoError(t, err)
+	require.Len(t, assessment.TestRegressions, 1)
+	assert.Equal(t, events.ArchitectureStatusViolations, assessment.ArchitectureStatus)
+}
+
+func TestThresholdDecisionEngine_TestRegressionsBlockApproval(t *testing.T) {
+	engine := newTestDecisionEngine()
+	arch := newCleanArchitectureAssessment()
+	arch.TestRegressions = []events.TestRegression{{
+		RegressionType: events.TestRegressionSkipped,
+		FilePath:       "order_test.go",
+		TestName:       "TestRefundOrder",
+		Description:    "Test TestRefundOrder is now skipped",
+		Severity:       events.ReviewIssueSeverityHigh,
+	}}
+
+	result, err := engine.MakeDecision(context.Background(), &DecisionRequest{
+		ExecutionID:            events.NewExecutionID(),
+		SecurityAssessment:     newCleanSecurityAssessment(),
+		ArchitectureAssessment: arch,
+		TestResult:             newPassingTestResult(),
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, events.ControlDecisionIterate, result.Decision)
+	require.Len(t, result.BlockingIssues, 1)
+	assert.Equal(t, events.ReviewIssueSeverityHigh, result.BlockingIssues[0].Severity)
+	assert.Contains(t, result.Warnings, "1 tests removed or disabled")
+}
@@ -0,0 +1,59 @@
+package review
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/antinvestor/builder/internal/events"
+)
+
+// Bounds of the test risk added for weak test evidence.
+const (
+	skippedTestsMaxRisk = 50
+	slowTestsRiskStep   = 5
+	slowTestsMaxRisk    = 20
+)
+
+// testSignal is a sign that a passing test run proves less than it appears to.
+type testSignal struct {
+	factor  string
+	warning string
+	risk    int
+}
+
+// testSignals returns the weak-evidence signs of a passing test run: a share of
+// skipped tests above MaxSkippedTestRatio, or an average test duration above
+// SlowTestThresholdMs. Skipped tests add risk in proportion to their share, up to
+// skippedTestsMaxRisk; slow runs add slowTestsRiskStep per multiple of the
+// threshold, up to slowTestsMaxRisk.
+func (e *ThresholdDecisionEngine) testSignals(result *events.TestResult) []testSignal {
+	if result == nil || !result.Success || result.TotalTests <= 0 {
+		return nil
+	}
+
+	var signals []testSignal
+	if limit := e.cfg.MaxSkippedTestRatio; limit > 0 {
+		ratio := float64(result.SkippedTests) / float64(result.TotalTests)
+		if ratio > limit {
+			warning := fmt.Sprintf("%.0f%% of tests skipped", ratio*100) //nolint:mnd // ratio to percent
+			signals = append(signals, testSignal{
+				factor:  fmt.Sprintf("%s (%d/%d)", warning, result.SkippedTests, result.TotalTests),
+				warning: warning,
+				risk:    int(math.Round(ratio * skippedTestsMaxRisk)),
+			})
+		}
+	}
+
+	if limit := e.cfg.SlowTestThresholdMs; limit > 0 {
+		average := result.DurationMs / int64(result.TotalTests)
+		if average > limit {
+			warning := fmt.Sprintf("Tests averaged %dms each, above the %dms threshold", average, limit)
+			signals = append(signals, testSignal{
+				factor:  fmt.Sprintf("Slow tests: %d tests in %dms", result.TotalTests, result.DurationMs),
+				warning: warning,
+				risk:    min(int(average/limit)*slowTestsRiskStep, slowTestsMaxRisk),
+			})
+		}
+	}
+	return signals
+}
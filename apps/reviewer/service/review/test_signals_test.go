@@ -0,0 +1,97 @@
+package review //nolint:testpackage // white-box testing requires internal access
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/antinvestor/builder/internal/events"
+)
+
+func newTestSignalsEngine() *ThresholdDecisionEngine {
+	engine := newTestDecisionEngine()
+	engine.cfg.MaxSkippedTestRatio = 0.2
+	engine.cfg.SlowTestThresholdMs = 2000
+	return engine
+}
+
+func testRiskFactors(ra events.RiskAssessment) []events.RiskFactor {
+	var factors []events.RiskFactor
+	for _, factor := range ra.RiskFactors {
+		if factor.Category == events.RiskCategoryTestCoverage {
+			factors = append(factors, factor)
+		}
+	}
+	return factors
+}
+
+func TestThresholdDecisionEngine_SkippedTestsAddRisk(t *testing.T) {
+	tests := []struct {
+		name        string
+		skipped     int
+		wantRisk    int
+		wantWarning string
+	}{
+		{name: "all tests skipped", skipped: 50, wantRisk: 50, wantWarning: "100% of tests skipped"},
+		{name: "mostly skipped", skipped: 42, wantRisk: 42, wantWarning: "84% of tests skipped"},
+		{name: "under the skipped limit", skipped: 10},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := newCleanDecisionRequest()
+			req.TestResult = &events.TestResult{
+				TotalTests:   50,
+				PassedTests:  50 - tt.skipped,
+				SkippedTests: tt.skipped,
+				Success:      true,
+				DurationMs:   5000,
+			}
+
+			result, err := newTestSignalsEngine().MakeDecision(context.Background(), req)
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.wantRisk, result.RiskAssessment.TestRiskScore)
+			// Security and architecture are clean, so the test risk is averaged over three components
+			assert.Equal(t, tt.wantRisk/3, result.RiskAssessment.OverallRiskScore)
+			factors := testRiskFactors(result.RiskAssessment)
+			if tt.wantWarning == "" {
+				assert.Empty(t, factors)
+				assert.Empty(t, result.Warnings)
+				return
+			}
+			require.Len(t, factors, 1)
+			assert.Equal(t, tt.wantRisk, factors[0].Contribution)
+			assert.Contains(t, result.Warnings, tt.wantWarning)
+		})
+	}
+}
+
+func TestThresholdDecisionEngine_SlowTestsAddBoundedRisk(t *testing.T) {
+	engine := newTestSignalsEngine()
+
+	req := newCleanDecisionRequest()
+	req.TestResult = &events.TestResult{TotalTests: 4, PassedTests: 4, Success: true, DurationMs: 4 * 6000}
+	ra := engine.calculateRiskAssessment(req, engine.getThresholds(req))
+	assert.Equal(t, 15, ra.TestRiskScore, "three times the threshold")
+
+	req.TestResult.DurationMs = 4 * 60000
+	ra = engine.calculateRiskAssessment(req, engine.getThresholds(req))
+	assert.Equal(t, slowTestsMaxRisk, ra.TestRiskScore)
+	require.Len(t, testRiskFactors(ra), 1)
+	assert.Equal(t, "Slow tests: 4 tests in 240000ms", testRiskFactors(ra)[0].Factor)
+}
+
+func TestThresholdDecisionEngine_TestSignalsIgnoredForFailingRuns(t *testing.T) {
+	req := newCleanDecisionRequest()
+	req.TestResult = &events.TestResult{TotalTests: 10, FailedTests: 1, SkippedTests: 9, DurationMs: 100000}
+
+	engine := newTestSignalsEngine()
+	ra := engine.calculateRiskAssessment(req, engine.getThresholds(req))
+
+	assert.Equal(t, maxScore, ra.TestRiskScore)
+	require.Len(t, testRiskFactors(ra), 1)
+	assert.Equal(t, "Tests failing", testRiskFactors(ra)[0].Factor)
+}
@@ -0,0 +1,146 @@
+package review
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/pitabwire/util"
+
+	appconfig "github.com/antinvestor/builder/apps/reviewer/config"
+	"github.com/antinvestor/builder/internal/events"
+)
+
+// defaultThresholdsReloadInterval is used when no reload interval is configured.
+const defaultThresholdsReloadInterval = 30 * time.Second
+
+// ErrInvalidThresholds is returned when a thresholds source contains unusable values.
+var ErrInvalidThresholds = errors.New("invalid review thresholds")
+
+// ThresholdsReloader watches a thresholds file and hot-reloads it into the reviewer config.
+// Decisions read thresholds through ReviewerConfig.GetReviewThresholds, so a reload
+// applies to the next decision while in-flight decisions keep their snapshot.
+type ThresholdsReloader struct {
+	cfg      *appconfig.ReviewerConfig
+	path     string
+	interval time.Duration
+	modTime  time.Time
+
+	stopCh    chan struct{}
+	stoppedCh chan struct{}
+}
+
+// NewThresholdsReloader creates a reloader for the configured thresholds file.
+func NewThresholdsReloader(cfg *appconfig.ReviewerConfig) *ThresholdsReloader {
+	interval := time.Duration(cfg.ThresholdsReloadIntervalSeconds) * time.Second
+	if interval <= 0 {
+		interval = defaultThresholdsReloadInterval
+	}
+
+	return &ThresholdsReloader{
+		cfg:       cfg,
+		path:      cfg.ThresholdsFilePath,
+		interval:  interval,
+		stopCh:    make(chan struct{}),
+		stoppedCh: make(chan struct{}),
+	}
+}
+
+// Start loads the thresholds file once and then watches it for changes.
+func (r *ThresholdsReloader) Start(ctx context.Context) {
+	log := util.Log(ctx)
+
+	if _, err := r.Reload(ctx); err != nil {
+		log.WithError(err).Error("initial thresholds load failed")
+	}
+
+	go r.watch(ctx)
+}
+
+// Stop stops watching the thresholds file.
+func (r *ThresholdsReloader) Stop() {
+	close(r.stopCh)
+	<-r.stoppedCh
+}
+
+// watch periodically reloads the thresholds file.
+func (r *ThresholdsReloader) watch(ctx context.Context) {
+	defer close(r.stoppedCh)
+
+	log := util.Log(ctx)
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-r.stopCh:
+			return
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := r.Reload(ctx); err != nil {
+				log.WithError(err).Error("thresholds reload failed")
+			}
+		}
+	}
+}
+
+// Reload reads the thresholds file if it changed since the last load and applies it.
+// It reports whether new thresholds were applied. Invalid files leave the current
+// thresholds untouched.
+func (r *ThresholdsReloader) Reload(ctx context.Context) (bool, error) {
+	info, err := os.Stat(r.path)
+	if err != nil {
+		return false, fmt.Errorf("stat thresholds file: %w", err)
+	}
+
+	if !info.ModTime().After(r.modTime) {
+		return false, nil
+	}
+
+	data, err := os.ReadFile(r.path)
+	if err != nil {
+		return false, fmt.Errorf("read thresholds file: %w", err)
+	}
+
+	thresholds, err := parseThresholds(data)
+	if err != nil {
+		return false, err
+	}
+
+	r.cfg.SetReviewThresholds(thresholds)
+	r.modTime = info.ModTime()
+
+	util.Log(ctx).Info("review thresholds reloaded",
+		"path", r.path,
+		"max_risk_score", thresholds.MaxRiskScore,
+		"max_iterations", thresholds.MaxIterations,
+	)
+
+	return true, nil
+}
+
+// LoadThresholdsFile reads and validates a JSON thresholds file.
+func LoadThresholdsFile(path string) (events.ReviewThresholds, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return events.ReviewThresholds{}, fmt.Errorf("read thresholds file: %w", err)
+	}
+	return parseThresholds(data)
+}
+
+// parseThresholds decodes JSON thresholds and rejects unusable values.
+func parseThresholds(data []byte) (events.ReviewThresholds, error) {
+	var thresholds events.ReviewThresholds
+	if err := json.Unmarshal(data, &thresholds); err != nil {
+		return events.ReviewThresholds{}, fmt.Errorf("parse thresholds file: %w", err)
+	}
+
+	if thresholds.MaxRiskScore <= 0 {
+		return events.ReviewThresholds{}, fmt.Errorf("%w: max_risk_score must be positive", ErrInvalidThresholds)
+	}
+	return thresholds, nil
+}
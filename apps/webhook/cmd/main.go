@@ -52,6 +52,10 @@ func main() {
 	// Setup HTTP Server
 	// ==========================================================================
 
+	if cfg.GitHubWebhookSecret == "" {
+		log.Warn("GITHUB_WEBHOOK_SECRET is not set, webhook signatures will not be verified")
+	}
+
 	qMan := svc.QueueManager()
 	webhookHandler := handlers.NewWebhookHandler(&cfg, qMan)
 
@@ -66,6 +66,34 @@ type WebhookConfig struct {
 	// AutoTriggerLabel is the label that triggers automatic feature processing.
 	AutoTriggerLabel string `envDefault:"auto-build" env:"AUTO_TRIGGER_LABEL"`
 
+	// BuildCommandPrefix is the command that starts a build when it opens an issue
+	// comment or issue body; the text after it is the feature specification
+	// (e.g. "/build add CSV export"). Empty disables build commands.
+	BuildCommandPrefix string `envDefault:"/build" env:"BUILD_COMMAND_PREFIX"`
+
+	// DeliveryDedupTTLSeconds is how long a delivery ID is remembered so that
+	// GitHub's redeliveries of it are ignored. 0 disables deduplication.
+	DeliveryDedupTTLSeconds int `envDefault:"86400" env:"DELIVERY_DEDUP_TTL_SECONDS"`
+
+	// DeliveryDedupCacheSize is the maximum number of delivery IDs remembered;
+	// the oldest are forgotten first. 0 disables deduplication.
+	DeliveryDedupCacheSize int `envDefault:"10000" env:"DELIVERY_DEDUP_CACHE_SIZE"`
+
 	// RequiredLabels are labels that must be present for processing (comma-separated).
 	RequiredLabels string `env:"REQUIRED_LABELS"`
+
+	// ==========================================================================
+	// Webhook Authorization
+	// ==========================================================================
+
+	// WebhookAuthorizedActions maps repositories to the actions their webhooks may
+	// trigger, separated by "|" (e.g. "acme/api:issue|comment|push,acme/*:issue").
+	// Keys are "owner/repo", "owner/*" or "*"; actions are "issue", "comment",
+	// "push" or "*". When set, repositories without a matching entry are rejected.
+	WebhookAuthorizedActions map[string]string `env:"WEBHOOK_AUTHORIZED_ACTIONS"`
+
+	// WebhookAuthorizedSenders maps repositories to the GitHub users allowed to
+	// trigger them, separated by "|" (e.g. "acme/api:alice|bob,acme/*:*").
+	// When set, repositories without a matching entry are rejected.
+	WebhookAuthorizedSenders map[string]string `env:"WEBHOOK_AUTHORIZED_SENDERS"`
 }
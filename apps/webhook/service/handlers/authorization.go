@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	appconfig "github.com/antinvestor/builder/apps/webhook/config"
+)
+
+// ErrUnauthorizedSource is returned when a webhook source may not trigger an action.
+var ErrUnauthorizedSource = errors.New("webhook source not authorized")
+
+// WebhookAction is an action a webhook source can trigger.
+type WebhookAction string
+
+const (
+	// WebhookActionIssue creates a feature request from a labeled issue.
+	WebhookActionIssue WebhookAction = "issue"
+	// WebhookActionComment creates a feature request from a /build comment.
+	WebhookActionComment WebhookAction = "comment"
+	// WebhookActionPush publishes a push event to the workers.
+	WebhookActionPush WebhookAction = "push"
+)
+
+const authorizationWildcard = "*"
+
+// SourceAuthorizer decides which actions a webhook source (repository and
+// sender) may trigger. Webhook deliveries bypass the gateway's authentication,
+// so this is the only check between a GitHub event and a published feature.
+type SourceAuthorizer struct {
+	actions map[string][]string
+	senders map[string][]string
+}
+
+// NewSourceAuthorizer creates an authorizer from the webhook authorization rules.
+// Without rules every source is authorized.
+func NewSourceAuthorizer(cfg *appconfig.WebhookConfig) *SourceAuthorizer {
+	return &SourceAuthorizer{
+		actions: parseAuthorizationRules(cfg.WebhookAuthorizedActions),
+		senders: parseAuthorizationRules(cfg.WebhookAuthorizedSenders),
+	}
+}
+
+// Authorize returns ErrUnauthorizedSource when sender may not trigger action on repo.
+func (a *SourceAuthorizer) Authorize(repo, sender string, action WebhookAction) error {
+	if !allowedByRules(a.actions, repo, string(action)) {
+		return fmt.Errorf("%w: %s may not trigger %s", ErrUnauthorizedSource, repo, action)
+	}
+	if !allowedByRules(a.senders, repo, sender) {
+		return fmt.Errorf("%w: %s may not trigger %s on %s", ErrUnauthorizedSource, sender, action, repo)
+	}
+	return nil
+}
+
+// allowedByRules checks value against the most specific rule matching repo.
+// An empty rule set allows everything.
+func allowedByRules(rules map[string][]string, repo, value string) bool {
+	if len(rules) == 0 {
+		return true
+	}
+
+	allowed, ok := matchRepositoryRule(rules, strings.ToLower(repo))
+	if !ok {
+		return false
+	}
+	return slices.Contains(allowed, authorizationWildcard) || slices.Contains(allowed, strings.ToLower(value))
+}
+
+// matchRepositoryRule finds the rule for "owner/repo", then "owner/*", then "*".
+func matchRepositoryRule(rules map[string][]string, repo string) ([]string, bool) {
+	if allowed, ok := rules[repo]; ok {
+		return allowed, true
+	}
+	if owner, _, found := strings.Cut(repo, "/"); found {
+		if allowed, ok := rules[owner+"/"+authorizationWildcard]; ok {
+			return allowed, true
+		}
+	}
+	allowed, ok := rules[authorizationWildcard]
+	return allowed, ok
+}
+
+func parseAuthorizationRules(raw map[string]string) map[string][]string {
+	rules := make(map[string][]string, len(raw))
+	for key, value := range raw {
+		var allowed []string
+		for entry := range strings.SplitSeq(value, "|") {
+			if entry = strings.ToLower(strings.TrimSpace(entry)); entry != "" {
+				allowed = append(allowed, entry)
+			}
+		}
+		rules[strings.ToLower(strings.TrimSpace(key))] = allowed
+	}
+	return rules
+}
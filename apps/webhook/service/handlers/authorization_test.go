@@ -0,0 +1,168 @@
+package handlers_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/pitabwire/frame/queue"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	appconfig "github.com/antinvestor/builder/apps/webhook/config"
+	"github.com/antinvestor/builder/apps/webhook/service/handlers"
+)
+
+// recordingQueue records the payloads published to each queue.
+type recordingQueue struct {
+	queue.Manager
+
+	published map[string][][]byte
+}
+
+type recordingPublisher struct {
+	queue.Publisher
+
+	name  string
+	queue *recordingQueue
+}
+
+func (q *recordingQueue) GetPublisher(reference string) (queue.Publisher, error) {
+	return &recordingPublisher{name: reference, queue: q}, nil
+}
+
+func (p *recordingPublisher) Publish(_ context.Context, payload any, _ ...map[string]string) error {
+	data, ok := payload.([]byte)
+	if !ok {
+		return nil
+	}
+	p.queue.published[p.name] = append(p.queue.published[p.name], data)
+	return nil
+}
+
+func newAuthorizedHandler() (*handlers.WebhookHandler, *recordingQueue) {
+	cfg := &appconfig.WebhookConfig{
+		QueueFeatureRequestName: "feature.requests",
+		QueueGitHubEventName:    "github.events",
+		EnableIssueProcessing:   true,
+		EnablePushProcessing:    true,
+		AutoTriggerLabel:        "auto-build",
+		BuildCommandPrefix:      "/build",
+		WebhookAuthorizedActions: map[string]string{
+			"acme/api": "issue|comment|push",
+			"acme/*":   "issue",
+		},
+		WebhookAuthorizedSenders: map[string]string{
+			"acme/api": "alice|bob",
+			"acme/*":   "*",
+		},
+	}
+	qMan := &recordingQueue{published: map[string][][]byte{}}
+	return handlers.NewWebhookHandler(cfg, qMan), qMan
+}
+
+func deliver(t *testing.T, handler *handlers.WebhookHandler, eventType string, payload any) *httptest.ResponseRecorder {
+	t.Helper()
+	body, err := json.Marshal(payload)
+	require.NoError(t, err)
+
+	req := httptest.NewRequest(http.MethodPost, "/webhooks/github", strings.NewReader(string(body)))
+	req.Header.Set("X-GitHub-Event", eventType)
+	rec := httptest.NewRecorder()
+	handler.HandleGitHubWebhook(rec, req)
+	return rec
+}
+
+func labeledIssue(repo, sender string) map[string]any {
+	return map[string]any{
+		"action": "labeled",
+		"issue": map[string]any{
+			"number": 7,
+			"title":  "Add endpoint",
+			"labels": []map[string]string{{"name": "auto-build"}},
+		},
+		"repository": map[string]any{"full_name": repo},
+		"sender":     map[string]any{"login": sender},
+	}
+}
+
+func push(repo, sender string) map[string]any {
+	return map[string]any{
+		"ref":        "refs/heads/main",
+		"repository": map[string]any{"full_name": repo},
+		"sender":     map[string]any{"login": sender},
+	}
+}
+
+func TestWebhookAuthorization_AllowlistedSourcesPublish(t *testing.T) {
+	handler, qMan := newAuthorizedHandler()
+
+	rec := deliver(t, handler, "issues", labeledIssue("acme/api", "alice"))
+	assert.Equal(t, http.StatusAccepted, rec.Code)
+	require.Len(t, qMan.published["feature.requests"], 1)
+
+	var feature handlers.FeatureRequest
+	require.NoError(t, json.Unmarshal(qMan.published["feature.requests"][0], &feature))
+	assert.Equal(t, "acme/api", feature.Repository)
+
+	rec = deliver(t, handler, "push", push("acme/api", "bob"))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Len(t, qMan.published["github.events"], 1)
+
+	// The owner wildcard authorizes issues from any sender on other repositories
+	rec = deliver(t, handler, "issues", labeledIssue("acme/web", "carol"))
+	assert.Equal(t, http.StatusAccepted, rec.Code)
+	assert.Len(t, qMan.published["feature.requests"], 2)
+}
+
+func TestWebhookAuthorization_RejectsUnauthorizedSources(t *testing.T) {
+	handler, qMan := newAuthorizedHandler()
+
+	tests := []struct {
+		name      string
+		eventType string
+		payload   any
+	}{
+		{name: "repository not allowlisted", eventType: "issues", payload: labeledIssue("other/api", "alice")},
+		{name: "sender not allowlisted", eventType: "issues", payload: labeledIssue("acme/api", "mallory")},
+		{name: "action not allowlisted", eventType: "push", payload: push("acme/web", "alice")},
+		{
+			name:      "comment from unauthorized user",
+			eventType: "issue_comment",
+			payload: map[string]any{
+				"action":     "created",
+				"issue":      map[string]any{"number": 7, "title": "Add endpoint"},
+				"comment":    map[string]any{"body": "/build", "user": map[string]any{"login": "mallory"}},
+				"repository": map[string]any{"full_name": "acme/api"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := deliver(t, handler, tt.eventType, tt.payload)
+			assert.Equal(t, http.StatusForbidden, rec.Code)
+		})
+	}
+	assert.Empty(t, qMan.published)
+}
+
+func TestSourceAuthorizer_NoRulesAllowsAll(t *testing.T) {
+	authorizer := handlers.NewSourceAuthorizer(&appconfig.WebhookConfig{})
+
+	require.NoError(t, authorizer.Authorize("any/repo", "anyone", handlers.WebhookActionPush))
+}
+
+func TestSourceAuthorizer_MatchesCaseInsensitively(t *testing.T) {
+	authorizer := handlers.NewSourceAuthorizer(&appconfig.WebhookConfig{
+		WebhookAuthorizedActions: map[string]string{"Acme/API": "Issue"},
+		WebhookAuthorizedSenders: map[string]string{"*": "Alice"},
+	})
+
+	require.NoError(t, authorizer.Authorize("acme/api", "alice", handlers.WebhookActionIssue))
+	require.ErrorIs(t, authorizer.Authorize("acme/api", "alice", handlers.WebhookActionPush),
+		handlers.ErrUnauthorizedSource)
+}
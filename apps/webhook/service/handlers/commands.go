@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/pitabwire/util"
+
+	"github.com/antinvestor/builder/internal/events"
+)
+
+// commandRequestSource identifies executions started by a build command.
+const commandRequestSource = "github"
+
+// autoBuildCommand is the comment command accepted alongside the configured
+// build command prefix.
+const autoBuildCommand = "/auto-build"
+
+// buildCommand is a build command found in an issue or comment.
+type buildCommand struct {
+	Repository    string
+	CloneURL      string
+	DefaultBranch string
+	IssueNumber   int
+	Title         string
+	Specification string
+	Requester     string
+}
+
+// parseBuildCommand extracts the specification following prefix in text. It
+// reports false when text does not start with the command, so "/builder" is not
+// mistaken for "/build". The specification may be empty.
+func parseBuildCommand(prefix, text string) (string, bool) {
+	prefix = strings.TrimSpace(prefix)
+	if prefix == "" {
+		return "", false
+	}
+	rest, ok := strings.CutPrefix(strings.TrimSpace(text), prefix)
+	if !ok {
+		return "", false
+	}
+	if next, _ := utf8.DecodeRuneInString(rest); rest != "" && !unicode.IsSpace(next) {
+		return "", false
+	}
+	return strings.TrimSpace(rest), true
+}
+
+// isBot reports whether user is a GitHub App or bot account.
+func isBot(user GitHubUser) bool {
+	return strings.EqualFold(user.Type, "Bot") || strings.HasSuffix(strings.ToLower(user.Login), "[bot]")
+}
+
+// publishBuildCommand publishes the initialization of the execution a build
+// command requests to the feature request queue.
+func (h *WebhookHandler) publishBuildCommand(ctx context.Context, cmd *buildCommand) error {
+	title := cmd.Title
+	if title == "" {
+		title, _, _ = strings.Cut(cmd.Specification, "\n")
+	}
+
+	payload := &events.FeatureExecutionInitializedPayload{
+		ExecutionID: events.NewExecutionID(),
+		Spec: events.FeatureSpecification{
+			Title:       strings.TrimSpace(title),
+			Description: cmd.Specification,
+		},
+		Repository: events.RepositoryContext{
+			RemoteURL:    cmd.CloneURL,
+			TargetBranch: cmd.DefaultBranch,
+		},
+		Request: events.RequestMetadata{
+			RequestedBy:   cmd.Requester,
+			RequestedAt:   time.Now(),
+			RequestSource: commandRequestSource,
+		},
+	}
+
+	data, marshalErr := json.Marshal(payload)
+	if marshalErr != nil {
+		return fmt.Errorf("marshal build command: %w", marshalErr)
+	}
+
+	publisher, pubErr := h.queue.GetPublisher(h.cfg.QueueFeatureRequestName)
+	if pubErr != nil {
+		return fmt.Errorf("get publisher %s: %w", h.cfg.QueueFeatureRequestName, pubErr)
+	}
+
+	if publishErr := publisher.Publish(ctx, data); publishErr != nil {
+		return fmt.Errorf("publish build command: %w", publishErr)
+	}
+
+	util.Log(ctx).Info("published build command",
+		"execution_id", payload.ExecutionID.String(),
+		"repo", cmd.Repository,
+		"issue", cmd.IssueNumber,
+		"requester", cmd.Requester,
+	)
+
+	return nil
+}
@@ -0,0 +1,119 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/antinvestor/builder/internal/events"
+)
+
+func commandComment(body string, commenter map[string]any) map[string]any {
+	return map[string]any{
+		"action": "created",
+		"issue": map[string]any{
+			"number": 7,
+			"title":  "Order export",
+			"body":   "Customers want their orders as a file",
+		},
+		"comment": map[string]any{"body": body, "user": commenter},
+		"repository": map[string]any{
+			"full_name":      "acme/api",
+			"clone_url":      "https://github.com/acme/api.git",
+			"default_branch": "develop",
+		},
+	}
+}
+
+func publishedCommands(t *testing.T, qMan *recordingQueue) []events.FeatureExecutionInitializedPayload {
+	t.Helper()
+	var commands []events.FeatureExecutionInitializedPayload
+	for _, data := range qMan.published["feature.requests"] {
+		var payload events.FeatureExecutionInitializedPayload
+		require.NoError(t, json.Unmarshal(data, &payload))
+		commands = append(commands, payload)
+	}
+	return commands
+}
+
+func TestBuildCommand_CommentPublishesExecution(t *testing.T) {
+	handler, qMan := newAuthorizedHandler()
+
+	rec := deliver(t, handler, "issue_comment",
+		commandComment("/build Export orders as CSV\nwith totals", map[string]any{"login": "alice", "type": "User"}))
+
+	assert.Equal(t, http.StatusAccepted, rec.Code)
+	commands := publishedCommands(t, qMan)
+	require.Len(t, commands, 1)
+	assert.False(t, commands[0].ExecutionID.IsZero())
+	assert.Equal(t, "Order export", commands[0].Spec.Title)
+	assert.Equal(t, "Export orders as CSV\nwith totals", commands[0].Spec.Description)
+	assert.Equal(t, "https://github.com/acme/api.git", commands[0].Repository.RemoteURL)
+	assert.Equal(t, "develop", commands[0].Repository.TargetBranch)
+	assert.Equal(t, "alice", commands[0].Request.RequestedBy)
+	assert.Equal(t, "github", commands[0].Request.RequestSource)
+}
+
+func TestBuildCommand_AutoBuildAliasPublishesExecution(t *testing.T) {
+	handler, qMan := newAuthorizedHandler()
+
+	rec := deliver(t, handler, "issue_comment",
+		commandComment("/auto-build Export orders as CSV", map[string]any{"login": "alice"}))
+
+	assert.Equal(t, http.StatusAccepted, rec.Code)
+	commands := publishedCommands(t, qMan)
+	require.Len(t, commands, 1)
+	assert.Equal(t, "Export orders as CSV", commands[0].Spec.Description)
+}
+
+func TestBuildCommand_BareCommandBuildsIssue(t *testing.T) {
+	handler, qMan := newAuthorizedHandler()
+
+	rec := deliver(t, handler, "issue_comment", commandComment("  /build  ", map[string]any{"login": "alice"}))
+
+	assert.Equal(t, http.StatusAccepted, rec.Code)
+	commands := publishedCommands(t, qMan)
+	require.Len(t, commands, 1)
+	assert.Equal(t, "Customers want their orders as a file", commands[0].Spec.Description)
+}
+
+func TestBuildCommand_IgnoredComments(t *testing.T) {
+	tests := []struct {
+		name      string
+		body      string
+		commenter map[string]any
+	}{
+		{name: "not a command", body: "Could we /build this next sprint?", commenter: map[string]any{"login": "alice"}},
+		{name: "longer word", body: "/builder is great", commenter: map[string]any{"login": "alice"}},
+		{name: "bot type", body: "/build Export orders", commenter: map[string]any{"login": "alice", "type": "Bot"}},
+		{name: "bot login", body: "/build Export orders", commenter: map[string]any{"login": "renovate[bot]"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler, qMan := newAuthorizedHandler()
+
+			rec := deliver(t, handler, "issue_comment", commandComment(tt.body, tt.commenter))
+
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.Empty(t, qMan.published)
+		})
+	}
+}
+
+func TestBuildCommand_IssueBodyPublishesExecution(t *testing.T) {
+	handler, qMan := newAuthorizedHandler()
+
+	issue := labeledIssue("acme/api", "alice")
+	issue["action"] = "opened"
+	issue["issue"] = map[string]any{"number": 8, "title": "Order export", "body": "/build Export orders as CSV"}
+	rec := deliver(t, handler, "issues", issue)
+
+	assert.Equal(t, http.StatusAccepted, rec.Code)
+	commands := publishedCommands(t, qMan)
+	require.Len(t, commands, 1)
+	assert.Equal(t, "Export orders as CSV", commands[0].Spec.Description)
+}
@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"container/list"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// deliveryCache remembers recently seen GitHub delivery IDs so that redelivered
+// webhooks are not processed twice. It holds at most size IDs, evicting the
+// oldest first, and forgets an ID once ttl has passed. A nil cache remembers
+// nothing.
+type deliveryCache struct {
+	size int
+	ttl  time.Duration
+	now  func() time.Time
+
+	mu      sync.Mutex
+	order   *list.List // of *deliveryEntry, newest first
+	entries map[string]*list.Element
+}
+
+type deliveryEntry struct {
+	id     string
+	seenAt time.Time
+}
+
+// newDeliveryCache creates a delivery cache, or returns nil when size or ttl
+// disables deduplication.
+func newDeliveryCache(size int, ttl time.Duration) *deliveryCache {
+	if size <= 0 || ttl <= 0 {
+		return nil
+	}
+	return &deliveryCache{
+		size:    size,
+		ttl:     ttl,
+		now:     time.Now,
+		order:   list.New(),
+		entries: make(map[string]*list.Element),
+	}
+}
+
+// Reserve records id as seen and reports whether it is new, i.e. not seen within
+// the TTL. Empty IDs are always new.
+func (c *deliveryCache) Reserve(id string) bool {
+	if c == nil || id == "" {
+		return true
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+	// Entries are ordered by age, so expired ones sit at the back
+	for back := c.order.Back(); back != nil; back = c.order.Back() {
+		entry, _ := back.Value.(*deliveryEntry)
+		if now.Sub(entry.seenAt) < c.ttl {
+			break
+		}
+		c.remove(back)
+	}
+
+	if _, seen := c.entries[id]; seen {
+		return false
+	}
+
+	c.entries[id] = c.order.PushFront(&deliveryEntry{id: id, seenAt: now})
+	for c.order.Len() > c.size {
+		c.remove(c.order.Back())
+	}
+	return true
+}
+
+// Release forgets id so that a redelivery is processed again.
+func (c *deliveryCache) Release(id string) {
+	if c == nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if element, ok := c.entries[id]; ok {
+		c.remove(element)
+	}
+}
+
+func (c *deliveryCache) remove(element *list.Element) {
+	entry, _ := c.order.Remove(element).(*deliveryEntry)
+	delete(c.entries, entry.id)
+}
+
+// statusRecorder captures the status code written to a response.
+type statusRecorder struct {
+	http.ResponseWriter
+
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
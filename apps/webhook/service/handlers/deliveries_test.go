@@ -0,0 +1,102 @@
+package handlers_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/pitabwire/frame/queue"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	appconfig "github.com/antinvestor/builder/apps/webhook/config"
+	"github.com/antinvestor/builder/apps/webhook/service/handlers"
+)
+
+// flakyQueue fails the first publish and records the rest.
+type flakyQueue struct {
+	*recordingQueue
+
+	failed bool
+}
+
+type flakyPublisher struct {
+	queue.Publisher
+
+	queue *flakyQueue
+}
+
+func (q *flakyQueue) GetPublisher(reference string) (queue.Publisher, error) {
+	publisher, err := q.recordingQueue.GetPublisher(reference)
+	return &flakyPublisher{Publisher: publisher, queue: q}, err
+}
+
+func (p *flakyPublisher) Publish(ctx context.Context, payload any, headers ...map[string]string) error {
+	if !p.queue.failed {
+		p.queue.failed = true
+		return errors.New("queue unavailable")
+	}
+	return p.Publisher.Publish(ctx, payload, headers...)
+}
+
+func newDedupHandler(qMan queue.Manager) *handlers.WebhookHandler {
+	return handlers.NewWebhookHandler(&appconfig.WebhookConfig{
+		QueueFeatureRequestName: "feature.requests",
+		EnableIssueProcessing:   true,
+		AutoTriggerLabel:        "auto-build",
+		DeliveryDedupTTLSeconds: 3600,
+		DeliveryDedupCacheSize:  2,
+	}, qMan)
+}
+
+func deliverWithID(t *testing.T, handler *handlers.WebhookHandler, deliveryID string) *httptest.ResponseRecorder {
+	t.Helper()
+	body, err := json.Marshal(labeledIssue("acme/api", "alice"))
+	require.NoError(t, err)
+
+	req := httptest.NewRequest(http.MethodPost, "/webhooks/github", strings.NewReader(string(body)))
+	req.Header.Set("X-GitHub-Event", "issues")
+	req.Header.Set("X-GitHub-Delivery", deliveryID)
+	rec := httptest.NewRecorder()
+	handler.HandleGitHubWebhook(rec, req)
+	return rec
+}
+
+func TestWebhookDeliveries_RepeatedDeliveryPublishedOnce(t *testing.T) {
+	qMan := &recordingQueue{published: map[string][][]byte{}}
+	handler := newDedupHandler(qMan)
+
+	assert.Equal(t, http.StatusAccepted, deliverWithID(t, handler, "delivery-1").Code)
+	rec := deliverWithID(t, handler, "delivery-1")
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), "duplicate delivery")
+	assert.Len(t, qMan.published["feature.requests"], 1)
+}
+
+func TestWebhookDeliveries_DistinctDeliveriesPublished(t *testing.T) {
+	qMan := &recordingQueue{published: map[string][][]byte{}}
+	handler := newDedupHandler(qMan)
+
+	assert.Equal(t, http.StatusAccepted, deliverWithID(t, handler, "delivery-1").Code)
+	assert.Equal(t, http.StatusAccepted, deliverWithID(t, handler, "delivery-2").Code)
+	assert.Len(t, qMan.published["feature.requests"], 2)
+
+	// Past the cache size the oldest delivery is forgotten
+	assert.Equal(t, http.StatusAccepted, deliverWithID(t, handler, "delivery-3").Code)
+	assert.Equal(t, http.StatusAccepted, deliverWithID(t, handler, "delivery-1").Code)
+	assert.Len(t, qMan.published["feature.requests"], 4)
+}
+
+func TestWebhookDeliveries_FailedDeliveryIsProcessedAgain(t *testing.T) {
+	qMan := &flakyQueue{recordingQueue: &recordingQueue{published: map[string][][]byte{}}}
+	handler := newDedupHandler(qMan)
+
+	assert.Equal(t, http.StatusInternalServerError, deliverWithID(t, handler, "delivery-1").Code)
+	assert.Equal(t, http.StatusAccepted, deliverWithID(t, handler, "delivery-1").Code)
+	assert.Len(t, qMan.published["feature.requests"], 1)
+}
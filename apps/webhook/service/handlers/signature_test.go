@@ -0,0 +1,106 @@
+package handlers_test
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	appconfig "github.com/antinvestor/builder/apps/webhook/config"
+	"github.com/antinvestor/builder/apps/webhook/service/handlers"
+)
+
+const testWebhookSecret = "s3cret"
+
+func sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func deliverSigned(handler *handlers.WebhookHandler, body []byte, signature string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/webhooks/github", strings.NewReader(string(body)))
+	req.Header.Set("X-GitHub-Event", "issues")
+	if signature != "" {
+		req.Header.Set("X-Hub-Signature-256", signature)
+	}
+	rec := httptest.NewRecorder()
+	handler.HandleGitHubWebhook(rec, req)
+	return rec
+}
+
+func newSignedHandler(t *testing.T) (*handlers.WebhookHandler, *recordingQueue, []byte) {
+	t.Helper()
+	cfg := &appconfig.WebhookConfig{
+		GitHubWebhookSecret:     testWebhookSecret,
+		QueueFeatureRequestName: "feature.requests",
+		EnableIssueProcessing:   true,
+		AutoTriggerLabel:        "auto-build",
+	}
+	qMan := &recordingQueue{published: map[string][][]byte{}}
+	handler := handlers.NewWebhookHandler(cfg, qMan)
+	body, err := json.Marshal(labeledIssue("acme/api", "alice"))
+	require.NoError(t, err)
+	return handler, qMan, body
+}
+
+func TestWebhookSignature_ValidSignaturePublishes(t *testing.T) {
+	handler, qMan, body := newSignedHandler(t)
+
+	rec := deliverSigned(handler, body, sign(testWebhookSecret, body))
+
+	assert.Equal(t, http.StatusAccepted, rec.Code)
+	assert.Len(t, qMan.published["feature.requests"], 1)
+}
+
+func TestWebhookSignature_InvalidSignatureRejected(t *testing.T) {
+	tests := []struct {
+		name      string
+		signature func(body []byte) string
+	}{
+		{name: "wrong secret", signature: func(body []byte) string { return sign("other", body) }},
+		{name: "tampered body", signature: func(body []byte) string { return sign(testWebhookSecret, append(body, ' ')) }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler, qMan, body := newSignedHandler(t)
+
+			rec := deliverSigned(handler, body, tt.signature(body))
+
+			assert.Equal(t, http.StatusUnauthorized, rec.Code)
+			assert.Empty(t, qMan.published)
+		})
+	}
+}
+
+func TestWebhookSignature_MissingOrMalformedHeaderRejected(t *testing.T) {
+	tests := []struct {
+		name      string
+		signature string
+	}{
+		{name: "missing header"},
+		{name: "missing prefix", signature: strings.Repeat("ab", sha256.Size)},
+		{name: "sha1 prefix", signature: "sha1=" + strings.Repeat("ab", 20)},
+		{name: "not hex", signature: "sha256=" + strings.Repeat("zz", sha256.Size)},
+		{name: "short digest", signature: "sha256=abcd"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler, qMan, body := newSignedHandler(t)
+
+			rec := deliverSigned(handler, body, tt.signature)
+
+			assert.Equal(t, http.StatusBadRequest, rec.Code)
+			assert.Empty(t, qMan.published)
+		})
+	}
+}
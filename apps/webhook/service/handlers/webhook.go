@@ -6,10 +6,12 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/pitabwire/frame/queue"
 	"github.com/pitabwire/util"
@@ -17,22 +19,49 @@ import (
 	appconfig "github.com/antinvestor/builder/apps/webhook/config"
 )
 
+const (
+	// signatureHeader carries the HMAC-SHA256 of the payload GitHub signs with the webhook secret.
+	signatureHeader = "X-Hub-Signature-256"
+	signaturePrefix = "sha256="
+)
+
+var (
+	// ErrMalformedSignature is returned when the signature header is missing or unparseable.
+	ErrMalformedSignature = errors.New("malformed webhook signature")
+	// ErrInvalidSignature is returned when the signature does not match the payload.
+	ErrInvalidSignature = errors.New("invalid webhook signature")
+)
+
 // Label represents a GitHub label.
 type Label struct {
 	Name string `json:"name"`
 }
 
+// GitHubUser represents the GitHub account behind an event.
+type GitHubUser struct {
+	Login string `json:"login"`
+	// Type is "User", "Organization" or "Bot".
+	Type string `json:"type"`
+}
+
 // WebhookHandler handles incoming GitHub webhooks.
 type WebhookHandler struct {
-	cfg   *appconfig.WebhookConfig
-	queue queue.Manager
+	cfg        *appconfig.WebhookConfig
+	queue      queue.Manager
+	authorizer *SourceAuthorizer
+	deliveries *deliveryCache
 }
 
 // NewWebhookHandler creates a new webhook handler.
 func NewWebhookHandler(cfg *appconfig.WebhookConfig, qMan queue.Manager) *WebhookHandler {
 	return &WebhookHandler{
-		cfg:   cfg,
-		queue: qMan,
+		cfg:        cfg,
+		queue:      qMan,
+		authorizer: NewSourceAuthorizer(cfg),
+		deliveries: newDeliveryCache(
+			cfg.DeliveryDedupCacheSize,
+			time.Duration(cfg.DeliveryDedupTTLSeconds)*time.Second,
+		),
 	}
 }
 
@@ -55,11 +84,16 @@ func (h *WebhookHandler) HandleGitHubWebhook(w http.ResponseWriter, r *http.Requ
 	}
 	defer util.CloseAndLogOnError(ctx, r.Body, "failed to close request body")
 
-	// Verify signature if secret is configured
+	// Verify signature if secret is configured, before anything reads the payload
 	if h.cfg.GitHubWebhookSecret != "" {
-		signature := r.Header.Get("X-Hub-Signature-256")
-		if !h.verifySignature(body, signature) {
-			log.Warn("invalid webhook signature")
+		err = h.verifySignature(body, r.Header.Get(signatureHeader))
+		switch {
+		case errors.Is(err, ErrMalformedSignature):
+			log.WithError(err).Warn("malformed webhook signature")
+			http.Error(w, "Missing or malformed signature", http.StatusBadRequest)
+			return
+		case err != nil:
+			log.WithError(err).Warn("invalid webhook signature")
 			http.Error(w, "Invalid signature", http.StatusUnauthorized)
 			return
 		}
@@ -74,6 +108,22 @@ func (h *WebhookHandler) HandleGitHubWebhook(w http.ResponseWriter, r *http.Requ
 		"delivery_id", deliveryID,
 	)
 
+	// GitHub redelivers webhooks, so each delivery is processed once
+	if !h.deliveries.Reserve(deliveryID) {
+		log.Info("ignoring duplicate webhook delivery", "delivery_id", deliveryID)
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(`{"status":"ignored","reason":"duplicate delivery"}`))
+		return
+	}
+	rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+	defer func() {
+		// A failed delivery is retried by GitHub and must then be processed
+		if rec.status >= http.StatusInternalServerError {
+			h.deliveries.Release(deliveryID)
+		}
+	}()
+	w = rec
+
 	// Process based on event type
 	switch eventType {
 	case "issues":
@@ -93,23 +143,31 @@ func (h *WebhookHandler) HandleGitHubWebhook(w http.ResponseWriter, r *http.Requ
 	}
 }
 
-func (h *WebhookHandler) verifySignature(body []byte, signature string) bool {
+// verifySignature checks an X-Hub-Signature-256 value against the HMAC-SHA256 of
+// the raw body. It returns ErrMalformedSignature when the header is missing or not
+// a hex sha256 digest, and ErrInvalidSignature when the digest does not match.
+func (h *WebhookHandler) verifySignature(body []byte, signature string) error {
 	if signature == "" {
-		return false
+		return fmt.Errorf("%w: missing %s header", ErrMalformedSignature, signatureHeader)
 	}
 
 	// Remove the "sha256=" prefix
-	if !strings.HasPrefix(signature, "sha256=") {
-		return false
+	digest, ok := strings.CutPrefix(signature, signaturePrefix)
+	if !ok {
+		return fmt.Errorf("%w: expected %s prefix", ErrMalformedSignature, signaturePrefix)
+	}
+	received, err := hex.DecodeString(digest)
+	if err != nil || len(received) != sha256.Size {
+		return fmt.Errorf("%w: not a hex sha256 digest", ErrMalformedSignature)
 	}
-	signature = strings.TrimPrefix(signature, "sha256=")
 
 	// Compute expected signature
 	mac := hmac.New(sha256.New, []byte(h.cfg.GitHubWebhookSecret))
 	mac.Write(body)
-	expected := hex.EncodeToString(mac.Sum(nil))
-
-	return hmac.Equal([]byte(expected), []byte(signature))
+	if !hmac.Equal(mac.Sum(nil), received) {
+		return ErrInvalidSignature
+	}
+	return nil
 }
 
 // IssueEvent represents a GitHub issue event payload.
@@ -127,19 +185,18 @@ type IssueEvent struct {
 		HTMLURL string `json:"html_url"`
 	} `json:"issue"`
 	Repository struct {
-		FullName string `json:"full_name"`
-		CloneURL string `json:"clone_url"`
-		SSHURL   string `json:"ssh_url"`
-		HTMLURL  string `json:"html_url"`
-		Private  bool   `json:"private"`
-		Owner    struct {
+		FullName      string `json:"full_name"`
+		CloneURL      string `json:"clone_url"`
+		SSHURL        string `json:"ssh_url"`
+		HTMLURL       string `json:"html_url"`
+		DefaultBranch string `json:"default_branch"`
+		Private       bool   `json:"private"`
+		Owner         struct {
 			Login string `json:"login"`
 		} `json:"owner"`
 	} `json:"repository"`
-	Sender struct {
-		Login string `json:"login"`
-	} `json:"sender"`
-	Label Label `json:"label"`
+	Sender GitHubUser `json:"sender"`
+	Label  Label      `json:"label"`
 }
 
 func (h *WebhookHandler) handleIssueEvent(w http.ResponseWriter, r *http.Request, body []byte) {
@@ -180,6 +237,11 @@ func (h *WebhookHandler) handleIssueEvent(w http.ResponseWriter, r *http.Request
 	case "opened", "labeled":
 		// Check if auto-trigger label is present
 		if h.hasAutoTriggerLabel(event.Issue.Labels) {
+			authErr := h.authorizer.Authorize(event.Repository.FullName, event.Sender.Login, WebhookActionIssue)
+			if authErr != nil {
+				writeUnauthorized(ctx, w, authErr)
+				return
+			}
 			if err := h.publishFeatureRequest(ctx, &event); err != nil {
 				log.WithError(err).Error("failed to publish feature request")
 				http.Error(w, "Failed to queue feature request", http.StatusInternalServerError)
@@ -190,6 +252,11 @@ func (h *WebhookHandler) handleIssueEvent(w http.ResponseWriter, r *http.Request
 			_, _ = w.Write([]byte(`{"status":"accepted","message":"Feature request queued"}`))
 			return
 		}
+		if event.Action == "opened" {
+			if h.processIssueCommand(ctx, w, &event) {
+				return
+			}
+		}
 	case "closed", "reopened", "edited":
 		// Publish state change event
 		if err := h.publishGitHubEvent(ctx, "issue", event.Action, body); err != nil {
@@ -201,6 +268,52 @@ func (h *WebhookHandler) handleIssueEvent(w http.ResponseWriter, r *http.Request
 	_, _ = w.Write([]byte(`{"status":"processed"}`))
 }
 
+// processIssueCommand publishes the execution requested by a build command that
+// opens an issue body. It reports whether it wrote the response.
+func (h *WebhookHandler) processIssueCommand(ctx context.Context, w http.ResponseWriter, event *IssueEvent) bool {
+	log := util.Log(ctx)
+
+	spec, ok := parseBuildCommand(h.cfg.BuildCommandPrefix, event.Issue.Body)
+	if !ok {
+		return false
+	}
+	if isBot(event.Sender) {
+		log.Debug("ignoring build command from bot", "sender", event.Sender.Login)
+		return false
+	}
+
+	log.Info("build command detected",
+		"repo", event.Repository.FullName,
+		"issue", event.Issue.Number,
+		"requester", event.Sender.Login,
+	)
+	if err := h.authorizer.Authorize(event.Repository.FullName, event.Sender.Login, WebhookActionIssue); err != nil {
+		writeUnauthorized(ctx, w, err)
+		return true
+	}
+
+	// A bare command builds what the title asks for
+	if spec == "" {
+		spec = event.Issue.Title
+	}
+	err := h.publishBuildCommand(ctx, &buildCommand{
+		Repository:    event.Repository.FullName,
+		CloneURL:      event.Repository.CloneURL,
+		DefaultBranch: event.Repository.DefaultBranch,
+		IssueNumber:   event.Issue.Number,
+		Title:         event.Issue.Title,
+		Specification: spec,
+		Requester:     event.Sender.Login,
+	})
+	if err != nil {
+		log.WithError(err).Error("failed to publish build command from issue")
+		http.Error(w, "Failed to queue build command", http.StatusInternalServerError)
+		return true
+	}
+	writeCommandQueued(w)
+	return true
+}
+
 // IssueCommentEvent represents a GitHub issue comment event.
 type IssueCommentEvent struct {
 	Action string `json:"action"`
@@ -214,20 +327,18 @@ type IssueCommentEvent struct {
 		} `json:"pull_request"`
 	} `json:"issue"`
 	Comment struct {
-		ID   int64  `json:"id"`
-		Body string `json:"body"`
-		User struct {
-			Login string `json:"login"`
-		} `json:"user"`
+		ID      int64      `json:"id"`
+		Body    string     `json:"body"`
+		HTMLURL string     `json:"html_url"`
+		User    GitHubUser `json:"user"`
 	} `json:"comment"`
 	Repository struct {
-		FullName string `json:"full_name"`
-		CloneURL string `json:"clone_url"`
-		SSHURL   string `json:"ssh_url"`
+		FullName      string `json:"full_name"`
+		CloneURL      string `json:"clone_url"`
+		SSHURL        string `json:"ssh_url"`
+		DefaultBranch string `json:"default_branch"`
 	} `json:"repository"`
-	Sender struct {
-		Login string `json:"login"`
-	} `json:"sender"`
+	Sender GitHubUser `json:"sender"`
 }
 
 func (h *WebhookHandler) handleIssueCommentEvent(w http.ResponseWriter, r *http.Request, body []byte) {
@@ -250,43 +361,75 @@ func (h *WebhookHandler) handleIssueCommentEvent(w http.ResponseWriter, r *http.
 
 	// Check for command triggers in comments
 	if event.Action == "created" {
-		h.processCommentCommands(ctx, &event)
+		queued, err := h.processCommentCommands(ctx, &event)
+		switch {
+		case errors.Is(err, ErrUnauthorizedSource):
+			writeUnauthorized(ctx, w, err)
+			return
+		case err != nil:
+			log.WithError(err).Error("failed to publish build command from comment")
+			http.Error(w, "Failed to queue build command", http.StatusInternalServerError)
+			return
+		case queued:
+			writeCommandQueued(w)
+			return
+		}
 	}
 
 	w.WriteHeader(http.StatusOK)
 	_, _ = w.Write([]byte(`{"status":"processed"}`))
 }
 
-func (h *WebhookHandler) processCommentCommands(ctx context.Context, event *IssueCommentEvent) {
+// processCommentCommands publishes the execution requested by a build command
+// comment and reports whether one was queued. The /auto-build alias is accepted
+// as well as the configured prefix. Comments from bots are ignored. It returns
+// ErrUnauthorizedSource when the commenter may not trigger builds.
+func (h *WebhookHandler) processCommentCommands(ctx context.Context, event *IssueCommentEvent) (bool, error) {
 	log := util.Log(ctx)
-	comment := strings.TrimSpace(event.Comment.Body)
-
-	// Check for build trigger command
-	if strings.HasPrefix(comment, "/build") || strings.HasPrefix(comment, "/auto-build") {
-		log.Info("build command detected",
-			"repo", event.Repository.FullName,
-			"issue", event.Issue.Number,
-			"requester", event.Comment.User.Login,
-		)
-		// Convert to feature request
-		issueEvent := &IssueEvent{
-			Action: "comment_triggered",
-		}
-		issueEvent.Issue.Number = event.Issue.Number
-		issueEvent.Issue.Title = event.Issue.Title
-		issueEvent.Issue.Body = event.Issue.Body
-		// Copy labels from IssueCommentEvent to IssueEvent
-		for _, l := range event.Issue.Labels {
-			issueEvent.Issue.Labels = append(issueEvent.Issue.Labels, Label{Name: l.Name})
-		}
-		issueEvent.Repository.FullName = event.Repository.FullName
-		issueEvent.Repository.CloneURL = event.Repository.CloneURL
-		issueEvent.Repository.SSHURL = event.Repository.SSHURL
 
-		if err := h.publishFeatureRequest(ctx, issueEvent); err != nil {
-			log.WithError(err).Error("failed to publish feature request from comment")
-		}
+	spec, ok := parseBuildCommand(h.cfg.BuildCommandPrefix, event.Comment.Body)
+	if !ok {
+		spec, ok = parseBuildCommand(autoBuildCommand, event.Comment.Body)
+	}
+	if !ok {
+		return false, nil
+	}
+	if isBot(event.Comment.User) {
+		log.Debug("ignoring build command from bot", "commenter", event.Comment.User.Login)
+		return false, nil
+	}
+	if !h.isRepositoryAllowed(event.Repository.FullName) {
+		log.Debug("repository not in allowed list", "repo", event.Repository.FullName)
+		return false, nil
 	}
+
+	log.Info("build command detected",
+		"repo", event.Repository.FullName,
+		"issue", event.Issue.Number,
+		"requester", event.Comment.User.Login,
+	)
+	err := h.authorizer.Authorize(event.Repository.FullName, event.Comment.User.Login, WebhookActionComment)
+	if err != nil {
+		return false, err
+	}
+
+	// A bare command builds the issue itself
+	if spec == "" {
+		spec = strings.TrimSpace(event.Issue.Body)
+	}
+	err = h.publishBuildCommand(ctx, &buildCommand{
+		Repository:    event.Repository.FullName,
+		CloneURL:      event.Repository.CloneURL,
+		DefaultBranch: event.Repository.DefaultBranch,
+		IssueNumber:   event.Issue.Number,
+		Title:         event.Issue.Title,
+		Specification: spec,
+		Requester:     event.Comment.User.Login,
+	})
+	if err != nil {
+		return false, err
+	}
+	return true, nil
 }
 
 // PullRequestEvent represents a GitHub pull request event.
@@ -376,6 +519,9 @@ type PushEvent struct {
 		Name  string `json:"name"`
 		Email string `json:"email"`
 	} `json:"pusher"`
+	Sender struct {
+		Login string `json:"login"`
+	} `json:"sender"`
 	Commits []struct {
 		ID      string `json:"id"`
 		Message string `json:"message"`
@@ -410,6 +556,15 @@ func (h *WebhookHandler) handlePushEvent(w http.ResponseWriter, r *http.Request,
 		"commits", len(event.Commits),
 	)
 
+	sender := event.Sender.Login
+	if sender == "" {
+		sender = event.Pusher.Name
+	}
+	if err := h.authorizer.Authorize(event.Repository.FullName, sender, WebhookActionPush); err != nil {
+		writeUnauthorized(ctx, w, err)
+		return
+	}
+
 	// Publish state change event
 	if err := h.publishGitHubEvent(ctx, "push", "pushed", body); err != nil {
 		log.WithError(err).Error("failed to publish GitHub event")
@@ -457,6 +612,27 @@ func (h *WebhookHandler) handlePingEvent(w http.ResponseWriter, r *http.Request,
 	_, _ = w.Write([]byte(`{"status":"pong","message":"Webhook configured successfully"}`))
 }
 
+// writeCommandQueued acknowledges a queued build command.
+func writeCommandQueued(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	_, _ = w.Write([]byte(`{"status":"accepted","message":"Build command queued"}`))
+}
+
+// writeUnauthorized rejects a webhook whose source failed authorization.
+func writeUnauthorized(ctx context.Context, w http.ResponseWriter, err error) {
+	if !errors.Is(err, ErrUnauthorizedSource) {
+		util.Log(ctx).WithError(err).Error("failed to authorize webhook source")
+		http.Error(w, "Failed to authorize webhook", http.StatusInternalServerError)
+		return
+	}
+
+	util.Log(ctx).WithError(err).Warn("rejected unauthorized webhook source")
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusForbidden)
+	_, _ = w.Write([]byte(`{"status":"rejected","reason":"source not authorized"}`))
+}
+
 func (h *WebhookHandler) isRepositoryAllowed(repo string) bool {
 	if h.cfg.AllowedRepositories == "" {
 		return true // All repositories allowed
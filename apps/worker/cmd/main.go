@@ -3,17 +3,26 @@ package main
 import (
 	"context"
 	"net/http"
+	"time"
 
 	"github.com/pitabwire/frame"
 	"github.com/pitabwire/frame/config"
 	"github.com/pitabwire/frame/datastore"
+	frameevents "github.com/pitabwire/frame/events"
 	"github.com/pitabwire/util"
 
 	appconfig "github.com/antinvestor/builder/apps/worker/config"
+	"github.com/antinvestor/builder/apps/worker/service/accounting"
 	"github.com/antinvestor/builder/apps/worker/service/events"
+	"github.com/antinvestor/builder/apps/worker/service/flakiness"
+	"github.com/antinvestor/builder/apps/worker/service/provider"
 	"github.com/antinvestor/builder/apps/worker/service/queue"
+	"github.com/antinvestor/builder/apps/worker/service/report"
 	"github.com/antinvestor/builder/apps/worker/service/repository"
+	"github.com/antinvestor/builder/internal/artifacts"
 	internalevents "github.com/antinvestor/builder/internal/events"
+	"github.com/antinvestor/builder/internal/executions"
+	"github.com/antinvestor/builder/internal/httpauth"
 	"github.com/antinvestor/builder/internal/llm"
 )
 
@@ -30,6 +39,11 @@ func main() {
 		return
 	}
 
+	if err = cfg.ValidateTestCommands(); err != nil {
+		util.Log(ctx).With("err", err).Error("invalid test commands")
+		return
+	}
+
 	if cfg.Name() == "" {
 		cfg.ServiceName = "feature_worker"
 	}
@@ -41,7 +55,7 @@ func main() {
 
 	// Get managers
 	dbManager := svc.DatastoreManager()
-	evtsMan := svc.EventsManager()
+	var evtsMan events.EventsEmitter = svc.EventsManager()
 	qMan := svc.QueueManager()
 
 	// Handle database migration
@@ -51,7 +65,7 @@ func main() {
 
 	// Get database pool and setup repositories
 	dbPool := dbManager.GetPool(ctx, datastore.DefaultPoolName)
-	executionRepo := repository.NewExecutionRepository(ctx, dbPool)
+	executionRepo := executions.NewExecutionRepository(ctx, dbPool)
 	workspaceRepo := repository.NewWorkspaceRepository(ctx, dbPool)
 
 	// ==========================================================================
@@ -73,8 +87,47 @@ func main() {
 	workspaceCleanup.Start(ctx)
 	defer workspaceCleanup.Stop()
 
+	// Per-execution resource accounting
+	ledger := accounting.NewLedger(accounting.BudgetFromConfig(&cfg))
+
+	// Each emitted event is recorded as its execution's latest progress
+	evtsMan = report.ProgressEmitter(evtsMan, executionRepo)
+
+	// Execution reports assembled from the events each execution emits and handles
+	// Artifacts shared with the executor, expired once past their retention
+	artifactStore, err := artifacts.OpenBucketStore(ctx, cfg.ArtifactStoreURL)
+	if err != nil {
+		log.WithError(err).Fatal("could not open artifact store")
+	}
+	defer func() {
+		if closeErr := artifactStore.Close(); closeErr != nil {
+			log.WithError(closeErr).Warn("could not close artifact store")
+		}
+	}()
+	if cfg.ArtifactRetentionHours > 0 {
+		artifactRetention := artifacts.NewRetention(artifactStore, time.Duration(cfg.ArtifactRetentionHours)*time.Hour)
+		artifactRetention.Start(ctx)
+		defer artifactRetention.Stop()
+	}
+
+	var reports *report.Recorder
+	if cfg.ExecutionReportEnabled {
+		reports = report.NewRecorder(report.NewArtifactStore(artifactStore), ledger)
+		evtsMan = reports.Emitter(evtsMan)
+	}
+
+	// Commit footer and pull request body templates tracing deliveries to their spec
+	deliveryTemplates, err := events.NewDeliveryTemplates(&cfg)
+	if err != nil {
+		log.WithError(err).Fatal("could not load delivery templates")
+	}
+
+	// Operator endpoints that act on or expose executions require authenticated callers
+	authMiddleware := httpauth.NewAuthMiddleware(svc.SecurityManager().GetAuthenticator(ctx))
+
 	// Build service options
-	serviceOptions := buildServiceOptions(&cfg, executionRepo, evtsMan, qMan, repoService, bamlClient)
+	serviceOptions := buildServiceOptions(&cfg, executionRepo, evtsMan, qMan, repoService, bamlClient, ledger,
+		deliveryTemplates, reports, authMiddleware)
 
 	// Initialize and run service
 	svc.Init(ctx, serviceOptions...)
@@ -86,14 +139,112 @@ func main() {
 
 func buildServiceOptions(
 	cfg *appconfig.WorkerConfig,
-	executionRepo repository.ExecutionRepository,
+	executionRepo executions.ExecutionRepository,
 	evtsMan events.EventsEmitter,
 	qMan events.QueueManager,
 	repoService *repository.RepositoryService,
 	bamlClient events.BAMLClient,
+	ledger *accounting.Ledger,
+	deliveryTemplates *events.DeliveryTemplates,
+	reports *report.Recorder,
+	authMiddleware *httpauth.AuthMiddleware,
 ) []frame.Option {
+	// Execution state shared by the handlers instead of being re-derived from each payload
+	execContexts := events.NewExecutionContextStore()
+	execContexts.OnRelease(func(execID internalevents.ExecutionID) { ledger.Forget(execID.String()) })
+	checkout := events.NewRepositoryCheckoutEvent(cfg, repoService, evtsMan)
+	checkout.SetExecutionContexts(execContexts)
+
+	patchGeneration := events.NewPatchGenerationEvent(cfg, bamlClient, repoService, ledger, evtsMan)
+	patchGeneration.SetExecutionContexts(execContexts)
+	patchGeneration.SetDeliveryTemplates(deliveryTemplates)
+	patchGeneration.SetExecutionRepository(executionRepo)
+
+	// Test results are recorded in the flaky test history before review is requested
+	flakyTests := flakiness.NewTracker(flakiness.PolicyFromConfig(cfg), flakiness.NewMemoryStore())
+	reviewRequest := events.NewReviewRequestEvent(cfg, qMan, ledger, evtsMan)
+	reviewRequest.SetExecutionContexts(execContexts)
+	reviewRequest.SetFlakyTestTracker(flakyTests)
+	reviewRequest.SetExecutionRepository(executionRepo)
+
+	if checker, ok := bamlClient.(events.AcceptanceChecker); ok && cfg.AcceptanceSelfCheckEnabled {
+		acceptanceCheck := events.NewAcceptanceSelfCheck(cfg, checker, ledger)
+		patchGeneration.SetAcceptanceSelfCheck(acceptanceCheck)
+		reviewRequest.SetAcceptanceSelfCheck(acceptanceCheck)
+		execContexts.OnRelease(acceptanceCheck.Forget)
+	}
+	if cfg.CompileCheckEnabled {
+		patchGeneration.SetCompileChecker(repoService)
+	}
+
+	// Patch preview holds generated patches until a human approves the diff
+	var previews *events.PatchPreviewStore
+	if cfg.PatchPreviewEnabled {
+		previews = events.NewPatchPreviewStore()
+		previews.SetLimits(time.Duration(cfg.PatchPreviewTTLMinutes)*time.Minute, cfg.PatchPreviewMaxPending)
+		patchGeneration.SetPatchPreview(previews)
+		execContexts.OnRelease(previews.Discard)
+	}
+
+	// Review results from the reviewer's result queue are delivered, iterated on or
+	// aborted with the execution's recorded branch, specification and workspace;
+	// iterations are counted on the execution record so the limit holds across
+	// redeliveries and restarts
+	reviewResult := events.NewReviewResultEvent(cfg, repoService, bamlClient, qMan, evtsMan)
+	reviewResult.SetExecutionContexts(execContexts)
+	reviewResult.SetDeliveryTemplates(deliveryTemplates)
+	reviewResult.SetExecutionRepository(executionRepo)
+	reviewResult.SetLedger(ledger)
+	if cfg.PRLabelsEnabled && cfg.GitHubToken != "" {
+		// Provider API calls wait out rate limits instead of failing the labelling
+		reviewResult.SetPullRequestLabeler(provider.NewGitHubLabeler(cfg, provider.NewClient(cfg, nil)))
+	}
+
+	iteration := events.NewIterationEvent(cfg, bamlClient, ledger, evtsMan)
+	iteration.SetExecutionContexts(execContexts)
+	iteration.SetExecutionRepository(executionRepo)
+	iteration.SetIterationCommitter(repoService)
+
+	// Infrastructure failures restart the whole execution from its original request
+	featureFailure := events.NewFeatureFailureEvent(cfg, executionRepo, qMan, evtsMan)
+	featureFailure.SetExecutionContexts(execContexts)
+	if cfg.ExecutionRetryMaxAttempts > 0 {
+		featureFailure.SetExecutionRetryPolicy(events.NewExecutionRetryPolicy(cfg, executionRepo, repoService, qMan))
+	}
+
+	// Tests run in the executor with the command of the workspace's detected language
+	testExecution := events.NewTestExecutionRequestEvent(cfg, qMan, evtsMan)
+	testExecution.SetLanguageDetector(repoService)
+
+	featureCompletion := events.NewFeatureCompletionEvent(cfg, executionRepo, qMan)
+	featureCompletion.SetExecutionContexts(execContexts)
+
+	featureNoOp := events.NewFeatureNoOpEvent(cfg, qMan)
+	featureNoOp.SetExecutionContexts(execContexts)
+
+	handlers := []frameevents.EventI{
+		checkout,
+		patchGeneration,
+		testExecution,
+		reviewRequest,
+		reviewResult,
+		iteration,
+		featureCompletion,
+		featureNoOp,
+		featureFailure,
+		events.NewPatchPreviewDecisionEvent(patchGeneration, evtsMan),
+		events.NewTestProgressEvent(execContexts),
+	}
+	if reports != nil {
+		handlers = reports.Handlers(handlers...)
+	}
+
+	// Failed requests are retried through the retry queue, then dead-lettered
+	featureRequests := queue.NewFeatureRequestHandler(cfg, executionRepo, evtsMan)
+	featureRequests.SetQueueManager(qMan)
+
 	return []frame.Option{
-		frame.WithHTTPHandler(setupHealthEndpoints()),
+		frame.WithHTTPHandler(setupHealthEndpoints(ledger, flakyTests, previews, evtsMan, reports, authMiddleware)),
 		// Publishers
 		frame.WithRegisterPublisher(cfg.QueueFeatureResultName, cfg.QueueFeatureResultURI),
 		frame.WithRegisterPublisher(cfg.QueueReviewRequestName, cfg.QueueReviewRequestURI),
@@ -104,19 +255,41 @@ func buildServiceOptions(
 		frame.WithRegisterSubscriber(
 			cfg.QueueFeatureRequestName,
 			cfg.QueueFeatureRequestURI,
-			queue.NewFeatureRequestHandler(cfg, executionRepo, evtsMan),
+			featureRequests,
 		),
-		// Event handlers
-		frame.WithRegisterEvents(
-			events.NewRepositoryCheckoutEvent(cfg, repoService, evtsMan),
-			events.NewPatchGenerationEvent(cfg, bamlClient, repoService, evtsMan),
-			events.NewFeatureCompletionEvent(cfg, executionRepo, qMan),
-			events.NewFeatureFailureEvent(cfg, executionRepo, qMan, evtsMan),
+		frame.WithRegisterSubscriber(
+			cfg.QueuePriorityFeatureRequestName,
+			cfg.QueuePriorityFeatureRequestURI,
+			featureRequests,
 		),
+		frame.WithRegisterSubscriber(
+			cfg.QueueRetryLevel1Name,
+			cfg.QueueRetryLevel1URI,
+			featureRequests,
+		),
+		frame.WithRegisterSubscriber(
+			cfg.QueueReviewResultName,
+			cfg.QueueReviewResultURI,
+			reviewResult,
+		),
+		frame.WithRegisterSubscriber(
+			cfg.QueueControlEventsName,
+			cfg.QueueControlEventsURI,
+			events.NewKillSwitchControlHandler(execContexts, evtsMan),
+		),
+		// Event handlers
+		frame.WithRegisterEvents(handlers...),
 	}
 }
 
-func setupHealthEndpoints() *http.ServeMux {
+func setupHealthEndpoints(
+	ledger *accounting.Ledger,
+	flakyTests *flakiness.Tracker,
+	previews *events.PatchPreviewStore,
+	evtsMan events.EventsEmitter,
+	reports *report.Recorder,
+	authMiddleware *httpauth.AuthMiddleware,
+) *http.ServeMux {
 	mux := http.NewServeMux()
 	mux.HandleFunc("/health", func(w http.ResponseWriter, _ *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
@@ -128,6 +301,25 @@ func setupHealthEndpoints() *http.ServeMux {
 		w.WriteHeader(http.StatusOK)
 		_, _ = w.Write([]byte(`{"status":"ready","service":"worker"}`))
 	})
+
+	// Resource accounting endpoints for running executions; a finished execution's
+	// usage is in its report
+	mux.Handle("/api/v1/executions/cost", authMiddleware.Middleware(accounting.NewCostHTTPHandler(ledger)))
+	mux.Handle("/api/v1/executions/timeline", authMiddleware.Middleware(accounting.NewTimelineHTTPHandler(ledger)))
+
+	// Flaky test report across executions
+	mux.Handle("/api/v1/tests/flaky", authMiddleware.Middleware(flakiness.NewReportHTTPHandler(flakyTests)))
+
+	// Patch previews awaiting approval
+	if previews != nil {
+		mux.Handle("/api/v1/previews", authMiddleware.Middleware(events.NewPatchPreviewHTTPHandler(previews, evtsMan)))
+	}
+
+	// Execution reports
+	if reports != nil {
+		mux.Handle("GET /api/v1/executions/{id}/report",
+			authMiddleware.Middleware(report.NewHTTPHandler(reports.Store())))
+	}
 	return mux
 }
 
@@ -151,16 +343,20 @@ func setupBAMLClient(ctx context.Context, cfg *appconfig.WorkerConfig) events.BA
 
 	// Create LLM client configuration
 	llmCfg := llm.ClientConfig{
-		AnthropicAPIKey: cfg.AnthropicAPIKey,
-		OpenAIAPIKey:    cfg.OpenAIAPIKey,
-		GoogleAPIKey:    cfg.GoogleAPIKey,
-		DefaultProvider: llm.Provider(cfg.DefaultLLMProvider),
-		DefaultModel:    llm.ModelClaudeSonnet,
-		TimeoutSeconds:  cfg.LLMTimeoutSeconds,
-		MaxRetries:      cfg.LLMMaxRetries,
-		MaxOutputTokens: defaultMaxOutputTokens,
-		Temperature:     0.0,
+		AnthropicAPIKey:   cfg.AnthropicAPIKey,
+		OpenAIAPIKey:      cfg.OpenAIAPIKey,
+		GoogleAPIKey:      cfg.GoogleAPIKey,
+		DefaultProvider:   llm.Provider(cfg.DefaultLLMProvider),
+		DefaultModel:      llm.ModelClaudeSonnet,
+		TimeoutSeconds:    cfg.LLMTimeoutSeconds,
+		MaxRetries:        cfg.LLMMaxRetries,
+		MaxOutputTokens:   defaultMaxOutputTokens,
+		Temperature:       0.0,
+		CacheTTLSeconds:   cfg.LLMCacheTTLSeconds,
+		StrictJSONOutput:  cfg.LLMStrictJSONOutput,
+		JSONReaskAttempts: cfg.LLMJSONReaskAttempts,
 	}
+	llmCfg.ProviderLimits, llmCfg.ModelLimits = buildLLMLimitOverrides(cfg)
 
 	// Try to create real LLM client
 	bamlClient, err := llm.NewBAMLClient(llmCfg)
@@ -171,11 +367,44 @@ func setupBAMLClient(ctx context.Context, cfg *appconfig.WorkerConfig) events.BA
 
 	log.Info("LLM client initialized",
 		"provider", cfg.DefaultLLMProvider,
+		"cache_ttl_seconds", cfg.LLMCacheTTLSeconds,
 	)
 
 	return &bamlClientAdapter{client: bamlClient}
 }
 
+// buildLLMLimitOverrides converts the per-provider and per-model limit settings
+// into llm limit overrides.
+func buildLLMLimitOverrides(
+	cfg *appconfig.WorkerConfig,
+) (map[llm.Provider]llm.LimitOverride, map[llm.Model]llm.LimitOverride) {
+	providerLimits := make(map[llm.Provider]llm.LimitOverride)
+	for name, seconds := range cfg.LLMProviderTimeoutSeconds {
+		override := providerLimits[llm.Provider(name)]
+		override.TimeoutSeconds = seconds
+		providerLimits[llm.Provider(name)] = override
+	}
+	for name, tokens := range cfg.LLMProviderMaxOutputTokens {
+		override := providerLimits[llm.Provider(name)]
+		override.MaxOutputTokens = tokens
+		providerLimits[llm.Provider(name)] = override
+	}
+
+	modelLimits := make(map[llm.Model]llm.LimitOverride)
+	for name, seconds := range cfg.LLMModelTimeoutSeconds {
+		override := modelLimits[llm.Model(name)]
+		override.TimeoutSeconds = seconds
+		modelLimits[llm.Model(name)] = override
+	}
+	for name, tokens := range cfg.LLMModelMaxOutputTokens {
+		override := modelLimits[llm.Model(name)]
+		override.MaxOutputTokens = tokens
+		modelLimits[llm.Model(name)] = override
+	}
+
+	return providerLimits, modelLimits
+}
+
 // bamlClientAdapter adapts llm.BAMLClient to events.BAMLClient.
 type bamlClientAdapter struct {
 	client *llm.BAMLClient
@@ -223,6 +452,14 @@ func (a *bamlClientAdapter) GeneratePatch(
 	evtResp := &events.GeneratePatchResponse{
 		CommitMessage: resp.CommitMessage,
 		TokensUsed:    resp.TokensUsed,
+		LLM:           llmInfo("GeneratePatch", resp.Provider, resp.Model, resp.ModelVersion),
+	}
+	for _, invocation := range resp.Invocations {
+		call := llmInfo(string(invocation.Function), invocation.Provider, invocation.Model, invocation.ModelVersion)
+		call.InputTokens = invocation.Usage.InputTokens
+		call.OutputTokens = invocation.Usage.OutputTokens
+		call.LatencyMS = invocation.LatencyMS
+		evtResp.LLMCalls = append(evtResp.LLMCalls, call)
 	}
 
 	// Convert patches
@@ -238,6 +475,56 @@ func (a *bamlClientAdapter) GeneratePatch(
 	return evtResp, nil
 }
 
+// SelfCheckAcceptance implements events.AcceptanceChecker.
+func (a *bamlClientAdapter) SelfCheckAcceptance(
+	ctx context.Context,
+	req *events.AcceptanceSelfCheckRequest,
+) (*events.AcceptanceSelfCheckResponse, error) {
+	llmReq := &llm.SelfCheckAcceptanceRequest{
+		ExecutionID: req.ExecutionID.String(),
+		Specification: llm.FeatureSpecification{
+			Title:              req.Specification.Title,
+			Description:        req.Specification.Description,
+			AcceptanceCriteria: req.Specification.AcceptanceCriteria,
+		},
+	}
+	for _, p := range req.Patches {
+		llmReq.Patches = append(llmReq.Patches, llm.Patch{
+			FilePath:   p.FilePath,
+			NewContent: p.NewContent,
+			Action:     string(p.Action),
+		})
+	}
+
+	resp, err := a.client.SelfCheckAcceptance(ctx, llmReq)
+	if err != nil {
+		return nil, err
+	}
+
+	evtResp := &events.AcceptanceSelfCheckResponse{
+		TokensUsed: resp.TokensUsed,
+		LLM:        llmInfo("SelfCheckAcceptance", resp.Provider, resp.Model, resp.ModelVersion),
+	}
+	for _, c := range resp.Criteria {
+		evtResp.Criteria = append(evtResp.Criteria, internalevents.AcceptanceCriterionAssessment{
+			Criterion: c.Criterion,
+			Satisfied: c.Satisfied,
+			Rationale: c.Rationale,
+		})
+	}
+	return evtResp, nil
+}
+
+// llmInfo describes the provider, model and model version that served an LLM function.
+func llmInfo(function string, provider llm.Provider, model llm.Model, version string) internalevents.LLMProcessingInfo {
+	return internalevents.LLMProcessingInfo{
+		Provider:     string(provider),
+		Model:        string(model),
+		ModelVersion: version,
+		Function:     function,
+	}
+}
+
 // bamlClientStub is a fallback stub when LLM is not configured.
 type bamlClientStub struct {
 	cfg *appconfig.WorkerConfig
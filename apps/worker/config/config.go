@@ -1,6 +1,11 @@
 package config
 
 import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
 	"github.com/pitabwire/frame/config"
 
 	"github.com/antinvestor/builder/internal/events"
@@ -34,6 +39,29 @@ type WorkerConfig struct {
 	// LLMMaxRetries is the maximum retries for LLM requests.
 	LLMMaxRetries int `envDefault:"3" env:"LLM_MAX_RETRIES"`
 
+	// LLMCacheTTLSeconds is how long identical LLM requests are served from cache (0 disables caching).
+	LLMCacheTTLSeconds int `envDefault:"0" env:"LLM_CACHE_TTL_SECONDS"`
+
+	// LLMStrictJSONOutput disables repairing malformed JSON responses (fenced output,
+	// trailing commas, truncation) and re-asking the model for valid JSON.
+	LLMStrictJSONOutput bool `envDefault:"false" env:"LLM_STRICT_JSON_OUTPUT"`
+
+	// LLMJSONReaskAttempts is how many times the model is asked again when a response
+	// is not valid JSON even after repair.
+	LLMJSONReaskAttempts int `envDefault:"1" env:"LLM_JSON_REASK_ATTEMPTS"`
+
+	// LLMProviderTimeoutSeconds overrides LLMTimeoutSeconds per provider (e.g. "anthropic:180,openai:60").
+	LLMProviderTimeoutSeconds map[string]int `env:"LLM_PROVIDER_TIMEOUT_SECONDS"`
+
+	// LLMModelTimeoutSeconds overrides the timeout per model; takes precedence over provider overrides.
+	LLMModelTimeoutSeconds map[string]int `env:"LLM_MODEL_TIMEOUT_SECONDS"`
+
+	// LLMProviderMaxOutputTokens overrides the output token cap per provider.
+	LLMProviderMaxOutputTokens map[string]int `env:"LLM_PROVIDER_MAX_OUTPUT_TOKENS"`
+
+	// LLMModelMaxOutputTokens overrides the output token cap per model; takes precedence over provider overrides.
+	LLMModelMaxOutputTokens map[string]int `env:"LLM_MODEL_MAX_OUTPUT_TOKENS"`
+
 	// ==========================================================================
 	// Repository Configuration
 	// ==========================================================================
@@ -41,15 +69,61 @@ type WorkerConfig struct {
 	// WorkspaceBasePath is the base path for repository workspaces.
 	WorkspaceBasePath string `envDefault:"/var/lib/feature-service/workspaces" env:"WORKSPACE_BASE_PATH"`
 
-	// MaxConcurrentClones is the maximum concurrent repository clones.
+	// MaxConcurrentClones is the checkout pool size: the maximum concurrent repository clones.
 	MaxConcurrentClones int `envDefault:"10" env:"MAX_CONCURRENT_CLONES"`
 
+	// MaxConcurrentClonesPerHost caps concurrent clones against one git host (0 = pool size only).
+	MaxConcurrentClonesPerHost int `envDefault:"4" env:"MAX_CONCURRENT_CLONES_PER_HOST"`
+
+	// CloneHostLimits overrides MaxConcurrentClonesPerHost per host (e.g. "github.com:8,git.internal:2").
+	CloneHostLimits map[string]int `env:"CLONE_HOST_LIMITS"`
+
 	// CloneTimeoutSeconds is the timeout for repository cloning.
 	CloneTimeoutSeconds int `envDefault:"300" env:"CLONE_TIMEOUT_SECONDS"`
 
 	// MaxWorkspaceAgeHours is the maximum workspace age before cleanup.
 	MaxWorkspaceAgeHours int `envDefault:"24" env:"MAX_WORKSPACE_AGE_HOURS"`
 
+	// CheckoutSubmodules initializes submodules recursively after cloning.
+	// Feature requests may override it per repository.
+	CheckoutSubmodules bool `envDefault:"false" env:"CHECKOUT_SUBMODULES"`
+
+	// CheckoutLFS fetches Git LFS objects after cloning; otherwise LFS files stay as pointers.
+	// Feature requests may override it per repository.
+	CheckoutLFS bool `envDefault:"false" env:"CHECKOUT_LFS"`
+
+	// SeedTemplatesPath is a directory of template files copied into the workspace after
+	// checkout: categories/<category>/ seeds every feature of a category and
+	// repositories/<repository_id>/ one repository, winning over category seeds.
+	SeedTemplatesPath string `env:"SEED_TEMPLATES_PATH"`
+
+	// RebaseBeforePush rebases the feature branch onto the latest base branch before pushing.
+	RebaseBeforePush bool `envDefault:"true" env:"REBASE_BEFORE_PUSH"`
+
+	// MergeConflictResolution handles conflicts with an advanced base branch:
+	// "regenerate" iterates on the conflicting files, "manual_review" pauses the execution.
+	MergeConflictResolution string `envDefault:"manual_review" env:"MERGE_CONFLICT_RESOLUTION"`
+
+	// BaseSyncStrategy is how the feature branch takes in an advanced base branch
+	// before pushing: "rebase" replays its commits onto the base, "merge" merges the base in.
+	BaseSyncStrategy string `envDefault:"rebase" env:"BASE_SYNC_STRATEGY"`
+
+	// NoChangesDecision handles patch generation that yields an empty diff:
+	// "noop" completes the execution without pushing, "fail" fails it.
+	NoChangesDecision string `envDefault:"noop" env:"NO_CHANGES_DECISION"`
+
+	// PatchApplicationMode handles patches that fail to apply: "strict" fails the
+	// execution on the first one, "lenient" applies the rest and commits those that applied.
+	PatchApplicationMode string `envDefault:"strict" env:"PATCH_APPLICATION_MODE"`
+
+	// PatchFailureThreshold is, in lenient mode, the share of patches (0-1) that may fail
+	// to apply before the execution fails; an execution with no applied patch always fails.
+	PatchFailureThreshold float64 `envDefault:"0.5" env:"PATCH_FAILURE_THRESHOLD"`
+
+	// VendoredDirs are names of directories holding vendored third-party code, left out
+	// of the repository context given to the LLM (comma-separated, empty = none).
+	VendoredDirs string `envDefault:"vendor,node_modules,third_party" env:"VENDORED_DIRS"`
+
 	// ==========================================================================
 	// Git Authentication
 	// ==========================================================================
@@ -71,6 +145,10 @@ type WorkerConfig struct {
 	QueueFeatureRequestName string `envDefault:"feature.requests"       env:"QUEUE_FEATURE_REQUEST_NAME"`
 	QueueFeatureRequestURI  string `envDefault:"mem://feature.requests" env:"QUEUE_FEATURE_REQUEST_URI"`
 
+	// Priority feature request queue (incoming fast lane for high-priority and urgent categories)
+	QueuePriorityFeatureRequestName string `envDefault:"feature.requests.priority"       env:"QUEUE_PRIORITY_FEATURE_REQUEST_NAME"`
+	QueuePriorityFeatureRequestURI  string `envDefault:"mem://feature.requests.priority" env:"QUEUE_PRIORITY_FEATURE_REQUEST_URI"`
+
 	// Feature result queue (outgoing)
 	QueueFeatureResultName string `envDefault:"feature.results"       env:"QUEUE_FEATURE_RESULT_NAME"`
 	QueueFeatureResultURI  string `envDefault:"mem://feature.results" env:"QUEUE_FEATURE_RESULT_URI"`
@@ -83,6 +161,10 @@ type WorkerConfig struct {
 	QueueReviewRequestName string `envDefault:"feature.review.requests"       env:"QUEUE_REVIEW_REQUEST_NAME"`
 	QueueReviewRequestURI  string `envDefault:"mem://feature.review.requests" env:"QUEUE_REVIEW_REQUEST_URI"`
 
+	// Review result queue (from reviewer service)
+	QueueReviewResultName string `envDefault:"feature.review.results"       env:"QUEUE_REVIEW_RESULT_NAME"`
+	QueueReviewResultURI  string `envDefault:"mem://feature.review.results" env:"QUEUE_REVIEW_RESULT_URI"`
+
 	// Execution queue (to executor service)
 	QueueExecutionRequestName string `envDefault:"feature.execution.requests"       env:"QUEUE_EXECUTION_REQUEST_NAME"`
 	QueueExecutionRequestURI  string `envDefault:"mem://feature.execution.requests" env:"QUEUE_EXECUTION_REQUEST_URI"`
@@ -99,6 +181,11 @@ type WorkerConfig struct {
 	QueueDLQName string `envDefault:"feature.events.dlq"       env:"QUEUE_DLQ_NAME"`
 	QueueDLQURI  string `envDefault:"mem://feature.events.dlq" env:"QUEUE_DLQ_URI"`
 
+	// Control events queue (from reviewer), carrying kill switch activations; every
+	// worker must receive each event, so in production it is a fan-out subscription
+	QueueControlEventsName string `envDefault:"feature.control"       env:"QUEUE_CONTROL_EVENTS_NAME"`
+	QueueControlEventsURI  string `envDefault:"mem://feature.control" env:"QUEUE_CONTROL_EVENTS_URI"`
+
 	// ==========================================================================
 	// Execution Limits
 	// ==========================================================================
@@ -106,18 +193,222 @@ type WorkerConfig struct {
 	// MaxConcurrentExecutions is the maximum concurrent feature executions.
 	MaxConcurrentExecutions int `envDefault:"50" env:"MAX_CONCURRENT_EXECUTIONS"`
 
+	// DedupFeatureRequests attaches requests whose repository and normalized
+	// specification match an in-flight execution to it instead of starting a new one.
+	DedupFeatureRequests bool `envDefault:"false" env:"DEDUP_FEATURE_REQUESTS"`
+
+	// FeatureRequestMaxRetries is how many times a feature request that failed on a
+	// retryable error is republished to the retry queue before it is dead-lettered.
+	FeatureRequestMaxRetries int `envDefault:"3" env:"FEATURE_REQUEST_MAX_RETRIES"`
+
+	// FeatureRequestRetryInitialDelaySeconds is the backoff ceiling of the first retry;
+	// it doubles per attempt, and each retry waits a random delay up to the ceiling.
+	FeatureRequestRetryInitialDelaySeconds int `envDefault:"5" env:"FEATURE_REQUEST_RETRY_INITIAL_DELAY_SECONDS"`
+
+	// FeatureRequestRetryMaxDelaySeconds caps the backoff between retries.
+	FeatureRequestRetryMaxDelaySeconds int `envDefault:"300" env:"FEATURE_REQUEST_RETRY_MAX_DELAY_SECONDS"`
+
 	// MaxStepsPerExecution is the maximum steps per execution.
 	MaxStepsPerExecution int `envDefault:"100" env:"MAX_STEPS_PER_EXECUTION"`
 
 	// MaxRetriesPerStep is the maximum retries per step.
 	MaxRetriesPerStep int `envDefault:"5" env:"MAX_RETRIES_PER_STEP"`
 
+	// ExecutionRetryMaxAttempts is how many times an execution that failed on a retryable
+	// infrastructure error is restarted from its original feature request (0 = disabled).
+	// Unlike step retries, a restart discards the workspace and begins again at checkout.
+	ExecutionRetryMaxAttempts int `envDefault:"0" env:"EXECUTION_RETRY_MAX_ATTEMPTS"`
+
+	// ExecutionRetryInitialDelaySeconds is the delay before the first restart; it doubles per attempt.
+	ExecutionRetryInitialDelaySeconds int `envDefault:"30" env:"EXECUTION_RETRY_INITIAL_DELAY_SECONDS"`
+
+	// ExecutionRetryMaxDelaySeconds caps the delay between restarts.
+	ExecutionRetryMaxDelaySeconds int `envDefault:"600" env:"EXECUTION_RETRY_MAX_DELAY_SECONDS"`
+
 	// StepTimeoutMinutes is the timeout for a single step.
 	StepTimeoutMinutes int `envDefault:"30" env:"STEP_TIMEOUT_MINUTES"`
 
 	// ExecutionTimeoutHours is the timeout for entire execution.
 	ExecutionTimeoutHours int `envDefault:"8" env:"EXECUTION_TIMEOUT_HOURS"`
 
+	// MaxLLMTokensPerExecution is the LLM token budget for one execution (0 = unlimited).
+	MaxLLMTokensPerExecution int `envDefault:"2000000" env:"MAX_LLM_TOKENS_PER_EXECUTION"`
+
+	// MaxSandboxCPUSecondsPerExecution is the sandbox CPU budget for one execution (0 = unlimited).
+	MaxSandboxCPUSecondsPerExecution float64 `envDefault:"7200" env:"MAX_SANDBOX_CPU_SECONDS_PER_EXECUTION"`
+
+	// AcceptanceSelfCheckEnabled asks the LLM to assess the generated changes against
+	// each acceptance criterion and attaches the assessment to the review request.
+	AcceptanceSelfCheckEnabled bool `envDefault:"false" env:"ACCEPTANCE_SELF_CHECK_ENABLED"`
+
+	// AcceptanceSelfCheckMinTokens is the LLM token budget that must remain for an
+	// execution before the self-check runs.
+	AcceptanceSelfCheckMinTokens int `envDefault:"20000" env:"ACCEPTANCE_SELF_CHECK_MIN_TOKENS"`
+
+	// PatchVerificationEnabled checks generated patches against the workspace before
+	// applying them and regenerates mismatching ones with the mismatches as feedback.
+	PatchVerificationEnabled bool `envDefault:"false" env:"PATCH_VERIFICATION_ENABLED"`
+
+	// PatchVerificationMaxRetries is how many times patches are regenerated after
+	// failing verification before patch generation fails.
+	PatchVerificationMaxRetries int `envDefault:"2" env:"PATCH_VERIFICATION_MAX_RETRIES"`
+
+	// CompileCheckEnabled runs a fast compile/syntax check after applying patches and
+	// regenerates with the compiler output as feedback before anything is committed.
+	CompileCheckEnabled bool `envDefault:"false" env:"COMPILE_CHECK_ENABLED"`
+
+	// CompileCheckCommand overrides the detected compile command; it is run with sh -c
+	// in the workspace (e.g. "make build").
+	CompileCheckCommand string `env:"COMPILE_CHECK_COMMAND"`
+
+	// CompileCheckTimeoutSeconds is the timeout for one compile check.
+	CompileCheckTimeoutSeconds int `envDefault:"120" env:"COMPILE_CHECK_TIMEOUT_SECONDS"`
+
+	// CompileCheckMaxRetries is how many times patches are regenerated after a failed
+	// compile check before patch generation fails.
+	CompileCheckMaxRetries int `envDefault:"2" env:"COMPILE_CHECK_MAX_RETRIES"`
+
+	// TestLanguage forces the language tests are run as (e.g. "python"), instead of
+	// detecting it from the workspace's project marker files.
+	TestLanguage string `env:"TEST_LANGUAGE"`
+
+	// LanguageDetectionPriority orders the languages preferred when a workspace has
+	// marker files of several (comma-separated); unlisted languages come last.
+	LanguageDetectionPriority string `envDefault:"go,node,rust,python,java" env:"LANGUAGE_DETECTION_PRIORITY"`
+
+	// TestCommands replaces the default test command of a language (e.g.
+	// "python:pytest -q,go:gotestsum"); commands are run with sh -c in the workspace.
+	TestCommands map[string]string `env:"TEST_COMMANDS"`
+
+	// FallbackTestCommand is the test command for workspaces whose language cannot be
+	// detected; it is run with sh -c in the workspace (e.g. "make test").
+	FallbackTestCommand string `env:"FALLBACK_TEST_COMMAND"`
+
+	// SkipTestsWhenUndetected skips the tests of workspaces whose language cannot be
+	// detected and that have no FallbackTestCommand, instead of running the Go tests.
+	// A skipped run is reported as unsuccessful.
+	SkipTestsWhenUndetected bool `envDefault:"false" env:"SKIP_TESTS_WHEN_UNDETECTED"`
+
+	// IterationFeedbackIncludeCode includes code snippets and locations of review
+	// findings in the feedback sent to the LLM when iterating.
+	IterationFeedbackIncludeCode bool `envDefault:"true" env:"ITERATION_FEEDBACK_INCLUDE_CODE"`
+
+	// PatchPreviewEnabled holds generated patches for human approval: the proposed
+	// diff is published and nothing is applied or committed until it is approved.
+	PatchPreviewEnabled bool `envDefault:"false" env:"PATCH_PREVIEW_ENABLED"`
+
+	// PatchPreviewTTLMinutes is how long a preview waits for a decision before its
+	// execution fails.
+	PatchPreviewTTLMinutes int `envDefault:"1440" env:"PATCH_PREVIEW_TTL_MINUTES"`
+
+	// PatchPreviewMaxPending bounds the previews awaiting a decision at once.
+	PatchPreviewMaxPending int `envDefault:"100" env:"PATCH_PREVIEW_MAX_PENDING"`
+
+	// PartialDeliveryEnabled delivers files that passed review on a separate branch
+	// while the files with blocking issues are iterated on.
+	PartialDeliveryEnabled bool `envDefault:"false" env:"PARTIAL_DELIVERY_ENABLED"`
+
+	// CompletionGateEnabled completes an execution only when its latest build and tests
+	// passed (failures of quarantined flaky tests aside) and its review approved them.
+	CompletionGateEnabled bool `envDefault:"true" env:"COMPLETION_GATE_ENABLED"`
+
+	// ==========================================================================
+	// Flaky Test Tracking
+	// ==========================================================================
+
+	// FlakyTestQuarantineEnabled excludes failures of tests classified as flaky from
+	// blocking a run, so they no longer trigger an iteration.
+	FlakyTestQuarantineEnabled bool `envDefault:"false" env:"FLAKY_TEST_QUARANTINE_ENABLED"`
+
+	// FlakyTestWindow is how many of a test's most recent runs are used to classify it.
+	FlakyTestWindow int `envDefault:"20" env:"FLAKY_TEST_WINDOW"`
+
+	// FlakyTestMinRuns is the number of recorded runs required before a test can be
+	// classified as flaky.
+	FlakyTestMinRuns int `envDefault:"5" env:"FLAKY_TEST_MIN_RUNS"`
+
+	// FlakyTestMinFailureRate and FlakyTestMaxFailureRate bound the failure rate of an
+	// intermittently failing test; tests failing more often are treated as broken.
+	FlakyTestMinFailureRate float64 `envDefault:"0.05" env:"FLAKY_TEST_MIN_FAILURE_RATE"`
+	FlakyTestMaxFailureRate float64 `envDefault:"0.8"  env:"FLAKY_TEST_MAX_FAILURE_RATE"`
+
+	// ==========================================================================
+	// Code Owner Routing
+	// ==========================================================================
+
+	// CodeOwnersRoutingEnabled routes manual reviews to the owners of the changed files
+	// as listed in the repository's CODEOWNERS file.
+	CodeOwnersRoutingEnabled bool `envDefault:"true" env:"CODE_OWNERS_ROUTING_ENABLED"`
+
+	// CodeOwnersPaths are the locations searched for CODEOWNERS, in order (comma-separated).
+	CodeOwnersPaths string `envDefault:".github/CODEOWNERS,CODEOWNERS,docs/CODEOWNERS" env:"CODE_OWNERS_PATHS"`
+
+	// ManualReviewFallbackReviewers review manual reviews no owner matches (comma-separated).
+	ManualReviewFallbackReviewers string `env:"MANUAL_REVIEW_FALLBACK_REVIEWERS"`
+
+	// ==========================================================================
+	// Pull Request Labels
+	// ==========================================================================
+
+	// PRLabelsEnabled labels delivered pull requests with the findings of their review.
+	PRLabelsEnabled bool `envDefault:"true" env:"PR_LABELS_ENABLED"`
+
+	// PRLabelMapping overrides the label applied for a finding (e.g. "security:sec,needs_tests:");
+	// findings are security, breaking_change and needs_tests, and an empty label drops the finding.
+	PRLabelMapping map[string]string `env:"PR_LABEL_MAPPING"`
+
+	// GitHubAPIURL is the API pull requests on GitHub are labeled through.
+	GitHubAPIURL string `envDefault:"https://api.github.com" env:"GITHUB_API_URL"`
+
+	// GitHubToken authenticates GitHub API calls; without one pull requests are not labeled.
+	GitHubToken string `env:"GITHUB_TOKEN"`
+
+	// ==========================================================================
+	// Provider API Rate Limits
+	// ==========================================================================
+
+	// ProviderAPIMaxRetries is how many times a rate-limited provider API call (pull
+	// requests, labels, comments) is retried before it fails.
+	ProviderAPIMaxRetries int `envDefault:"3" env:"PROVIDER_API_MAX_RETRIES"`
+
+	// ProviderAPIInitialBackoffMS is the wait before the first retry when the provider
+	// gives no Retry-After or rate-limit reset header; it doubles per retry.
+	ProviderAPIInitialBackoffMS int `envDefault:"1000" env:"PROVIDER_API_INITIAL_BACKOFF_MS"`
+
+	// ProviderAPIMaxWaitSeconds is the longest wait for a rate limit to lift; a call
+	// told to wait longer fails instead of holding up delivery.
+	ProviderAPIMaxWaitSeconds int `envDefault:"60" env:"PROVIDER_API_MAX_WAIT_SECONDS"`
+
+	// ==========================================================================
+	// Commit and Pull Request Templates
+	// ==========================================================================
+
+	// CommitFooterTemplatePath is a text/template file rendered into the footer of
+	// generated commit messages (empty = built-in template). Templates can reference
+	// .Title, .Description, .AcceptanceCriteria and .ExecutionID.
+	CommitFooterTemplatePath string `env:"COMMIT_FOOTER_TEMPLATE_PATH"`
+
+	// PRBodyTemplatePath is a text/template file rendered into the body of delivered
+	// pull requests (empty = built-in template), with the same fields as the commit footer.
+	PRBodyTemplatePath string `env:"PR_BODY_TEMPLATE_PATH"`
+
+	// ==========================================================================
+	// Execution Reports
+	// ==========================================================================
+
+	// ExecutionReportEnabled writes a JSON report of each execution when it completes
+	// or fails, served at GET /api/v1/executions/{id}/report.
+	ExecutionReportEnabled bool `envDefault:"false" env:"EXECUTION_REPORT_ENABLED"`
+
+	// ArtifactStoreURL is the blob bucket execution reports are written to, the one
+	// the executor offloads test outputs to, e.g. file:///var/lib/artifacts or the
+	// URL of an object store bucket shared between the services.
+	ArtifactStoreURL string `envDefault:"file:///var/lib/feature-service/artifacts?create_dir=true" env:"ARTIFACT_STORE_URL"`
+
+	// ArtifactRetentionHours is how long artifacts are kept before they are deleted
+	// (0 = kept until removed by the bucket's own lifecycle rules).
+	ArtifactRetentionHours int `envDefault:"168" env:"ARTIFACT_RETENTION_HOURS"`
+
 	// ==========================================================================
 	// Review Thresholds (for delegating to reviewer)
 	// ==========================================================================
@@ -125,3 +416,19 @@ type WorkerConfig struct {
 	// ReviewThresholds contains review thresholds.
 	ReviewThresholds events.ReviewThresholds `json:"review_thresholds"`
 }
+
+// TestCommand returns the test command configured for a language, or "" when the
+// language runs its built-in command.
+func (c *WorkerConfig) TestCommand(language string) string {
+	return strings.TrimSpace(c.TestCommands[strings.ToLower(language)])
+}
+
+// ValidateTestCommands reports a language configured with an empty test command.
+func (c *WorkerConfig) ValidateTestCommands() error {
+	for _, language := range slices.Sorted(maps.Keys(c.TestCommands)) {
+		if strings.TrimSpace(c.TestCommands[language]) == "" {
+			return fmt.Errorf("test command for language %q is empty", language)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,3 @@
+-- Rollback migration: Drop the executions optimistic locking version
+
+ALTER TABLE executions DROP COLUMN IF EXISTS version;
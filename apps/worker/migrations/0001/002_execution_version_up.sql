@@ -0,0 +1,3 @@
+-- Migration: Add an optimistic locking version to executions
+
+ALTER TABLE executions ADD COLUMN IF NOT EXISTS version BIGINT NOT NULL DEFAULT 0;
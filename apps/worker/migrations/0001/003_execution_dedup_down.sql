@@ -0,0 +1,6 @@
+-- Rollback migration: Drop feature request deduplication from executions
+
+DROP INDEX IF EXISTS idx_executions_spec_hash;
+
+ALTER TABLE executions DROP COLUMN IF EXISTS requesters;
+ALTER TABLE executions DROP COLUMN IF EXISTS spec_hash;
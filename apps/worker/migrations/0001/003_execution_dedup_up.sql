@@ -0,0 +1,6 @@
+-- Migration: Add feature request deduplication to executions
+
+ALTER TABLE executions ADD COLUMN IF NOT EXISTS spec_hash VARCHAR(64);
+ALTER TABLE executions ADD COLUMN IF NOT EXISTS requesters JSONB;
+
+CREATE INDEX IF NOT EXISTS idx_executions_spec_hash ON executions(spec_hash);
@@ -0,0 +1,4 @@
+-- Rollback migration: Drop execution-level retry state from executions
+
+ALTER TABLE executions DROP COLUMN IF EXISTS initial_request;
+ALTER TABLE executions DROP COLUMN IF EXISTS retry_attempts;
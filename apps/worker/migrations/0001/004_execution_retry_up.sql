@@ -0,0 +1,4 @@
+-- Migration: Add execution-level retry state to executions
+
+ALTER TABLE executions ADD COLUMN IF NOT EXISTS retry_attempts INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE executions ADD COLUMN IF NOT EXISTS initial_request JSONB;
@@ -0,0 +1,3 @@
+-- Rollback migration: Drop the LLM models recorded for executions
+
+ALTER TABLE executions DROP COLUMN IF EXISTS llm_models;
@@ -0,0 +1,3 @@
+-- Migration: Record the LLM models that served each execution
+
+ALTER TABLE executions ADD COLUMN IF NOT EXISTS llm_models JSONB;
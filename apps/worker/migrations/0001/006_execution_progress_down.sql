@@ -0,0 +1,5 @@
+-- Rollback migration: Drop execution progress tracking from executions
+
+ALTER TABLE executions DROP COLUMN IF EXISTS feature_branch;
+ALTER TABLE executions DROP COLUMN IF EXISTS last_event;
+ALTER TABLE executions DROP COLUMN IF EXISTS phase;
@@ -0,0 +1,5 @@
+-- Migration: Track the progress of each execution for status queries
+
+ALTER TABLE executions ADD COLUMN IF NOT EXISTS phase VARCHAR(50);
+ALTER TABLE executions ADD COLUMN IF NOT EXISTS last_event VARCHAR(255);
+ALTER TABLE executions ADD COLUMN IF NOT EXISTS feature_branch VARCHAR(255);
@@ -0,0 +1,3 @@
+-- Rollback migration: Drop the one in-flight execution per spec hash index
+
+DROP INDEX IF EXISTS idx_executions_spec_hash_in_flight;
@@ -0,0 +1,4 @@
+-- Migration: Allow one in-flight execution per spec hash
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_executions_spec_hash_in_flight ON executions(spec_hash)
+    WHERE spec_hash <> '' AND status IN ('pending', 'running');
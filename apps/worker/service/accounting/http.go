@@ -0,0 +1,86 @@
+package accounting
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/pitabwire/util"
+)
+
+// CostResponse is the body of GET /api/v1/executions/cost.
+type CostResponse struct {
+	Usage     Usage  `json:"usage"`
+	Budget    Budget `json:"budget"`
+	Exhausted bool   `json:"exhausted"`
+	Reason    string `json:"reason,omitempty"`
+}
+
+// TimelineResponse is the body of GET /api/v1/executions/timeline.
+type TimelineResponse struct {
+	ExecutionID string  `json:"execution_id"`
+	Entries     []Entry `json:"entries"`
+}
+
+// NewCostHTTPHandler returns the handler for GET /api/v1/executions/cost?execution_id=...
+// reporting an execution's cumulative usage against its budget.
+func NewCostHTTPHandler(ledger *Ledger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		executionID, ok := executionIDParam(w, r)
+		if !ok {
+			return
+		}
+
+		usage, found := ledger.Usage(executionID)
+		if !found {
+			http.Error(w, "execution not found", http.StatusNotFound)
+			return
+		}
+
+		resp := CostResponse{Usage: usage, Budget: ledger.Budget()}
+		if err := resp.Budget.Check(usage); err != nil {
+			resp.Exhausted = errors.Is(err, ErrBudgetExceeded)
+			resp.Reason = err.Error()
+		}
+		writeJSON(w, r, resp)
+	}
+}
+
+// NewTimelineHTTPHandler returns the handler for GET /api/v1/executions/timeline?execution_id=...
+// listing the resource charges recorded against an execution.
+func NewTimelineHTTPHandler(ledger *Ledger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		executionID, ok := executionIDParam(w, r)
+		if !ok {
+			return
+		}
+
+		entries, found := ledger.Timeline(executionID)
+		if !found {
+			http.Error(w, "execution not found", http.StatusNotFound)
+			return
+		}
+		writeJSON(w, r, TimelineResponse{ExecutionID: executionID, Entries: entries})
+	}
+}
+
+func executionIDParam(w http.ResponseWriter, r *http.Request) (string, bool) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return "", false
+	}
+	executionID := r.URL.Query().Get("execution_id")
+	if executionID == "" {
+		http.Error(w, "execution_id is required", http.StatusBadRequest)
+		return "", false
+	}
+	return executionID, true
+}
+
+func writeJSON(w http.ResponseWriter, r *http.Request, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		util.Log(r.Context()).WithError(err).Error("failed to encode accounting response")
+	}
+}
@@ -0,0 +1,247 @@
+// Package accounting tracks the resources each feature execution consumes and
+// enforces a per-execution budget on them.
+package accounting
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	appconfig "github.com/antinvestor/builder/apps/worker/config"
+)
+
+// ErrBudgetExceeded is returned when an execution has consumed more than its budget.
+var ErrBudgetExceeded = errors.New("execution resource budget exceeded")
+
+// Resource identifies a metered resource.
+type Resource string
+
+const (
+	// ResourceLLMTokens counts LLM tokens (input and output).
+	ResourceLLMTokens Resource = "llm_tokens"
+
+	// ResourceSandboxCPU counts sandbox CPU time in seconds.
+	ResourceSandboxCPU Resource = "sandbox_cpu_seconds"
+)
+
+// Model identifies the LLM that served a charge of tokens.
+type Model struct {
+	Provider string `json:"provider"`
+	Model    string `json:"model"`
+	Version  string `json:"version,omitempty"`
+}
+
+// ModelUsage is the LLM tokens an execution spent on one model.
+type ModelUsage struct {
+	Model
+	LLMTokens int `json:"llm_tokens"`
+}
+
+// Entry is a single charge against an execution, forming its resource timeline.
+type Entry struct {
+	Resource   Resource  `json:"resource"`
+	Amount     float64   `json:"amount"`
+	Phase      string    `json:"phase"`
+	Model      *Model    `json:"model,omitempty"`
+	RecordedAt time.Time `json:"recorded_at"`
+}
+
+// Usage is the cumulative resource consumption of an execution.
+type Usage struct {
+	ExecutionID       string       `json:"execution_id"`
+	LLMTokens         int          `json:"llm_tokens"`
+	Models            []ModelUsage `json:"models,omitempty"`
+	SandboxCPUSeconds float64      `json:"sandbox_cpu_seconds"`
+	WallTimeMS        int64        `json:"wall_time_ms"`
+	StartedAt         time.Time    `json:"started_at"`
+}
+
+// Budget caps the resources a single execution may consume. Zero values are unlimited.
+type Budget struct {
+	MaxLLMTokens         int     `json:"max_llm_tokens"`
+	MaxSandboxCPUSeconds float64 `json:"max_sandbox_cpu_seconds"`
+	MaxWallTimeMS        int64   `json:"max_wall_time_ms"`
+}
+
+// BudgetFromConfig builds the per-execution budget from worker configuration.
+// The wall time budget is the execution timeout.
+func BudgetFromConfig(cfg *appconfig.WorkerConfig) Budget {
+	return Budget{
+		MaxLLMTokens:         cfg.MaxLLMTokensPerExecution,
+		MaxSandboxCPUSeconds: cfg.MaxSandboxCPUSecondsPerExecution,
+		MaxWallTimeMS:        (time.Duration(cfg.ExecutionTimeoutHours) * time.Hour).Milliseconds(),
+	}
+}
+
+// Check returns an error wrapping ErrBudgetExceeded if usage exceeds the budget.
+func (b Budget) Check(usage Usage) error {
+	if b.MaxLLMTokens > 0 && usage.LLMTokens > b.MaxLLMTokens {
+		return fmt.Errorf("%w: %d LLM tokens used (max: %d)", ErrBudgetExceeded, usage.LLMTokens, b.MaxLLMTokens)
+	}
+	if b.MaxSandboxCPUSeconds > 0 && usage.SandboxCPUSeconds > b.MaxSandboxCPUSeconds {
+		return fmt.Errorf("%w: %.1f sandbox CPU seconds used (max: %.1f)",
+			ErrBudgetExceeded, usage.SandboxCPUSeconds, b.MaxSandboxCPUSeconds)
+	}
+	if b.MaxWallTimeMS > 0 && usage.WallTimeMS > b.MaxWallTimeMS {
+		return fmt.Errorf("%w: %s wall time elapsed (max: %s)", ErrBudgetExceeded,
+			time.Duration(usage.WallTimeMS)*time.Millisecond, time.Duration(b.MaxWallTimeMS)*time.Millisecond)
+	}
+	return nil
+}
+
+type executionLedger struct {
+	usage    Usage
+	timeline []Entry
+}
+
+// addModelTokens adds tokens to the usage of a model, in order of first use.
+func (e *executionLedger) addModelTokens(model Model, tokens int) {
+	for i := range e.usage.Models {
+		if e.usage.Models[i].Model == model {
+			e.usage.Models[i].LLMTokens += tokens
+			return
+		}
+	}
+	e.usage.Models = append(e.usage.Models, ModelUsage{Model: model, LLMTokens: tokens})
+}
+
+// Ledger accumulates resource usage per execution in memory, until the execution
+// is forgotten.
+type Ledger struct {
+	mu         sync.Mutex
+	budget     Budget
+	now        func() time.Time
+	executions map[string]*executionLedger
+}
+
+// NewLedger creates a ledger enforcing the given budget.
+func NewLedger(budget Budget) *Ledger {
+	return &Ledger{
+		budget:     budget,
+		now:        time.Now,
+		executions: make(map[string]*executionLedger),
+	}
+}
+
+// Budget returns the per-execution budget.
+func (l *Ledger) Budget() Budget {
+	return l.budget
+}
+
+// Start marks the beginning of an execution's wall time. It is a no-op for an
+// execution that has already been started or charged.
+func (l *Ledger) Start(executionID string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.execution(executionID)
+}
+
+// RecordLLMTokens charges LLM tokens to an execution and returns an error
+// wrapping ErrBudgetExceeded if the execution is now over budget.
+func (l *Ledger) RecordLLMTokens(executionID, phase string, tokens int) error {
+	return l.RecordLLMUsage(executionID, phase, tokens, Model{})
+}
+
+// RecordLLMUsage charges LLM tokens like RecordLLMTokens and attributes them to
+// the model that served them. A zero model leaves the tokens unattributed.
+func (l *Ledger) RecordLLMUsage(executionID, phase string, tokens int, model Model) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	exec := l.execution(executionID)
+	exec.usage.LLMTokens += tokens
+	entry := Entry{
+		Resource:   ResourceLLMTokens,
+		Amount:     float64(tokens),
+		Phase:      phase,
+		RecordedAt: l.now(),
+	}
+	if model != (Model{}) {
+		entry.Model = &model
+		exec.addModelTokens(model, tokens)
+	}
+	exec.timeline = append(exec.timeline, entry)
+	return l.budget.Check(l.snapshot(exec))
+}
+
+// RecordSandboxCPU charges sandbox CPU seconds to an execution and returns an
+// error wrapping ErrBudgetExceeded if the execution is now over budget.
+func (l *Ledger) RecordSandboxCPU(executionID, phase string, seconds float64) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	exec := l.execution(executionID)
+	exec.usage.SandboxCPUSeconds += seconds
+	exec.timeline = append(exec.timeline, Entry{
+		Resource:   ResourceSandboxCPU,
+		Amount:     seconds,
+		Phase:      phase,
+		RecordedAt: l.now(),
+	})
+	return l.budget.Check(l.snapshot(exec))
+}
+
+// Forget drops the usage of a finished execution.
+func (l *Ledger) Forget(executionID string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.executions, executionID)
+}
+
+// Check returns an error wrapping ErrBudgetExceeded if the execution is over budget.
+func (l *Ledger) Check(executionID string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	exec, ok := l.executions[executionID]
+	if !ok {
+		return nil
+	}
+	return l.budget.Check(l.snapshot(exec))
+}
+
+// Usage returns the cumulative usage of an execution.
+func (l *Ledger) Usage(executionID string) (Usage, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	exec, ok := l.executions[executionID]
+	if !ok {
+		return Usage{}, false
+	}
+	return l.snapshot(exec), true
+}
+
+// Timeline returns the charges recorded against an execution in order.
+func (l *Ledger) Timeline(executionID string) ([]Entry, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	exec, ok := l.executions[executionID]
+	if !ok {
+		return nil, false
+	}
+	return slices.Clone(exec.timeline), true
+}
+
+// execution returns the ledger for an execution, creating it if needed.
+// The caller must hold l.mu.
+func (l *Ledger) execution(executionID string) *executionLedger {
+	exec, ok := l.executions[executionID]
+	if !ok {
+		exec = &executionLedger{usage: Usage{ExecutionID: executionID, StartedAt: l.now()}}
+		l.executions[executionID] = exec
+	}
+	return exec
+}
+
+// snapshot returns the execution's usage with wall time measured up to now.
+// The caller must hold l.mu.
+func (l *Ledger) snapshot(exec *executionLedger) Usage {
+	usage := exec.usage
+	usage.Models = slices.Clone(exec.usage.Models)
+	usage.WallTimeMS = l.now().Sub(usage.StartedAt).Milliseconds()
+	return usage
+}
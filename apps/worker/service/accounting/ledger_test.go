@@ -0,0 +1,167 @@
+//nolint:testpackage // white-box testing requires control of the ledger clock
+package accounting
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestLedger(budget Budget, now *time.Time) *Ledger {
+	ledger := NewLedger(budget)
+	ledger.now = func() time.Time { return *now }
+	return ledger
+}
+
+func TestLedger_TokensPastBudgetExceeded(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	ledger := newTestLedger(Budget{MaxLLMTokens: 1000}, &now)
+
+	require.NoError(t, ledger.RecordLLMTokens("exec-1", "patch_generation", 600))
+	require.NoError(t, ledger.RecordLLMTokens("exec-1", "iteration", 400))
+
+	err := ledger.RecordLLMTokens("exec-1", "iteration", 1)
+	require.ErrorIs(t, err, ErrBudgetExceeded)
+	assert.Contains(t, err.Error(), "1001 LLM tokens")
+
+	usage, ok := ledger.Usage("exec-1")
+	require.True(t, ok)
+	assert.Equal(t, 1001, usage.LLMTokens)
+
+	// Other executions have their own budget
+	require.NoError(t, ledger.RecordLLMTokens("exec-2", "patch_generation", 500))
+}
+
+func TestLedger_ForgetDropsFinishedExecution(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	ledger := newTestLedger(Budget{MaxLLMTokens: 1000}, &now)
+	require.NoError(t, ledger.RecordLLMTokens("exec-1", "patch_generation", 600))
+	require.NoError(t, ledger.RecordLLMTokens("exec-2", "patch_generation", 100))
+
+	ledger.Forget("exec-1")
+
+	_, ok := ledger.Usage("exec-1")
+	assert.False(t, ok)
+	_, ok = ledger.Timeline("exec-1")
+	assert.False(t, ok)
+	_, ok = ledger.Usage("exec-2")
+	assert.True(t, ok)
+}
+
+func TestLedger_SandboxCPUPastBudgetExceeded(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	ledger := newTestLedger(Budget{MaxSandboxCPUSeconds: 60}, &now)
+
+	require.NoError(t, ledger.RecordSandboxCPU("exec-1", "test_execution", 45))
+	require.ErrorIs(t, ledger.RecordSandboxCPU("exec-1", "test_execution", 20), ErrBudgetExceeded)
+}
+
+func TestLedger_WallTimeBudget(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	ledger := newTestLedger(Budget{MaxWallTimeMS: time.Hour.Milliseconds()}, &now)
+
+	ledger.Start("exec-1")
+	require.NoError(t, ledger.Check("exec-1"))
+
+	now = now.Add(2 * time.Hour)
+	require.ErrorIs(t, ledger.Check("exec-1"), ErrBudgetExceeded)
+
+	usage, ok := ledger.Usage("exec-1")
+	require.True(t, ok)
+	assert.Equal(t, (2 * time.Hour).Milliseconds(), usage.WallTimeMS)
+
+	// Unknown executions are never over budget
+	require.NoError(t, ledger.Check("unknown"))
+}
+
+func TestLedger_ZeroBudgetIsUnlimited(t *testing.T) {
+	ledger := NewLedger(Budget{})
+
+	require.NoError(t, ledger.RecordLLMTokens("exec-1", "patch_generation", 10_000_000))
+	require.NoError(t, ledger.RecordSandboxCPU("exec-1", "test_execution", 1_000_000))
+}
+
+func TestCostHTTPHandler(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	ledger := newTestLedger(Budget{MaxLLMTokens: 100}, &now)
+	_ = ledger.RecordLLMTokens("exec-1", "patch_generation", 150)
+	_ = ledger.RecordSandboxCPU("exec-1", "test_execution", 12.5)
+
+	handler := NewCostHTTPHandler(ledger)
+
+	rec := httptest.NewRecorder()
+	handler(rec, httptest.NewRequest(http.MethodGet, "/api/v1/executions/cost?execution_id=exec-1", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var resp CostResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.Equal(t, 150, resp.Usage.LLMTokens)
+	assert.InDelta(t, 12.5, resp.Usage.SandboxCPUSeconds, 0.001)
+	assert.Equal(t, 100, resp.Budget.MaxLLMTokens)
+	assert.True(t, resp.Exhausted)
+	assert.NotEmpty(t, resp.Reason)
+
+	rec = httptest.NewRecorder()
+	handler(rec, httptest.NewRequest(http.MethodGet, "/api/v1/executions/cost?execution_id=missing", nil))
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	rec = httptest.NewRecorder()
+	handler(rec, httptest.NewRequest(http.MethodGet, "/api/v1/executions/cost", nil))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestTimelineHTTPHandler(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	ledger := newTestLedger(Budget{}, &now)
+	_ = ledger.RecordLLMTokens("exec-1", "patch_generation", 150)
+	now = now.Add(time.Minute)
+	_ = ledger.RecordSandboxCPU("exec-1", "test_execution", 30)
+
+	rec := httptest.NewRecorder()
+	NewTimelineHTTPHandler(ledger)(rec,
+		httptest.NewRequest(http.MethodGet, "/api/v1/executions/timeline?execution_id=exec-1", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var resp TimelineResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.Equal(t, "exec-1", resp.ExecutionID)
+	require.Len(t, resp.Entries, 2)
+	assert.Equal(t, ResourceLLMTokens, resp.Entries[0].Resource)
+	assert.Equal(t, "patch_generation", resp.Entries[0].Phase)
+	assert.Equal(t, ResourceSandboxCPU, resp.Entries[1].Resource)
+	assert.True(t, resp.Entries[1].RecordedAt.After(resp.Entries[0].RecordedAt))
+}
+
+func TestCostHTTPHandler_ReportsModelsUsed(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	ledger := newTestLedger(Budget{}, &now)
+	sonnet := Model{Provider: "anthropic", Model: "claude-sonnet-4", Version: "claude-sonnet-4-20250514"}
+	gpt := Model{Provider: "openai", Model: "gpt-4o", Version: "gpt-4o-2024-08-06"}
+	require.NoError(t, ledger.RecordLLMUsage("exec-1", "patch_generation", 100, sonnet))
+	require.NoError(t, ledger.RecordLLMUsage("exec-1", "iteration", 40, gpt))
+	require.NoError(t, ledger.RecordLLMUsage("exec-1", "iteration", 60, sonnet))
+	require.NoError(t, ledger.RecordLLMTokens("exec-1", "acceptance_self_check", 5))
+
+	rec := httptest.NewRecorder()
+	NewCostHTTPHandler(ledger)(rec,
+		httptest.NewRequest(http.MethodGet, "/api/v1/executions/cost?execution_id=exec-1", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var resp CostResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.Equal(t, 205, resp.Usage.LLMTokens)
+	assert.Equal(t, []ModelUsage{
+		{Model: sonnet, LLMTokens: 160},
+		{Model: gpt, LLMTokens: 40},
+	}, resp.Usage.Models)
+
+	entries, ok := ledger.Timeline("exec-1")
+	require.True(t, ok)
+	assert.Equal(t, &gpt, entries[1].Model)
+	assert.Nil(t, entries[3].Model)
+}
@@ -0,0 +1,155 @@
+package events
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/pitabwire/util"
+
+	appconfig "github.com/antinvestor/builder/apps/worker/config"
+	"github.com/antinvestor/builder/apps/worker/service/accounting"
+	"github.com/antinvestor/builder/internal/events"
+)
+
+// AcceptanceChecker asks the LLM whether generated changes satisfy the acceptance criteria.
+type AcceptanceChecker interface {
+	SelfCheckAcceptance(ctx context.Context, req *AcceptanceSelfCheckRequest) (*AcceptanceSelfCheckResponse, error)
+}
+
+// AcceptanceSelfCheckRequest contains the changes to assess against a specification.
+type AcceptanceSelfCheckRequest struct {
+	ExecutionID   events.ExecutionID
+	Specification events.FeatureSpecification
+	Patches       []Patch
+}
+
+// AcceptanceSelfCheckResponse contains one assessment per acceptance criterion.
+type AcceptanceSelfCheckResponse struct {
+	Criteria   []events.AcceptanceCriterionAssessment
+	TokensUsed int
+	// LLM identifies the provider, model and model version that made the assessment.
+	LLM events.LLMProcessingInfo
+}
+
+// AcceptanceSelfCheck runs the acceptance-criteria self-check after patch generation
+// and holds each execution's assessment until its review request is sent or the
+// execution ends.
+type AcceptanceSelfCheck struct {
+	cfg     *appconfig.WorkerConfig
+	checker AcceptanceChecker
+	ledger  *accounting.Ledger
+	now     func() time.Time
+
+	mu          sync.Mutex
+	assessments map[events.ExecutionID]*events.AcceptanceSelfAssessment
+}
+
+// NewAcceptanceSelfCheck creates a self-check charging its tokens to the ledger, which may be nil.
+func NewAcceptanceSelfCheck(
+	cfg *appconfig.WorkerConfig,
+	checker AcceptanceChecker,
+	ledger *accounting.Ledger,
+) *AcceptanceSelfCheck {
+	return &AcceptanceSelfCheck{
+		cfg:         cfg,
+		checker:     checker,
+		ledger:      ledger,
+		now:         time.Now,
+		assessments: make(map[events.ExecutionID]*events.AcceptanceSelfAssessment),
+	}
+}
+
+// Run assesses the patches against the specification's acceptance criteria and
+// returns the assessment, or nil when none was made. It is skipped when disabled,
+// when there are no criteria or when the execution's remaining token budget is too
+// small. A failed self-check never fails the execution.
+func (c *AcceptanceSelfCheck) Run(
+	ctx context.Context,
+	execID events.ExecutionID,
+	spec events.FeatureSpecification,
+	patches []Patch,
+) *events.AcceptanceSelfAssessment {
+	log := util.Log(ctx)
+
+	if !c.cfg.AcceptanceSelfCheckEnabled || len(spec.AcceptanceCriteria) == 0 {
+		return nil
+	}
+
+	if !c.withinBudget(execID) {
+		log.Info("skipping acceptance self-check, token budget too low",
+			"execution_id", execID.String(),
+		)
+		return nil
+	}
+
+	resp, err := c.checker.SelfCheckAcceptance(ctx, &AcceptanceSelfCheckRequest{
+		ExecutionID:   execID,
+		Specification: spec,
+		Patches:       patches,
+	})
+	if err != nil {
+		log.WithError(err).Warn("acceptance self-check failed", "execution_id", execID.String())
+		return nil
+	}
+
+	if c.ledger != nil {
+		if recordErr := c.ledger.RecordLLMUsage(
+			execID.String(), "acceptance_self_check", resp.TokensUsed, ledgerModel(resp.LLM),
+		); recordErr != nil {
+			log.WithError(recordErr).Warn("acceptance self-check exhausted the token budget",
+				"execution_id", execID.String(),
+			)
+		}
+	}
+
+	assessment := &events.AcceptanceSelfAssessment{
+		Criteria:   resp.Criteria,
+		TokensUsed: resp.TokensUsed,
+		LLMInfo:    resp.LLM,
+		AssessedAt: c.now(),
+	}
+
+	log.Info("acceptance self-check completed",
+		"execution_id", execID.String(),
+		"criteria", len(assessment.Criteria),
+		"unmet", len(assessment.UnmetCriteria()),
+	)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.assessments[execID] = assessment
+	return assessment
+}
+
+// Take returns and forgets the assessment recorded for an execution.
+func (c *AcceptanceSelfCheck) Take(execID events.ExecutionID) *events.AcceptanceSelfAssessment {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	assessment := c.assessments[execID]
+	delete(c.assessments, execID)
+	return assessment
+}
+
+// Forget drops the assessment held for an execution that ended before it was taken.
+func (c *AcceptanceSelfCheck) Forget(execID events.ExecutionID) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.assessments, execID)
+}
+
+// withinBudget reports whether enough LLM tokens remain for the self-check.
+func (c *AcceptanceSelfCheck) withinBudget(execID events.ExecutionID) bool {
+	if c.ledger == nil {
+		return true
+	}
+
+	budget := c.ledger.Budget()
+	if budget.MaxLLMTokens <= 0 {
+		return true
+	}
+
+	usage, _ := c.ledger.Usage(execID.String())
+	return budget.MaxLLMTokens-usage.LLMTokens >= c.cfg.AcceptanceSelfCheckMinTokens
+}
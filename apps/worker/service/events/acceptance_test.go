@@ -0,0 +1,174 @@
+//nolint:testpackage // white-box testing requires internal package access
+package events
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	appconfig "github.com/antinvestor/builder/apps/worker/config"
+	"github.com/antinvestor/builder/apps/worker/service/accounting"
+	"github.com/antinvestor/builder/internal/events"
+)
+
+// stubAcceptanceChecker reports the second criterion of every request as unmet.
+type stubAcceptanceChecker struct {
+	calls int
+	err   error
+}
+
+func (s *stubAcceptanceChecker) SelfCheckAcceptance(
+	_ context.Context,
+	req *AcceptanceSelfCheckRequest,
+) (*AcceptanceSelfCheckResponse, error) {
+	s.calls++
+	if s.err != nil {
+		return nil, s.err
+	}
+
+	resp := &AcceptanceSelfCheckResponse{TokensUsed: 500}
+	for i, criterion := range req.Specification.AcceptanceCriteria {
+		assessment := events.AcceptanceCriterionAssessment{Criterion: criterion, Satisfied: i != 1}
+		if !assessment.Satisfied {
+			assessment.Rationale = "no pagination parameters are handled"
+		}
+		resp.Criteria = append(resp.Criteria, assessment)
+	}
+	return resp, nil
+}
+
+func newAcceptanceSpec() events.FeatureSpecification {
+	return events.FeatureSpecification{
+		Title:              "List users endpoint",
+		AcceptanceCriteria: []string{"Returns all users", "Supports pagination"},
+	}
+}
+
+// requestReview runs the review request handler for a passing test run and
+// returns the published review request.
+func requestReview(
+	t *testing.T,
+	check *AcceptanceSelfCheck,
+	execID events.ExecutionID,
+) *events.ComprehensiveReviewRequestedPayload {
+	t.Helper()
+	cfg := &appconfig.WorkerConfig{QueueReviewRequestName: "review-request-queue"}
+	queueMan := &mockQueueManager{}
+
+	handler := NewReviewRequestEvent(cfg, queueMan, nil, &mockEmitter{})
+	handler.SetAcceptanceSelfCheck(check)
+
+	require.NoError(t, handler.Execute(context.Background(), &events.TestExecutionCompletedPayload{
+		ExecutionID: execID,
+		Success:     true,
+		Result:      &events.TestResult{TotalTests: 1, PassedTests: 1, Success: true},
+	}))
+
+	require.Len(t, queueMan.publishedMessages, 1)
+	reviewRequest, ok := queueMan.publishedMessages[0].payload.(*events.ComprehensiveReviewRequestedPayload)
+	require.True(t, ok)
+	return reviewRequest
+}
+
+func TestAcceptanceSelfCheck_UnmetCriterionInReviewContext(t *testing.T) {
+	cfg := &appconfig.WorkerConfig{AcceptanceSelfCheckEnabled: true, AcceptanceSelfCheckMinTokens: 1000}
+	checker := &stubAcceptanceChecker{}
+	ledger := accounting.NewLedger(accounting.Budget{MaxLLMTokens: 10_000})
+	check := NewAcceptanceSelfCheck(cfg, checker, ledger)
+	execID := events.NewExecutionID()
+
+	check.Run(context.Background(), execID, newAcceptanceSpec(), []Patch{{FilePath: "users.go"}})
+
+	reviewRequest := requestReview(t, check, execID)
+	require.NotNil(t, reviewRequest.Context)
+	assessment := reviewRequest.Context.AcceptanceAssessment
+	require.NotNil(t, assessment)
+	assert.Len(t, assessment.Criteria, 2)
+
+	unmet := assessment.UnmetCriteria()
+	require.Len(t, unmet, 1)
+	assert.Equal(t, "Supports pagination", unmet[0].Criterion)
+	assert.NotEmpty(t, unmet[0].Rationale)
+
+	// The self-check is charged to the execution's budget
+	usage, ok := ledger.Usage(execID.String())
+	require.True(t, ok)
+	assert.Equal(t, 500, usage.LLMTokens)
+
+	// The assessment is attached to one review request only
+	assert.Nil(t, check.Take(execID))
+}
+
+func TestAcceptanceSelfCheck_SkippedWhenDisabled(t *testing.T) {
+	checker := &stubAcceptanceChecker{}
+	check := NewAcceptanceSelfCheck(&appconfig.WorkerConfig{}, checker, nil)
+	execID := events.NewExecutionID()
+
+	check.Run(context.Background(), execID, newAcceptanceSpec(), nil)
+
+	assert.Zero(t, checker.calls)
+	assert.Nil(t, requestReview(t, check, execID).Context)
+}
+
+func TestAcceptanceSelfCheck_SkippedWhenBudgetTooLow(t *testing.T) {
+	cfg := &appconfig.WorkerConfig{AcceptanceSelfCheckEnabled: true, AcceptanceSelfCheckMinTokens: 1000}
+	checker := &stubAcceptanceChecker{}
+	ledger := accounting.NewLedger(accounting.Budget{MaxLLMTokens: 10_000})
+	check := NewAcceptanceSelfCheck(cfg, checker, ledger)
+	execID := events.NewExecutionID()
+	require.NoError(t, ledger.RecordLLMTokens(execID.String(), "patch_generation", 9_500))
+
+	check.Run(context.Background(), execID, newAcceptanceSpec(), nil)
+
+	assert.Zero(t, checker.calls)
+	assert.Nil(t, requestReview(t, check, execID).Context)
+}
+
+func TestAcceptanceSelfCheck_FailureDoesNotBlockReview(t *testing.T) {
+	cfg := &appconfig.WorkerConfig{AcceptanceSelfCheckEnabled: true}
+	checker := &stubAcceptanceChecker{err: errors.New("provider unavailable")}
+	check := NewAcceptanceSelfCheck(cfg, checker, nil)
+	execID := events.NewExecutionID()
+
+	check.Run(context.Background(), execID, newAcceptanceSpec(), nil)
+
+	assert.Equal(t, 1, checker.calls)
+	assert.Nil(t, requestReview(t, check, execID).Context)
+}
+
+func TestAcceptanceSelfCheck_AssessmentDeliveredWithFeature(t *testing.T) {
+	cfg := &appconfig.WorkerConfig{AcceptanceSelfCheckEnabled: true}
+	svc, request := checkoutGoModule(t, cfg)
+	request.Spec.AcceptanceCriteria = newAcceptanceSpec().AcceptanceCriteria
+	client := &scriptedBAMLClient{responses: []*GeneratePatchResponse{calcPatch(fixedCalc)}}
+	emitter := &mockEmitter{}
+
+	handler := NewPatchGenerationEvent(cfg, client, svc, nil, emitter)
+	handler.SetAcceptanceSelfCheck(NewAcceptanceSelfCheck(cfg, &stubAcceptanceChecker{}, nil))
+
+	require.NoError(t, handler.Execute(context.Background(), request))
+
+	delivered := findEmitted(emitter, events.FeatureDelivered)
+	require.Len(t, delivered, 1)
+	payload, ok := delivered[0].(*events.FeatureDeliveredPayload)
+	require.True(t, ok)
+	require.NotNil(t, payload.Summary.AcceptanceAssessment)
+	assert.Len(t, payload.Summary.AcceptanceAssessment.UnmetCriteria(), 1)
+}
+
+func TestAcceptanceSelfCheck_ForgottenWhenExecutionEnds(t *testing.T) {
+	cfg := &appconfig.WorkerConfig{AcceptanceSelfCheckEnabled: true}
+	check := NewAcceptanceSelfCheck(cfg, &stubAcceptanceChecker{}, nil)
+	store := NewExecutionContextStore()
+	store.OnRelease(check.Forget)
+	execID := events.NewExecutionID()
+	store.Start(cfg, &events.FeatureExecutionInitializedPayload{ExecutionID: execID})
+
+	require.NotNil(t, check.Run(context.Background(), execID, newAcceptanceSpec(), nil))
+	store.Delete(execID)
+
+	assert.Nil(t, check.Take(execID))
+}
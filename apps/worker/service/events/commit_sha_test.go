@@ -0,0 +1,111 @@
+//nolint:testpackage // white-box testing requires internal package access
+package events
+
+import (
+	"context"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	appconfig "github.com/antinvestor/builder/apps/worker/config"
+	"github.com/antinvestor/builder/internal/events"
+)
+
+func gitRevParse(t *testing.T, dir, ref string) string {
+	t.Helper()
+	cmd := exec.Command("git", "rev-parse", ref)
+	cmd.Dir = dir
+	output, err := cmd.Output()
+	require.NoError(t, err)
+	return strings.TrimSpace(string(output))
+}
+
+func TestCommitSHA_PropagatesFromIterationToDelivery(t *testing.T) {
+	ctx := context.Background()
+	cfg := &appconfig.WorkerConfig{ReviewThresholds: events.ReviewThresholds{MaxIterations: 3}}
+	svc, checkout := checkoutGoModule(t, cfg)
+	runTestGit(t, checkout.WorkspacePath, "checkout", "-q", "-b", "feature/calc")
+
+	store := NewExecutionContextStore()
+	store.Start(cfg, &events.FeatureExecutionInitializedPayload{
+		ExecutionID: checkout.ExecutionID,
+		Repository:  events.RepositoryContext{FeatureBranchName: "feature/calc"},
+	})
+	emitter := &mockEmitter{}
+
+	// The iteration commits its patches and reports the commit
+	iteration := NewIterationEvent(cfg, &mockBAMLClient{generatePatchResponse: &GeneratePatchResponse{
+		Patches: []Patch{{
+			FilePath:   "calc.go",
+			Action:     events.FileActionCreate,
+			NewContent: "package calc\n\nfunc Add(a, b int) int { return a + b }\n",
+		}},
+	}}, nil, emitter)
+	iteration.SetExecutionContexts(store)
+	iteration.SetIterationCommitter(svc)
+	require.NoError(t, iteration.Execute(ctx, &events.FeatureIterationRequestedPayload{
+		ExecutionID:     checkout.ExecutionID,
+		IterationNumber: 1,
+	}))
+
+	completed := findEmitted(emitter, events.PatchGenerationCompleted)
+	require.Len(t, completed, 1)
+	generation, ok := completed[0].(*events.PatchGenerationCompletedPayload)
+	require.True(t, ok)
+	iterationSHA := gitRevParse(t, checkout.WorkspacePath, "HEAD")
+	assert.Equal(t, iterationSHA, generation.FinalCommitSHA)
+
+	// Approval pushes that commit and delivers it
+	review := NewReviewResultEvent(cfg, svc, nil, nil, emitter)
+	review.SetExecutionContexts(store)
+	require.NoError(t, review.Execute(ctx, &events.ComprehensiveReviewCompletedPayload{
+		ExecutionID: checkout.ExecutionID,
+		Decision:    events.ControlDecisionApprove,
+	}))
+
+	pushes := findEmitted(emitter, events.GitPushCompleted)
+	require.Len(t, pushes, 1)
+	push, ok := pushes[0].(*events.GitPushCompletedPayload)
+	require.True(t, ok)
+	assert.Equal(t, iterationSHA, push.RemoteCommitSHA)
+	assert.Equal(t, iterationSHA, gitRevParse(t, checkout.RepositoryURL, "refs/heads/feature/calc"))
+
+	deliveries := findEmitted(emitter, events.FeatureDelivered)
+	require.Len(t, deliveries, 1)
+	delivered, ok := deliveries[0].(*events.FeatureDeliveredPayload)
+	require.True(t, ok)
+	assert.Equal(t, iterationSHA, delivered.HeadCommitSHA)
+
+	// The completed execution links the delivered commit
+	delivery := NewDeliveryEvent(cfg, svc, nil, emitter)
+	require.NoError(t, delivery.Execute(ctx, push))
+
+	executions := findEmitted(emitter, events.FeatureExecutionCompleted)
+	require.Len(t, executions, 1)
+	execution, ok := executions[0].(*events.FeatureExecutionCompletedPayload)
+	require.True(t, ok)
+	assert.Equal(t, iterationSHA, execution.FinalCommit.SHA)
+}
+
+func TestIterationEvent_UnchangedTreeReportsHeadCommit(t *testing.T) {
+	cfg := &appconfig.WorkerConfig{ReviewThresholds: events.ReviewThresholds{MaxIterations: 3}}
+	svc, checkout := checkoutGoModule(t, cfg)
+	emitter := &mockEmitter{}
+
+	iteration := NewIterationEvent(cfg, &mockBAMLClient{}, nil, emitter)
+	iteration.SetIterationCommitter(svc)
+	require.NoError(t, iteration.Execute(context.Background(), &events.FeatureIterationRequestedPayload{
+		ExecutionID:     checkout.ExecutionID,
+		IterationNumber: 1,
+	}))
+
+	completed := findEmitted(emitter, events.PatchGenerationCompleted)
+	require.Len(t, completed, 1)
+	generation, ok := completed[0].(*events.PatchGenerationCompletedPayload)
+	require.True(t, ok)
+	assert.Equal(t, gitRevParse(t, checkout.WorkspacePath, "HEAD"), generation.FinalCommitSHA)
+	assert.Empty(t, findEmitted(emitter, events.GitCommitCreated))
+}
@@ -0,0 +1,203 @@
+//nolint:testpackage // white-box testing requires internal package access
+package events
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	appconfig "github.com/antinvestor/builder/apps/worker/config"
+	"github.com/antinvestor/builder/apps/worker/service/repository"
+	"github.com/antinvestor/builder/internal/events"
+)
+
+const (
+	brokenCalc = "package calc\n\nfunc Add(a, b int) int { return a + }\n"
+	fixedCalc  = "package calc\n\nfunc Add(a, b int) int { return a + b }\n"
+)
+
+// scriptedBAMLClient returns its responses in order and records every request.
+type scriptedBAMLClient struct {
+	responses []*GeneratePatchResponse
+	requests  []*GeneratePatchRequest
+}
+
+func (c *scriptedBAMLClient) GeneratePatch(
+	_ context.Context,
+	req *GeneratePatchRequest,
+) (*GeneratePatchResponse, error) {
+	c.requests = append(c.requests, req)
+	resp := c.responses[min(len(c.requests), len(c.responses))-1]
+	copied := *resp
+	return &copied, nil
+}
+
+func calcPatch(content string) *GeneratePatchResponse {
+	return &GeneratePatchResponse{
+		Patches:    []Patch{{FilePath: "calc.go", NewContent: content, Action: events.FileActionCreate}},
+		TokensUsed: 100,
+	}
+}
+
+func runTestGit(t *testing.T, dir string, args ...string) {
+	t.Helper()
+	cmd := exec.Command("git", append([]string{
+		"-c", "user.name=Test", "-c", "user.email=test@example.com", "-c", "commit.gpgsign=false",
+	}, args...)...)
+	cmd.Dir = dir
+	output, err := cmd.CombinedOutput()
+	require.NoError(t, err, string(output))
+}
+
+// checkoutGoModule checks out an origin repository containing an empty Go module.
+func checkoutGoModule(
+	t *testing.T,
+	cfg *appconfig.WorkerConfig,
+) (*repository.Service, *events.RepositoryCheckoutCompletedPayload) {
+	t.Helper()
+	for _, tool := range []string{"git", "go"} {
+		if _, err := exec.LookPath(tool); err != nil {
+			t.Skipf("%s not available", tool)
+		}
+	}
+
+	origin := t.TempDir()
+	runTestGit(t, origin, "init", "-q", "-b", "main")
+	goMod := []byte("module example.com/calc\n\ngo 1.21\n")
+	require.NoError(t, os.WriteFile(filepath.Join(origin, "go.mod"), goMod, 0o600))
+	runTestGit(t, origin, "add", "-A")
+	runTestGit(t, origin, "commit", "-q", "-m", "initial")
+
+	cfg.WorkspaceBasePath = t.TempDir()
+	cfg.MaxConcurrentClones = 1
+	cfg.CloneTimeoutSeconds = 30
+	svc := repository.NewService(cfg, repository.NewWorkspaceRepository(context.Background(), nil))
+
+	execID := events.NewExecutionID()
+	checkout, err := svc.Checkout(context.Background(), &repository.CheckoutRequest{
+		ExecutionID:   execID,
+		RepositoryURL: origin,
+		Branch:        "main",
+	})
+	require.NoError(t, err)
+
+	return svc, &events.RepositoryCheckoutCompletedPayload{
+		ExecutionID:       execID,
+		WorkspacePath:     checkout.WorkspacePath,
+		BranchName:        "main",
+		FeatureBranchName: "feature/calc",
+		RepositoryURL:     origin,
+		Spec:              events.FeatureSpecification{Title: "Add calculator"},
+	}
+}
+
+func findEmitted(emitter *mockEmitter, name events.EventType) []any {
+	var payloads []any
+	for _, evt := range emitter.emittedEvents {
+		if evt.name == string(name) {
+			payloads = append(payloads, evt.payload)
+		}
+	}
+	return payloads
+}
+
+func TestPatchGenerationEvent_CompileFailureIteratesWithCompilerOutput(t *testing.T) {
+	cfg := &appconfig.WorkerConfig{CompileCheckEnabled: true, CompileCheckMaxRetries: 2}
+	svc, request := checkoutGoModule(t, cfg)
+	client := &scriptedBAMLClient{responses: []*GeneratePatchResponse{calcPatch(brokenCalc), calcPatch(fixedCalc)}}
+	emitter := &mockEmitter{}
+
+	handler := NewPatchGenerationEvent(cfg, client, svc, nil, emitter)
+	handler.SetCompileChecker(svc)
+
+	require.NoError(t, handler.Execute(context.Background(), request))
+
+	// The broken patch was regenerated immediately with the compiler errors
+	require.Len(t, client.requests, 2)
+	retry := client.requests[1]
+	assert.Equal(t, 2, retry.IterationNumber)
+	assert.Contains(t, retry.FeedbackFromReview, "go build ./...")
+	assert.Contains(t, retry.FeedbackFromReview, "calc.go:3")
+	require.Len(t, retry.PreviousPatches, 1)
+	assert.Equal(t, brokenCalc, retry.PreviousPatches[0].NewContent)
+
+	iterations := findEmitted(emitter, events.IterationStarted)
+	require.Len(t, iterations, 1)
+	started, ok := iterations[0].(*events.IterationStartedPayload)
+	require.True(t, ok)
+	assert.Equal(t, events.IterationReasonBuildFailed, started.Reason)
+
+	// Only the compiling version is committed, charged for both attempts
+	completed := findEmitted(emitter, events.PatchGenerationCompleted)
+	require.Len(t, completed, 1)
+	payload, ok := completed[0].(*events.PatchGenerationCompletedPayload)
+	require.True(t, ok)
+	assert.Equal(t, 200, payload.TotalLLMTokens)
+	assert.Equal(t, 1, payload.FilesCreated)
+
+	content, err := os.ReadFile(filepath.Join(request.WorkspacePath, "calc.go"))
+	require.NoError(t, err)
+	assert.Equal(t, fixedCalc, string(content))
+}
+
+func TestPatchGenerationEvent_CompileFailureExhaustsRetries(t *testing.T) {
+	cfg := &appconfig.WorkerConfig{CompileCheckEnabled: true, CompileCheckMaxRetries: 1}
+	svc, request := checkoutGoModule(t, cfg)
+	client := &scriptedBAMLClient{responses: []*GeneratePatchResponse{calcPatch(brokenCalc)}}
+	emitter := &mockEmitter{}
+
+	handler := NewPatchGenerationEvent(cfg, client, svc, nil, emitter)
+	handler.SetCompileChecker(svc)
+
+	err := handler.Execute(context.Background(), request)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "compile_check failed")
+	assert.Len(t, client.requests, 2)
+
+	failures := findEmitted(emitter, events.PatchGenerationStepFailed)
+	require.Len(t, failures, 1)
+	failure, ok := failures[0].(*events.PatchGenerationStepFailedPayload)
+	require.True(t, ok)
+	assert.Equal(t, events.StepErrorCategorySyntax, failure.ErrorCategory)
+	assert.Contains(t, failure.ErrorMessage, "calc.go:3")
+	assert.Empty(t, findEmitted(emitter, events.GitCommitCreated))
+}
+
+func TestPatchGenerationEvent_CompileCheckDisabled(t *testing.T) {
+	cfg := &appconfig.WorkerConfig{}
+	svc, request := checkoutGoModule(t, cfg)
+	client := &scriptedBAMLClient{responses: []*GeneratePatchResponse{calcPatch(brokenCalc)}}
+
+	handler := NewPatchGenerationEvent(cfg, client, svc, nil, &mockEmitter{})
+	handler.SetCompileChecker(svc)
+
+	require.NoError(t, handler.Execute(context.Background(), request))
+	assert.Len(t, client.requests, 1)
+}
+
+func TestMergePatches(t *testing.T) {
+	merged := mergePatches(
+		[]Patch{
+			{FilePath: "new.go", NewContent: "v1", Action: events.FileActionCreate},
+			{FilePath: "old.go", OldContent: "orig", NewContent: "v1", Action: events.FileActionModify},
+		},
+		[]Patch{
+			{FilePath: "new.go", OldContent: "v1", NewContent: "v2", Action: events.FileActionModify},
+			{FilePath: "old.go", OldContent: "v1", NewContent: "v2", Action: events.FileActionModify},
+			{FilePath: "other.go", NewContent: "v1", Action: events.FileActionCreate},
+		},
+	)
+
+	require.Len(t, merged, 3)
+	assert.Equal(t, Patch{FilePath: "new.go", NewContent: "v2", Action: events.FileActionCreate}, merged[0])
+	assert.Equal(t,
+		Patch{FilePath: "old.go", OldContent: "orig", NewContent: "v2", Action: events.FileActionModify},
+		merged[1],
+	)
+	assert.Equal(t, "other.go", merged[2].FilePath)
+}
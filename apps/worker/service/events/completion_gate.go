@@ -0,0 +1,124 @@
+package events
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/pitabwire/util"
+
+	"github.com/antinvestor/builder/internal/events"
+)
+
+// completionGateErrorCode is the error code of an execution the completion gate blocked.
+const completionGateErrorCode = "completion_gate_blocked"
+
+// CompletionEvidence is what the completion gate checks: the outcome of an
+// execution's latest test run and the review decision made on it.
+type CompletionEvidence struct {
+	// TestsRun is set once a test run of the current patches completed.
+	TestsRun bool
+	// BuildSucceeded reports whether the run built and executed the tests.
+	BuildSucceeded bool
+	// TestsPassed reports whether the tests passed, counting a run in which only
+	// quarantined flaky tests failed as passing.
+	TestsPassed      bool
+	QuarantinedTests []string
+	// ReviewDecision is the decision of the review of this run, empty until it completes.
+	ReviewDecision events.ControlDecision
+}
+
+// recordTestRun records a completed test run. It supersedes the review of any
+// earlier run, so the decision is cleared until this run is reviewed.
+func (e *CompletionEvidence) recordTestRun(
+	request *events.TestExecutionCompletedPayload,
+	result *events.TestResult,
+	passed bool,
+) {
+	*e = CompletionEvidence{
+		TestsRun:       true,
+		BuildSucceeded: request.Error == nil && result != nil,
+		TestsPassed:    passed,
+	}
+	if result != nil {
+		e.QuarantinedTests = result.QuarantinedTests
+	}
+}
+
+// BlockingReasons returns why the execution may not complete, or nothing when
+// the build succeeded, the tests passed and the review approved.
+func (e CompletionEvidence) BlockingReasons() []string {
+	var reasons []string
+	switch {
+	case !e.TestsRun:
+		reasons = append(reasons, "no completed build and test run")
+	case !e.BuildSucceeded:
+		reasons = append(reasons, "build did not succeed")
+	case !e.TestsPassed:
+		reasons = append(reasons, "tests did not pass")
+	}
+
+	switch {
+	case e.ReviewApproved():
+	case e.ReviewDecision == "":
+		reasons = append(reasons, "review has not completed")
+	default:
+		reasons = append(reasons, fmt.Sprintf("review decision is %s, not approve", e.ReviewDecision))
+	}
+	return reasons
+}
+
+// ReviewApproved reports whether the review of the latest run approved it.
+func (e CompletionEvidence) ReviewApproved() bool {
+	switch e.ReviewDecision {
+	case events.ControlDecisionApprove, events.ControlDecisionApproveWithWarnings, events.ControlDecisionMarkComplete:
+		return true
+	default:
+		return false
+	}
+}
+
+// completionBlockedError describes the reasons the completion gate blocked an execution.
+func completionBlockedError(reasons []string) string {
+	return "completion blocked: " + strings.Join(reasons, "; ")
+}
+
+// completionBlockers returns why the completion gate blocks an execution.
+func completionBlockers(store *ExecutionContextStore, executionID events.ExecutionID) []string {
+	execCtx, ok := store.Get(executionID)
+	if !ok {
+		return []string{"no record of the execution's build, tests or review"}
+	}
+	return execCtx.Completion.BlockingReasons()
+}
+
+// emitCompletionBlocked fails an approved execution whose build, tests or review
+// did not pass the completion gate, so it is neither pushed nor completed.
+func emitCompletionBlocked(
+	ctx context.Context,
+	eventsMan Emitter,
+	executionID events.ExecutionID,
+	branchName string,
+	reasons []string,
+) error {
+	util.Log(ctx).Warn("completion gate blocked execution",
+		"execution_id", executionID.String(),
+		"branch_name", branchName,
+		"reasons", reasons,
+	)
+	classification := events.FailureClassification{
+		Type:           events.FailureTypeDeterministic,
+		Severity:       events.FailureSeverityError,
+		Retryable:      false,
+		UserActionable: true,
+	}
+	return eventsMan.Emit(ctx, string(events.FeatureExecutionFailed), &events.FeatureExecutionFailedPayload{
+		ExecutionID:    executionID,
+		Classification: classification,
+		FailedPhase:    events.ExecutionPhaseDelivery,
+		ErrorCode:      completionGateErrorCode,
+		ErrorMessage:   completionBlockedError(reasons),
+		ErrorContext:   map[string]string{"branch_name": branchName},
+		Recovery:       failureRecovery(classification, events.ExecutionPhaseDelivery, false),
+	})
+}
@@ -0,0 +1,223 @@
+//nolint:testpackage // white-box testing requires internal package access
+package events
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	appconfig "github.com/antinvestor/builder/apps/worker/config"
+	"github.com/antinvestor/builder/internal/events"
+)
+
+// passedEvidence is the evidence of an execution that may complete.
+func passedEvidence() CompletionEvidence {
+	return CompletionEvidence{
+		TestsRun:       true,
+		BuildSucceeded: true,
+		TestsPassed:    true,
+		ReviewDecision: events.ControlDecisionApprove,
+	}
+}
+
+// gatedExecution is a checked-out execution whose review results are handled
+// with the completion gate enabled.
+type gatedExecution struct {
+	request *events.RepositoryCheckoutCompletedPayload
+	store   *ExecutionContextStore
+	emitter *mockEmitter
+	review  *ReviewResultEvent
+}
+
+// newGatedExecution checks out an execution on feature/gated. Its context is
+// recorded unless started is false.
+func newGatedExecution(t *testing.T, started bool) *gatedExecution {
+	t.Helper()
+	cfg := &appconfig.WorkerConfig{CompletionGateEnabled: true}
+	svc, request := checkoutGoModule(t, cfg)
+	runTestGit(t, request.WorkspacePath, "checkout", "-q", "-b", "feature/gated")
+
+	store := NewExecutionContextStore()
+	if started {
+		store.Start(cfg, &events.FeatureExecutionInitializedPayload{
+			ExecutionID: request.ExecutionID,
+			Repository:  events.RepositoryContext{FeatureBranchName: "feature/gated"},
+		})
+	}
+	emitter := &mockEmitter{}
+	review := NewReviewResultEvent(cfg, svc, nil, nil, emitter)
+	review.SetExecutionContexts(store)
+	return &gatedExecution{request: request, store: store, emitter: emitter, review: review}
+}
+
+// completeTests reports run as the execution's test run to tests, or to a
+// handler without flaky test tracking when tests is nil.
+func (g *gatedExecution) completeTests(
+	t *testing.T,
+	tests *ReviewRequestEvent,
+	run *events.TestExecutionCompletedPayload,
+) {
+	t.Helper()
+	if tests == nil {
+		cfg := &appconfig.WorkerConfig{ReviewThresholds: events.ReviewThresholds{MaxIterations: 3}}
+		tests = NewReviewRequestEvent(cfg, &mockQueueManager{}, nil, &mockEmitter{})
+	}
+	tests.SetExecutionContexts(g.store)
+	run.ExecutionID = g.request.ExecutionID
+	require.NoError(t, tests.Execute(context.Background(), run))
+}
+
+// approve reports the review of the execution with decision.
+func (g *gatedExecution) approve(t *testing.T, decision events.ControlDecision) {
+	t.Helper()
+	require.NoError(t, g.review.Execute(context.Background(), &events.ComprehensiveReviewCompletedPayload{
+		ExecutionID: g.request.ExecutionID,
+		Decision:    decision,
+	}))
+}
+
+// blockedFailure returns the failure a blocked delivery emitted, after checking
+// that nothing was pushed.
+func blockedFailure(t *testing.T, eventsMan *mockEmitter) *events.FeatureExecutionFailedPayload {
+	t.Helper()
+	assert.Empty(t, findEmitted(eventsMan, events.GitPushStarted), "a blocked branch is not pushed")
+	assert.Empty(t, findEmitted(eventsMan, events.FeatureDelivered))
+	failures := findEmitted(eventsMan, events.FeatureExecutionFailed)
+	require.Len(t, failures, 1)
+	failed, ok := failures[0].(*events.FeatureExecutionFailedPayload)
+	require.True(t, ok)
+	return failed
+}
+
+func TestReviewResultEvent_CompletionGate_AllPassed(t *testing.T) {
+	execution := newGatedExecution(t, true)
+	execution.completeTests(t, nil, testRun(true, true))
+	execution.approve(t, events.ControlDecisionApprove)
+
+	assert.Len(t, findEmitted(execution.emitter, events.GitPushCompleted), 1)
+	assert.Len(t, findEmitted(execution.emitter, events.FeatureDelivered), 1)
+	assert.Empty(t, findEmitted(execution.emitter, events.FeatureExecutionFailed))
+}
+
+func TestReviewResultEvent_CompletionGate_FlakyOnlyFailuresPass(t *testing.T) {
+	tests, _, _ := newFlakyTestHandler(t, true)
+	execution := newGatedExecution(t, true)
+	execution.completeTests(t, tests, testRun(false, true))
+	execution.approve(t, events.ControlDecisionApproveWithWarnings)
+
+	assert.Len(t, findEmitted(execution.emitter, events.FeatureDelivered), 1)
+}
+
+func TestReviewResultEvent_CompletionGate_Blocked(t *testing.T) {
+	buildFailed := testRun(true, true)
+	buildFailed.Success = false
+	buildFailed.Result = nil
+	buildFailed.Error = &events.ExecutionError{Code: "build_failed", Message: "compilation failed"}
+
+	tests := []struct {
+		name       string
+		run        *events.TestExecutionCompletedPayload
+		wantReason string
+	}{
+		{
+			name:       "no test run",
+			wantReason: "no completed build and test run",
+		},
+		{
+			name:       "build failed",
+			run:        buildFailed,
+			wantReason: "build did not succeed",
+		},
+		{
+			name:       "tests failed",
+			run:        testRun(true, false),
+			wantReason: "tests did not pass",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			execution := newGatedExecution(t, true)
+			if tt.run != nil {
+				execution.completeTests(t, nil, tt.run)
+			}
+			execution.approve(t, events.ControlDecisionApprove)
+			failed := blockedFailure(t, execution.emitter)
+
+			assert.Equal(t, completionGateErrorCode, failed.ErrorCode)
+			assert.Equal(t, "completion blocked: "+tt.wantReason, failed.ErrorMessage)
+			assert.Equal(t, events.ExecutionPhaseDelivery, failed.FailedPhase)
+			assert.Equal(t, "feature/gated", failed.ErrorContext["branch_name"])
+		})
+	}
+}
+
+func TestReviewResultEvent_CompletionGate_UnknownExecutionBlocked(t *testing.T) {
+	execution := newGatedExecution(t, false)
+	execution.approve(t, events.ControlDecisionApprove)
+	failed := blockedFailure(t, execution.emitter)
+
+	assert.Contains(t, failed.ErrorMessage, "no record of the execution's build, tests or review")
+}
+
+func TestPatchGenerationEvent_CompletionGateLeavesGenerationPush(t *testing.T) {
+	cfg := &appconfig.WorkerConfig{CompletionGateEnabled: true}
+	svc, request := checkoutGoModule(t, cfg)
+	emitter := &mockEmitter{}
+	client := &scriptedBAMLClient{responses: []*GeneratePatchResponse{calcPatch(fixedCalc)}}
+
+	// Generated patches are pushed for testing before any review
+	handler := NewPatchGenerationEvent(cfg, client, svc, nil, emitter)
+	handler.SetExecutionContexts(NewExecutionContextStore())
+	require.NoError(t, handler.Execute(context.Background(), request))
+
+	assert.Len(t, findEmitted(emitter, events.GitPushCompleted), 1)
+	assert.Empty(t, findEmitted(emitter, events.FeatureExecutionFailed))
+}
+
+func TestCompletionEvidence_ListsEveryMissingComponent(t *testing.T) {
+	evidence := CompletionEvidence{TestsRun: true, BuildSucceeded: true, ReviewDecision: events.ControlDecisionIterate}
+
+	assert.Equal(t, []string{
+		"tests did not pass",
+		"review decision is iterate, not approve",
+	}, evidence.BlockingReasons())
+	assert.Empty(t, passedEvidence().BlockingReasons())
+}
+
+func TestCompletionEvidence_RecordedByPipeline(t *testing.T) {
+	handler, _, _ := newFlakyTestHandler(t, true)
+	store := NewExecutionContextStore()
+	handler.SetExecutionContexts(store)
+	reviewResult := NewReviewResultEvent(&appconfig.WorkerConfig{}, nil, nil, nil, &mockEmitter{})
+	reviewResult.SetExecutionContexts(store)
+
+	// Only the quarantined flaky test fails, which counts as passing
+	run := testRun(false, true)
+	store.Start(nil, &events.FeatureExecutionInitializedPayload{ExecutionID: run.ExecutionID})
+	require.NoError(t, handler.Execute(context.Background(), run))
+
+	execCtx, ok := store.Get(run.ExecutionID)
+	require.True(t, ok)
+	assert.True(t, execCtx.Completion.BuildSucceeded)
+	assert.True(t, execCtx.Completion.TestsPassed)
+	assert.Equal(t, []string{"TestFlaky"}, execCtx.Completion.QuarantinedTests)
+	assert.Equal(t, []string{"review has not completed"}, execCtx.Completion.BlockingReasons())
+
+	require.NoError(t, reviewResult.Execute(context.Background(), &events.ComprehensiveReviewCompletedPayload{
+		ExecutionID: run.ExecutionID,
+		Decision:    events.ControlDecisionAbort,
+	}))
+	execCtx, _ = store.Get(run.ExecutionID)
+	assert.Equal(t, events.ControlDecisionAbort, execCtx.Completion.ReviewDecision)
+
+	// A new test run supersedes the earlier review
+	rerun := testRun(true, true)
+	rerun.ExecutionID = run.ExecutionID
+	require.NoError(t, handler.Execute(context.Background(), rerun))
+	execCtx, _ = store.Get(run.ExecutionID)
+	assert.Empty(t, execCtx.Completion.ReviewDecision)
+	assert.Empty(t, execCtx.Completion.QuarantinedTests)
+}
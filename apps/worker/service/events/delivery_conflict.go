@@ -0,0 +1,125 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pitabwire/util"
+
+	appconfig "github.com/antinvestor/builder/apps/worker/config"
+	"github.com/antinvestor/builder/apps/worker/service/repository"
+	"github.com/antinvestor/builder/internal/events"
+)
+
+// errDeliveryPaused is returned by a push that was routed to manual review; the
+// execution is paused rather than failed.
+var errDeliveryPaused = errors.New("delivery paused for manual review")
+
+// deliveryConflicts recovers pushes rejected because the remote feature branch
+// moved, and routes the ones it cannot recover to manual review.
+type deliveryConflicts struct {
+	cfg         *appconfig.WorkerConfig
+	repoService *repository.Service
+	rebaser     BaseRebaser
+	codeOwners  CodeOwnersLoader
+	eventsMan   Emitter
+}
+
+// baseSyncStrategy returns the configured way of taking in an advanced branch,
+// rebasing unless merging is configured.
+func baseSyncStrategy(cfg *appconfig.WorkerConfig) events.BaseSyncStrategy {
+	if cfg != nil && events.BaseSyncStrategy(cfg.BaseSyncStrategy) == events.BaseSyncMerge {
+		return events.BaseSyncMerge
+	}
+	return events.BaseSyncRebase
+}
+
+// recoverRejectedPush handles a failed push. A non-fast-forward rejection means
+// the remote branch moved, so its commits are taken in and the push is retried
+// once; retrying it unchanged cannot succeed. A conflict, or a second rejection,
+// is returned as a delivery conflict. Other failures are returned as they are.
+func (d deliveryConflicts) recoverRejectedPush(
+	ctx context.Context,
+	executionID events.ExecutionID,
+	branchName string,
+	pushErr error,
+) (string, *events.DeliveryConflictPayload, error) {
+	if code, _ := classifyPushError(pushErr); code != events.GitPushErrorRejected {
+		return "", nil, pushErr
+	}
+
+	conflict := &events.DeliveryConflictPayload{
+		ExecutionID:      executionID,
+		BranchName:       branchName,
+		Strategy:         baseSyncStrategy(d.cfg),
+		ConflictingFiles: []string{},
+		ErrorMessage:     pushErr.Error(),
+		DetectedAt:       time.Now(),
+	}
+	if d.rebaser == nil {
+		return "", conflict, nil
+	}
+
+	result, err := d.rebaser.SyncWithRemoteBranch(ctx, executionID, branchName, conflict.Strategy)
+	if err != nil {
+		return "", nil, fmt.Errorf("sync with rejected remote branch: %w", err)
+	}
+	conflict.BaseBranch = result.BaseBranch
+	conflict.BaseCommitSHA = result.BaseCommitSHA
+	conflict.LatestBaseCommitSHA = result.LatestBaseCommitSHA
+	if result.Conflicted() {
+		conflict.ConflictingFiles = result.ConflictingFiles
+		return "", conflict, nil
+	}
+
+	headSHA, retryErr := d.repoService.PushBranch(ctx, executionID, branchName)
+	if retryErr == nil {
+		return headSHA, nil, nil
+	}
+	if code, _ := classifyPushError(retryErr); code == events.GitPushErrorRejected {
+		conflict.ErrorMessage = retryErr.Error()
+		return "", conflict, nil
+	}
+	return "", nil, retryErr
+}
+
+// routeDeliveryConflict reports a push blocked by a conflict and pauses the
+// execution for manual review instead of failing it into the retry queue.
+func (d deliveryConflicts) routeDeliveryConflict(
+	ctx context.Context,
+	conflict *events.DeliveryConflictPayload,
+) error {
+	util.Log(ctx).Warn("delivery blocked by conflict, requesting manual review",
+		"execution_id", conflict.ExecutionID.String(),
+		"branch_name", conflict.BranchName,
+		"conflicting_files", conflict.ConflictingFiles,
+	)
+
+	if err := d.eventsMan.Emit(ctx, string(events.GitPushFailed), &events.GitPushFailedPayload{
+		BranchName:   conflict.BranchName,
+		ErrorCode:    events.GitPushErrorRejected,
+		ErrorMessage: conflict.ErrorMessage,
+		Retryable:    false,
+		FailedAt:     conflict.DetectedAt,
+	}); err != nil {
+		return err
+	}
+	if err := d.eventsMan.Emit(ctx, string(events.DeliveryConflictDetected), conflict); err != nil {
+		return err
+	}
+
+	reason := fmt.Sprintf("Push of %s was rejected and could not be synced with the remote branch", conflict.BranchName)
+	if len(conflict.ConflictingFiles) > 0 {
+		reason = fmt.Sprintf("Push of %s conflicts with %s in %s", conflict.BranchName, conflict.BaseBranch,
+			strings.Join(conflict.ConflictingFiles, ", "))
+	}
+	return requestManualReview(ctx, d.cfg, d.codeOwners, d.eventsMan, &events.ManualReviewRequestedPayload{
+		ExecutionID:  conflict.ExecutionID,
+		Reason:       reason,
+		ChangedFiles: conflict.ConflictingFiles,
+		RequestedAt:  time.Now(),
+	})
+}
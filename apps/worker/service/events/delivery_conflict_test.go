@@ -0,0 +1,271 @@
+//nolint:testpackage // white-box testing requires internal package access
+package events
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	appconfig "github.com/antinvestor/builder/apps/worker/config"
+	"github.com/antinvestor/builder/apps/worker/service/repository"
+	"github.com/antinvestor/builder/internal/events"
+)
+
+// pushedFeatureBranch checks out a Go module, commits calc.go on the execution's
+// default feature branch and pushes it, returning the branch name.
+func pushedFeatureBranch(
+	t *testing.T,
+	cfg *appconfig.WorkerConfig,
+) (*repository.Service, *events.RepositoryCheckoutCompletedPayload, string) {
+	t.Helper()
+	svc, request := checkoutGoModule(t, cfg)
+	branchName := fmt.Sprintf("feature/%s", request.ExecutionID.String())
+
+	runTestGit(t, request.WorkspacePath, "checkout", "-q", "-b", branchName)
+	writeTestFile(t, request.WorkspacePath, "calc.go", fixedCalc)
+	runTestGit(t, request.WorkspacePath, "add", "calc.go")
+	runTestGit(t, request.WorkspacePath, "commit", "-q", "-m", "add calc")
+	_, err := svc.PushBranch(context.Background(), request.ExecutionID, branchName)
+	require.NoError(t, err)
+
+	return svc, request, branchName
+}
+
+// advanceRemoteBranch commits a file on a branch of origin, as another pusher would.
+func advanceRemoteBranch(t *testing.T, origin, branchName, file, content string) {
+	t.Helper()
+	runTestGit(t, origin, "checkout", "-q", branchName)
+	writeTestFile(t, origin, file, content)
+	runTestGit(t, origin, "add", file)
+	runTestGit(t, origin, "commit", "-q", "-m", "concurrent change")
+	runTestGit(t, origin, "checkout", "-q", "main")
+}
+
+func writeTestFile(t *testing.T, dir, name, content string) {
+	t.Helper()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600))
+}
+
+func gitHead(t *testing.T, dir, ref string) string {
+	t.Helper()
+	output, err := exec.Command("git", "-C", dir, "rev-parse", ref).Output()
+	require.NoError(t, err)
+	return strings.TrimSpace(string(output))
+}
+
+func TestReviewResultEvent_Execute_FastForwardPushDelivers(t *testing.T) {
+	cfg := &appconfig.WorkerConfig{RebaseBeforePush: true}
+	svc, request, branchName := pushedFeatureBranch(t, cfg)
+	writeTestFile(t, request.WorkspacePath, "doc.go", "// Package calc adds numbers.\npackage calc\n")
+	runTestGit(t, request.WorkspacePath, "add", "doc.go")
+	runTestGit(t, request.WorkspacePath, "commit", "-q", "-m", "add docs")
+	emitter := &mockEmitter{}
+
+	handler := NewReviewResultEvent(cfg, svc, nil, nil, emitter)
+
+	require.NoError(t, handler.Execute(context.Background(), newApprovedReviewPayload(request.ExecutionID)))
+
+	assert.Len(t, findEmitted(emitter, events.FeatureDelivered), 1)
+	assert.Empty(t, findEmitted(emitter, events.DeliveryConflictDetected))
+	assert.Empty(t, findEmitted(emitter, events.GitPushFailed))
+	assert.Equal(t, gitHead(t, request.WorkspacePath, "HEAD"), gitHead(t, request.RepositoryURL, branchName))
+}
+
+func TestReviewResultEvent_Execute_RejectedPushSyncsAndDelivers(t *testing.T) {
+	for _, strategy := range []events.BaseSyncStrategy{events.BaseSyncRebase, events.BaseSyncMerge} {
+		t.Run(string(strategy), func(t *testing.T) {
+			cfg := &appconfig.WorkerConfig{BaseSyncStrategy: string(strategy)}
+			svc, request, branchName := pushedFeatureBranch(t, cfg)
+			advanceRemoteBranch(t, request.RepositoryURL, branchName, "README.md", "notes\n")
+			remoteHead := gitHead(t, request.RepositoryURL, branchName)
+
+			writeTestFile(t, request.WorkspacePath, "doc.go", "// Package calc adds numbers.\npackage calc\n")
+			runTestGit(t, request.WorkspacePath, "add", "doc.go")
+			runTestGit(t, request.WorkspacePath, "commit", "-q", "-m", "add docs")
+			emitter := &mockEmitter{}
+
+			handler := NewReviewResultEvent(cfg, svc, nil, nil, emitter)
+
+			require.NoError(t, handler.Execute(context.Background(), newApprovedReviewPayload(request.ExecutionID)))
+
+			assert.Len(t, findEmitted(emitter, events.FeatureDelivered), 1)
+			assert.Empty(t, findEmitted(emitter, events.DeliveryConflictDetected))
+			assert.Empty(t, findEmitted(emitter, events.GitPushFailed))
+
+			// The concurrent commit is kept on the delivered branch
+			pushed := gitHead(t, request.RepositoryURL, branchName)
+			assert.Equal(t, gitHead(t, request.WorkspacePath, "HEAD"), pushed)
+			require.NoError(t, exec.Command(
+				"git", "-C", request.RepositoryURL, "merge-base", "--is-ancestor", remoteHead, pushed,
+			).Run())
+		})
+	}
+}
+
+func TestReviewResultEvent_Execute_ConflictingPushRoutesToManualReview(t *testing.T) {
+	cfg := &appconfig.WorkerConfig{ManualReviewFallbackReviewers: "alice"}
+	svc, request, branchName := pushedFeatureBranch(t, cfg)
+	advanceRemoteBranch(t, request.RepositoryURL, branchName, "calc.go", "package calc\n\n// Add is elsewhere.\n")
+	remoteHead := gitHead(t, request.RepositoryURL, branchName)
+
+	writeTestFile(t, request.WorkspacePath, "calc.go", fixedCalc+"\n// Sub is next.\n")
+	runTestGit(t, request.WorkspacePath, "commit", "-q", "-am", "extend calc")
+	localHead := gitHead(t, request.WorkspacePath, "HEAD")
+	emitter := &mockEmitter{}
+
+	handler := NewReviewResultEvent(cfg, svc, nil, nil, emitter)
+
+	require.NoError(t, handler.Execute(context.Background(), newApprovedReviewPayload(request.ExecutionID)),
+		"a conflict is routed to manual review rather than returned for retry")
+
+	conflicts := findEmitted(emitter, events.DeliveryConflictDetected)
+	require.Len(t, conflicts, 1)
+	conflict, ok := conflicts[0].(*events.DeliveryConflictPayload)
+	require.True(t, ok)
+	assert.Equal(t, request.ExecutionID, conflict.ExecutionID)
+	assert.Equal(t, branchName, conflict.BranchName)
+	assert.Equal(t, []string{"calc.go"}, conflict.ConflictingFiles)
+	assert.Equal(t, events.BaseSyncRebase, conflict.Strategy)
+	assert.Equal(t, remoteHead, conflict.LatestBaseCommitSHA)
+
+	failures := findEmitted(emitter, events.GitPushFailed)
+	require.Len(t, failures, 1)
+	failure, ok := failures[0].(*events.GitPushFailedPayload)
+	require.True(t, ok)
+	assert.Equal(t, events.GitPushErrorRejected, failure.ErrorCode)
+	assert.False(t, failure.Retryable)
+
+	reviews := findEmitted(emitter, events.ReviewManualRequested)
+	require.Len(t, reviews, 1)
+	review, ok := reviews[0].(*events.ManualReviewRequestedPayload)
+	require.True(t, ok)
+	assert.Equal(t, []string{"calc.go"}, review.ChangedFiles)
+	assert.Equal(t, []string{"alice"}, review.RequiredReviewers)
+	assert.Contains(t, review.Reason, "calc.go")
+
+	assert.Empty(t, findEmitted(emitter, events.FeatureDelivered))
+	assert.Equal(t, localHead, gitHead(t, request.WorkspacePath, "HEAD"), "the aborted sync leaves the branch as it was")
+}
+
+func TestReviewResultEvent_Execute_PushFailureIsClassified(t *testing.T) {
+	cfg := &appconfig.WorkerConfig{}
+	svc, request, _ := pushedFeatureBranch(t, cfg)
+	require.NoError(t, os.RemoveAll(request.RepositoryURL))
+	emitter := &mockEmitter{}
+
+	handler := NewReviewResultEvent(cfg, svc, nil, nil, emitter)
+
+	require.Error(t, handler.Execute(context.Background(), newApprovedReviewPayload(request.ExecutionID)))
+	assert.Empty(t, findEmitted(emitter, events.DeliveryConflictDetected))
+
+	failures := findEmitted(emitter, events.GitPushFailed)
+	require.Len(t, failures, 1)
+	failure, ok := failures[0].(*events.GitPushFailedPayload)
+	require.True(t, ok)
+	// A missing remote cannot be read from, so the push is not retried
+	assert.Equal(t, events.GitPushErrorAuth, failure.ErrorCode)
+	assert.False(t, failure.Retryable)
+}
+
+// divergeRemoteFeatureBranch creates the feature branch on origin with a commit
+// the checkout does not have, so the generated push is rejected.
+func divergeRemoteFeatureBranch(
+	t *testing.T,
+	request *events.RepositoryCheckoutCompletedPayload,
+	file, content string,
+) {
+	t.Helper()
+	runTestGit(t, request.RepositoryURL, "branch", request.FeatureBranchName)
+	advanceRemoteBranch(t, request.RepositoryURL, request.FeatureBranchName, file, content)
+}
+
+func TestPatchGenerationEvent_RejectedPushSyncsAndDelivers(t *testing.T) {
+	cfg := &appconfig.WorkerConfig{ExecutionRetryMaxAttempts: 3}
+	svc, request := checkoutGoModule(t, cfg)
+	divergeRemoteFeatureBranch(t, request, "README.md", "notes\n")
+	remoteHead := gitHead(t, request.RepositoryURL, request.FeatureBranchName)
+	client := &scriptedBAMLClient{responses: []*GeneratePatchResponse{calcPatch(fixedCalc)}}
+	emitter := &mockEmitter{}
+
+	handler := NewPatchGenerationEvent(cfg, client, svc, nil, emitter)
+
+	require.NoError(t, handler.Execute(context.Background(), request))
+
+	assert.Empty(t, findEmitted(emitter, events.DeliveryConflictDetected))
+	assert.Empty(t, findEmitted(emitter, events.FeatureExecutionFailed))
+
+	pushed := gitHead(t, request.RepositoryURL, request.FeatureBranchName)
+	require.NoError(t, exec.Command(
+		"git", "-C", request.RepositoryURL, "merge-base", "--is-ancestor", remoteHead, pushed,
+	).Run())
+	delivered := findEmitted(emitter, events.FeatureDelivered)
+	require.Len(t, delivered, 1)
+	payload, ok := delivered[0].(*events.FeatureDeliveredPayload)
+	require.True(t, ok)
+	assert.Equal(t, pushed, payload.HeadCommitSHA)
+}
+
+func TestPatchGenerationEvent_ConflictingPushRoutesToManualReview(t *testing.T) {
+	cfg := &appconfig.WorkerConfig{ExecutionRetryMaxAttempts: 3, ManualReviewFallbackReviewers: "alice"}
+	svc, request := checkoutGoModule(t, cfg)
+	divergeRemoteFeatureBranch(t, request, "calc.go", "package calc\n\n// Add is elsewhere.\n")
+	client := &scriptedBAMLClient{responses: []*GeneratePatchResponse{calcPatch(fixedCalc)}}
+	emitter := &mockEmitter{}
+
+	handler := NewPatchGenerationEvent(cfg, client, svc, nil, emitter)
+
+	require.NoError(t, handler.Execute(context.Background(), request),
+		"a conflict pauses the execution rather than failing it into the retry queue")
+
+	assert.Empty(t, findEmitted(emitter, events.FeatureExecutionFailed))
+	assert.Empty(t, findEmitted(emitter, events.FeatureDelivered))
+
+	failures := findEmitted(emitter, events.GitPushFailed)
+	require.Len(t, failures, 1)
+	failure, ok := failures[0].(*events.GitPushFailedPayload)
+	require.True(t, ok)
+	assert.Equal(t, events.GitPushErrorRejected, failure.ErrorCode)
+	assert.False(t, failure.Retryable)
+
+	conflicts := findEmitted(emitter, events.DeliveryConflictDetected)
+	require.Len(t, conflicts, 1)
+	conflict, ok := conflicts[0].(*events.DeliveryConflictPayload)
+	require.True(t, ok)
+	assert.Equal(t, []string{"calc.go"}, conflict.ConflictingFiles)
+
+	reviews := findEmitted(emitter, events.ReviewManualRequested)
+	require.Len(t, reviews, 1)
+	review, ok := reviews[0].(*events.ManualReviewRequestedPayload)
+	require.True(t, ok)
+	assert.Equal(t, []string{"alice"}, review.RequiredReviewers)
+}
+
+func TestPatchGenerationEvent_ConflictingPushRoutesToCodeOwners(t *testing.T) {
+	cfg := &appconfig.WorkerConfig{
+		ExecutionRetryMaxAttempts:     3,
+		CodeOwnersRoutingEnabled:      true,
+		CodeOwnersPaths:               "CODEOWNERS",
+		ManualReviewFallbackReviewers: "alice",
+	}
+	svc, request := checkoutGoModule(t, cfg)
+	writeTestFile(t, request.WorkspacePath, "CODEOWNERS", "/calc.go @example/calc\n")
+	divergeRemoteFeatureBranch(t, request, "calc.go", "package calc\n\n// Add is elsewhere.\n")
+	client := &scriptedBAMLClient{responses: []*GeneratePatchResponse{calcPatch(fixedCalc)}}
+	emitter := &mockEmitter{}
+
+	handler := NewPatchGenerationEvent(cfg, client, svc, nil, emitter)
+
+	require.NoError(t, handler.Execute(context.Background(), request))
+
+	review := emittedManualReview(t, emitter)
+	assert.Equal(t, "CODEOWNERS", review.CodeOwnersSource)
+	assert.Equal(t, []string{"@example/calc"}, review.RequiredReviewers)
+	assert.Equal(t, map[string][]string{"calc.go": {"@example/calc"}}, review.FileOwners)
+}
@@ -0,0 +1,153 @@
+package events
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"text/template"
+
+	"github.com/pitabwire/util"
+
+	appconfig "github.com/antinvestor/builder/apps/worker/config"
+	"github.com/antinvestor/builder/internal/events"
+)
+
+// defaultCommitFooterTemplate renders git trailers tracing a commit to its spec.
+const defaultCommitFooterTemplate = `{{range .AcceptanceCriteria}}Acceptance-Criterion: {{.}}
+{{end}}Execution-ID: {{.ExecutionID}}`
+
+// defaultPRBodyTemplate renders the feature specification as a pull request description.
+const defaultPRBodyTemplate = `## {{.Title}}
+{{- if .Description}}
+
+{{.Description}}
+{{- end}}
+{{- if .AcceptanceCriteria}}
+
+### Acceptance criteria
+{{range .AcceptanceCriteria}}
+- [ ] {{.}}
+{{- end}}
+{{- end}}
+
+---
+Execution ID: {{.ExecutionID}}
+`
+
+// traceabilityData is what the commit footer and pull request templates can reference.
+type traceabilityData struct {
+	Title              string
+	Description        string
+	AcceptanceCriteria []string
+	ExecutionID        string
+}
+
+func newTraceabilityData(executionID events.ExecutionID, spec events.FeatureSpecification) traceabilityData {
+	return traceabilityData{
+		Title:              spec.Title,
+		Description:        spec.Description,
+		AcceptanceCriteria: spec.AcceptanceCriteria,
+		ExecutionID:        executionID.String(),
+	}
+}
+
+// DeliveryTemplates render the commit message footer and pull request body that
+// link a delivered change back to its feature specification. A nil value renders
+// the built-in templates.
+type DeliveryTemplates struct {
+	commitFooter *template.Template
+	prBody       *template.Template
+}
+
+// NewDeliveryTemplates parses the configured templates, using the built-in
+// template for any that is not configured.
+func NewDeliveryTemplates(cfg *appconfig.WorkerConfig) (*DeliveryTemplates, error) {
+	commitFooter, err := loadDeliveryTemplate("commit_footer", cfg.CommitFooterTemplatePath, defaultCommitFooterTemplate)
+	if err != nil {
+		return nil, err
+	}
+	prBody, err := loadDeliveryTemplate("pr_body", cfg.PRBodyTemplatePath, defaultPRBodyTemplate)
+	if err != nil {
+		return nil, err
+	}
+	return &DeliveryTemplates{commitFooter: commitFooter, prBody: prBody}, nil
+}
+
+var defaultDeliveryTemplates = &DeliveryTemplates{
+	commitFooter: template.Must(template.New("commit_footer").Parse(defaultCommitFooterTemplate)),
+	prBody:       template.Must(template.New("pr_body").Parse(defaultPRBodyTemplate)),
+}
+
+func loadDeliveryTemplate(name, path, fallback string) (*template.Template, error) {
+	text := fallback
+	if path != "" {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("read %s template: %w", name, err)
+		}
+		text = string(content)
+	}
+	tmpl, err := template.New(name).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("parse %s template: %w", name, err)
+	}
+	return tmpl, nil
+}
+
+// CommitFooter renders the footer appended to a generated commit message.
+func (t *DeliveryTemplates) CommitFooter(
+	executionID events.ExecutionID,
+	spec events.FeatureSpecification,
+) (string, error) {
+	return renderDeliveryTemplate(t.orDefault().commitFooter, newTraceabilityData(executionID, spec))
+}
+
+// PullRequestBody renders the description of the pull request for a feature.
+func (t *DeliveryTemplates) PullRequestBody(
+	executionID events.ExecutionID,
+	spec events.FeatureSpecification,
+) (string, error) {
+	return renderDeliveryTemplate(t.orDefault().prBody, newTraceabilityData(executionID, spec))
+}
+
+func (t *DeliveryTemplates) orDefault() *DeliveryTemplates {
+	if t == nil {
+		return defaultDeliveryTemplates
+	}
+	return t
+}
+
+func renderDeliveryTemplate(tmpl *template.Template, data traceabilityData) (string, error) {
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("render %s template: %w", tmpl.Name(), err)
+	}
+	return strings.TrimSpace(buf.String()), nil
+}
+
+// withCommitFooter appends a footer to a commit message, separated by a blank line
+// so git reads it as trailers.
+func withCommitFooter(message, footer string) string {
+	if footer == "" {
+		return message
+	}
+	return strings.TrimRight(message, "\n") + "\n\n" + footer
+}
+
+// pullRequestBody renders the pull request body from the execution's feature
+// specification. It is empty when the execution has no context or the template
+// fails, which leaves the description to the provider's default.
+func (h *ReviewResultEvent) pullRequestBody(ctx context.Context, executionID events.ExecutionID) string {
+	execCtx, ok := h.execContexts.Get(executionID)
+	if !ok {
+		return ""
+	}
+	body, err := h.templates.PullRequestBody(executionID, execCtx.Spec)
+	if err != nil {
+		util.Log(ctx).Warn("failed to render pull request body", "error", err)
+		return ""
+	}
+	return body
+}
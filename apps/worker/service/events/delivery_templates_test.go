@@ -0,0 +1,131 @@
+//nolint:testpackage // white-box testing requires internal package access
+package events
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	appconfig "github.com/antinvestor/builder/apps/worker/config"
+	"github.com/antinvestor/builder/internal/events"
+)
+
+func tracedSpec() events.FeatureSpecification {
+	return events.FeatureSpecification{
+		Title:       "Add calculator",
+		Description: "Add a calculator package with basic arithmetic.",
+		AcceptanceCriteria: []string{
+			"Add returns the sum of two integers",
+			"Divide rejects a zero divisor",
+		},
+	}
+}
+
+func TestDeliveryTemplates_DefaultPullRequestBody(t *testing.T) {
+	execID := events.NewExecutionID()
+	body, err := (*DeliveryTemplates)(nil).PullRequestBody(execID, tracedSpec())
+	require.NoError(t, err)
+
+	assert.Contains(t, body, "## Add calculator")
+	assert.Contains(t, body, "Add a calculator package with basic arithmetic.")
+	assert.Contains(t, body, "- [ ] Add returns the sum of two integers")
+	assert.Contains(t, body, "- [ ] Divide rejects a zero divisor")
+	assert.Contains(t, body, "Execution ID: "+execID.String())
+}
+
+func TestDeliveryTemplates_DefaultCommitFooter(t *testing.T) {
+	execID := events.NewExecutionID()
+	footer, err := (*DeliveryTemplates)(nil).CommitFooter(execID, tracedSpec())
+	require.NoError(t, err)
+	assert.Equal(t, "Acceptance-Criterion: Add returns the sum of two integers\n"+
+		"Acceptance-Criterion: Divide rejects a zero divisor\n"+
+		"Execution-ID: "+execID.String(), footer)
+
+	footer, err = (*DeliveryTemplates)(nil).CommitFooter(execID, events.FeatureSpecification{Title: "Add calculator"})
+	require.NoError(t, err)
+	assert.Equal(t, "Execution-ID: "+execID.String(), footer)
+}
+
+func TestNewDeliveryTemplates_ConfiguredTemplates(t *testing.T) {
+	dir := t.TempDir()
+	prBodyPath := filepath.Join(dir, "pr_body.tmpl")
+	require.NoError(t, os.WriteFile(prBodyPath,
+		[]byte("Implements {{.Title}} ({{.ExecutionID}})\n{{range .AcceptanceCriteria}}* {{.}}\n{{end}}"), 0o600))
+
+	templates, err := NewDeliveryTemplates(&appconfig.WorkerConfig{PRBodyTemplatePath: prBodyPath})
+	require.NoError(t, err)
+
+	execID := events.NewExecutionID()
+	body, err := templates.PullRequestBody(execID, tracedSpec())
+	require.NoError(t, err)
+	assert.Equal(t, fmt.Sprintf("Implements Add calculator (%s)\n"+
+		"* Add returns the sum of two integers\n* Divide rejects a zero divisor", execID), body)
+
+	// The commit footer is not configured, so the built-in template applies
+	footer, err := templates.CommitFooter(execID, tracedSpec())
+	require.NoError(t, err)
+	assert.Contains(t, footer, "Execution-ID: "+execID.String())
+}
+
+func TestNewDeliveryTemplates_InvalidTemplate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "footer.tmpl")
+	require.NoError(t, os.WriteFile(path, []byte("{{.Title"), 0o600))
+
+	_, err := NewDeliveryTemplates(&appconfig.WorkerConfig{CommitFooterTemplatePath: path})
+	require.Error(t, err)
+
+	_, err = NewDeliveryTemplates(&appconfig.WorkerConfig{PRBodyTemplatePath: filepath.Join(t.TempDir(), "missing")})
+	require.Error(t, err)
+}
+
+func TestPatchGenerationEvent_CommitMessageTracesSpec(t *testing.T) {
+	cfg := &appconfig.WorkerConfig{}
+	svc, request := checkoutGoModule(t, cfg)
+	request.Spec = tracedSpec()
+	emitter := &mockEmitter{}
+
+	client := &scriptedBAMLClient{responses: []*GeneratePatchResponse{calcPatch(fixedCalc)}}
+	handler := NewPatchGenerationEvent(cfg, client, svc, nil, emitter)
+	require.NoError(t, handler.Execute(context.Background(), request))
+
+	show := exec.Command("git", "log", "-1", "--format=%B")
+	show.Dir = request.WorkspacePath
+	output, err := show.Output()
+	require.NoError(t, err)
+	message := string(output)
+	assert.Contains(t, message, "feat: Add calculator")
+	assert.Contains(t, message, "\n\nAcceptance-Criterion: Add returns the sum of two integers\n")
+	assert.Contains(t, message, "Acceptance-Criterion: Divide rejects a zero divisor\n")
+	assert.Contains(t, message, "Execution-ID: "+request.ExecutionID.String())
+}
+
+func TestReviewResultEvent_ApprovalRendersPullRequestBody(t *testing.T) {
+	cfg := &appconfig.WorkerConfig{}
+	svc, request := checkoutGoModule(t, cfg)
+	runTestGit(t, request.WorkspacePath, "checkout", "-q", "-b", fmt.Sprintf("feature/%s", request.ExecutionID))
+
+	store := NewExecutionContextStore()
+	store.Start(cfg, &events.FeatureExecutionInitializedPayload{ExecutionID: request.ExecutionID, Spec: tracedSpec()})
+
+	emitter := &mockEmitter{}
+	handler := NewReviewResultEvent(cfg, svc, nil, nil, emitter)
+	handler.SetExecutionContexts(store)
+	require.NoError(t, handler.Execute(context.Background(), &events.ComprehensiveReviewCompletedPayload{
+		ExecutionID: request.ExecutionID,
+		Decision:    events.ControlDecisionApprove,
+	}))
+
+	delivered := findEmitted(emitter, events.FeatureDelivered)
+	require.Len(t, delivered, 1)
+	payload, ok := delivered[0].(*events.FeatureDeliveredPayload)
+	require.True(t, ok)
+	assert.Contains(t, payload.PullRequestBody, "- [ ] Add returns the sum of two integers")
+	assert.Contains(t, payload.PullRequestBody, "- [ ] Divide rejects a zero divisor")
+	assert.Contains(t, payload.PullRequestBody, "Execution ID: "+request.ExecutionID.String())
+}
@@ -0,0 +1,117 @@
+package events
+
+import (
+	"cmp"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/antinvestor/builder/internal/events"
+)
+
+// rootDirectory groups files that live at the repository root.
+const rootDirectory = "."
+
+// languageByExtension maps file extensions to the language reported in diff stats.
+var languageByExtension = map[string]string{
+	".go":    "Go",
+	".py":    "Python",
+	".js":    "JavaScript",
+	".jsx":   "JavaScript",
+	".mjs":   "JavaScript",
+	".ts":    "TypeScript",
+	".tsx":   "TypeScript",
+	".java":  "Java",
+	".kt":    "Kotlin",
+	".rs":    "Rust",
+	".rb":    "Ruby",
+	".php":   "PHP",
+	".cs":    "C#",
+	".c":     "C",
+	".h":     "C",
+	".cpp":   "C++",
+	".hpp":   "C++",
+	".swift": "Swift",
+	".sql":   "SQL",
+	".sh":    "Shell",
+	".proto": "Protocol Buffers",
+	".html":  "HTML",
+	".css":   "CSS",
+	".scss":  "CSS",
+	".md":    "Markdown",
+	".yaml":  "YAML",
+	".yml":   "YAML",
+	".json":  "JSON",
+	".toml":  "TOML",
+}
+
+// diffLanguage returns the language of a changed file, "Other" when unrecognized.
+func diffLanguage(filePath string) string {
+	base := path.Base(filePath)
+	switch base {
+	case "Dockerfile":
+		return "Dockerfile"
+	case "Makefile":
+		return "Makefile"
+	}
+	if language, ok := languageByExtension[strings.ToLower(path.Ext(base))]; ok {
+		return language
+	}
+	return "Other"
+}
+
+// diffDirectory returns the top-level directory of a changed file.
+func diffDirectory(filePath string) string {
+	dir, _, found := strings.Cut(strings.TrimPrefix(path.Clean(filePath), "/"), "/")
+	if !found {
+		return rootDirectory
+	}
+	return dir
+}
+
+// recordDiff adds a changed file to the per-language and per-directory breakdown.
+func (s *patchStats) recordDiff(filePath string, linesAdded, linesRemoved int) {
+	if s.byLanguage == nil {
+		s.byLanguage = make(map[string]*events.DiffStatsEntry)
+		s.byDirectory = make(map[string]*events.DiffStatsEntry)
+	}
+	addDiffStatsEntry(s.byLanguage, diffLanguage(filePath), linesAdded, linesRemoved)
+	addDiffStatsEntry(s.byDirectory, diffDirectory(filePath), linesAdded, linesRemoved)
+}
+
+func addDiffStatsEntry(groups map[string]*events.DiffStatsEntry, name string, linesAdded, linesRemoved int) {
+	entry, ok := groups[name]
+	if !ok {
+		entry = &events.DiffStatsEntry{Name: name}
+		groups[name] = entry
+	}
+	entry.Files++
+	entry.LinesAdded += linesAdded
+	entry.LinesRemoved += linesRemoved
+}
+
+// diffStats returns the breakdown with the most changed groups first.
+func (s *patchStats) diffStats() *events.DiffStats {
+	if len(s.byLanguage) == 0 {
+		return nil
+	}
+	return &events.DiffStats{
+		ByLanguage:  sortedDiffStatsEntries(s.byLanguage),
+		ByDirectory: sortedDiffStatsEntries(s.byDirectory),
+	}
+}
+
+func sortedDiffStatsEntries(groups map[string]*events.DiffStatsEntry) []events.DiffStatsEntry {
+	entries := make([]events.DiffStatsEntry, 0, len(groups))
+	for _, entry := range groups {
+		entries = append(entries, *entry)
+	}
+	slices.SortFunc(entries, func(a, b events.DiffStatsEntry) int {
+		return cmp.Or(
+			cmp.Compare(b.LinesAdded+b.LinesRemoved, a.LinesAdded+a.LinesRemoved),
+			cmp.Compare(b.Files, a.Files),
+			cmp.Compare(a.Name, b.Name),
+		)
+	})
+	return entries
+}
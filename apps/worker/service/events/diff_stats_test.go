@@ -0,0 +1,92 @@
+//nolint:testpackage // white-box testing requires internal package access
+package events
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	appconfig "github.com/antinvestor/builder/apps/worker/config"
+	"github.com/antinvestor/builder/internal/events"
+)
+
+func TestPatchStats_DiffStatsBreakdown(t *testing.T) {
+	patches := []Patch{
+		{FilePath: "service/orders.go", Action: events.FileActionCreate, NewContent: "package service\n\nfunc A() {}"},
+		{
+			FilePath:   "service/orders_test.go",
+			Action:     events.FileActionModify,
+			OldContent: "package service",
+			NewContent: "package service\n\nfunc TestA() {}",
+		},
+		{FilePath: "web/src/app.ts", Action: events.FileActionCreate, NewContent: "export {}\nconsole.log(1)"},
+		{FilePath: "scripts/migrate.py", Action: events.FileActionDelete, OldContent: "import os\nprint(1)\n"},
+		{FilePath: "README.md", Action: events.FileActionModify, OldContent: "# App", NewContent: "# App\nDocs"},
+	}
+
+	handler := &PatchGenerationEvent{}
+	stats := &patchStats{}
+	for i := range patches {
+		handler.updatePatchStats(stats, &patches[i])
+	}
+	diff := stats.diffStats()
+	require.NotNil(t, diff)
+
+	assert.Equal(t, []events.DiffStatsEntry{
+		{Name: "Go", Files: 2, LinesAdded: 6, LinesRemoved: 1},
+		{Name: "Markdown", Files: 1, LinesAdded: 2, LinesRemoved: 1},
+		{Name: "Python", Files: 1, LinesAdded: 0, LinesRemoved: 3},
+		{Name: "TypeScript", Files: 1, LinesAdded: 2, LinesRemoved: 0},
+	}, diff.ByLanguage)
+	assert.Equal(t, []events.DiffStatsEntry{
+		{Name: "service", Files: 2, LinesAdded: 6, LinesRemoved: 1},
+		{Name: ".", Files: 1, LinesAdded: 2, LinesRemoved: 1},
+		{Name: "scripts", Files: 1, LinesAdded: 0, LinesRemoved: 3},
+		{Name: "web", Files: 1, LinesAdded: 2, LinesRemoved: 0},
+	}, diff.ByDirectory)
+
+	// The breakdown adds up to the overall totals
+	var added, removed int
+	for _, entry := range diff.ByLanguage {
+		added += entry.LinesAdded
+		removed += entry.LinesRemoved
+	}
+	assert.Equal(t, stats.linesAdded, added)
+	assert.Equal(t, stats.linesRemoved, removed)
+}
+
+func TestPatchStats_NoChangesHasNoDiffStats(t *testing.T) {
+	assert.Nil(t, (&patchStats{}).diffStats())
+}
+
+func TestPatchGenerationEvent_DeliverySummaryIncludesDiffStats(t *testing.T) {
+	cfg := &appconfig.WorkerConfig{}
+	svc, request := checkoutGoModule(t, cfg)
+	client := &scriptedBAMLClient{responses: []*GeneratePatchResponse{{
+		Patches: []Patch{
+			{FilePath: "calc.go", NewContent: fixedCalc, Action: events.FileActionCreate},
+			{FilePath: "docs/calc.md", NewContent: "# Calc", Action: events.FileActionCreate},
+		},
+	}}}
+	emitter := &mockEmitter{}
+
+	handler := NewPatchGenerationEvent(cfg, client, svc, nil, emitter)
+
+	require.NoError(t, handler.Execute(context.Background(), request))
+
+	delivered := findEmitted(emitter, events.FeatureDelivered)
+	require.Len(t, delivered, 1)
+	payload, ok := delivered[0].(*events.FeatureDeliveredPayload)
+	require.True(t, ok)
+	require.NotNil(t, payload.Summary.DiffStats)
+	assert.Equal(t, []events.DiffStatsEntry{
+		{Name: "Go", Files: 1, LinesAdded: 4},
+		{Name: "Markdown", Files: 1, LinesAdded: 1},
+	}, payload.Summary.DiffStats.ByLanguage)
+	assert.Equal(t, []events.DiffStatsEntry{
+		{Name: ".", Files: 1, LinesAdded: 4},
+		{Name: "docs", Files: 1, LinesAdded: 1},
+	}, payload.Summary.DiffStats.ByDirectory)
+}
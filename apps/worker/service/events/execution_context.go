@@ -0,0 +1,272 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"time"
+
+	appconfig "github.com/antinvestor/builder/apps/worker/config"
+	"github.com/antinvestor/builder/internal/events"
+)
+
+// executionContextTTL is how long an execution context is kept after its last update.
+// Contexts of executions that never reach a terminal event are pruned after it.
+const executionContextTTL = 24 * time.Hour
+
+// ErrKillSwitchActivated is the cause of a bound context canceled because a kill
+// switch stopped its execution.
+var ErrKillSwitchActivated = errors.New("kill switch activated")
+
+// ExecutionConfig is the configuration an execution started with, captured once so
+// a configuration reload does not change an execution's limits midway.
+type ExecutionConfig struct {
+	MaxIterations                int
+	IterationFeedbackIncludeCode bool
+}
+
+// snapshotExecutionConfig captures the settings an execution keeps for its lifetime.
+func snapshotExecutionConfig(cfg *appconfig.WorkerConfig) ExecutionConfig {
+	if cfg == nil {
+		return ExecutionConfig{}
+	}
+	return ExecutionConfig{
+		MaxIterations:                cfg.ReviewThresholds.MaxIterations,
+		IterationFeedbackIncludeCode: cfg.IterationFeedbackIncludeCode,
+	}
+}
+
+// ExecutionContext is the state of one execution shared by the pipeline handlers.
+// It is created when the execution is initialized and updated as each phase
+// completes, so later phases read it instead of re-deriving it from event payloads.
+type ExecutionContext struct {
+	ExecutionID events.ExecutionID
+	Spec        events.FeatureSpecification
+	Repository  events.RepositoryContext
+	Config      ExecutionConfig
+
+	// Set once the repository is checked out
+	WorkspacePath     string
+	BaseBranch        string
+	BaseCommitSHA     string
+	FeatureBranchName string
+	SeedFiles         []string
+
+	// Updated as patches are generated and iterated on
+	HeadCommitSHA   string
+	IterationNumber int
+	Patches         []Patch
+
+	// Latest progress of the running test execution, if one reported any
+	TestProgress *events.TestProgressPayload
+
+	// Outcome of the latest test run and its review, checked before completion
+	Completion CompletionEvidence
+
+	StartedAt time.Time
+	UpdatedAt time.Time
+
+	// run is canceled, with the kill switch as its cause, when a kill switch stops
+	// the execution; handlers bind their work to it with ExecutionContextStore.Bind
+	run    context.Context
+	cancel context.CancelCauseFunc
+}
+
+// clone copies the context so callers cannot change the stored one by accident.
+func (c *ExecutionContext) clone() *ExecutionContext {
+	cloned := *c
+	cloned.Patches = slices.Clone(c.Patches)
+	cloned.SeedFiles = slices.Clone(c.SeedFiles)
+	cloned.Completion.QuarantinedTests = slices.Clone(c.Completion.QuarantinedTests)
+	return &cloned
+}
+
+// ExecutionContextStore holds the context of each running execution. All methods
+// are safe on a nil store, which holds nothing.
+type ExecutionContextStore struct {
+	now func() time.Time
+
+	mu       sync.Mutex
+	contexts map[events.ExecutionID]*ExecutionContext
+	released []func(execID events.ExecutionID)
+}
+
+// NewExecutionContextStore creates an empty execution context store.
+func NewExecutionContextStore() *ExecutionContextStore {
+	return &ExecutionContextStore{
+		now:      time.Now,
+		contexts: make(map[events.ExecutionID]*ExecutionContext),
+	}
+}
+
+// Start records the context of a newly initialized execution, replacing one left by
+// an earlier attempt, and prunes contexts that have gone stale.
+func (s *ExecutionContextStore) Start(
+	cfg *appconfig.WorkerConfig,
+	request *events.FeatureExecutionInitializedPayload,
+) *ExecutionContext {
+	if s == nil {
+		return nil
+	}
+	now := s.now()
+	execCtx := &ExecutionContext{
+		ExecutionID:       request.ExecutionID,
+		Spec:              request.Spec,
+		Repository:        request.Repository,
+		Config:            snapshotExecutionConfig(cfg),
+		FeatureBranchName: request.Repository.FeatureBranchName,
+		StartedAt:         now,
+		UpdatedAt:         now,
+	}
+	execCtx.run, execCtx.cancel = context.WithCancelCause(context.Background())
+
+	var pruned []events.ExecutionID
+	s.mu.Lock()
+	for id, stale := range s.contexts {
+		if now.Sub(stale.UpdatedAt) > executionContextTTL {
+			delete(s.contexts, id)
+			pruned = append(pruned, id)
+		}
+	}
+	s.contexts[request.ExecutionID] = execCtx
+	cloned := execCtx.clone()
+	s.mu.Unlock()
+
+	s.release(pruned...)
+	return cloned
+}
+
+// Get returns a copy of an execution's context.
+func (s *ExecutionContextStore) Get(execID events.ExecutionID) (*ExecutionContext, bool) {
+	if s == nil {
+		return nil, false
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	execCtx, ok := s.contexts[execID]
+	if !ok {
+		return nil, false
+	}
+	return execCtx.clone(), true
+}
+
+// Update applies a mutation to an execution's context and returns the result. It
+// reports false, without calling mutate, when the execution has no context.
+func (s *ExecutionContextStore) Update(
+	execID events.ExecutionID,
+	mutate func(execCtx *ExecutionContext),
+) (*ExecutionContext, bool) {
+	if s == nil {
+		return nil, false
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	execCtx, ok := s.contexts[execID]
+	if !ok {
+		return nil, false
+	}
+	mutate(execCtx)
+	execCtx.UpdatedAt = s.now()
+	return execCtx.clone(), true
+}
+
+// Delete drops the context of a finished execution.
+func (s *ExecutionContextStore) Delete(execID events.ExecutionID) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	delete(s.contexts, execID)
+	s.mu.Unlock()
+
+	s.release(execID)
+}
+
+// OnRelease registers a function called with each execution whose context is
+// dropped, when it finishes or goes stale, so state kept elsewhere for the
+// execution is dropped with it.
+func (s *ExecutionContextStore) OnRelease(release func(execID events.ExecutionID)) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.released = append(s.released, release)
+}
+
+// release calls the registered release functions for the executions.
+func (s *ExecutionContextStore) release(execIDs ...events.ExecutionID) {
+	s.mu.Lock()
+	released := slices.Clone(s.released)
+	s.mu.Unlock()
+	for _, execID := range execIDs {
+		for _, fn := range released {
+			fn(execID)
+		}
+	}
+}
+
+// Find returns the executions whose contexts match.
+func (s *ExecutionContextStore) Find(match func(execCtx *ExecutionContext) bool) []events.ExecutionID {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var found []events.ExecutionID
+	for id, execCtx := range s.contexts {
+		if match(execCtx) {
+			found = append(found, id)
+		}
+	}
+	return found
+}
+
+// Bind returns a context of parent that is also canceled when the execution is
+// killed, and a function releasing it. Without a context for the execution,
+// parent is returned as is.
+func (s *ExecutionContextStore) Bind(
+	parent context.Context,
+	execID events.ExecutionID,
+) (context.Context, context.CancelFunc) {
+	if s == nil {
+		return parent, func() {}
+	}
+	s.mu.Lock()
+	execCtx, ok := s.contexts[execID]
+	s.mu.Unlock()
+	if !ok || execCtx.run == nil {
+		return parent, func() {}
+	}
+
+	run := execCtx.run
+	ctx, cancel := context.WithCancelCause(parent)
+	stop := context.AfterFunc(run, func() { cancel(context.Cause(run)) })
+	return ctx, func() {
+		stop()
+		cancel(nil)
+	}
+}
+
+// Kill cancels the contexts bound to an execution with ErrKillSwitchActivated. It
+// reports false when the execution has no context or was already killed.
+func (s *ExecutionContextStore) Kill(execID events.ExecutionID) bool {
+	if s == nil {
+		return false
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	execCtx, ok := s.contexts[execID]
+	if !ok || execCtx.run == nil || execCtx.run.Err() != nil {
+		return false
+	}
+	execCtx.cancel(ErrKillSwitchActivated)
+	return true
+}
+
+// killedBySwitch reports whether ctx was canceled because a kill switch stopped
+// its execution.
+func killedBySwitch(ctx context.Context) bool {
+	return errors.Is(context.Cause(ctx), ErrKillSwitchActivated)
+}
@@ -0,0 +1,257 @@
+//nolint:testpackage // white-box testing requires internal package access
+package events
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	appconfig "github.com/antinvestor/builder/apps/worker/config"
+	"github.com/antinvestor/builder/internal/events"
+)
+
+func TestExecutionContextStore_GetReturnsCopy(t *testing.T) {
+	store := NewExecutionContextStore()
+	execID := events.NewExecutionID()
+	store.Start(&appconfig.WorkerConfig{}, &events.FeatureExecutionInitializedPayload{ExecutionID: execID})
+
+	updated, ok := store.Update(execID, func(execCtx *ExecutionContext) {
+		execCtx.Patches = []Patch{{FilePath: "calc.go"}}
+	})
+	require.True(t, ok)
+	updated.Patches[0].FilePath = "changed.go"
+	updated.WorkspacePath = "/elsewhere"
+
+	stored, ok := store.Get(execID)
+	require.True(t, ok)
+	assert.Equal(t, "calc.go", stored.Patches[0].FilePath, "only Update changes the stored context")
+	assert.Empty(t, stored.WorkspacePath)
+}
+
+func TestExecutionContextStore_PrunesStaleContexts(t *testing.T) {
+	store := NewExecutionContextStore()
+	now := time.Date(2026, time.October, 14, 9, 0, 0, 0, time.UTC)
+	store.now = func() time.Time { return now }
+
+	stale := events.NewExecutionID()
+	store.Start(nil, &events.FeatureExecutionInitializedPayload{ExecutionID: stale})
+	now = now.Add(executionContextTTL + time.Minute)
+	fresh := events.NewExecutionID()
+	store.Start(nil, &events.FeatureExecutionInitializedPayload{ExecutionID: fresh})
+
+	_, ok := store.Get(stale)
+	assert.False(t, ok)
+	_, ok = store.Get(fresh)
+	assert.True(t, ok)
+}
+
+func TestExecutionContextStore_NilStoreHoldsNothing(t *testing.T) {
+	var store *ExecutionContextStore
+	execID := events.NewExecutionID()
+
+	assert.Nil(t, store.Start(nil, &events.FeatureExecutionInitializedPayload{ExecutionID: execID}))
+	_, ok := store.Update(execID, func(*ExecutionContext) { t.Fatal("mutate called on a nil store") })
+	assert.False(t, ok)
+	_, ok = store.Get(execID)
+	assert.False(t, ok)
+	store.Delete(execID)
+}
+
+// TestExecutionContext_SharedAcrossPhases runs an execution through checkout, patch
+// generation, review and iteration, checking each phase reads the state the
+// earlier phases recorded.
+func TestExecutionContext_SharedAcrossPhases(t *testing.T) {
+	cfg := &appconfig.WorkerConfig{QueueReviewRequestName: "review.requests"}
+	cfg.ReviewThresholds.MaxIterations = 5
+	svc, base := checkoutGoModule(t, cfg)
+	store := NewExecutionContextStore()
+	emitter := &mockEmitter{}
+
+	// Checkout records the workspace and branches
+	checkout := NewRepositoryCheckoutEvent(cfg, svc, emitter)
+	checkout.SetExecutionContexts(store)
+	execID := events.NewExecutionID()
+	require.NoError(t, checkout.Execute(context.Background(), &events.FeatureExecutionInitializedPayload{
+		ExecutionID: execID,
+		Spec: events.FeatureSpecification{
+			Title:              "Add calculator",
+			Description:        "Add an Add function",
+			AcceptanceCriteria: []string{"Add returns the sum"},
+		},
+		Repository: events.RepositoryContext{
+			RepositoryID: "repo-1",
+			RemoteURL:    base.RepositoryURL,
+			TargetBranch: "main",
+		},
+	}))
+
+	execCtx, ok := store.Get(execID)
+	require.True(t, ok)
+	assert.NotEmpty(t, execCtx.WorkspacePath)
+	assert.Equal(t, "main", execCtx.BaseBranch)
+	assert.NotEmpty(t, execCtx.BaseCommitSHA)
+	assert.Contains(t, execCtx.FeatureBranchName, "feature/add-calculator-")
+	assert.Equal(t, 5, execCtx.Config.MaxIterations)
+
+	// A config reload does not change the limits of a running execution
+	cfg.ReviewThresholds.MaxIterations = 1
+
+	// Patch generation records the patches and the commit
+	checkedOut := findEmitted(emitter, events.RepositoryCheckoutCompleted)
+	require.Len(t, checkedOut, 1)
+	generation := NewPatchGenerationEvent(cfg,
+		&scriptedBAMLClient{responses: []*GeneratePatchResponse{calcPatch(fixedCalc)}}, svc, nil, emitter)
+	generation.SetExecutionContexts(store)
+	require.NoError(t, generation.Execute(context.Background(), checkedOut[0]))
+
+	execCtx, ok = store.Get(execID)
+	require.True(t, ok)
+	assert.NotEmpty(t, execCtx.HeadCommitSHA)
+	require.Len(t, execCtx.Patches, 1)
+	assert.Equal(t, "calc.go", execCtx.Patches[0].FilePath)
+
+	// The review request describes the change from the context
+	qMan := &mockQueueManager{}
+	reviewRequest := NewReviewRequestEvent(cfg, qMan, nil, emitter)
+	reviewRequest.SetExecutionContexts(store)
+	passed := &events.TestExecutionCompletedPayload{
+		ExecutionID: execID,
+		Success:     true,
+		Result:      &events.TestResult{TotalTests: 1, PassedTests: 1, Success: true},
+	}
+	require.NoError(t, reviewRequest.Execute(context.Background(), passed))
+	review := lastReviewRequest(t, qMan)
+	require.NotNil(t, review.Context)
+	assert.Equal(t, "Add an Add function", review.Context.FeatureDescription)
+	assert.Equal(t, []string{"Add returns the sum"}, review.Context.AcceptanceCriteria)
+	require.NotNil(t, review.Context.RepositoryContext)
+	assert.Equal(t, "repo-1", review.Context.RepositoryContext.RepositoryID)
+	assert.Equal(t, 0, review.Context.IterationNumber)
+
+	// Iteration regenerates from the recorded spec, workspace and patches
+	client := &scriptedBAMLClient{responses: []*GeneratePatchResponse{calcPatch(fixedCalc)}}
+	iteration := NewIterationEvent(cfg, client, nil, emitter)
+	iteration.SetExecutionContexts(store)
+	require.NoError(t, iteration.Execute(context.Background(), &events.FeatureIterationRequestedPayload{
+		ExecutionID:     execID,
+		IterationNumber: 2,
+		Issues:          []events.ReviewIssue{{Title: "Handle overflow", Severity: events.ReviewIssueSeverityHigh}},
+	}))
+	assert.Empty(t, findEmitted(emitter, events.FeatureExecutionFailed),
+		"the iteration limit the execution started with applies")
+	require.Len(t, client.requests, 1)
+	assert.Equal(t, "Add calculator", client.requests[0].Specification.Title)
+	assert.Equal(t, execCtx.WorkspacePath, client.requests[0].WorkspacePath)
+	assert.Equal(t, execCtx.Patches, client.requests[0].PreviousPatches)
+
+	// The iteration number persists into the next review request
+	require.NoError(t, reviewRequest.Execute(context.Background(), passed))
+	assert.Equal(t, 2, lastReviewRequest(t, qMan).Context.IterationNumber)
+
+	// A final failure drops the context
+	failure := NewFeatureFailureEvent(cfg, nil, qMan, emitter)
+	failure.SetExecutionContexts(store)
+	require.NoError(t, failure.Execute(context.Background(), &events.FeatureExecutionFailedPayload{ExecutionID: execID}))
+	_, ok = store.Get(execID)
+	assert.False(t, ok)
+}
+
+func TestExecutionContext_SurvivesGenerationDelivery(t *testing.T) {
+	ctx := context.Background()
+	cfg := &appconfig.WorkerConfig{QueueFeatureResultName: "feature.results"}
+	svc, base := checkoutGoModule(t, cfg)
+	store := NewExecutionContextStore()
+	emitter := &mockEmitter{}
+
+	checkout := NewRepositoryCheckoutEvent(cfg, svc, emitter)
+	checkout.SetExecutionContexts(store)
+	execID := events.NewExecutionID()
+	require.NoError(t, checkout.Execute(ctx, &events.FeatureExecutionInitializedPayload{
+		ExecutionID: execID,
+		Spec:        events.FeatureSpecification{Title: "Add calculator"},
+		Repository:  events.RepositoryContext{RemoteURL: base.RepositoryURL, TargetBranch: "main"},
+	}))
+	checkedOut := findEmitted(emitter, events.RepositoryCheckoutCompleted)
+	require.Len(t, checkedOut, 1)
+	generation := NewPatchGenerationEvent(cfg,
+		&scriptedBAMLClient{responses: []*GeneratePatchResponse{calcPatch(fixedCalc)}}, svc, nil, emitter)
+	generation.SetExecutionContexts(store)
+	require.NoError(t, generation.Execute(ctx, checkedOut[0]))
+	generated, ok := store.Get(execID)
+	require.True(t, ok)
+
+	// The delivery of the generated patches is followed by tests and review
+	completion := NewFeatureCompletionEvent(cfg, nil, &mockQueueManager{})
+	completion.SetExecutionContexts(store)
+	delivered := findEmitted(emitter, events.FeatureDelivered)
+	require.Len(t, delivered, 1)
+	require.NoError(t, completion.Execute(ctx, delivered[0]))
+
+	client := &scriptedBAMLClient{responses: []*GeneratePatchResponse{calcPatch(fixedCalc)}}
+	iteration := NewIterationEvent(cfg, client, nil, emitter)
+	iteration.SetExecutionContexts(store)
+	require.NoError(t, iteration.Execute(ctx, &events.FeatureIterationRequestedPayload{
+		ExecutionID:     execID,
+		IterationNumber: 1,
+	}))
+	require.Len(t, client.requests, 1)
+	assert.Equal(t, "Add calculator", client.requests[0].Specification.Title)
+	assert.Equal(t, generated.WorkspacePath, client.requests[0].WorkspacePath)
+	assert.Equal(t, generated.Patches, client.requests[0].PreviousPatches)
+
+	// Approval pushes the generated feature branch, and its delivery ends the execution
+	review := NewReviewResultEvent(cfg, svc, nil, nil, emitter)
+	review.SetExecutionContexts(store)
+	require.NoError(t, review.Execute(ctx, &events.ComprehensiveReviewCompletedPayload{
+		ExecutionID: execID,
+		Decision:    events.ControlDecisionApprove,
+	}))
+	delivered = findEmitted(emitter, events.FeatureDelivered)
+	require.Len(t, delivered, 2)
+	approved, ok := delivered[1].(*events.FeatureDeliveredPayload)
+	require.True(t, ok)
+	assert.Equal(t, generated.FeatureBranchName, approved.BranchName)
+	assert.Contains(t, approved.BranchName, "feature/add-calculator-")
+
+	require.NoError(t, completion.Execute(ctx, approved))
+	_, ok = store.Get(execID)
+	assert.False(t, ok)
+}
+
+func TestReviewResultEvent_ApprovalPushesContextFeatureBranch(t *testing.T) {
+	cfg := &appconfig.WorkerConfig{}
+	svc, request := checkoutGoModule(t, cfg)
+	runTestGit(t, request.WorkspacePath, "checkout", "-q", "-b", "feature/add-calculator")
+
+	store := NewExecutionContextStore()
+	store.Start(cfg, &events.FeatureExecutionInitializedPayload{
+		ExecutionID: request.ExecutionID,
+		Repository:  events.RepositoryContext{FeatureBranchName: "feature/add-calculator"},
+	})
+
+	emitter := &mockEmitter{}
+	handler := NewReviewResultEvent(cfg, svc, nil, nil, emitter)
+	handler.SetExecutionContexts(store)
+	require.NoError(t, handler.Execute(context.Background(), &events.ComprehensiveReviewCompletedPayload{
+		ExecutionID: request.ExecutionID,
+		Decision:    events.ControlDecisionApprove,
+	}))
+
+	pushed := findEmitted(emitter, events.GitPushCompleted)
+	require.Len(t, pushed, 1)
+	payload, ok := pushed[0].(*events.GitPushCompletedPayload)
+	require.True(t, ok)
+	assert.Equal(t, "feature/add-calculator", payload.BranchName)
+}
+
+func lastReviewRequest(t *testing.T, qMan *mockQueueManager) *events.ComprehensiveReviewRequestedPayload {
+	t.Helper()
+	require.NotEmpty(t, qMan.publishedMessages)
+	last := qMan.publishedMessages[len(qMan.publishedMessages)-1]
+	review, ok := last.payload.(*events.ComprehensiveReviewRequestedPayload)
+	require.True(t, ok)
+	return review
+}
@@ -0,0 +1,156 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/pitabwire/util"
+
+	appconfig "github.com/antinvestor/builder/apps/worker/config"
+	"github.com/antinvestor/builder/apps/worker/service/queue"
+	"github.com/antinvestor/builder/apps/worker/service/repository"
+	"github.com/antinvestor/builder/internal/events"
+	"github.com/antinvestor/builder/internal/executions"
+)
+
+const (
+	// maxRetryUpdateAttempts bounds re-reads when recording a restart races another writer.
+	maxRetryUpdateAttempts = 5
+	millisPerSecond        = 1000
+)
+
+// errRetriesExhausted stops a restart once the execution has used its attempts.
+var errRetriesExhausted = errors.New("execution retries exhausted")
+
+// ExecutionRetryPolicy restarts executions that failed on a retryable infrastructure
+// error from their original feature request, with exponential backoff between
+// attempts. The request is requeued on the feature request retry queue, due once
+// the backoff elapses, so no handler waits it out. It complements step retries,
+// which redeliver a single phase.
+type ExecutionRetryPolicy struct {
+	maxAttempts   int
+	backoff       events.RetryPolicy
+	retryQueue    string
+	executionRepo executions.ExecutionRepository
+	repoService   *repository.Service
+	queueMan      QueueManager
+	now           func() time.Time
+}
+
+// NewExecutionRetryPolicy creates an execution retry policy from configuration.
+// repoService may be nil, in which case workspaces are not cleaned before a restart.
+func NewExecutionRetryPolicy(
+	cfg *appconfig.WorkerConfig,
+	executionRepo executions.ExecutionRepository,
+	repoService *repository.Service,
+	queueMan QueueManager,
+) *ExecutionRetryPolicy {
+	return &ExecutionRetryPolicy{
+		maxAttempts: cfg.ExecutionRetryMaxAttempts,
+		backoff: events.RetryPolicy{
+			MaxRetries:        cfg.ExecutionRetryMaxAttempts,
+			InitialDelayMS:    cfg.ExecutionRetryInitialDelaySeconds * millisPerSecond,
+			MaxDelayMS:        cfg.ExecutionRetryMaxDelaySeconds * millisPerSecond,
+			BackoffMultiplier: 2.0,
+		},
+		retryQueue:    cfg.QueueRetryLevel1Name,
+		executionRepo: executionRepo,
+		repoService:   repoService,
+		queueMan:      queueMan,
+		now:           time.Now,
+	}
+}
+
+// isInfrastructureFailure reports whether a failure is a retryable outage rather
+// than a problem with the change itself.
+func isInfrastructureFailure(classification events.FailureClassification) bool {
+	return classification.IsOutage()
+}
+
+// Restart re-initializes a failed execution when the failure is a retryable
+// infrastructure failure and attempts remain. It reports whether a restart was started.
+func (p *ExecutionRetryPolicy) Restart(
+	ctx context.Context,
+	failure *events.FeatureExecutionFailedPayload,
+) (bool, error) {
+	if p == nil || p.maxAttempts <= 0 || failure.ExecutionID.IsZero() ||
+		!isInfrastructureFailure(failure.Classification) {
+		return false, nil
+	}
+
+	execution, err := executions.UpdateExecution(ctx, p.executionRepo, failure.ExecutionID.String(),
+		maxRetryUpdateAttempts, func(e *executions.Execution) error {
+			if len(e.InitialRequest) == 0 || e.RetryAttempts >= p.maxAttempts {
+				return errRetriesExhausted
+			}
+			e.RetryAttempts++
+			e.Status = executions.ExecutionStatusPending
+			e.ErrorMessage = failure.ErrorMessage
+			e.StartedAt = nil
+			e.CompletedAt = nil
+			return nil
+		})
+	if errors.Is(err, errRetriesExhausted) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("record execution retry: %w", err)
+	}
+
+	delay := p.backoff.CalculateDelay(execution.RetryAttempts)
+	util.Log(ctx).Warn("restarting execution after infrastructure failure",
+		"execution_id", failure.ExecutionID.String(),
+		"attempt", execution.RetryAttempts,
+		"max_attempts", p.maxAttempts,
+		"failed_phase", failure.FailedPhase,
+		"error_code", failure.ErrorCode,
+		"delay", delay,
+	)
+
+	// The restart clones afresh, so whatever the failed attempt left behind goes
+	if p.repoService != nil {
+		if cleanupErr := p.repoService.CleanupWorkspace(ctx, failure.ExecutionID); cleanupErr != nil {
+			util.Log(ctx).Debug("no workspace to clean before restart",
+				"execution_id", failure.ExecutionID.String(),
+				"error", cleanupErr,
+			)
+		}
+	}
+
+	// The stored initialization is requeued as the request, resuming the execution
+	if err = p.queueMan.Publish(ctx, p.retryQueue, []byte(execution.InitialRequest), map[string]string{
+		queue.HeaderExecutionID: failure.ExecutionID.String(),
+		queue.HeaderNotBefore:   p.now().Add(delay).UTC().Format(time.RFC3339Nano),
+	}); err != nil {
+		return false, fmt.Errorf("requeue execution restart: %w", err)
+	}
+	return true, nil
+}
+
+// emitInfrastructureFailure fails an execution on a retryable infrastructure error,
+// leaving the execution retry policy to decide whether to start it over.
+func emitInfrastructureFailure(
+	ctx context.Context,
+	eventsMan Emitter,
+	executionID events.ExecutionID,
+	phase events.ExecutionPhase,
+	errorCode string,
+	err error,
+) error {
+	classification := events.FailureClassification{
+		Type:           events.FailureTypeInfrastructure,
+		Severity:       events.FailureSeverityError,
+		Retryable:      true,
+		UserActionable: false,
+	}
+	return eventsMan.Emit(ctx, string(events.FeatureExecutionFailed), &events.FeatureExecutionFailedPayload{
+		ExecutionID:    executionID,
+		Classification: classification,
+		FailedPhase:    phase,
+		ErrorCode:      errorCode,
+		ErrorMessage:   err.Error(),
+		Recovery:       failureRecovery(classification, phase, true),
+	})
+}
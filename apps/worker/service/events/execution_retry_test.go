@@ -0,0 +1,234 @@
+//nolint:testpackage // white-box testing requires internal package access
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	appconfig "github.com/antinvestor/builder/apps/worker/config"
+	"github.com/antinvestor/builder/apps/worker/service/queue"
+	"github.com/antinvestor/builder/apps/worker/service/repository"
+	"github.com/antinvestor/builder/internal/events"
+	"github.com/antinvestor/builder/internal/executions"
+)
+
+// retryTestNow is when the retry policy under test requeues restarts.
+var retryTestNow = time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+
+// newRetryTestFailureHandler stores an initialized execution and returns a failure
+// handler whose retry policy requeues restarts at retryTestNow.
+func newRetryTestFailureHandler(
+	t *testing.T,
+	maxAttempts int,
+) (*FeatureFailureEvent, *executions.MemoryExecutionRepository, *mockQueueManager, events.ExecutionID) {
+	t.Helper()
+	cfg := &appconfig.WorkerConfig{
+		QueueFeatureResultName:            "feature.results",
+		QueueRetryLevel1Name:              "feature.events.retry.1",
+		ExecutionRetryMaxAttempts:         maxAttempts,
+		ExecutionRetryInitialDelaySeconds: 1,
+		ExecutionRetryMaxDelaySeconds:     60,
+	}
+
+	execID := events.NewExecutionID()
+	initialRequest, err := json.Marshal(&events.FeatureExecutionInitializedPayload{
+		ExecutionID: execID,
+		Spec:        events.FeatureSpecification{Title: "Add calculator"},
+		Repository:  events.RepositoryContext{RemoteURL: "https://github.com/example/calc.git", TargetBranch: "main"},
+	})
+	require.NoError(t, err)
+	repo := executions.NewMemoryExecutionRepository()
+	require.NoError(t, repo.Create(context.Background(), &executions.Execution{
+		ID:             execID.String(),
+		Status:         executions.ExecutionStatusRunning,
+		InitialRequest: initialRequest,
+	}))
+
+	qMan := &mockQueueManager{}
+	policy := NewExecutionRetryPolicy(cfg, repo, nil, qMan)
+	policy.now = func() time.Time { return retryTestNow }
+
+	handler := NewFeatureFailureEvent(cfg, repo, qMan, &mockEmitter{})
+	handler.SetExecutionRetryPolicy(policy)
+	return handler, repo, qMan, execID
+}
+
+// publishedTo returns the messages published to a queue.
+func publishedTo(qMan *mockQueueManager, queueName string) []publishedMessage {
+	var messages []publishedMessage
+	for _, message := range qMan.publishedMessages {
+		if message.queueName == queueName {
+			messages = append(messages, message)
+		}
+	}
+	return messages
+}
+
+// restartDelays returns how long after retryTestNow each requeued restart is due.
+func restartDelays(t *testing.T, restarts []publishedMessage) []time.Duration {
+	t.Helper()
+	delays := make([]time.Duration, 0, len(restarts))
+	for _, restart := range restarts {
+		notBefore, err := time.Parse(time.RFC3339Nano, restart.headers[queue.HeaderNotBefore])
+		require.NoError(t, err)
+		delays = append(delays, notBefore.Sub(retryTestNow))
+	}
+	return delays
+}
+
+func infrastructureFailure(execID events.ExecutionID) *events.FeatureExecutionFailedPayload {
+	return &events.FeatureExecutionFailedPayload{
+		ExecutionID: execID,
+		Classification: events.FailureClassification{
+			Type:      events.FailureTypeInfrastructure,
+			Retryable: true,
+		},
+		FailedPhase:  events.ExecutionPhaseCheckout,
+		ErrorCode:    string(events.CheckoutErrorNetwork),
+		ErrorMessage: "connection reset by peer",
+	}
+}
+
+func TestFeatureFailureEvent_InfraFailureRequeuesExecution(t *testing.T) {
+	handler, repo, qMan, execID := newRetryTestFailureHandler(t, 2)
+
+	require.NoError(t, handler.Execute(context.Background(), infrastructureFailure(execID)))
+
+	restarts := publishedTo(qMan, "feature.events.retry.1")
+	require.Len(t, restarts, 1)
+	body, ok := restarts[0].payload.([]byte)
+	require.True(t, ok)
+	var payload events.FeatureExecutionInitializedPayload
+	require.NoError(t, json.Unmarshal(body, &payload))
+	assert.Equal(t, execID, payload.ExecutionID)
+	assert.Equal(t, "Add calculator", payload.Spec.Title)
+	assert.Equal(t, "https://github.com/example/calc.git", payload.Repository.RemoteURL)
+	assert.Equal(t, execID.String(), restarts[0].headers[queue.HeaderExecutionID])
+	assert.Equal(t, []time.Duration{time.Second}, restartDelays(t, restarts), "the restart is due after the backoff")
+
+	assert.Empty(t, publishedTo(qMan, "feature.results"), "a restarted execution is not reported as failed")
+
+	stored, err := repo.GetByID(context.Background(), execID.String())
+	require.NoError(t, err)
+	assert.Equal(t, 1, stored.RetryAttempts)
+	assert.Equal(t, executions.ExecutionStatusPending, stored.Status)
+}
+
+func TestFeatureFailureEvent_InfraFailureGivesUpAfterMaxAttempts(t *testing.T) {
+	handler, repo, qMan, execID := newRetryTestFailureHandler(t, 2)
+
+	for range 3 {
+		require.NoError(t, handler.Execute(context.Background(), infrastructureFailure(execID)))
+	}
+
+	restarts := publishedTo(qMan, "feature.events.retry.1")
+	assert.Equal(t, []time.Duration{time.Second, 2 * time.Second}, restartDelays(t, restarts),
+		"backoff doubles per attempt")
+
+	results := publishedTo(qMan, "feature.results")
+	require.Len(t, results, 1)
+	result, ok := results[0].payload.(map[string]interface{})
+	require.True(t, ok)
+	assert.Equal(t, "failed", result["status"])
+	assert.Equal(t, execID.String(), result["execution_id"])
+
+	stored, err := repo.GetByID(context.Background(), execID.String())
+	require.NoError(t, err)
+	assert.Equal(t, 2, stored.RetryAttempts)
+	assert.Equal(t, executions.ExecutionStatusFailed, stored.Status, "an exhausted execution ends failed")
+	assert.NotNil(t, stored.CompletedAt)
+}
+
+func TestFeatureFailureEvent_SemanticFailureIsNotRetried(t *testing.T) {
+	handler, repo, qMan, execID := newRetryTestFailureHandler(t, 2)
+
+	require.NoError(t, handler.Execute(context.Background(), &events.FeatureExecutionFailedPayload{
+		ExecutionID: execID,
+		Classification: events.FailureClassification{
+			Type:      events.FailureTypeSemantic,
+			Retryable: false,
+		},
+		FailedPhase:  events.ExecutionPhaseVerification,
+		ErrorCode:    "review_abort",
+		ErrorMessage: "critical issues exceed threshold",
+	}))
+
+	assert.Empty(t, publishedTo(qMan, "feature.events.retry.1"))
+	require.Len(t, publishedTo(qMan, "feature.results"), 1)
+
+	stored, err := repo.GetByID(context.Background(), execID.String())
+	require.NoError(t, err)
+	assert.Equal(t, 0, stored.RetryAttempts)
+}
+
+func TestExecutionRetryPolicy_RequeueFailureIsReturned(t *testing.T) {
+	handler, _, qMan, execID := newRetryTestFailureHandler(t, 2)
+	qMan.publishError = errors.New("broker unavailable")
+
+	err := handler.Execute(context.Background(), infrastructureFailure(execID))
+	require.ErrorContains(t, err, "requeue execution restart")
+}
+
+func TestRepositoryCheckoutEvent_CloneFailureFailsExecutionAsInfrastructure(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	cfg := &appconfig.WorkerConfig{
+		WorkspaceBasePath:         t.TempDir(),
+		MaxConcurrentClones:       1,
+		CloneTimeoutSeconds:       30,
+		ExecutionRetryMaxAttempts: 2,
+	}
+	svc := repository.NewService(cfg, repository.NewWorkspaceRepository(context.Background(), nil))
+	emitter := &mockEmitter{}
+	handler := NewRepositoryCheckoutEvent(cfg, svc, emitter)
+
+	execID := events.NewExecutionID()
+	require.NoError(t, handler.Execute(context.Background(), &events.FeatureExecutionInitializedPayload{
+		ExecutionID: execID,
+		Repository: events.RepositoryContext{
+			RemoteURL:    filepath.Join(t.TempDir(), "missing"),
+			TargetBranch: "main",
+		},
+	}))
+
+	failures := findEmitted(emitter, events.FeatureExecutionFailed)
+	require.Len(t, failures, 1)
+	failure, ok := failures[0].(*events.FeatureExecutionFailedPayload)
+	require.True(t, ok)
+	assert.Equal(t, execID, failure.ExecutionID)
+	assert.True(t, isInfrastructureFailure(failure.Classification))
+	assert.Equal(t, events.ExecutionPhaseCheckout, failure.FailedPhase)
+}
+
+func TestRepositoryCheckoutEvent_CloneFailureRedeliveredWithoutExecutionRetry(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	cfg := &appconfig.WorkerConfig{
+		WorkspaceBasePath:   t.TempDir(),
+		MaxConcurrentClones: 1,
+		CloneTimeoutSeconds: 30,
+	}
+	svc := repository.NewService(cfg, repository.NewWorkspaceRepository(context.Background(), nil))
+	emitter := &mockEmitter{}
+	handler := NewRepositoryCheckoutEvent(cfg, svc, emitter)
+
+	err := handler.Execute(context.Background(), &events.FeatureExecutionInitializedPayload{
+		ExecutionID: events.NewExecutionID(),
+		Repository: events.RepositoryContext{
+			RemoteURL:    filepath.Join(t.TempDir(), "missing"),
+			TargetBranch: "main",
+		},
+	})
+	require.Error(t, err, "checkout is redelivered as a step retry")
+	assert.Empty(t, findEmitted(emitter, events.FeatureExecutionFailed))
+}
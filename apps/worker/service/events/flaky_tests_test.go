@@ -0,0 +1,103 @@
+//nolint:testpackage // white-box testing requires internal package access
+package events
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	appconfig "github.com/antinvestor/builder/apps/worker/config"
+	"github.com/antinvestor/builder/apps/worker/service/flakiness"
+	"github.com/antinvestor/builder/internal/events"
+)
+
+// testRun builds a completed test execution where flakyPassed and stablePassed
+// are the outcomes of TestFlaky and TestStable.
+func testRun(flakyPassed, stablePassed bool) *events.TestExecutionCompletedPayload {
+	result := &events.TestResult{TotalTests: 2, Success: true}
+	for name, passed := range map[string]bool{"TestFlaky": flakyPassed, "TestStable": stablePassed} {
+		status := "passed"
+		if passed {
+			result.PassedTests++
+		} else {
+			status = "failed"
+			result.FailedTests++
+			result.Success = false
+		}
+		result.TestCases = append(result.TestCases, events.TestCaseResult{Name: name, Status: status})
+	}
+	return &events.TestExecutionCompletedPayload{
+		ExecutionID: events.NewExecutionID(),
+		Success:     result.Success,
+		Result:      result,
+	}
+}
+
+func newFlakyTestHandler(
+	t *testing.T,
+	quarantine bool,
+) (*ReviewRequestEvent, *mockQueueManager, *mockEmitter) {
+	t.Helper()
+	cfg := &appconfig.WorkerConfig{
+		QueueReviewRequestName:     "review-request-queue",
+		FlakyTestQuarantineEnabled: quarantine,
+		FlakyTestWindow:            20,
+		FlakyTestMinRuns:           5,
+		FlakyTestMinFailureRate:    0.1,
+		FlakyTestMaxFailureRate:    0.8,
+		ReviewThresholds:           events.ReviewThresholds{MaxIterations: 3},
+	}
+	queueMan := &mockQueueManager{}
+	eventsMan := &mockEmitter{}
+
+	handler := NewReviewRequestEvent(cfg, queueMan, nil, eventsMan)
+	handler.SetFlakyTestTracker(flakiness.NewTracker(flakiness.PolicyFromConfig(cfg), flakiness.NewMemoryStore()))
+
+	// TestFlaky fails intermittently across earlier executions
+	for _, flakyPassed := range []bool{true, false, true, true, false, true} {
+		require.NoError(t, handler.Execute(context.Background(), testRun(flakyPassed, true)))
+	}
+	queueMan.publishedMessages = nil
+	eventsMan.emittedEvents = nil
+
+	return handler, queueMan, eventsMan
+}
+
+func TestReviewRequestEvent_FlakyFailureExcludedFromIterate(t *testing.T) {
+	handler, queueMan, eventsMan := newFlakyTestHandler(t, true)
+
+	require.NoError(t, handler.Execute(context.Background(), testRun(false, true)))
+
+	// The run proceeds to review instead of iterating
+	for _, evt := range eventsMan.emittedEvents {
+		assert.NotEqual(t, string(events.IterationRequired), evt.name)
+	}
+	require.Len(t, queueMan.publishedMessages, 1)
+	reviewRequest, ok := queueMan.publishedMessages[0].payload.(*events.ComprehensiveReviewRequestedPayload)
+	require.True(t, ok)
+	assert.True(t, reviewRequest.TestResults.Success)
+	assert.Equal(t, []string{"TestFlaky"}, reviewRequest.TestResults.QuarantinedTests)
+	assert.Equal(t, 1, reviewRequest.TestResults.FailedTests)
+}
+
+func TestReviewRequestEvent_StableFailureStillIterates(t *testing.T) {
+	handler, queueMan, eventsMan := newFlakyTestHandler(t, true)
+
+	require.NoError(t, handler.Execute(context.Background(), testRun(false, false)))
+
+	assert.Empty(t, queueMan.publishedMessages)
+	require.Len(t, eventsMan.emittedEvents, 1)
+	assert.Equal(t, string(events.IterationRequired), eventsMan.emittedEvents[0].name)
+}
+
+func TestReviewRequestEvent_FlakyFailureBlocksWithoutQuarantine(t *testing.T) {
+	handler, queueMan, eventsMan := newFlakyTestHandler(t, false)
+
+	require.NoError(t, handler.Execute(context.Background(), testRun(false, true)))
+
+	assert.Empty(t, queueMan.publishedMessages)
+	require.Len(t, eventsMan.emittedEvents, 1)
+	assert.Equal(t, string(events.IterationRequired), eventsMan.emittedEvents[0].name)
+}
@@ -11,8 +11,10 @@ import (
 	"github.com/pitabwire/util"
 
 	appconfig "github.com/antinvestor/builder/apps/worker/config"
+	"github.com/antinvestor/builder/apps/worker/service/accounting"
 	"github.com/antinvestor/builder/apps/worker/service/repository"
 	"github.com/antinvestor/builder/internal/events"
+	"github.com/antinvestor/builder/internal/executions"
 )
 
 // maxBranchNameLength is the maximum length for feature branch names.
@@ -24,6 +26,9 @@ const shortIDLength = 8
 // slugifyRegexp matches characters that should be replaced in branch names.
 var slugifyRegexp = regexp.MustCompile(`[^a-z0-9]+`)
 
+// errModelRecorded skips the execution update when its model is already recorded.
+var errModelRecorded = errors.New("model already recorded")
+
 // generateFeatureBranchName creates a feature branch name from the title.
 func generateFeatureBranchName(title string, execID events.ExecutionID) string {
 	// Convert to lowercase and replace non-alphanumeric with hyphens
@@ -66,9 +71,10 @@ type QueueManager interface {
 
 // RepositoryCheckoutEvent handles repository checkout operations.
 type RepositoryCheckoutEvent struct {
-	cfg         *appconfig.WorkerConfig
-	repoService *repository.Service
-	eventsMan   Emitter
+	cfg          *appconfig.WorkerConfig
+	repoService  *repository.Service
+	eventsMan    Emitter
+	execContexts *ExecutionContextStore
 }
 
 // NewRepositoryCheckoutEvent creates a new repository checkout event handler.
@@ -84,6 +90,11 @@ func NewRepositoryCheckoutEvent(
 	}
 }
 
+// SetExecutionContexts records each execution's context as it is initialized and checked out.
+func (h *RepositoryCheckoutEvent) SetExecutionContexts(store *ExecutionContextStore) {
+	h.execContexts = store
+}
+
 // Name returns the event name.
 func (h *RepositoryCheckoutEvent) Name() string {
 	return string(events.FeatureExecutionInitialized)
@@ -108,6 +119,7 @@ func (h *RepositoryCheckoutEvent) Execute(ctx context.Context, payload any) erro
 
 	// Use execution ID from the request (created by queue handler)
 	execID := request.ExecutionID
+	h.execContexts.Start(h.cfg, request)
 
 	// Emit checkout started
 	if err := h.eventsMan.Emit(ctx, string(events.RepositoryCheckoutStarted), &events.RepositoryCheckoutStartedPayload{
@@ -124,6 +136,8 @@ func (h *RepositoryCheckoutEvent) Execute(ctx context.Context, payload any) erro
 		RepositoryURL: request.Repository.RemoteURL,
 		Branch:        request.Repository.TargetBranch,
 		CommitSHA:     request.Repository.BaseCommitSHA,
+		Submodules:    request.Repository.Submodules,
+		LFS:           request.Repository.LFS,
 	})
 	if err != nil {
 		emitErr := h.eventsMan.Emit(
@@ -139,6 +153,17 @@ func (h *RepositoryCheckoutEvent) Execute(ctx context.Context, payload any) erro
 		if emitErr != nil {
 			util.Log(ctx).Warn("failed to emit checkout failure event", "error", emitErr)
 		}
+		// With execution retries the whole execution is restarted rather than redelivering checkout
+		if h.cfg != nil && h.cfg.ExecutionRetryMaxAttempts > 0 {
+			return emitInfrastructureFailure(ctx, h.eventsMan, execID, events.ExecutionPhaseCheckout,
+				string(events.CheckoutErrorNetwork), err)
+		}
+		return err
+	}
+
+	// Copy scaffold files in before generation so they are committed with the feature
+	seedFiles, err := h.seedWorkspace(ctx, request)
+	if err != nil {
 		return err
 	}
 
@@ -148,6 +173,14 @@ func (h *RepositoryCheckoutEvent) Execute(ctx context.Context, payload any) erro
 		featureBranch = generateFeatureBranchName(request.Spec.Title, execID)
 	}
 
+	h.execContexts.Update(execID, func(execCtx *ExecutionContext) {
+		execCtx.WorkspacePath = result.WorkspacePath
+		execCtx.BaseBranch = result.Branch
+		execCtx.BaseCommitSHA = result.CommitSHA
+		execCtx.FeatureBranchName = featureBranch
+		execCtx.SeedFiles = seedFiles
+	})
+
 	// Emit completion with feature spec for downstream handlers
 	return h.eventsMan.Emit(
 		ctx,
@@ -157,9 +190,13 @@ func (h *RepositoryCheckoutEvent) Execute(ctx context.Context, payload any) erro
 			WorkspacePath:     result.WorkspacePath,
 			HeadCommitSHA:     result.CommitSHA,
 			BranchName:        result.Branch,
+			DefaultBranch:     result.DefaultBranch,
 			FeatureBranchName: featureBranch,
 			Spec:              request.Spec,
 			RepositoryURL:     request.Repository.RemoteURL,
+			Submodules:        result.Submodules,
+			LFS:               result.LFS,
+			SeedFiles:         seedFiles,
 			DurationMS:        result.CheckoutTimeMS,
 			CompletedAt:       time.Now(),
 		},
@@ -191,6 +228,11 @@ type GeneratePatchResponse struct {
 	Patches       []Patch
 	CommitMessage string
 	TokensUsed    int
+	// LLM identifies the provider, model and model version that generated the patches.
+	LLM events.LLMProcessingInfo
+	// LLMCalls are the LLM calls the generation made, each with its tokens and the
+	// model that served it.
+	LLMCalls []events.LLMProcessingInfo
 }
 
 // Patch represents a code patch.
@@ -201,12 +243,25 @@ type Patch struct {
 	Action     events.FileAction
 }
 
+// CompileChecker runs a fast compile/syntax check on an execution's workspace.
+type CompileChecker interface {
+	CompileCheck(ctx context.Context, executionID events.ExecutionID) (*repository.CompileCheckResult, error)
+}
+
 // PatchGenerationEvent handles patch generation operations.
 type PatchGenerationEvent struct {
 	cfg         *appconfig.WorkerConfig
 	bamlClient  BAMLClient
 	repoService *repository.Service
+	ledger      *accounting.Ledger
 	eventsMan   Emitter
+
+	acceptanceCheck *AcceptanceSelfCheck
+	compileChecker  CompileChecker
+	previews        *PatchPreviewStore
+	execContexts    *ExecutionContextStore
+	templates       *DeliveryTemplates
+	executionRepo   executions.ExecutionRepository
 }
 
 // NewPatchGenerationEvent creates a new patch generation event handler.
@@ -214,16 +269,44 @@ func NewPatchGenerationEvent(
 	cfg *appconfig.WorkerConfig,
 	bamlClient BAMLClient,
 	repoService *repository.Service,
+	ledger *accounting.Ledger,
 	eventsMan Emitter,
 ) *PatchGenerationEvent {
 	return &PatchGenerationEvent{
 		cfg:         cfg,
 		bamlClient:  bamlClient,
 		repoService: repoService,
+		ledger:      ledger,
 		eventsMan:   eventsMan,
 	}
 }
 
+// SetAcceptanceSelfCheck enables the acceptance-criteria self-check after generation.
+func (h *PatchGenerationEvent) SetAcceptanceSelfCheck(check *AcceptanceSelfCheck) {
+	h.acceptanceCheck = check
+}
+
+// SetCompileChecker enables the compile check after patches are applied.
+func (h *PatchGenerationEvent) SetCompileChecker(checker CompileChecker) {
+	h.compileChecker = checker
+}
+
+// SetExecutionContexts records the delivered patches and commit in each execution's context.
+func (h *PatchGenerationEvent) SetExecutionContexts(store *ExecutionContextStore) {
+	h.execContexts = store
+}
+
+// SetDeliveryTemplates replaces the built-in commit message footer template.
+func (h *PatchGenerationEvent) SetDeliveryTemplates(templates *DeliveryTemplates) {
+	h.templates = templates
+}
+
+// SetExecutionRepository records the models that generated each execution's
+// patches on its execution record.
+func (h *PatchGenerationEvent) SetExecutionRepository(repo executions.ExecutionRepository) {
+	h.executionRepo = repo
+}
+
 // Name returns the event name.
 func (h *PatchGenerationEvent) Name() string {
 	return string(events.RepositoryCheckoutCompleted)
@@ -246,17 +329,31 @@ type patchStats struct {
 	filesDeleted  int
 	linesAdded    int
 	linesRemoved  int
+
+	byLanguage  map[string]*events.DiffStatsEntry
+	byDirectory map[string]*events.DiffStatsEntry
 }
 
-// Execute processes patch generation.
+// Execute processes patch generation. A kill switch activated meanwhile cancels
+// the generation; the execution has then already been failed.
 func (h *PatchGenerationEvent) Execute(ctx context.Context, payload any) error {
-	log := util.Log(ctx)
-
 	request, ok := payload.(*events.RepositoryCheckoutCompletedPayload)
 	if !ok {
 		return errors.New("invalid payload type: expected *RepositoryCheckoutCompletedPayload")
 	}
 
+	ctx, release := h.execContexts.Bind(ctx, request.ExecutionID)
+	defer release()
+	err := h.generate(ctx, request)
+	if killedBySwitch(ctx) {
+		return nil
+	}
+	return err
+}
+
+// generate generates, applies and delivers the patches of a checked out execution.
+func (h *PatchGenerationEvent) generate(ctx context.Context, request *events.RepositoryCheckoutCompletedPayload) error {
+	log := util.Log(ctx)
 	execID := request.ExecutionID
 	startTime := time.Now()
 
@@ -265,25 +362,159 @@ func (h *PatchGenerationEvent) Execute(ctx context.Context, payload any) error {
 		"feature_branch", request.FeatureBranchName,
 	)
 
+	if h.ledger != nil {
+		h.ledger.Start(execID.String())
+	}
+
 	// Phase 1: Setup - emit started, create branch
 	if err := h.setupPatchGeneration(ctx, execID, request, startTime); err != nil {
 		return err
 	}
 
+	// In preview mode the patches wait for approval before being applied
+	if h.previews != nil {
+		return h.requestPreview(ctx, execID, request, startTime)
+	}
+
 	// Phase 2: Generate and apply patches
 	resp, stats, err := h.generateAndApplyPatches(ctx, execID, request)
 	if err != nil {
 		return err
 	}
 
+	// Charge LLM tokens against the execution budget before delivering anything
+	if err = h.recordGenerationTokens(ctx, execID, resp); err != nil {
+		return err
+	}
+
+	return h.deliver(ctx, execID, request, resp, stats, startTime)
+}
+
+// recordGenerationTokens charges the generation's LLM tokens against the execution
+// budget and records the models that generated the patches.
+func (h *PatchGenerationEvent) recordGenerationTokens(
+	ctx context.Context,
+	execID events.ExecutionID,
+	resp *GeneratePatchResponse,
+) error {
+	if err := recordGenerationUsage(ctx, h.ledger, h.executionRepo, execID, "patch_generation", resp); err != nil {
+		return emitResourceExhausted(ctx, h.eventsMan, execID, events.ExecutionPhaseGeneration, err)
+	}
+	return nil
+}
+
+// recordGenerationUsage charges the tokens of each of a generation's LLM calls to
+// the model that served it, and records the models on the execution record. Tokens
+// the calls do not account for are charged without a model. A response without
+// calls charges all its tokens to the model it names. Either store may be nil.
+func recordGenerationUsage(
+	ctx context.Context,
+	ledger *accounting.Ledger,
+	repo executions.ExecutionRepository,
+	execID events.ExecutionID,
+	phase string,
+	resp *GeneratePatchResponse,
+) error {
+	if len(resp.LLMCalls) == 0 {
+		if repo != nil {
+			recordExecutionModel(ctx, repo, execID, resp.LLM)
+		}
+		if ledger == nil {
+			return nil
+		}
+		return ledger.RecordLLMUsage(execID.String(), phase, resp.TokensUsed, ledgerModel(resp.LLM))
+	}
+
+	if repo != nil {
+		for _, call := range resp.LLMCalls {
+			recordExecutionModel(ctx, repo, execID, call)
+		}
+	}
+	if ledger == nil {
+		return nil
+	}
+	attributed := 0
+	for _, call := range resp.LLMCalls {
+		tokens := call.InputTokens + call.OutputTokens
+		attributed += tokens
+		if err := ledger.RecordLLMUsage(execID.String(), phase, tokens, ledgerModel(call)); err != nil {
+			return err
+		}
+	}
+	if resp.TokensUsed > attributed {
+		return ledger.RecordLLMTokens(execID.String(), phase, resp.TokensUsed-attributed)
+	}
+	return nil
+}
+
+// ledgerModel returns the model to attribute LLM tokens to in the ledger.
+func ledgerModel(info events.LLMProcessingInfo) accounting.Model {
+	return accounting.Model{Provider: info.Provider, Model: info.Model, Version: info.ModelVersion}
+}
+
+// recordExecutionModel adds the model that served an LLM call to the execution
+// record. Failing to record it is logged and does not fail the execution.
+func recordExecutionModel(
+	ctx context.Context,
+	repo executions.ExecutionRepository,
+	execID events.ExecutionID,
+	info events.LLMProcessingInfo,
+) {
+	if info.Model == "" {
+		return
+	}
+	model := executions.LLMModel{Provider: info.Provider, Model: info.Model, Version: info.ModelVersion}
+	_, err := executions.UpdateExecution(ctx, repo, execID.String(), maxRetryUpdateAttempts,
+		func(e *executions.Execution) error {
+			if !e.RecordLLMModel(model) {
+				return errModelRecorded
+			}
+			return nil
+		})
+	if err != nil && !errors.Is(err, errModelRecorded) {
+		util.Log(ctx).WithError(err).Warn("failed to record LLM model on execution",
+			"execution_id", execID.String(),
+			"model", info.Model,
+		)
+	}
+}
+
+// deliver commits and pushes applied patches and emits the completion events.
+func (h *PatchGenerationEvent) deliver(
+	ctx context.Context,
+	execID events.ExecutionID,
+	request *events.RepositoryCheckoutCompletedPayload,
+	resp *GeneratePatchResponse,
+	stats *patchStats,
+	startTime time.Time,
+) error {
+	// Self-assess the changes against the acceptance criteria for the reviewer
+	var acceptance *events.AcceptanceSelfAssessment
+	if h.acceptanceCheck != nil {
+		acceptance = h.acceptanceCheck.Run(ctx, execID, request.Spec, resp.Patches)
+	}
+	if acceptance != nil && h.executionRepo != nil {
+		recordExecutionModel(ctx, h.executionRepo, execID, acceptance.LLMInfo)
+	}
+
 	// Phase 3: Commit and push
 	commitInfo, err := h.commitAndPush(ctx, execID, request, resp)
+	if errors.Is(err, repository.ErrNoChanges) && h.noChangesIsNoOp() {
+		return h.emitNoOp(ctx, execID, request, resp)
+	}
+	if errors.Is(err, errDeliveryPaused) {
+		return nil
+	}
 	if err != nil {
 		return err
 	}
+	h.execContexts.Update(execID, func(execCtx *ExecutionContext) {
+		execCtx.HeadCommitSHA = commitInfo.SHA
+		execCtx.Patches = resp.Patches
+	})
 
 	// Phase 4: Emit completion events
-	return h.emitCompletionEvents(ctx, execID, request, resp, stats, commitInfo, startTime)
+	return h.emitCompletionEvents(ctx, execID, request, resp, stats, commitInfo, acceptance, startTime)
 }
 
 // setupPatchGeneration handles initial setup for patch generation.
@@ -329,42 +560,277 @@ func (h *PatchGenerationEvent) generateAndApplyPatches(
 ) (*GeneratePatchResponse, *patchStats, error) {
 	log := util.Log(ctx)
 
-	// Get repository structure for LLM context
-	repoContext, err := h.repoService.GetProjectStructure(ctx, execID)
+	// Get repository structure and declarations for LLM context
+	repoContext, err := h.repoService.GetRepositoryContext(ctx, execID)
 	if err != nil {
 		log.Warn("failed to get project structure", "error", err)
 		repoContext = ""
 	}
 
-	// Generate patches using BAML/LLM
-	resp, err := h.bamlClient.GeneratePatch(ctx, &GeneratePatchRequest{
+	genReq := &GeneratePatchRequest{
 		ExecutionID:       execID,
 		Specification:     request.Spec,
 		WorkspacePath:     request.WorkspacePath,
 		RepositoryContext: repoContext,
 		IterationNumber:   1,
-	})
-	if err != nil {
-		return nil, nil, h.emitGenerationFailure(ctx, execID, "llm_generation", err, events.StepErrorCategoryLLM)
 	}
 
-	// Apply patches and collect stats
-	stats, err := h.applyPatches(ctx, execID, resp.Patches)
+	var (
+		applied    []Patch
+		tokensUsed int
+	)
+	for attempt := 0; ; attempt++ {
+		// Generate patches using BAML/LLM
+		resp, genErr := h.generateVerifiedPatches(ctx, execID, genReq, applied)
+		if genErr != nil {
+			return nil, nil, genErr
+		}
+		tokensUsed += resp.TokensUsed
+
+		// Only the patches that applied are committed
+		appliedNow, _, applyErr := h.applyPatches(ctx, execID, resp.Patches, h.patchApplicationMode())
+		if applyErr != nil {
+			return nil, nil, applyErr
+		}
+		applied = mergePatches(applied, appliedNow)
+
+		compileResult := h.compileCheck(ctx, execID)
+		if compileResult == nil || compileResult.Passed {
+			resp.Patches = applied
+			resp.TokensUsed = tokensUsed
+			stats := &patchStats{}
+			for i := range applied {
+				h.updatePatchStats(stats, &applied[i])
+			}
+			return resp, stats, nil
+		}
+
+		if attempt >= h.cfg.CompileCheckMaxRetries {
+			return nil, nil, h.emitGenerationFailure(ctx, execID, "compile_check",
+				fmt.Errorf("%s failed after %d attempts:\n%s", compileResult.Command, attempt+1,
+					truncateCompileOutput(compileResult.Output)),
+				events.StepErrorCategorySyntax)
+		}
+
+		// Iterate immediately with the compiler errors instead of spending a test cycle
+		genReq.IterationNumber++
+		genReq.PreviousPatches = applied
+		genReq.FeedbackFromReview = buildCompileFeedback(compileResult)
+
+		log.Info("compile check failed, regenerating patches",
+			"execution_id", execID.String(),
+			"command", compileResult.Command,
+			"iteration_number", genReq.IterationNumber,
+		)
+
+		if emitErr := h.eventsMan.Emit(ctx, string(events.IterationStarted), &events.IterationStartedPayload{
+			IterationNumber: genReq.IterationNumber,
+			Reason:          events.IterationReasonBuildFailed,
+			TargetIssues: []events.IterationIssue{{
+				Type:        "compile_error",
+				Severity:    string(events.ReviewIssueSeverityHigh),
+				Description: truncateCompileOutput(compileResult.Output),
+			}},
+			Strategy: events.IterationStrategy{
+				Approach: events.IterationApproachFix,
+			},
+			StartedAt: time.Now(),
+		}); emitErr != nil {
+			log.Warn("failed to emit iteration started event", "error", emitErr)
+		}
+	}
+}
+
+// generateVerifiedPatches generates patches and, when patch verification is
+// enabled, regenerates them with the mismatches as feedback until they match the
+// workspace. The response counts the tokens spent on rejected patches too.
+func (h *PatchGenerationEvent) generateVerifiedPatches(
+	ctx context.Context,
+	execID events.ExecutionID,
+	genReq *GeneratePatchRequest,
+	applied []Patch,
+) (*GeneratePatchResponse, error) {
+	tokensUsed := 0
+	for attempt := 0; ; attempt++ {
+		resp, err := h.bamlClient.GeneratePatch(ctx, genReq)
+		if err != nil {
+			return nil, h.emitGenerationFailure(ctx, execID, "llm_generation", err, events.StepErrorCategoryLLM)
+		}
+		tokensUsed += resp.TokensUsed
+
+		mismatches := h.verifyPatches(ctx, execID, resp.Patches, applied)
+		if len(mismatches) == 0 {
+			resp.TokensUsed = tokensUsed
+			return resp, nil
+		}
+		if attempt >= h.cfg.PatchVerificationMaxRetries {
+			errs := make([]error, 0, len(mismatches))
+			for _, mismatch := range mismatches {
+				errs = append(errs, mismatch)
+			}
+			return nil, h.emitGenerationFailure(ctx, execID, "patch_verification",
+				fmt.Errorf("patches still mismatch the workspace after %d attempts: %w",
+					attempt+1, errors.Join(errs...)),
+				events.StepErrorCategoryConflict)
+		}
+
+		genReq.IterationNumber++
+		genReq.PreviousPatches = applied
+		genReq.FeedbackFromReview = buildVerificationFeedback(mismatches)
+
+		log := util.Log(ctx)
+		log.Info("patches do not match the workspace, regenerating",
+			"execution_id", execID.String(),
+			"mismatches", len(mismatches),
+			"iteration_number", genReq.IterationNumber,
+		)
+
+		issues := make([]events.IterationIssue, 0, len(mismatches))
+		for _, mismatch := range mismatches {
+			issues = append(issues, events.IterationIssue{
+				Type:        string(mismatch.Reason),
+				Severity:    string(events.ReviewIssueSeverityHigh),
+				FilePath:    mismatch.FilePath,
+				Description: mismatch.Error(),
+			})
+		}
+		if emitErr := h.eventsMan.Emit(ctx, string(events.IterationStarted), &events.IterationStartedPayload{
+			IterationNumber: genReq.IterationNumber,
+			Reason:          events.IterationReasonValidationFailed,
+			TargetIssues:    issues,
+			Strategy: events.IterationStrategy{
+				Approach: events.IterationApproachFix,
+			},
+			StartedAt: time.Now(),
+		}); emitErr != nil {
+			log.Warn("failed to emit iteration started event", "error", emitErr)
+		}
+	}
+}
+
+// verifyPatches returns the patches that do not match the workspace. Files this
+// execution already patched are not checked, as a later attempt rewrites them.
+// Verification that cannot run is left to the application to surface.
+func (h *PatchGenerationEvent) verifyPatches(
+	ctx context.Context,
+	execID events.ExecutionID,
+	patches []Patch,
+	applied []Patch,
+) []*repository.PatchMismatchError {
+	if !h.cfg.PatchVerificationEnabled {
+		return nil
+	}
+
+	patched := make(map[string]bool, len(applied))
+	for _, patch := range applied {
+		patched[patch.FilePath] = true
+	}
+
+	var mismatches []*repository.PatchMismatchError
+	for _, patch := range patches {
+		if patched[patch.FilePath] {
+			continue
+		}
+		err := h.repoService.VerifyPatch(ctx, execID, &events.Patch{
+			FilePath:   patch.FilePath,
+			Action:     patch.Action,
+			OldContent: patch.OldContent,
+			NewContent: patch.NewContent,
+		})
+		var mismatch *repository.PatchMismatchError
+		switch {
+		case errors.As(err, &mismatch):
+			mismatches = append(mismatches, mismatch)
+		case err != nil:
+			util.Log(ctx).WithError(err).Warn("patch verification could not run", "file", patch.FilePath)
+		}
+	}
+	return mismatches
+}
+
+// buildVerificationFeedback formats patches that failed verification as
+// iteration feedback.
+func buildVerificationFeedback(mismatches []*repository.PatchMismatchError) string {
+	var sb strings.Builder
+	sb.WriteString("The previous patches do not match the current repository files. ")
+	sb.WriteString("Regenerate them against the files as they are now:\n\n")
+	for _, mismatch := range mismatches {
+		fmt.Fprintf(&sb, "- %s (%s): ", mismatch.FilePath, mismatch.Action)
+		switch mismatch.Reason {
+		case repository.PatchMismatchStaleContext:
+			sb.WriteString("old_content does not occur in the file; base the change on its current content\n")
+		case repository.PatchMismatchFileExists:
+			sb.WriteString("the file already exists; modify it instead of creating it\n")
+		case repository.PatchMismatchFileMissing:
+			sb.WriteString("the file does not exist; create it instead of modifying it\n")
+		default:
+			sb.WriteString(string(mismatch.Reason) + "\n")
+		}
+	}
+	return sb.String()
+}
+
+// compileCheck runs the configured compile check, returning nil when it is
+// disabled, not applicable or could not run.
+func (h *PatchGenerationEvent) compileCheck(
+	ctx context.Context,
+	execID events.ExecutionID,
+) *repository.CompileCheckResult {
+	if h.compileChecker == nil || !h.cfg.CompileCheckEnabled {
+		return nil
+	}
+
+	result, err := h.compileChecker.CompileCheck(ctx, execID)
 	if err != nil {
-		return nil, nil, err
+		util.Log(ctx).WithError(err).Warn("compile check could not run, skipping",
+			"execution_id", execID.String(),
+		)
+		return nil
 	}
+	if result.Skipped() {
+		return nil
+	}
+	return result
+}
 
-	return resp, stats, nil
+// mergePatches overlays patches from a later attempt onto those already applied,
+// keeping the latest patch per file.
+func mergePatches(applied, next []Patch) []Patch {
+	merged := make([]Patch, 0, len(applied)+len(next))
+	index := make(map[string]int, len(applied)+len(next))
+	for _, patch := range append(append([]Patch{}, applied...), next...) {
+		if i, ok := index[patch.FilePath]; ok {
+			// A file created earlier stays a creation; a modified file keeps its original content
+			if merged[i].Action == events.FileActionCreate && patch.Action == events.FileActionModify {
+				patch.Action = events.FileActionCreate
+				patch.OldContent = ""
+			} else if merged[i].Action == events.FileActionModify {
+				patch.OldContent = merged[i].OldContent
+			}
+			merged[i] = patch
+			continue
+		}
+		index[patch.FilePath] = len(merged)
+		merged = append(merged, patch)
+	}
+	return merged
 }
 
-// applyPatches applies patches and returns statistics.
+// applyPatches applies patches and returns those that applied with their
+// statistics. In strict mode the first patch that fails to apply fails the
+// execution. In lenient mode the remaining patches are still applied, the outcome
+// is emitted as a PatchApplicationResult, and the execution fails only when more
+// than the configured share of patches failed.
 func (h *PatchGenerationEvent) applyPatches(
 	ctx context.Context,
 	execID events.ExecutionID,
 	patches []Patch,
-) (*patchStats, error) {
+	mode events.PatchApplicationMode,
+) ([]Patch, *patchStats, error) {
 	log := util.Log(ctx)
 	stats := &patchStats{}
+	applied := make([]Patch, 0, len(patches))
+	var failures []events.PatchApplicationError
 
 	for _, patch := range patches {
 		eventsPatch := &events.Patch{
@@ -376,15 +842,88 @@ func (h *PatchGenerationEvent) applyPatches(
 
 		if applyErr := h.repoService.ApplyPatch(ctx, execID, eventsPatch); applyErr != nil {
 			log.WithError(applyErr).Error("failed to apply patch", "file", patch.FilePath)
-			return nil, h.emitGenerationFailure(
-				ctx, execID, "patch_application", applyErr, events.StepErrorCategoryResource,
-			)
+			category := events.StepErrorCategoryResource
+			if errors.Is(applyErr, repository.ErrInvalidEncoding) {
+				category = events.StepErrorCategoryValidation
+			}
+			if mode != events.PatchApplicationLenient {
+				return nil, nil, h.emitGenerationFailure(ctx, execID, "patch_application", applyErr, category)
+			}
+			failures = append(failures, events.PatchApplicationError{
+				FilePath:      patch.FilePath,
+				Action:        patch.Action,
+				ErrorMessage:  applyErr.Error(),
+				ErrorCategory: category,
+			})
+			continue
 		}
 
 		h.updatePatchStats(stats, &patch)
+		applied = append(applied, patch)
 	}
 
-	return stats, nil
+	if mode != events.PatchApplicationLenient {
+		return applied, stats, nil
+	}
+	if err := h.reportPatchApplication(ctx, execID, len(patches), applied, failures); err != nil {
+		return nil, nil, err
+	}
+	return applied, stats, nil
+}
+
+// reportPatchApplication emits the outcome of a lenient patch application and
+// fails the execution when the failed patches exceed the threshold.
+func (h *PatchGenerationEvent) reportPatchApplication(
+	ctx context.Context,
+	execID events.ExecutionID,
+	total int,
+	applied []Patch,
+	failures []events.PatchApplicationError,
+) error {
+	exceeded := len(failures) > 0 &&
+		(len(applied) == 0 || float64(len(failures))/float64(total) > h.cfg.PatchFailureThreshold)
+
+	result := &events.PatchApplicationResultPayload{
+		ExecutionID:       execID,
+		Mode:              events.PatchApplicationLenient,
+		TotalPatches:      total,
+		AppliedFiles:      make([]string, 0, len(applied)),
+		FailedFiles:       failures,
+		ThresholdExceeded: exceeded,
+		CompletedAt:       time.Now(),
+	}
+	for _, patch := range applied {
+		result.AppliedFiles = append(result.AppliedFiles, patch.FilePath)
+	}
+	if err := h.eventsMan.Emit(ctx, string(events.PatchApplicationResult), result); err != nil {
+		util.Log(ctx).Warn("failed to emit patch application result", "error", err)
+	}
+
+	if !exceeded {
+		if len(failures) > 0 {
+			util.Log(ctx).Warn("continuing with partially applied patches",
+				"execution_id", execID.String(),
+				"applied", len(applied),
+				"failed", len(failures),
+			)
+		}
+		return nil
+	}
+	failedFiles := make([]string, 0, len(failures))
+	for _, failure := range failures {
+		failedFiles = append(failedFiles, failure.FilePath)
+	}
+	return h.emitGenerationFailure(ctx, execID, "patch_application",
+		fmt.Errorf("%d of %d patches failed to apply: %s", len(failures), total, strings.Join(failedFiles, ", ")),
+		failures[0].ErrorCategory)
+}
+
+// patchApplicationMode returns the configured patch application mode.
+func (h *PatchGenerationEvent) patchApplicationMode() events.PatchApplicationMode {
+	if events.PatchApplicationMode(h.cfg.PatchApplicationMode) == events.PatchApplicationLenient {
+		return events.PatchApplicationLenient
+	}
+	return events.PatchApplicationStrict
 }
 
 // updatePatchStats updates statistics based on patch action.
@@ -392,17 +931,15 @@ func (h *PatchGenerationEvent) updatePatchStats(stats *patchStats, patch *Patch)
 	switch patch.Action {
 	case events.FileActionCreate:
 		stats.filesCreated++
-		stats.linesAdded += countLines(patch.NewContent)
-	case events.FileActionModify:
+	case events.FileActionModify, events.FileActionRename:
 		stats.filesModified++
-		stats.linesAdded += countLines(patch.NewContent)
-		stats.linesRemoved += countLines(patch.OldContent)
 	case events.FileActionDelete:
 		stats.filesDeleted++
-		stats.linesRemoved += countLines(patch.OldContent)
-	case events.FileActionRename:
-		stats.filesModified++
 	}
+	linesAdded, linesRemoved := patchLineCounts(patch)
+	stats.linesAdded += linesAdded
+	stats.linesRemoved += linesRemoved
+	stats.recordDiff(patch.FilePath, linesAdded, linesRemoved)
 }
 
 // commitAndPush creates commit and pushes to remote.
@@ -418,8 +955,17 @@ func (h *PatchGenerationEvent) commitAndPush(
 	if commitMessage == "" {
 		commitMessage = fmt.Sprintf("feat: %s\n\nImplemented via automated feature builder.", request.Spec.Title)
 	}
+	// The footer traces the commit back to its spec; without it the commit is still made
+	if footer, footerErr := h.templates.CommitFooter(execID, request.Spec); footerErr != nil {
+		log.Warn("failed to render commit footer", "error", footerErr)
+	} else {
+		commitMessage = withCommitFooter(commitMessage, footer)
+	}
 
 	commitInfo, err := h.repoService.CreateCommit(ctx, execID, commitMessage)
+	if errors.Is(err, repository.ErrNoChanges) && h.noChangesIsNoOp() {
+		return nil, err
+	}
 	if err != nil {
 		return nil, h.emitGenerationFailure(ctx, execID, "commit_creation", err, events.StepErrorCategoryResource)
 	}
@@ -459,18 +1005,58 @@ func (h *PatchGenerationEvent) pushBranch(
 		log.Warn("failed to emit push started event", "error", err)
 	}
 
-	if err := h.repoService.PushBranch(ctx, execID, request.FeatureBranchName); err != nil {
-		h.emitPushFailure(ctx, request.FeatureBranchName, err)
+	pushedSHA, err := h.repoService.PushBranch(ctx, execID, request.FeatureBranchName)
+	if err != nil {
+		conflicts := h.deliveryConflicts()
+		var conflict *events.DeliveryConflictPayload
+		pushedSHA, conflict, err = conflicts.recoverRejectedPush(ctx, execID, request.FeatureBranchName, err)
+		if conflict != nil {
+			if routeErr := conflicts.routeDeliveryConflict(ctx, conflict); routeErr != nil {
+				return routeErr
+			}
+			return errDeliveryPaused
+		}
+	}
+	if err != nil {
+		errorCode, retryable := h.emitPushFailure(ctx, request.FeatureBranchName, err)
+		if retryable && h.cfg.ExecutionRetryMaxAttempts > 0 {
+			return emitInfrastructureFailure(ctx, h.eventsMan, execID, events.ExecutionPhaseDelivery,
+				string(errorCode), err)
+		}
 		return h.emitGenerationFailure(ctx, execID, "push", err, events.StepErrorCategoryResource)
 	}
 
+	// The delivered commit is the one the remote received; taking in remote
+	// commits after a rejection moves it off the local commit
+	if pushedSHA != "" {
+		commitInfo.SHA = pushedSHA
+	}
 	return nil
 }
 
-// emitPushFailure emits a push failed event.
-func (h *PatchGenerationEvent) emitPushFailure(ctx context.Context, branchName string, err error) {
+// deliveryConflicts returns the recovery of pushes the remote branch rejected.
+func (h *PatchGenerationEvent) deliveryConflicts() deliveryConflicts {
+	return deliveryConflicts{
+		cfg:         h.cfg,
+		repoService: h.repoService,
+		rebaser:     h.repoService,
+		codeOwners:  h.repoService,
+		eventsMan:   h.eventsMan,
+	}
+}
+
+// emitPushFailure emits a push failed event and returns the failure's classification.
+func (h *PatchGenerationEvent) emitPushFailure(
+	ctx context.Context,
+	branchName string,
+	err error,
+) (events.GitPushErrorCode, bool) {
 	log := util.Log(ctx)
 	errorCode, retryable := classifyPushError(err)
+	if errorCode == events.GitPushErrorRejected {
+		// The remote branch was already synced with; pushing it unchanged again cannot succeed
+		retryable = false
+	}
 	pushFailErr := h.eventsMan.Emit(ctx, string(events.GitPushFailed), &events.GitPushFailedPayload{
 		BranchName:   branchName,
 		ErrorCode:    errorCode,
@@ -481,6 +1067,7 @@ func (h *PatchGenerationEvent) emitPushFailure(ctx context.Context, branchName s
 	if pushFailErr != nil {
 		log.Warn("failed to emit push failed event", "error", pushFailErr)
 	}
+	return errorCode, retryable
 }
 
 // classifyPushError determines the error code and retryability based on error message.
@@ -524,6 +1111,7 @@ func (h *PatchGenerationEvent) emitCompletionEvents(
 	resp *GeneratePatchResponse,
 	stats *patchStats,
 	commitInfo *events.CommitInfo,
+	acceptance *events.AcceptanceSelfAssessment,
 	startTime time.Time,
 ) error {
 	log := util.Log(ctx)
@@ -531,6 +1119,7 @@ func (h *PatchGenerationEvent) emitCompletionEvents(
 
 	// Emit push completed
 	if err := h.eventsMan.Emit(ctx, string(events.GitPushCompleted), &events.GitPushCompletedPayload{
+		ExecutionID:     execID,
 		BranchName:      request.FeatureBranchName,
 		RemoteRef:       fmt.Sprintf("refs/heads/%s", request.FeatureBranchName),
 		RemoteCommitSHA: commitInfo.SHA,
@@ -556,6 +1145,7 @@ func (h *PatchGenerationEvent) emitCompletionEvents(
 		FinalCommitSHA:    commitInfo.SHA,
 		TotalDurationMS:   durationMS,
 		TotalLLMTokens:    resp.TokensUsed,
+		LLMInfo:           resp.LLM,
 		CompletedAt:       time.Now(),
 	}); err != nil {
 		return err
@@ -563,6 +1153,7 @@ func (h *PatchGenerationEvent) emitCompletionEvents(
 
 	// Emit feature delivered
 	return h.eventsMan.Emit(ctx, string(events.FeatureDelivered), &events.FeatureDeliveredPayload{
+		ExecutionID:   execID,
 		BranchName:    request.FeatureBranchName,
 		RemoteRef:     fmt.Sprintf("refs/heads/%s", request.FeatureBranchName),
 		HeadCommitSHA: commitInfo.SHA,
@@ -580,10 +1171,39 @@ func (h *PatchGenerationEvent) emitCompletionEvents(
 				TotalDurationMS:   durationMS,
 				LLMTokensUsed:     resp.TokensUsed,
 			},
+			DiffStats:            stats.diffStats(),
+			AcceptanceAssessment: acceptance,
 		},
 	})
 }
 
+// noChangesIsNoOp reports whether an empty diff completes the execution rather than failing it.
+func (h *PatchGenerationEvent) noChangesIsNoOp() bool {
+	return events.NoChangesDecision(h.cfg.NoChangesDecision) != events.NoChangesDecisionFail
+}
+
+// emitNoOp ends an execution whose patches left the tree unchanged, without pushing the branch.
+func (h *PatchGenerationEvent) emitNoOp(
+	ctx context.Context,
+	execID events.ExecutionID,
+	request *events.RepositoryCheckoutCompletedPayload,
+	resp *GeneratePatchResponse,
+) error {
+	util.Log(ctx).Info("patch generation produced no changes, skipping push",
+		"execution_id", execID.String(),
+		"feature_branch", request.FeatureBranchName,
+		"patches", len(resp.Patches),
+	)
+
+	return h.eventsMan.Emit(ctx, string(events.FeatureNoOp), &events.FeatureNoOpPayload{
+		ExecutionID:      execID,
+		BranchName:       request.FeatureBranchName,
+		Reason:           "generated patches produced no changes; the feature may already exist",
+		PatchesGenerated: len(resp.Patches),
+		CompletedAt:      time.Now(),
+	})
+}
+
 // emitGenerationFailure emits a patch generation step failed event.
 func (h *PatchGenerationEvent) emitGenerationFailure(
 	ctx context.Context,
@@ -609,6 +1229,29 @@ func (h *PatchGenerationEvent) emitGenerationFailure(
 	return fmt.Errorf("%s failed: %w", phase, err)
 }
 
+// maxCompileFeedbackBytes caps how much compiler output is fed back to the LLM.
+const maxCompileFeedbackBytes = 8 * 1024
+
+// buildCompileFeedback formats a failed compile check as iteration feedback.
+func buildCompileFeedback(result *repository.CompileCheckResult) string {
+	var sb strings.Builder
+	sb.WriteString("The previous patches do not compile. Fix the following errors reported by `")
+	sb.WriteString(result.Command)
+	sb.WriteString("` without changing unrelated code:\n\n```\n")
+	sb.WriteString(truncateCompileOutput(result.Output))
+	sb.WriteString("\n```\n")
+	return sb.String()
+}
+
+// truncateCompileOutput trims compiler output to maxCompileFeedbackBytes.
+func truncateCompileOutput(output string) string {
+	output = strings.TrimSpace(output)
+	if len(output) <= maxCompileFeedbackBytes {
+		return output
+	}
+	return output[:maxCompileFeedbackBytes] + "\n... (output truncated)"
+}
+
 // countLines counts the number of lines in a string.
 func countLines(s string) int {
 	if s == "" {
@@ -624,14 +1267,15 @@ func countLines(s string) int {
 // FeatureCompletionEvent handles feature completion.
 type FeatureCompletionEvent struct {
 	cfg           *appconfig.WorkerConfig
-	executionRepo repository.ExecutionRepository
+	executionRepo executions.ExecutionRepository
 	queueMan      QueueManager
+	execContexts  *ExecutionContextStore
 }
 
 // NewFeatureCompletionEvent creates a new feature completion event handler.
 func NewFeatureCompletionEvent(
 	cfg *appconfig.WorkerConfig,
-	executionRepo repository.ExecutionRepository,
+	executionRepo executions.ExecutionRepository,
 	queueMan QueueManager,
 ) *FeatureCompletionEvent {
 	return &FeatureCompletionEvent{
@@ -641,6 +1285,13 @@ func NewFeatureCompletionEvent(
 	}
 }
 
+// SetExecutionContexts drops the context of executions once delivered after an
+// approving review, so a later kill switch does not stop an execution that
+// already completed.
+func (h *FeatureCompletionEvent) SetExecutionContexts(store *ExecutionContextStore) {
+	h.execContexts = store
+}
+
 // Name returns the event name.
 func (h *FeatureCompletionEvent) Name() string {
 	return string(events.FeatureDelivered)
@@ -662,13 +1313,78 @@ func (h *FeatureCompletionEvent) Execute(ctx context.Context, payload any) error
 	if !ok {
 		return errors.New("invalid payload type: expected *FeatureDeliveredPayload")
 	}
+	// Only a delivery the review approved ends the execution. Generated patches
+	// are delivered before they are tested and reviewed, and a partial delivery
+	// keeps iterating on the held files.
+	if execCtx, found := h.execContexts.Get(request.ExecutionID); found && !request.Partial &&
+		execCtx.Completion.ReviewApproved() {
+		h.execContexts.Delete(request.ExecutionID)
+	}
 
 	// Publish result to gateway
-	return h.queueMan.Publish(ctx, h.cfg.QueueFeatureResultName, map[string]interface{}{
+	result := map[string]interface{}{
 		"status":      "completed",
 		"branch_name": request.BranchName,
 		"commit_sha":  request.HeadCommitSHA,
 		"summary":     request.Summary,
+	}
+	if request.Partial {
+		result["status"] = "partially_delivered"
+		result["delivered_files"] = request.DeliveredFiles
+		result["held_files"] = request.HeldFiles
+	}
+
+	return h.queueMan.Publish(ctx, h.cfg.QueueFeatureResultName, result)
+}
+
+// =============================================================================
+// Feature No-Op Handler
+// =============================================================================
+
+// FeatureNoOpEvent reports executions that completed without changes.
+type FeatureNoOpEvent struct {
+	cfg          *appconfig.WorkerConfig
+	queueMan     QueueManager
+	execContexts *ExecutionContextStore
+}
+
+// NewFeatureNoOpEvent creates a new feature no-op event handler.
+func NewFeatureNoOpEvent(cfg *appconfig.WorkerConfig, queueMan QueueManager) *FeatureNoOpEvent {
+	return &FeatureNoOpEvent{cfg: cfg, queueMan: queueMan}
+}
+
+// SetExecutionContexts drops the context of executions that end without changes.
+func (h *FeatureNoOpEvent) SetExecutionContexts(store *ExecutionContextStore) {
+	h.execContexts = store
+}
+
+// Name returns the event name.
+func (h *FeatureNoOpEvent) Name() string {
+	return string(events.FeatureNoOp)
+}
+
+// PayloadType returns the expected payload type.
+func (h *FeatureNoOpEvent) PayloadType() any {
+	return &events.FeatureNoOpPayload{}
+}
+
+// Validate validates the payload.
+func (h *FeatureNoOpEvent) Validate(_ context.Context, _ any) error {
+	return nil
+}
+
+// Execute publishes the no-op result to the gateway.
+func (h *FeatureNoOpEvent) Execute(ctx context.Context, payload any) error {
+	request, ok := payload.(*events.FeatureNoOpPayload)
+	if !ok {
+		return errors.New("invalid payload type: expected *FeatureNoOpPayload")
+	}
+	h.execContexts.Delete(request.ExecutionID)
+
+	return h.queueMan.Publish(ctx, h.cfg.QueueFeatureResultName, map[string]interface{}{
+		"execution_id": request.ExecutionID.String(),
+		"status":       "no_changes",
+		"reason":       request.Reason,
 	})
 }
 
@@ -679,15 +1395,17 @@ func (h *FeatureCompletionEvent) Execute(ctx context.Context, payload any) error
 // FeatureFailureEvent handles feature execution failure.
 type FeatureFailureEvent struct {
 	cfg           *appconfig.WorkerConfig
-	executionRepo repository.ExecutionRepository
+	executionRepo executions.ExecutionRepository
 	queueMan      QueueManager
 	eventsMan     Emitter
+	retry         *ExecutionRetryPolicy
+	execContexts  *ExecutionContextStore
 }
 
 // NewFeatureFailureEvent creates a new feature failure event handler.
 func NewFeatureFailureEvent(
 	cfg *appconfig.WorkerConfig,
-	executionRepo repository.ExecutionRepository,
+	executionRepo executions.ExecutionRepository,
 	queueMan QueueManager,
 	eventsMan Emitter,
 ) *FeatureFailureEvent {
@@ -699,6 +1417,17 @@ func NewFeatureFailureEvent(
 	}
 }
 
+// SetExecutionRetryPolicy restarts executions that fail on infrastructure errors
+// instead of reporting the failure.
+func (h *FeatureFailureEvent) SetExecutionRetryPolicy(policy *ExecutionRetryPolicy) {
+	h.retry = policy
+}
+
+// SetExecutionContexts drops the context of executions that fail for good.
+func (h *FeatureFailureEvent) SetExecutionContexts(store *ExecutionContextStore) {
+	h.execContexts = store
+}
+
 // Name returns the event name.
 func (h *FeatureFailureEvent) Name() string {
 	return string(events.FeatureExecutionFailed)
@@ -721,11 +1450,44 @@ func (h *FeatureFailureEvent) Execute(ctx context.Context, payload any) error {
 		return errors.New("invalid payload type: expected *FeatureExecutionFailedPayload")
 	}
 
+	// Infrastructure outages start the execution over while attempts remain
+	restarted, err := h.retry.Restart(ctx, request)
+	if err != nil {
+		return err
+	}
+	if restarted {
+		return nil
+	}
+	h.execContexts.Delete(request.ExecutionID)
+	h.recordFailure(ctx, request)
+
 	// Publish failure result to gateway
 	return h.queueMan.Publish(ctx, h.cfg.QueueFeatureResultName, map[string]interface{}{
 		"status":        "failed",
+		"execution_id":  request.ExecutionID.String(),
 		"error_code":    request.ErrorCode,
 		"error_message": request.ErrorMessage,
 		"failed_phase":  request.FailedPhase,
 	})
 }
+
+// recordFailure fails the execution record of a retryable failure that was not
+// restarted. Progress tracking leaves such failures running for the retry policy.
+func (h *FeatureFailureEvent) recordFailure(ctx context.Context, request *events.FeatureExecutionFailedPayload) {
+	if h.executionRepo == nil || request.ExecutionID.IsZero() || !isInfrastructureFailure(request.Classification) {
+		return
+	}
+	now := time.Now()
+	_, err := executions.UpdateExecution(ctx, h.executionRepo, request.ExecutionID.String(), maxRetryUpdateAttempts,
+		func(e *executions.Execution) error {
+			e.Status = executions.ExecutionStatusFailed
+			e.ErrorMessage = request.ErrorMessage
+			e.CompletedAt = &now
+			return nil
+		})
+	if err != nil {
+		util.Log(ctx).WithError(err).Warn("failed to record execution failure",
+			"execution_id", request.ExecutionID.String(),
+		)
+	}
+}
@@ -0,0 +1,66 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/pitabwire/util"
+
+	"github.com/antinvestor/builder/internal/events"
+	"github.com/antinvestor/builder/internal/executions"
+)
+
+// iterationCounter numbers iterations from the count persisted on each execution
+// record, so the count survives redeliveries and restarts and the max-iteration
+// abort sees every cycle. Without a repository, or for an execution with no
+// record, iteration numbers come from the requesting payload.
+type iterationCounter struct {
+	repo executions.ExecutionRepository
+}
+
+// next returns the number of the iteration that would be requested now.
+func (c iterationCounter) next(ctx context.Context, executionID events.ExecutionID) int {
+	if c.repo == nil {
+		return 1
+	}
+	count, err := c.repo.GetIterationCount(ctx, executionID.String())
+	if err != nil {
+		if !errors.Is(err, executions.ErrExecutionNotFound) {
+			util.Log(ctx).WithError(err).Warn("failed to read iteration count",
+				"execution_id", executionID.String(),
+			)
+		}
+		return 1
+	}
+	return count + 1
+}
+
+// start records that an iteration is starting, returning its number. The count
+// is raised to the requested number, so a redelivered request for an iteration
+// already started does not count it twice; a request without a number starts
+// the next iteration.
+func (c iterationCounter) start(ctx context.Context, executionID events.ExecutionID, requested int) (int, error) {
+	if c.repo == nil {
+		return requested, nil
+	}
+	var count int
+	var err error
+	if requested > 0 {
+		count, err = c.repo.AdvanceIteration(ctx, executionID.String(), requested)
+	} else {
+		count, err = c.repo.IncrementIteration(ctx, executionID.String())
+	}
+	if errors.Is(err, executions.ErrExecutionNotFound) {
+		return requested, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("increment iteration count: %w", err)
+	}
+	return count, nil
+}
+
+// iterationsRemaining returns how many iterations are left after iterationNumber.
+func iterationsRemaining(maxIterations, iterationNumber int) int {
+	return max(0, maxIterations-iterationNumber)
+}
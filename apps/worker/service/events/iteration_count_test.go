@@ -0,0 +1,153 @@
+//nolint:testpackage // white-box testing requires internal package access
+package events
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	appconfig "github.com/antinvestor/builder/apps/worker/config"
+	"github.com/antinvestor/builder/internal/events"
+	"github.com/antinvestor/builder/internal/executions"
+)
+
+func newIteratingExecution(t *testing.T) (executions.ExecutionRepository, events.ExecutionID) {
+	t.Helper()
+	repo := executions.NewMemoryExecutionRepository()
+	executionID := events.NewExecutionID()
+	require.NoError(t, repo.Create(context.Background(), &executions.Execution{
+		ID:     executionID.String(),
+		Status: executions.ExecutionStatusRunning,
+	}))
+	return repo, executionID
+}
+
+func TestIterationCount_RepeatedRejectionsExceedMaxIterations(t *testing.T) {
+	ctx := context.Background()
+	repo, executionID := newIteratingExecution(t)
+	cfg := &appconfig.WorkerConfig{ReviewThresholds: events.ReviewThresholds{MaxIterations: 3}}
+
+	reviewEmitter := &mockEmitter{}
+	review := NewReviewResultEvent(cfg, nil, nil, nil, reviewEmitter)
+	review.SetExecutionRepository(repo)
+
+	iterationEmitter := &mockEmitter{}
+	iteration := NewIterationEvent(cfg, &mockBAMLClient{generatePatchResponse: &GeneratePatchResponse{}}, nil,
+		iterationEmitter)
+	iteration.SetExecutionRepository(repo)
+
+	rejection := &events.ComprehensiveReviewCompletedPayload{
+		ExecutionID: executionID,
+		Decision:    events.ControlDecisionIterate,
+		BlockingIssues: []events.ReviewIssue{
+			{ID: "issue-1", Title: "Missing validation", Severity: events.ReviewIssueSeverityHigh},
+		},
+	}
+
+	var requested []int
+	for range 3 {
+		require.NoError(t, review.Execute(ctx, rejection))
+		request, ok := lastEmitted(reviewEmitter).payload.(*events.FeatureIterationRequestedPayload)
+		require.True(t, ok)
+		requested = append(requested, request.IterationNumber)
+
+		require.NoError(t, iteration.Execute(ctx, request))
+	}
+
+	// Each rejection asks for the next iteration; the third reaches the limit
+	assert.Equal(t, []int{1, 2, 3}, requested)
+	assert.Equal(t, []int{1, 2}, startedIterations(iterationEmitter))
+
+	last := lastEmitted(iterationEmitter)
+	require.Equal(t, string(events.FeatureExecutionFailed), last.name)
+	failure, ok := last.payload.(*events.FeatureExecutionFailedPayload)
+	require.True(t, ok)
+	assert.Equal(t, "max_iterations_exceeded", failure.ErrorCode)
+
+	count, err := repo.GetIterationCount(ctx, executionID.String())
+	require.NoError(t, err)
+	assert.Equal(t, 3, count)
+}
+
+func TestIterationCount_TestFailuresNumberFromExecutionRecord(t *testing.T) {
+	ctx := context.Background()
+	repo, executionID := newIteratingExecution(t)
+	cfg := &appconfig.WorkerConfig{ReviewThresholds: events.ReviewThresholds{MaxIterations: 3}}
+
+	// A review rejection already started the first iteration
+	_, err := repo.IncrementIteration(ctx, executionID.String())
+	require.NoError(t, err)
+
+	emitter := &mockEmitter{}
+	reviewRequest := NewReviewRequestEvent(cfg, &mockQueueManager{}, nil, emitter)
+	reviewRequest.SetExecutionRepository(repo)
+
+	require.NoError(t, reviewRequest.Execute(ctx, &events.TestExecutionCompletedPayload{
+		ExecutionID: executionID,
+		Success:     false,
+	}))
+
+	request, ok := lastEmitted(emitter).payload.(*events.IterationRequiredPayload)
+	require.True(t, ok)
+	assert.Equal(t, executionID, request.ExecutionID)
+	assert.Equal(t, 2, request.IterationNumber)
+	assert.Equal(t, 1, request.MaxIterationsRemaining)
+
+	// The iteration handler counts it against the same execution
+	iterationEmitter := &mockEmitter{}
+	iteration := NewIterationEvent(cfg, &mockBAMLClient{generatePatchResponse: &GeneratePatchResponse{}}, nil,
+		iterationEmitter)
+	iteration.SetExecutionRepository(repo)
+	require.NoError(t, iteration.Execute(ctx, request))
+
+	assert.Equal(t, []int{2}, startedIterations(iterationEmitter))
+	count, err := repo.GetIterationCount(ctx, executionID.String())
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+}
+
+func TestIterationCount_RedeliveredIterationIsCountedOnce(t *testing.T) {
+	ctx := context.Background()
+	repo, executionID := newIteratingExecution(t)
+	cfg := &appconfig.WorkerConfig{ReviewThresholds: events.ReviewThresholds{MaxIterations: 3}}
+
+	emitter := &mockEmitter{}
+	iteration := NewIterationEvent(cfg, &mockBAMLClient{generatePatchResponse: &GeneratePatchResponse{}}, nil, emitter)
+	iteration.SetExecutionRepository(repo)
+
+	request := &events.FeatureIterationRequestedPayload{ExecutionID: executionID, IterationNumber: 1}
+	require.NoError(t, iteration.Execute(ctx, request))
+	require.NoError(t, iteration.Execute(ctx, request))
+
+	assert.Equal(t, []int{1, 1}, startedIterations(emitter))
+	count, err := repo.GetIterationCount(ctx, executionID.String())
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+}
+
+func TestIterationCount_WithoutRecordUsesRequestedNumber(t *testing.T) {
+	counter := iterationCounter{repo: executions.NewMemoryExecutionRepository()}
+	executionID := events.NewExecutionID()
+
+	assert.Equal(t, 1, counter.next(context.Background(), executionID))
+	number, err := counter.start(context.Background(), executionID, 2)
+	require.NoError(t, err)
+	assert.Equal(t, 2, number)
+}
+
+func lastEmitted(emitter *mockEmitter) emittedEvent {
+	return emitter.emittedEvents[len(emitter.emittedEvents)-1]
+}
+
+// startedIterations returns the numbers of the iterations an emitter started.
+func startedIterations(emitter *mockEmitter) []int {
+	var started []int
+	for _, event := range emitter.emittedEvents {
+		if payload, ok := event.payload.(*events.IterationStartedPayload); ok {
+			started = append(started, payload.IterationNumber)
+		}
+	}
+	return started
+}
@@ -0,0 +1,143 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/pitabwire/util"
+
+	"github.com/antinvestor/builder/internal/events"
+)
+
+// KillSwitchControlHandler consumes the reviewer's kill switch control events and
+// stops the executions this worker is running that an activation covers: their
+// bound contexts are canceled and each is failed with the kill switch abort reason.
+// Messages are told apart by their HeaderEventType header; deactivations need no
+// action, as stopped executions are not resumed.
+type KillSwitchControlHandler struct {
+	execContexts *ExecutionContextStore
+	eventsMan    Emitter
+}
+
+// NewKillSwitchControlHandler creates a handler stopping the executions in
+// execContexts.
+func NewKillSwitchControlHandler(execContexts *ExecutionContextStore, eventsMan Emitter) *KillSwitchControlHandler {
+	return &KillSwitchControlHandler{
+		execContexts: execContexts,
+		eventsMan:    eventsMan,
+	}
+}
+
+// Handle processes a control event from the control events queue.
+func (h *KillSwitchControlHandler) Handle(ctx context.Context, headers map[string]string, message []byte) error {
+	log := util.Log(ctx)
+
+	switch eventType := events.EventType(headers[events.HeaderEventType]); eventType {
+	case events.KillSwitchActivated:
+	case events.KillSwitchDeactivated:
+		// Stopped executions are not resumed
+		return nil
+	default:
+		log.Debug("ignoring control event", "event_type", eventType)
+		return nil
+	}
+
+	var activation events.KillSwitchActivatedPayload
+	if err := json.Unmarshal(message, &activation); err != nil {
+		// A malformed control event cannot become valid on redelivery
+		log.WithError(err).Error("discarding malformed control event")
+		return nil
+	}
+
+	targets := h.execContexts.Find(func(execCtx *ExecutionContext) bool {
+		return killSwitchCovers(&activation, execCtx)
+	})
+	for _, executionID := range targets {
+		execCtx, ok := h.execContexts.Get(executionID)
+		if !ok || !h.execContexts.Kill(executionID) {
+			continue
+		}
+		log.Warn("kill switch activated, stopping execution",
+			"execution_id", executionID.String(),
+			"scope", activation.Scope,
+			"reason", activation.Reason,
+			"activated_by", activation.ActivatedBy,
+		)
+		if err := h.emitKilled(ctx, execCtx, &activation); err != nil {
+			return fmt.Errorf("fail killed execution %s: %w", executionID.String(), err)
+		}
+	}
+	return nil
+}
+
+// emitKilled fails an execution stopped by a kill switch.
+func (h *KillSwitchControlHandler) emitKilled(
+	ctx context.Context,
+	execCtx *ExecutionContext,
+	activation *events.KillSwitchActivatedPayload,
+) error {
+	classification := events.FailureClassification{
+		Type:           events.FailureTypeDeterministic,
+		Severity:       events.FailureSeverityError,
+		Retryable:      false,
+		UserActionable: true,
+	}
+	phase := runningPhase(execCtx)
+	return h.eventsMan.Emit(ctx, string(events.FeatureExecutionFailed), &events.FeatureExecutionFailedPayload{
+		ExecutionID:    execCtx.ExecutionID,
+		Classification: classification,
+		FailedPhase:    phase,
+		ErrorCode:      string(events.AbortReasonKillSwitch),
+		ErrorMessage:   fmt.Sprintf("Kill switch activated (%s scope): %s", activation.Scope, activation.Reason),
+		ErrorContext: map[string]string{
+			"scope":        string(activation.Scope),
+			"reason":       string(activation.Reason),
+			"activated_by": activation.ActivatedBy,
+			"details":      activation.Details,
+		},
+		Recovery: failureRecovery(classification, phase, false),
+	})
+}
+
+// killSwitchCovers reports whether an activation covers an execution: a global
+// one covers all, a repository one the repository's executions and those it
+// lists as affected, and a feature one only its own execution.
+func killSwitchCovers(activation *events.KillSwitchActivatedPayload, execCtx *ExecutionContext) bool {
+	switch activation.Scope {
+	case events.KillSwitchScopeGlobal:
+		return true
+	case events.KillSwitchScopeRepository:
+		repositoryID := repositoryKey(activation.RepositoryID)
+		if repositoryID != "" && (repositoryKey(execCtx.Repository.RepositoryID) == repositoryID ||
+			repositoryKey(execCtx.Repository.RemoteURL) == repositoryID) {
+			return true
+		}
+		return slices.Contains(activation.AffectedExecutions, execCtx.ExecutionID)
+	case events.KillSwitchScopeFeature:
+		return activation.ExecutionID == execCtx.ExecutionID
+	default:
+		return false
+	}
+}
+
+// repositoryKey normalizes a repository identifier or remote URL the way the
+// reviewer keys repository kill switches.
+func repositoryKey(repositoryID string) string {
+	key := strings.TrimSuffix(strings.TrimSpace(repositoryID), "/")
+	return strings.TrimSuffix(key, ".git")
+}
+
+// runningPhase returns the phase an execution is in, judged by its context.
+func runningPhase(execCtx *ExecutionContext) events.ExecutionPhase {
+	switch {
+	case execCtx.WorkspacePath == "":
+		return events.ExecutionPhaseCheckout
+	case len(execCtx.Patches) == 0:
+		return events.ExecutionPhaseGeneration
+	default:
+		return events.ExecutionPhaseVerification
+	}
+}
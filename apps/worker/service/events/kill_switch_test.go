@@ -0,0 +1,208 @@
+//nolint:testpackage // white-box testing requires internal package access
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	appconfig "github.com/antinvestor/builder/apps/worker/config"
+	"github.com/antinvestor/builder/internal/events"
+)
+
+// startRunning starts an execution of remoteURL and binds a context to it, as a
+// handler running it would.
+func startRunning(t *testing.T, store *ExecutionContextStore, remoteURL string) (events.ExecutionID, context.Context) {
+	t.Helper()
+	execID := events.NewExecutionID()
+	store.Start(nil, &events.FeatureExecutionInitializedPayload{
+		ExecutionID: execID,
+		Repository:  events.RepositoryContext{RemoteURL: remoteURL},
+	})
+	ctx, release := store.Bind(context.Background(), execID)
+	t.Cleanup(release)
+	return execID, ctx
+}
+
+// requireKilled waits for a bound context to be canceled by a kill switch, which
+// happens asynchronously.
+func requireKilled(t *testing.T, ctx context.Context) {
+	t.Helper()
+	select {
+	case <-ctx.Done():
+		assert.True(t, killedBySwitch(ctx))
+	case <-time.After(time.Second):
+		t.Fatal("bound context was not canceled")
+	}
+}
+
+func handleControlEvent(t *testing.T, handler *KillSwitchControlHandler, eventType events.EventType, payload any) {
+	t.Helper()
+	message, err := json.Marshal(payload)
+	require.NoError(t, err)
+	headers := map[string]string{events.HeaderEventType: string(eventType)}
+	require.NoError(t, handler.Handle(context.Background(), headers, message))
+}
+
+func TestKillSwitchControlHandler_FeatureActivationStopsOnlyItsExecution(t *testing.T) {
+	store := NewExecutionContextStore()
+	emitter := &mockEmitter{}
+	handler := NewKillSwitchControlHandler(store, emitter)
+	killed, killedCtx := startRunning(t, store, "https://github.com/acme/api")
+	other, otherCtx := startRunning(t, store, "https://github.com/acme/api")
+
+	handleControlEvent(t, handler, events.KillSwitchActivated, &events.KillSwitchActivatedPayload{
+		Scope:       events.KillSwitchScopeFeature,
+		ExecutionID: killed,
+		Reason:      events.KillSwitchReasonManual,
+		ActivatedBy: "oncall",
+		ActivatedAt: time.Now(),
+	})
+
+	requireKilled(t, killedCtx)
+	require.NoError(t, otherCtx.Err())
+
+	require.Len(t, emitter.emittedEvents, 1)
+	assert.Equal(t, string(events.FeatureExecutionFailed), emitter.emittedEvents[0].name)
+	failed, ok := emitter.emittedEvents[0].payload.(*events.FeatureExecutionFailedPayload)
+	require.True(t, ok)
+	assert.Equal(t, killed, failed.ExecutionID)
+	assert.Equal(t, string(events.AbortReasonKillSwitch), failed.ErrorCode)
+	assert.Equal(t, events.ExecutionPhaseCheckout, failed.FailedPhase)
+	assert.False(t, failed.Classification.Retryable)
+	assert.NotEqual(t, killed, other)
+}
+
+func TestKillSwitchControlHandler_UnrelatedActivationIsIgnored(t *testing.T) {
+	store := NewExecutionContextStore()
+	emitter := &mockEmitter{}
+	handler := NewKillSwitchControlHandler(store, emitter)
+	_, ctx := startRunning(t, store, "https://github.com/acme/api")
+
+	handleControlEvent(t, handler, events.KillSwitchActivated, &events.KillSwitchActivatedPayload{
+		Scope:       events.KillSwitchScopeFeature,
+		ExecutionID: events.NewExecutionID(),
+		ActivatedBy: "oncall",
+		ActivatedAt: time.Now(),
+	})
+	handleControlEvent(t, handler, events.KillSwitchActivated, &events.KillSwitchActivatedPayload{
+		Scope:        events.KillSwitchScopeRepository,
+		RepositoryID: "https://github.com/acme/web",
+		ActivatedBy:  "oncall",
+		ActivatedAt:  time.Now(),
+	})
+	// A deactivation stops nothing
+	handleControlEvent(t, handler, events.KillSwitchDeactivated, &events.KillSwitchDeactivatedPayload{
+		Scope:         events.KillSwitchScopeGlobal,
+		DeactivatedBy: "oncall",
+		DeactivatedAt: time.Now(),
+	})
+
+	require.NoError(t, ctx.Err())
+	assert.Empty(t, emitter.emittedEvents)
+}
+
+func TestKillSwitchControlHandler_RepositoryAndGlobalScopes(t *testing.T) {
+	store := NewExecutionContextStore()
+	emitter := &mockEmitter{}
+	handler := NewKillSwitchControlHandler(store, emitter)
+	_, noisyCtx := startRunning(t, store, "https://github.com/acme/noisy.git")
+	_, quietCtx := startRunning(t, store, "https://github.com/acme/quiet")
+
+	handleControlEvent(t, handler, events.KillSwitchActivated, &events.KillSwitchActivatedPayload{
+		Scope:        events.KillSwitchScopeRepository,
+		RepositoryID: "https://github.com/acme/noisy",
+		ActivatedBy:  "oncall",
+		ActivatedAt:  time.Now(),
+	})
+	requireKilled(t, noisyCtx)
+	require.NoError(t, quietCtx.Err())
+	require.Len(t, emitter.emittedEvents, 1)
+
+	// The global switch stops the rest, and a redelivery fails nothing twice
+	global := &events.KillSwitchActivatedPayload{
+		Scope:       events.KillSwitchScopeGlobal,
+		ActivatedBy: "automatic",
+		ActivatedAt: time.Now(),
+	}
+	handleControlEvent(t, handler, events.KillSwitchActivated, global)
+	handleControlEvent(t, handler, events.KillSwitchActivated, global)
+	requireKilled(t, quietCtx)
+	assert.Len(t, emitter.emittedEvents, 2)
+}
+
+func TestKillSwitchControlHandler_MessageWithoutEventTypeIsIgnored(t *testing.T) {
+	store := NewExecutionContextStore()
+	emitter := &mockEmitter{}
+	handler := NewKillSwitchControlHandler(store, emitter)
+	_, ctx := startRunning(t, store, "https://github.com/acme/api")
+
+	// An activation is only acted on when its header says so
+	message, err := json.Marshal(&events.KillSwitchActivatedPayload{
+		Scope:       events.KillSwitchScopeGlobal,
+		ActivatedBy: "oncall",
+		ActivatedAt: time.Now(),
+	})
+	require.NoError(t, err)
+	require.NoError(t, handler.Handle(context.Background(), nil, message))
+
+	require.NoError(t, ctx.Err())
+	assert.Empty(t, emitter.emittedEvents)
+}
+
+func TestKillSwitchControlHandler_DeliveredExecutionIsNotFailed(t *testing.T) {
+	store := NewExecutionContextStore()
+	emitter := &mockEmitter{}
+	handler := NewKillSwitchControlHandler(store, emitter)
+	delivered, _ := startRunning(t, store, "https://github.com/acme/api")
+	generated, generatedCtx := startRunning(t, store, "https://github.com/acme/api")
+	partial, partialCtx := startRunning(t, store, "https://github.com/acme/api")
+	for _, execID := range []events.ExecutionID{delivered, partial} {
+		store.Update(execID, func(stored *ExecutionContext) {
+			stored.Completion.ReviewDecision = events.ControlDecisionApprove
+		})
+	}
+
+	completion := NewFeatureCompletionEvent(&appconfig.WorkerConfig{}, nil, &mockQueueManager{})
+	completion.SetExecutionContexts(store)
+	for _, delivery := range []*events.FeatureDeliveredPayload{
+		{ExecutionID: delivered},
+		{ExecutionID: generated},
+		{ExecutionID: partial, Partial: true},
+	} {
+		require.NoError(t, completion.Execute(context.Background(), delivery))
+	}
+
+	handleControlEvent(t, handler, events.KillSwitchActivated, &events.KillSwitchActivatedPayload{
+		Scope:       events.KillSwitchScopeGlobal,
+		ActivatedBy: "oncall",
+		ActivatedAt: time.Now(),
+	})
+
+	// Executions whose delivery was not yet reviewed, or was partial, are stopped
+	requireKilled(t, generatedCtx)
+	requireKilled(t, partialCtx)
+	require.Len(t, emitter.emittedEvents, 2)
+	stopped := make([]events.ExecutionID, 0, len(emitter.emittedEvents))
+	for _, emitted := range emitter.emittedEvents {
+		failed, ok := emitted.payload.(*events.FeatureExecutionFailedPayload)
+		require.True(t, ok)
+		stopped = append(stopped, failed.ExecutionID)
+	}
+	assert.ElementsMatch(t, []events.ExecutionID{generated, partial}, stopped)
+}
+
+func TestExecutionContextStore_BindWithoutContext(t *testing.T) {
+	store := NewExecutionContextStore()
+	parent := context.Background()
+
+	ctx, release := store.Bind(parent, events.NewExecutionID())
+	defer release()
+
+	assert.Equal(t, parent, ctx)
+	assert.False(t, store.Kill(events.NewExecutionID()))
+}
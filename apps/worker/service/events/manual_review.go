@@ -0,0 +1,94 @@
+package events
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/pitabwire/util"
+
+	appconfig "github.com/antinvestor/builder/apps/worker/config"
+	"github.com/antinvestor/builder/internal/events"
+)
+
+// handleManualReview pauses the execution and requests review from the code owners
+// of the changed files, falling back to the configured reviewers.
+func (h *ReviewResultEvent) handleManualReview(
+	ctx context.Context,
+	request *events.ComprehensiveReviewCompletedPayload,
+) error {
+	return h.requestManualReview(ctx, &events.ManualReviewRequestedPayload{
+		ExecutionID:  request.ExecutionID,
+		Reason:       request.DecisionRationale,
+		ChangedFiles: reviewedFiles(request),
+		RequestedAt:  time.Now(),
+	})
+}
+
+// requestManualReview requests review through the handler's code owners and
+// configured reviewers.
+func (h *ReviewResultEvent) requestManualReview(
+	ctx context.Context,
+	payload *events.ManualReviewRequestedPayload,
+) error {
+	return requestManualReview(ctx, h.cfg, h.codeOwners, h.eventsMan, payload)
+}
+
+// requestManualReview routes a manual review request to the code owners of its
+// changed files, or the configured reviewers, and emits it.
+func requestManualReview(
+	ctx context.Context,
+	cfg *appconfig.WorkerConfig,
+	codeOwners CodeOwnersLoader,
+	eventsMan Emitter,
+	payload *events.ManualReviewRequestedPayload,
+) error {
+	log := util.Log(ctx)
+
+	if cfg != nil && cfg.CodeOwnersRoutingEnabled && codeOwners != nil {
+		owners, err := codeOwners.LoadCodeOwners(ctx, payload.ExecutionID, splitList(cfg.CodeOwnersPaths))
+		if err != nil {
+			// Routing is best effort; the review is still requested from the fallback reviewers
+			log.WithError(err).Warn("could not load code owners",
+				"execution_id", payload.ExecutionID.String(),
+			)
+		} else {
+			routing := owners.Route(payload.ChangedFiles)
+			payload.RequiredReviewers = routing.Reviewers
+			payload.FileOwners = routing.FileOwners
+			payload.UnownedFiles = routing.Unowned
+			payload.CodeOwnersSource = owners.Source
+		}
+	}
+	if len(payload.RequiredReviewers) == 0 && cfg != nil {
+		payload.RequiredReviewers = splitList(cfg.ManualReviewFallbackReviewers)
+	}
+
+	log.Info("manual review required, pausing execution",
+		"execution_id", payload.ExecutionID.String(),
+		"required_reviewers", payload.RequiredReviewers,
+	)
+	return eventsMan.Emit(ctx, string(events.ReviewManualRequested), payload)
+}
+
+// reviewedFiles returns the files of a review result, sorted.
+func reviewedFiles(request *events.ComprehensiveReviewCompletedPayload) []string {
+	files := make([]string, 0, len(request.FileStatuses))
+	for file := range request.FileStatuses {
+		files = append(files, file)
+	}
+	slices.Sort(files)
+	return files
+}
+
+// splitList splits a comma-separated configuration value, dropping empty entries.
+func splitList(value string) []string {
+	var items []string
+	for item := range strings.SplitSeq(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
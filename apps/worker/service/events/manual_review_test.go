@@ -0,0 +1,103 @@
+//nolint:testpackage // white-box testing requires internal package access
+package events
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	appconfig "github.com/antinvestor/builder/apps/worker/config"
+	"github.com/antinvestor/builder/internal/events"
+)
+
+func manualReviewPayload(execID events.ExecutionID, files ...string) *events.ComprehensiveReviewCompletedPayload {
+	statuses := make(map[string]events.FileReviewStatus, len(files))
+	for _, file := range files {
+		statuses[file] = events.FileReviewStatusClean
+	}
+	return &events.ComprehensiveReviewCompletedPayload{
+		ExecutionID:       execID,
+		Decision:          events.ControlDecisionManualReview,
+		DecisionRationale: "Manual review required: high-impact config files changed",
+		FileStatuses:      statuses,
+	}
+}
+
+func emittedManualReview(t *testing.T, emitter *mockEmitter) *events.ManualReviewRequestedPayload {
+	t.Helper()
+	requested := findEmitted(emitter, events.ReviewManualRequested)
+	require.Len(t, requested, 1)
+	payload, ok := requested[0].(*events.ManualReviewRequestedPayload)
+	require.True(t, ok)
+	return payload
+}
+
+func TestReviewResultEvent_ManualReviewRoutedToCodeOwners(t *testing.T) {
+	cfg := &appconfig.WorkerConfig{
+		CodeOwnersRoutingEnabled:      true,
+		CodeOwnersPaths:               ".github/CODEOWNERS,CODEOWNERS",
+		ManualReviewFallbackReviewers: "@example/maintainers",
+	}
+	svc, request := checkoutGoModule(t, cfg)
+	codeOwners := "/.github/ @example/platform\n/api/ @example/api alice@example.com\n"
+	require.NoError(t, os.MkdirAll(filepath.Join(request.WorkspacePath, ".github"), 0o750))
+	require.NoError(t, os.WriteFile(
+		filepath.Join(request.WorkspacePath, ".github", "CODEOWNERS"), []byte(codeOwners), 0o600))
+
+	emitter := &mockEmitter{}
+	handler := NewReviewResultEvent(cfg, svc, nil, nil, emitter)
+
+	payload := manualReviewPayload(request.ExecutionID, ".github/workflows/ci.yml", "api/handler.go", "README.md")
+	require.NoError(t, handler.Execute(context.Background(), payload))
+
+	manual := emittedManualReview(t, emitter)
+	assert.Equal(t, request.ExecutionID, manual.ExecutionID)
+	assert.Equal(t, payload.DecisionRationale, manual.Reason)
+	assert.Equal(t, ".github/CODEOWNERS", manual.CodeOwnersSource)
+	assert.Equal(t, []string{".github/workflows/ci.yml", "README.md", "api/handler.go"}, manual.ChangedFiles)
+	assert.Equal(t, []string{"@example/api", "@example/platform", "alice@example.com"}, manual.RequiredReviewers)
+	assert.Equal(t, map[string][]string{
+		".github/workflows/ci.yml": {"@example/platform"},
+		"api/handler.go":           {"@example/api", "alice@example.com"},
+	}, manual.FileOwners)
+	assert.Equal(t, []string{"README.md"}, manual.UnownedFiles)
+}
+
+func TestReviewResultEvent_ManualReviewFallsBackWithoutCodeOwners(t *testing.T) {
+	cfg := &appconfig.WorkerConfig{
+		CodeOwnersRoutingEnabled:      true,
+		CodeOwnersPaths:               ".github/CODEOWNERS,CODEOWNERS",
+		ManualReviewFallbackReviewers: "@example/maintainers, bob@example.com",
+	}
+	svc, request := checkoutGoModule(t, cfg)
+
+	emitter := &mockEmitter{}
+	handler := NewReviewResultEvent(cfg, svc, nil, nil, emitter)
+	require.NoError(t, handler.Execute(context.Background(), manualReviewPayload(request.ExecutionID, "main.go")))
+
+	manual := emittedManualReview(t, emitter)
+	assert.Empty(t, manual.CodeOwnersSource)
+	assert.Equal(t, []string{"@example/maintainers", "bob@example.com"}, manual.RequiredReviewers)
+	assert.Equal(t, []string{"main.go"}, manual.UnownedFiles)
+}
+
+func TestReviewResultEvent_ManualReviewRoutingDisabled(t *testing.T) {
+	cfg := &appconfig.WorkerConfig{
+		CodeOwnersPaths:               "CODEOWNERS",
+		ManualReviewFallbackReviewers: "@example/maintainers",
+	}
+	svc, request := checkoutGoModule(t, cfg)
+	require.NoError(t, os.WriteFile(filepath.Join(request.WorkspacePath, "CODEOWNERS"), []byte("* @example/all\n"), 0o600))
+
+	emitter := &mockEmitter{}
+	handler := NewReviewResultEvent(cfg, svc, nil, nil, emitter)
+	require.NoError(t, handler.Execute(context.Background(), manualReviewPayload(request.ExecutionID, "main.go")))
+
+	manual := emittedManualReview(t, emitter)
+	assert.Equal(t, []string{"@example/maintainers"}, manual.RequiredReviewers)
+	assert.Empty(t, manual.FileOwners)
+}
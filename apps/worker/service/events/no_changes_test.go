@@ -0,0 +1,109 @@
+//nolint:testpackage // white-box testing requires internal package access
+package events
+
+import (
+	"context"
+	"os/exec"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	appconfig "github.com/antinvestor/builder/apps/worker/config"
+	"github.com/antinvestor/builder/apps/worker/service/repository"
+	"github.com/antinvestor/builder/internal/events"
+)
+
+// unchangedGoModPatch rewrites go.mod with the content it already has.
+func unchangedGoModPatch() *GeneratePatchResponse {
+	return &GeneratePatchResponse{
+		Patches: []Patch{{
+			FilePath:   "go.mod",
+			OldContent: "module example.com/calc\n\ngo 1.21\n",
+			NewContent: "module example.com/calc\n\ngo 1.21\n",
+			Action:     events.FileActionModify,
+		}},
+		TokensUsed: 50,
+	}
+}
+
+func remoteHasBranch(t *testing.T, origin, branch string) bool {
+	t.Helper()
+	output, err := exec.Command("git", "-C", origin, "branch", "--list", branch).Output()
+	require.NoError(t, err)
+	return len(output) > 0
+}
+
+func TestPatchGenerationEvent_EmptyDiffIsNoOp(t *testing.T) {
+	cfg := &appconfig.WorkerConfig{NoChangesDecision: string(events.NoChangesDecisionNoOp)}
+	svc, request := checkoutGoModule(t, cfg)
+	client := &scriptedBAMLClient{responses: []*GeneratePatchResponse{unchangedGoModPatch()}}
+	emitter := &mockEmitter{}
+
+	handler := NewPatchGenerationEvent(cfg, client, svc, nil, emitter)
+
+	require.NoError(t, handler.Execute(context.Background(), request))
+
+	noOps := findEmitted(emitter, events.FeatureNoOp)
+	require.Len(t, noOps, 1)
+	noOp, ok := noOps[0].(*events.FeatureNoOpPayload)
+	require.True(t, ok)
+	assert.Equal(t, request.ExecutionID, noOp.ExecutionID)
+	assert.Equal(t, "feature/calc", noOp.BranchName)
+	assert.Equal(t, 1, noOp.PatchesGenerated)
+
+	assert.Empty(t, findEmitted(emitter, events.GitCommitCreated))
+	assert.Empty(t, findEmitted(emitter, events.GitPushCompleted))
+	assert.Empty(t, findEmitted(emitter, events.FeatureDelivered))
+	assert.False(t, remoteHasBranch(t, request.RepositoryURL, "feature/calc"))
+}
+
+func TestPatchGenerationEvent_EmptyDiffFailsWhenConfigured(t *testing.T) {
+	cfg := &appconfig.WorkerConfig{NoChangesDecision: string(events.NoChangesDecisionFail)}
+	svc, request := checkoutGoModule(t, cfg)
+	client := &scriptedBAMLClient{responses: []*GeneratePatchResponse{unchangedGoModPatch()}}
+	emitter := &mockEmitter{}
+
+	handler := NewPatchGenerationEvent(cfg, client, svc, nil, emitter)
+
+	err := handler.Execute(context.Background(), request)
+	require.ErrorIs(t, err, repository.ErrNoChanges)
+
+	assert.Len(t, findEmitted(emitter, events.PatchGenerationStepFailed), 1)
+	assert.Empty(t, findEmitted(emitter, events.FeatureNoOp))
+	assert.False(t, remoteHasBranch(t, request.RepositoryURL, "feature/calc"))
+}
+
+func TestPatchGenerationEvent_ChangedTreeIsPushed(t *testing.T) {
+	cfg := &appconfig.WorkerConfig{NoChangesDecision: string(events.NoChangesDecisionNoOp)}
+	svc, request := checkoutGoModule(t, cfg)
+	client := &scriptedBAMLClient{responses: []*GeneratePatchResponse{calcPatch(fixedCalc)}}
+	emitter := &mockEmitter{}
+
+	handler := NewPatchGenerationEvent(cfg, client, svc, nil, emitter)
+
+	require.NoError(t, handler.Execute(context.Background(), request))
+
+	assert.Empty(t, findEmitted(emitter, events.FeatureNoOp))
+	assert.Len(t, findEmitted(emitter, events.FeatureDelivered), 1)
+	assert.True(t, remoteHasBranch(t, request.RepositoryURL, "feature/calc"))
+}
+
+func TestFeatureNoOpEvent_PublishesNoChangesResult(t *testing.T) {
+	cfg := &appconfig.WorkerConfig{QueueFeatureResultName: "feature.results"}
+	qMan := &mockQueueManager{}
+	handler := NewFeatureNoOpEvent(cfg, qMan)
+
+	execID := events.NewExecutionID()
+	require.NoError(t, handler.Execute(context.Background(), &events.FeatureNoOpPayload{
+		ExecutionID: execID,
+		Reason:      "nothing to do",
+	}))
+
+	require.Len(t, qMan.publishedMessages, 1)
+	assert.Equal(t, "feature.results", qMan.publishedMessages[0].queueName)
+	result, ok := qMan.publishedMessages[0].payload.(map[string]interface{})
+	require.True(t, ok)
+	assert.Equal(t, "no_changes", result["status"])
+	assert.Equal(t, execID.String(), result["execution_id"])
+}
@@ -0,0 +1,134 @@
+//nolint:testpackage // white-box testing requires internal package access
+package events
+
+import (
+	"context"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	appconfig "github.com/antinvestor/builder/apps/worker/config"
+	"github.com/antinvestor/builder/internal/events"
+)
+
+// mixedPatches returns two patches that apply and one that does not, as its
+// content is not valid UTF-8.
+func mixedPatches() *GeneratePatchResponse {
+	return &GeneratePatchResponse{
+		Patches: []Patch{
+			{FilePath: "calc.go", NewContent: fixedCalc, Action: events.FileActionCreate},
+			{FilePath: "README.md", NewContent: "docs \xff\xfe broken\n", Action: events.FileActionCreate},
+			{FilePath: "doc.go", NewContent: "// Package calc adds numbers.\npackage calc\n", Action: events.FileActionCreate},
+		},
+		TokensUsed: 100,
+	}
+}
+
+// pushedFiles lists the files changed by the head commit of a branch in origin.
+func pushedFiles(t *testing.T, origin, branch string) []string {
+	t.Helper()
+	output, err := exec.Command("git", "-C", origin, "show", "--name-only", "--format=", branch).Output()
+	require.NoError(t, err)
+	return strings.Fields(string(output))
+}
+
+func patchApplicationResults(emitter *mockEmitter) []*events.PatchApplicationResultPayload {
+	var results []*events.PatchApplicationResultPayload
+	for _, payload := range findEmitted(emitter, events.PatchApplicationResult) {
+		if result, ok := payload.(*events.PatchApplicationResultPayload); ok {
+			results = append(results, result)
+		}
+	}
+	return results
+}
+
+func TestPatchGenerationEvent_StrictModeFailsOnFirstUnapplyablePatch(t *testing.T) {
+	cfg := &appconfig.WorkerConfig{PatchApplicationMode: string(events.PatchApplicationStrict)}
+	svc, request := checkoutGoModule(t, cfg)
+	client := &scriptedBAMLClient{responses: []*GeneratePatchResponse{mixedPatches()}}
+	emitter := &mockEmitter{}
+
+	handler := NewPatchGenerationEvent(cfg, client, svc, nil, emitter)
+
+	err := handler.Execute(context.Background(), request)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "patch_application")
+
+	assert.Empty(t, patchApplicationResults(emitter))
+	assert.Empty(t, findEmitted(emitter, events.GitCommitCreated))
+	assert.False(t, remoteHasBranch(t, request.RepositoryURL, "feature/calc"))
+}
+
+func TestPatchGenerationEvent_LenientModeCommitsAppliedPatches(t *testing.T) {
+	cfg := &appconfig.WorkerConfig{
+		PatchApplicationMode:  string(events.PatchApplicationLenient),
+		PatchFailureThreshold: 0.5,
+	}
+	svc, request := checkoutGoModule(t, cfg)
+	client := &scriptedBAMLClient{responses: []*GeneratePatchResponse{mixedPatches()}}
+	emitter := &mockEmitter{}
+
+	handler := NewPatchGenerationEvent(cfg, client, svc, nil, emitter)
+
+	require.NoError(t, handler.Execute(context.Background(), request))
+
+	results := patchApplicationResults(emitter)
+	require.Len(t, results, 1)
+	result := results[0]
+	assert.Equal(t, request.ExecutionID, result.ExecutionID)
+	assert.Equal(t, 3, result.TotalPatches)
+	assert.Equal(t, []string{"calc.go", "doc.go"}, result.AppliedFiles)
+	require.Len(t, result.FailedFiles, 1)
+	assert.Equal(t, "README.md", result.FailedFiles[0].FilePath)
+	assert.Equal(t, events.StepErrorCategoryValidation, result.FailedFiles[0].ErrorCategory)
+	assert.False(t, result.ThresholdExceeded)
+
+	assert.ElementsMatch(t, []string{"calc.go", "doc.go"}, pushedFiles(t, request.RepositoryURL, "feature/calc"),
+		"only the applied patches are committed")
+	assert.Len(t, findEmitted(emitter, events.FeatureDelivered), 1)
+}
+
+func TestPatchGenerationEvent_LenientModeFailsOverThreshold(t *testing.T) {
+	cfg := &appconfig.WorkerConfig{
+		PatchApplicationMode:  string(events.PatchApplicationLenient),
+		PatchFailureThreshold: 0.25,
+	}
+	svc, request := checkoutGoModule(t, cfg)
+	client := &scriptedBAMLClient{responses: []*GeneratePatchResponse{mixedPatches()}}
+	emitter := &mockEmitter{}
+
+	handler := NewPatchGenerationEvent(cfg, client, svc, nil, emitter)
+
+	err := handler.Execute(context.Background(), request)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "1 of 3 patches failed to apply: README.md")
+
+	results := patchApplicationResults(emitter)
+	require.Len(t, results, 1)
+	assert.True(t, results[0].ThresholdExceeded)
+	assert.Len(t, findEmitted(emitter, events.PatchGenerationStepFailed), 1)
+	assert.False(t, remoteHasBranch(t, request.RepositoryURL, "feature/calc"))
+}
+
+func TestPatchGenerationEvent_LenientModeFailsWhenNothingApplies(t *testing.T) {
+	cfg := &appconfig.WorkerConfig{
+		PatchApplicationMode:  string(events.PatchApplicationLenient),
+		PatchFailureThreshold: 1,
+	}
+	svc, request := checkoutGoModule(t, cfg)
+	client := &scriptedBAMLClient{responses: []*GeneratePatchResponse{{
+		Patches: []Patch{{FilePath: "README.md", NewContent: "\xff", Action: events.FileActionCreate}},
+	}}}
+	emitter := &mockEmitter{}
+
+	handler := NewPatchGenerationEvent(cfg, client, svc, nil, emitter)
+
+	require.Error(t, handler.Execute(context.Background(), request))
+	results := patchApplicationResults(emitter)
+	require.Len(t, results, 1)
+	assert.Empty(t, results[0].AppliedFiles)
+	assert.True(t, results[0].ThresholdExceeded)
+}
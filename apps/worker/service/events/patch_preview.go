@@ -0,0 +1,472 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pitabwire/util"
+
+	"github.com/antinvestor/builder/internal/events"
+	"github.com/antinvestor/builder/internal/httpauth"
+)
+
+const (
+	// defaultPatchPreviewTTL is how long a preview waits for a decision by default.
+	defaultPatchPreviewTTL = 24 * time.Hour
+	// defaultMaxPendingPreviews bounds the previews awaiting a decision by default.
+	defaultMaxPendingPreviews = 100
+	// maxPreviewDecisionBytes bounds the size of a preview decision body.
+	maxPreviewDecisionBytes = 64 * 1024
+)
+
+// ErrPatchPreviewStoreFull is returned when a preview cannot be held because too
+// many are already awaiting a decision.
+var ErrPatchPreviewStoreFull = errors.New("too many patch previews awaiting a decision")
+
+// pendingPreview is a generated change waiting for approval.
+type pendingPreview struct {
+	request   *events.RepositoryCheckoutCompletedPayload
+	resp      *GeneratePatchResponse
+	preview   *events.PatchPreviewReadyPayload
+	startTime time.Time
+	expiresAt time.Time
+}
+
+// PatchPreviewStore holds the patch previews awaiting a decision. A preview
+// expires when it is not decided within the store's TTL.
+type PatchPreviewStore struct {
+	now        func() time.Time
+	ttl        time.Duration
+	maxPending int
+
+	mu      sync.Mutex
+	pending map[events.ExecutionID]*pendingPreview
+}
+
+// NewPatchPreviewStore creates an empty patch preview store with the default limits.
+func NewPatchPreviewStore() *PatchPreviewStore {
+	return &PatchPreviewStore{
+		now:        time.Now,
+		ttl:        defaultPatchPreviewTTL,
+		maxPending: defaultMaxPendingPreviews,
+		pending:    make(map[events.ExecutionID]*pendingPreview),
+	}
+}
+
+// SetLimits sets how long previews wait for a decision and how many may wait at
+// once. Non-positive values keep the current limit.
+func (s *PatchPreviewStore) SetLimits(ttl time.Duration, maxPending int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if ttl > 0 {
+		s.ttl = ttl
+	}
+	if maxPending > 0 {
+		s.maxPending = maxPending
+	}
+}
+
+// admit drops expired previews, returning their executions, and reports
+// ErrPatchPreviewStoreFull when there is no room for a preview of execID.
+func (s *PatchPreviewStore) admit(execID events.ExecutionID) ([]events.ExecutionID, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now()
+	var expired []events.ExecutionID
+	for id, pending := range s.pending {
+		if !now.Before(pending.expiresAt) {
+			delete(s.pending, id)
+			expired = append(expired, id)
+		}
+	}
+	if _, held := s.pending[execID]; !held && len(s.pending) >= s.maxPending {
+		return expired, ErrPatchPreviewStoreFull
+	}
+	return expired, nil
+}
+
+func (s *PatchPreviewStore) put(execID events.ExecutionID, pending *pendingPreview) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	pending.expiresAt = s.now().Add(s.ttl)
+	s.pending[execID] = pending
+}
+
+// take removes and returns the pending preview of an execution, so a decision is acted on once.
+func (s *PatchPreviewStore) take(execID events.ExecutionID) (*pendingPreview, bool) {
+	if s == nil {
+		return nil, false
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	pending, ok := s.pending[execID]
+	delete(s.pending, execID)
+	if !ok || !s.now().Before(pending.expiresAt) {
+		return nil, false
+	}
+	return pending, true
+}
+
+// Discard drops the preview of an execution that ended while awaiting a decision.
+func (s *PatchPreviewStore) Discard(execID events.ExecutionID) {
+	s.take(execID)
+}
+
+// Get returns the preview awaiting a decision for an execution.
+func (s *PatchPreviewStore) Get(execID events.ExecutionID) (*events.PatchPreviewReadyPayload, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	pending, ok := s.pending[execID]
+	if !ok || !s.now().Before(pending.expiresAt) {
+		return nil, false
+	}
+	return pending.preview, true
+}
+
+// SetPatchPreview holds generated patches for approval instead of applying them.
+func (h *PatchGenerationEvent) SetPatchPreview(store *PatchPreviewStore) {
+	h.previews = store
+}
+
+// requestPreview generates patches without applying them and emits the proposed diff.
+func (h *PatchGenerationEvent) requestPreview(
+	ctx context.Context,
+	execID events.ExecutionID,
+	request *events.RepositoryCheckoutCompletedPayload,
+	startTime time.Time,
+) error {
+	expired, err := h.previews.admit(execID)
+	for _, expiredID := range expired {
+		h.failExpiredPreview(ctx, expiredID)
+	}
+	if err != nil {
+		return h.emitGenerationFailure(ctx, execID, "patch_preview", err, events.StepErrorCategoryResource)
+	}
+
+	repoContext, err := h.repoService.GetRepositoryContext(ctx, execID)
+	if err != nil {
+		util.Log(ctx).Warn("failed to get project structure", "error", err)
+		repoContext = ""
+	}
+
+	resp, err := h.bamlClient.GeneratePatch(ctx, &GeneratePatchRequest{
+		ExecutionID:       execID,
+		Specification:     request.Spec,
+		WorkspacePath:     request.WorkspacePath,
+		RepositoryContext: repoContext,
+		IterationNumber:   1,
+	})
+	if err != nil {
+		return h.emitGenerationFailure(ctx, execID, "llm_generation", err, events.StepErrorCategoryLLM)
+	}
+	if err = h.recordGenerationTokens(ctx, execID, resp); err != nil {
+		return err
+	}
+
+	preview := buildPatchPreview(execID, request.FeatureBranchName, resp.Patches)
+	h.previews.put(execID, &pendingPreview{
+		request:   request,
+		resp:      resp,
+		preview:   preview,
+		startTime: startTime,
+	})
+
+	util.Log(ctx).Info("patch preview awaiting approval",
+		"execution_id", execID.String(),
+		"patches", len(resp.Patches),
+	)
+	return h.eventsMan.Emit(ctx, string(events.PatchPreviewReady), preview)
+}
+
+// failExpiredPreview fails an execution whose preview was not decided in time,
+// releasing its workspace.
+func (h *PatchGenerationEvent) failExpiredPreview(ctx context.Context, execID events.ExecutionID) {
+	log := util.Log(ctx)
+	log.Warn("patch preview expired before a decision", "execution_id", execID.String())
+
+	if err := h.repoService.CleanupWorkspace(ctx, execID); err != nil {
+		log.WithError(err).Warn("failed to clean up workspace of expired preview",
+			"execution_id", execID.String())
+	}
+
+	classification := events.FailureClassification{
+		Type:           events.FailureTypeDeterministic,
+		Severity:       events.FailureSeverityError,
+		Retryable:      false,
+		UserActionable: true,
+	}
+	if err := h.eventsMan.Emit(ctx, string(events.FeatureExecutionFailed), &events.FeatureExecutionFailedPayload{
+		ExecutionID:    execID,
+		Classification: classification,
+		FailedPhase:    events.ExecutionPhaseGeneration,
+		ErrorCode:      "patch_preview_expired",
+		ErrorMessage:   "patch preview expired before it was approved or rejected",
+		Recovery:       failureRecovery(classification, events.ExecutionPhaseGeneration, false),
+	}); err != nil {
+		log.WithError(err).Warn("failed to emit expired preview failure", "execution_id", execID.String())
+	}
+}
+
+// deliverPreview applies an approved preview and delivers it. The compile check
+// still runs, but a failure ends the execution instead of regenerating, since
+// regenerated patches would differ from the approved diff.
+func (h *PatchGenerationEvent) deliverPreview(
+	ctx context.Context,
+	execID events.ExecutionID,
+	pending *pendingPreview,
+) error {
+	// The approved diff is delivered whole or not at all
+	_, stats, err := h.applyPatches(ctx, execID, pending.resp.Patches, events.PatchApplicationStrict)
+	if err != nil {
+		return err
+	}
+
+	if result := h.compileCheck(ctx, execID); result != nil && !result.Passed {
+		return h.emitGenerationFailure(ctx, execID, "compile_check",
+			fmt.Errorf("%s failed on the approved patches:\n%s",
+				result.Command, truncateCompileOutput(result.Output)),
+			events.StepErrorCategorySyntax)
+	}
+
+	return h.deliver(ctx, execID, pending.request, pending.resp, stats, pending.startTime)
+}
+
+// buildPatchPreview renders the proposed change of every patch.
+func buildPatchPreview(execID events.ExecutionID, branchName string, patches []Patch) *events.PatchPreviewReadyPayload {
+	preview := &events.PatchPreviewReadyPayload{
+		ExecutionID: execID,
+		BranchName:  branchName,
+		Patches:     make([]events.Patch, 0, len(patches)),
+		CreatedAt:   time.Now(),
+	}
+
+	var diff strings.Builder
+	for _, patch := range patches {
+		fileDiff := proposedDiff(&patch)
+		linesAdded, linesRemoved := patchLineCounts(&patch)
+		preview.Patches = append(preview.Patches, events.Patch{
+			FilePath:     patch.FilePath,
+			Action:       patch.Action,
+			OldContent:   patch.OldContent,
+			NewContent:   patch.NewContent,
+			DiffContent:  fileDiff,
+			LinesAdded:   linesAdded,
+			LinesRemoved: linesRemoved,
+		})
+		preview.TotalLinesAdded += linesAdded
+		preview.TotalLinesRemoved += linesRemoved
+		diff.WriteString(fileDiff)
+	}
+	preview.Diff = diff.String()
+	return preview
+}
+
+// patchLineCounts returns the lines a patch adds and removes.
+func patchLineCounts(patch *Patch) (int, int) {
+	switch patch.Action {
+	case events.FileActionCreate:
+		return countLines(patch.NewContent), 0
+	case events.FileActionModify:
+		return countLines(patch.NewContent), countLines(patch.OldContent)
+	case events.FileActionDelete:
+		return 0, countLines(patch.OldContent)
+	default:
+		return 0, 0
+	}
+}
+
+// proposedDiff renders a patch as a unified diff with a single whole-file hunk.
+func proposedDiff(patch *Patch) string {
+	oldPath, newPath := "a/"+patch.FilePath, "b/"+patch.FilePath
+	oldContent, newContent := patch.OldContent, patch.NewContent
+	switch patch.Action {
+	case events.FileActionCreate:
+		oldPath, oldContent = "/dev/null", ""
+	case events.FileActionDelete:
+		newPath, newContent = "/dev/null", ""
+	case events.FileActionModify, events.FileActionRename:
+	}
+
+	oldLines, newLines := diffLines(oldContent), diffLines(newContent)
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "--- %s\n+++ %s\n", oldPath, newPath)
+	fmt.Fprintf(&sb, "@@ -%s +%s @@\n", hunkRange(len(oldLines)), hunkRange(len(newLines)))
+	for _, line := range oldLines {
+		sb.WriteString("-" + line + "\n")
+	}
+	for _, line := range newLines {
+		sb.WriteString("+" + line + "\n")
+	}
+	return sb.String()
+}
+
+func diffLines(content string) []string {
+	if content == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+}
+
+// hunkRange formats a unified diff range covering a whole file of n lines.
+func hunkRange(n int) string {
+	if n == 0 {
+		return "0,0"
+	}
+	return fmt.Sprintf("1,%d", n)
+}
+
+// =============================================================================
+// Patch Preview Decision Handler
+// =============================================================================
+
+// PatchPreviewDecisionEvent applies approved previews and aborts rejected ones.
+type PatchPreviewDecisionEvent struct {
+	generation *PatchGenerationEvent
+	eventsMan  Emitter
+}
+
+// NewPatchPreviewDecisionEvent creates a handler acting on the previews of generation.
+func NewPatchPreviewDecisionEvent(generation *PatchGenerationEvent, eventsMan Emitter) *PatchPreviewDecisionEvent {
+	return &PatchPreviewDecisionEvent{generation: generation, eventsMan: eventsMan}
+}
+
+// Name returns the event name.
+func (h *PatchPreviewDecisionEvent) Name() string {
+	return string(events.PatchPreviewDecided)
+}
+
+// PayloadType returns the expected payload type.
+func (h *PatchPreviewDecisionEvent) PayloadType() any {
+	return &events.PatchPreviewDecidedPayload{}
+}
+
+// Validate validates the payload.
+func (h *PatchPreviewDecisionEvent) Validate(_ context.Context, _ any) error {
+	return nil
+}
+
+// Execute delivers an approved preview or aborts the execution of a rejected one.
+func (h *PatchPreviewDecisionEvent) Execute(ctx context.Context, payload any) error {
+	log := util.Log(ctx)
+
+	decision, ok := payload.(*events.PatchPreviewDecidedPayload)
+	if !ok {
+		return errors.New("invalid payload type: expected *PatchPreviewDecidedPayload")
+	}
+
+	pending, ok := h.generation.previews.take(decision.ExecutionID)
+	if !ok {
+		// Already decided, or the preview did not survive a restart
+		log.Warn("no patch preview awaiting a decision", "execution_id", decision.ExecutionID.String())
+		return nil
+	}
+
+	log.Info("patch preview decided",
+		"execution_id", decision.ExecutionID.String(),
+		"approved", decision.Approved,
+		"decided_by", decision.DecidedBy,
+	)
+
+	if decision.Approved {
+		return h.generation.deliverPreview(ctx, decision.ExecutionID, pending)
+	}
+
+	if err := h.generation.repoService.CleanupWorkspace(ctx, decision.ExecutionID); err != nil {
+		log.WithError(err).Warn("failed to clean up workspace of rejected preview",
+			"execution_id", decision.ExecutionID.String())
+	}
+
+	h.generation.execContexts.Delete(decision.ExecutionID)
+
+	reason := "patch preview rejected"
+	if decision.Reason != "" {
+		reason += ": " + decision.Reason
+	}
+	return h.eventsMan.Emit(ctx, string(events.FeatureExecutionAborted), &events.FeatureExecutionAbortedPayload{
+		AbortedBy:    decision.DecidedBy,
+		Reason:       reason,
+		AbortedAt:    time.Now(),
+		PhaseAtAbort: events.ExecutionPhaseGeneration,
+	})
+}
+
+// =============================================================================
+// Patch Preview HTTP Handler
+// =============================================================================
+
+// PatchPreviewDecisionRequest is the body of POST /api/v1/previews.
+type PatchPreviewDecisionRequest struct {
+	ExecutionID string `json:"execution_id"`
+	Approved    bool   `json:"approved"`
+	Reason      string `json:"reason,omitempty"`
+}
+
+// NewPatchPreviewHTTPHandler returns the handler for /api/v1/previews. GET with an
+// execution_id query parameter returns the pending preview; POST records a decision.
+// The handler must be served behind authentication: requests without an
+// authenticated subject are refused, and the subject is recorded as the decider.
+func NewPatchPreviewHTTPHandler(store *PatchPreviewStore, eventsMan Emitter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		decidedBy := httpauth.UserIDFromContext(r.Context())
+		if decidedBy == "" {
+			http.Error(w, "authentication required", http.StatusUnauthorized)
+			return
+		}
+
+		switch r.Method {
+		case http.MethodGet:
+			execID, err := events.ParseExecutionID(r.URL.Query().Get("execution_id"))
+			if err != nil {
+				http.Error(w, "invalid execution_id", http.StatusBadRequest)
+				return
+			}
+			preview, ok := store.Get(execID)
+			if !ok {
+				http.Error(w, "no preview awaiting a decision", http.StatusNotFound)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusOK)
+			if encodeErr := json.NewEncoder(w).Encode(preview); encodeErr != nil {
+				util.Log(r.Context()).WithError(encodeErr).Error("failed to encode patch preview")
+			}
+
+		case http.MethodPost:
+			var body PatchPreviewDecisionRequest
+			if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxPreviewDecisionBytes)).Decode(&body); err != nil {
+				http.Error(w, "invalid request body", http.StatusBadRequest)
+				return
+			}
+			execID, err := events.ParseExecutionID(body.ExecutionID)
+			if err != nil {
+				http.Error(w, "invalid execution_id", http.StatusBadRequest)
+				return
+			}
+			if _, ok := store.Get(execID); !ok {
+				http.Error(w, "no preview awaiting a decision", http.StatusNotFound)
+				return
+			}
+			if err = eventsMan.Emit(r.Context(), string(events.PatchPreviewDecided), &events.PatchPreviewDecidedPayload{
+				ExecutionID: execID,
+				Approved:    body.Approved,
+				DecidedBy:   decidedBy,
+				Reason:      body.Reason,
+				DecidedAt:   time.Now(),
+			}); err != nil {
+				util.Log(r.Context()).WithError(err).Error("failed to emit patch preview decision")
+				http.Error(w, "failed to record decision", http.StatusInternalServerError)
+				return
+			}
+			w.WriteHeader(http.StatusAccepted)
+
+		default:
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		}
+	}
+}
@@ -0,0 +1,220 @@
+//nolint:testpackage // white-box testing requires internal package access
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pitabwire/frame/security"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	appconfig "github.com/antinvestor/builder/apps/worker/config"
+	"github.com/antinvestor/builder/internal/events"
+)
+
+// previewPatchGeneration runs patch generation in preview mode and returns the
+// handler with the preview awaiting a decision.
+func previewPatchGeneration(
+	t *testing.T,
+) (*PatchGenerationEvent, *events.RepositoryCheckoutCompletedPayload, *mockEmitter) {
+	t.Helper()
+	cfg := &appconfig.WorkerConfig{PatchPreviewEnabled: true}
+	svc, request := checkoutGoModule(t, cfg)
+	client := &scriptedBAMLClient{responses: []*GeneratePatchResponse{calcPatch(fixedCalc)}}
+	emitter := &mockEmitter{}
+
+	handler := NewPatchGenerationEvent(cfg, client, svc, nil, emitter)
+	handler.SetPatchPreview(NewPatchPreviewStore())
+
+	require.NoError(t, handler.Execute(context.Background(), request))
+	return handler, request, emitter
+}
+
+func TestPatchGenerationEvent_PreviewWaitsForApproval(t *testing.T) {
+	handler, request, emitter := previewPatchGeneration(t)
+
+	previews := findEmitted(emitter, events.PatchPreviewReady)
+	require.Len(t, previews, 1)
+	preview, ok := previews[0].(*events.PatchPreviewReadyPayload)
+	require.True(t, ok)
+	assert.Equal(t, request.ExecutionID, preview.ExecutionID)
+	require.Len(t, preview.Patches, 1)
+	assert.Equal(t, "calc.go", preview.Patches[0].FilePath)
+	assert.Contains(t, preview.Diff, "+++ b/calc.go")
+	assert.Contains(t, preview.Diff, "+func Add(a, b int) int { return a + b }")
+	assert.Equal(t, countLines(fixedCalc), preview.TotalLinesAdded)
+
+	// Nothing is applied, committed or pushed before the decision
+	assert.NoFileExists(t, filepath.Join(request.WorkspacePath, "calc.go"))
+	assert.Empty(t, findEmitted(emitter, events.GitCommitCreated))
+	assert.Empty(t, findEmitted(emitter, events.FeatureDelivered))
+	assert.False(t, remoteHasBranch(t, request.RepositoryURL, "feature/calc"))
+
+	decision := NewPatchPreviewDecisionEvent(handler, emitter)
+	require.NoError(t, decision.Execute(context.Background(), &events.PatchPreviewDecidedPayload{
+		ExecutionID: request.ExecutionID,
+		Approved:    true,
+		DecidedBy:   "alice",
+	}))
+
+	content, err := os.ReadFile(filepath.Join(request.WorkspacePath, "calc.go"))
+	require.NoError(t, err)
+	assert.Equal(t, fixedCalc, string(content))
+	assert.Len(t, findEmitted(emitter, events.GitCommitCreated), 1)
+	assert.Len(t, findEmitted(emitter, events.FeatureDelivered), 1)
+	assert.True(t, remoteHasBranch(t, request.RepositoryURL, "feature/calc"))
+
+	_, pending := handler.previews.Get(request.ExecutionID)
+	assert.False(t, pending)
+}
+
+func TestPatchPreviewDecisionEvent_RejectionAborts(t *testing.T) {
+	handler, request, emitter := previewPatchGeneration(t)
+	store := NewExecutionContextStore()
+	store.Start(nil, &events.FeatureExecutionInitializedPayload{ExecutionID: request.ExecutionID})
+	handler.SetExecutionContexts(store)
+
+	decision := NewPatchPreviewDecisionEvent(handler, emitter)
+	require.NoError(t, decision.Execute(context.Background(), &events.PatchPreviewDecidedPayload{
+		ExecutionID: request.ExecutionID,
+		Approved:    false,
+		DecidedBy:   "alice",
+		Reason:      "wrong approach",
+	}))
+
+	aborted := findEmitted(emitter, events.FeatureExecutionAborted)
+	require.Len(t, aborted, 1)
+	payload, ok := aborted[0].(*events.FeatureExecutionAbortedPayload)
+	require.True(t, ok)
+	assert.Equal(t, "alice", payload.AbortedBy)
+	assert.Equal(t, "patch preview rejected: wrong approach", payload.Reason)
+
+	assert.Empty(t, findEmitted(emitter, events.GitCommitCreated))
+	assert.False(t, remoteHasBranch(t, request.RepositoryURL, "feature/calc"))
+	assert.NoDirExists(t, request.WorkspacePath)
+	_, ok = store.Get(request.ExecutionID)
+	assert.False(t, ok, "an aborted execution drops its context")
+
+	// A redelivered decision is ignored
+	require.NoError(t, decision.Execute(context.Background(), &events.PatchPreviewDecidedPayload{
+		ExecutionID: request.ExecutionID,
+		Approved:    true,
+	}))
+	assert.Empty(t, findEmitted(emitter, events.FeatureDelivered))
+}
+
+func TestProposedDiff(t *testing.T) {
+	assert.Equal(t,
+		"--- /dev/null\n+++ b/calc.go\n@@ -0,0 +1,2 @@\n+package calc\n+\n",
+		proposedDiff(&Patch{FilePath: "calc.go", Action: events.FileActionCreate, NewContent: "package calc\n\n"}))
+	assert.Equal(t,
+		"--- a/calc.go\n+++ b/calc.go\n@@ -1,1 +1,1 @@\n-old\n+new\n",
+		proposedDiff(&Patch{FilePath: "calc.go", Action: events.FileActionModify, OldContent: "old\n", NewContent: "new"}))
+	assert.Equal(t,
+		"--- a/calc.go\n+++ /dev/null\n@@ -1,1 +0,0 @@\n-old\n",
+		proposedDiff(&Patch{FilePath: "calc.go", Action: events.FileActionDelete, OldContent: "old"}))
+}
+
+// authenticatedRequest returns a request carrying the claims of subject.
+func authenticatedRequest(method, target, body, subject string) *http.Request {
+	claims := &security.AuthenticationClaims{}
+	claims.Subject = subject
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	return req.WithContext(claims.ClaimsToContext(req.Context()))
+}
+
+func TestPatchPreviewHTTPHandler(t *testing.T) {
+	store := NewPatchPreviewStore()
+	execID := events.NewExecutionID()
+	store.put(execID, &pendingPreview{
+		preview: buildPatchPreview(execID, "feature/calc", calcPatch(fixedCalc).Patches),
+	})
+	emitter := &mockEmitter{}
+	handler := NewPatchPreviewHTTPHandler(store, emitter)
+
+	rec := httptest.NewRecorder()
+	handler(rec, authenticatedRequest(http.MethodGet, "/api/v1/previews?execution_id="+execID.String(), "", "bob"))
+	require.Equal(t, http.StatusOK, rec.Code)
+	var preview events.PatchPreviewReadyPayload
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&preview))
+	assert.Equal(t, execID, preview.ExecutionID)
+	assert.NotEmpty(t, preview.Diff)
+
+	rec = httptest.NewRecorder()
+	body := `{"execution_id":"` + execID.String() + `","approved":false,"decided_by":"mallory","reason":"no"}`
+	handler(rec, authenticatedRequest(http.MethodPost, "/api/v1/previews", body, "bob"))
+	require.Equal(t, http.StatusAccepted, rec.Code)
+	decisions := findEmitted(emitter, events.PatchPreviewDecided)
+	require.Len(t, decisions, 1)
+	decision, ok := decisions[0].(*events.PatchPreviewDecidedPayload)
+	require.True(t, ok)
+	assert.False(t, decision.Approved)
+	assert.Equal(t, "bob", decision.DecidedBy, "the decider is the authenticated subject, not the body")
+
+	rec = httptest.NewRecorder()
+	unknown := "/api/v1/previews?execution_id=" + events.NewExecutionID().String()
+	handler(rec, authenticatedRequest(http.MethodGet, unknown, "", "bob"))
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestPatchPreviewHTTPHandler_RequiresAuthentication(t *testing.T) {
+	store := NewPatchPreviewStore()
+	execID := events.NewExecutionID()
+	store.put(execID, &pendingPreview{preview: buildPatchPreview(execID, "feature/calc", nil)})
+	emitter := &mockEmitter{}
+	handler := NewPatchPreviewHTTPHandler(store, emitter)
+
+	rec := httptest.NewRecorder()
+	body := `{"execution_id":"` + execID.String() + `","approved":true}`
+	handler(rec, httptest.NewRequest(http.MethodPost, "/api/v1/previews", strings.NewReader(body)))
+
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.Empty(t, findEmitted(emitter, events.PatchPreviewDecided))
+}
+
+func TestPatchPreviewStore_ExpiresAndBoundsPreviews(t *testing.T) {
+	store := NewPatchPreviewStore()
+	store.SetLimits(time.Hour, 1)
+	now := time.Now()
+	store.now = func() time.Time { return now }
+
+	first, second := events.NewExecutionID(), events.NewExecutionID()
+	expired, err := store.admit(first)
+	require.NoError(t, err)
+	assert.Empty(t, expired)
+	store.put(first, &pendingPreview{preview: buildPatchPreview(first, "feature/calc", nil)})
+
+	_, err = store.admit(second)
+	require.ErrorIs(t, err, ErrPatchPreviewStoreFull)
+
+	now = now.Add(2 * time.Hour)
+	_, ok := store.Get(first)
+	assert.False(t, ok, "an expired preview can no longer be decided")
+
+	expired, err = store.admit(second)
+	require.NoError(t, err)
+	assert.Equal(t, []events.ExecutionID{first}, expired)
+}
+
+func TestPatchPreviewStore_DiscardedWhenExecutionReleased(t *testing.T) {
+	store := NewPatchPreviewStore()
+	execContexts := NewExecutionContextStore()
+	execContexts.OnRelease(store.Discard)
+
+	execID := events.NewExecutionID()
+	store.put(execID, &pendingPreview{preview: buildPatchPreview(execID, "feature/calc", nil)})
+	_, ok := store.Get(execID)
+	require.True(t, ok)
+
+	execContexts.Delete(execID)
+	_, ok = store.Get(execID)
+	assert.False(t, ok, "the preview of a finished execution is dropped with its context")
+}
@@ -0,0 +1,73 @@
+//nolint:testpackage // white-box testing requires internal package access
+package events
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	appconfig "github.com/antinvestor/builder/apps/worker/config"
+	"github.com/antinvestor/builder/apps/worker/service/repository"
+	"github.com/antinvestor/builder/internal/events"
+)
+
+// staleGoModPatch modifies go.mod from content it no longer has.
+func staleGoModPatch() *GeneratePatchResponse {
+	return &GeneratePatchResponse{
+		Patches: []Patch{{
+			FilePath:   "go.mod",
+			OldContent: "module example.com/calc\n\ngo 1.19\n",
+			NewContent: "module example.com/calc\n\ngo 1.22\n",
+			Action:     events.FileActionModify,
+		}},
+		TokensUsed: 40,
+	}
+}
+
+func TestPatchGenerationEvent_StalePatchIsRegeneratedWithFeedback(t *testing.T) {
+	cfg := &appconfig.WorkerConfig{PatchVerificationEnabled: true, PatchVerificationMaxRetries: 2}
+	svc, request := checkoutGoModule(t, cfg)
+	client := &scriptedBAMLClient{responses: []*GeneratePatchResponse{staleGoModPatch(), calcPatch(fixedCalc)}}
+	emitter := &mockEmitter{}
+
+	handler := NewPatchGenerationEvent(cfg, client, svc, nil, emitter)
+
+	require.NoError(t, handler.Execute(context.Background(), request))
+
+	require.Len(t, client.requests, 2)
+	feedback := client.requests[1].FeedbackFromReview
+	assert.Contains(t, feedback, "go.mod (modify)")
+	assert.Contains(t, feedback, "old_content does not occur")
+	assert.Equal(t, 2, client.requests[1].IterationNumber)
+
+	iterations := findEmitted(emitter, events.IterationStarted)
+	require.Len(t, iterations, 1)
+	iteration, ok := iterations[0].(*events.IterationStartedPayload)
+	require.True(t, ok)
+	assert.Equal(t, events.IterationReasonValidationFailed, iteration.Reason)
+
+	// Only the verified patch was applied
+	assert.Equal(t, []string{"calc.go"}, pushedFiles(t, request.RepositoryURL, "feature/calc"))
+}
+
+func TestPatchGenerationEvent_CreateOverExistingFailsAfterRetries(t *testing.T) {
+	cfg := &appconfig.WorkerConfig{PatchVerificationEnabled: true, PatchVerificationMaxRetries: 1}
+	svc, request := checkoutGoModule(t, cfg)
+	conflict := &GeneratePatchResponse{Patches: []Patch{{
+		FilePath:   "go.mod",
+		NewContent: "module example.com/other\n",
+		Action:     events.FileActionCreate,
+	}}}
+	client := &scriptedBAMLClient{responses: []*GeneratePatchResponse{conflict}}
+	emitter := &mockEmitter{}
+
+	handler := NewPatchGenerationEvent(cfg, client, svc, nil, emitter)
+
+	err := handler.Execute(context.Background(), request)
+	require.ErrorIs(t, err, repository.ErrPatchMismatch)
+	assert.Contains(t, err.Error(), "the file to create already exists")
+	assert.Len(t, client.requests, 2)
+	assert.False(t, remoteHasBranch(t, request.RepositoryURL, "feature/calc"))
+}
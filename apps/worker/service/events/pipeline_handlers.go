@@ -2,16 +2,21 @@ package events
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"sort"
 	"strings"
 	"time"
 
 	"github.com/pitabwire/util"
 
 	appconfig "github.com/antinvestor/builder/apps/worker/config"
+	"github.com/antinvestor/builder/apps/worker/service/accounting"
+	"github.com/antinvestor/builder/apps/worker/service/flakiness"
 	"github.com/antinvestor/builder/apps/worker/service/repository"
 	"github.com/antinvestor/builder/internal/events"
+	"github.com/antinvestor/builder/internal/executions"
 )
 
 // Test execution timeout constant.
@@ -21,11 +26,18 @@ const defaultTestTimeoutSeconds = 300
 // Test Execution Request Handler
 // =============================================================================
 
+// LanguageDetector detects the project language of an execution's workspace.
+type LanguageDetector interface {
+	DetectWorkspaceLanguage(executionID events.ExecutionID) (string, string, error)
+}
+
 // TestExecutionRequestEvent sends test execution requests to the executor.
 type TestExecutionRequestEvent struct {
 	cfg       *appconfig.WorkerConfig
 	queueMan  QueueManager
 	eventsMan Emitter
+
+	languages LanguageDetector
 }
 
 // NewTestExecutionRequestEvent creates a new test execution request event handler.
@@ -41,6 +53,11 @@ func NewTestExecutionRequestEvent(
 	}
 }
 
+// SetLanguageDetector selects the test command from each workspace's detected language.
+func (h *TestExecutionRequestEvent) SetLanguageDetector(detector LanguageDetector) {
+	h.languages = detector
+}
+
 // Name returns the event name.
 func (h *TestExecutionRequestEvent) Name() string {
 	return string(events.PatchGenerationCompleted)
@@ -69,26 +86,97 @@ func (h *TestExecutionRequestEvent) Execute(ctx context.Context, payload any) er
 		"commit_sha", request.FinalCommitSHA,
 	)
 
+	plan := h.planTests(request.ExecutionID)
+	if plan.warning != "" {
+		log.Warn(plan.warning, "execution_id", request.ExecutionID.String())
+	}
+	if plan.skip {
+		return h.emitTestsSkipped(ctx, request.ExecutionID, plan.warning)
+	}
+
 	// Emit test execution started event
-	// TODO: Make TestCommand, TimeoutSeconds, and Language configurable via WorkerConfig
+	// TODO: Make TimeoutSeconds configurable via WorkerConfig
 	if err := h.eventsMan.Emit(ctx, string(events.TestExecutionStarted), &events.TestExecutionStartedPayload{
-		TestCommand:    "go test ./...",
+		ExecutionID:    request.ExecutionID,
+		TestCommand:    plan.command,
 		TimeoutSeconds: defaultTestTimeoutSeconds,
 		StartedAt:      time.Now(),
+		Warning:        plan.warning,
 	}); err != nil {
 		return err
 	}
 
 	// Publish test execution request to executor queue
-	// TODO: Detect language from workspace or config instead of hardcoding
 	return h.queueMan.Publish(ctx, h.cfg.QueueExecutionRequestName, &events.TestExecutionRequestedPayload{
 		ExecutionID:   request.ExecutionID,
-		Language:      "go",
+		Language:      plan.language,
 		TestFiles:     []string{},
+		TestCommand:   plan.override,
 		WorkspacePath: "", // Executor will use its own workspace
 	})
 }
 
+// testPlan is how an execution's tests are run.
+type testPlan struct {
+	language string
+	// command is the test command that will run, for reporting.
+	command string
+	// override replaces the executor's test command for the language when set.
+	override string
+	skip     bool
+	// warning explains a plan chosen without detecting the project language.
+	warning string
+}
+
+// planTests picks the test command for the workspace's detected language, preferring
+// one configured in TestCommands. When the language cannot be detected, the
+// configured fallback command runs, or the tests are skipped rather than running a
+// command meant for another ecosystem.
+func (h *TestExecutionRequestEvent) planTests(executionID events.ExecutionID) testPlan {
+	if h.languages == nil {
+		return testPlan{language: "go", command: "go test ./..."}
+	}
+	language, command, err := h.languages.DetectWorkspaceLanguage(executionID)
+	if err == nil {
+		if configured := h.cfg.TestCommand(language); configured != "" {
+			return testPlan{language: language, command: configured, override: configured}
+		}
+		return testPlan{language: language, command: command}
+	}
+
+	switch {
+	case h.cfg.FallbackTestCommand != "":
+		return testPlan{
+			command:  h.cfg.FallbackTestCommand,
+			override: h.cfg.FallbackTestCommand,
+			warning:  fmt.Sprintf("%v, running fallback test command %q", err, h.cfg.FallbackTestCommand),
+		}
+	case h.cfg.SkipTestsWhenUndetected:
+		return testPlan{skip: true, warning: fmt.Sprintf("%v and no fallback test command, skipping tests", err)}
+	default:
+		return testPlan{
+			language: "go",
+			command:  "go test ./...",
+			warning:  fmt.Sprintf("%v, running the Go tests", err),
+		}
+	}
+}
+
+// emitTestsSkipped completes a test run that was skipped without reaching the
+// executor. No tests ran, so the run is not reported as passing.
+func (h *TestExecutionRequestEvent) emitTestsSkipped(
+	ctx context.Context,
+	executionID events.ExecutionID,
+	reason string,
+) error {
+	return h.eventsMan.Emit(ctx, string(events.TestExecutionCompleted), &events.TestExecutionCompletedPayload{
+		ExecutionID: executionID,
+		Success:     false,
+		Skipped:     true,
+		Output:      "Tests skipped: " + reason,
+	})
+}
+
 // =============================================================================
 // Review Request Handler
 // =============================================================================
@@ -97,22 +185,51 @@ func (h *TestExecutionRequestEvent) Execute(ctx context.Context, payload any) er
 type ReviewRequestEvent struct {
 	cfg       *appconfig.WorkerConfig
 	queueMan  QueueManager
+	ledger    *accounting.Ledger
 	eventsMan Emitter
+
+	acceptanceCheck *AcceptanceSelfCheck
+	flakyTests      *flakiness.Tracker
+	execContexts    *ExecutionContextStore
+	iterations      iterationCounter
 }
 
 // NewReviewRequestEvent creates a new review request event handler.
 func NewReviewRequestEvent(
 	cfg *appconfig.WorkerConfig,
 	queueMan QueueManager,
+	ledger *accounting.Ledger,
 	eventsMan Emitter,
 ) *ReviewRequestEvent {
 	return &ReviewRequestEvent{
 		cfg:       cfg,
 		queueMan:  queueMan,
+		ledger:    ledger,
 		eventsMan: eventsMan,
 	}
 }
 
+// SetAcceptanceSelfCheck attaches acceptance self-assessments to review requests.
+func (h *ReviewRequestEvent) SetAcceptanceSelfCheck(check *AcceptanceSelfCheck) {
+	h.acceptanceCheck = check
+}
+
+// SetFlakyTestTracker records test outcomes and applies the flaky test quarantine.
+func (h *ReviewRequestEvent) SetFlakyTestTracker(tracker *flakiness.Tracker) {
+	h.flakyTests = tracker
+}
+
+// SetExecutionContexts describes the change under review from each execution's context.
+func (h *ReviewRequestEvent) SetExecutionContexts(store *ExecutionContextStore) {
+	h.execContexts = store
+}
+
+// SetExecutionRepository numbers the iterations requested for failed tests from
+// the count on each execution record.
+func (h *ReviewRequestEvent) SetExecutionRepository(repo executions.ExecutionRepository) {
+	h.iterations = iterationCounter{repo: repo}
+}
+
 // Name returns the event name.
 func (h *ReviewRequestEvent) Name() string {
 	return string(events.TestExecutionCompleted)
@@ -136,16 +253,40 @@ func (h *ReviewRequestEvent) Execute(ctx context.Context, payload any) error {
 		return errors.New("invalid payload type: expected *TestExecutionCompletedPayload")
 	}
 
+	// Charge sandbox CPU time against the execution budget
+	if h.ledger != nil && request.ResourceUsage != nil {
+		if err := h.ledger.RecordSandboxCPU(
+			request.ExecutionID.String(), "test_execution", request.ResourceUsage.CPUSecondsUsed,
+		); err != nil {
+			return emitResourceExhausted(ctx, h.eventsMan, request.ExecutionID, events.ExecutionPhaseVerification, err)
+		}
+	}
+
+	// Claim the self-assessment of these patches so it cannot go stale on iteration
+	var acceptanceAssessment *events.AcceptanceSelfAssessment
+	if h.acceptanceCheck != nil {
+		acceptanceAssessment = h.acceptanceCheck.Take(request.ExecutionID)
+	}
+
+	testResults := request.Result
+	testsPassed := request.Success
+	if h.flakyTests != nil {
+		testResults, testsPassed = h.applyFlakyTestPolicy(ctx, request)
+	}
+	h.execContexts.Update(request.ExecutionID, func(stored *ExecutionContext) {
+		stored.Completion.recordTestRun(request, testResults, testsPassed)
+	})
+
 	// Only request review if tests passed
-	if !request.Success {
+	if !testsPassed {
 		log.Info("tests failed, skipping review request",
 			"execution_id", request.ExecutionID.String(),
 		)
 		// Emit iteration required event
-		// TODO: IterationNumber should be tracked in execution state, not hardcoded
-		// TODO: MaxIterationsRemaining should be calculated from tracked iteration count
+		iterationNumber := h.iterations.next(ctx, request.ExecutionID)
 		return h.eventsMan.Emit(ctx, string(events.IterationRequired), &events.IterationRequiredPayload{
-			IterationNumber: 1, // TODO: Track and increment across iterations
+			ExecutionID:     request.ExecutionID,
+			IterationNumber: iterationNumber,
 			Reason:          events.IterationReasonTestsFailed,
 			Issues: []events.IterationIssue{
 				{
@@ -155,7 +296,7 @@ func (h *ReviewRequestEvent) Execute(ctx context.Context, payload any) error {
 				},
 			},
 			ProposedActions:        []string{"Fix failing tests", "Check test output for errors"},
-			MaxIterationsRemaining: h.cfg.ReviewThresholds.MaxIterations - 1, // TODO: Calculate from tracked count
+			MaxIterationsRemaining: iterationsRemaining(h.cfg.ReviewThresholds.MaxIterations, iterationNumber),
 			RequiredAt:             time.Now(),
 		})
 	}
@@ -173,26 +314,136 @@ func (h *ReviewRequestEvent) Execute(ctx context.Context, payload any) error {
 		return err
 	}
 
-	// Publish review request to reviewer queue
-	return h.queueMan.Publish(ctx, h.cfg.QueueReviewRequestName, &events.ComprehensiveReviewRequestedPayload{
+	reviewRequest := &events.ComprehensiveReviewRequestedPayload{
 		ExecutionID: request.ExecutionID,
 		ReviewPhase: events.ReviewPhasePostImplementation,
-		TestResults: request.Result,
+		TestResults: testResults,
 		RequestedAt: time.Now(),
-	})
+		Context:     h.reviewContext(request.ExecutionID, acceptanceAssessment),
+	}
+
+	// Publish review request to reviewer queue
+	return h.queueMan.Publish(ctx, h.cfg.QueueReviewRequestName, reviewRequest)
+}
+
+// reviewContext describes the change under review from the execution's context,
+// returning nil when there is nothing to attach.
+func (h *ReviewRequestEvent) reviewContext(
+	execID events.ExecutionID,
+	acceptanceAssessment *events.AcceptanceSelfAssessment,
+) *events.ReviewContext {
+	execCtx, ok := h.execContexts.Get(execID)
+	if !ok && acceptanceAssessment == nil {
+		return nil
+	}
+
+	reviewCtx := &events.ReviewContext{AcceptanceAssessment: acceptanceAssessment}
+	if ok {
+		repo := execCtx.Repository
+		reviewCtx.FeatureDescription = execCtx.Spec.Description
+		reviewCtx.AcceptanceCriteria = execCtx.Spec.AcceptanceCriteria
+		reviewCtx.IterationNumber = execCtx.IterationNumber
+		reviewCtx.RepositoryContext = &repo
+	}
+	return reviewCtx
+}
+
+// applyFlakyTestPolicy records the run's test outcomes and, when only failures of
+// tests already classified as flaky are left, treats the run as passing so it does
+// not trigger an iteration. Failures are classified against the earlier history so
+// a first failure of a stable test is never quarantined.
+func (h *ReviewRequestEvent) applyFlakyTestPolicy(
+	ctx context.Context,
+	request *events.TestExecutionCompletedPayload,
+) (*events.TestResult, bool) {
+	log := util.Log(ctx)
+	if request.Result == nil {
+		return request.Result, request.Success
+	}
+
+	var evaluation *flakiness.Evaluation
+	if !request.Success {
+		var err error
+		if evaluation, err = h.flakyTests.Evaluate(ctx, request.Result); err != nil {
+			log.WithError(err).Warn("failed to evaluate flaky tests", "execution_id", request.ExecutionID.String())
+		}
+	}
+
+	if err := h.flakyTests.Record(ctx, request.ExecutionID.String(), request.Result); err != nil {
+		log.WithError(err).Warn("failed to record test history", "execution_id", request.ExecutionID.String())
+	}
+
+	if request.Success || evaluation == nil || !evaluation.OnlyQuarantinedFailures() {
+		return request.Result, request.Success
+	}
+
+	log.Info("only quarantined flaky tests failed, continuing to review",
+		"execution_id", request.ExecutionID.String(),
+		"quarantined_tests", evaluation.QuarantinedFailures,
+	)
+
+	result := *request.Result
+	result.Success = true
+	result.QuarantinedTests = evaluation.QuarantinedFailures
+	return &result, true
 }
 
 // =============================================================================
 // Review Result Handler
 // =============================================================================
 
+// PartialDeliverer delivers a subset of the changed files on their own branch.
+type PartialDeliverer interface {
+	DeliverFiles(
+		ctx context.Context,
+		executionID events.ExecutionID,
+		branchName string,
+		files []string,
+		message string,
+	) (*events.CommitInfo, error)
+}
+
+// BaseRebaser syncs the feature branch with the latest base branch, or with the
+// remote feature branch when a push of it is rejected.
+type BaseRebaser interface {
+	SyncWithBase(
+		ctx context.Context,
+		executionID events.ExecutionID,
+		strategy events.BaseSyncStrategy,
+	) (*repository.RebaseResult, error)
+	SyncWithRemoteBranch(
+		ctx context.Context,
+		executionID events.ExecutionID,
+		branchName string,
+		strategy events.BaseSyncStrategy,
+	) (*repository.RebaseResult, error)
+}
+
+// CodeOwnersLoader loads the CODEOWNERS rules of an execution's workspace.
+type CodeOwnersLoader interface {
+	LoadCodeOwners(
+		ctx context.Context,
+		executionID events.ExecutionID,
+		candidates []string,
+	) (*repository.CodeOwners, error)
+}
+
 // ReviewResultEvent handles review results from the reviewer service.
 type ReviewResultEvent struct {
 	cfg         *appconfig.WorkerConfig
 	repoService *repository.Service
+	deliverer   PartialDeliverer
+	rebaser     BaseRebaser
+	codeOwners  CodeOwnersLoader
+	labeler     PullRequestLabeler
+	templates   *DeliveryTemplates
 	bamlClient  BAMLClient
 	queueMan    QueueManager
 	eventsMan   Emitter
+
+	execContexts *ExecutionContextStore
+	iterations   iterationCounter
+	ledger       *accounting.Ledger
 }
 
 // NewReviewResultEvent creates a new review result event handler.
@@ -203,13 +454,45 @@ func NewReviewResultEvent(
 	queueMan QueueManager,
 	eventsMan Emitter,
 ) *ReviewResultEvent {
-	return &ReviewResultEvent{
+	h := &ReviewResultEvent{
 		cfg:         cfg,
 		repoService: repoService,
 		bamlClient:  bamlClient,
 		queueMan:    queueMan,
 		eventsMan:   eventsMan,
 	}
+	if repoService != nil {
+		h.deliverer = repoService
+		h.rebaser = repoService
+		h.codeOwners = repoService
+	}
+	return h
+}
+
+// SetExecutionContexts delivers each execution on the feature branch recorded in its context.
+func (h *ReviewResultEvent) SetExecutionContexts(store *ExecutionContextStore) {
+	h.execContexts = store
+}
+
+// SetExecutionRepository numbers the iterations requested from reviews from the
+// count on each execution record.
+func (h *ReviewResultEvent) SetExecutionRepository(repo executions.ExecutionRepository) {
+	h.iterations = iterationCounter{repo: repo}
+}
+
+// SetLedger charges the LLM tokens the reviewer reports against the execution budget.
+func (h *ReviewResultEvent) SetLedger(ledger *accounting.Ledger) {
+	h.ledger = ledger
+}
+
+// SetPullRequestLabeler applies the labels derived from the review to delivered pull requests.
+func (h *ReviewResultEvent) SetPullRequestLabeler(labeler PullRequestLabeler) {
+	h.labeler = labeler
+}
+
+// SetDeliveryTemplates replaces the built-in pull request body template.
+func (h *ReviewResultEvent) SetDeliveryTemplates(templates *DeliveryTemplates) {
+	h.templates = templates
 }
 
 // Name returns the event name.
@@ -227,6 +510,42 @@ func (h *ReviewResultEvent) Validate(_ context.Context, _ any) error {
 	return nil
 }
 
+// Handle processes a review result published to the review result queue.
+func (h *ReviewResultEvent) Handle(ctx context.Context, _ map[string]string, message []byte) error {
+	var result events.ComprehensiveReviewCompletedPayload
+	if err := json.Unmarshal(message, &result); err != nil {
+		// A malformed result cannot become valid on redelivery
+		util.Log(ctx).WithError(err).Error("discarding malformed review result")
+		return nil
+	}
+	return h.Execute(ctx, &result)
+}
+
+// recordReviewUsage charges the LLM tokens the review spent and records the model
+// that served it. A review over budget is still acted on; its cost is logged.
+func (h *ReviewResultEvent) recordReviewUsage(
+	ctx context.Context,
+	request *events.ComprehensiveReviewCompletedPayload,
+) {
+	info := request.LLMInfo
+	if info.Model == "" && info.InputTokens+info.OutputTokens == 0 {
+		return
+	}
+	if h.iterations.repo != nil {
+		recordExecutionModel(ctx, h.iterations.repo, request.ExecutionID, info)
+	}
+	if h.ledger == nil {
+		return
+	}
+	if err := h.ledger.RecordLLMUsage(
+		request.ExecutionID.String(), "review", info.InputTokens+info.OutputTokens, ledgerModel(info),
+	); err != nil {
+		util.Log(ctx).WithError(err).Warn("review exhausted the token budget",
+			"execution_id", request.ExecutionID.String(),
+		)
+	}
+}
+
 // Execute processes review results and routes to appropriate handler.
 func (h *ReviewResultEvent) Execute(ctx context.Context, payload any) error {
 	log := util.Log(ctx)
@@ -239,6 +558,10 @@ func (h *ReviewResultEvent) Execute(ctx context.Context, payload any) error {
 		"execution_id", request.ExecutionID.String(),
 		"decision", request.Decision,
 	)
+	h.execContexts.Update(request.ExecutionID, func(stored *ExecutionContext) {
+		stored.Completion.ReviewDecision = request.Decision
+	})
+	h.recordReviewUsage(ctx, request)
 
 	switch request.Decision {
 	case events.ControlDecisionApprove, events.ControlDecisionApproveWithWarnings:
@@ -254,11 +577,8 @@ func (h *ReviewResultEvent) Execute(ctx context.Context, payload any) error {
 		return h.handleAbort(ctx, request)
 
 	case events.ControlDecisionManualReview:
-		// Manual review required - pause and wait
-		log.Info("manual review required, pausing execution",
-			"execution_id", request.ExecutionID.String(),
-		)
-		return nil
+		// Manual review required - request it from the owners and wait
+		return h.handleManualReview(ctx, request)
 
 	case events.ControlDecisionMarkComplete:
 		// Mark as complete - proceed to delivery
@@ -278,12 +598,30 @@ func (h *ReviewResultEvent) handleApproval(
 ) error {
 	log := util.Log(ctx)
 	branchName := fmt.Sprintf("feature/%s", request.ExecutionID.String())
+	if execCtx, ok := h.execContexts.Get(request.ExecutionID); ok && execCtx.FeatureBranchName != "" {
+		branchName = execCtx.FeatureBranchName
+	}
 
 	log.Info("review approved, proceeding to delivery",
 		"execution_id", request.ExecutionID.String(),
 		"branch_name", branchName,
 	)
 
+	// Nothing whose build, tests and review did not all pass is delivered
+	if h.cfg != nil && h.cfg.CompletionGateEnabled {
+		if reasons := completionBlockers(h.execContexts, request.ExecutionID); len(reasons) > 0 {
+			return emitCompletionBlocked(ctx, h.eventsMan, request.ExecutionID, branchName, reasons)
+		}
+	}
+
+	// Take in the latest base so the push does not conflict with it
+	if h.cfg != nil && h.cfg.RebaseBeforePush && h.rebaser != nil {
+		conflicted, err := h.rebaseOntoBase(ctx, request, branchName)
+		if err != nil || conflicted {
+			return err
+		}
+	}
+
 	// Emit git push started
 	if err := h.eventsMan.Emit(ctx, string(events.GitPushStarted), &events.GitPushStartedPayload{
 		BranchName: branchName,
@@ -295,13 +633,22 @@ func (h *ReviewResultEvent) handleApproval(
 
 	// Push the feature branch using the correct method
 	startTime := time.Now()
-	pushErr := h.repoService.PushBranch(ctx, request.ExecutionID, branchName)
+	headSHA, pushErr := h.repoService.PushBranch(ctx, request.ExecutionID, branchName)
+	if pushErr != nil {
+		conflicts := h.deliveryConflicts()
+		var conflict *events.DeliveryConflictPayload
+		headSHA, conflict, pushErr = conflicts.recoverRejectedPush(ctx, request.ExecutionID, branchName, pushErr)
+		if conflict != nil {
+			return conflicts.routeDeliveryConflict(ctx, conflict)
+		}
+	}
 	if pushErr != nil {
+		errorCode, retryable := classifyPushError(pushErr)
 		if emitErr := h.eventsMan.Emit(ctx, string(events.GitPushFailed), &events.GitPushFailedPayload{
 			BranchName:   branchName,
-			ErrorCode:    events.GitPushErrorNetwork,
+			ErrorCode:    errorCode,
 			ErrorMessage: pushErr.Error(),
-			Retryable:    true,
+			Retryable:    retryable,
 			FailedAt:     time.Now(),
 		}); emitErr != nil {
 			log.WithError(emitErr).Error("failed to emit git push failed event")
@@ -311,11 +658,11 @@ func (h *ReviewResultEvent) handleApproval(
 	durationMS := time.Since(startTime).Milliseconds()
 
 	// Emit git push completed
-	// TODO: repoService.PushBranch should return the remote HEAD commit SHA
 	if emitErr := h.eventsMan.Emit(ctx, string(events.GitPushCompleted), &events.GitPushCompletedPayload{
+		ExecutionID:     request.ExecutionID,
 		BranchName:      branchName,
 		RemoteRef:       fmt.Sprintf("refs/heads/%s", branchName),
-		RemoteCommitSHA: "", // TODO: Populate from repoService.PushBranch return value
+		RemoteCommitSHA: headSHA,
 		CommitsPushed:   1,
 		DurationMS:      durationMS,
 		CompletedAt:     time.Now(),
@@ -323,13 +670,18 @@ func (h *ReviewResultEvent) handleApproval(
 		return emitErr
 	}
 
+	labels := h.labelPullRequest(ctx, request, branchName)
+	prBody := h.pullRequestBody(ctx, request.ExecutionID)
+
 	// Emit feature delivered
-	// TODO: HeadCommitSHA should be the commit SHA returned from the push
 	return h.eventsMan.Emit(ctx, string(events.FeatureDelivered), &events.FeatureDeliveredPayload{
-		BranchName:    branchName,
-		RemoteRef:     fmt.Sprintf("refs/heads/%s", branchName),
-		HeadCommitSHA: "", // TODO: Populate from repoService.PushBranch return value
-		Artifacts:     []events.ArtifactReference{},
+		ExecutionID:     request.ExecutionID,
+		BranchName:      branchName,
+		RemoteRef:       fmt.Sprintf("refs/heads/%s", branchName),
+		HeadCommitSHA:   headSHA,
+		Artifacts:       []events.ArtifactReference{},
+		Labels:          labels,
+		PullRequestBody: prBody,
 		Summary: events.DeliverySummary{
 			Title:       "Feature delivered successfully",
 			Description: request.DecisionRationale,
@@ -340,6 +692,98 @@ func (h *ReviewResultEvent) handleApproval(
 	})
 }
 
+// rebaseOntoBase rebases the feature branch onto the base branch if it advanced
+// since checkout. A conflict is reported and then either sent back for
+// regeneration or left for manual review, per configuration; in both cases the
+// push does not proceed and true is returned.
+func (h *ReviewResultEvent) rebaseOntoBase(
+	ctx context.Context,
+	request *events.ComprehensiveReviewCompletedPayload,
+	branchName string,
+) (bool, error) {
+	log := util.Log(ctx)
+
+	result, err := h.rebaser.SyncWithBase(ctx, request.ExecutionID, baseSyncStrategy(h.cfg))
+	if err != nil {
+		return false, fmt.Errorf("rebase onto base branch: %w", err)
+	}
+	if !result.Conflicted() {
+		if result.Rebased || result.Merged {
+			log.Info("synced feature branch with advanced base",
+				"execution_id", request.ExecutionID.String(),
+				"base_commit_sha", result.LatestBaseCommitSHA,
+				"strategy", result.Strategy,
+			)
+		}
+		return false, nil
+	}
+
+	resolution := events.MergeConflictResolution(h.cfg.MergeConflictResolution)
+	if resolution != events.MergeConflictResolutionRegenerate {
+		resolution = events.MergeConflictResolutionManualReview
+	}
+
+	log.Warn("feature branch conflicts with base branch",
+		"execution_id", request.ExecutionID.String(),
+		"conflicting_files", result.ConflictingFiles,
+		"resolution", resolution,
+	)
+
+	if emitErr := h.eventsMan.Emit(ctx, string(events.GitMergeConflictDetected), &events.GitMergeConflictDetectedPayload{
+		ExecutionID:         request.ExecutionID,
+		BranchName:          branchName,
+		BaseBranch:          result.BaseBranch,
+		BaseCommitSHA:       result.BaseCommitSHA,
+		LatestBaseCommitSHA: result.LatestBaseCommitSHA,
+		ConflictingFiles:    result.ConflictingFiles,
+		Resolution:          resolution,
+		DetectedAt:          time.Now(),
+	}); emitErr != nil {
+		return true, emitErr
+	}
+
+	if resolution == events.MergeConflictResolutionManualReview {
+		log.Info("manual review required to resolve merge conflict, pausing execution",
+			"execution_id", request.ExecutionID.String(),
+		)
+		return true, nil
+	}
+
+	issues := make([]events.IterationIssue, 0, len(result.ConflictingFiles))
+	for _, file := range result.ConflictingFiles {
+		issues = append(issues, events.IterationIssue{
+			Type:        "merge_conflict",
+			FilePath:    file,
+			Description: fmt.Sprintf("%s was also changed on %s since checkout", file, result.BaseBranch),
+			Severity:    string(events.ReviewIssueSeverityHigh),
+		})
+	}
+
+	iterationNumber := h.iterations.next(ctx, request.ExecutionID)
+	return true, h.eventsMan.Emit(ctx, string(events.IterationRequired), &events.IterationRequiredPayload{
+		ExecutionID:     request.ExecutionID,
+		IterationNumber: iterationNumber,
+		Reason:          events.IterationReasonMergeConflict,
+		Issues:          issues,
+		ProposedActions: []string{
+			fmt.Sprintf("Regenerate the change on top of %s at %s", result.BaseBranch, result.LatestBaseCommitSHA),
+		},
+		MaxIterationsRemaining: iterationsRemaining(h.cfg.ReviewThresholds.MaxIterations, iterationNumber),
+		RequiredAt:             time.Now(),
+	})
+}
+
+// deliveryConflicts returns the recovery of pushes the remote branch rejected.
+func (h *ReviewResultEvent) deliveryConflicts() deliveryConflicts {
+	return deliveryConflicts{
+		cfg:         h.cfg,
+		repoService: h.repoService,
+		rebaser:     h.rebaser,
+		codeOwners:  h.codeOwners,
+		eventsMan:   h.eventsMan,
+	}
+}
+
 func (h *ReviewResultEvent) handleIteration(
 	ctx context.Context,
 	request *events.ComprehensiveReviewCompletedPayload,
@@ -350,11 +794,19 @@ func (h *ReviewResultEvent) handleIteration(
 		"blocking_issues", len(request.BlockingIssues),
 	)
 
-	// TODO: IterationNumber should be retrieved from execution state and incremented
+	if h.cfg != nil && h.cfg.PartialDeliveryEnabled && h.deliverer != nil {
+		if err := h.deliverCleanFiles(ctx, request); err != nil {
+			// The full change set is still iterated on, so a failed partial delivery is not fatal.
+			log.WithError(err).Warn("partial delivery failed, iterating on all files",
+				"execution_id", request.ExecutionID.String(),
+			)
+		}
+	}
+
 	return h.eventsMan.Emit(ctx, string(events.IterationRequired), &events.FeatureIterationRequestedPayload{
 		ExecutionID:     request.ExecutionID,
 		ReviewID:        request.ReviewID,
-		IterationNumber: 1, // TODO: Track and increment iteration count in execution state
+		IterationNumber: h.iterations.next(ctx, request.ExecutionID),
 		Issues:          request.BlockingIssues,
 		IterationGuidance: &events.IterationGuidance{
 			MustFix: extractIssueTitles(request.BlockingIssues),
@@ -363,6 +815,55 @@ func (h *ReviewResultEvent) handleIteration(
 	})
 }
 
+// deliverCleanFiles pushes the files that passed review to a partial delivery
+// branch while the flagged files are held for iteration. It does nothing when
+// every file is clean or flagged, or when a blocking issue is not tied to a file.
+func (h *ReviewResultEvent) deliverCleanFiles(
+	ctx context.Context,
+	request *events.ComprehensiveReviewCompletedPayload,
+) error {
+	for _, issue := range request.BlockingIssues {
+		if issue.FilePath == "" {
+			return nil
+		}
+	}
+
+	clean, flagged := partitionFileStatuses(request.FileStatuses)
+	if len(clean) == 0 || len(flagged) == 0 {
+		return nil
+	}
+
+	branchName := fmt.Sprintf("feature/%s-partial", request.ExecutionID.String())
+	message := fmt.Sprintf("Deliver %d reviewed files (%d held for iteration)", len(clean), len(flagged))
+
+	commit, err := h.deliverer.DeliverFiles(ctx, request.ExecutionID, branchName, clean, message)
+	if err != nil {
+		return fmt.Errorf("deliver clean files: %w", err)
+	}
+
+	util.Log(ctx).Info("partially delivered feature",
+		"execution_id", request.ExecutionID.String(),
+		"branch_name", branchName,
+		"delivered_files", len(clean),
+		"held_files", len(flagged),
+	)
+
+	return h.eventsMan.Emit(ctx, string(events.FeatureDelivered), &events.FeatureDeliveredPayload{
+		ExecutionID:    request.ExecutionID,
+		BranchName:     branchName,
+		RemoteRef:      fmt.Sprintf("refs/heads/%s", branchName),
+		HeadCommitSHA:  commit.SHA,
+		Artifacts:      []events.ArtifactReference{},
+		Partial:        true,
+		DeliveredFiles: clean,
+		HeldFiles:      flagged,
+		Summary: events.DeliverySummary{
+			Title:       "Feature partially delivered",
+			Description: request.DecisionRationale,
+		},
+	})
+}
+
 func (h *ReviewResultEvent) handleAbort(
 	ctx context.Context,
 	request *events.ComprehensiveReviewCompletedPayload,
@@ -373,20 +874,20 @@ func (h *ReviewResultEvent) handleAbort(
 		"reason", request.DecisionRationale,
 	)
 
+	classification := events.FailureClassification{
+		Type:           events.FailureTypeSemantic,
+		Severity:       events.FailureSeverityError,
+		Retryable:      false,
+		UserActionable: true,
+	}
 	return h.eventsMan.Emit(ctx, string(events.FeatureExecutionFailed), &events.FeatureExecutionFailedPayload{
-		Classification: events.FailureClassification{
-			Type:           events.FailureTypeSemantic,
-			Severity:       events.FailureSeverityError,
-			Retryable:      false,
-			UserActionable: true,
-		},
-		FailedPhase:  events.ExecutionPhaseVerification,
-		ErrorCode:    "review_abort",
-		ErrorMessage: request.DecisionRationale,
-		Recovery: events.RecoveryInfo{
-			CanRetry:  false,
-			CanResume: false,
-		},
+		ExecutionID:    request.ExecutionID,
+		Classification: classification,
+		FailedPhase:    events.ExecutionPhaseVerification,
+		ErrorCode:      "review_abort",
+		ErrorMessage:   request.DecisionRationale,
+		Recovery: failureRecovery(classification, events.ExecutionPhaseVerification,
+			hasIterationsLeft(h.execContexts.Get(request.ExecutionID))),
 	})
 }
 
@@ -396,24 +897,55 @@ func (h *ReviewResultEvent) handleAbort(
 
 // IterationEvent handles iteration requests.
 type IterationEvent struct {
-	cfg        *appconfig.WorkerConfig
-	bamlClient BAMLClient
-	eventsMan  Emitter
+	cfg          *appconfig.WorkerConfig
+	bamlClient   BAMLClient
+	ledger       *accounting.Ledger
+	eventsMan    Emitter
+	execContexts *ExecutionContextStore
+	iterations   iterationCounter
+	committer    IterationCommitter
+}
+
+// IterationCommitter applies an iteration's patches to the workspace and commits them.
+type IterationCommitter interface {
+	ApplyPatch(ctx context.Context, executionID events.ExecutionID, patch *events.Patch) error
+	CreateCommit(ctx context.Context, executionID events.ExecutionID, message string) (*events.CommitInfo, error)
+	GetHeadCommitSHA(ctx context.Context, executionID events.ExecutionID) (string, error)
 }
 
 // NewIterationEvent creates a new iteration event handler.
 func NewIterationEvent(
 	cfg *appconfig.WorkerConfig,
 	bamlClient BAMLClient,
+	ledger *accounting.Ledger,
 	eventsMan Emitter,
 ) *IterationEvent {
 	return &IterationEvent{
 		cfg:        cfg,
 		bamlClient: bamlClient,
+		ledger:     ledger,
 		eventsMan:  eventsMan,
 	}
 }
 
+// SetExecutionContexts iterates from the specification, workspace and patches in
+// each execution's context, under the limits the execution started with.
+func (h *IterationEvent) SetExecutionContexts(store *ExecutionContextStore) {
+	h.execContexts = store
+}
+
+// SetExecutionRepository counts each started iteration on the execution record,
+// so the max-iteration limit applies across every review and test cycle.
+func (h *IterationEvent) SetExecutionRepository(repo executions.ExecutionRepository) {
+	h.iterations = iterationCounter{repo: repo}
+}
+
+// SetIterationCommitter commits each iteration's patches so the next test run and
+// delivery see them, and reports the resulting commit.
+func (h *IterationEvent) SetIterationCommitter(committer IterationCommitter) {
+	h.committer = committer
+}
+
 // Name returns the event name.
 func (h *IterationEvent) Name() string {
 	return string(events.IterationRequired)
@@ -449,13 +981,15 @@ func (h *IterationEvent) Execute(ctx context.Context, payload any) error {
 
 	case *events.IterationRequiredPayload:
 		// Handle iteration from test failures
-		// TODO: Need to pass execution ID and track iteration count properly
+		executionID = p.ExecutionID
 		iterationNumber = p.IterationNumber
 		reason = p.Reason
 		// Convert IterationIssues to ReviewIssues for consistent handling
 		for _, issue := range p.Issues {
 			issues = append(issues, events.ReviewIssue{
 				Type:        events.ReviewIssueType(issue.Type),
+				FilePath:    issue.FilePath,
+				LineStart:   issue.LineNumber,
 				Description: issue.Description,
 				Severity:    events.ReviewIssueSeverity(issue.Severity),
 			})
@@ -467,14 +1001,26 @@ func (h *IterationEvent) Execute(ctx context.Context, payload any) error {
 		)
 	}
 
+	// The persisted count numbers the iteration, whatever the requester saw
+	iterationNumber, err := h.iterations.start(ctx, executionID, iterationNumber)
+	if err != nil {
+		return err
+	}
+
 	log.Info("starting iteration",
 		"execution_id", executionID.String(),
 		"iteration_number", iterationNumber,
 		"issues", len(issues),
 	)
 
+	execCtx, hasExecCtx := h.execContexts.Get(executionID)
+	execCfg := snapshotExecutionConfig(h.cfg)
+	if hasExecCtx {
+		execCfg = execCtx.Config
+	}
+
 	// Check max iterations
-	maxIterations := h.cfg.ReviewThresholds.MaxIterations
+	maxIterations := execCfg.MaxIterations
 	if maxIterations == 0 {
 		maxIterations = 3
 	}
@@ -483,23 +1029,29 @@ func (h *IterationEvent) Execute(ctx context.Context, payload any) error {
 			"execution_id", executionID.String(),
 			"max_iterations", maxIterations,
 		)
+		classification := events.FailureClassification{
+			Type:           events.FailureTypeSemantic,
+			Severity:       events.FailureSeverityError,
+			Retryable:      false,
+			UserActionable: true,
+		}
 		return h.eventsMan.Emit(ctx, string(events.FeatureExecutionFailed), &events.FeatureExecutionFailedPayload{
-			Classification: events.FailureClassification{
-				Type:           events.FailureTypeSemantic,
-				Severity:       events.FailureSeverityError,
-				Retryable:      false,
-				UserActionable: true,
-			},
-			FailedPhase:  events.ExecutionPhaseGeneration,
-			ErrorCode:    "max_iterations_exceeded",
-			ErrorMessage: fmt.Sprintf("Maximum iterations (%d) exceeded", maxIterations),
-			Recovery: events.RecoveryInfo{
-				CanRetry:  false,
-				CanResume: false,
-			},
+			ExecutionID:    executionID,
+			Classification: classification,
+			FailedPhase:    events.ExecutionPhaseGeneration,
+			ErrorCode:      "max_iterations_exceeded",
+			ErrorMessage:   fmt.Sprintf("Maximum iterations (%d) exceeded", maxIterations),
+			Recovery:       failureRecovery(classification, events.ExecutionPhaseGeneration, false),
 		})
 	}
 
+	// Stop before spending more on an execution that is already over budget
+	if h.ledger != nil {
+		if err := h.ledger.Check(executionID.String()); err != nil {
+			return emitResourceExhausted(ctx, h.eventsMan, executionID, events.ExecutionPhaseGeneration, err)
+		}
+	}
+
 	// Convert ReviewIssues to IterationIssues for the IterationStartedPayload
 	targetIssues := convertToIterationIssues(issues)
 
@@ -515,33 +1067,124 @@ func (h *IterationEvent) Execute(ctx context.Context, payload any) error {
 	}); err != nil {
 		return err
 	}
+	h.execContexts.Update(executionID, func(stored *ExecutionContext) {
+		stored.IterationNumber = iterationNumber
+	})
 
 	// Build feedback from review issues
-	feedback := buildFeedbackFromReviewIssues(issues)
-
-	// Generate new patches with feedback
-	resp, err := h.bamlClient.GeneratePatch(ctx, &GeneratePatchRequest{
+	genReq := &GeneratePatchRequest{
 		ExecutionID:        executionID,
 		IterationNumber:    iterationNumber,
-		FeedbackFromReview: feedback,
+		FeedbackFromReview: buildFeedbackFromReviewIssues(issues, execCfg.IterationFeedbackIncludeCode),
+	}
+	if hasExecCtx {
+		genReq.Specification = execCtx.Spec
+		genReq.WorkspacePath = execCtx.WorkspacePath
+		genReq.PreviousPatches = execCtx.Patches
+	}
+
+	// Generate new patches with feedback
+	resp, err := h.bamlClient.GeneratePatch(ctx, genReq)
+	if err != nil {
+		return err
+	}
+	h.execContexts.Update(executionID, func(stored *ExecutionContext) {
+		stored.Patches = mergePatches(stored.Patches, resp.Patches)
 	})
+
+	if err = recordGenerationUsage(ctx, h.ledger, h.iterations.repo, executionID, "iteration", resp); err != nil {
+		return emitResourceExhausted(ctx, h.eventsMan, executionID, events.ExecutionPhaseGeneration, err)
+	}
+
+	commitSHA, err := h.commitIteration(ctx, executionID, iterationNumber, resp)
 	if err != nil {
 		return err
 	}
 
 	// Emit patch generation completed to trigger test execution again
-	// TODO: After applying patches in repo, get the actual commit SHA and use it here.
-	// The bamlClient.GeneratePatch only generates patches; the repo service applies them and creates the commit.
 	return h.eventsMan.Emit(ctx, string(events.PatchGenerationCompleted), &events.PatchGenerationCompletedPayload{
 		ExecutionID:    executionID,
 		TotalSteps:     1,
 		StepsCompleted: 1,
 		TotalLLMTokens: resp.TokensUsed,
-		FinalCommitSHA: "", // TODO: Populate with actual commit SHA after applying patches
+		LLMInfo:        resp.LLM,
+		FinalCommitSHA: commitSHA,
 		CompletedAt:    time.Now(),
 	})
 }
 
+// commitIteration applies and commits an iteration's patches, returning the SHA
+// the workspace is at afterwards. It returns "" when no committer is set.
+func (h *IterationEvent) commitIteration(
+	ctx context.Context,
+	executionID events.ExecutionID,
+	iterationNumber int,
+	resp *GeneratePatchResponse,
+) (string, error) {
+	if h.committer == nil {
+		return "", nil
+	}
+
+	for _, patch := range resp.Patches {
+		if err := h.committer.ApplyPatch(ctx, executionID, &events.Patch{
+			FilePath:   patch.FilePath,
+			Action:     patch.Action,
+			OldContent: patch.OldContent,
+			NewContent: patch.NewContent,
+		}); err != nil {
+			return "", fmt.Errorf("apply iteration patch %s: %w", patch.FilePath, err)
+		}
+	}
+
+	message := resp.CommitMessage
+	if message == "" {
+		message = fmt.Sprintf("fix: address feedback from iteration %d", iterationNumber)
+	}
+	commit, err := h.committer.CreateCommit(ctx, executionID, message)
+	if errors.Is(err, repository.ErrNoChanges) {
+		// The iteration left the tree as it was, so the tests rerun on the current commit
+		return h.committer.GetHeadCommitSHA(ctx, executionID)
+	}
+	if err != nil {
+		return "", fmt.Errorf("commit iteration: %w", err)
+	}
+
+	if emitErr := h.eventsMan.Emit(ctx, string(events.GitCommitCreated), &events.GitCommitCreatedPayload{
+		Commit: *commit,
+	}); emitErr != nil {
+		util.Log(ctx).Warn("failed to emit commit created event", "error", emitErr)
+	}
+	return commit.SHA, nil
+}
+
+// emitResourceExhausted fails an execution that has exceeded its resource budget.
+func emitResourceExhausted(
+	ctx context.Context,
+	eventsMan Emitter,
+	executionID events.ExecutionID,
+	phase events.ExecutionPhase,
+	err error,
+) error {
+	util.Log(ctx).Warn("resource budget exceeded, aborting",
+		"execution_id", executionID.String(),
+		"error", err,
+	)
+	classification := events.FailureClassification{
+		Type:           events.FailureTypeDeterministic,
+		Severity:       events.FailureSeverityError,
+		Retryable:      false,
+		UserActionable: true,
+	}
+	return eventsMan.Emit(ctx, string(events.FeatureExecutionFailed), &events.FeatureExecutionFailedPayload{
+		ExecutionID:    executionID,
+		Classification: classification,
+		FailedPhase:    phase,
+		ErrorCode:      string(events.AbortReasonResourceExhausted),
+		ErrorMessage:   err.Error(),
+		Recovery:       failureRecovery(classification, phase, true),
+	})
+}
+
 // =============================================================================
 // Delivery Handler (for direct delivery without full review)
 // =============================================================================
@@ -599,7 +1242,8 @@ func (h *DeliveryEvent) Execute(ctx context.Context, payload any) error {
 
 	// Emit feature execution completed
 	return h.eventsMan.Emit(ctx, string(events.FeatureExecutionCompleted), &events.FeatureExecutionCompletedPayload{
-		BranchName: request.BranchName,
+		ExecutionID: request.ExecutionID,
+		BranchName:  request.BranchName,
 		FinalCommit: events.CommitInfo{
 			SHA: request.RemoteCommitSHA,
 		},
@@ -621,7 +1265,7 @@ func extractIssueTitles(issues []events.ReviewIssue) []string {
 	return titles
 }
 
-func buildFeedbackFromReviewIssues(issues []events.ReviewIssue) string {
+func buildFeedbackFromReviewIssues(issues []events.ReviewIssue, includeCode bool) string {
 	if len(issues) == 0 {
 		return ""
 	}
@@ -630,9 +1274,19 @@ func buildFeedbackFromReviewIssues(issues []events.ReviewIssue) string {
 	feedback.WriteString("Please fix the following issues:\n\n")
 	for i, issue := range issues {
 		feedback.WriteString(fmt.Sprintf("%d. [%s] %s\n", i+1, issue.Severity, issue.Title))
+		if location := formatIssueLocation(issue); location != "" {
+			feedback.WriteString(fmt.Sprintf("   Location: %s\n", location))
+		}
 		if issue.Description != "" {
 			feedback.WriteString(fmt.Sprintf("   %s\n", issue.Description))
 		}
+		if includeCode && issue.CodeSnippet != "" {
+			feedback.WriteString("   Code:\n   ```\n")
+			for line := range strings.SplitSeq(strings.TrimRight(issue.CodeSnippet, "\n"), "\n") {
+				feedback.WriteString(fmt.Sprintf("   %s\n", line))
+			}
+			feedback.WriteString("   ```\n")
+		}
 		if issue.Suggestion != "" {
 			feedback.WriteString(fmt.Sprintf("   Suggestion: %s\n", issue.Suggestion))
 		}
@@ -641,7 +1295,37 @@ func buildFeedbackFromReviewIssues(issues []events.ReviewIssue) string {
 	return feedback.String()
 }
 
-// convertToIterationIssues converts ReviewIssues to IterationIssues.
+// formatIssueLocation formats the file and line range of an issue as path:start[-end].
+func formatIssueLocation(issue events.ReviewIssue) string {
+	if issue.FilePath == "" {
+		return ""
+	}
+	switch {
+	case issue.LineStart <= 0:
+		return issue.FilePath
+	case issue.LineEnd > issue.LineStart:
+		return fmt.Sprintf("%s:%d-%d", issue.FilePath, issue.LineStart, issue.LineEnd)
+	default:
+		return fmt.Sprintf("%s:%d", issue.FilePath, issue.LineStart)
+	}
+}
+
+// partitionFileStatuses splits reviewed files into clean and flagged, sorted by path.
+// Files left out of a partial review are never treated as clean.
+func partitionFileStatuses(statuses map[string]events.FileReviewStatus) ([]string, []string) {
+	var clean, flagged []string
+	for file, status := range statuses {
+		if status == events.FileReviewStatusClean {
+			clean = append(clean, file)
+		} else {
+			flagged = append(flagged, file)
+		}
+	}
+	sort.Strings(clean)
+	sort.Strings(flagged)
+	return clean, flagged
+}
+
 func convertToIterationIssues(issues []events.ReviewIssue) []events.IterationIssue {
 	result := make([]events.IterationIssue, 0, len(issues))
 	for _, issue := range issues {
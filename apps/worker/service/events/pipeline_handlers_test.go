@@ -3,7 +3,11 @@ package events
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"maps"
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
@@ -11,7 +15,10 @@ import (
 	"github.com/stretchr/testify/require"
 
 	appconfig "github.com/antinvestor/builder/apps/worker/config"
+	"github.com/antinvestor/builder/apps/worker/service/accounting"
+	"github.com/antinvestor/builder/apps/worker/service/repository"
 	"github.com/antinvestor/builder/internal/events"
+	"github.com/antinvestor/builder/internal/executions"
 )
 
 // =============================================================================
@@ -26,16 +33,23 @@ type mockQueueManager struct {
 type publishedMessage struct {
 	queueName string
 	payload   any
+	headers   map[string]string
 }
 
-func (m *mockQueueManager) Publish(_ context.Context, queueName string, payload any, _ ...map[string]string) error {
+func (m *mockQueueManager) Publish(
+	_ context.Context,
+	queueName string,
+	payload any,
+	headers ...map[string]string,
+) error {
 	if m.publishError != nil {
 		return m.publishError
 	}
-	m.publishedMessages = append(m.publishedMessages, publishedMessage{
-		queueName: queueName,
-		payload:   payload,
-	})
+	message := publishedMessage{queueName: queueName, payload: payload, headers: map[string]string{}}
+	for _, h := range headers {
+		maps.Copy(message.headers, h)
+	}
+	m.publishedMessages = append(m.publishedMessages, message)
 	return nil
 }
 
@@ -63,9 +77,11 @@ func (m *mockEmitter) Emit(_ context.Context, name string, payload any) error {
 type mockBAMLClient struct {
 	generatePatchResponse *GeneratePatchResponse
 	generatePatchError    error
+	lastRequest           *GeneratePatchRequest
 }
 
-func (m *mockBAMLClient) GeneratePatch(_ context.Context, _ *GeneratePatchRequest) (*GeneratePatchResponse, error) {
+func (m *mockBAMLClient) GeneratePatch(_ context.Context, req *GeneratePatchRequest) (*GeneratePatchResponse, error) {
+	m.lastRequest = req
 	if m.generatePatchError != nil {
 		return nil, m.generatePatchError
 	}
@@ -77,6 +93,57 @@ func (m *mockBAMLClient) GeneratePatch(_ context.Context, _ *GeneratePatchReques
 	}, nil
 }
 
+type mockPartialDeliverer struct {
+	branchName string
+	files      []string
+	deliverErr error
+	calls      int
+}
+
+func (m *mockPartialDeliverer) DeliverFiles(
+	_ context.Context,
+	_ events.ExecutionID,
+	branchName string,
+	files []string,
+	_ string,
+) (*events.CommitInfo, error) {
+	m.calls++
+	if m.deliverErr != nil {
+		return nil, m.deliverErr
+	}
+	m.branchName = branchName
+	m.files = files
+	return &events.CommitInfo{SHA: "partial-sha"}, nil
+}
+
+type mockBaseRebaser struct {
+	result   *repository.RebaseResult
+	err      error
+	calls    int
+	strategy events.BaseSyncStrategy
+}
+
+func (m *mockBaseRebaser) SyncWithBase(
+	_ context.Context,
+	_ events.ExecutionID,
+	strategy events.BaseSyncStrategy,
+) (*repository.RebaseResult, error) {
+	m.calls++
+	m.strategy = strategy
+	return m.result, m.err
+}
+
+func (m *mockBaseRebaser) SyncWithRemoteBranch(
+	_ context.Context,
+	_ events.ExecutionID,
+	_ string,
+	strategy events.BaseSyncStrategy,
+) (*repository.RebaseResult, error) {
+	m.calls++
+	m.strategy = strategy
+	return m.result, m.err
+}
+
 // =============================================================================
 // TestExecutionRequestEvent Tests
 // =============================================================================
@@ -145,12 +212,121 @@ func TestTestExecutionRequestEvent_Execute_EventEmitError(t *testing.T) {
 	assert.Contains(t, err.Error(), "emit failed")
 }
 
+// dirLanguageDetector detects the language of one directory for every execution.
+type dirLanguageDetector string
+
+func (d dirLanguageDetector) DetectWorkspaceLanguage(events.ExecutionID) (string, string, error) {
+	return repository.DetectLanguage(string(d))
+}
+
+// requestTests runs the test execution request handler on a workspace detected
+// from the given marker files.
+func requestTests(
+	t *testing.T,
+	cfg *appconfig.WorkerConfig,
+	markers ...string,
+) (*mockQueueManager, *mockEmitter) {
+	t.Helper()
+	dir := t.TempDir()
+	for _, marker := range markers {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, marker), nil, 0o600))
+	}
+	cfg.QueueExecutionRequestName = "test-execution-queue"
+	queueMan := &mockQueueManager{}
+	eventsMan := &mockEmitter{}
+	handler := NewTestExecutionRequestEvent(cfg, queueMan, eventsMan)
+	handler.SetLanguageDetector(dirLanguageDetector(dir))
+
+	require.NoError(t, handler.Execute(context.Background(), &events.PatchGenerationCompletedPayload{
+		ExecutionID: events.NewExecutionID(),
+	}))
+	return queueMan, eventsMan
+}
+
+func TestTestExecutionRequestEvent_Execute_DetectedLanguage(t *testing.T) {
+	queueMan, eventsMan := requestTests(t, &appconfig.WorkerConfig{FallbackTestCommand: "make test"}, "package.json")
+
+	started, ok := eventsMan.emittedEvents[0].payload.(*events.TestExecutionStartedPayload)
+	require.True(t, ok)
+	assert.Equal(t, "npm test", started.TestCommand)
+	assert.Empty(t, started.Warning)
+
+	require.Len(t, queueMan.publishedMessages, 1)
+	testReq, ok := queueMan.publishedMessages[0].payload.(*events.TestExecutionRequestedPayload)
+	require.True(t, ok)
+	assert.Equal(t, "node", testReq.Language)
+	assert.Empty(t, testReq.TestCommand)
+}
+
+func TestTestExecutionRequestEvent_Execute_ConfiguredTestCommand(t *testing.T) {
+	queueMan, eventsMan := requestTests(t, &appconfig.WorkerConfig{
+		TestCommands: map[string]string{"python": "pytest -q"},
+	}, "pyproject.toml")
+
+	started, ok := eventsMan.emittedEvents[0].payload.(*events.TestExecutionStartedPayload)
+	require.True(t, ok)
+	assert.Equal(t, "pytest -q", started.TestCommand)
+
+	require.Len(t, queueMan.publishedMessages, 1)
+	testReq, ok := queueMan.publishedMessages[0].payload.(*events.TestExecutionRequestedPayload)
+	require.True(t, ok)
+	assert.Equal(t, "python", testReq.Language)
+	assert.Equal(t, "pytest -q", testReq.TestCommand)
+}
+
+func TestTestExecutionRequestEvent_Execute_FallbackCommandWhenUndetected(t *testing.T) {
+	queueMan, eventsMan := requestTests(t, &appconfig.WorkerConfig{
+		FallbackTestCommand:     "make test",
+		SkipTestsWhenUndetected: true,
+	}, "Makefile")
+
+	require.Len(t, eventsMan.emittedEvents, 1)
+	started, ok := eventsMan.emittedEvents[0].payload.(*events.TestExecutionStartedPayload)
+	require.True(t, ok)
+	assert.Equal(t, "make test", started.TestCommand)
+	assert.Contains(t, started.Warning, "project language not detected")
+	assert.Contains(t, started.Warning, `fallback test command "make test"`)
+
+	require.Len(t, queueMan.publishedMessages, 1)
+	testReq, ok := queueMan.publishedMessages[0].payload.(*events.TestExecutionRequestedPayload)
+	require.True(t, ok)
+	assert.Empty(t, testReq.Language)
+	assert.Equal(t, "make test", testReq.TestCommand)
+}
+
+func TestTestExecutionRequestEvent_Execute_SkipsTestsWhenUndetected(t *testing.T) {
+	queueMan, eventsMan := requestTests(t, &appconfig.WorkerConfig{SkipTestsWhenUndetected: true})
+
+	// Nothing is sent to the executor; the run completes without tests
+	assert.Empty(t, queueMan.publishedMessages)
+	require.Len(t, eventsMan.emittedEvents, 1)
+	assert.Equal(t, string(events.TestExecutionCompleted), eventsMan.emittedEvents[0].name)
+	completed, ok := eventsMan.emittedEvents[0].payload.(*events.TestExecutionCompletedPayload)
+	require.True(t, ok)
+	assert.True(t, completed.Skipped)
+	assert.False(t, completed.Success, "a run without tests does not pass")
+	assert.Nil(t, completed.Result)
+	assert.Contains(t, completed.Output, "Tests skipped: project language not detected")
+}
+
+func TestTestExecutionRequestEvent_Execute_GoTestsWhenUndetectedAndNotSkipped(t *testing.T) {
+	queueMan, eventsMan := requestTests(t, &appconfig.WorkerConfig{})
+
+	started, ok := eventsMan.emittedEvents[0].payload.(*events.TestExecutionStartedPayload)
+	require.True(t, ok)
+	assert.Equal(t, "go test ./...", started.TestCommand)
+	assert.Contains(t, started.Warning, "running the Go tests")
+	testReq, ok := queueMan.publishedMessages[0].payload.(*events.TestExecutionRequestedPayload)
+	require.True(t, ok)
+	assert.Equal(t, "go", testReq.Language)
+}
+
 // =============================================================================
 // ReviewRequestEvent Tests
 // =============================================================================
 
 func TestReviewRequestEvent_Name(t *testing.T) {
-	handler := NewReviewRequestEvent(nil, nil, nil)
+	handler := NewReviewRequestEvent(nil, nil, nil, nil)
 	assert.Equal(t, string(events.TestExecutionCompleted), handler.Name())
 }
 
@@ -161,7 +337,7 @@ func TestReviewRequestEvent_Execute_TestsPassed(t *testing.T) {
 	queueMan := &mockQueueManager{}
 	eventsMan := &mockEmitter{}
 
-	handler := NewReviewRequestEvent(cfg, queueMan, eventsMan)
+	handler := NewReviewRequestEvent(cfg, queueMan, nil, eventsMan)
 
 	executionID := events.NewExecutionID()
 	payload := &events.TestExecutionCompletedPayload{
@@ -193,7 +369,7 @@ func TestReviewRequestEvent_Execute_TestsFailed(t *testing.T) {
 	queueMan := &mockQueueManager{}
 	eventsMan := &mockEmitter{}
 
-	handler := NewReviewRequestEvent(cfg, queueMan, eventsMan)
+	handler := NewReviewRequestEvent(cfg, queueMan, nil, eventsMan)
 
 	executionID := events.NewExecutionID()
 	payload := &events.TestExecutionCompletedPayload{
@@ -231,6 +407,30 @@ func TestReviewResultEvent_Name(t *testing.T) {
 	assert.Equal(t, string(events.ReviewCompleted), handler.Name())
 }
 
+func TestReviewResultEvent_HandleConsumesPublishedResult(t *testing.T) {
+	eventsMan := &mockEmitter{}
+	handler := NewReviewResultEvent(&appconfig.WorkerConfig{}, nil, nil, nil, eventsMan)
+
+	executionID := events.NewExecutionID()
+	message, err := json.Marshal(&events.ComprehensiveReviewCompletedPayload{
+		ExecutionID:       executionID,
+		Decision:          events.ControlDecisionAbort,
+		DecisionRationale: "kill_switch: Kill switch is active",
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, handler.Handle(context.Background(), nil, message))
+	require.Len(t, eventsMan.emittedEvents, 1)
+	assert.Equal(t, string(events.FeatureExecutionFailed), eventsMan.emittedEvents[0].name)
+	failure, ok := eventsMan.emittedEvents[0].payload.(*events.FeatureExecutionFailedPayload)
+	require.True(t, ok)
+	assert.Equal(t, executionID, failure.ExecutionID)
+
+	// A malformed result is dropped rather than redelivered
+	require.NoError(t, handler.Handle(context.Background(), nil, []byte("{")))
+	assert.Len(t, eventsMan.emittedEvents, 1)
+}
+
 func TestReviewResultEvent_Execute_ManualReview(t *testing.T) {
 	cfg := &appconfig.WorkerConfig{}
 	eventsMan := &mockEmitter{}
@@ -250,8 +450,13 @@ func TestReviewResultEvent_Execute_ManualReview(t *testing.T) {
 
 	err := handler.Execute(context.Background(), payload)
 	require.NoError(t, err)
-	// ManualReview decision doesn't emit any events, it just pauses
-	assert.Empty(t, eventsMan.emittedEvents)
+	// ManualReview decision only requests the review and pauses
+	require.Len(t, eventsMan.emittedEvents, 1)
+	assert.Equal(t, string(events.ReviewManualRequested), eventsMan.emittedEvents[0].name)
+	manual, ok := eventsMan.emittedEvents[0].payload.(*events.ManualReviewRequestedPayload)
+	require.True(t, ok)
+	assert.Equal(t, executionID, manual.ExecutionID)
+	assert.Equal(t, "Manual review required", manual.Reason)
 }
 
 func TestReviewResultEvent_Execute_Iterate(t *testing.T) {
@@ -293,6 +498,210 @@ func TestReviewResultEvent_Execute_Iterate(t *testing.T) {
 	assert.Len(t, iterPayload.Issues, 1)
 }
 
+func newPartialReviewPayload(executionID events.ExecutionID) *events.ComprehensiveReviewCompletedPayload {
+	return &events.ComprehensiveReviewCompletedPayload{
+		ExecutionID:       executionID,
+		ReviewID:          "review-456",
+		Decision:          events.ControlDecisionIterate,
+		DecisionRationale: "Issues found in handler",
+		BlockingIssues: []events.ReviewIssue{
+			{
+				ID:       "issue-1",
+				Severity: events.ReviewIssueSeverityHigh,
+				Title:    "SQL Injection vulnerability",
+				FilePath: "api/handler.go",
+			},
+		},
+		FileStatuses: map[string]events.FileReviewStatus{
+			"api/handler.go": events.FileReviewStatusFlagged,
+			"models/user.go": events.FileReviewStatusClean,
+			"docs/README.md": events.FileReviewStatusClean,
+		},
+	}
+}
+
+func TestReviewResultEvent_Execute_PartialDelivery(t *testing.T) {
+	cfg := &appconfig.WorkerConfig{PartialDeliveryEnabled: true}
+	eventsMan := &mockEmitter{}
+	deliverer := &mockPartialDeliverer{}
+
+	handler := &ReviewResultEvent{
+		cfg:       cfg,
+		deliverer: deliverer,
+		eventsMan: eventsMan,
+	}
+
+	executionID := events.NewExecutionID()
+	err := handler.Execute(context.Background(), newPartialReviewPayload(executionID))
+
+	require.NoError(t, err)
+
+	// Clean files are committed and pushed on the partial branch
+	require.Equal(t, 1, deliverer.calls)
+	assert.Equal(t, "feature/"+executionID.String()+"-partial", deliverer.branchName)
+	assert.Equal(t, []string{"docs/README.md", "models/user.go"}, deliverer.files)
+
+	require.Len(t, eventsMan.emittedEvents, 2)
+	assert.Equal(t, string(events.FeatureDelivered), eventsMan.emittedEvents[0].name)
+	delivered, ok := eventsMan.emittedEvents[0].payload.(*events.FeatureDeliveredPayload)
+	require.True(t, ok)
+	assert.True(t, delivered.Partial)
+	assert.Equal(t, "partial-sha", delivered.HeadCommitSHA)
+	assert.Equal(t, []string{"docs/README.md", "models/user.go"}, delivered.DeliveredFiles)
+	assert.Equal(t, []string{"api/handler.go"}, delivered.HeldFiles)
+
+	// Flagged files are held for iteration
+	assert.Equal(t, string(events.IterationRequired), eventsMan.emittedEvents[1].name)
+	iterPayload, ok := eventsMan.emittedEvents[1].payload.(*events.FeatureIterationRequestedPayload)
+	require.True(t, ok)
+	require.Len(t, iterPayload.Issues, 1)
+	assert.Equal(t, "api/handler.go", iterPayload.Issues[0].FilePath)
+}
+
+func TestReviewResultEvent_Execute_PartialDeliveryDisabled(t *testing.T) {
+	eventsMan := &mockEmitter{}
+	deliverer := &mockPartialDeliverer{}
+
+	handler := &ReviewResultEvent{
+		cfg:       &appconfig.WorkerConfig{},
+		deliverer: deliverer,
+		eventsMan: eventsMan,
+	}
+
+	err := handler.Execute(context.Background(), newPartialReviewPayload(events.NewExecutionID()))
+
+	require.NoError(t, err)
+	assert.Equal(t, 0, deliverer.calls)
+	require.Len(t, eventsMan.emittedEvents, 1)
+	assert.Equal(t, string(events.IterationRequired), eventsMan.emittedEvents[0].name)
+}
+
+func TestReviewResultEvent_Execute_PartialDeliverySkippedForUnscopedIssue(t *testing.T) {
+	eventsMan := &mockEmitter{}
+	deliverer := &mockPartialDeliverer{}
+
+	handler := &ReviewResultEvent{
+		cfg:       &appconfig.WorkerConfig{PartialDeliveryEnabled: true},
+		deliverer: deliverer,
+		eventsMan: eventsMan,
+	}
+
+	payload := newPartialReviewPayload(events.NewExecutionID())
+	payload.BlockingIssues = append(payload.BlockingIssues, events.ReviewIssue{
+		ID:    "issue-2",
+		Title: "Tests failing",
+	})
+
+	err := handler.Execute(context.Background(), payload)
+
+	require.NoError(t, err)
+	assert.Equal(t, 0, deliverer.calls)
+	require.Len(t, eventsMan.emittedEvents, 1)
+	assert.Equal(t, string(events.IterationRequired), eventsMan.emittedEvents[0].name)
+}
+
+func TestReviewResultEvent_Execute_PartialDeliveryFailureStillIterates(t *testing.T) {
+	eventsMan := &mockEmitter{}
+	deliverer := &mockPartialDeliverer{deliverErr: errors.New("push rejected")}
+
+	handler := &ReviewResultEvent{
+		cfg:       &appconfig.WorkerConfig{PartialDeliveryEnabled: true},
+		deliverer: deliverer,
+		eventsMan: eventsMan,
+	}
+
+	err := handler.Execute(context.Background(), newPartialReviewPayload(events.NewExecutionID()))
+
+	require.NoError(t, err)
+	assert.Equal(t, 1, deliverer.calls)
+	require.Len(t, eventsMan.emittedEvents, 1)
+	assert.Equal(t, string(events.IterationRequired), eventsMan.emittedEvents[0].name)
+}
+
+func newConflictingRebaser() *mockBaseRebaser {
+	return &mockBaseRebaser{
+		result: &repository.RebaseResult{
+			BaseBranch:          "main",
+			BaseCommitSHA:       "base-at-checkout",
+			LatestBaseCommitSHA: "base-advanced",
+			ConflictingFiles:    []string{"api/handler.go"},
+		},
+	}
+}
+
+func newApprovedReviewPayload(executionID events.ExecutionID) *events.ComprehensiveReviewCompletedPayload {
+	return &events.ComprehensiveReviewCompletedPayload{
+		ExecutionID:       executionID,
+		ReviewID:          "review-789",
+		Decision:          events.ControlDecisionApprove,
+		DecisionRationale: "All checks passed",
+	}
+}
+
+func TestReviewResultEvent_Execute_MergeConflictRegenerates(t *testing.T) {
+	cfg := &appconfig.WorkerConfig{
+		RebaseBeforePush:        true,
+		MergeConflictResolution: string(events.MergeConflictResolutionRegenerate),
+	}
+	eventsMan := &mockEmitter{}
+	rebaser := newConflictingRebaser()
+
+	handler := &ReviewResultEvent{cfg: cfg, rebaser: rebaser, eventsMan: eventsMan}
+
+	executionID := events.NewExecutionID()
+	require.NoError(t, handler.Execute(context.Background(), newApprovedReviewPayload(executionID)))
+
+	assert.Equal(t, 1, rebaser.calls)
+	require.Len(t, eventsMan.emittedEvents, 2)
+	assert.Equal(t, string(events.GitMergeConflictDetected), eventsMan.emittedEvents[0].name)
+
+	conflict, ok := eventsMan.emittedEvents[0].payload.(*events.GitMergeConflictDetectedPayload)
+	require.True(t, ok)
+	assert.Equal(t, executionID, conflict.ExecutionID)
+	assert.Equal(t, []string{"api/handler.go"}, conflict.ConflictingFiles)
+	assert.Equal(t, "base-advanced", conflict.LatestBaseCommitSHA)
+	assert.Equal(t, events.MergeConflictResolutionRegenerate, conflict.Resolution)
+
+	assert.Equal(t, string(events.IterationRequired), eventsMan.emittedEvents[1].name)
+	iteration, ok := eventsMan.emittedEvents[1].payload.(*events.IterationRequiredPayload)
+	require.True(t, ok)
+	assert.Equal(t, events.IterationReasonMergeConflict, iteration.Reason)
+	require.Len(t, iteration.Issues, 1)
+	assert.Equal(t, "api/handler.go", iteration.Issues[0].FilePath)
+}
+
+func TestReviewResultEvent_Execute_MergeConflictManualReview(t *testing.T) {
+	cfg := &appconfig.WorkerConfig{
+		RebaseBeforePush:        true,
+		MergeConflictResolution: string(events.MergeConflictResolutionManualReview),
+	}
+	eventsMan := &mockEmitter{}
+
+	handler := &ReviewResultEvent{cfg: cfg, rebaser: newConflictingRebaser(), eventsMan: eventsMan}
+
+	require.NoError(t, handler.Execute(context.Background(), newApprovedReviewPayload(events.NewExecutionID())))
+
+	// Only the conflict is reported; nothing is pushed or iterated
+	require.Len(t, eventsMan.emittedEvents, 1)
+	conflict, ok := eventsMan.emittedEvents[0].payload.(*events.GitMergeConflictDetectedPayload)
+	require.True(t, ok)
+	assert.Equal(t, events.MergeConflictResolutionManualReview, conflict.Resolution)
+}
+
+func TestReviewResultEvent_Execute_RebaseFailure(t *testing.T) {
+	cfg := &appconfig.WorkerConfig{RebaseBeforePush: true}
+	eventsMan := &mockEmitter{}
+	rebaser := &mockBaseRebaser{err: errors.New("git fetch failed")}
+
+	handler := &ReviewResultEvent{cfg: cfg, rebaser: rebaser, eventsMan: eventsMan}
+
+	err := handler.Execute(context.Background(), newApprovedReviewPayload(events.NewExecutionID()))
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "rebase onto base branch")
+	assert.Empty(t, eventsMan.emittedEvents)
+}
+
 func TestReviewResultEvent_Execute_Abort(t *testing.T) {
 	cfg := &appconfig.WorkerConfig{}
 	eventsMan := &mockEmitter{}
@@ -345,7 +754,7 @@ func TestReviewResultEvent_Execute_UnknownDecision(t *testing.T) {
 // =============================================================================
 
 func TestIterationEvent_Name(t *testing.T) {
-	handler := NewIterationEvent(nil, nil, nil)
+	handler := NewIterationEvent(nil, nil, nil, nil)
 	assert.Equal(t, string(events.IterationRequired), handler.Name())
 }
 
@@ -362,7 +771,7 @@ func TestIterationEvent_Execute_Success(t *testing.T) {
 	}
 	eventsMan := &mockEmitter{}
 
-	handler := NewIterationEvent(cfg, bamlClient, eventsMan)
+	handler := NewIterationEvent(cfg, bamlClient, nil, eventsMan)
 
 	executionID := events.NewExecutionID()
 	payload := &events.FeatureIterationRequestedPayload{
@@ -408,7 +817,7 @@ func TestIterationEvent_Execute_MaxIterationsReached(t *testing.T) {
 	}
 	eventsMan := &mockEmitter{}
 
-	handler := NewIterationEvent(cfg, nil, eventsMan)
+	handler := NewIterationEvent(cfg, nil, nil, eventsMan)
 
 	payload := &events.FeatureIterationRequestedPayload{
 		ExecutionID:     events.NewExecutionID(),
@@ -434,7 +843,7 @@ func TestIterationEvent_Execute_DefaultMaxIterations(t *testing.T) {
 	}
 	eventsMan := &mockEmitter{}
 
-	handler := NewIterationEvent(cfg, nil, eventsMan)
+	handler := NewIterationEvent(cfg, nil, nil, eventsMan)
 
 	payload := &events.FeatureIterationRequestedPayload{
 		ExecutionID:     events.NewExecutionID(),
@@ -459,7 +868,7 @@ func TestIterationEvent_Execute_BAMLError(t *testing.T) {
 	}
 	eventsMan := &mockEmitter{}
 
-	handler := NewIterationEvent(cfg, bamlClient, eventsMan)
+	handler := NewIterationEvent(cfg, bamlClient, nil, eventsMan)
 
 	payload := &events.FeatureIterationRequestedPayload{
 		ExecutionID:     events.NewExecutionID(),
@@ -472,6 +881,194 @@ func TestIterationEvent_Execute_BAMLError(t *testing.T) {
 	assert.Contains(t, err.Error(), "BAML generation failed")
 }
 
+func TestIterationEvent_Execute_TokenBudgetExhausted(t *testing.T) {
+	cfg := &appconfig.WorkerConfig{
+		ReviewThresholds: events.ReviewThresholds{
+			MaxIterations: 5,
+		},
+	}
+	bamlClient := &mockBAMLClient{
+		generatePatchResponse: &GeneratePatchResponse{
+			TokensUsed: 600,
+		},
+	}
+	eventsMan := &mockEmitter{}
+	ledger := accounting.NewLedger(accounting.Budget{MaxLLMTokens: 1000})
+
+	handler := NewIterationEvent(cfg, bamlClient, ledger, eventsMan)
+
+	executionID := events.NewExecutionID()
+	payload := &events.FeatureIterationRequestedPayload{
+		ExecutionID:     executionID,
+		IterationNumber: 1,
+	}
+
+	// First iteration stays within budget
+	require.NoError(t, handler.Execute(context.Background(), payload))
+	require.Len(t, eventsMan.emittedEvents, 2)
+	assert.Equal(t, string(events.PatchGenerationCompleted), eventsMan.emittedEvents[1].name)
+
+	// Second iteration drives token usage past the budget
+	payload.IterationNumber = 2
+	require.NoError(t, handler.Execute(context.Background(), payload))
+	require.Len(t, eventsMan.emittedEvents, 4)
+	assert.Equal(t, string(events.FeatureExecutionFailed), eventsMan.emittedEvents[3].name)
+
+	failPayload, ok := eventsMan.emittedEvents[3].payload.(*events.FeatureExecutionFailedPayload)
+	require.True(t, ok)
+	assert.Equal(t, string(events.AbortReasonResourceExhausted), failPayload.ErrorCode)
+	assert.Contains(t, failPayload.ErrorMessage, "1200 LLM tokens used")
+
+	// Further iterations are refused before spending more tokens
+	payload.IterationNumber = 3
+	require.NoError(t, handler.Execute(context.Background(), payload))
+	require.Len(t, eventsMan.emittedEvents, 5)
+	assert.Equal(t, string(events.FeatureExecutionFailed), eventsMan.emittedEvents[4].name)
+
+	usage, found := ledger.Usage(executionID.String())
+	require.True(t, found)
+	assert.Equal(t, 1200, usage.LLMTokens)
+}
+
+func TestPatchGenerationEvent_RecordsModelUsed(t *testing.T) {
+	cfg := &appconfig.WorkerConfig{}
+	svc, request := checkoutGoModule(t, cfg)
+	served := events.LLMProcessingInfo{
+		Provider:     "anthropic",
+		Model:        "claude-sonnet",
+		ModelVersion: "claude-sonnet-20250101",
+		Function:     "GeneratePatch",
+	}
+	client := &scriptedBAMLClient{responses: []*GeneratePatchResponse{{
+		Patches:    []Patch{{FilePath: "calc.go", NewContent: fixedCalc, Action: events.FileActionCreate}},
+		TokensUsed: 300,
+		LLM:        served,
+	}}}
+	emitter := &mockEmitter{}
+	ledger := accounting.NewLedger(accounting.Budget{})
+	executionRepo := executions.NewMemoryExecutionRepository()
+	require.NoError(t, executionRepo.Create(context.Background(), &executions.Execution{ID: request.ExecutionID.String()}))
+
+	handler := NewPatchGenerationEvent(cfg, client, svc, ledger, emitter)
+	handler.SetExecutionRepository(executionRepo)
+	require.NoError(t, handler.Execute(context.Background(), request))
+
+	model := accounting.Model{Provider: "anthropic", Model: "claude-sonnet", Version: "claude-sonnet-20250101"}
+	usage, found := ledger.Usage(request.ExecutionID.String())
+	require.True(t, found)
+	assert.Equal(t, []accounting.ModelUsage{{Model: model, LLMTokens: 300}}, usage.Models)
+
+	execution, err := executionRepo.GetByID(context.Background(), request.ExecutionID.String())
+	require.NoError(t, err)
+	assert.Equal(t, []executions.LLMModel{
+		{Provider: "anthropic", Model: "claude-sonnet", Version: "claude-sonnet-20250101"},
+	}, execution.LLMModels)
+
+	completed := findEmitted(emitter, events.PatchGenerationCompleted)
+	require.Len(t, completed, 1)
+	payload, ok := completed[0].(*events.PatchGenerationCompletedPayload)
+	require.True(t, ok)
+	assert.Equal(t, served, payload.LLMInfo)
+}
+
+func TestPatchGenerationEvent_AttributesTokensToEachCall(t *testing.T) {
+	cfg := &appconfig.WorkerConfig{}
+	svc, request := checkoutGoModule(t, cfg)
+	planner := events.LLMProcessingInfo{Provider: "openai", Model: "gpt-4o", InputTokens: 80, OutputTokens: 20}
+	coder := events.LLMProcessingInfo{Provider: "anthropic", Model: "claude-sonnet", InputTokens: 150, OutputTokens: 50}
+	client := &scriptedBAMLClient{responses: []*GeneratePatchResponse{{
+		Patches:    []Patch{{FilePath: "calc.go", NewContent: fixedCalc, Action: events.FileActionCreate}},
+		TokensUsed: 310,
+		LLM:        coder,
+		LLMCalls:   []events.LLMProcessingInfo{planner, coder},
+	}}}
+	ledger := accounting.NewLedger(accounting.Budget{})
+	executionRepo := executions.NewMemoryExecutionRepository()
+	require.NoError(t, executionRepo.Create(context.Background(), &executions.Execution{ID: request.ExecutionID.String()}))
+
+	handler := NewPatchGenerationEvent(cfg, client, svc, ledger, &mockEmitter{})
+	handler.SetExecutionRepository(executionRepo)
+	require.NoError(t, handler.Execute(context.Background(), request))
+
+	usage, found := ledger.Usage(request.ExecutionID.String())
+	require.True(t, found)
+	// Tokens the calls do not account for are charged without a model
+	assert.Equal(t, 310, usage.LLMTokens)
+	assert.Equal(t, []accounting.ModelUsage{
+		{Model: accounting.Model{Provider: "openai", Model: "gpt-4o"}, LLMTokens: 100},
+		{Model: accounting.Model{Provider: "anthropic", Model: "claude-sonnet"}, LLMTokens: 200},
+	}, usage.Models)
+
+	execution, err := executionRepo.GetByID(context.Background(), request.ExecutionID.String())
+	require.NoError(t, err)
+	assert.Equal(t, []executions.LLMModel{
+		{Provider: "openai", Model: "gpt-4o"},
+		{Provider: "anthropic", Model: "claude-sonnet"},
+	}, execution.LLMModels)
+}
+
+func TestReviewResultEvent_RecordsReviewUsage(t *testing.T) {
+	ledger := accounting.NewLedger(accounting.Budget{})
+	executionRepo := executions.NewMemoryExecutionRepository()
+	executionID := events.NewExecutionID()
+	require.NoError(t, executionRepo.Create(context.Background(), &executions.Execution{ID: executionID.String()}))
+
+	handler := NewReviewResultEvent(&appconfig.WorkerConfig{}, nil, nil, nil, &mockEmitter{})
+	handler.SetLedger(ledger)
+	handler.SetExecutionRepository(executionRepo)
+	require.NoError(t, handler.Execute(context.Background(), &events.ComprehensiveReviewCompletedPayload{
+		ExecutionID: executionID,
+		Decision:    events.ControlDecisionAbort,
+		LLMInfo: events.LLMProcessingInfo{
+			Provider:     "anthropic",
+			Model:        "claude-haiku",
+			ModelVersion: "claude-haiku-20250101",
+			InputTokens:  70,
+			OutputTokens: 30,
+		},
+	}))
+
+	model := accounting.Model{Provider: "anthropic", Model: "claude-haiku", Version: "claude-haiku-20250101"}
+	usage, found := ledger.Usage(executionID.String())
+	require.True(t, found)
+	assert.Equal(t, []accounting.ModelUsage{{Model: model, LLMTokens: 100}}, usage.Models)
+	entries, _ := ledger.Timeline(executionID.String())
+	require.Len(t, entries, 1)
+	assert.Equal(t, "review", entries[0].Phase)
+
+	execution, err := executionRepo.GetByID(context.Background(), executionID.String())
+	require.NoError(t, err)
+	assert.Equal(t, []executions.LLMModel{
+		{Provider: "anthropic", Model: "claude-haiku", Version: "claude-haiku-20250101"},
+	}, execution.LLMModels)
+}
+
+func TestReviewRequestEvent_Execute_SandboxCPUBudgetExhausted(t *testing.T) {
+	cfg := &appconfig.WorkerConfig{
+		QueueReviewRequestName: "review-request-queue",
+	}
+	queueMan := &mockQueueManager{}
+	eventsMan := &mockEmitter{}
+	ledger := accounting.NewLedger(accounting.Budget{MaxSandboxCPUSeconds: 60})
+
+	handler := NewReviewRequestEvent(cfg, queueMan, ledger, eventsMan)
+
+	payload := &events.TestExecutionCompletedPayload{
+		ExecutionID:   events.NewExecutionID(),
+		Success:       true,
+		Result:        &events.TestResult{TotalTests: 1, PassedTests: 1, Success: true},
+		ResourceUsage: &events.SandboxResourceUsage{CPUSecondsUsed: 90},
+	}
+
+	require.NoError(t, handler.Execute(context.Background(), payload))
+
+	require.Len(t, eventsMan.emittedEvents, 1)
+	failPayload, ok := eventsMan.emittedEvents[0].payload.(*events.FeatureExecutionFailedPayload)
+	require.True(t, ok)
+	assert.Equal(t, string(events.AbortReasonResourceExhausted), failPayload.ErrorCode)
+	assert.Empty(t, queueMan.publishedMessages)
+}
+
 // =============================================================================
 // DeliveryEvent Tests
 // =============================================================================
@@ -556,7 +1153,7 @@ func TestBuildFeedbackFromReviewIssues(t *testing.T) {
 		},
 	}
 
-	feedback := buildFeedbackFromReviewIssues(issues)
+	feedback := buildFeedbackFromReviewIssues(issues, true)
 
 	assert.Contains(t, feedback, "Please fix the following issues")
 	assert.Contains(t, feedback, "[high] SQL Injection")
@@ -565,8 +1162,53 @@ func TestBuildFeedbackFromReviewIssues(t *testing.T) {
 	assert.Contains(t, feedback, "[low] Missing comment")
 }
 
+func TestBuildFeedbackFromReviewIssues_IncludesCodeContext(t *testing.T) {
+	issues := []events.ReviewIssue{
+		{
+			Severity:    events.ReviewIssueSeverityHigh,
+			FilePath:    "store/users.go",
+			LineStart:   42,
+			LineEnd:     43,
+			Title:       "SQL Injection",
+			Description: "Query built from user input",
+			Suggestion:  "Use db.Query(\"SELECT * FROM users WHERE id = $1\", id)",
+			CodeSnippet: "q := \"SELECT * FROM users WHERE id = \" + id\nrows, _ := db.Query(q)",
+		},
+		{
+			Severity:  events.ReviewIssueSeverityLow,
+			FilePath:  "main.go",
+			LineStart: 7,
+			Title:     "Missing comment",
+		},
+	}
+
+	feedback := buildFeedbackFromReviewIssues(issues, true)
+
+	assert.Contains(t, feedback, "Location: store/users.go:42-43")
+	assert.Contains(t, feedback, "Location: main.go:7")
+	assert.Contains(t, feedback, "   ```\n   q := \"SELECT * FROM users WHERE id = \" + id\n   rows, _ := db.Query(q)\n   ```")
+	assert.Contains(t, feedback, "Suggestion: Use db.Query(")
+}
+
+func TestBuildFeedbackFromReviewIssues_ExcludesCodeWhenDisabled(t *testing.T) {
+	issues := []events.ReviewIssue{
+		{
+			Severity:    events.ReviewIssueSeverityHigh,
+			FilePath:    "store/users.go",
+			LineStart:   42,
+			Title:       "SQL Injection",
+			CodeSnippet: "db.Query(q)",
+		},
+	}
+
+	feedback := buildFeedbackFromReviewIssues(issues, false)
+
+	assert.Contains(t, feedback, "Location: store/users.go:42")
+	assert.NotContains(t, feedback, "db.Query(q)")
+}
+
 func TestBuildFeedbackFromReviewIssues_Empty(t *testing.T) {
-	feedback := buildFeedbackFromReviewIssues(nil)
+	feedback := buildFeedbackFromReviewIssues(nil, true)
 	assert.Empty(t, feedback)
 }
 
@@ -760,17 +1402,17 @@ func TestPatchStats_UpdatePatchStats(t *testing.T) {
 }
 
 func TestPatchGenerationEvent_Name(t *testing.T) {
-	handler := NewPatchGenerationEvent(nil, nil, nil, nil)
+	handler := NewPatchGenerationEvent(nil, nil, nil, nil, nil)
 	assert.Equal(t, string(events.RepositoryCheckoutCompleted), handler.Name())
 }
 
 func TestPatchGenerationEvent_PayloadType(t *testing.T) {
-	handler := NewPatchGenerationEvent(nil, nil, nil, nil)
+	handler := NewPatchGenerationEvent(nil, nil, nil, nil, nil)
 	assert.IsType(t, &events.RepositoryCheckoutCompletedPayload{}, handler.PayloadType())
 }
 
 func TestPatchGenerationEvent_Execute_InvalidPayload(t *testing.T) {
-	handler := NewPatchGenerationEvent(nil, nil, nil, nil)
+	handler := NewPatchGenerationEvent(nil, nil, nil, nil, nil)
 	err := handler.Execute(context.Background(), "invalid")
 	require.Error(t, err)
 	assert.Contains(t, err.Error(), "invalid payload type")
@@ -0,0 +1,113 @@
+package events
+
+import (
+	"context"
+	"slices"
+
+	"github.com/pitabwire/util"
+
+	"github.com/antinvestor/builder/internal/events"
+)
+
+// Review findings a pull request label can be derived from.
+const (
+	prFindingSecurity       = "security"
+	prFindingBreakingChange = "breaking_change"
+	prFindingNeedsTests     = "needs_tests"
+)
+
+// defaultPRLabels are the labels applied for each finding unless overridden.
+var defaultPRLabels = map[string]string{
+	prFindingSecurity:       "security",
+	prFindingBreakingChange: "breaking-change",
+	prFindingNeedsTests:     "needs-tests",
+}
+
+// PullRequestLabeler applies labels to the pull request opened for a feature branch
+// through the repository provider's API.
+type PullRequestLabeler interface {
+	LabelPullRequest(
+		ctx context.Context,
+		repository events.RepositoryContext,
+		branchName string,
+		labels []string,
+	) error
+}
+
+// reviewFindings returns the findings of a review that warrant a label.
+func reviewFindings(request *events.ComprehensiveReviewCompletedPayload) []string {
+	var findings []string
+
+	security := request.SecurityAssessment
+	if len(security.VulnerabilitiesFound) > 0 || len(security.SecretsDetected) > 0 ||
+		len(security.InsecurePatterns) > 0 || len(security.SecurityRegressions) > 0 ||
+		security.SecurityStatus == events.SecurityStatusCritical ||
+		security.SecurityStatus == events.SecurityStatusBlocked {
+		findings = append(findings, prFindingSecurity)
+	}
+
+	if len(request.ArchitectureAssessment.BreakingChanges) > 0 ||
+		hasRiskFactor(request.RiskAssessment, events.RiskCategoryBreakingChange) {
+		findings = append(findings, prFindingBreakingChange)
+	}
+
+	if len(request.ArchitectureAssessment.TestRegressions) > 0 ||
+		hasRiskFactor(request.RiskAssessment, events.RiskCategoryTestCoverage) {
+		findings = append(findings, prFindingNeedsTests)
+	}
+
+	return findings
+}
+
+func hasRiskFactor(risk events.RiskAssessment, category events.RiskCategory) bool {
+	return slices.ContainsFunc(risk.RiskFactors, func(f events.RiskFactor) bool {
+		return f.Category == category
+	})
+}
+
+// derivePRLabels maps the findings of a review to pull request labels, sorted.
+// The mapping overrides the default label of a finding; an empty label drops it.
+func derivePRLabels(request *events.ComprehensiveReviewCompletedPayload, mapping map[string]string) []string {
+	var labels []string
+	for _, finding := range reviewFindings(request) {
+		label, ok := mapping[finding]
+		if !ok {
+			label = defaultPRLabels[finding]
+		}
+		if label != "" && !slices.Contains(labels, label) {
+			labels = append(labels, label)
+		}
+	}
+	slices.Sort(labels)
+	return labels
+}
+
+// labelPullRequest derives the labels of a delivered branch and applies them when a
+// labeler is set. Labelling is best effort: a provider failure is logged and does
+// not fail the delivery.
+func (h *ReviewResultEvent) labelPullRequest(
+	ctx context.Context,
+	request *events.ComprehensiveReviewCompletedPayload,
+	branchName string,
+) []string {
+	if h.cfg == nil || !h.cfg.PRLabelsEnabled {
+		return nil
+	}
+	labels := derivePRLabels(request, h.cfg.PRLabelMapping)
+	if len(labels) == 0 || h.labeler == nil {
+		return labels
+	}
+
+	var repository events.RepositoryContext
+	if execCtx, ok := h.execContexts.Get(request.ExecutionID); ok {
+		repository = execCtx.Repository
+	}
+	if err := h.labeler.LabelPullRequest(ctx, repository, branchName, labels); err != nil {
+		util.Log(ctx).WithError(err).Warn("could not label pull request",
+			"execution_id", request.ExecutionID.String(),
+			"branch_name", branchName,
+			"labels", labels,
+		)
+	}
+	return labels
+}
@@ -0,0 +1,132 @@
+//nolint:testpackage // white-box testing requires internal package access
+package events
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	appconfig "github.com/antinvestor/builder/apps/worker/config"
+	"github.com/antinvestor/builder/internal/events"
+)
+
+type recordingLabeler struct {
+	branchName string
+	repository events.RepositoryContext
+	labels     []string
+	err        error
+}
+
+func (l *recordingLabeler) LabelPullRequest(
+	_ context.Context,
+	repository events.RepositoryContext,
+	branchName string,
+	labels []string,
+) error {
+	l.repository = repository
+	l.branchName = branchName
+	l.labels = labels
+	return l.err
+}
+
+func breakingChangeReview(execID events.ExecutionID) *events.ComprehensiveReviewCompletedPayload {
+	return &events.ComprehensiveReviewCompletedPayload{
+		ExecutionID:       execID,
+		Decision:          events.ControlDecisionApproveWithWarnings,
+		DecisionRationale: "Approved with a breaking change",
+		ArchitectureAssessment: events.ArchitectureAssessment{
+			BreakingChanges: []events.BreakingChange{{
+				ChangeType:  events.BreakingChangeRemovedAPI,
+				Description: "Removed exported function Add",
+			}},
+		},
+	}
+}
+
+func TestDerivePRLabels_BreakingChange(t *testing.T) {
+	labels := derivePRLabels(breakingChangeReview(events.NewExecutionID()), nil)
+	assert.Equal(t, []string{"breaking-change"}, labels)
+}
+
+func TestDerivePRLabels_AllFindings(t *testing.T) {
+	review := breakingChangeReview(events.NewExecutionID())
+	review.SecurityAssessment.SecretsDetected = []events.SecretFinding{{Type: "aws_access_key"}}
+	review.RiskAssessment.RiskFactors = []events.RiskFactor{{Category: events.RiskCategoryTestCoverage}}
+
+	labels := derivePRLabels(review, nil)
+	assert.Equal(t, []string{"breaking-change", "needs-tests", "security"}, labels)
+}
+
+func TestDerivePRLabels_MappingOverrides(t *testing.T) {
+	review := breakingChangeReview(events.NewExecutionID())
+	review.ArchitectureAssessment.TestRegressions = []events.TestRegression{{}}
+
+	labels := derivePRLabels(review, map[string]string{
+		"breaking_change": "semver-major",
+		"needs_tests":     "",
+	})
+	assert.Equal(t, []string{"semver-major"}, labels)
+}
+
+func TestDerivePRLabels_CleanReview(t *testing.T) {
+	review := &events.ComprehensiveReviewCompletedPayload{
+		SecurityAssessment: events.SecurityAssessment{SecurityStatus: events.SecurityStatusSecure},
+	}
+	assert.Empty(t, derivePRLabels(review, nil))
+}
+
+func TestReviewResultEvent_ApprovalLabelsPullRequest(t *testing.T) {
+	cfg := &appconfig.WorkerConfig{PRLabelsEnabled: true}
+	svc, request := checkoutGoModule(t, cfg)
+	runTestGit(t, request.WorkspacePath, "checkout", "-q", "-b", fmt.Sprintf("feature/%s", request.ExecutionID))
+
+	store := NewExecutionContextStore()
+	repo := events.RepositoryContext{RemoteURL: request.RepositoryURL}
+	store.Start(cfg, &events.FeatureExecutionInitializedPayload{ExecutionID: request.ExecutionID, Repository: repo})
+
+	emitter := &mockEmitter{}
+	labeler := &recordingLabeler{}
+	handler := NewReviewResultEvent(cfg, svc, nil, nil, emitter)
+	handler.SetExecutionContexts(store)
+	handler.SetPullRequestLabeler(labeler)
+
+	require.NoError(t, handler.Execute(context.Background(), breakingChangeReview(request.ExecutionID)))
+
+	branchName := fmt.Sprintf("feature/%s", request.ExecutionID)
+	assert.Equal(t, branchName, labeler.branchName)
+	assert.Equal(t, repo, labeler.repository)
+	assert.Equal(t, []string{"breaking-change"}, labeler.labels)
+
+	delivered := findEmitted(emitter, events.FeatureDelivered)
+	require.Len(t, delivered, 1)
+	payload, ok := delivered[0].(*events.FeatureDeliveredPayload)
+	require.True(t, ok)
+	assert.Equal(t, []string{"breaking-change"}, payload.Labels)
+}
+
+func TestReviewResultEvent_LabelFailureDoesNotFailDelivery(t *testing.T) {
+	cfg := &appconfig.WorkerConfig{PRLabelsEnabled: true}
+	svc, request := checkoutGoModule(t, cfg)
+	runTestGit(t, request.WorkspacePath, "checkout", "-q", "-b", fmt.Sprintf("feature/%s", request.ExecutionID))
+
+	emitter := &mockEmitter{}
+	handler := NewReviewResultEvent(cfg, svc, nil, nil, emitter)
+	handler.SetPullRequestLabeler(&recordingLabeler{err: errors.New("provider unavailable")})
+
+	require.NoError(t, handler.Execute(context.Background(), breakingChangeReview(request.ExecutionID)))
+	assert.Len(t, findEmitted(emitter, events.FeatureDelivered), 1)
+}
+
+func TestReviewResultEvent_LabelsDisabled(t *testing.T) {
+	handler := NewReviewResultEvent(&appconfig.WorkerConfig{}, nil, nil, nil, &mockEmitter{})
+	labeler := &recordingLabeler{}
+	handler.SetPullRequestLabeler(labeler)
+
+	labels := handler.labelPullRequest(context.Background(), breakingChangeReview(events.NewExecutionID()), "feature/x")
+	assert.Empty(t, labels)
+	assert.Nil(t, labeler.labels)
+}
@@ -0,0 +1,78 @@
+package events
+
+import (
+	"fmt"
+
+	"github.com/antinvestor/builder/internal/events"
+)
+
+// workspacePhases are the phases that run on a checked-out workspace, which a
+// resumed execution can pick up from without starting over.
+var workspacePhases = map[events.ExecutionPhase]bool{
+	events.ExecutionPhaseGeneration:   true,
+	events.ExecutionPhaseVerification: true,
+	events.ExecutionPhaseDelivery:     true,
+}
+
+// failureRecovery derives the recovery options offered for a failure from its
+// classification, the phase it failed in and whether the execution had iterations
+// left, so the same kind of failure always offers the same options. Recovery
+// describes what a user can do next; Classification.Retryable is whether the
+// worker restarts the execution on its own.
+func failureRecovery(
+	classification events.FailureClassification,
+	phase events.ExecutionPhase,
+	iterationsLeft bool,
+) events.RecoveryInfo {
+	switch classification.Type {
+	case events.FailureTypeTransient:
+		if workspacePhases[phase] {
+			return events.RecoveryInfo{
+				CanRetry:             true,
+				CanResume:            true,
+				RecoveryInstructions: fmt.Sprintf("The failure was transient; resume from the %s phase or retry", phase),
+			}
+		}
+		return events.RecoveryInfo{
+			CanRetry:             true,
+			RecoveryInstructions: "The failure was transient; retry the execution",
+		}
+
+	case events.FailureTypeInfrastructure:
+		// The workspace may be gone, so the execution starts over from its request
+		return events.RecoveryInfo{
+			CanRetry:             true,
+			RecoveryInstructions: "Infrastructure failure; retry the execution from its original request",
+		}
+
+	case events.FailureTypeSemantic:
+		if !iterationsLeft {
+			return events.RecoveryInfo{
+				RecoveryInstructions: "No iterations left; refine the feature specification and submit it again",
+			}
+		}
+		return events.RecoveryInfo{
+			CanRetry:             true,
+			RecoveryInstructions: "Retry the execution, refining the feature specification if it fails again",
+		}
+
+	case events.FailureTypeDeterministic:
+	}
+
+	// Deterministic failures recur on retry until their cause is fixed
+	if classification.UserActionable {
+		return events.RecoveryInfo{
+			RecoveryInstructions: "Fix the request or the limits it exceeded and submit it again",
+		}
+	}
+	return events.RecoveryInfo{RecoveryInstructions: "Contact an operator; retrying will fail the same way"}
+}
+
+// hasIterationsLeft reports whether an execution could iterate again after its
+// current iteration. Without a context or a limit it is assumed to have some left.
+func hasIterationsLeft(execCtx *ExecutionContext, ok bool) bool {
+	if !ok || execCtx.Config.MaxIterations <= 0 {
+		return true
+	}
+	return execCtx.IterationNumber+1 < execCtx.Config.MaxIterations
+}
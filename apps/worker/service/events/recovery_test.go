@@ -0,0 +1,167 @@
+//nolint:testpackage // white-box testing requires internal package access
+package events
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	appconfig "github.com/antinvestor/builder/apps/worker/config"
+	"github.com/antinvestor/builder/internal/events"
+)
+
+func TestFailureRecovery(t *testing.T) {
+	tests := []struct {
+		name           string
+		failureType    events.FailureType
+		phase          events.ExecutionPhase
+		iterationsLeft bool
+		wantRetry      bool
+		wantResume     bool
+	}{
+		{
+			name:           "transient during generation resumes",
+			failureType:    events.FailureTypeTransient,
+			phase:          events.ExecutionPhaseGeneration,
+			iterationsLeft: true,
+			wantRetry:      true,
+			wantResume:     true,
+		},
+		{
+			name:           "transient during checkout retries",
+			failureType:    events.FailureTypeTransient,
+			phase:          events.ExecutionPhaseCheckout,
+			iterationsLeft: true,
+			wantRetry:      true,
+			wantResume:     false,
+		},
+		{
+			name:           "infrastructure restarts",
+			failureType:    events.FailureTypeInfrastructure,
+			phase:          events.ExecutionPhaseVerification,
+			iterationsLeft: true,
+			wantRetry:      true,
+			wantResume:     false,
+		},
+		{
+			name:           "semantic with iterations left",
+			failureType:    events.FailureTypeSemantic,
+			phase:          events.ExecutionPhaseVerification,
+			iterationsLeft: true,
+			wantRetry:      true,
+			wantResume:     false,
+		},
+		{
+			name:           "semantic out of iterations",
+			failureType:    events.FailureTypeSemantic,
+			phase:          events.ExecutionPhaseGeneration,
+			iterationsLeft: false,
+			wantRetry:      false,
+			wantResume:     false,
+		},
+		{
+			name:           "deterministic never retries",
+			failureType:    events.FailureTypeDeterministic,
+			phase:          events.ExecutionPhaseGeneration,
+			iterationsLeft: true,
+			wantRetry:      false,
+			wantResume:     false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recovery := failureRecovery(events.FailureClassification{Type: tt.failureType}, tt.phase, tt.iterationsLeft)
+			assert.Equal(t, tt.wantRetry, recovery.CanRetry)
+			assert.Equal(t, tt.wantResume, recovery.CanResume)
+			assert.NotEmpty(t, recovery.RecoveryInstructions)
+		})
+	}
+}
+
+func emittedFailure(t *testing.T, emitter *mockEmitter) *events.FeatureExecutionFailedPayload {
+	t.Helper()
+	failed := findEmitted(emitter, events.FeatureExecutionFailed)
+	require.Len(t, failed, 1)
+	payload, ok := failed[0].(*events.FeatureExecutionFailedPayload)
+	require.True(t, ok)
+	return payload
+}
+
+func TestReviewResultEvent_AbortRecoveryFollowsIterations(t *testing.T) {
+	cfg := &appconfig.WorkerConfig{}
+	cfg.ReviewThresholds.MaxIterations = 3
+
+	tests := []struct {
+		name      string
+		iteration int
+		wantRetry bool
+	}{
+		{"iterations left", 1, true},
+		{"last iteration", 2, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := NewExecutionContextStore()
+			execID := events.NewExecutionID()
+			store.Start(cfg, &events.FeatureExecutionInitializedPayload{ExecutionID: execID})
+			store.Update(execID, func(execCtx *ExecutionContext) { execCtx.IterationNumber = tt.iteration })
+
+			emitter := &mockEmitter{}
+			handler := NewReviewResultEvent(cfg, nil, nil, nil, emitter)
+			handler.SetExecutionContexts(store)
+			require.NoError(t, handler.Execute(context.Background(), &events.ComprehensiveReviewCompletedPayload{
+				ExecutionID:       execID,
+				Decision:          events.ControlDecisionAbort,
+				DecisionRationale: "Critical security issue",
+			}))
+
+			failure := emittedFailure(t, emitter)
+			assert.Equal(t, events.FailureTypeSemantic, failure.Classification.Type)
+			assert.Equal(t, events.ExecutionPhaseVerification, failure.FailedPhase)
+			assert.Equal(t, tt.wantRetry, failure.Recovery.CanRetry)
+			assert.False(t, failure.Recovery.CanResume)
+		})
+	}
+}
+
+func TestIterationEvent_MaxIterationsCannotRetry(t *testing.T) {
+	cfg := &appconfig.WorkerConfig{}
+	cfg.ReviewThresholds.MaxIterations = 3
+	emitter := &mockEmitter{}
+
+	handler := NewIterationEvent(cfg, nil, nil, emitter)
+	require.NoError(t, handler.Execute(context.Background(), &events.FeatureIterationRequestedPayload{
+		ExecutionID:     events.NewExecutionID(),
+		IterationNumber: 3,
+	}))
+
+	failure := emittedFailure(t, emitter)
+	assert.False(t, failure.Recovery.CanRetry)
+	assert.False(t, failure.Recovery.CanResume)
+	assert.Contains(t, failure.Recovery.RecoveryInstructions, "No iterations left")
+}
+
+func TestEmittedFailures_RecoveryMatchesClassification(t *testing.T) {
+	execID := events.NewExecutionID()
+
+	infra := &mockEmitter{}
+	require.NoError(t, emitInfrastructureFailure(context.Background(), infra, execID,
+		events.ExecutionPhaseCheckout, string(events.CheckoutErrorNetwork), errors.New("connection reset")))
+	failure := emittedFailure(t, infra)
+	assert.True(t, failure.Classification.Retryable)
+	assert.True(t, failure.Recovery.CanRetry)
+	assert.False(t, failure.Recovery.CanResume)
+
+	exhausted := &mockEmitter{}
+	require.NoError(t, emitResourceExhausted(context.Background(), exhausted, execID,
+		events.ExecutionPhaseGeneration, errors.New("token budget exhausted")))
+	failure = emittedFailure(t, exhausted)
+	assert.Equal(t, events.FailureTypeDeterministic, failure.Classification.Type)
+	assert.False(t, failure.Recovery.CanRetry)
+	assert.False(t, failure.Recovery.CanResume)
+}
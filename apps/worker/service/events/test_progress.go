@@ -0,0 +1,58 @@
+package events
+
+import (
+	"context"
+	"errors"
+
+	"github.com/pitabwire/util"
+
+	"github.com/antinvestor/builder/internal/events"
+)
+
+// TestProgressEvent records the progress the executor reports while tests run, so
+// a long test run is visible before its TestExecutionCompleted result arrives.
+type TestProgressEvent struct {
+	execContexts *ExecutionContextStore
+}
+
+// NewTestProgressEvent creates a handler keeping the latest progress on each execution's context.
+func NewTestProgressEvent(execContexts *ExecutionContextStore) *TestProgressEvent {
+	return &TestProgressEvent{execContexts: execContexts}
+}
+
+// Name returns the event name.
+func (h *TestProgressEvent) Name() string {
+	return string(events.TestExecutionProgress)
+}
+
+// PayloadType returns the expected payload type.
+func (h *TestProgressEvent) PayloadType() any {
+	return &events.TestProgressPayload{}
+}
+
+// Validate validates the payload.
+func (h *TestProgressEvent) Validate(_ context.Context, _ any) error {
+	return nil
+}
+
+// Execute stores the progress on the execution's context.
+func (h *TestProgressEvent) Execute(ctx context.Context, payload any) error {
+	progress, ok := payload.(*events.TestProgressPayload)
+	if !ok {
+		return errors.New("invalid payload type: expected *TestProgressPayload")
+	}
+
+	util.Log(ctx).Info("test run progress",
+		"execution_id", progress.ExecutionID.String(),
+		"tests_completed", progress.TestsCompleted,
+		"tests_failed", progress.TestsFailed,
+	)
+
+	h.execContexts.Update(progress.ExecutionID, func(execCtx *ExecutionContext) {
+		// Events can arrive out of order; never move progress backwards
+		if execCtx.TestProgress == nil || progress.TestsCompleted >= execCtx.TestProgress.TestsCompleted {
+			execCtx.TestProgress = progress
+		}
+	})
+	return nil
+}
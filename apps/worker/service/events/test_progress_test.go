@@ -0,0 +1,33 @@
+//nolint:testpackage // white-box testing requires internal package access
+package events
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	appconfig "github.com/antinvestor/builder/apps/worker/config"
+	"github.com/antinvestor/builder/internal/events"
+)
+
+func TestTestProgressEvent_RecordsLatestProgress(t *testing.T) {
+	store := NewExecutionContextStore()
+	execID := events.NewExecutionID()
+	store.Start(&appconfig.WorkerConfig{}, &events.FeatureExecutionInitializedPayload{ExecutionID: execID})
+	handler := NewTestProgressEvent(store)
+	ctx := context.Background()
+
+	require.NoError(t, handler.Execute(ctx, &events.TestProgressPayload{ExecutionID: execID, TestsCompleted: 4}))
+	// A late, older report does not replace newer progress
+	require.NoError(t, handler.Execute(ctx, &events.TestProgressPayload{ExecutionID: execID, TestsCompleted: 2}))
+
+	execCtx, ok := store.Get(execID)
+	require.True(t, ok)
+	require.NotNil(t, execCtx.TestProgress)
+	assert.Equal(t, 4, execCtx.TestProgress.TestsCompleted)
+
+	// Progress of an execution without a context is ignored
+	require.NoError(t, handler.Execute(ctx, &events.TestProgressPayload{ExecutionID: events.NewExecutionID()}))
+}
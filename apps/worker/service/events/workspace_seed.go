@@ -0,0 +1,65 @@
+package events
+
+import (
+	"context"
+	"path/filepath"
+	"time"
+
+	"github.com/pitabwire/util"
+
+	"github.com/antinvestor/builder/internal/events"
+)
+
+// seedDirectories returns the seed template directories for a feature, in the
+// order they are applied: the category seed, then the repository seed.
+func seedDirectories(root string, request *events.FeatureExecutionInitializedPayload) []string {
+	if root == "" {
+		return nil
+	}
+	var dirs []string
+	if category := string(request.Spec.Category); category != "" && filepath.IsLocal(category) {
+		dirs = append(dirs, filepath.Join(root, "categories", category))
+	}
+	if repositoryID := request.Repository.RepositoryID; repositoryID != "" && filepath.IsLocal(repositoryID) {
+		dirs = append(dirs, filepath.Join(root, "repositories", repositoryID))
+	}
+	return dirs
+}
+
+// seedWorkspace copies the configured seed files into a checked-out workspace. A
+// failure is reported as a checkout failure, since generation would run without
+// the scaffolding the feature expects.
+func (h *RepositoryCheckoutEvent) seedWorkspace(
+	ctx context.Context,
+	request *events.FeatureExecutionInitializedPayload,
+) ([]string, error) {
+	if h.cfg == nil {
+		return nil, nil
+	}
+	dirs := seedDirectories(h.cfg.SeedTemplatesPath, request)
+	if len(dirs) == 0 {
+		return nil, nil
+	}
+
+	seeded, err := h.repoService.SeedWorkspace(ctx, request.ExecutionID, dirs)
+	if err != nil {
+		if emitErr := h.eventsMan.Emit(ctx, string(events.RepositoryCheckoutFailed),
+			&events.RepositoryCheckoutFailedPayload{
+				ErrorCode:    events.CheckoutErrorSeed,
+				ErrorMessage: err.Error(),
+				Retryable:    false,
+				FailedAt:     time.Now(),
+			}); emitErr != nil {
+			util.Log(ctx).Warn("failed to emit checkout failure event", "error", emitErr)
+		}
+		return nil, err
+	}
+
+	if len(seeded) > 0 {
+		util.Log(ctx).Info("seeded workspace with template files",
+			"execution_id", request.ExecutionID.String(),
+			"seed_files", seeded,
+		)
+	}
+	return seeded, nil
+}
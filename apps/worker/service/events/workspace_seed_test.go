@@ -0,0 +1,143 @@
+//nolint:testpackage // white-box testing requires internal package access
+package events
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	appconfig "github.com/antinvestor/builder/apps/worker/config"
+	"github.com/antinvestor/builder/internal/events"
+)
+
+// seedCheckingBAMLClient records which seed files were in the workspace when
+// generation started.
+type seedCheckingBAMLClient struct {
+	scriptedBAMLClient
+	seedFiles []string
+	present   map[string]bool
+}
+
+func (c *seedCheckingBAMLClient) GeneratePatch(
+	ctx context.Context,
+	req *GeneratePatchRequest,
+) (*GeneratePatchResponse, error) {
+	c.present = make(map[string]bool)
+	for _, file := range c.seedFiles {
+		_, err := os.Stat(filepath.Join(req.WorkspacePath, file))
+		c.present[file] = err == nil
+	}
+	return c.scriptedBAMLClient.GeneratePatch(ctx, req)
+}
+
+func writeSeedFile(t *testing.T, root, rel, content string) {
+	t.Helper()
+	path := filepath.Join(root, rel)
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o750))
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+}
+
+func TestSeedDirectories(t *testing.T) {
+	request := &events.FeatureExecutionInitializedPayload{
+		Spec:       events.FeatureSpecification{Category: events.FeatureCategoryTest},
+		Repository: events.RepositoryContext{RepositoryID: "repo-1"},
+	}
+	assert.Equal(t, []string{
+		filepath.Join("/seeds", "categories", "test"),
+		filepath.Join("/seeds", "repositories", "repo-1"),
+	}, seedDirectories("/seeds", request))
+
+	assert.Nil(t, seedDirectories("", request))
+
+	request.Repository.RepositoryID = "../escape"
+	assert.Equal(t, []string{filepath.Join("/seeds", "categories", "test")}, seedDirectories("/seeds", request))
+}
+
+func TestRepositoryCheckoutEvent_SeedFilesCommittedWithFeature(t *testing.T) {
+	seeds := t.TempDir()
+	writeSeedFile(t, seeds, "categories/test/testdata/harness.go", "package testdata\n")
+	writeSeedFile(t, seeds, "categories/test/README.seed", "category seed\n")
+	writeSeedFile(t, seeds, "repositories/repo-1/README.seed", "repository seed\n")
+
+	cfg := &appconfig.WorkerConfig{SeedTemplatesPath: seeds}
+	svc, base := checkoutGoModule(t, cfg)
+	emitter := &mockEmitter{}
+
+	checkout := NewRepositoryCheckoutEvent(cfg, svc, emitter)
+	execID := events.NewExecutionID()
+	require.NoError(t, checkout.Execute(context.Background(), &events.FeatureExecutionInitializedPayload{
+		ExecutionID: execID,
+		Spec:        events.FeatureSpecification{Title: "Add calculator", Category: events.FeatureCategoryTest},
+		Repository: events.RepositoryContext{
+			RepositoryID: "repo-1",
+			RemoteURL:    base.RepositoryURL,
+			TargetBranch: "main",
+		},
+	}))
+
+	checkedOut := findEmitted(emitter, events.RepositoryCheckoutCompleted)
+	require.Len(t, checkedOut, 1)
+	completed, ok := checkedOut[0].(*events.RepositoryCheckoutCompletedPayload)
+	require.True(t, ok)
+	seedFiles := []string{"README.seed", "testdata/harness.go"}
+	assert.Equal(t, seedFiles, completed.SeedFiles)
+
+	// The repository seed wins over the category seed
+	readme, err := os.ReadFile(filepath.Join(completed.WorkspacePath, "README.seed"))
+	require.NoError(t, err)
+	assert.Equal(t, "repository seed\n", string(readme))
+
+	client := &seedCheckingBAMLClient{
+		scriptedBAMLClient: scriptedBAMLClient{responses: []*GeneratePatchResponse{calcPatch(fixedCalc)}},
+		seedFiles:          seedFiles,
+	}
+	generation := NewPatchGenerationEvent(cfg, client, svc, nil, emitter)
+	require.NoError(t, generation.Execute(context.Background(), completed))
+
+	for _, file := range seedFiles {
+		assert.True(t, client.present[file], "%s should be present before generation", file)
+	}
+
+	show := exec.Command("git", "show", "--name-only", "--format=", "HEAD")
+	show.Dir = completed.WorkspacePath
+	output, err := show.Output()
+	require.NoError(t, err)
+	committed := strings.Fields(string(output))
+	assert.ElementsMatch(t, append([]string{"calc.go"}, seedFiles...), committed)
+}
+
+func TestRepositoryCheckoutEvent_SeedKeepsExistingFiles(t *testing.T) {
+	seeds := t.TempDir()
+	writeSeedFile(t, seeds, "repositories/repo-1/go.mod", "module example.com/seeded\n")
+
+	cfg := &appconfig.WorkerConfig{SeedTemplatesPath: seeds}
+	svc, base := checkoutGoModule(t, cfg)
+	emitter := &mockEmitter{}
+
+	checkout := NewRepositoryCheckoutEvent(cfg, svc, emitter)
+	require.NoError(t, checkout.Execute(context.Background(), &events.FeatureExecutionInitializedPayload{
+		ExecutionID: events.NewExecutionID(),
+		Spec:        events.FeatureSpecification{Title: "Add calculator"},
+		Repository: events.RepositoryContext{
+			RepositoryID: "repo-1",
+			RemoteURL:    base.RepositoryURL,
+			TargetBranch: "main",
+		},
+	}))
+
+	checkedOut := findEmitted(emitter, events.RepositoryCheckoutCompleted)
+	require.Len(t, checkedOut, 1)
+	completed, ok := checkedOut[0].(*events.RepositoryCheckoutCompletedPayload)
+	require.True(t, ok)
+	assert.Empty(t, completed.SeedFiles)
+
+	goMod, err := os.ReadFile(filepath.Join(completed.WorkspacePath, "go.mod"))
+	require.NoError(t, err)
+	assert.Equal(t, "module example.com/calc\n\ngo 1.21\n", string(goMod))
+}
@@ -0,0 +1,37 @@
+package flakiness
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/pitabwire/util"
+)
+
+// ReportResponse is the body of GET /api/v1/tests/flaky.
+type ReportResponse struct {
+	Tests []Stats `json:"tests"`
+}
+
+// NewReportHTTPHandler returns the handler for GET /api/v1/tests/flaky listing
+// the tests currently classified as flaky.
+func NewReportHTTPHandler(tracker *Tracker) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		report, err := tracker.Report(r.Context())
+		if err != nil {
+			util.Log(r.Context()).WithError(err).Error("failed to build flaky test report")
+			http.Error(w, "failed to build report", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		if encodeErr := json.NewEncoder(w).Encode(ReportResponse{Tests: report}); encodeErr != nil {
+			util.Log(r.Context()).WithError(encodeErr).Error("failed to encode flaky test report")
+		}
+	}
+}
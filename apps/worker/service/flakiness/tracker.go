@@ -0,0 +1,261 @@
+// Package flakiness records the pass/fail history of individual tests across
+// executions, classifies chronically flaky tests and quarantines them so their
+// failures no longer block a run.
+package flakiness
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	appconfig "github.com/antinvestor/builder/apps/worker/config"
+	"github.com/antinvestor/builder/internal/events"
+)
+
+const (
+	statusPassed = "passed"
+	statusFailed = "failed"
+)
+
+// Policy decides when a test is flaky and whether flaky tests are quarantined.
+type Policy struct {
+	// QuarantineEnabled excludes failures of flaky tests from blocking a run.
+	QuarantineEnabled bool
+	// Window is how many of a test's most recent runs are considered (0 = all).
+	Window int
+	// MinRuns is the number of runs required before a test can be classified.
+	MinRuns int
+	// MinFailureRate and MaxFailureRate bound the failure rate of a flaky test.
+	MinFailureRate float64
+	MaxFailureRate float64
+}
+
+// PolicyFromConfig builds the flakiness policy from worker configuration.
+func PolicyFromConfig(cfg *appconfig.WorkerConfig) Policy {
+	return Policy{
+		QuarantineEnabled: cfg.FlakyTestQuarantineEnabled,
+		Window:            cfg.FlakyTestWindow,
+		MinRuns:           cfg.FlakyTestMinRuns,
+		MinFailureRate:    cfg.FlakyTestMinFailureRate,
+		MaxFailureRate:    cfg.FlakyTestMaxFailureRate,
+	}
+}
+
+// Run is the outcome of one test in one execution.
+type Run struct {
+	ExecutionID string    `json:"execution_id"`
+	Passed      bool      `json:"passed"`
+	RecordedAt  time.Time `json:"recorded_at"`
+}
+
+// Store persists the run history of each test, keyed by test name.
+type Store interface {
+	// Append records a run and keeps at most limit runs per test (0 = unlimited).
+	Append(ctx context.Context, testName string, run Run, limit int) error
+	// Runs returns a test's recorded runs, oldest first.
+	Runs(ctx context.Context, testName string) ([]Run, error)
+	// TestNames returns the names of all tests with recorded runs.
+	TestNames(ctx context.Context) ([]string, error)
+}
+
+// Stats summarises a test's recent history.
+type Stats struct {
+	Name         string    `json:"name"`
+	Runs         int       `json:"runs"`
+	Failures     int       `json:"failures"`
+	FailureRate  float64   `json:"failure_rate"`
+	Flaky        bool      `json:"flaky"`
+	Quarantined  bool      `json:"quarantined"`
+	LastFailedAt time.Time `json:"last_failed_at,omitzero"`
+}
+
+// Evaluation splits the failures of a run into blocking and quarantined tests.
+type Evaluation struct {
+	BlockingFailures    []string
+	QuarantinedFailures []string
+	// UnattributedFailures counts failures not reported as individual test cases.
+	UnattributedFailures int
+}
+
+// OnlyQuarantinedFailures reports whether every failure of the run was quarantined.
+func (e *Evaluation) OnlyQuarantinedFailures() bool {
+	return len(e.QuarantinedFailures) > 0 && len(e.BlockingFailures) == 0 && e.UnattributedFailures == 0
+}
+
+// Tracker records test outcomes and classifies flaky tests by policy.
+type Tracker struct {
+	policy Policy
+	store  Store
+	now    func() time.Time
+}
+
+// NewTracker creates a tracker keeping its history in store.
+func NewTracker(policy Policy, store Store) *Tracker {
+	return &Tracker{
+		policy: policy,
+		store:  store,
+		now:    time.Now,
+	}
+}
+
+// Record adds the passed and failed test cases of a run to their history.
+// Skipped tests are not recorded.
+func (t *Tracker) Record(ctx context.Context, executionID string, result *events.TestResult) error {
+	if result == nil {
+		return nil
+	}
+
+	recordedAt := t.now()
+	for _, tc := range result.TestCases {
+		if tc.Status != statusPassed && tc.Status != statusFailed {
+			continue
+		}
+		run := Run{ExecutionID: executionID, Passed: tc.Status == statusPassed, RecordedAt: recordedAt}
+		if err := t.store.Append(ctx, TestName(tc), run, t.policy.Window); err != nil {
+			return fmt.Errorf("record test run %s: %w", TestName(tc), err)
+		}
+	}
+	return nil
+}
+
+// Evaluate classifies the failed test cases of a run against their history.
+func (t *Tracker) Evaluate(ctx context.Context, result *events.TestResult) (*Evaluation, error) {
+	evaluation := &Evaluation{}
+	if result == nil {
+		return evaluation, nil
+	}
+
+	failedCases := 0
+	for _, tc := range result.TestCases {
+		if tc.Status != statusFailed {
+			continue
+		}
+		failedCases++
+
+		stats, err := t.Stats(ctx, TestName(tc))
+		if err != nil {
+			return nil, err
+		}
+		if stats.Quarantined {
+			evaluation.QuarantinedFailures = append(evaluation.QuarantinedFailures, stats.Name)
+		} else {
+			evaluation.BlockingFailures = append(evaluation.BlockingFailures, stats.Name)
+		}
+	}
+	evaluation.UnattributedFailures = max(result.FailedTests-failedCases, 0)
+
+	return evaluation, nil
+}
+
+// Stats summarises a test's history within the policy window.
+func (t *Tracker) Stats(ctx context.Context, testName string) (Stats, error) {
+	runs, err := t.store.Runs(ctx, testName)
+	if err != nil {
+		return Stats{}, fmt.Errorf("load test history %s: %w", testName, err)
+	}
+	if t.policy.Window > 0 && len(runs) > t.policy.Window {
+		runs = runs[len(runs)-t.policy.Window:]
+	}
+
+	stats := Stats{Name: testName, Runs: len(runs)}
+	for _, run := range runs {
+		if !run.Passed {
+			stats.Failures++
+			stats.LastFailedAt = run.RecordedAt
+		}
+	}
+	if stats.Runs == 0 {
+		return stats, nil
+	}
+
+	stats.FailureRate = float64(stats.Failures) / float64(stats.Runs)
+	stats.Flaky = stats.Runs >= t.policy.MinRuns &&
+		stats.Failures > 0 && stats.Failures < stats.Runs &&
+		stats.FailureRate >= t.policy.MinFailureRate &&
+		(t.policy.MaxFailureRate <= 0 || stats.FailureRate <= t.policy.MaxFailureRate)
+	stats.Quarantined = stats.Flaky && t.policy.QuarantineEnabled
+
+	return stats, nil
+}
+
+// Report returns the tests currently classified as flaky, most failing first.
+func (t *Tracker) Report(ctx context.Context) ([]Stats, error) {
+	names, err := t.store.TestNames(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list tests: %w", err)
+	}
+
+	report := []Stats{}
+	for _, name := range names {
+		stats, statsErr := t.Stats(ctx, name)
+		if statsErr != nil {
+			return nil, statsErr
+		}
+		if stats.Flaky {
+			report = append(report, stats)
+		}
+	}
+
+	slices.SortFunc(report, func(a, b Stats) int {
+		if byRate := cmp.Compare(b.FailureRate, a.FailureRate); byRate != 0 {
+			return byRate
+		}
+		return cmp.Compare(a.Name, b.Name)
+	})
+	return report, nil
+}
+
+// TestName identifies a test case across executions.
+func TestName(tc events.TestCaseResult) string {
+	if tc.Suite == "" {
+		return tc.Name
+	}
+	return tc.Suite + "/" + tc.Name
+}
+
+// MemoryStore keeps test history in memory.
+type MemoryStore struct {
+	mu   sync.Mutex
+	runs map[string][]Run
+}
+
+// NewMemoryStore creates an empty in-memory history store.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{runs: make(map[string][]Run)}
+}
+
+// Append implements Store.
+func (s *MemoryStore) Append(_ context.Context, testName string, run Run, limit int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	runs := append(s.runs[testName], run)
+	if limit > 0 && len(runs) > limit {
+		runs = slices.Clone(runs[len(runs)-limit:])
+	}
+	s.runs[testName] = runs
+	return nil
+}
+
+// Runs implements Store.
+func (s *MemoryStore) Runs(_ context.Context, testName string) ([]Run, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.runs[testName]), nil
+}
+
+// TestNames implements Store.
+func (s *MemoryStore) TestNames(_ context.Context) ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	names := make([]string, 0, len(s.runs))
+	for name := range s.runs {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names, nil
+}
@@ -0,0 +1,168 @@
+package flakiness_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/antinvestor/builder/apps/worker/service/flakiness"
+	"github.com/antinvestor/builder/internal/events"
+)
+
+func testPolicy() flakiness.Policy {
+	return flakiness.Policy{
+		QuarantineEnabled: true,
+		Window:            10,
+		MinRuns:           5,
+		MinFailureRate:    0.1,
+		MaxFailureRate:    0.8,
+	}
+}
+
+// runResult builds a test result where the named tests have the given outcome.
+func runResult(outcomes map[string]bool) *events.TestResult {
+	result := &events.TestResult{Success: true}
+	for name, passed := range outcomes {
+		status := "passed"
+		if !passed {
+			status = "failed"
+			result.FailedTests++
+			result.Success = false
+		}
+		result.TotalTests++
+		result.TestCases = append(result.TestCases, events.TestCaseResult{Name: name, Status: status})
+	}
+	return result
+}
+
+// recordRuns records one run per outcome for each test.
+func recordRuns(t *testing.T, tracker *flakiness.Tracker, history map[string][]bool) {
+	t.Helper()
+	runs := 0
+	for _, outcomes := range history {
+		runs = max(runs, len(outcomes))
+	}
+	for i := range runs {
+		outcomes := map[string]bool{}
+		for name, testOutcomes := range history {
+			if i < len(testOutcomes) {
+				outcomes[name] = testOutcomes[i]
+			}
+		}
+		require.NoError(t, tracker.Record(context.Background(), fmt.Sprintf("exec-%d", i), runResult(outcomes)))
+	}
+}
+
+func TestTracker_IntermittentFailureIsFlaky(t *testing.T) {
+	tracker := flakiness.NewTracker(testPolicy(), flakiness.NewMemoryStore())
+	recordRuns(t, tracker, map[string][]bool{
+		"TestFlaky":  {true, false, true, true, false, true},
+		"TestStable": {true, true, true, true, true, true},
+		"TestBroken": {false, false, false, false, false, false},
+	})
+
+	flaky, err := tracker.Stats(context.Background(), "TestFlaky")
+	require.NoError(t, err)
+	assert.True(t, flaky.Flaky)
+	assert.True(t, flaky.Quarantined)
+	assert.Equal(t, 6, flaky.Runs)
+	assert.Equal(t, 2, flaky.Failures)
+
+	stable, err := tracker.Stats(context.Background(), "TestStable")
+	require.NoError(t, err)
+	assert.False(t, stable.Flaky)
+
+	// A test that always fails is broken, not flaky
+	broken, err := tracker.Stats(context.Background(), "TestBroken")
+	require.NoError(t, err)
+	assert.False(t, broken.Flaky)
+}
+
+func TestTracker_NeedsMinimumRuns(t *testing.T) {
+	tracker := flakiness.NewTracker(testPolicy(), flakiness.NewMemoryStore())
+	recordRuns(t, tracker, map[string][]bool{"TestNew": {true, false, true}})
+
+	stats, err := tracker.Stats(context.Background(), "TestNew")
+	require.NoError(t, err)
+	assert.False(t, stats.Flaky)
+}
+
+func TestTracker_WindowForgetsOldFailures(t *testing.T) {
+	policy := testPolicy()
+	policy.Window = 5
+	tracker := flakiness.NewTracker(policy, flakiness.NewMemoryStore())
+	recordRuns(t, tracker, map[string][]bool{"TestFixed": {false, true, false, true, true, true, true, true}})
+
+	stats, err := tracker.Stats(context.Background(), "TestFixed")
+	require.NoError(t, err)
+	assert.Equal(t, 5, stats.Runs)
+	assert.Zero(t, stats.Failures)
+	assert.False(t, stats.Flaky)
+}
+
+func TestTracker_EvaluateExcludesQuarantinedFailures(t *testing.T) {
+	tracker := flakiness.NewTracker(testPolicy(), flakiness.NewMemoryStore())
+	recordRuns(t, tracker, map[string][]bool{
+		"TestFlaky":  {true, false, true, true, false, true},
+		"TestStable": {true, true, true, true, true, true},
+	})
+
+	evaluation, err := tracker.Evaluate(context.Background(), runResult(map[string]bool{
+		"TestFlaky": false, "TestStable": true,
+	}))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"TestFlaky"}, evaluation.QuarantinedFailures)
+	assert.True(t, evaluation.OnlyQuarantinedFailures())
+
+	evaluation, err = tracker.Evaluate(context.Background(), runResult(map[string]bool{
+		"TestFlaky": false, "TestStable": false,
+	}))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"TestStable"}, evaluation.BlockingFailures)
+	assert.False(t, evaluation.OnlyQuarantinedFailures())
+
+	// Failures without individual test cases are never quarantined
+	unattributed := runResult(map[string]bool{"TestFlaky": false})
+	unattributed.FailedTests++
+	evaluation, err = tracker.Evaluate(context.Background(), unattributed)
+	require.NoError(t, err)
+	assert.False(t, evaluation.OnlyQuarantinedFailures())
+}
+
+func TestTracker_QuarantineDisabled(t *testing.T) {
+	policy := testPolicy()
+	policy.QuarantineEnabled = false
+	tracker := flakiness.NewTracker(policy, flakiness.NewMemoryStore())
+	recordRuns(t, tracker, map[string][]bool{"TestFlaky": {true, false, true, true, false, true}})
+
+	evaluation, err := tracker.Evaluate(context.Background(), runResult(map[string]bool{"TestFlaky": false}))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"TestFlaky"}, evaluation.BlockingFailures)
+	assert.False(t, evaluation.OnlyQuarantinedFailures())
+}
+
+func TestReportHTTPHandler(t *testing.T) {
+	tracker := flakiness.NewTracker(testPolicy(), flakiness.NewMemoryStore())
+	recordRuns(t, tracker, map[string][]bool{
+		"TestRarelyFlaky": {true, true, true, true, false, true},
+		"TestOftenFlaky":  {false, true, false, true, false, true},
+		"TestStable":      {true, true, true, true, true, true},
+	})
+
+	rec := httptest.NewRecorder()
+	flakiness.NewReportHTTPHandler(tracker)(rec, httptest.NewRequest(http.MethodGet, "/api/v1/tests/flaky", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var resp flakiness.ReportResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	require.Len(t, resp.Tests, 2)
+	assert.Equal(t, "TestOftenFlaky", resp.Tests[0].Name)
+	assert.Equal(t, "TestRarelyFlaky", resp.Tests[1].Name)
+	assert.True(t, resp.Tests[0].Quarantined)
+}
@@ -0,0 +1,160 @@
+// Package provider holds the HTTP plumbing shared by the repository provider
+// clients (GitHub, GitLab) that open pull requests, label them and post comments.
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/pitabwire/util"
+
+	appconfig "github.com/antinvestor/builder/apps/worker/config"
+)
+
+// maxDrainBytes bounds how much of a rate-limited response is read so its
+// connection can be reused.
+const maxDrainBytes = 64 << 10
+
+// ErrRateLimited is returned when a provider API call is still rate limited after
+// its retries, or the provider asked for a longer wait than allowed.
+var ErrRateLimited = errors.New("provider API rate limited")
+
+// Client sends requests to a provider API, retrying rate-limited calls after the
+// wait the provider asks for instead of failing them.
+type Client struct {
+	httpClient     *http.Client
+	maxRetries     int
+	initialBackoff time.Duration
+	maxWait        time.Duration
+	now            func() time.Time
+	sleep          func(ctx context.Context, d time.Duration) error
+}
+
+// NewClient creates a provider API client from configuration. A nil httpClient
+// uses http.DefaultClient.
+func NewClient(cfg *appconfig.WorkerConfig, httpClient *http.Client) *Client {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	return &Client{
+		httpClient:     httpClient,
+		maxRetries:     cfg.ProviderAPIMaxRetries,
+		initialBackoff: time.Duration(cfg.ProviderAPIInitialBackoffMS) * time.Millisecond,
+		maxWait:        time.Duration(cfg.ProviderAPIMaxWaitSeconds) * time.Second,
+		now:            time.Now,
+		sleep:          sleepContext,
+	}
+}
+
+// Do sends a request, waiting out rate limits. Requests with a body are only
+// retried when the body can be replayed (http.NewRequest sets GetBody for in-memory
+// bodies). Responses other than rate limits are returned to the caller as is.
+func (c *Client) Do(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	replayable := req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+
+	for attempt := 0; ; attempt++ {
+		attemptReq, err := replay(req, attempt)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := c.httpClient.Do(attemptReq)
+		if err != nil {
+			return nil, fmt.Errorf("provider API request: %w", err)
+		}
+		if !isRateLimited(resp) {
+			return resp, nil
+		}
+		discard(resp)
+
+		if attempt >= c.maxRetries || !replayable {
+			return nil, fmt.Errorf("%w: %s %s returned %d after %d retries",
+				ErrRateLimited, req.Method, req.URL.Path, resp.StatusCode, attempt)
+		}
+		wait := c.retryDelay(resp, attempt)
+		if wait > c.maxWait {
+			return nil, fmt.Errorf("%w: %s %s asked to wait %s", ErrRateLimited, req.Method, req.URL.Path, wait)
+		}
+
+		util.Log(ctx).Info("provider API rate limited, waiting before retry",
+			"method", req.Method,
+			"path", req.URL.Path,
+			"status", resp.StatusCode,
+			"attempt", attempt+1,
+			"wait", wait,
+		)
+		if err = c.sleep(ctx, wait); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// isRateLimited reports whether a response is a rate limit: a 429, or a 403 that
+// GitHub uses for exhausted and secondary rate limits.
+func isRateLimited(resp *http.Response) bool {
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests:
+		return true
+	case http.StatusForbidden:
+		return resp.Header.Get("Retry-After") != "" || resp.Header.Get("X-RateLimit-Remaining") == "0"
+	default:
+		return false
+	}
+}
+
+// retryDelay returns how long to wait before retrying a rate-limited response. It
+// honors Retry-After (seconds or an HTTP date), then the GitHub and GitLab reset
+// headers (Unix seconds), and falls back to exponential backoff.
+func (c *Client) retryDelay(resp *http.Response, attempt int) time.Duration {
+	if retryAfter := resp.Header.Get("Retry-After"); retryAfter != "" {
+		if seconds, err := strconv.Atoi(retryAfter); err == nil {
+			return max(time.Duration(seconds)*time.Second, 0)
+		}
+		if at, err := http.ParseTime(retryAfter); err == nil {
+			return max(at.Sub(c.now()), 0)
+		}
+	}
+	for _, header := range []string{"X-RateLimit-Reset", "RateLimit-Reset"} {
+		if reset, err := strconv.ParseInt(resp.Header.Get(header), 10, 64); err == nil {
+			return max(time.Unix(reset, 0).Sub(c.now()), 0)
+		}
+	}
+	return c.initialBackoff << attempt
+}
+
+// replay returns the request to send on an attempt, with a fresh body after the first.
+func replay(req *http.Request, attempt int) (*http.Request, error) {
+	if attempt == 0 || req.GetBody == nil {
+		return req, nil
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, fmt.Errorf("replay request body: %w", err)
+	}
+	retry := req.Clone(req.Context())
+	retry.Body = body
+	return retry, nil
+}
+
+// discard drains and closes a response body so its connection can be reused.
+func discard(resp *http.Response) {
+	_, _ = io.CopyN(io.Discard, resp.Body, maxDrainBytes)
+	_ = resp.Body.Close()
+}
+
+// sleepContext waits for d or until ctx is done.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,158 @@
+//nolint:testpackage // white-box testing requires internal package access
+package provider
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	appconfig "github.com/antinvestor/builder/apps/worker/config"
+)
+
+// rateLimitedServer answers the first limited requests with a 429 and the rest
+// with a 200, recording the body of every request.
+type rateLimitedServer struct {
+	limited    int
+	retryAfter string
+
+	mu     sync.Mutex
+	bodies []string
+}
+
+func (s *rateLimitedServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	s.mu.Lock()
+	s.bodies = append(s.bodies, string(body))
+	attempt := len(s.bodies)
+	s.mu.Unlock()
+
+	if attempt <= s.limited {
+		w.Header().Set("Retry-After", s.retryAfter)
+		w.WriteHeader(http.StatusTooManyRequests)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// newTestClient creates a client that records its waits instead of sleeping.
+func newTestClient(cfg *appconfig.WorkerConfig) (*Client, *[]time.Duration) {
+	client := NewClient(cfg, nil)
+	var waits []time.Duration
+	client.sleep = func(_ context.Context, d time.Duration) error {
+		waits = append(waits, d)
+		return nil
+	}
+	return client, &waits
+}
+
+func postLabels(t *testing.T, client *Client, url string) (*http.Response, error) {
+	t.Helper()
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, url,
+		strings.NewReader(`{"labels":["security"]}`))
+	require.NoError(t, err)
+	return client.Do(req)
+}
+
+func TestClient_RetriesAfterRetryAfter(t *testing.T) {
+	server := &rateLimitedServer{limited: 1, retryAfter: "2"}
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	client, waits := newTestClient(&appconfig.WorkerConfig{ProviderAPIMaxRetries: 3, ProviderAPIMaxWaitSeconds: 60})
+	resp, err := postLabels(t, client, ts.URL+"/repos/acme/app/issues/1/labels")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, []time.Duration{2 * time.Second}, *waits)
+	// The body is replayed on the retry
+	assert.Equal(t, []string{`{"labels":["security"]}`, `{"labels":["security"]}`}, server.bodies)
+}
+
+func TestClient_GivesUpAfterMaxRetries(t *testing.T) {
+	server := &rateLimitedServer{limited: 10, retryAfter: "1"}
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	client, waits := newTestClient(&appconfig.WorkerConfig{ProviderAPIMaxRetries: 2, ProviderAPIMaxWaitSeconds: 60})
+	_, err := postLabels(t, client, ts.URL)
+	require.ErrorIs(t, err, ErrRateLimited)
+	assert.Len(t, server.bodies, 3)
+	assert.Len(t, *waits, 2)
+}
+
+func TestClient_FailsWhenWaitExceedsLimit(t *testing.T) {
+	server := &rateLimitedServer{limited: 1, retryAfter: "3600"}
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	client, waits := newTestClient(&appconfig.WorkerConfig{ProviderAPIMaxRetries: 3, ProviderAPIMaxWaitSeconds: 60})
+	_, err := postLabels(t, client, ts.URL)
+	require.ErrorIs(t, err, ErrRateLimited)
+	assert.Len(t, server.bodies, 1)
+	assert.Empty(t, *waits)
+}
+
+func TestClient_ReturnsOtherErrorsAsIs(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer ts.Close()
+
+	client, waits := newTestClient(&appconfig.WorkerConfig{ProviderAPIMaxRetries: 3, ProviderAPIMaxWaitSeconds: 60})
+	resp, err := postLabels(t, client, ts.URL)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	assert.Empty(t, *waits)
+}
+
+func TestClient_RetryDelay(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	client := NewClient(&appconfig.WorkerConfig{ProviderAPIInitialBackoffMS: 500}, nil)
+	client.now = func() time.Time { return now }
+
+	tests := []struct {
+		name    string
+		header  string
+		value   string
+		attempt int
+		want    time.Duration
+	}{
+		{"retry after seconds", "Retry-After", "7", 0, 7 * time.Second},
+		{"retry after date", "Retry-After", now.Add(30 * time.Second).Format(http.TimeFormat), 0, 30 * time.Second},
+		{"github reset", "X-RateLimit-Reset", strconv.FormatInt(now.Add(time.Minute).Unix(), 10), 0, time.Minute},
+		{"gitlab reset", "RateLimit-Reset", strconv.FormatInt(now.Add(45*time.Second).Unix(), 10), 0, 45 * time.Second},
+		{"reset in the past", "X-RateLimit-Reset", strconv.FormatInt(now.Add(-time.Minute).Unix(), 10), 0, 0},
+		{"backoff without headers", "", "", 2, 2 * time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := &http.Response{StatusCode: http.StatusTooManyRequests, Header: http.Header{}}
+			if tt.header != "" {
+				resp.Header.Set(tt.header, tt.value)
+			}
+			assert.Equal(t, tt.want, client.retryDelay(resp, tt.attempt))
+		})
+	}
+}
+
+func TestIsRateLimited(t *testing.T) {
+	exhausted := &http.Response{StatusCode: http.StatusForbidden, Header: http.Header{}}
+	exhausted.Header.Set("X-RateLimit-Remaining", "0")
+	assert.True(t, isRateLimited(exhausted))
+
+	assert.True(t, isRateLimited(&http.Response{StatusCode: http.StatusTooManyRequests, Header: http.Header{}}))
+	assert.False(t, isRateLimited(&http.Response{StatusCode: http.StatusForbidden, Header: http.Header{}}))
+	assert.False(t, isRateLimited(&http.Response{StatusCode: http.StatusOK, Header: http.Header{}}))
+}
@@ -0,0 +1,141 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	appconfig "github.com/antinvestor/builder/apps/worker/config"
+	"github.com/antinvestor/builder/internal/events"
+)
+
+// maxErrorBodyBytes bounds how much of a failed response is quoted in its error.
+const maxErrorBodyBytes = 1 << 10
+
+// Doer sends HTTP requests; *http.Client and *Client implement it.
+type Doer interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// GitHubLabeler labels the pull request opened for a feature branch on GitHub.
+type GitHubLabeler struct {
+	client  Doer
+	baseURL string
+	token   string
+}
+
+// NewGitHubLabeler creates a labeler calling the configured GitHub API through client.
+func NewGitHubLabeler(cfg *appconfig.WorkerConfig, client Doer) *GitHubLabeler {
+	return &GitHubLabeler{
+		client:  client,
+		baseURL: strings.TrimSuffix(cfg.GitHubAPIURL, "/"),
+		token:   cfg.GitHubToken,
+	}
+}
+
+// LabelPullRequest adds labels to the open pull request of branchName in the
+// repository, keeping the labels it already has. A branch without an open pull
+// request is left alone: its labels are carried on the delivery for whoever
+// opens one.
+func (l *GitHubLabeler) LabelPullRequest(
+	ctx context.Context,
+	repository events.RepositoryContext,
+	branchName string,
+	labels []string,
+) error {
+	owner, name, err := gitHubRepository(repository.RemoteURL)
+	if err != nil {
+		return err
+	}
+	number, found, err := l.findPullRequest(ctx, owner, name, branchName)
+	if err != nil || !found {
+		return err
+	}
+
+	body, err := json.Marshal(map[string][]string{"labels": labels})
+	if err != nil {
+		return fmt.Errorf("encode labels: %w", err)
+	}
+	path := fmt.Sprintf("/repos/%s/%s/issues/%d/labels", url.PathEscape(owner), url.PathEscape(name), number)
+	resp, err := l.send(ctx, http.MethodPost, path, body)
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+// findPullRequest returns the number of the open pull request of branchName.
+func (l *GitHubLabeler) findPullRequest(ctx context.Context, owner, name, branchName string) (int, bool, error) {
+	query := url.Values{"state": {"open"}, "head": {owner + ":" + branchName}}
+	path := fmt.Sprintf("/repos/%s/%s/pulls?%s", url.PathEscape(owner), url.PathEscape(name), query.Encode())
+	resp, err := l.send(ctx, http.MethodGet, path, nil)
+	if err != nil {
+		return 0, false, err
+	}
+	defer resp.Body.Close()
+
+	var pulls []struct {
+		Number int `json:"number"`
+	}
+	if err = json.NewDecoder(resp.Body).Decode(&pulls); err != nil {
+		return 0, false, fmt.Errorf("decode pull requests: %w", err)
+	}
+	if len(pulls) == 0 {
+		return 0, false, nil
+	}
+	return pulls[0].Number, true, nil
+}
+
+// send calls the API and returns a successful response, whose body the caller closes.
+func (l *GitHubLabeler) send(ctx context.Context, method, path string, body []byte) (*http.Response, error) {
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, l.baseURL+path, reader)
+	if err != nil {
+		return nil, fmt.Errorf("build provider API request: %w", err)
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if l.token != "" {
+		req.Header.Set("Authorization", "Bearer "+l.token)
+	}
+
+	resp, err := l.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBodyBytes))
+		_ = resp.Body.Close()
+		return nil, fmt.Errorf("%s %s returned %d: %s",
+			method, req.URL.Path, resp.StatusCode, strings.TrimSpace(string(detail)))
+	}
+	return resp, nil
+}
+
+// gitHubRepository returns the owner and name of a GitHub repository from its
+// remote URL, in HTTPS (https://github.com/owner/name.git) or SSH
+// (git@github.com:owner/name.git) form.
+func gitHubRepository(remoteURL string) (string, string, error) {
+	repoPath := remoteURL
+	if parsed, err := url.Parse(remoteURL); err == nil && parsed.Host != "" {
+		repoPath = parsed.Path
+	} else if _, afterHost, ok := strings.Cut(remoteURL, ":"); ok {
+		repoPath = afterHost
+	}
+
+	parts := strings.Split(strings.Trim(strings.TrimSuffix(repoPath, ".git"), "/"), "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", fmt.Errorf("not a GitHub repository URL: %q", remoteURL)
+	}
+	return parts[0], parts[1], nil
+}
@@ -0,0 +1,118 @@
+//nolint:testpackage // white-box testing requires internal package access
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	appconfig "github.com/antinvestor/builder/apps/worker/config"
+	"github.com/antinvestor/builder/internal/events"
+)
+
+// gitHubServer serves the pull requests of a repository and records the labels
+// added to them.
+type gitHubServer struct {
+	pulls string
+
+	query  string
+	auth   string
+	path   string
+	labels []string
+}
+
+func (s *gitHubServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.auth = r.Header.Get("Authorization")
+	switch r.Method {
+	case http.MethodGet:
+		s.query = r.URL.RawQuery
+		_, _ = io.WriteString(w, s.pulls)
+	case http.MethodPost:
+		s.path = r.URL.Path
+		var body struct {
+			Labels []string `json:"labels"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		s.labels = body.Labels
+		w.WriteHeader(http.StatusOK)
+	}
+}
+
+func newTestLabeler(t *testing.T, server *gitHubServer) *GitHubLabeler {
+	t.Helper()
+	srv := httptest.NewServer(server)
+	t.Cleanup(srv.Close)
+	return NewGitHubLabeler(&appconfig.WorkerConfig{GitHubAPIURL: srv.URL + "/", GitHubToken: "tok"}, srv.Client())
+}
+
+func TestGitHubLabeler_LabelsOpenPullRequestOfBranch(t *testing.T) {
+	server := &gitHubServer{pulls: `[{"number":42}]`}
+	labeler := newTestLabeler(t, server)
+
+	err := labeler.LabelPullRequest(context.Background(),
+		events.RepositoryContext{RemoteURL: "https://github.com/acme/api.git"},
+		"feature/calc", []string{"breaking-change", "security"})
+
+	require.NoError(t, err)
+	assert.Equal(t, "head=acme%3Afeature%2Fcalc&state=open", server.query)
+	assert.Equal(t, "/repos/acme/api/issues/42/labels", server.path)
+	assert.Equal(t, []string{"breaking-change", "security"}, server.labels)
+	assert.Equal(t, "Bearer tok", server.auth)
+}
+
+func TestGitHubLabeler_BranchWithoutPullRequestIsLeftAlone(t *testing.T) {
+	server := &gitHubServer{pulls: `[]`}
+	labeler := newTestLabeler(t, server)
+
+	err := labeler.LabelPullRequest(context.Background(),
+		events.RepositoryContext{RemoteURL: "git@github.com:acme/api.git"}, "feature/calc", []string{"security"})
+
+	require.NoError(t, err)
+	assert.Empty(t, server.path)
+}
+
+func TestGitHubRepository(t *testing.T) {
+	for _, remoteURL := range []string{
+		"https://github.com/acme/api.git",
+		"https://github.com/acme/api",
+		"git@github.com:acme/api.git",
+		"ssh://git@github.com/acme/api.git",
+	} {
+		owner, name, err := gitHubRepository(remoteURL)
+		require.NoError(t, err, remoteURL)
+		assert.Equal(t, "acme", owner, remoteURL)
+		assert.Equal(t, "api", name, remoteURL)
+	}
+
+	_, _, err := gitHubRepository("/srv/git/api.git")
+	require.Error(t, err)
+}
+
+func TestGitHubLabeler_WaitsOutRateLimit(t *testing.T) {
+	limited := &rateLimitedServer{limited: 1, retryAfter: "2"}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /repos/acme/api/pulls", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = io.WriteString(w, `[{"number":42}]`)
+	})
+	mux.Handle("POST /repos/acme/api/issues/42/labels", limited)
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	cfg := &appconfig.WorkerConfig{GitHubAPIURL: srv.URL, ProviderAPIMaxRetries: 2, ProviderAPIMaxWaitSeconds: 60}
+	client, waits := newTestClient(cfg)
+	labeler := NewGitHubLabeler(cfg, client)
+
+	err := labeler.LabelPullRequest(context.Background(),
+		events.RepositoryContext{RemoteURL: "https://github.com/acme/api.git"}, "feature/calc", []string{"security"})
+
+	require.NoError(t, err)
+	assert.Equal(t, []time.Duration{2 * time.Second}, *waits)
+	assert.Equal(t, []string{`{"labels":["security"]}`, `{"labels":["security"]}`}, limited.bodies)
+}
@@ -0,0 +1,45 @@
+//nolint:testpackage // white-box testing requires internal package access
+package queue
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	appconfig "github.com/antinvestor/builder/apps/worker/config"
+)
+
+func newBackoffHandler() *FeatureRequestHandler {
+	return &FeatureRequestHandler{cfg: &appconfig.WorkerConfig{
+		FeatureRequestRetryInitialDelaySeconds: 5,
+		FeatureRequestRetryMaxDelaySeconds:     60,
+	}}
+}
+
+func TestComputeBackoff_NeverExceedsCeilingOrCap(t *testing.T) {
+	h := newBackoffHandler()
+
+	ceilings := []time.Duration{
+		5 * time.Second, 10 * time.Second, 20 * time.Second, 40 * time.Second, 60 * time.Second, 60 * time.Second,
+	}
+	for i, ceiling := range ceilings {
+		for range 100 {
+			delay := h.computeBackoff(i + 1)
+			assert.GreaterOrEqual(t, delay, time.Duration(0))
+			assert.LessOrEqual(t, delay, ceiling, "attempt %d", i+1)
+		}
+	}
+	assert.LessOrEqual(t, h.computeBackoff(1000), 60*time.Second, "large attempts must not overflow")
+	assert.Zero(t, h.computeBackoff(0))
+}
+
+func TestComputeBackoff_JittersDelays(t *testing.T) {
+	h := newBackoffHandler()
+
+	delays := make(map[time.Duration]bool)
+	for range 20 {
+		delays[h.computeBackoff(3)] = true
+	}
+	assert.Greater(t, len(delays), 1, "full jitter spreads retries over the backoff window")
+}
@@ -0,0 +1,92 @@
+package queue
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/pitabwire/util"
+
+	"github.com/antinvestor/builder/internal/executions"
+)
+
+// maxAttachAttempts bounds the retries when attaching a requester races other writers.
+const maxAttachAttempts = 5
+
+// SpecHash returns the deduplication key of a request: a hash of its repository,
+// branch and specification, normalized so that case, whitespace and list order do
+// not distinguish otherwise identical requests.
+func (r *FeatureRequest) SpecHash() string {
+	normalizeList := func(items []string) string {
+		normalized := make([]string, 0, len(items))
+		for _, item := range items {
+			if item = normalizeText(item); item != "" {
+				normalized = append(normalized, item)
+			}
+		}
+		slices.Sort(normalized)
+		return strings.Join(normalized, "\n")
+	}
+
+	repoURL := strings.ToLower(strings.TrimSpace(r.RepositoryURL))
+	repoURL = strings.TrimSuffix(strings.TrimSuffix(repoURL, "/"), ".git")
+
+	hash := sha256.New()
+	for _, part := range []string{
+		repoURL,
+		strings.TrimSpace(r.Branch),
+		normalizeText(r.Specification.Title),
+		normalizeText(r.Specification.Description),
+		normalizeList(r.Specification.Requirements),
+		normalizeList(r.Specification.TargetFiles),
+		normalizeText(r.Specification.Language),
+	} {
+		hash.Write([]byte(part))
+		hash.Write([]byte{0})
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// normalizeText lowercases text and collapses its whitespace.
+func normalizeText(text string) string {
+	return strings.Join(strings.Fields(strings.ToLower(text)), " ")
+}
+
+// attachToInFlight records the requester on the in-flight execution with the same
+// spec hash. It reports false when there is no such execution.
+func (h *FeatureRequestHandler) attachToInFlight(
+	ctx context.Context,
+	specHash string,
+	request *FeatureRequest,
+) (bool, error) {
+	existing, err := h.executionRepo.FindActiveBySpecHash(ctx, specHash)
+	if err != nil {
+		return false, fmt.Errorf("find in-flight execution: %w", err)
+	}
+	if existing == nil {
+		return false, nil
+	}
+
+	_, err = executions.UpdateExecution(ctx, h.executionRepo, existing.ID, maxAttachAttempts,
+		func(execution *executions.Execution) error {
+			requester := request.RequestedBy
+			if requester != "" && requester != execution.RequestedBy &&
+				!slices.Contains(execution.Requesters, requester) {
+				execution.Requesters = append(execution.Requesters, requester)
+			}
+			return nil
+		})
+	if err != nil {
+		return false, fmt.Errorf("attach requester to execution %s: %w", existing.ID, err)
+	}
+
+	util.Log(ctx).Info("duplicate feature request attached to in-flight execution",
+		"execution_id", existing.ID,
+		"requested_execution_id", request.ExecutionID,
+		"requested_by", request.RequestedBy,
+	)
+	return true, nil
+}
@@ -1,14 +1,17 @@
 package queue
 
 import (
+	"cmp"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	appconfig "github.com/antinvestor/builder/apps/worker/config"
-	"github.com/antinvestor/builder/apps/worker/service/repository"
 	"github.com/antinvestor/builder/internal/events"
+	"github.com/antinvestor/builder/internal/executions"
 )
 
 // Time conversion constants.
@@ -22,14 +25,15 @@ type EventsEmitter interface {
 // FeatureRequestHandler handles incoming feature requests from the gateway.
 type FeatureRequestHandler struct {
 	cfg           *appconfig.WorkerConfig
-	executionRepo repository.ExecutionRepository
+	executionRepo executions.ExecutionRepository
 	eventsMan     EventsEmitter
+	queueMan      QueueManager
 }
 
 // NewFeatureRequestHandler creates a new feature request handler.
 func NewFeatureRequestHandler(
 	cfg *appconfig.WorkerConfig,
-	executionRepo repository.ExecutionRepository,
+	executionRepo executions.ExecutionRepository,
 	eventsMan EventsEmitter,
 ) *FeatureRequestHandler {
 	return &FeatureRequestHandler{
@@ -39,6 +43,12 @@ func NewFeatureRequestHandler(
 	}
 }
 
+// SetQueueManager enables routing failed requests to the retry and dead-letter
+// queues. Without it, failures are returned to the broker for redelivery.
+func (h *FeatureRequestHandler) SetQueueManager(queueMan QueueManager) {
+	h.queueMan = queueMan
+}
+
 // FeatureRequest is the incoming feature request from the gateway.
 type FeatureRequest struct {
 	// ExecutionID is the execution identifier (generated by gateway or provided).
@@ -50,14 +60,40 @@ type FeatureRequest struct {
 	// Branch is the target branch.
 	Branch string `json:"branch"`
 
+	// Submodules overrides whether submodules are initialized during checkout.
+	Submodules *bool `json:"submodules,omitempty"`
+
+	// LFS overrides whether Git LFS objects are fetched during checkout.
+	LFS *bool `json:"lfs,omitempty"`
+
 	// Specification is the feature specification.
 	Specification FeatureSpecification `json:"specification"`
 
+	// Priority is the requested scheduling priority (low, normal, high, critical).
+	Priority string `json:"priority,omitempty"`
+
+	// Category classifies the request (e.g. feature, bugfix); used for queue routing.
+	Category string `json:"category,omitempty"`
+
 	// RequestedBy identifies who requested the feature.
 	RequestedBy string `json:"requested_by,omitempty"`
 
 	// RequestedAt is when the request was made.
 	RequestedAt time.Time `json:"requested_at,omitempty"`
+
+	// RequestSource identifies where the request came from (defaults to "api").
+	RequestSource string `json:"request_source,omitempty"`
+}
+
+// validate reports a request missing what an execution cannot start without.
+func (r *FeatureRequest) validate() error {
+	if strings.TrimSpace(r.RepositoryURL) == "" {
+		return fmt.Errorf("%w: repository URL is required", errInvalidRequest)
+	}
+	if strings.TrimSpace(r.Specification.Title) == "" {
+		return fmt.Errorf("%w: specification title is required", errInvalidRequest)
+	}
+	return nil
 }
 
 // FeatureSpecification describes the feature to build.
@@ -69,52 +105,106 @@ type FeatureSpecification struct {
 	Language     string   `json:"language,omitempty"`
 }
 
-// Handle processes incoming feature request messages.
+// Handle processes incoming feature request messages, from the request queues
+// and from the retry queue. A retried request delivered before its backoff
+// elapses is returned for redelivery.
 func (h *FeatureRequestHandler) Handle(
 	ctx context.Context,
-	_ map[string]string,
+	headers map[string]string,
 	payload []byte,
 ) error {
-	var request FeatureRequest
-	if err := json.Unmarshal(payload, &request); err != nil {
-		return fmt.Errorf("unmarshal feature request: %w", err)
-	}
-
-	// Generate execution ID if not provided
-	var execID events.ExecutionID
-	if request.ExecutionID != "" {
-		var err error
-		execID, err = events.ParseExecutionID(request.ExecutionID)
-		if err != nil {
-			execID = events.NewExecutionID()
+	if !h.retryDue(ctx, headers) {
+		return errRetryNotDue
+	}
+
+	execID, err := h.process(ctx, payload, headers[HeaderExecutionID])
+	if err == nil || h.queueMan == nil {
+		return err
+	}
+	return h.routeFailure(ctx, headers, payload, execID, err)
+}
+
+// process starts an execution for a feature request message, returning the
+// execution ID it chose so a retry reuses it. A retried request whose execution
+// record was already created resumes that execution.
+func (h *FeatureRequestHandler) process(
+	ctx context.Context,
+	payload []byte,
+	retriedExecutionID string,
+) (events.ExecutionID, error) {
+	request, err := decodeFeatureRequest(payload)
+	if err != nil {
+		return events.ExecutionID{}, fmt.Errorf("%w: %w", errMalformedRequest, err)
+	}
+	if err = request.validate(); err != nil {
+		return events.ExecutionID{}, err
+	}
+	execID := requestExecutionID(request.ExecutionID, retriedExecutionID)
+
+	// A retry of a request whose record was created resumes that execution
+	if retriedExecutionID != "" {
+		if resumed, resumeErr := h.resume(ctx, execID); resumed || resumeErr != nil {
+			return execID, resumeErr
+		}
+	}
+
+	// Identical in-flight requests share one execution
+	var specHash string
+	if h.cfg.DedupFeatureRequests {
+		specHash = request.SpecHash()
+		attached, attachErr := h.attachToInFlight(ctx, specHash, request)
+		if attachErr != nil {
+			return execID, attachErr
+		}
+		if attached {
+			return execID, nil
+		}
+	}
+
+	return execID, h.start(ctx, execID, request, specHash)
+}
+
+// requestExecutionID returns the execution ID a request asked for, the one chosen
+// on the attempt a retry repeats, or a new one.
+func requestExecutionID(requested, retried string) events.ExecutionID {
+	for _, candidate := range []string{requested, retried} {
+		if candidate == "" {
+			continue
+		}
+		if execID, err := events.ParseExecutionID(candidate); err == nil {
+			return execID
 		}
-	} else {
-		execID = events.NewExecutionID()
 	}
+	return events.NewExecutionID()
+}
+
+// resume re-emits the stored initialization of a pending execution whose record
+// an earlier attempt created. It reports false when there is no such execution.
+func (h *FeatureRequestHandler) resume(ctx context.Context, execID events.ExecutionID) (bool, error) {
+	existing, err := h.executionRepo.GetByID(ctx, execID.String())
+	if err != nil || existing.Status != executions.ExecutionStatusPending || len(existing.InitialRequest) == 0 {
+		return false, nil
+	}
+	var initPayload events.FeatureExecutionInitializedPayload
+	if err = json.Unmarshal(existing.InitialRequest, &initPayload); err != nil {
+		return false, nil
+	}
+	return true, h.emitInitialized(ctx, &initPayload)
+}
 
+// start creates the execution record of a request and emits its initialization.
+func (h *FeatureRequestHandler) start(
+	ctx context.Context,
+	execID events.ExecutionID,
+	request *FeatureRequest,
+	specHash string,
+) error {
 	// Set request time if not provided
 	if request.RequestedAt.IsZero() {
 		request.RequestedAt = time.Now()
 	}
 
-	// Create execution record
-	execution := &repository.Execution{
-		ID:            execID.String(),
-		RepositoryURL: request.RepositoryURL,
-		Branch:        request.Branch,
-		Title:         request.Specification.Title,
-		Description:   request.Specification.Description,
-		Status:        repository.ExecutionStatusPending,
-		RequestedBy:   request.RequestedBy,
-		RequestedAt:   request.RequestedAt,
-		CreatedAt:     time.Now(),
-	}
-
-	if err := h.executionRepo.Create(ctx, execution); err != nil {
-		return fmt.Errorf("create execution record: %w", err)
-	}
-
-	// Emit initialization event
+	// Build initialization event
 	initPayload := &events.FeatureExecutionInitializedPayload{
 		ExecutionID: execID,
 		Spec: events.FeatureSpecification{
@@ -126,6 +216,8 @@ func (h *FeatureRequestHandler) Handle(
 		Repository: events.RepositoryContext{
 			RemoteURL:    request.RepositoryURL,
 			TargetBranch: request.Branch,
+			Submodules:   request.Submodules,
+			LFS:          request.LFS,
 		},
 		Constraints: events.ExecutionConstraints{
 			MaxSteps:       h.cfg.MaxStepsPerExecution,
@@ -134,13 +226,118 @@ func (h *FeatureRequestHandler) Handle(
 		Request: events.RequestMetadata{
 			RequestedBy:   request.RequestedBy,
 			RequestedAt:   request.RequestedAt,
-			RequestSource: "api",
+			RequestSource: cmp.Or(request.RequestSource, "api"),
+			Priority:      parsePriority(request.Priority),
 		},
 	}
 
+	// The initialization is kept so the execution can be restarted from it
+	initialRequest, err := json.Marshal(initPayload)
+	if err != nil {
+		return fmt.Errorf("encode initialization event: %w", err)
+	}
+
+	// Create execution record
+	execution := &executions.Execution{
+		ID:             execID.String(),
+		RepositoryURL:  request.RepositoryURL,
+		Branch:         request.Branch,
+		Title:          request.Specification.Title,
+		Description:    request.Specification.Description,
+		Status:         executions.ExecutionStatusPending,
+		RequestedBy:    request.RequestedBy,
+		RequestedAt:    request.RequestedAt,
+		SpecHash:       specHash,
+		InitialRequest: initialRequest,
+		CreatedAt:      time.Now(),
+	}
+
+	err = h.executionRepo.Create(ctx, execution)
+	if errors.Is(err, executions.ErrSpecHashInFlight) {
+		// An identical request created its execution after the check above
+		attached, attachErr := h.attachToInFlight(ctx, specHash, request)
+		if attachErr != nil {
+			return attachErr
+		}
+		if attached {
+			return nil
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("create execution record: %w", err)
+	}
+
+	return h.emitInitialized(ctx, initPayload)
+}
+
+// emitInitialized emits the initialization event that starts an execution.
+func (h *FeatureRequestHandler) emitInitialized(
+	ctx context.Context,
+	initPayload *events.FeatureExecutionInitializedPayload,
+) error {
 	if err := h.eventsMan.Emit(ctx, string(events.FeatureExecutionInitialized), initPayload); err != nil {
 		return fmt.Errorf("emit initialization event: %w", err)
 	}
-
 	return nil
 }
+
+// decodeFeatureRequest decodes a feature request message. Besides requests, the
+// queue carries initialization events published by the webhook service for build
+// commands; those are recognized by their "spec" field and mapped onto a request.
+func decodeFeatureRequest(payload []byte) (*FeatureRequest, error) {
+	var envelope struct {
+		Spec *json.RawMessage `json:"spec"`
+	}
+	if err := json.Unmarshal(payload, &envelope); err != nil {
+		return nil, fmt.Errorf("unmarshal feature request: %w", err)
+	}
+
+	if envelope.Spec == nil {
+		var request FeatureRequest
+		if err := json.Unmarshal(payload, &request); err != nil {
+			return nil, fmt.Errorf("unmarshal feature request: %w", err)
+		}
+		return &request, nil
+	}
+
+	var initialized events.FeatureExecutionInitializedPayload
+	if err := json.Unmarshal(payload, &initialized); err != nil {
+		return nil, fmt.Errorf("unmarshal initialization event: %w", err)
+	}
+	request := &FeatureRequest{
+		RepositoryURL: initialized.Repository.RemoteURL,
+		Branch:        initialized.Repository.TargetBranch,
+		Submodules:    initialized.Repository.Submodules,
+		LFS:           initialized.Repository.LFS,
+		Specification: FeatureSpecification{
+			Title:        initialized.Spec.Title,
+			Description:  initialized.Spec.Description,
+			Requirements: initialized.Spec.AcceptanceCriteria,
+			TargetFiles:  initialized.Spec.PathHints,
+		},
+		Priority:      initialized.Request.Priority.String(),
+		RequestedBy:   initialized.Request.RequestedBy,
+		RequestedAt:   initialized.Request.RequestedAt,
+		RequestSource: initialized.Request.RequestSource,
+	}
+	if !initialized.ExecutionID.IsZero() {
+		request.ExecutionID = initialized.ExecutionID.String()
+	}
+	return request, nil
+}
+
+// parsePriority converts a request priority name to an event priority.
+func parsePriority(priority string) events.Priority {
+	switch strings.ToLower(strings.TrimSpace(priority)) {
+	case "low":
+		return events.PriorityLow
+	case "normal":
+		return events.PriorityNormal
+	case "high":
+		return events.PriorityHigh
+	case "critical":
+		return events.PriorityCritical
+	default:
+		return events.PriorityUnspecified
+	}
+}
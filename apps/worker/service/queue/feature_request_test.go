@@ -0,0 +1,217 @@
+package queue_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	appconfig "github.com/antinvestor/builder/apps/worker/config"
+	"github.com/antinvestor/builder/apps/worker/service/queue"
+	"github.com/antinvestor/builder/internal/events"
+	"github.com/antinvestor/builder/internal/executions"
+)
+
+type recordingEmitter struct {
+	initialized []*events.FeatureExecutionInitializedPayload
+}
+
+func (e *recordingEmitter) Emit(_ context.Context, eventName string, payload any) error {
+	if eventName == string(events.FeatureExecutionInitialized) {
+		if initialized, ok := payload.(*events.FeatureExecutionInitializedPayload); ok {
+			e.initialized = append(e.initialized, initialized)
+		}
+	}
+	return nil
+}
+
+func featureRequestPayload(t *testing.T, request *queue.FeatureRequest) []byte {
+	t.Helper()
+	payload, err := json.Marshal(request)
+	require.NoError(t, err)
+	return payload
+}
+
+func newDedupRequest(requestedBy string) *queue.FeatureRequest {
+	return &queue.FeatureRequest{
+		ExecutionID:   events.NewExecutionID().String(),
+		RepositoryURL: "https://github.com/example/orders.git",
+		Branch:        "main",
+		RequestedBy:   requestedBy,
+		Specification: queue.FeatureSpecification{
+			Title:        "Add order export",
+			Description:  "Export orders as CSV",
+			Requirements: []string{"Include totals", "Support date ranges"},
+		},
+	}
+}
+
+func TestFeatureRequestHandler_DuplicateSpecReusesInFlightExecution(t *testing.T) {
+	ctx := context.Background()
+	repo := executions.NewMemoryExecutionRepository()
+	emitter := &recordingEmitter{}
+	handler := queue.NewFeatureRequestHandler(&appconfig.WorkerConfig{DedupFeatureRequests: true}, repo, emitter)
+
+	first := newDedupRequest("alice")
+	require.NoError(t, handler.Handle(ctx, nil, featureRequestPayload(t, first)))
+
+	// The same feature, worded with different case, spacing and requirement order
+	duplicate := newDedupRequest("bob")
+	duplicate.RepositoryURL = "https://github.com/Example/orders/"
+	duplicate.Specification.Title = "  add ORDER export"
+	duplicate.Specification.Requirements = []string{"Support date  ranges", "include totals"}
+	require.NoError(t, handler.Handle(ctx, nil, featureRequestPayload(t, duplicate)))
+
+	require.Len(t, emitter.initialized, 1, "the duplicate must not start a new execution")
+	assert.Equal(t, first.ExecutionID, emitter.initialized[0].ExecutionID.String())
+
+	execution, err := repo.GetByID(ctx, first.ExecutionID)
+	require.NoError(t, err)
+	assert.Equal(t, "alice", execution.RequestedBy)
+	assert.Equal(t, []string{"bob"}, execution.Requesters)
+	assert.Equal(t, first.SpecHash(), execution.SpecHash)
+
+	_, err = repo.GetByID(ctx, duplicate.ExecutionID)
+	require.Error(t, err)
+}
+
+// staleSpecHashRepository misses in-flight executions on its first lookup, as a
+// lookup racing the commit of an identical request does.
+type staleSpecHashRepository struct {
+	*executions.MemoryExecutionRepository
+	lookups int
+}
+
+func (r *staleSpecHashRepository) FindActiveBySpecHash(
+	ctx context.Context,
+	specHash string,
+) (*executions.Execution, error) {
+	r.lookups++
+	if r.lookups == 1 {
+		return nil, nil //nolint:nilnil // The in-flight execution is not visible yet
+	}
+	return r.MemoryExecutionRepository.FindActiveBySpecHash(ctx, specHash)
+}
+
+func TestFeatureRequestHandler_DuplicateCreatedConcurrentlyAttaches(t *testing.T) {
+	ctx := context.Background()
+	repo := &staleSpecHashRepository{MemoryExecutionRepository: executions.NewMemoryExecutionRepository()}
+	first := newDedupRequest("alice")
+	require.NoError(t, repo.Create(ctx, &executions.Execution{
+		ID:          first.ExecutionID,
+		Status:      executions.ExecutionStatusPending,
+		RequestedBy: "alice",
+		SpecHash:    first.SpecHash(),
+	}))
+	emitter := &recordingEmitter{}
+	handler := queue.NewFeatureRequestHandler(&appconfig.WorkerConfig{DedupFeatureRequests: true}, repo, emitter)
+
+	require.NoError(t, handler.Handle(ctx, nil, featureRequestPayload(t, newDedupRequest("bob"))))
+
+	assert.Empty(t, emitter.initialized, "the conflicting insert must not start a second execution")
+	execution, err := repo.GetByID(ctx, first.ExecutionID)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"bob"}, execution.Requesters)
+}
+
+func TestFeatureRequestHandler_FinishedExecutionIsNotReused(t *testing.T) {
+	ctx := context.Background()
+	repo := executions.NewMemoryExecutionRepository()
+	emitter := &recordingEmitter{}
+	handler := queue.NewFeatureRequestHandler(&appconfig.WorkerConfig{DedupFeatureRequests: true}, repo, emitter)
+
+	first := newDedupRequest("alice")
+	require.NoError(t, handler.Handle(ctx, nil, featureRequestPayload(t, first)))
+	require.NoError(t, repo.UpdateStatus(ctx, first.ExecutionID, executions.ExecutionStatusCompleted, ""))
+
+	require.NoError(t, handler.Handle(ctx, nil, featureRequestPayload(t, newDedupRequest("bob"))))
+
+	assert.Len(t, emitter.initialized, 2)
+}
+
+func TestFeatureRequestHandler_DedupDisabledStartsNewExecutions(t *testing.T) {
+	ctx := context.Background()
+	repo := executions.NewMemoryExecutionRepository()
+	emitter := &recordingEmitter{}
+	handler := queue.NewFeatureRequestHandler(&appconfig.WorkerConfig{}, repo, emitter)
+
+	require.NoError(t, handler.Handle(ctx, nil, featureRequestPayload(t, newDedupRequest("alice"))))
+	require.NoError(t, handler.Handle(ctx, nil, featureRequestPayload(t, newDedupRequest("bob"))))
+
+	assert.Len(t, emitter.initialized, 2)
+}
+
+func TestFeatureRequest_SpecHashDistinguishesRepositoriesAndSpecs(t *testing.T) {
+	base := newDedupRequest("alice")
+
+	otherRepo := newDedupRequest("alice")
+	otherRepo.RepositoryURL = "https://github.com/example/billing.git"
+
+	otherSpec := newDedupRequest("alice")
+	otherSpec.Specification.Requirements = append(otherSpec.Specification.Requirements, "Stream large exports")
+
+	assert.NotEqual(t, base.SpecHash(), otherRepo.SpecHash())
+	assert.NotEqual(t, base.SpecHash(), otherSpec.SpecHash())
+	assert.Equal(t, base.SpecHash(), newDedupRequest("bob").SpecHash())
+}
+
+func TestFeatureRequestHandler_StoresInitialRequestForRetry(t *testing.T) {
+	ctx := context.Background()
+	repo := executions.NewMemoryExecutionRepository()
+	emitter := &recordingEmitter{}
+	handler := queue.NewFeatureRequestHandler(&appconfig.WorkerConfig{}, repo, emitter)
+
+	request := newDedupRequest("alice")
+	require.NoError(t, handler.Handle(ctx, nil, featureRequestPayload(t, request)))
+
+	stored, err := repo.GetByID(ctx, request.ExecutionID)
+	require.NoError(t, err)
+	var initial events.FeatureExecutionInitializedPayload
+	require.NoError(t, json.Unmarshal(stored.InitialRequest, &initial))
+
+	require.Len(t, emitter.initialized, 1)
+	assert.Equal(t, emitter.initialized[0].ExecutionID, initial.ExecutionID)
+	assert.Equal(t, emitter.initialized[0].Spec, initial.Spec)
+	assert.Equal(t, emitter.initialized[0].Repository.RemoteURL, initial.Repository.RemoteURL)
+}
+
+func TestFeatureRequestHandler_AcceptsInitializationEvents(t *testing.T) {
+	ctx := context.Background()
+	repo := executions.NewMemoryExecutionRepository()
+	emitter := &recordingEmitter{}
+	handler := queue.NewFeatureRequestHandler(&appconfig.WorkerConfig{MaxStepsPerExecution: 12}, repo, emitter)
+
+	// Build commands arrive from the webhook service already shaped as an initialization
+	command := &events.FeatureExecutionInitializedPayload{
+		ExecutionID: events.NewExecutionID(),
+		Spec:        events.FeatureSpecification{Title: "Order export", Description: "Export orders as CSV"},
+		Repository: events.RepositoryContext{
+			RemoteURL:    "https://github.com/example/orders.git",
+			TargetBranch: "develop",
+		},
+		Request: events.RequestMetadata{
+			RequestedBy:   "alice",
+			RequestSource: "github",
+			Priority:      events.PriorityHigh,
+		},
+	}
+	payload, err := json.Marshal(command)
+	require.NoError(t, err)
+	require.NoError(t, handler.Handle(ctx, nil, payload))
+
+	require.Len(t, emitter.initialized, 1)
+	initialized := emitter.initialized[0]
+	assert.Equal(t, command.ExecutionID, initialized.ExecutionID)
+	assert.Equal(t, command.Spec, initialized.Spec)
+	assert.Equal(t, "develop", initialized.Repository.TargetBranch)
+	assert.Equal(t, "github", initialized.Request.RequestSource)
+	assert.Equal(t, events.PriorityHigh, initialized.Request.Priority)
+	assert.Equal(t, 12, initialized.Constraints.MaxSteps)
+
+	execution, err := repo.GetByID(ctx, command.ExecutionID.String())
+	require.NoError(t, err)
+	assert.Equal(t, "https://github.com/example/orders.git", execution.RepositoryURL)
+	assert.Equal(t, "alice", execution.RequestedBy)
+}
@@ -0,0 +1,205 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/pitabwire/util"
+
+	"github.com/antinvestor/builder/internal/events"
+	"github.com/antinvestor/builder/internal/executions"
+)
+
+// HeaderRetryAttempt is the message header counting how many times a feature
+// request has been republished to the retry queue.
+const HeaderRetryAttempt = "retry_attempt"
+
+// HeaderNotBefore is the message header holding the time, in RFC 3339 format,
+// before which a retried feature request must not be processed. A request
+// delivered earlier is nacked, so the retry queue's subscription should be
+// given a redelivery delay.
+const HeaderNotBefore = "not_before"
+
+// HeaderExecutionID is the message header holding the execution ID chosen for a
+// retried feature request, so every attempt starts the same execution.
+const HeaderExecutionID = "execution_id"
+
+const (
+	// dlqRetentionDays is how long a dead-lettered feature request is kept.
+	dlqRetentionDays = 28
+	// backoffMultiplier grows the backoff ceiling per attempt.
+	backoffMultiplier = 2.0
+	// millisPerSecond converts the configured delays for the retry policy.
+	millisPerSecond = 1000
+)
+
+// errMalformedRequest marks a request that cannot be decoded; retrying it
+// cannot succeed.
+var errMalformedRequest = errors.New("malformed feature request")
+
+// errInvalidRequest marks a request missing required fields; retrying it cannot
+// succeed.
+var errInvalidRequest = errors.New("invalid feature request")
+
+// errRetryNotDue is returned for a retried request delivered before its
+// not-before time, so the message is nacked and redelivered by the broker
+// instead of holding the subscriber.
+var errRetryNotDue = errors.New("feature request retry not yet due")
+
+// QueueManager publishes to queues.
+type QueueManager interface {
+	Publish(ctx context.Context, queueName string, payload any, headers ...map[string]string) error
+}
+
+// routeFailure republishes a failed request to the retry queue with an
+// incremented attempt header and the execution ID it was given, or dead-letters
+// it when the failure is not retryable or the attempts exceed the configured
+// maximum. A routed request is acknowledged; only a failure to publish is
+// returned for redelivery.
+func (h *FeatureRequestHandler) routeFailure(
+	ctx context.Context,
+	headers map[string]string,
+	payload []byte,
+	executionID events.ExecutionID,
+	failure error,
+) error {
+	log := util.Log(ctx).WithError(failure)
+	attempt := retryAttempt(headers)
+
+	if class, permanent := permanentFailure(failure); permanent {
+		log.Error("dead-lettering feature request that cannot succeed on retry")
+		return h.deadLetter(ctx, payload, attempt, failure, class)
+	}
+
+	next := attempt + 1
+	if next > h.cfg.FeatureRequestMaxRetries {
+		log.Error("feature request retries exhausted, dead-lettering", "attempts", attempt)
+		return h.deadLetter(ctx, payload, attempt, failure, events.DLQFailureTransient)
+	}
+
+	delay := h.computeBackoff(next)
+	log.Warn("feature request failed, scheduling retry", "attempt", next, "delay", delay)
+	retryHeaders := map[string]string{
+		HeaderRetryAttempt: strconv.Itoa(next),
+		HeaderNotBefore:    time.Now().Add(delay).UTC().Format(time.RFC3339Nano),
+	}
+	if !executionID.IsZero() {
+		retryHeaders[HeaderExecutionID] = executionID.String()
+	}
+	if err := h.queueMan.Publish(ctx, h.cfg.QueueRetryLevel1Name, payload, retryHeaders); err != nil {
+		return fmt.Errorf("publish feature request retry: %w", err)
+	}
+	return nil
+}
+
+// permanentFailure returns the dead-letter class of a failure that no retry can
+// fix: a request that is malformed or invalid, or one naming an execution that
+// does not exist.
+func permanentFailure(failure error) (events.DLQFailureClass, bool) {
+	switch {
+	case errors.Is(failure, errMalformedRequest), errors.Is(failure, errInvalidRequest):
+		return events.DLQFailureValidation, true
+	case errors.Is(failure, executions.ErrExecutionNotFound):
+		return events.DLQFailurePermanent, true
+	default:
+		return "", false
+	}
+}
+
+// deadLetter publishes the original request with its failure to the DLQ.
+func (h *FeatureRequestHandler) deadLetter(
+	ctx context.Context,
+	payload []byte,
+	attempt int,
+	failure error,
+	class events.DLQFailureClass,
+) error {
+	// A payload that is not JSON is kept as a JSON string
+	original := json.RawMessage(payload)
+	if !json.Valid(payload) {
+		quoted, err := json.Marshal(string(payload))
+		if err != nil {
+			return fmt.Errorf("encode dead-lettered feature request: %w", err)
+		}
+		original = quoted
+	}
+
+	now := time.Now()
+	entry := &events.DLQEntry{
+		Event: &events.Event{
+			EventID:       events.NewEventID(),
+			EventType:     events.FeatureExecutionInitialized,
+			SchemaVersion: "1.0.0",
+			CreatedAt:     now.UTC(),
+			HLCTimestamp:  events.NewHybridTimestamp(),
+			Payload:       original,
+			Metadata: events.EventMetadata{
+				Tags: map[string]string{
+					HeaderRetryAttempt: strconv.Itoa(attempt),
+					"source":           "feature_request",
+				},
+			},
+		},
+		RetryMetadata: events.RetryMetadata{
+			CurrentAttempt:   attempt,
+			MaxAttempts:      h.cfg.FeatureRequestMaxRetries,
+			LastAttemptAt:    now,
+			LastErrorMessage: failure.Error(),
+		},
+		FailureReason:         failure.Error(),
+		FailureClassification: class,
+		EnteredDLQAt:          now,
+		ExpiresAt:             now.AddDate(0, 0, dlqRetentionDays),
+		ManualReviewRequired:  class != events.DLQFailureTransient,
+	}
+
+	if err := h.queueMan.Publish(ctx, h.cfg.QueueDLQName, entry, map[string]string{
+		HeaderRetryAttempt: strconv.Itoa(attempt),
+	}); err != nil {
+		return fmt.Errorf("publish dead-lettered feature request: %w", err)
+	}
+	return nil
+}
+
+// computeBackoff returns the delay before a retry attempt: a random duration up
+// to the initial delay doubled per attempt after the first and capped at the
+// maximum ("full jitter"), so requests failing together do not retry together.
+func (h *FeatureRequestHandler) computeBackoff(attempt int) time.Duration {
+	return events.RetryPolicy{
+		InitialDelayMS:    h.cfg.FeatureRequestRetryInitialDelaySeconds * millisPerSecond,
+		MaxDelayMS:        h.cfg.FeatureRequestRetryMaxDelaySeconds * millisPerSecond,
+		BackoffMultiplier: backoffMultiplier,
+		FullJitter:        true,
+	}.CalculateDelay(attempt)
+}
+
+// retryDue reports whether a retried request's not-before time has passed. A
+// not-before time further out than the longest request or execution restart
+// backoff cannot have been set by the worker and is ignored.
+func (h *FeatureRequestHandler) retryDue(ctx context.Context, headers map[string]string) bool {
+	value, ok := headers[HeaderNotBefore]
+	if !ok {
+		return true
+	}
+	notBefore, err := time.Parse(time.RFC3339Nano, value)
+	if err != nil {
+		util.Log(ctx).WithError(err).Warn("ignoring invalid not-before header", "value", value)
+		return true
+	}
+	wait := time.Until(notBefore)
+	maxDelaySeconds := max(h.cfg.FeatureRequestRetryMaxDelaySeconds, h.cfg.ExecutionRetryMaxDelaySeconds)
+	return wait <= 0 || wait > time.Duration(maxDelaySeconds)*time.Second
+}
+
+// retryAttempt returns the attempt header of a message, zero for a first delivery.
+func retryAttempt(headers map[string]string) int {
+	attempt, err := strconv.Atoi(headers[HeaderRetryAttempt])
+	if err != nil || attempt < 0 {
+		return 0
+	}
+	return attempt
+}
@@ -0,0 +1,271 @@
+package queue_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"maps"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	appconfig "github.com/antinvestor/builder/apps/worker/config"
+	"github.com/antinvestor/builder/apps/worker/service/queue"
+	"github.com/antinvestor/builder/internal/events"
+	"github.com/antinvestor/builder/internal/executions"
+)
+
+type publishedMessage struct {
+	queueName string
+	payload   any
+	headers   map[string]string
+}
+
+type recordingQueueManager struct {
+	published []publishedMessage
+}
+
+func (m *recordingQueueManager) Publish(
+	_ context.Context,
+	queueName string,
+	payload any,
+	headers ...map[string]string,
+) error {
+	message := publishedMessage{queueName: queueName, payload: payload, headers: map[string]string{}}
+	for _, h := range headers {
+		maps.Copy(message.headers, h)
+	}
+	m.published = append(m.published, message)
+	return nil
+}
+
+// failingExecutionRepository fails every execution it is asked to create.
+type failingExecutionRepository struct {
+	executions.ExecutionRepository
+}
+
+func (r *failingExecutionRepository) Create(context.Context, *executions.Execution) error {
+	return errors.New("database unavailable")
+}
+
+func newRetryingHandler(queueMan *recordingQueueManager) *queue.FeatureRequestHandler {
+	cfg := &appconfig.WorkerConfig{
+		FeatureRequestMaxRetries:               3,
+		FeatureRequestRetryInitialDelaySeconds: 5,
+		FeatureRequestRetryMaxDelaySeconds:     60,
+		QueueRetryLevel1Name:                   "feature.events.retry.1",
+		QueueDLQName:                           "feature.events.dlq",
+	}
+	repo := &failingExecutionRepository{ExecutionRepository: executions.NewMemoryExecutionRepository()}
+	handler := queue.NewFeatureRequestHandler(cfg, repo, &recordingEmitter{})
+	handler.SetQueueManager(queueMan)
+	return handler
+}
+
+func TestFeatureRequestHandler_RetryableFailureIncrementsAttempt(t *testing.T) {
+	queueMan := &recordingQueueManager{}
+	handler := newRetryingHandler(queueMan)
+	payload := featureRequestPayload(t, newDedupRequest("alice"))
+
+	require.NoError(t, handler.Handle(context.Background(), nil, payload))
+	require.NoError(t, handler.Handle(context.Background(), map[string]string{queue.HeaderRetryAttempt: "1"}, payload))
+
+	require.Len(t, queueMan.published, 2)
+	for i, message := range queueMan.published {
+		assert.Equal(t, "feature.events.retry.1", message.queueName)
+		assert.Equal(t, payload, message.payload, "the original request is retried")
+		assert.Equal(t, []string{"1", "2"}[i], message.headers[queue.HeaderRetryAttempt])
+	}
+}
+
+func TestFeatureRequestHandler_ExhaustedRetriesRouteToDLQ(t *testing.T) {
+	queueMan := &recordingQueueManager{}
+	handler := newRetryingHandler(queueMan)
+	payload := featureRequestPayload(t, newDedupRequest("alice"))
+
+	require.NoError(t, handler.Handle(context.Background(), map[string]string{queue.HeaderRetryAttempt: "3"}, payload))
+
+	require.Len(t, queueMan.published, 1)
+	message := queueMan.published[0]
+	assert.Equal(t, "feature.events.dlq", message.queueName)
+	entry, ok := message.payload.(*events.DLQEntry)
+	require.True(t, ok)
+	assert.JSONEq(t, string(payload), string(entry.Event.Payload))
+	assert.Equal(t, events.DLQFailureTransient, entry.FailureClassification)
+	assert.Equal(t, 3, entry.RetryMetadata.CurrentAttempt)
+	assert.Equal(t, 3, entry.RetryMetadata.MaxAttempts)
+	assert.Contains(t, entry.FailureReason, "database unavailable")
+}
+
+func TestFeatureRequestHandler_MalformedRequestSkipsRetries(t *testing.T) {
+	queueMan := &recordingQueueManager{}
+	handler := newRetryingHandler(queueMan)
+
+	require.NoError(t, handler.Handle(context.Background(), nil, []byte("not json")))
+
+	require.Len(t, queueMan.published, 1)
+	message := queueMan.published[0]
+	assert.Equal(t, "feature.events.dlq", message.queueName)
+	entry, ok := message.payload.(*events.DLQEntry)
+	require.True(t, ok)
+	assert.Equal(t, events.DLQFailureValidation, entry.FailureClassification)
+	assert.True(t, entry.ManualReviewRequired)
+
+	var original string
+	require.NoError(t, json.Unmarshal(entry.Event.Payload, &original))
+	assert.Equal(t, "not json", original)
+}
+
+func TestFeatureRequestHandler_FailureWithoutQueueManagerIsReturned(t *testing.T) {
+	cfg := &appconfig.WorkerConfig{FeatureRequestMaxRetries: 3}
+	repo := &failingExecutionRepository{ExecutionRepository: executions.NewMemoryExecutionRepository()}
+	handler := queue.NewFeatureRequestHandler(cfg, repo, &recordingEmitter{})
+
+	err := handler.Handle(context.Background(), nil, featureRequestPayload(t, newDedupRequest("alice")))
+	require.Error(t, err)
+}
+
+func TestFeatureRequestHandler_RetryStampsNotBeforeWithinBackoff(t *testing.T) {
+	queueMan := &recordingQueueManager{}
+	handler := newRetryingHandler(queueMan)
+	payload := featureRequestPayload(t, newDedupRequest("alice"))
+
+	before := time.Now()
+	require.NoError(t, handler.Handle(context.Background(), map[string]string{queue.HeaderRetryAttempt: "1"}, payload))
+
+	require.Len(t, queueMan.published, 1)
+	notBefore, err := time.Parse(time.RFC3339Nano, queueMan.published[0].headers[queue.HeaderNotBefore])
+	require.NoError(t, err)
+	// The second attempt waits up to twice the initial delay
+	assert.False(t, notBefore.Before(before.Add(-time.Second)))
+	assert.False(t, notBefore.After(time.Now().Add(10*time.Second)))
+}
+
+func TestFeatureRequestHandler_RetryNotDueIsReturnedForRedelivery(t *testing.T) {
+	queueMan := &recordingQueueManager{}
+	handler := newRetryingHandler(queueMan)
+	payload := featureRequestPayload(t, newDedupRequest("alice"))
+	headers := map[string]string{
+		queue.HeaderRetryAttempt: "1",
+		queue.HeaderNotBefore:    time.Now().Add(time.Minute).Format(time.RFC3339Nano),
+	}
+
+	start := time.Now()
+	require.Error(t, handler.Handle(context.Background(), headers, payload))
+	assert.Less(t, time.Since(start), time.Second, "the subscriber is not held until the retry is due")
+	assert.Empty(t, queueMan.published, "a request not yet due is neither retried nor dead-lettered")
+}
+
+func TestFeatureRequestHandler_InvalidRequestSkipsRetries(t *testing.T) {
+	queueMan := &recordingQueueManager{}
+	handler := newRetryingHandler(queueMan)
+	request := newDedupRequest("alice")
+	request.Specification.Title = ""
+
+	require.NoError(t, handler.Handle(context.Background(), nil, featureRequestPayload(t, request)))
+
+	require.Len(t, queueMan.published, 1)
+	message := queueMan.published[0]
+	assert.Equal(t, "feature.events.dlq", message.queueName)
+	entry, ok := message.payload.(*events.DLQEntry)
+	require.True(t, ok)
+	assert.Equal(t, events.DLQFailureValidation, entry.FailureClassification)
+	assert.Contains(t, entry.FailureReason, "title")
+}
+
+func TestFeatureRequestHandler_RetryReusesExecutionID(t *testing.T) {
+	queueMan := &recordingQueueManager{}
+	handler := newRetryingHandler(queueMan)
+	request := newDedupRequest("alice")
+	request.ExecutionID = ""
+	payload := featureRequestPayload(t, request)
+
+	require.NoError(t, handler.Handle(context.Background(), nil, payload))
+	require.Len(t, queueMan.published, 1)
+	executionID := queueMan.published[0].headers[queue.HeaderExecutionID]
+	require.NotEmpty(t, executionID, "the retry carries the execution ID chosen for the request")
+
+	require.NoError(t, handler.Handle(context.Background(), retryHeaders(queueMan.published[0]), payload))
+	require.Len(t, queueMan.published, 2)
+	assert.Equal(t, executionID, queueMan.published[1].headers[queue.HeaderExecutionID])
+}
+
+// retryHeaders returns the headers of a retry without its not-before time, so it
+// is handled at once.
+func retryHeaders(message publishedMessage) map[string]string {
+	headers := maps.Clone(message.headers)
+	delete(headers, queue.HeaderNotBefore)
+	return headers
+}
+
+// failOnceEmitter fails the first initialization it is asked to emit.
+type failOnceEmitter struct {
+	recordingEmitter
+	failed bool
+}
+
+func (e *failOnceEmitter) Emit(ctx context.Context, eventName string, payload any) error {
+	if !e.failed {
+		e.failed = true
+		return errors.New("broker unavailable")
+	}
+	return e.recordingEmitter.Emit(ctx, eventName, payload)
+}
+
+func TestFeatureRequestHandler_RetryResumesCreatedExecution(t *testing.T) {
+	cfg := &appconfig.WorkerConfig{
+		FeatureRequestMaxRetries:               3,
+		FeatureRequestRetryInitialDelaySeconds: 5,
+		FeatureRequestRetryMaxDelaySeconds:     60,
+		QueueRetryLevel1Name:                   "feature.events.retry.1",
+		QueueDLQName:                           "feature.events.dlq",
+	}
+	repo := executions.NewMemoryExecutionRepository()
+	emitter := &failOnceEmitter{}
+	queueMan := &recordingQueueManager{}
+	handler := queue.NewFeatureRequestHandler(cfg, repo, emitter)
+	handler.SetQueueManager(queueMan)
+	request := newDedupRequest("alice")
+	request.ExecutionID = ""
+	payload := featureRequestPayload(t, request)
+
+	require.NoError(t, handler.Handle(context.Background(), nil, payload))
+	require.Len(t, queueMan.published, 1)
+	executionID := queueMan.published[0].headers[queue.HeaderExecutionID]
+
+	require.NoError(t, handler.Handle(context.Background(), retryHeaders(queueMan.published[0]), payload))
+	require.Len(t, queueMan.published, 1, "the resumed execution is not retried again")
+	require.Len(t, emitter.initialized, 1)
+	assert.Equal(t, executionID, emitter.initialized[0].ExecutionID.String())
+	assert.Equal(t, "Add order export", emitter.initialized[0].Spec.Title)
+}
+
+func TestFeatureRequestHandler_RequeuedRestartResumesExecution(t *testing.T) {
+	execID := events.NewExecutionID()
+	initialRequest, err := json.Marshal(&events.FeatureExecutionInitializedPayload{
+		ExecutionID: execID,
+		Spec:        events.FeatureSpecification{Title: "Add calculator"},
+		Repository:  events.RepositoryContext{RemoteURL: "https://github.com/example/calc.git", TargetBranch: "main"},
+	})
+	require.NoError(t, err)
+	repo := executions.NewMemoryExecutionRepository()
+	require.NoError(t, repo.Create(context.Background(), &executions.Execution{
+		ID:             execID.String(),
+		Status:         executions.ExecutionStatusPending,
+		RetryAttempts:  1,
+		InitialRequest: initialRequest,
+	}))
+	emitter := &recordingEmitter{}
+	handler := queue.NewFeatureRequestHandler(&appconfig.WorkerConfig{}, repo, emitter)
+
+	headers := map[string]string{queue.HeaderExecutionID: execID.String()}
+	require.NoError(t, handler.Handle(context.Background(), headers, initialRequest))
+
+	require.Len(t, emitter.initialized, 1)
+	assert.Equal(t, execID, emitter.initialized[0].ExecutionID)
+	stored, err := repo.GetByID(context.Background(), execID.String())
+	require.NoError(t, err)
+	assert.Equal(t, 1, stored.RetryAttempts, "the restarted execution keeps its record")
+}
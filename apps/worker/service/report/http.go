@@ -0,0 +1,44 @@
+package report
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/pitabwire/util"
+
+	"github.com/antinvestor/builder/internal/events"
+)
+
+// NewHTTPHandler returns the handler for GET /api/v1/executions/{id}/report
+// serving the stored report of an execution.
+func NewHTTPHandler(store Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		executionID, err := events.ParseExecutionID(r.PathValue("id"))
+		if err != nil {
+			http.Error(w, "invalid execution id", http.StatusBadRequest)
+			return
+		}
+
+		report, err := store.Load(r.Context(), executionID.String())
+		if errors.Is(err, ErrNotFound) {
+			http.Error(w, "execution report not found", http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			util.Log(r.Context()).WithError(err).Error("failed to load execution report")
+			http.Error(w, "failed to load report", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		if encodeErr := json.NewEncoder(w).Encode(report); encodeErr != nil {
+			util.Log(r.Context()).WithError(encodeErr).Error("failed to encode execution report")
+		}
+	}
+}
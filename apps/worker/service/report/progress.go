@@ -0,0 +1,171 @@
+package report
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/pitabwire/util"
+
+	"github.com/antinvestor/builder/internal/events"
+	"github.com/antinvestor/builder/internal/executions"
+)
+
+// maxProgressUpdateAttempts bounds the retries when a progress update races
+// other writers of the execution record.
+const maxProgressUpdateAttempts = 3
+
+// maxProgressMarks bounds the executions whose recorded progress is remembered;
+// forgetting one only costs a save on its next event.
+const maxProgressMarks = 10000
+
+// errProgressUnchanged skips saving an execution whose progress is already recorded.
+var errProgressUnchanged = errors.New("execution progress unchanged")
+
+// executionStatus maps report statuses to the execution status they end with.
+var executionStatus = map[Status]executions.ExecutionStatus{
+	StatusCompleted: executions.ExecutionStatusCompleted,
+	StatusDelivered: executions.ExecutionStatusCompleted,
+	StatusNoOp:      executions.ExecutionStatusCompleted,
+	StatusFailed:    executions.ExecutionStatusFailed,
+}
+
+// ProgressEmitter wraps an emitter so each event it emits is recorded as the
+// progress of its execution: the phase, event name, feature branch and status on
+// the execution record, which API clients read to follow it. Progress is recorded
+// before the event is emitted, so handlers of the event write after it, and only
+// for events that move the execution on: a new phase, a named branch, the start
+// or the end of the execution. Events within a phase do not touch the record.
+func ProgressEmitter(next Emitter, repo executions.ExecutionRepository) Emitter {
+	return &progressEmitter{next: next, repo: repo, marks: make(map[string]progressMark)}
+}
+
+type progressEmitter struct {
+	next Emitter
+	repo executions.ExecutionRepository
+
+	mu sync.Mutex
+	// marks are the last phase and branch recorded for each running execution.
+	marks map[string]progressMark
+}
+
+// progressMark is the progress last recorded for an execution.
+type progressMark struct {
+	phase  events.ExecutionPhase
+	branch string
+}
+
+func (e *progressEmitter) Emit(ctx context.Context, eventName string, payload any) error {
+	if executionID, ok := executionIDOf(payload); ok {
+		e.record(ctx, executionID, eventName, payload)
+	}
+	return e.next.Emit(ctx, eventName, payload)
+}
+
+// record saves the progress an event makes, unless it makes none over the
+// progress last recorded for its execution.
+func (e *progressEmitter) record(ctx context.Context, executionID, eventName string, payload any) {
+	mark := progressMark{phase: phaseOf(eventName), branch: branchOf(payload)}
+	_, terminal := terminalStatus[eventName]
+	ending := terminal || eventName == string(events.FeatureExecutionInitialized)
+
+	e.mu.Lock()
+	last, seen := e.marks[executionID]
+	e.mu.Unlock()
+	if seen && !ending && (mark.phase == "" || mark.phase == last.phase) &&
+		(mark.branch == "" || mark.branch == last.branch) {
+		return
+	}
+
+	_, err := executions.UpdateExecution(ctx, e.repo, executionID, maxProgressUpdateAttempts,
+		func(execution *executions.Execution) error {
+			if !recordProgress(execution, eventName, payload, time.Now()) {
+				return errProgressUnchanged
+			}
+			return nil
+		})
+	if err != nil && !errors.Is(err, errProgressUnchanged) {
+		util.Log(ctx).WithError(err).Warn("failed to record execution progress",
+			"execution_id", executionID,
+			"event", eventName,
+		)
+		return
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if terminal {
+		// A restart after a retryable failure starts over from initialization
+		delete(e.marks, executionID)
+		return
+	}
+	if !seen && len(e.marks) >= maxProgressMarks {
+		for id := range e.marks {
+			delete(e.marks, id)
+			break
+		}
+	}
+	if mark.phase == "" {
+		mark.phase = last.phase
+	}
+	if mark.branch == "" {
+		mark.branch = last.branch
+	}
+	e.marks[executionID] = mark
+}
+
+// recordProgress records an event on the execution and reports whether it changed.
+func recordProgress(execution *executions.Execution, eventName string, payload any, now time.Time) bool {
+	before := *execution
+
+	execution.LastEvent = eventName
+	if phase := phaseOf(eventName); phase != "" {
+		execution.Phase = string(phase)
+	}
+	if branch := branchOf(payload); branch != "" {
+		execution.FeatureBranch = branch
+	}
+
+	// An execution runs from its first event after initialization until a terminal
+	// one. A retryable failure is not terminal: the execution retry policy either
+	// restarts the execution or fails it once its attempts are used up.
+	status, terminal := terminalStatus[eventName]
+	failed, isFailure := payload.(*events.FeatureExecutionFailedPayload)
+	switch {
+	case isFailure && failed.Classification.IsOutage():
+		execution.ErrorMessage = failed.ErrorMessage
+	case terminal:
+		execution.Status = executionStatus[status]
+		execution.CompletedAt = &now
+		if isFailure {
+			execution.ErrorMessage = failed.ErrorMessage
+		}
+	case execution.Status == executions.ExecutionStatusPending &&
+		eventName != string(events.FeatureExecutionInitialized):
+		execution.Status = executions.ExecutionStatusRunning
+		execution.StartedAt = &now
+	}
+
+	return execution.LastEvent != before.LastEvent ||
+		execution.Phase != before.Phase ||
+		execution.FeatureBranch != before.FeatureBranch ||
+		execution.Status != before.Status ||
+		execution.ErrorMessage != before.ErrorMessage
+}
+
+// branchOf returns the feature branch an event names, or "" when it names none.
+func branchOf(payload any) string {
+	switch p := payload.(type) {
+	case *events.GitPushCompletedPayload:
+		return p.BranchName
+	case *events.FeatureDeliveredPayload:
+		return p.BranchName
+	case *events.FeatureExecutionCompletedPayload:
+		return p.BranchName
+	case *events.FeatureNoOpPayload:
+		return p.BranchName
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,166 @@
+//nolint:testpackage // white-box testing requires internal package access
+package report
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/antinvestor/builder/internal/events"
+	"github.com/antinvestor/builder/internal/executions"
+)
+
+func newTrackedExecution(t *testing.T) (*executions.MemoryExecutionRepository, events.ExecutionID) {
+	t.Helper()
+	repo := executions.NewMemoryExecutionRepository()
+	executionID := events.NewExecutionID()
+	require.NoError(t, repo.Create(context.Background(), &executions.Execution{
+		ID:     executionID.String(),
+		Status: executions.ExecutionStatusPending,
+	}))
+	return repo, executionID
+}
+
+func TestProgressEmitter_RecordsLatestProgress(t *testing.T) {
+	ctx := context.Background()
+	repo, executionID := newTrackedExecution(t)
+	next := &mockEmitter{}
+	emitter := ProgressEmitter(next, repo)
+
+	steps := []struct {
+		event      recordedEvent
+		wantStatus executions.ExecutionStatus
+		wantPhase  events.ExecutionPhase
+	}{
+		{executionEvents(executionID)[0], executions.ExecutionStatusPending, events.ExecutionPhaseInitialization},
+		{executionEvents(executionID)[1], executions.ExecutionStatusRunning, events.ExecutionPhaseCheckout},
+		{executionEvents(executionID)[4], executions.ExecutionStatusRunning, events.ExecutionPhaseVerification},
+		{executionEvents(executionID)[11], executions.ExecutionStatusCompleted, events.ExecutionPhaseDelivery},
+	}
+	for _, step := range steps {
+		require.NoError(t, emitter.Emit(ctx, step.event.name, step.event.payload))
+
+		execution, err := repo.GetByID(ctx, executionID.String())
+		require.NoError(t, err)
+		assert.Equal(t, step.wantStatus, execution.Status, step.event.name)
+		assert.Equal(t, string(step.wantPhase), execution.Phase, step.event.name)
+		assert.Equal(t, step.event.name, execution.LastEvent)
+	}
+
+	execution, err := repo.GetByID(ctx, executionID.String())
+	require.NoError(t, err)
+	assert.Equal(t, "feature/report", execution.FeatureBranch)
+	assert.NotNil(t, execution.StartedAt)
+	assert.NotNil(t, execution.CompletedAt)
+	assert.Len(t, next.emitted, len(steps))
+}
+
+func TestProgressEmitter_RecordsFailure(t *testing.T) {
+	ctx := context.Background()
+	repo, executionID := newTrackedExecution(t)
+	emitter := ProgressEmitter(&mockEmitter{}, repo)
+
+	require.NoError(t, emitter.Emit(ctx, string(events.FeatureExecutionFailed), &events.FeatureExecutionFailedPayload{
+		ExecutionID:  executionID,
+		ErrorMessage: "tests kept failing",
+	}))
+
+	execution, err := repo.GetByID(ctx, executionID.String())
+	require.NoError(t, err)
+	assert.Equal(t, executions.ExecutionStatusFailed, execution.Status)
+	assert.Equal(t, "tests kept failing", execution.ErrorMessage)
+}
+
+func TestProgressEmitter_UnknownExecutionDoesNotFailEmit(t *testing.T) {
+	next := &mockEmitter{}
+	emitter := ProgressEmitter(next, executions.NewMemoryExecutionRepository())
+
+	require.NoError(t, emitter.Emit(context.Background(), string(events.RepositoryCheckoutCompleted),
+		&events.RepositoryCheckoutCompletedPayload{ExecutionID: events.NewExecutionID()}))
+	assert.Len(t, next.emitted, 1)
+}
+
+// emitterFunc emits by calling a function.
+type emitterFunc func(ctx context.Context, eventName string, payload any) error
+
+func (f emitterFunc) Emit(ctx context.Context, eventName string, payload any) error {
+	return f(ctx, eventName, payload)
+}
+
+// countingRepository counts the execution updates saved.
+type countingRepository struct {
+	*executions.MemoryExecutionRepository
+	updates int
+}
+
+func (r *countingRepository) Update(ctx context.Context, execution *executions.Execution) error {
+	r.updates++
+	return r.MemoryExecutionRepository.Update(ctx, execution)
+}
+
+func TestProgressEmitter_RecordsBeforeEmitting(t *testing.T) {
+	ctx := context.Background()
+	repo, executionID := newTrackedExecution(t)
+	var seen string
+	emitter := ProgressEmitter(emitterFunc(func(ctx context.Context, _ string, _ any) error {
+		execution, err := repo.GetByID(ctx, executionID.String())
+		require.NoError(t, err)
+		seen = execution.LastEvent
+		return nil
+	}), repo)
+
+	checkout := executionEvents(executionID)[1]
+	require.NoError(t, emitter.Emit(ctx, checkout.name, checkout.payload))
+	assert.Equal(t, checkout.name, seen)
+}
+
+func TestProgressEmitter_RetryableFailureKeepsRestart(t *testing.T) {
+	ctx := context.Background()
+	repo, executionID := newTrackedExecution(t)
+	// The failure handler restarts the execution, as the execution retry policy does
+	emitter := ProgressEmitter(emitterFunc(func(ctx context.Context, eventName string, _ any) error {
+		if eventName != string(events.FeatureExecutionFailed) {
+			return nil
+		}
+		_, err := executions.UpdateExecution(ctx, repo, executionID.String(), 1, func(e *executions.Execution) error {
+			e.Status = executions.ExecutionStatusPending
+			return nil
+		})
+		return err
+	}), repo)
+
+	checkout := executionEvents(executionID)[1]
+	require.NoError(t, emitter.Emit(ctx, checkout.name, checkout.payload))
+	require.NoError(t, emitter.Emit(ctx, string(events.FeatureExecutionFailed), &events.FeatureExecutionFailedPayload{
+		ExecutionID:    executionID,
+		Classification: events.FailureClassification{Type: events.FailureTypeInfrastructure, Retryable: true},
+		ErrorMessage:   "git clone timed out",
+	}))
+
+	execution, err := repo.GetByID(ctx, executionID.String())
+	require.NoError(t, err)
+	assert.Equal(t, executions.ExecutionStatusPending, execution.Status)
+	assert.Nil(t, execution.CompletedAt)
+	assert.Equal(t, "git clone timed out", execution.ErrorMessage)
+}
+
+func TestProgressEmitter_SkipsEventsWithinPhase(t *testing.T) {
+	ctx := context.Background()
+	memory, executionID := newTrackedExecution(t)
+	repo := &countingRepository{MemoryExecutionRepository: memory}
+	next := &mockEmitter{}
+	emitter := ProgressEmitter(next, repo)
+
+	all := executionEvents(executionID)
+	for _, event := range []recordedEvent{all[1], all[2], all[6], all[7]} {
+		require.NoError(t, emitter.Emit(ctx, event.name, event.payload))
+	}
+
+	assert.Equal(t, 2, repo.updates)
+	assert.Len(t, next.emitted, 4)
+	execution, err := repo.GetByID(ctx, executionID.String())
+	require.NoError(t, err)
+	assert.Equal(t, string(events.ExecutionPhaseGeneration), execution.Phase)
+}
@@ -0,0 +1,237 @@
+package report
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+
+	frameevents "github.com/pitabwire/frame/events"
+	"github.com/pitabwire/util"
+
+	"github.com/antinvestor/builder/apps/worker/service/accounting"
+	"github.com/antinvestor/builder/internal/events"
+)
+
+const (
+	// recordingTTL is how long the events of an execution are kept after its last
+	// event, so later deliveries and retries extend the same report.
+	recordingTTL = 24 * time.Hour
+	// maxRecordedExecutions bounds the executions recorded at once; beyond it the
+	// execution that recorded nothing for longest is dropped.
+	maxRecordedExecutions = 1000
+	// maxRecordedEvents bounds the events kept per execution; beyond it the oldest
+	// events after the first are dropped.
+	maxRecordedEvents = 500
+)
+
+// Emitter emits events, as the worker's events manager does.
+type Emitter interface {
+	Emit(ctx context.Context, eventName string, payload any) error
+}
+
+// UsageSource reports the resources an execution consumed.
+type UsageSource interface {
+	Usage(executionID string) (accounting.Usage, bool)
+}
+
+// Recorder records the events of each execution and, when an event ends the
+// execution, stores its report. Only what the report needs is kept of each
+// payload, and an execution is forgotten once its report is stored unless it
+// goes on.
+type Recorder struct {
+	store Store
+	usage UsageSource
+	now   func() time.Time
+
+	mu         sync.Mutex
+	executions map[string][]Event
+	// handled are the events delivered to a wrapped handler, which records them
+	// instead of the emitter.
+	handled map[string]bool
+}
+
+// NewRecorder creates a recorder saving reports to store, with the cost of each
+// execution taken from usage when it is set.
+func NewRecorder(store Store, usage UsageSource) *Recorder {
+	return &Recorder{
+		store:      store,
+		usage:      usage,
+		now:        time.Now,
+		executions: make(map[string][]Event),
+		handled:    make(map[string]bool),
+	}
+}
+
+// Store returns the store reports are saved to.
+func (r *Recorder) Store() Store {
+	return r.store
+}
+
+// Record records an event of the execution it names. Events without an execution
+// ID are ignored. An event ending the execution stores its report.
+func (r *Recorder) Record(ctx context.Context, eventName string, payload any) error {
+	executionID, ok := executionIDOf(payload)
+	if !ok {
+		return nil
+	}
+
+	_, terminal := terminalStatus[eventName]
+
+	r.mu.Lock()
+	now := r.now()
+	r.prune(now)
+	recorded := r.executions[executionID]
+	if len(recorded) >= maxRecordedEvents {
+		// Trimming into a new array leaves reports being built from the old one intact.
+		recorded = append(recorded[:1:1], recorded[len(recorded)-maxRecordedEvents+2:]...)
+	}
+	recorded = append(recorded, Event{
+		Type:    eventName,
+		Phase:   phaseOf(eventName),
+		At:      now,
+		payload: retained(payload),
+	})
+	if terminal && !continues(payload) {
+		delete(r.executions, executionID)
+	} else {
+		r.executions[executionID] = recorded
+	}
+	r.mu.Unlock()
+
+	if !terminal {
+		return nil
+	}
+	_, err := r.store.Save(ctx, Build(executionID, recorded, r.cost(executionID)))
+	if err != nil {
+		return fmt.Errorf("store execution report: %w", err)
+	}
+	return nil
+}
+
+// cost returns the resources an execution consumed, when they are known.
+func (r *Recorder) cost(executionID string) *accounting.Usage {
+	if r.usage == nil {
+		return nil
+	}
+	usage, ok := r.usage.Usage(executionID)
+	if !ok {
+		return nil
+	}
+	return &usage
+}
+
+// continues reports whether an execution goes on after a terminal event: after
+// a partial delivery, or a failure on an outage the worker retries. Its later
+// events then extend the same report.
+func continues(payload any) bool {
+	switch p := payload.(type) {
+	case *events.FeatureDeliveredPayload:
+		return p.Partial
+	case *events.FeatureExecutionFailedPayload:
+		return p.Classification.IsOutage()
+	}
+	return false
+}
+
+// prune drops executions that recorded nothing within recordingTTL and, when at
+// capacity, the one that recorded nothing for longest. Callers hold mu.
+func (r *Recorder) prune(now time.Time) {
+	oldestID, oldestAt := "", now
+	for executionID, recorded := range r.executions {
+		lastAt := recorded[len(recorded)-1].At
+		if now.Sub(lastAt) > recordingTTL {
+			delete(r.executions, executionID)
+			continue
+		}
+		if !lastAt.After(oldestAt) {
+			oldestID, oldestAt = executionID, lastAt
+		}
+	}
+	if len(r.executions) >= maxRecordedExecutions {
+		delete(r.executions, oldestID)
+	}
+}
+
+// executionIDOf returns the execution a payload belongs to, read from its
+// ExecutionID field.
+func executionIDOf(payload any) (string, bool) {
+	value := reflect.ValueOf(payload)
+	for value.Kind() == reflect.Pointer {
+		if value.IsNil() {
+			return "", false
+		}
+		value = value.Elem()
+	}
+	if value.Kind() != reflect.Struct {
+		return "", false
+	}
+	field := value.FieldByName("ExecutionID")
+	if !field.IsValid() || !field.CanInterface() {
+		return "", false
+	}
+	executionID, ok := field.Interface().(events.ExecutionID)
+	if !ok || executionID.IsZero() {
+		return "", false
+	}
+	return executionID.String(), true
+}
+
+// Emitter wraps an emitter so the events it emits are recorded. Events a wrapped
+// handler receives are left for the handler to record, so each is recorded once.
+func (r *Recorder) Emitter(next Emitter) Emitter {
+	return &recordingEmitter{next: next, recorder: r}
+}
+
+type recordingEmitter struct {
+	next     Emitter
+	recorder *Recorder
+}
+
+func (e *recordingEmitter) Emit(ctx context.Context, eventName string, payload any) error {
+	if err := e.next.Emit(ctx, eventName, payload); err != nil {
+		return err
+	}
+	if !e.recorder.isHandled(eventName) {
+		e.recorder.recordQuietly(ctx, eventName, payload)
+	}
+	return nil
+}
+
+// Handlers wraps event handlers so the events they receive are recorded.
+func (r *Recorder) Handlers(handlers ...frameevents.EventI) []frameevents.EventI {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	wrapped := make([]frameevents.EventI, 0, len(handlers))
+	for _, handler := range handlers {
+		r.handled[handler.Name()] = true
+		wrapped = append(wrapped, &recordingHandler{EventI: handler, recorder: r})
+	}
+	return wrapped
+}
+
+type recordingHandler struct {
+	frameevents.EventI
+	recorder *Recorder
+}
+
+func (h *recordingHandler) Execute(ctx context.Context, payload any) error {
+	h.recorder.recordQuietly(ctx, h.Name(), payload)
+	return h.EventI.Execute(ctx, payload)
+}
+
+func (r *Recorder) isHandled(eventName string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.handled[eventName]
+}
+
+// recordQuietly records an event, logging rather than failing the pipeline when
+// its report cannot be stored.
+func (r *Recorder) recordQuietly(ctx context.Context, eventName string, payload any) {
+	if err := r.Record(ctx, eventName, payload); err != nil {
+		util.Log(ctx).WithError(err).Warn("failed to record execution report", "event", eventName)
+	}
+}
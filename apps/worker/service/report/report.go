@@ -0,0 +1,256 @@
+// Package report assembles a machine-readable report of each execution from the
+// events it produced: its spec, the phases it went through and how long each
+// took, the patches generated, the test and review outcome and what it cost.
+package report
+
+import (
+	"strings"
+	"time"
+
+	"github.com/antinvestor/builder/apps/worker/service/accounting"
+	"github.com/antinvestor/builder/internal/events"
+)
+
+// Status is how an execution ended.
+type Status string
+
+const (
+	StatusCompleted Status = "completed"
+	StatusDelivered Status = "delivered"
+	StatusNoOp      Status = "noop"
+	StatusFailed    Status = "failed"
+)
+
+// terminalStatus maps the events that end an execution to the status they end it with.
+var terminalStatus = map[string]Status{
+	string(events.FeatureExecutionCompleted): StatusCompleted,
+	string(events.FeatureDelivered):          StatusDelivered,
+	string(events.FeatureNoOp):               StatusNoOp,
+	string(events.FeatureExecutionFailed):    StatusFailed,
+}
+
+// phasePrefixes maps event name prefixes to the phase the event belongs to.
+// Terminal failures and no-ops belong to no phase.
+var phasePrefixes = []struct {
+	prefix string
+	phase  events.ExecutionPhase
+}{
+	{prefix: string(events.FeatureExecutionInitialized), phase: events.ExecutionPhaseInitialization},
+	{prefix: "repository.checkout.", phase: events.ExecutionPhaseCheckout},
+	{prefix: "repository.indexing.", phase: events.ExecutionPhaseIndexing},
+	{prefix: "specification.", phase: events.ExecutionPhaseNormalization},
+	{prefix: "analysis.", phase: events.ExecutionPhaseAnalysis},
+	{prefix: "planning.", phase: events.ExecutionPhasePlanning},
+	{prefix: "patch.", phase: events.ExecutionPhaseGeneration},
+	{prefix: "iteration.", phase: events.ExecutionPhaseGeneration},
+	{prefix: "test.", phase: events.ExecutionPhaseVerification},
+	{prefix: "build.", phase: events.ExecutionPhaseVerification},
+	{prefix: "review.", phase: events.ExecutionPhaseVerification},
+	{prefix: "git.", phase: events.ExecutionPhaseDelivery},
+	{prefix: string(events.FeatureDelivered), phase: events.ExecutionPhaseDelivery},
+	{prefix: string(events.FeatureExecutionCompleted), phase: events.ExecutionPhaseDelivery},
+}
+
+// phaseOf returns the phase an event belongs to, or "" when it belongs to none.
+func phaseOf(eventName string) events.ExecutionPhase {
+	for _, candidate := range phasePrefixes {
+		if strings.HasPrefix(eventName, candidate.prefix) {
+			return candidate.phase
+		}
+	}
+	return ""
+}
+
+// Event is one recorded event of an execution.
+type Event struct {
+	Type  string                `json:"type"`
+	Phase events.ExecutionPhase `json:"phase,omitempty"`
+	At    time.Time             `json:"at"`
+
+	payload any
+}
+
+// Phase is a contiguous stretch of an execution spent in one phase. A phase an
+// iteration returns to appears again.
+type Phase struct {
+	Name        events.ExecutionPhase `json:"name"`
+	StartedAt   time.Time             `json:"started_at"`
+	CompletedAt time.Time             `json:"completed_at"`
+	DurationMS  int64                 `json:"duration_ms"`
+}
+
+// PatchSummary summarises one round of patch generation.
+type PatchSummary struct {
+	FilesCreated  int                      `json:"files_created"`
+	FilesModified int                      `json:"files_modified"`
+	FilesDeleted  int                      `json:"files_deleted"`
+	LinesAdded    int                      `json:"lines_added"`
+	LinesRemoved  int                      `json:"lines_removed"`
+	CommitSHA     string                   `json:"commit_sha,omitempty"`
+	LLMTokens     int                      `json:"llm_tokens"`
+	LLM           events.LLMProcessingInfo `json:"llm"`
+	CompletedAt   time.Time                `json:"completed_at"`
+}
+
+// Failure describes why an execution failed.
+type Failure struct {
+	Code    string                `json:"code"`
+	Message string                `json:"message"`
+	Phase   events.ExecutionPhase `json:"phase,omitempty"`
+}
+
+// Report is the machine-readable report of one execution.
+type Report struct {
+	ExecutionID    string                       `json:"execution_id"`
+	Status         Status                       `json:"status"`
+	Spec           *events.FeatureSpecification `json:"spec,omitempty"`
+	StartedAt      time.Time                    `json:"started_at"`
+	CompletedAt    time.Time                    `json:"completed_at"`
+	DurationMS     int64                        `json:"duration_ms"`
+	Phases         []Phase                      `json:"phases"`
+	Patches        []PatchSummary               `json:"patches"`
+	TestResult     *events.TestResult           `json:"test_result,omitempty"`
+	ReviewDecision events.ControlDecision       `json:"review_decision,omitempty"`
+	Cost           *accounting.Usage            `json:"cost,omitempty"`
+	BranchName     string                       `json:"branch_name,omitempty"`
+	HeadCommitSHA  string                       `json:"head_commit_sha,omitempty"`
+	PullRequestURL string                       `json:"pull_request_url,omitempty"`
+	Error          *Failure                     `json:"error,omitempty"`
+	Events         []Event                      `json:"events"`
+}
+
+// Build assembles the report of an execution from its recorded events, oldest
+// first. The last terminal event decides the status; later test results and
+// review decisions supersede earlier ones.
+func Build(executionID string, recorded []Event, cost *accounting.Usage) *Report {
+	report := &Report{
+		ExecutionID: executionID,
+		Phases:      []Phase{},
+		Patches:     []PatchSummary{},
+		Cost:        cost,
+		Events:      recorded,
+	}
+	if len(recorded) == 0 {
+		return report
+	}
+	report.StartedAt = recorded[0].At
+	report.CompletedAt = recorded[len(recorded)-1].At
+	report.DurationMS = report.CompletedAt.Sub(report.StartedAt).Milliseconds()
+
+	for _, event := range recorded {
+		if status, ok := terminalStatus[event.Type]; ok {
+			report.Status = status
+		}
+		report.addPhase(event)
+		report.apply(event.payload)
+	}
+	report.closePhase(report.CompletedAt)
+	return report
+}
+
+// addPhase extends the current phase with an event, or closes it and opens the
+// event's phase when the event moved the execution on.
+func (r *Report) addPhase(event Event) {
+	if event.Phase == "" {
+		return
+	}
+	if n := len(r.Phases); n > 0 && r.Phases[n-1].Name == event.Phase {
+		return
+	}
+	r.closePhase(event.At)
+	r.Phases = append(r.Phases, Phase{Name: event.Phase, StartedAt: event.At})
+}
+
+// closePhase ends the current phase at the given time.
+func (r *Report) closePhase(at time.Time) {
+	if n := len(r.Phases); n > 0 && r.Phases[n-1].CompletedAt.IsZero() {
+		current := &r.Phases[n-1]
+		current.CompletedAt = at
+		current.DurationMS = at.Sub(current.StartedAt).Milliseconds()
+	}
+}
+
+// retained returns the part of a payload apply reads, so a recorded execution does
+// not hold patches, test output or artifacts until its report is built. Payloads
+// apply ignores are not kept at all.
+func retained(payload any) any {
+	switch p := payload.(type) {
+	case *events.FeatureExecutionInitializedPayload:
+		return &events.FeatureExecutionInitializedPayload{ExecutionID: p.ExecutionID, Spec: p.Spec}
+	case *events.PatchGenerationCompletedPayload:
+		kept := *p
+		kept.Commits = nil
+		return &kept
+	case *events.TestExecutionCompletedPayload:
+		return &events.TestExecutionCompletedPayload{ExecutionID: p.ExecutionID, Result: p.Result}
+	case *events.ComprehensiveReviewCompletedPayload:
+		return &events.ComprehensiveReviewCompletedPayload{ExecutionID: p.ExecutionID, Decision: p.Decision}
+	case *events.FeatureDeliveredPayload:
+		return &events.FeatureDeliveredPayload{
+			ExecutionID:    p.ExecutionID,
+			BranchName:     p.BranchName,
+			HeadCommitSHA:  p.HeadCommitSHA,
+			PullRequestURL: p.PullRequestURL,
+			Summary:        events.DeliverySummary{Tests: p.Summary.Tests},
+		}
+	case *events.FeatureExecutionCompletedPayload:
+		return &events.FeatureExecutionCompletedPayload{
+			ExecutionID: p.ExecutionID,
+			BranchName:  p.BranchName,
+			FinalCommit: events.CommitInfo{SHA: p.FinalCommit.SHA},
+		}
+	case *events.FeatureNoOpPayload:
+		return &events.FeatureNoOpPayload{ExecutionID: p.ExecutionID, BranchName: p.BranchName}
+	case *events.FeatureExecutionFailedPayload:
+		return &events.FeatureExecutionFailedPayload{
+			ExecutionID:  p.ExecutionID,
+			ErrorCode:    p.ErrorCode,
+			ErrorMessage: p.ErrorMessage,
+			FailedPhase:  p.FailedPhase,
+		}
+	}
+	return nil
+}
+
+// apply takes what the report needs from an event's payload.
+func (r *Report) apply(payload any) {
+	switch p := payload.(type) {
+	case *events.FeatureExecutionInitializedPayload:
+		spec := p.Spec
+		r.Spec = &spec
+	case *events.PatchGenerationCompletedPayload:
+		r.Patches = append(r.Patches, PatchSummary{
+			FilesCreated:  p.FilesCreated,
+			FilesModified: p.FilesModified,
+			FilesDeleted:  p.FilesDeleted,
+			LinesAdded:    p.TotalLinesAdded,
+			LinesRemoved:  p.TotalLinesRemoved,
+			CommitSHA:     p.FinalCommitSHA,
+			LLMTokens:     p.TotalLLMTokens,
+			LLM:           p.LLMInfo,
+			CompletedAt:   p.CompletedAt,
+		})
+	case *events.TestExecutionCompletedPayload:
+		if p.Result != nil {
+			r.TestResult = p.Result
+		}
+	case *events.ComprehensiveReviewCompletedPayload:
+		r.ReviewDecision = p.Decision
+	case *events.FeatureDeliveredPayload:
+		r.BranchName = p.BranchName
+		r.HeadCommitSHA = p.HeadCommitSHA
+		r.PullRequestURL = p.PullRequestURL
+		if r.TestResult == nil && p.Summary.Tests != nil {
+			r.TestResult = p.Summary.Tests
+		}
+	case *events.FeatureExecutionCompletedPayload:
+		r.BranchName = p.BranchName
+		if p.FinalCommit.SHA != "" {
+			r.HeadCommitSHA = p.FinalCommit.SHA
+		}
+	case *events.FeatureNoOpPayload:
+		r.BranchName = p.BranchName
+	case *events.FeatureExecutionFailedPayload:
+		r.Error = &Failure{Code: p.ErrorCode, Message: p.ErrorMessage, Phase: p.FailedPhase}
+	}
+}
@@ -0,0 +1,337 @@
+//nolint:testpackage // white-box testing requires internal package access
+package report
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	frameevents "github.com/pitabwire/frame/events"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gocloud.dev/blob/memblob"
+
+	"github.com/antinvestor/builder/apps/worker/service/accounting"
+	"github.com/antinvestor/builder/internal/artifacts"
+	"github.com/antinvestor/builder/internal/events"
+)
+
+var reportStart = time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+
+type recordedEvent struct {
+	name    string
+	payload any
+}
+
+// newTestRecorder returns a recorder storing reports under a temporary directory
+// whose clock advances one second per event.
+func newTestRecorder(t *testing.T, usage UsageSource) *Recorder {
+	t.Helper()
+	recorder := NewRecorder(NewArtifactStore(artifacts.NewBucketStore(memblob.OpenBucket(nil), "mem://")), usage)
+	tick := 0
+	recorder.now = func() time.Time {
+		tick++
+		return reportStart.Add(time.Duration(tick) * time.Second)
+	}
+	return recorder
+}
+
+// executionEvents are the events the worker records for an execution that is
+// delivered after iterating once on its first review.
+func executionEvents(executionID events.ExecutionID) []recordedEvent {
+	testResult := &events.TestResult{TotalTests: 4, PassedTests: 4}
+	return []recordedEvent{
+		{string(events.FeatureExecutionInitialized), &events.FeatureExecutionInitializedPayload{
+			ExecutionID: executionID,
+			Spec:        events.FeatureSpecification{Title: "Add report"},
+		}},
+		{string(events.RepositoryCheckoutCompleted), &events.RepositoryCheckoutCompletedPayload{ExecutionID: executionID}},
+		{string(events.PatchGenerationCompleted), &events.PatchGenerationCompletedPayload{
+			ExecutionID:     executionID,
+			FilesCreated:    1,
+			TotalLinesAdded: 40,
+			FinalCommitSHA:  "first",
+			TotalLLMTokens:  900,
+			LLMInfo:         events.LLMProcessingInfo{Provider: "openai", Model: "gpt-4o"},
+		}},
+		{string(events.TestExecutionStarted), &events.TestExecutionStartedPayload{ExecutionID: executionID}},
+		{string(events.TestExecutionCompleted), &events.TestExecutionCompletedPayload{
+			ExecutionID: executionID,
+			Result:      &events.TestResult{TotalTests: 4, PassedTests: 3, FailedTests: 1},
+		}},
+		{string(events.ReviewCompleted), &events.ComprehensiveReviewCompletedPayload{
+			ExecutionID: executionID,
+			Decision:    events.ControlDecisionIterate,
+		}},
+		{string(events.IterationRequired), &events.FeatureIterationRequestedPayload{ExecutionID: executionID}},
+		{string(events.PatchGenerationCompleted), &events.PatchGenerationCompletedPayload{
+			ExecutionID:    executionID,
+			FilesModified:  1,
+			FinalCommitSHA: "second",
+		}},
+		{string(events.TestExecutionCompleted), &events.TestExecutionCompletedPayload{
+			ExecutionID: executionID,
+			Success:     true,
+			Result:      testResult,
+		}},
+		{string(events.ReviewCompleted), &events.ComprehensiveReviewCompletedPayload{
+			ExecutionID: executionID,
+			Decision:    events.ControlDecisionApprove,
+		}},
+		{string(events.GitPushCompleted), &events.GitPushCompletedPayload{ExecutionID: executionID}},
+		{string(events.FeatureDelivered), &events.FeatureDeliveredPayload{
+			ExecutionID:    executionID,
+			BranchName:     "feature/report",
+			HeadCommitSHA:  "second",
+			PullRequestURL: "https://example.com/pulls/7",
+		}},
+	}
+}
+
+func TestRecorder_ReportIncludesAllPhases(t *testing.T) {
+	ledger := accounting.NewLedger(accounting.Budget{})
+	recorder := newTestRecorder(t, ledger)
+	ctx := context.Background()
+	executionID := events.NewExecutionID()
+	ledger.Start(executionID.String())
+	require.NoError(t, ledger.RecordLLMTokens(executionID.String(), "generation", 900))
+
+	recorded := executionEvents(executionID)
+	for _, event := range recorded {
+		require.NoError(t, recorder.Record(ctx, event.name, event.payload))
+	}
+
+	report, err := recorder.Store().Load(ctx, executionID.String())
+	require.NoError(t, err)
+
+	assert.Equal(t, executionID.String(), report.ExecutionID)
+	assert.Equal(t, StatusDelivered, report.Status)
+	require.NotNil(t, report.Spec)
+	assert.Equal(t, "Add report", report.Spec.Title)
+
+	var phases []events.ExecutionPhase
+	for _, phase := range report.Phases {
+		phases = append(phases, phase.Name)
+		assert.Equal(t, phase.CompletedAt.Sub(phase.StartedAt).Milliseconds(), phase.DurationMS)
+	}
+	assert.Equal(t, []events.ExecutionPhase{
+		events.ExecutionPhaseInitialization,
+		events.ExecutionPhaseCheckout,
+		events.ExecutionPhaseGeneration,
+		events.ExecutionPhaseVerification,
+		events.ExecutionPhaseGeneration,
+		events.ExecutionPhaseVerification,
+		events.ExecutionPhaseDelivery,
+	}, phases)
+	// Phases run back to back from the first event to the last
+	assert.Equal(t, reportStart.Add(time.Second), report.Phases[0].StartedAt)
+	assert.Equal(t, int64(3000), report.Phases[3].DurationMS)
+	assert.Equal(t, report.Phases[1].StartedAt, report.Phases[0].CompletedAt)
+	assert.Equal(t, report.CompletedAt, report.Phases[len(report.Phases)-1].CompletedAt)
+	assert.Equal(t, int64(11000), report.DurationMS)
+
+	require.Len(t, report.Events, len(recorded))
+	for i, event := range report.Events {
+		assert.Equal(t, recorded[i].name, event.Type)
+		assert.Equal(t, reportStart.Add(time.Duration(i+1)*time.Second), event.At)
+	}
+
+	require.Len(t, report.Patches, 2)
+	assert.Equal(t, "first", report.Patches[0].CommitSHA)
+	assert.Equal(t, "gpt-4o", report.Patches[0].LLM.Model)
+	assert.Equal(t, "second", report.Patches[1].CommitSHA)
+	require.NotNil(t, report.TestResult)
+	assert.Equal(t, 4, report.TestResult.PassedTests)
+	assert.Equal(t, events.ControlDecisionApprove, report.ReviewDecision)
+	require.NotNil(t, report.Cost)
+	assert.Equal(t, 900, report.Cost.LLMTokens)
+	assert.Equal(t, "feature/report", report.BranchName)
+	assert.Equal(t, "https://example.com/pulls/7", report.PullRequestURL)
+	assert.Nil(t, report.Error)
+}
+
+func TestRecorder_FailedExecutionReport(t *testing.T) {
+	recorder := newTestRecorder(t, nil)
+	ctx := context.Background()
+	executionID := events.NewExecutionID()
+
+	require.NoError(t, recorder.Record(ctx, string(events.FeatureExecutionInitialized),
+		&events.FeatureExecutionInitializedPayload{ExecutionID: executionID}))
+	require.NoError(t, recorder.Record(ctx, string(events.RepositoryCheckoutCompleted),
+		&events.RepositoryCheckoutCompletedPayload{ExecutionID: executionID}))
+	_, err := recorder.Store().Load(ctx, executionID.String())
+	require.ErrorIs(t, err, ErrNotFound, "no report before the execution ends")
+
+	require.NoError(t, recorder.Record(ctx, string(events.FeatureExecutionFailed),
+		&events.FeatureExecutionFailedPayload{
+			ExecutionID:  executionID,
+			ErrorCode:    "push_failed",
+			ErrorMessage: "remote rejected the push",
+			FailedPhase:  events.ExecutionPhaseDelivery,
+		}))
+
+	report, err := recorder.Store().Load(ctx, executionID.String())
+	require.NoError(t, err)
+	assert.Equal(t, StatusFailed, report.Status)
+	require.NotNil(t, report.Error)
+	assert.Equal(t, "push_failed", report.Error.Code)
+	assert.Equal(t, events.ExecutionPhaseDelivery, report.Error.Phase)
+	require.Len(t, report.Phases, 2)
+	// The failure closes the phase the execution was in
+	assert.Equal(t, reportStart.Add(3*time.Second), report.Phases[1].CompletedAt)
+	assert.Nil(t, report.Cost)
+	require.Len(t, report.Events, 3)
+	assert.Empty(t, report.Events[2].Phase)
+}
+
+func TestRecorder_ForgetsExecutionOnceReported(t *testing.T) {
+	recorder := newTestRecorder(t, nil)
+	ctx := context.Background()
+	executionID := events.NewExecutionID()
+
+	require.NoError(t, recorder.Record(ctx, string(events.TestExecutionCompleted),
+		&events.TestExecutionCompletedPayload{
+			ExecutionID: executionID,
+			Output:      strings.Repeat("ok\n", 1000),
+			Result:      &events.TestResult{TotalTests: 1, PassedTests: 1},
+		}))
+	kept, ok := recorder.executions[executionID.String()][0].payload.(*events.TestExecutionCompletedPayload)
+	require.True(t, ok)
+	assert.Empty(t, kept.Output, "only what the report needs is kept")
+	assert.Equal(t, 1, kept.Result.PassedTests)
+
+	// An outage is retried, so the execution's later events extend its report
+	require.NoError(t, recorder.Record(ctx, string(events.FeatureExecutionFailed),
+		&events.FeatureExecutionFailedPayload{
+			ExecutionID:    executionID,
+			Classification: events.FailureClassification{Type: events.FailureTypeInfrastructure, Retryable: true},
+		}))
+	require.Len(t, recorder.executions[executionID.String()], 2)
+
+	require.NoError(t, recorder.Record(ctx, string(events.FeatureDelivered),
+		&events.FeatureDeliveredPayload{ExecutionID: executionID, BranchName: "feature/report"}))
+	assert.Empty(t, recorder.executions)
+
+	report, err := recorder.Store().Load(ctx, executionID.String())
+	require.NoError(t, err)
+	assert.Equal(t, StatusDelivered, report.Status)
+	assert.Len(t, report.Events, 3)
+	require.NotNil(t, report.TestResult)
+	assert.Equal(t, 1, report.TestResult.PassedTests)
+}
+
+func TestRecorder_BoundsRecordedEvents(t *testing.T) {
+	recorder := newTestRecorder(t, nil)
+	ctx := context.Background()
+	executionID := events.NewExecutionID()
+
+	require.NoError(t, recorder.Record(ctx, string(events.FeatureExecutionInitialized),
+		&events.FeatureExecutionInitializedPayload{
+			ExecutionID: executionID,
+			Spec:        events.FeatureSpecification{Title: "Add report"},
+		}))
+	for range 2 * maxRecordedEvents {
+		require.NoError(t, recorder.Record(ctx, string(events.TestExecutionStarted),
+			&events.TestExecutionStartedPayload{ExecutionID: executionID}))
+	}
+
+	recorded := recorder.executions[executionID.String()]
+	require.Len(t, recorded, maxRecordedEvents)
+	assert.Equal(t, string(events.FeatureExecutionInitialized), recorded[0].Type, "the first event is kept")
+}
+
+type mockEmitter struct {
+	emitted []string
+}
+
+func (m *mockEmitter) Emit(_ context.Context, eventName string, _ any) error {
+	m.emitted = append(m.emitted, eventName)
+	return nil
+}
+
+type stubHandler struct {
+	name     string
+	executed int
+}
+
+func (h *stubHandler) Name() string                            { return h.name }
+func (h *stubHandler) PayloadType() any                        { return &events.FeatureDeliveredPayload{} }
+func (h *stubHandler) Validate(_ context.Context, _ any) error { return nil }
+
+func (h *stubHandler) Execute(_ context.Context, _ any) error {
+	h.executed++
+	return nil
+}
+
+func TestRecorder_EmitterAndHandlersRecordEachEventOnce(t *testing.T) {
+	recorder := newTestRecorder(t, nil)
+	ctx := context.Background()
+	executionID := events.NewExecutionID()
+
+	delivered := &stubHandler{name: string(events.FeatureDelivered)}
+	handlers := recorder.Handlers(delivered)
+	next := &mockEmitter{}
+	emitter := recorder.Emitter(next)
+
+	require.NoError(t, emitter.Emit(ctx, string(events.PatchGenerationCompleted),
+		&events.PatchGenerationCompletedPayload{ExecutionID: executionID}))
+	deliveredPayload := &events.FeatureDeliveredPayload{ExecutionID: executionID}
+	require.NoError(t, emitter.Emit(ctx, string(events.FeatureDelivered), deliveredPayload))
+	require.NoError(t, handlers[0].Execute(ctx, deliveredPayload))
+	// Events without an execution are not recorded
+	require.NoError(t, emitter.Emit(ctx, string(events.TestExecutionStarted), &events.TestExecutionStartedPayload{}))
+
+	assert.Equal(t, []string{
+		string(events.PatchGenerationCompleted),
+		string(events.FeatureDelivered),
+		string(events.TestExecutionStarted),
+	}, next.emitted)
+	assert.Equal(t, 1, delivered.executed)
+
+	report, err := recorder.Store().Load(ctx, executionID.String())
+	require.NoError(t, err)
+	var types []string
+	for _, event := range report.Events {
+		types = append(types, event.Type)
+	}
+	assert.Equal(t, []string{string(events.PatchGenerationCompleted), string(events.FeatureDelivered)}, types)
+}
+
+var _ frameevents.EventI = (*stubHandler)(nil)
+
+func TestHTTPHandler_ServesStoredReport(t *testing.T) {
+	store := NewArtifactStore(artifacts.NewBucketStore(memblob.OpenBucket(nil), "mem://"))
+	executionID := events.NewExecutionID()
+	artifact, err := store.Save(context.Background(), &Report{
+		ExecutionID: executionID.String(),
+		Status:      StatusCompleted,
+		Phases:      []Phase{{Name: events.ExecutionPhaseDelivery}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "report", artifact.Type)
+	assert.Equal(t, "application/json", artifact.ContentType)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/v1/executions/{id}/report", NewHTTPHandler(store))
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/executions/"+executionID.String()+"/report", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	var report Report
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&report))
+	assert.Equal(t, StatusCompleted, report.Status)
+	assert.Equal(t, events.ExecutionPhaseDelivery, report.Phases[0].Name)
+
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet,
+		"/api/v1/executions/"+events.NewExecutionID().String()+"/report", nil))
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/executions/not-an-id/report", nil))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
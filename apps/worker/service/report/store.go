@@ -0,0 +1,80 @@
+package report
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/antinvestor/builder/internal/artifacts"
+	"github.com/antinvestor/builder/internal/events"
+)
+
+const (
+	// artifactName is the artifact name of a stored execution report.
+	artifactName = "execution-report.json"
+	// artifactType is the artifact type of a stored execution report.
+	artifactType = "report"
+)
+
+// ErrNotFound is returned when no report is stored for an execution.
+var ErrNotFound = errors.New("execution report not found")
+
+// Store persists execution reports.
+type Store interface {
+	// Save stores a report, replacing any earlier report of the execution.
+	Save(ctx context.Context, report *Report) (*events.ArtifactReference, error)
+	// Load returns the stored report of an execution, or ErrNotFound.
+	Load(ctx context.Context, executionID string) (*Report, error)
+}
+
+// ArtifactStore stores each report as the execution-report.json artifact of its
+// execution, alongside the execution's other artifacts.
+type ArtifactStore struct {
+	artifacts artifacts.Store
+}
+
+// NewArtifactStore creates a report store keeping reports in store.
+func NewArtifactStore(store artifacts.Store) *ArtifactStore {
+	return &ArtifactStore{artifacts: store}
+}
+
+// Save implements Store.
+func (s *ArtifactStore) Save(ctx context.Context, report *Report) (*events.ArtifactReference, error) {
+	executionID, err := events.ParseExecutionID(report.ExecutionID)
+	if err != nil {
+		return nil, fmt.Errorf("execution report has an invalid execution id: %w", err)
+	}
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("encode execution report: %w", err)
+	}
+
+	ref, err := s.artifacts.Put(ctx, executionID, artifactName, "application/json", data)
+	if err != nil {
+		return nil, fmt.Errorf("write execution report: %w", err)
+	}
+	ref.Type = artifactType
+	return ref, nil
+}
+
+// Load implements Store.
+func (s *ArtifactStore) Load(ctx context.Context, executionID string) (*Report, error) {
+	id, err := events.ParseExecutionID(executionID)
+	if err != nil {
+		return nil, ErrNotFound
+	}
+	data, err := s.artifacts.Get(ctx, id, artifactName)
+	if errors.Is(err, artifacts.ErrNotFound) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read execution report: %w", err)
+	}
+
+	var report Report
+	if err = json.Unmarshal(data, &report); err != nil {
+		return nil, fmt.Errorf("decode execution report: %w", err)
+	}
+	return &report, nil
+}
@@ -0,0 +1,122 @@
+package repository
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// localHost is the pool key for repositories cloned from the local filesystem.
+const localHost = "local"
+
+// CheckoutPool bounds concurrent checkouts overall and per git host, so clones
+// run in parallel across executions without overwhelming a single server.
+type CheckoutPool struct {
+	slots        chan struct{}
+	perHostLimit int
+	hostLimits   map[string]int
+
+	mu    sync.Mutex
+	hosts map[string]chan struct{}
+}
+
+// NewCheckoutPool creates a pool with size total slots. perHostLimit caps each
+// host (0 = only the pool size applies) and hostLimits overrides it per host.
+func NewCheckoutPool(size, perHostLimit int, hostLimits map[string]int) *CheckoutPool {
+	if size <= 0 {
+		size = 1
+	}
+
+	limits := make(map[string]int, len(hostLimits))
+	for host, limit := range hostLimits {
+		limits[strings.ToLower(host)] = limit
+	}
+
+	return &CheckoutPool{
+		slots:        make(chan struct{}, size),
+		perHostLimit: perHostLimit,
+		hostLimits:   limits,
+		hosts:        make(map[string]chan struct{}),
+	}
+}
+
+// Acquire blocks until a slot is free both for the repository's host and in the
+// pool. The returned function releases both slots.
+func (p *CheckoutPool) Acquire(ctx context.Context, repositoryURL string) (func(), error) {
+	hostSem := p.hostSemaphore(RepositoryHost(repositoryURL))
+
+	// Take the host slot first so executions waiting on a busy host do not hold
+	// pool slots that other hosts could use.
+	if hostSem != nil {
+		select {
+		case hostSem <- struct{}{}:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	select {
+	case p.slots <- struct{}{}:
+	case <-ctx.Done():
+		if hostSem != nil {
+			<-hostSem
+		}
+		return nil, ctx.Err()
+	}
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			<-p.slots
+			if hostSem != nil {
+				<-hostSem
+			}
+		})
+	}, nil
+}
+
+// hostSemaphore returns the semaphore for a host, or nil when the host is unlimited.
+func (p *CheckoutPool) hostSemaphore(host string) chan struct{} {
+	limit := p.perHostLimit
+	if override, ok := p.hostLimits[host]; ok {
+		limit = override
+	}
+	if limit <= 0 {
+		return nil
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	sem, ok := p.hosts[host]
+	if !ok {
+		sem = make(chan struct{}, limit)
+		p.hosts[host] = sem
+	}
+	return sem
+}
+
+// RepositoryHost returns the lower-cased git host of a repository URL. It handles
+// URL forms (https://, ssh://), scp-like "user@host:path" remotes and local paths.
+func RepositoryHost(repositoryURL string) string {
+	if strings.Contains(repositoryURL, "://") {
+		parsed, err := url.Parse(repositoryURL)
+		if err != nil || parsed.Hostname() == "" {
+			return localHost
+		}
+		return strings.ToLower(parsed.Hostname())
+	}
+
+	// scp-like syntax: [user@]host:path, where the host part has no slash
+	if hostPart, _, ok := strings.Cut(repositoryURL, ":"); ok && !strings.Contains(hostPart, "/") {
+		if _, host, hasUser := strings.Cut(hostPart, "@"); hasUser {
+			hostPart = host
+		}
+		if hostPart != "" {
+			return strings.ToLower(hostPart)
+		}
+	}
+
+	return localHost
+}
@@ -0,0 +1,153 @@
+package repository
+
+import (
+	"context"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/antinvestor/builder/internal/events"
+)
+
+// CodeOwners maps repository paths to their owners, as declared in a CODEOWNERS file.
+type CodeOwners struct {
+	// Source is the CODEOWNERS file the rules were read from, empty when none was found.
+	Source string
+	rules  []codeOwnersRule
+}
+
+type codeOwnersRule struct {
+	segments []string
+	owners   []string
+}
+
+// CodeOwnersRouting is the result of mapping changed files to their owners.
+type CodeOwnersRouting struct {
+	// FileOwners are the owners of each owned file.
+	FileOwners map[string][]string
+	// Reviewers are the distinct owners across all files, sorted.
+	Reviewers []string
+	// Unowned are the files no rule assigns an owner to.
+	Unowned []string
+}
+
+// ParseCodeOwners parses CODEOWNERS content. Each line is a path pattern followed by
+// its owners; comments start with '#'. Patterns follow the gitignore-like syntax
+// GitHub uses: a leading '/' anchors to the repository root, a pattern without a
+// slash matches at any depth, "**" spans directories and a pattern naming a
+// directory covers everything beneath it.
+func ParseCodeOwners(content string) *CodeOwners {
+	owners := &CodeOwners{}
+	for line := range strings.SplitSeq(content, "\n") {
+		if comment := strings.Index(line, "#"); comment >= 0 {
+			line = line[:comment]
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		owners.rules = append(owners.rules, codeOwnersRule{
+			segments: codeOwnersSegments(fields[0]),
+			owners:   fields[1:],
+		})
+	}
+	return owners
+}
+
+// codeOwnersSegments compiles a pattern into path segments matched by matchCodeOwners.
+func codeOwnersSegments(pattern string) []string {
+	anchored := strings.HasPrefix(pattern, "/")
+	directory := strings.HasSuffix(pattern, "/")
+	pattern = strings.Trim(pattern, "/")
+	if !anchored && !strings.Contains(pattern, "/") {
+		pattern = "**/" + pattern
+	}
+
+	segments := strings.Split(pattern, "/")
+	switch last := segments[len(segments)-1]; {
+	case directory:
+		// Only the directory's contents, never a file of the same name
+		segments = append(segments, "*", "**")
+	case !strings.Contains(last, "*"):
+		// A name may be a directory, which owns everything beneath it
+		segments = append(segments, "**")
+	}
+	return segments
+}
+
+// Owners returns the owners of a file. The last matching rule wins, as on GitHub,
+// so a later rule without owners leaves the file unowned.
+func (c *CodeOwners) Owners(filePath string) []string {
+	if c == nil {
+		return nil
+	}
+	segments := strings.Split(strings.TrimPrefix(path.Clean(filePath), "/"), "/")
+	for i := len(c.rules) - 1; i >= 0; i-- {
+		if matchCodeOwners(c.rules[i].segments, segments) {
+			return c.rules[i].owners
+		}
+	}
+	return nil
+}
+
+// Route maps changed files to their owners.
+func (c *CodeOwners) Route(files []string) *CodeOwnersRouting {
+	routing := &CodeOwnersRouting{FileOwners: make(map[string][]string)}
+	for _, file := range files {
+		owners := c.Owners(file)
+		if len(owners) == 0 {
+			routing.Unowned = append(routing.Unowned, file)
+			continue
+		}
+		routing.FileOwners[file] = owners
+		for _, owner := range owners {
+			if !slices.Contains(routing.Reviewers, owner) {
+				routing.Reviewers = append(routing.Reviewers, owner)
+			}
+		}
+	}
+	slices.Sort(routing.Reviewers)
+	return routing
+}
+
+func matchCodeOwners(pattern, segments []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for skip := range len(segments) + 1 {
+				if matchCodeOwners(pattern[1:], segments[skip:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(segments) == 0 {
+			return false
+		}
+		if matched, _ := path.Match(pattern[0], segments[0]); !matched {
+			return false
+		}
+		pattern, segments = pattern[1:], segments[1:]
+	}
+	return len(segments) == 0
+}
+
+// LoadCodeOwners reads the first CODEOWNERS file found at the candidate paths in an
+// execution's workspace. Without one, the returned CodeOwners has no rules.
+func (s *Service) LoadCodeOwners(
+	ctx context.Context,
+	executionID events.ExecutionID,
+	candidates []string,
+) (*CodeOwners, error) {
+	contents, err := s.ReadFiles(ctx, executionID, candidates)
+	if err != nil {
+		return nil, err
+	}
+	for _, candidate := range candidates {
+		if content, ok := contents[candidate]; ok {
+			owners := ParseCodeOwners(content)
+			owners.Source = candidate
+			return owners, nil
+		}
+	}
+	return &CodeOwners{}, nil
+}
@@ -0,0 +1,62 @@
+package repository_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/antinvestor/builder/apps/worker/service/repository"
+)
+
+const testCodeOwners = `# Default owners
+*                     @example/maintainers
+
+# Payments
+/services/payments/   @example/payments alice@example.com
+*.sql                 @example/dba
+docs/*                @example/docs
+**/migrations         @example/dba
+/vendor/              # vendored code has no owner
+`
+
+func TestCodeOwners_ResolvesOwnersFromChangedPaths(t *testing.T) {
+	owners := repository.ParseCodeOwners(testCodeOwners)
+
+	tests := []struct {
+		path string
+		want []string
+	}{
+		{"main.go", []string{"@example/maintainers"}},
+		{"services/payments/charge.go", []string{"@example/payments", "alice@example.com"}},
+		{"services/payments/internal/refund.go", []string{"@example/payments", "alice@example.com"}},
+		{"services/payments.go", []string{"@example/maintainers"}},
+		{"db/schema.sql", []string{"@example/dba"}},
+		{"docs/index.md", []string{"@example/docs"}},
+		{"docs/guides/setup.md", []string{"@example/maintainers"}},
+		{"services/orders/migrations/001_init.go", []string{"@example/dba"}},
+		{"vendor/lib/lib.go", []string{}},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, owners.Owners(tt.path), tt.path)
+	}
+}
+
+func TestCodeOwners_RouteCollectsReviewers(t *testing.T) {
+	owners := repository.ParseCodeOwners(testCodeOwners)
+
+	routing := owners.Route([]string{"services/payments/charge.go", "db/schema.sql", "vendor/lib/lib.go"})
+
+	assert.Equal(t, []string{"@example/dba", "@example/payments", "alice@example.com"}, routing.Reviewers)
+	assert.Equal(t, map[string][]string{
+		"services/payments/charge.go": {"@example/payments", "alice@example.com"},
+		"db/schema.sql":               {"@example/dba"},
+	}, routing.FileOwners)
+	assert.Equal(t, []string{"vendor/lib/lib.go"}, routing.Unowned)
+}
+
+func TestCodeOwners_NoRulesLeavesEverythingUnowned(t *testing.T) {
+	routing := repository.ParseCodeOwners("").Route([]string{"main.go"})
+
+	assert.Empty(t, routing.Reviewers)
+	assert.Equal(t, []string{"main.go"}, routing.Unowned)
+}
@@ -0,0 +1,108 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/antinvestor/builder/internal/events"
+)
+
+// defaultCompileCheckTimeout applies when no compile check timeout is configured.
+const defaultCompileCheckTimeout = 2 * time.Minute
+
+// compileCommands maps a project marker file to the fast compile/syntax check for
+// that ecosystem, in detection order.
+var compileCommands = []struct {
+	marker  string
+	command []string
+}{
+	{marker: "go.mod", command: []string{"go", "build", "./..."}},
+	{marker: "tsconfig.json", command: []string{"npx", "--no-install", "tsc", "--noEmit"}},
+	{marker: "Cargo.toml", command: []string{"cargo", "check", "--quiet"}},
+	{marker: "pyproject.toml", command: []string{"python3", "-m", "compileall", "-q", "."}},
+	{marker: "setup.py", command: []string{"python3", "-m", "compileall", "-q", "."}},
+	{marker: "requirements.txt", command: []string{"python3", "-m", "compileall", "-q", "."}},
+}
+
+// CompileCheckResult contains the outcome of a compile check.
+type CompileCheckResult struct {
+	// Command is the command that was run; empty when the check was skipped.
+	Command string
+	// Passed is true when the workspace compiled or no check applies.
+	Passed bool
+	// Output is the combined compiler output.
+	Output string
+	// DurationMS is how long the check took.
+	DurationMS int64
+}
+
+// Skipped reports whether no compile command applied to the workspace.
+func (r *CompileCheckResult) Skipped() bool {
+	return r.Command == ""
+}
+
+// DetectCompileCommand returns the compile check for the project in dir, or nil
+// when its ecosystem is not recognised.
+func DetectCompileCommand(dir string) []string {
+	for _, candidate := range compileCommands {
+		if _, err := os.Stat(filepath.Join(dir, candidate.marker)); err == nil {
+			return candidate.command
+		}
+	}
+	return nil
+}
+
+// CompileCheck runs a fast compile/syntax check in an execution's workspace. The
+// configured CompileCheckCommand takes precedence over the detected command. A
+// failing build is reported in the result; an error means the check could not run.
+func (s *Service) CompileCheck(
+	ctx context.Context,
+	executionID events.ExecutionID,
+) (*CompileCheckResult, error) {
+	workspacePath := s.GetWorkspacePath(executionID)
+
+	command := DetectCompileCommand(workspacePath)
+	if s.cfg.CompileCheckCommand != "" {
+		command = []string{"sh", "-c", s.cfg.CompileCheckCommand}
+	}
+	if len(command) == 0 {
+		return &CompileCheckResult{Passed: true}, nil
+	}
+
+	timeout := defaultCompileCheckTimeout
+	if s.cfg.CompileCheckTimeoutSeconds > 0 {
+		timeout = time.Duration(s.cfg.CompileCheckTimeoutSeconds) * time.Second
+	}
+	checkCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	startTime := time.Now()
+	//nolint:gosec // command is a fixed toolchain invocation or operator configuration
+	cmd := exec.CommandContext(checkCtx, command[0], command[1:]...)
+	cmd.Dir = workspacePath
+	output, err := cmd.CombinedOutput()
+
+	result := &CompileCheckResult{
+		Command:    strings.Join(command, " "),
+		Passed:     err == nil,
+		Output:     string(output),
+		DurationMS: time.Since(startTime).Milliseconds(),
+	}
+
+	if checkCtx.Err() != nil {
+		return nil, fmt.Errorf("compile check timed out after %s", timeout)
+	}
+
+	var exitErr *exec.ExitError
+	if err != nil && !errors.As(err, &exitErr) {
+		return nil, fmt.Errorf("run compile check %q: %w", result.Command, err)
+	}
+
+	return result, nil
+}
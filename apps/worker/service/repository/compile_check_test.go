@@ -0,0 +1,83 @@
+package repository_test
+
+import (
+	"context"
+	"os/exec"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	appconfig "github.com/antinvestor/builder/apps/worker/config"
+	"github.com/antinvestor/builder/apps/worker/service/repository"
+	"github.com/antinvestor/builder/internal/events"
+)
+
+// setupGoWorkspace checks out an origin repository containing a Go module.
+func setupGoWorkspace(t *testing.T, cfg *appconfig.WorkerConfig) (*repository.Service, events.ExecutionID, string) {
+	t.Helper()
+	for _, tool := range []string{"git", "go"} {
+		if _, err := exec.LookPath(tool); err != nil {
+			t.Skipf("%s not available", tool)
+		}
+	}
+
+	origin := t.TempDir()
+	runGit(t, origin, "init", "-q", "-b", "main")
+	writeFile(t, origin, "go.mod", "module example.com/calc\n\ngo 1.21\n")
+	writeFile(t, origin, "calc.go", "package calc\n\nfunc Add(a, b int) int { return a + b }\n")
+	runGit(t, origin, "add", "-A")
+	runGit(t, origin, "commit", "-q", "-m", "initial")
+
+	cfg.WorkspaceBasePath = t.TempDir()
+	cfg.MaxConcurrentClones = 1
+	cfg.CloneTimeoutSeconds = 30
+	svc := repository.NewService(cfg, repository.NewWorkspaceRepository(context.Background(), nil))
+
+	executionID := events.NewExecutionID()
+	checkout, err := svc.Checkout(context.Background(), &repository.CheckoutRequest{
+		ExecutionID:   executionID,
+		RepositoryURL: origin,
+		Branch:        "main",
+	})
+	require.NoError(t, err)
+
+	return svc, executionID, checkout.WorkspacePath
+}
+
+func TestCompileCheck_GoModule(t *testing.T) {
+	svc, executionID, workspace := setupGoWorkspace(t, &appconfig.WorkerConfig{})
+
+	result, err := svc.CompileCheck(context.Background(), executionID)
+	require.NoError(t, err)
+	assert.True(t, result.Passed, result.Output)
+	assert.Equal(t, "go build ./...", result.Command)
+
+	writeFile(t, workspace, "calc.go", "package calc\n\nfunc Add(a, b int) int { return a + }\n")
+
+	result, err = svc.CompileCheck(context.Background(), executionID)
+	require.NoError(t, err)
+	assert.False(t, result.Passed)
+	assert.Contains(t, result.Output, "calc.go:3")
+}
+
+func TestCompileCheck_ConfiguredCommand(t *testing.T) {
+	cfg := &appconfig.WorkerConfig{CompileCheckCommand: "echo custom check failed; exit 1"}
+	svc, executionID, _ := setupGoWorkspace(t, cfg)
+
+	result, err := svc.CompileCheck(context.Background(), executionID)
+	require.NoError(t, err)
+	assert.False(t, result.Passed)
+	assert.Equal(t, "custom check failed\n", result.Output)
+}
+
+func TestDetectCompileCommand(t *testing.T) {
+	dir := t.TempDir()
+	assert.Nil(t, repository.DetectCompileCommand(dir))
+
+	writeFile(t, dir, "requirements.txt", "requests\n")
+	assert.Equal(t, []string{"python3", "-m", "compileall", "-q", "."}, repository.DetectCompileCommand(dir))
+
+	writeFile(t, dir, "tsconfig.json", "{}\n")
+	assert.Equal(t, []string{"npx", "--no-install", "tsc", "--noEmit"}, repository.DetectCompileCommand(dir))
+}
@@ -0,0 +1,118 @@
+package repository
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+)
+
+// ErrInvalidEncoding is returned when patch content is not valid UTF-8 and the
+// target is not a known binary file.
+var ErrInvalidEncoding = errors.New("invalid patch encoding")
+
+// utf8BOM is the byte order mark some editors prefix UTF-8 files with.
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
+// binaryExtensions are file types whose content is written byte for byte.
+var binaryExtensions = map[string]bool{
+	".png": true, ".jpg": true, ".jpeg": true, ".gif": true, ".ico": true, ".webp": true,
+	".pdf": true, ".zip": true, ".gz": true, ".tgz": true, ".jar": true, ".wasm": true,
+	".woff": true, ".woff2": true, ".ttf": true, ".otf": true, ".so": true, ".exe": true,
+}
+
+// EncodingIssue names a normalization applied to patch content before it was written.
+type EncodingIssue string
+
+const (
+	// EncodingIssueLineEndings means line endings were converted to the file's convention.
+	EncodingIssueLineEndings EncodingIssue = "line_endings_normalized"
+
+	// EncodingIssueBOMRestored means the byte order mark of the existing file was kept.
+	EncodingIssueBOMRestored EncodingIssue = "bom_restored"
+
+	// EncodingIssueBOMRemoved means a byte order mark the existing file lacked was dropped.
+	EncodingIssueBOMRemoved EncodingIssue = "bom_removed"
+)
+
+// normalizePatchContent validates new content for a file and adapts it to the
+// conventions of the existing content: its byte order mark and line endings. A new
+// file keeps its BOM and takes the dominant line ending of its own content. Binary
+// files are returned unchanged.
+func normalizePatchContent(
+	filePath string,
+	existing []byte,
+	exists bool,
+	content string,
+) ([]byte, []EncodingIssue, error) {
+	data := []byte(content)
+	if isBinaryFile(filePath, existing) {
+		return data, nil, nil
+	}
+	if !utf8.Valid(data) {
+		return nil, nil, fmt.Errorf("%w: %s: not valid UTF-8 at byte %d",
+			ErrInvalidEncoding, filePath, invalidUTF8Offset(data))
+	}
+
+	var issues []EncodingIssue
+	hasBOM := bytes.HasPrefix(data, utf8BOM)
+	body := bytes.TrimPrefix(data, utf8BOM)
+
+	wantBOM := hasBOM
+	reference := body
+	if exists {
+		wantBOM = bytes.HasPrefix(existing, utf8BOM)
+		reference = existing
+		switch {
+		case wantBOM && !hasBOM:
+			issues = append(issues, EncodingIssueBOMRestored)
+		case !wantBOM && hasBOM:
+			issues = append(issues, EncodingIssueBOMRemoved)
+		}
+	}
+
+	normalized := convertLineEndings(body, usesCRLF(reference))
+	if !bytes.Equal(normalized, body) {
+		issues = append(issues, EncodingIssueLineEndings)
+	}
+
+	if wantBOM {
+		normalized = append(append([]byte{}, utf8BOM...), normalized...)
+	}
+	return normalized, issues, nil
+}
+
+// isBinaryFile reports whether a file is binary by extension, or because its
+// existing content contains a NUL byte.
+func isBinaryFile(filePath string, existing []byte) bool {
+	return binaryExtensions[strings.ToLower(filepath.Ext(filePath))] || bytes.IndexByte(existing, 0) >= 0
+}
+
+// usesCRLF reports whether most line endings in content are CRLF.
+func usesCRLF(content []byte) bool {
+	crlf := bytes.Count(content, []byte("\r\n"))
+	return crlf > 0 && crlf*2 >= bytes.Count(content, []byte("\n"))
+}
+
+// convertLineEndings rewrites every line ending to CRLF or LF.
+func convertLineEndings(content []byte, crlf bool) []byte {
+	lf := bytes.ReplaceAll(content, []byte("\r\n"), []byte("\n"))
+	if !crlf {
+		return lf
+	}
+	return bytes.ReplaceAll(lf, []byte("\n"), []byte("\r\n"))
+}
+
+// invalidUTF8Offset returns the offset of the first invalid UTF-8 sequence.
+func invalidUTF8Offset(data []byte) int {
+	for offset := 0; offset < len(data); {
+		r, size := utf8.DecodeRune(data[offset:])
+		if r == utf8.RuneError && size <= 1 {
+			return offset
+		}
+		offset += size
+	}
+	return len(data)
+}
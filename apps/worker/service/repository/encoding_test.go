@@ -0,0 +1,99 @@
+package repository_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/antinvestor/builder/apps/worker/service/repository"
+	"github.com/antinvestor/builder/internal/events"
+)
+
+func readWorkspaceFile(t *testing.T, workspace, name string) string {
+	t.Helper()
+	content, err := os.ReadFile(filepath.Join(workspace, name))
+	require.NoError(t, err)
+	return string(content)
+}
+
+func TestApplyPatch_KeepsCRLFLineEndings(t *testing.T) {
+	svc, executionID, _, workspace := setupFeatureBranch(t)
+	writeFile(t, workspace, "windows.txt", "line one\r\nline two\r\n")
+
+	require.NoError(t, svc.ApplyPatch(context.Background(), executionID, &events.Patch{
+		FilePath:   "windows.txt",
+		Action:     events.FileActionModify,
+		NewContent: "line one\nline two\nline three\n",
+	}))
+
+	assert.Equal(t, "line one\r\nline two\r\nline three\r\n", readWorkspaceFile(t, workspace, "windows.txt"))
+}
+
+func TestApplyPatch_NormalizesMixedLineEndingsInNewFile(t *testing.T) {
+	svc, executionID, _, workspace := setupFeatureBranch(t)
+
+	require.NoError(t, svc.ApplyPatch(context.Background(), executionID, &events.Patch{
+		FilePath:   "new.txt",
+		Action:     events.FileActionCreate,
+		NewContent: "a\nb\r\nc\n",
+	}))
+
+	assert.Equal(t, "a\nb\nc\n", readWorkspaceFile(t, workspace, "new.txt"))
+}
+
+func TestApplyPatch_PreservesByteOrderMark(t *testing.T) {
+	svc, executionID, _, workspace := setupFeatureBranch(t)
+	writeFile(t, workspace, "bom.cs", "\uFEFFclass A {}\n")
+
+	require.NoError(t, svc.ApplyPatch(context.Background(), executionID, &events.Patch{
+		FilePath:   "bom.cs",
+		Action:     events.FileActionModify,
+		NewContent: "class B {}\n",
+	}))
+	assert.Equal(t, "\uFEFFclass B {}\n", readWorkspaceFile(t, workspace, "bom.cs"))
+
+	// A BOM the file did not have is dropped, and an existing one is never doubled
+	require.NoError(t, svc.ApplyPatch(context.Background(), executionID, &events.Patch{
+		FilePath:   "README.md",
+		Action:     events.FileActionModify,
+		NewContent: "\uFEFFdocs\n",
+	}))
+	assert.Equal(t, "docs\n", readWorkspaceFile(t, workspace, "README.md"))
+
+	require.NoError(t, svc.ApplyPatch(context.Background(), executionID, &events.Patch{
+		FilePath:   "bom.cs",
+		Action:     events.FileActionModify,
+		NewContent: "\uFEFFclass C {}\n",
+	}))
+	assert.Equal(t, "\uFEFFclass C {}\n", readWorkspaceFile(t, workspace, "bom.cs"))
+}
+
+func TestApplyPatch_RejectsInvalidUTF8(t *testing.T) {
+	svc, executionID, _, workspace := setupFeatureBranch(t)
+
+	err := svc.ApplyPatch(context.Background(), executionID, &events.Patch{
+		FilePath:   "README.md",
+		Action:     events.FileActionModify,
+		NewContent: "docs \xff\xfe broken\n",
+	})
+	require.ErrorIs(t, err, repository.ErrInvalidEncoding)
+	assert.Contains(t, err.Error(), "README.md")
+	assert.Equal(t, "docs\n", readWorkspaceFile(t, workspace, "README.md"), "the file is left untouched")
+}
+
+func TestApplyPatch_WritesBinaryBlobUnchanged(t *testing.T) {
+	svc, executionID, _, workspace := setupFeatureBranch(t)
+	blob := "\x89PNG\r\n\x1a\n\x00\xff\xfe"
+
+	require.NoError(t, svc.ApplyPatch(context.Background(), executionID, &events.Patch{
+		FilePath:   "logo.png",
+		Action:     events.FileActionCreate,
+		NewContent: blob,
+	}))
+
+	assert.Equal(t, blob, readWorkspaceFile(t, workspace, "logo.png"))
+}
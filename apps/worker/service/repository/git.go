@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
@@ -9,6 +10,8 @@ import (
 	"strings"
 	"time"
 
+	"github.com/pitabwire/util"
+
 	appconfig "github.com/antinvestor/builder/apps/worker/config"
 	"github.com/antinvestor/builder/internal/events"
 )
@@ -20,13 +23,19 @@ const (
 	filePermissions = 0o600
 )
 
+// ErrNoChanges is returned when a commit is requested but the workspace has no changes.
+var ErrNoChanges = errors.New("no changes to commit")
+
 // Service handles git repository operations.
 type Service struct {
 	cfg           *appconfig.WorkerConfig
 	workspaceRepo WorkspaceRepository
 
-	// Semaphore for limiting concurrent clones
-	cloneSem chan struct{}
+	// Pool limiting concurrent clones overall and per git host
+	checkoutPool *CheckoutPool
+
+	// Cached file tree and symbol table of each workspace
+	indexes *indexCache
 }
 
 // NewService creates a new repository service.
@@ -37,7 +46,12 @@ func NewService(
 	return &Service{
 		cfg:           cfg,
 		workspaceRepo: workspaceRepo,
-		cloneSem:      make(chan struct{}, cfg.MaxConcurrentClones),
+		checkoutPool: NewCheckoutPool(
+			cfg.MaxConcurrentClones,
+			cfg.MaxConcurrentClonesPerHost,
+			cfg.CloneHostLimits,
+		),
+		indexes: newIndexCache(),
 	}
 }
 
@@ -55,15 +69,25 @@ func NewRepositoryService(cfg *appconfig.WorkerConfig, workspaceRepo WorkspaceRe
 type CheckoutRequest struct {
 	ExecutionID   events.ExecutionID
 	RepositoryURL string
-	Branch        string
-	CommitSHA     string
+	// Branch to check out; empty checks out the remote's default branch.
+	Branch    string
+	CommitSHA string
+	// Submodules and LFS override the configured checkout options when set.
+	Submodules *bool
+	LFS        *bool
 }
 
 // CheckoutResult contains the result of a checkout operation.
 type CheckoutResult struct {
-	WorkspacePath  string
-	CommitSHA      string
-	Branch         string
+	WorkspacePath string
+	CommitSHA     string
+	Branch        string
+	// DefaultBranch is the detected default branch when no branch was requested.
+	DefaultBranch string
+	// Submodules is set when submodules were initialized.
+	Submodules *events.SubmoduleStatus
+	// LFS is set when Git LFS objects were fetched.
+	LFS            *events.LFSStatus
 	CheckoutTimeMS int64
 }
 
@@ -74,15 +98,15 @@ func (s *Service) Checkout(
 ) (*CheckoutResult, error) {
 	startTime := time.Now()
 
-	// Acquire semaphore
-	select {
-	case s.cloneSem <- struct{}{}:
-		defer func() { <-s.cloneSem }()
-	case <-ctx.Done():
-		return nil, ctx.Err()
+	// Acquire a checkout slot for the repository's host
+	release, err := s.checkoutPool.Acquire(ctx, req.RepositoryURL)
+	if err != nil {
+		return nil, err
 	}
+	defer release()
 
 	// Create workspace directory
+	s.InvalidateIndex(req.ExecutionID)
 	workspacePath := filepath.Join(s.cfg.WorkspaceBasePath, req.ExecutionID.String())
 	if err := os.MkdirAll(workspacePath, dirPermissions); err != nil {
 		return nil, fmt.Errorf("create workspace directory: %w", err)
@@ -95,10 +119,21 @@ func (s *Service) Checkout(
 	)
 	defer cancel()
 
+	// Resolve the remote's default branch when none was requested
+	branch := req.Branch
+	var defaultBranch string
+	if branch == "" {
+		defaultBranch, err = s.DetectDefaultBranch(cloneCtx, req.RepositoryURL)
+		if err != nil {
+			return nil, err
+		}
+		branch = defaultBranch
+	}
+
 	args := []string{
 		"clone",
 		"--branch",
-		req.Branch,
+		branch,
 		"--single-branch",
 		"--depth",
 		"100",
@@ -106,14 +141,29 @@ func (s *Service) Checkout(
 		workspacePath,
 	}
 
+	// LFS objects are fetched in one batch afterwards rather than per file during clone
 	cmd := exec.CommandContext(cloneCtx, "git", args...)
-	cmd.Env = s.buildGitEnv()
+	cmd.Env = append(s.buildGitEnv(), "GIT_LFS_SKIP_SMUDGE=1")
 
 	output, err := cmd.CombinedOutput()
 	if err != nil {
 		return nil, fmt.Errorf("git clone failed: %w: %s", err, string(output))
 	}
 
+	var submodules *events.SubmoduleStatus
+	if resolveCheckoutOption(req.Submodules, s.cfg.CheckoutSubmodules) {
+		if submodules, err = s.initSubmodules(cloneCtx, workspacePath); err != nil {
+			return nil, err
+		}
+	}
+
+	var lfs *events.LFSStatus
+	if resolveCheckoutOption(req.LFS, s.cfg.CheckoutLFS) {
+		if lfs, err = s.fetchLFSObjects(cloneCtx, workspacePath); err != nil {
+			return nil, err
+		}
+	}
+
 	// Get the current commit SHA
 	commitSHA := req.CommitSHA
 	if commitSHA == "" {
@@ -131,7 +181,7 @@ func (s *Service) Checkout(
 		ExecutionID:   req.ExecutionID.String(),
 		LocalPath:     workspacePath,
 		RepositoryURL: req.RepositoryURL,
-		Branch:        req.Branch,
+		Branch:        branch,
 		CommitSHA:     commitSHA,
 		CreatedAt:     time.Now(),
 	}
@@ -143,11 +193,38 @@ func (s *Service) Checkout(
 	return &CheckoutResult{
 		WorkspacePath:  workspacePath,
 		CommitSHA:      commitSHA,
-		Branch:         req.Branch,
+		Branch:         branch,
+		DefaultBranch:  defaultBranch,
+		Submodules:     submodules,
+		LFS:            lfs,
 		CheckoutTimeMS: time.Since(startTime).Milliseconds(),
 	}, nil
 }
 
+// DetectDefaultBranch returns the branch the remote's HEAD points to.
+func (s *Service) DetectDefaultBranch(ctx context.Context, repositoryURL string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", "ls-remote", "--symref", repositoryURL, "HEAD")
+	cmd.Env = s.buildGitEnv()
+
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("detect default branch: %w", err)
+	}
+
+	// The symref line reads "ref: refs/heads/<branch>\tHEAD"
+	for line := range strings.SplitSeq(string(output), "\n") {
+		ref, found := strings.CutPrefix(line, "ref: ")
+		if !found {
+			continue
+		}
+		ref, _, _ = strings.Cut(ref, "\t")
+		if branch, ok := strings.CutPrefix(ref, "refs/heads/"); ok && branch != "" {
+			return branch, nil
+		}
+	}
+	return "", fmt.Errorf("detect default branch: remote %s does not advertise HEAD", repositoryURL)
+}
+
 // GetWorkspace retrieves a workspace by execution ID.
 func (s *Service) GetWorkspace(
 	ctx context.Context,
@@ -192,52 +269,33 @@ func (s *Service) ReadFiles(
 	return contents, nil
 }
 
-// GetProjectStructure returns the project structure as a string.
+// GetProjectStructure returns the project structure as a string, from the
+// workspace's cached index.
 func (s *Service) GetProjectStructure(
 	ctx context.Context,
 	executionID events.ExecutionID,
 ) (string, error) {
-	workspacePath := s.GetWorkspacePath(executionID)
-
-	// Use tree command if available, otherwise fall back to find
-	cmd := exec.CommandContext(
-		ctx,
-		"tree",
-		"-L",
-		"4",
-		"--noreport",
-		"-I",
-		"node_modules|.git|vendor|__pycache__|.venv",
-	)
-	cmd.Dir = workspacePath
-
-	output, err := cmd.Output()
+	index, err := s.Index(ctx, executionID)
 	if err != nil {
-		// Fallback to find
-		cmd = exec.CommandContext(
-			ctx,
-			"find",
-			".",
-			"-type",
-			"f",
-			"-not",
-			"-path",
-			"./.git/*",
-			"-not",
-			"-path",
-			"./node_modules/*",
-		)
-		cmd.Dir = workspacePath
-		output, err = cmd.Output()
-		if err != nil {
-			return "", fmt.Errorf("get project structure: %w", err)
-		}
+		return "", err
 	}
+	return index.Structure, nil
+}
 
-	return string(output), nil
+// GetRepositoryContext returns the project structure and top-level declarations
+// of a workspace, from its cached index.
+func (s *Service) GetRepositoryContext(
+	ctx context.Context,
+	executionID events.ExecutionID,
+) (string, error) {
+	index, err := s.Index(ctx, executionID)
+	if err != nil {
+		return "", err
+	}
+	return index.RepositoryContext(), nil
 }
 
-// CleanupWorkspace removes a workspace.
+// CleanupWorkspace removes a workspace and its cached index.
 func (s *Service) CleanupWorkspace(
 	ctx context.Context,
 	executionID events.ExecutionID,
@@ -246,6 +304,7 @@ func (s *Service) CleanupWorkspace(
 	if err != nil {
 		return err
 	}
+	defer s.indexes.evict(executionID)
 
 	// Remove directory
 	if rmErr := os.RemoveAll(workspace.LocalPath); rmErr != nil {
@@ -256,7 +315,9 @@ func (s *Service) CleanupWorkspace(
 	return s.workspaceRepo.Delete(ctx, executionID.String())
 }
 
-// ApplyPatch applies a patch to a file in the workspace.
+// ApplyPatch applies a patch to a file in the workspace. Text content must be valid
+// UTF-8 and is written with the existing file's byte order mark and line endings;
+// known-binary files are written unchanged.
 func (s *Service) ApplyPatch(
 	ctx context.Context,
 	executionID events.ExecutionID,
@@ -266,6 +327,7 @@ func (s *Service) ApplyPatch(
 	if err != nil {
 		return err
 	}
+	defer s.InvalidateIndex(executionID)
 
 	filePath := filepath.Join(workspace.LocalPath, patch.FilePath)
 
@@ -275,8 +337,23 @@ func (s *Service) ApplyPatch(
 		if mkdirErr := os.MkdirAll(filepath.Dir(filePath), dirPermissions); mkdirErr != nil {
 			return fmt.Errorf("create parent directory: %w", mkdirErr)
 		}
+		existing, readErr := os.ReadFile(filePath)
+		if readErr != nil && !os.IsNotExist(readErr) {
+			return fmt.Errorf("read file: %w", readErr)
+		}
+		content, issues, encErr := normalizePatchContent(patch.FilePath, existing, readErr == nil, patch.NewContent)
+		if encErr != nil {
+			return encErr
+		}
+		if len(issues) > 0 {
+			util.Log(ctx).Info("normalized patch encoding",
+				"execution_id", executionID.String(),
+				"file", patch.FilePath,
+				"issues", issues,
+			)
+		}
 		// Write new content
-		if writeErr := os.WriteFile(filePath, []byte(patch.NewContent), filePermissions); writeErr != nil {
+		if writeErr := os.WriteFile(filePath, content, filePermissions); writeErr != nil {
 			return fmt.Errorf("write file: %w", writeErr)
 		}
 
@@ -310,15 +387,28 @@ func (s *Service) CreateCommit(
 		return nil, fmt.Errorf("git add failed: %w: %s", err, string(output))
 	}
 
+	return commitStaged(ctx, workspacePath, message)
+}
+
+// commitStaged commits the changes staged in the workspace, returning
+// ErrNoChanges when nothing is staged.
+func commitStaged(ctx context.Context, workspacePath, message string) (*events.CommitInfo, error) {
+	// An empty diff means the patches left the tree unchanged
+	diffCmd := exec.CommandContext(ctx, "git", "diff", "--cached", "--quiet")
+	diffCmd.Dir = workspacePath
+	diffErr := diffCmd.Run()
+	if diffErr == nil {
+		return nil, ErrNoChanges
+	}
+	var exitErr *exec.ExitError
+	if !errors.As(diffErr, &exitErr) {
+		return nil, fmt.Errorf("git diff failed: %w", diffErr)
+	}
+
 	// Create commit
 	commitCmd := exec.CommandContext(ctx, "git", "commit", "-m", message)
 	commitCmd.Dir = workspacePath
-	commitCmd.Env = append(os.Environ(),
-		"GIT_AUTHOR_NAME=Feature Service",
-		"GIT_AUTHOR_EMAIL=feature-service@example.com",
-		"GIT_COMMITTER_NAME=Feature Service",
-		"GIT_COMMITTER_EMAIL=feature-service@example.com",
-	)
+	commitCmd.Env = append(os.Environ(), commitIdentityEnv()...)
 	if output, err := commitCmd.CombinedOutput(); err != nil {
 		return nil, fmt.Errorf("git commit failed: %w: %s", err, string(output))
 	}
@@ -348,22 +438,279 @@ func (s *Service) CreateCommit(
 	}, nil
 }
 
-// PushBranch pushes the feature branch to the remote.
+// PushBranch pushes the feature branch to the remote and returns the SHA of the
+// commit the remote branch now points at.
 func (s *Service) PushBranch(
 	ctx context.Context,
 	executionID events.ExecutionID,
 	branchName string,
-) error {
+) (string, error) {
 	workspacePath := s.GetWorkspacePath(executionID)
 
-	pushCmd := exec.CommandContext(ctx, "git", "push", "-u", "origin", branchName)
+	// Resolved before pushing, so a successful push always reports its commit
+	shaCmd := exec.CommandContext(ctx, "git", "rev-parse", "refs/heads/"+branchName)
+	shaCmd.Dir = workspacePath
+	shaOutput, err := shaCmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("resolve branch to push: %w", err)
+	}
+	localSHA := strings.TrimSpace(string(shaOutput))
+
+	pushCmd := exec.CommandContext(ctx, "git", "push", "--porcelain", "-u", "origin", branchName)
 	pushCmd.Dir = workspacePath
 	pushCmd.Env = s.buildGitEnv()
 
-	if output, err := pushCmd.CombinedOutput(); err != nil {
-		return fmt.Errorf("git push failed: %w: %s", err, string(output))
+	output, err := pushCmd.CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("git push failed: %w: %s", err, string(output))
+	}
+
+	// The push reports abbreviated SHAs, and none for a new or unchanged branch
+	reported := pushedCommitSHA(string(output), branchName)
+	if reported == "" || strings.HasPrefix(localSHA, reported) {
+		return localSHA, nil
+	}
+	return reported, nil
+}
+
+// pushedCommitSHA returns the commit a porcelain push reports the remote branch
+// was updated to, or "" when it reports none. Updated refs are reported as
+// "<flag>\t<src>:<dst>\t<old>..<new>", or "<old>...<new>" when forced.
+func pushedCommitSHA(output, branchName string) string {
+	ref := "refs/heads/" + branchName
+	for line := range strings.Lines(output) {
+		fields := strings.Split(strings.TrimRight(line, "\r\n"), "\t")
+		if len(fields) < 3 || !strings.HasSuffix(fields[1], ":"+ref) {
+			continue
+		}
+		summary, _, _ := strings.Cut(fields[2], " ")
+		if idx := strings.LastIndex(summary, ".."); idx >= 0 {
+			return summary[idx+2:]
+		}
+	}
+	return ""
+}
+
+// DeliverFiles commits only the given files, as they are on the current branch, onto
+// a new branch created from the workspace base commit and pushes it. The current
+// branch is restored afterwards, so files left out remain available for iteration.
+func (s *Service) DeliverFiles(
+	ctx context.Context,
+	executionID events.ExecutionID,
+	branchName string,
+	files []string,
+	message string,
+) (*events.CommitInfo, error) {
+	workspace, err := s.workspaceRepo.GetByExecutionID(ctx, executionID.String())
+	if err != nil {
+		return nil, err
+	}
+
+	sourceBranch, err := s.GetCurrentBranch(ctx, executionID)
+	if err != nil {
+		return nil, err
 	}
 
+	if runErr := s.runGit(ctx, workspace.LocalPath, "checkout", "-B", branchName, workspace.CommitSHA); runErr != nil {
+		return nil, runErr
+	}
+	defer func() {
+		// Restore the iteration branch even if delivery fails midway.
+		_ = s.runGit(context.WithoutCancel(ctx), workspace.LocalPath, "checkout", "-f", sourceBranch)
+	}()
+
+	for _, file := range files {
+		if !isSubPath(workspace.LocalPath, filepath.Join(workspace.LocalPath, file)) {
+			return nil, fmt.Errorf("file outside workspace: %s", file)
+		}
+
+		// Files deleted on the source branch are removed; others are taken from it.
+		if s.runGit(ctx, workspace.LocalPath, "cat-file", "-e", sourceBranch+":"+file) != nil {
+			if rmErr := s.runGit(ctx, workspace.LocalPath, "rm", "-q", "--ignore-unmatch", "--", file); rmErr != nil {
+				return nil, rmErr
+			}
+			continue
+		}
+		if coErr := s.runGit(ctx, workspace.LocalPath, "checkout", sourceBranch, "--", file); coErr != nil {
+			return nil, coErr
+		}
+	}
+
+	// Only the delivered files were staged above; other changes in the working
+	// tree belong to the iteration and stay out of the delivered commit
+	commit, err := commitStaged(ctx, workspace.LocalPath, message)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, pushErr := s.PushBranch(ctx, executionID, branchName); pushErr != nil {
+		return nil, pushErr
+	}
+
+	return commit, nil
+}
+
+// RebaseResult describes the outcome of syncing the feature branch with the latest base.
+type RebaseResult struct {
+	// BaseBranch is the branch the feature branch was synced with.
+	BaseBranch string
+
+	// BaseCommitSHA is the base commit at checkout; empty when syncing with
+	// another remote branch.
+	BaseCommitSHA string
+
+	// LatestBaseCommitSHA is the current head of the remote branch.
+	LatestBaseCommitSHA string
+
+	// Strategy is how the base was taken in.
+	Strategy events.BaseSyncStrategy
+
+	// Rebased is true when the base had advanced and the branch was replayed onto it.
+	Rebased bool
+
+	// Merged is true when the base had advanced and was merged into the branch.
+	Merged bool
+
+	// ConflictingFiles are the files that could not be rebased or merged cleanly.
+	ConflictingFiles []string
+}
+
+// Conflicted reports whether the rebase or merge stopped on conflicts.
+func (r *RebaseResult) Conflicted() bool {
+	return len(r.ConflictingFiles) > 0
+}
+
+// RebaseOntoBase fetches the base branch and, if it advanced since checkout,
+// rebases the current branch onto it. On conflict the rebase is aborted so the
+// branch is left as it was, and the conflicting files are reported.
+func (s *Service) RebaseOntoBase(
+	ctx context.Context,
+	executionID events.ExecutionID,
+) (*RebaseResult, error) {
+	return s.SyncWithBase(ctx, executionID, events.BaseSyncRebase)
+}
+
+// SyncWithBase fetches the base branch and, if it advanced since checkout and
+// the current branch does not contain it yet, rebases the branch onto it or
+// merges it in, per strategy. On conflict the rebase or merge is aborted so the
+// branch is left as it was, and the conflicting files are reported.
+func (s *Service) SyncWithBase(
+	ctx context.Context,
+	executionID events.ExecutionID,
+	strategy events.BaseSyncStrategy,
+) (*RebaseResult, error) {
+	return s.syncWithBranch(ctx, executionID, "", strategy)
+}
+
+// SyncWithRemoteBranch takes in commits of the remote branch the current branch
+// is missing, such as those that make a push of it non-fast-forward, the same
+// way SyncWithBase takes in the base branch.
+func (s *Service) SyncWithRemoteBranch(
+	ctx context.Context,
+	executionID events.ExecutionID,
+	branchName string,
+	strategy events.BaseSyncStrategy,
+) (*RebaseResult, error) {
+	return s.syncWithBranch(ctx, executionID, branchName, strategy)
+}
+
+// syncWithBranch rebases the current branch onto, or merges in, a remote branch,
+// the base branch when branchName is empty.
+func (s *Service) syncWithBranch(
+	ctx context.Context,
+	executionID events.ExecutionID,
+	branchName string,
+	strategy events.BaseSyncStrategy,
+) (*RebaseResult, error) {
+	if strategy != events.BaseSyncRebase && strategy != events.BaseSyncMerge {
+		return nil, fmt.Errorf("unknown base sync strategy: %q", strategy)
+	}
+
+	workspace, err := s.workspaceRepo.GetByExecutionID(ctx, executionID.String())
+	if err != nil {
+		return nil, err
+	}
+	if branchName == "" {
+		branchName = workspace.Branch
+	}
+
+	fetchCmd := exec.CommandContext(ctx, "git", "fetch", "origin", branchName)
+	fetchCmd.Dir = workspace.LocalPath
+	fetchCmd.Env = s.buildGitEnv()
+	if output, fetchErr := fetchCmd.CombinedOutput(); fetchErr != nil {
+		return nil, fmt.Errorf("git fetch failed: %w: %s", fetchErr, string(output))
+	}
+
+	shaCmd := exec.CommandContext(ctx, "git", "rev-parse", "FETCH_HEAD")
+	shaCmd.Dir = workspace.LocalPath
+	shaOutput, err := shaCmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("get base commit SHA: %w", err)
+	}
+
+	result := &RebaseResult{
+		BaseBranch:          branchName,
+		LatestBaseCommitSHA: strings.TrimSpace(string(shaOutput)),
+		Strategy:            strategy,
+	}
+	if branchName == workspace.Branch {
+		result.BaseCommitSHA = workspace.CommitSHA
+	}
+
+	// Nothing to do if the base has not moved or the branch already contains it.
+	if result.LatestBaseCommitSHA == result.BaseCommitSHA ||
+		s.runGit(ctx, workspace.LocalPath, "merge-base", "--is-ancestor", result.LatestBaseCommitSHA, "HEAD") == nil {
+		return result, nil
+	}
+
+	args := []string{"rebase", result.LatestBaseCommitSHA}
+	if strategy == events.BaseSyncMerge {
+		args = []string{"merge", "--no-edit", result.LatestBaseCommitSHA}
+	}
+	syncCmd := exec.CommandContext(ctx, "git", args...)
+	syncCmd.Dir = workspace.LocalPath
+	syncCmd.Env = append(os.Environ(), commitIdentityEnv()...)
+	syncOutput, syncErr := syncCmd.CombinedOutput()
+	if syncErr == nil {
+		s.InvalidateIndex(executionID)
+		result.Rebased = strategy == events.BaseSyncRebase
+		result.Merged = strategy == events.BaseSyncMerge
+		return result, nil
+	}
+
+	conflictCmd := exec.CommandContext(ctx, "git", "diff", "--name-only", "--diff-filter=U")
+	conflictCmd.Dir = workspace.LocalPath
+	conflictOutput, _ := conflictCmd.Output()
+
+	// Leave the branch exactly as it was before the attempt.
+	if abortErr := s.runGit(context.WithoutCancel(ctx), workspace.LocalPath, args[0], "--abort"); abortErr != nil {
+		return nil, abortErr
+	}
+
+	result.ConflictingFiles = strings.Fields(string(conflictOutput))
+	if !result.Conflicted() {
+		return nil, fmt.Errorf("git %s failed: %w: %s", args[0], syncErr, string(syncOutput))
+	}
+	return result, nil
+}
+
+// commitIdentityEnv returns the author and committer identity for service commits.
+func commitIdentityEnv() []string {
+	return []string{
+		"GIT_AUTHOR_NAME=Feature Service",
+		"GIT_AUTHOR_EMAIL=feature-service@example.com",
+		"GIT_COMMITTER_NAME=Feature Service",
+		"GIT_COMMITTER_EMAIL=feature-service@example.com",
+	}
+}
+
+// runGit runs a git command in the workspace directory.
+func (s *Service) runGit(ctx context.Context, dir string, args ...string) error {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("git %s failed: %w: %s", args[0], err, string(output))
+	}
 	return nil
 }
 
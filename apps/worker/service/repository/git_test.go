@@ -0,0 +1,404 @@
+package repository_test
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	appconfig "github.com/antinvestor/builder/apps/worker/config"
+	"github.com/antinvestor/builder/apps/worker/service/repository"
+	"github.com/antinvestor/builder/internal/events"
+)
+
+func runGit(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command("git", append([]string{
+		"-c", "user.name=Test", "-c", "user.email=test@example.com", "-c", "commit.gpgsign=false",
+	}, args...)...)
+	cmd.Dir = dir
+	output, err := cmd.CombinedOutput()
+	require.NoError(t, err, string(output))
+	return strings.TrimSpace(string(output))
+}
+
+func writeFile(t *testing.T, dir, name, content string) {
+	t.Helper()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600))
+}
+
+// setupFeatureBranch creates an origin repository, checks it out through the
+// service and commits a change to handler.go on a feature branch.
+func setupFeatureBranch(t *testing.T) (*repository.Service, events.ExecutionID, string, string) {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+
+	origin := t.TempDir()
+	runGit(t, origin, "init", "-q", "-b", "main")
+	writeFile(t, origin, "handler.go", "package api\n\nfunc Handle() string { return \"v1\" }\n")
+	writeFile(t, origin, "README.md", "docs\n")
+	runGit(t, origin, "add", "-A")
+	runGit(t, origin, "commit", "-q", "-m", "initial")
+
+	cfg := &appconfig.WorkerConfig{
+		WorkspaceBasePath:   t.TempDir(),
+		MaxConcurrentClones: 1,
+		CloneTimeoutSeconds: 30,
+	}
+	svc := repository.NewService(cfg, repository.NewWorkspaceRepository(context.Background(), nil))
+
+	executionID := events.NewExecutionID()
+	checkout, err := svc.Checkout(context.Background(), &repository.CheckoutRequest{
+		ExecutionID:   executionID,
+		RepositoryURL: origin,
+		Branch:        "main",
+	})
+	require.NoError(t, err)
+
+	workspace := checkout.WorkspacePath
+	runGit(t, workspace, "checkout", "-q", "-b", "feature/x")
+	writeFile(t, workspace, "handler.go", "package api\n\nfunc Handle() string { return \"feature\" }\n")
+	runGit(t, workspace, "commit", "-q", "-am", "feature change")
+
+	return svc, executionID, origin, workspace
+}
+
+func TestRebaseOntoBase_BaseUnchanged(t *testing.T) {
+	svc, executionID, _, _ := setupFeatureBranch(t)
+
+	result, err := svc.RebaseOntoBase(context.Background(), executionID)
+
+	require.NoError(t, err)
+	assert.False(t, result.Rebased)
+	assert.False(t, result.Conflicted())
+	assert.Equal(t, result.BaseCommitSHA, result.LatestBaseCommitSHA)
+}
+
+func TestRebaseOntoBase_BaseAdvancedWithoutConflict(t *testing.T) {
+	svc, executionID, origin, workspace := setupFeatureBranch(t)
+
+	writeFile(t, origin, "README.md", "updated docs\n")
+	runGit(t, origin, "commit", "-q", "-am", "docs on main")
+	baseHead := runGit(t, origin, "rev-parse", "HEAD")
+
+	result, err := svc.RebaseOntoBase(context.Background(), executionID)
+
+	require.NoError(t, err)
+	assert.True(t, result.Rebased)
+	assert.False(t, result.Conflicted())
+	assert.Equal(t, baseHead, result.LatestBaseCommitSHA)
+
+	// The feature commit now sits on top of the advanced base
+	assert.Equal(t, baseHead, runGit(t, workspace, "rev-parse", "HEAD~1"))
+	assert.Equal(t, "feature/x", runGit(t, workspace, "rev-parse", "--abbrev-ref", "HEAD"))
+}
+
+func TestRebaseOntoBase_BaseAdvancedWithConflict(t *testing.T) {
+	svc, executionID, origin, workspace := setupFeatureBranch(t)
+	featureHead := runGit(t, workspace, "rev-parse", "HEAD")
+
+	writeFile(t, origin, "handler.go", "package api\n\nfunc Handle() string { return \"v2\" }\n")
+	runGit(t, origin, "commit", "-q", "-am", "conflicting change on main")
+
+	result, err := svc.RebaseOntoBase(context.Background(), executionID)
+
+	require.NoError(t, err)
+	require.True(t, result.Conflicted())
+	assert.False(t, result.Rebased)
+	assert.Equal(t, []string{"handler.go"}, result.ConflictingFiles)
+	assert.Equal(t, "main", result.BaseBranch)
+
+	// The aborted rebase leaves the feature branch untouched
+	assert.Equal(t, featureHead, runGit(t, workspace, "rev-parse", "HEAD"))
+	assert.Equal(t, "feature/x", runGit(t, workspace, "rev-parse", "--abbrev-ref", "HEAD"))
+	assert.Empty(t, runGit(t, workspace, "status", "--porcelain"))
+}
+
+func TestSyncWithBase_MergeStrategyMergesAdvancedBase(t *testing.T) {
+	svc, executionID, origin, workspace := setupFeatureBranch(t)
+	featureHead := runGit(t, workspace, "rev-parse", "HEAD")
+
+	writeFile(t, origin, "README.md", "updated docs\n")
+	runGit(t, origin, "commit", "-q", "-am", "docs on main")
+	baseHead := runGit(t, origin, "rev-parse", "HEAD")
+
+	result, err := svc.SyncWithBase(context.Background(), executionID, events.BaseSyncMerge)
+
+	require.NoError(t, err)
+	assert.True(t, result.Merged)
+	assert.False(t, result.Rebased)
+	assert.False(t, result.Conflicted())
+	assert.Equal(t, events.BaseSyncMerge, result.Strategy)
+
+	// The feature commit is kept and the base is merged in on top of it
+	assert.Equal(t, featureHead, runGit(t, workspace, "rev-parse", "HEAD^1"))
+	assert.Equal(t, baseHead, runGit(t, workspace, "rev-parse", "HEAD^2"))
+	assert.Equal(t, "feature/x", runGit(t, workspace, "rev-parse", "--abbrev-ref", "HEAD"))
+}
+
+func TestSyncWithBase_MergeStrategyConflictIsAborted(t *testing.T) {
+	svc, executionID, origin, workspace := setupFeatureBranch(t)
+	featureHead := runGit(t, workspace, "rev-parse", "HEAD")
+
+	writeFile(t, origin, "handler.go", "package api\n\nfunc Handle() string { return \"v2\" }\n")
+	runGit(t, origin, "commit", "-q", "-am", "conflicting change on main")
+
+	result, err := svc.SyncWithBase(context.Background(), executionID, events.BaseSyncMerge)
+
+	require.NoError(t, err)
+	require.True(t, result.Conflicted())
+	assert.False(t, result.Merged)
+	assert.Equal(t, []string{"handler.go"}, result.ConflictingFiles)
+
+	// The aborted merge leaves the feature branch untouched
+	assert.Equal(t, featureHead, runGit(t, workspace, "rev-parse", "HEAD"))
+	assert.Empty(t, runGit(t, workspace, "status", "--porcelain"))
+}
+
+func TestSyncWithBase_RejectsUnknownStrategy(t *testing.T) {
+	svc, executionID, _, _ := setupFeatureBranch(t)
+
+	_, err := svc.SyncWithBase(context.Background(), executionID, events.BaseSyncStrategy("squash"))
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unknown base sync strategy")
+}
+
+func TestSyncWithRemoteBranch_TakesInCommitsThatRejectThePush(t *testing.T) {
+	svc, executionID, origin, workspace := setupFeatureBranch(t)
+	_, err := svc.PushBranch(context.Background(), executionID, "feature/x")
+	require.NoError(t, err)
+
+	// Someone else pushes to the feature branch
+	runGit(t, origin, "checkout", "-q", "feature/x")
+	writeFile(t, origin, "README.md", "reviewer notes\n")
+	runGit(t, origin, "commit", "-q", "-am", "notes on feature")
+	remoteHead := runGit(t, origin, "rev-parse", "HEAD")
+	runGit(t, origin, "checkout", "-q", "main")
+
+	writeFile(t, workspace, "extra.go", "package api\n")
+	runGit(t, workspace, "add", "extra.go")
+	runGit(t, workspace, "commit", "-q", "-m", "more feature")
+	_, err = svc.PushBranch(context.Background(), executionID, "feature/x")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "rejected")
+
+	result, err := svc.SyncWithRemoteBranch(context.Background(), executionID, "feature/x", events.BaseSyncRebase)
+
+	require.NoError(t, err)
+	assert.True(t, result.Rebased)
+	assert.Equal(t, "feature/x", result.BaseBranch)
+	assert.Equal(t, remoteHead, result.LatestBaseCommitSHA)
+	assert.Empty(t, result.BaseCommitSHA)
+
+	// The push is now a fast-forward of the remote branch
+	sha, err := svc.PushBranch(context.Background(), executionID, "feature/x")
+	require.NoError(t, err)
+	assert.Equal(t, remoteHead, runGit(t, workspace, "rev-parse", "HEAD~1"))
+	assert.Equal(t, sha, runGit(t, origin, "rev-parse", "refs/heads/feature/x"))
+}
+
+func TestPushBranch_ReturnsPushedCommitSHA(t *testing.T) {
+	svc, executionID, origin, workspace := setupFeatureBranch(t)
+
+	sha, err := svc.PushBranch(context.Background(), executionID, "feature/x")
+
+	require.NoError(t, err)
+	assert.Equal(t, runGit(t, workspace, "rev-parse", "HEAD"), sha)
+	assert.Equal(t, sha, runGit(t, origin, "rev-parse", "refs/heads/feature/x"))
+}
+
+func TestPushBranch_ReturnsCommitOfUpdatedBranch(t *testing.T) {
+	svc, executionID, origin, workspace := setupFeatureBranch(t)
+	_, err := svc.PushBranch(context.Background(), executionID, "feature/x")
+	require.NoError(t, err)
+
+	writeFile(t, workspace, "extra.go", "package api\n")
+	runGit(t, workspace, "add", "extra.go")
+	runGit(t, workspace, "commit", "-q", "-m", "more feature")
+	sha, err := svc.PushBranch(context.Background(), executionID, "feature/x")
+
+	require.NoError(t, err)
+	assert.Equal(t, runGit(t, workspace, "rev-parse", "HEAD"), sha)
+	assert.Equal(t, sha, runGit(t, origin, "rev-parse", "refs/heads/feature/x"))
+}
+
+func TestDeliverFiles_CommitsOnlyDeliveredFiles(t *testing.T) {
+	svc, executionID, origin, workspace := setupFeatureBranch(t)
+	writeFile(t, workspace, "README.md", "docs in progress\n")
+	writeFile(t, workspace, "scratch.go", "package api\n")
+
+	commit, err := svc.DeliverFiles(context.Background(), executionID, "feature/partial",
+		[]string{"handler.go"}, "deliver handler")
+
+	require.NoError(t, err)
+	assert.Equal(t, commit.SHA, runGit(t, origin, "rev-parse", "refs/heads/feature/partial"))
+	assert.Equal(t, "handler.go", runGit(t, origin, "diff", "--name-only", "main", "feature/partial"))
+	// The undelivered changes are left for the iteration
+	assert.Equal(t, "feature/x", runGit(t, workspace, "rev-parse", "--abbrev-ref", "HEAD"))
+	assert.FileExists(t, filepath.Join(workspace, "scratch.go"))
+}
+
+func TestCheckout_UsesRemoteDefaultBranch(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+
+	origin := t.TempDir()
+	runGit(t, origin, "init", "-q", "-b", "develop")
+	writeFile(t, origin, "README.md", "develop docs\n")
+	runGit(t, origin, "add", "-A")
+	runGit(t, origin, "commit", "-q", "-m", "initial")
+	runGit(t, origin, "branch", "main")
+
+	cfg := &appconfig.WorkerConfig{
+		WorkspaceBasePath:   t.TempDir(),
+		MaxConcurrentClones: 1,
+		CloneTimeoutSeconds: 30,
+	}
+	svc := repository.NewService(cfg, repository.NewWorkspaceRepository(context.Background(), nil))
+
+	defaultBranch, err := svc.DetectDefaultBranch(context.Background(), origin)
+	require.NoError(t, err)
+	assert.Equal(t, "develop", defaultBranch)
+
+	checkout, err := svc.Checkout(context.Background(), &repository.CheckoutRequest{
+		ExecutionID:   events.NewExecutionID(),
+		RepositoryURL: origin,
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, "develop", checkout.Branch)
+	assert.Equal(t, "develop", checkout.DefaultBranch)
+	assert.Equal(t, "develop", runGit(t, checkout.WorkspacePath, "rev-parse", "--abbrev-ref", "HEAD"))
+}
+
+func TestCheckout_ExplicitBranchSkipsDetection(t *testing.T) {
+	svc, executionID, _, _ := setupFeatureBranch(t)
+
+	workspace, err := svc.GetWorkspace(context.Background(), executionID)
+	require.NoError(t, err)
+	assert.Equal(t, "main", workspace.Branch)
+}
+
+func TestDetectDefaultBranch_UnreachableRemote(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	svc := repository.NewService(&appconfig.WorkerConfig{MaxConcurrentClones: 1},
+		repository.NewWorkspaceRepository(context.Background(), nil))
+
+	_, err := svc.DetectDefaultBranch(context.Background(), filepath.Join(t.TempDir(), "missing"))
+	require.Error(t, err)
+}
+
+// setupRepositoryWithSubmodule creates an origin repository with a submodule at lib.
+func setupRepositoryWithSubmodule(t *testing.T) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	// Local fixture repositories are cloned over the file transport
+	t.Setenv("GIT_CONFIG_COUNT", "1")
+	t.Setenv("GIT_CONFIG_KEY_0", "protocol.file.allow")
+	t.Setenv("GIT_CONFIG_VALUE_0", "always")
+
+	library := t.TempDir()
+	runGit(t, library, "init", "-q", "-b", "main")
+	writeFile(t, library, "lib.go", "package lib\n")
+	runGit(t, library, "add", "-A")
+	runGit(t, library, "commit", "-q", "-m", "library")
+
+	origin := t.TempDir()
+	runGit(t, origin, "init", "-q", "-b", "main")
+	writeFile(t, origin, "README.md", "app\n")
+	runGit(t, origin, "submodule", "add", "-q", library, "lib")
+	runGit(t, origin, "commit", "-q", "-am", "add library submodule")
+	return origin
+}
+
+func checkoutWithConfig(
+	t *testing.T,
+	cfg *appconfig.WorkerConfig,
+	req *repository.CheckoutRequest,
+) *repository.CheckoutResult {
+	t.Helper()
+	cfg.WorkspaceBasePath = t.TempDir()
+	cfg.MaxConcurrentClones = 1
+	cfg.CloneTimeoutSeconds = 30
+	svc := repository.NewService(cfg, repository.NewWorkspaceRepository(context.Background(), nil))
+
+	req.ExecutionID = events.NewExecutionID()
+	checkout, err := svc.Checkout(context.Background(), req)
+	require.NoError(t, err)
+	return checkout
+}
+
+func TestCheckout_InitializesSubmodulesWhenEnabled(t *testing.T) {
+	origin := setupRepositoryWithSubmodule(t)
+
+	checkout := checkoutWithConfig(t, &appconfig.WorkerConfig{CheckoutSubmodules: true},
+		&repository.CheckoutRequest{RepositoryURL: origin, Branch: "main"})
+
+	assert.FileExists(t, filepath.Join(checkout.WorkspacePath, "lib", "lib.go"))
+	require.NotNil(t, checkout.Submodules)
+	assert.True(t, checkout.Submodules.Initialized)
+	require.Len(t, checkout.Submodules.Submodules, 1)
+	assert.Equal(t, "lib", checkout.Submodules.Submodules[0].Path)
+	assert.Len(t, checkout.Submodules.Submodules[0].CommitSHA, 40)
+	assert.Nil(t, checkout.LFS)
+}
+
+func TestCheckout_SkipsSubmodulesByDefault(t *testing.T) {
+	origin := setupRepositoryWithSubmodule(t)
+
+	checkout := checkoutWithConfig(t, &appconfig.WorkerConfig{},
+		&repository.CheckoutRequest{RepositoryURL: origin, Branch: "main"})
+
+	assert.NoFileExists(t, filepath.Join(checkout.WorkspacePath, "lib", "lib.go"))
+	assert.Nil(t, checkout.Submodules)
+}
+
+func TestCheckout_RequestOverridesSubmoduleConfig(t *testing.T) {
+	origin := setupRepositoryWithSubmodule(t)
+	enabled, disabled := true, false
+
+	checkout := checkoutWithConfig(t, &appconfig.WorkerConfig{},
+		&repository.CheckoutRequest{RepositoryURL: origin, Branch: "main", Submodules: &enabled})
+	assert.FileExists(t, filepath.Join(checkout.WorkspacePath, "lib", "lib.go"))
+
+	checkout = checkoutWithConfig(t, &appconfig.WorkerConfig{CheckoutSubmodules: true},
+		&repository.CheckoutRequest{RepositoryURL: origin, Branch: "main", Submodules: &disabled})
+	assert.NoFileExists(t, filepath.Join(checkout.WorkspacePath, "lib", "lib.go"))
+}
+
+func TestCheckout_FetchesLFSObjectsWhenEnabled(t *testing.T) {
+	if err := exec.Command("git", "lfs", "version").Run(); err != nil {
+		t.Skip("git lfs not available")
+	}
+
+	origin := t.TempDir()
+	runGit(t, origin, "init", "-q", "-b", "main")
+	runGit(t, origin, "lfs", "install", "--local")
+	runGit(t, origin, "lfs", "track", "*.bin")
+	writeFile(t, origin, "model.bin", "binary asset\n")
+	runGit(t, origin, "add", "-A")
+	runGit(t, origin, "commit", "-q", "-m", "add asset")
+
+	checkout := checkoutWithConfig(t, &appconfig.WorkerConfig{CheckoutLFS: true},
+		&repository.CheckoutRequest{RepositoryURL: origin, Branch: "main"})
+
+	require.NotNil(t, checkout.LFS)
+	assert.True(t, checkout.LFS.Fetched)
+	assert.Equal(t, 1, checkout.LFS.FileCount)
+	content, err := os.ReadFile(filepath.Join(checkout.WorkspacePath, "model.bin"))
+	require.NoError(t, err)
+	assert.Equal(t, "binary asset\n", string(content))
+}
@@ -0,0 +1,334 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/antinvestor/builder/internal/events"
+)
+
+const (
+	// maxIndexedFileBytes bounds the source files scanned for symbols.
+	maxIndexedFileBytes = 1 << 20
+	// maxCachedIndexes bounds the workspaces whose index is kept; the oldest
+	// index is evicted to make room.
+	maxCachedIndexes = 64
+	// maxContextSymbols bounds the declarations listed in a repository context.
+	maxContextSymbols = 300
+)
+
+// toolDirs are directories of tooling state, always left out of the index.
+var toolDirs = []string{".git", "__pycache__", ".venv"}
+
+// symbolPatterns find top-level declarations in languages without a parser here,
+// by file extension. The first group is the kind and the second the name.
+var symbolPatterns = map[string]*regexp.Regexp{
+	".py":  regexp.MustCompile(`(?m)^(class|def)\s+(\w+)`),
+	".js":  regexp.MustCompile(`(?m)^(?:export\s+)?(?:default\s+)?(?:async\s+)?(function|class)\s+(\w+)`),
+	".jsx": regexp.MustCompile(`(?m)^(?:export\s+)?(?:default\s+)?(?:async\s+)?(function|class)\s+(\w+)`),
+	".ts":  regexp.MustCompile(`(?m)^(?:export\s+)?(?:default\s+)?(?:async\s+)?(function|class|interface)\s+(\w+)`),
+	".tsx": regexp.MustCompile(`(?m)^(?:export\s+)?(?:default\s+)?(?:async\s+)?(function|class|interface)\s+(\w+)`),
+}
+
+// Symbol is a top-level declaration in a workspace source file.
+type Symbol struct {
+	Name     string
+	Kind     string // func, method, type, class, interface, def
+	FilePath string
+	Line     int
+}
+
+// WorkspaceIndex is the file tree and symbol table of a workspace. It is built
+// once per checkout and reused until the workspace's files change.
+type WorkspaceIndex struct {
+	// Structure is the rendered project structure given to the LLM as context.
+	Structure string
+	// Files are the workspace files relative to its root, sorted.
+	Files []string
+	// Symbols are the top-level declarations of the indexed source files.
+	Symbols []Symbol
+	BuiltAt time.Time
+}
+
+// SymbolsIn returns the symbols declared in a file.
+func (idx *WorkspaceIndex) SymbolsIn(filePath string) []Symbol {
+	var symbols []Symbol
+	for _, symbol := range idx.Symbols {
+		if symbol.FilePath == filePath {
+			symbols = append(symbols, symbol)
+		}
+	}
+	return symbols
+}
+
+// RepositoryContext renders the project structure followed by the top-level
+// declarations, the context given to the LLM.
+func (idx *WorkspaceIndex) RepositoryContext() string {
+	if len(idx.Symbols) == 0 {
+		return idx.Structure
+	}
+
+	var b strings.Builder
+	b.WriteString(idx.Structure)
+	b.WriteString("\nTop-level declarations:\n")
+	for _, symbol := range idx.Symbols[:min(len(idx.Symbols), maxContextSymbols)] {
+		fmt.Fprintf(&b, "%s:%d %s %s\n", symbol.FilePath, symbol.Line, symbol.Kind, symbol.Name)
+	}
+	if omitted := len(idx.Symbols) - maxContextSymbols; omitted > 0 {
+		fmt.Fprintf(&b, "... and %d more\n", omitted)
+	}
+	return b.String()
+}
+
+// indexCache holds the index of each workspace. A workspace is tracked from the
+// first lookup until it is evicted; invalidation bumps its version, so an index
+// built while files were changing, or after eviction, is discarded.
+type indexCache struct {
+	mu       sync.Mutex
+	indexes  map[events.ExecutionID]*WorkspaceIndex
+	versions map[events.ExecutionID]uint64
+}
+
+func newIndexCache() *indexCache {
+	return &indexCache{
+		indexes:  make(map[events.ExecutionID]*WorkspaceIndex),
+		versions: make(map[events.ExecutionID]uint64),
+	}
+}
+
+func (c *indexCache) get(executionID events.ExecutionID) (*WorkspaceIndex, uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	version, tracked := c.versions[executionID]
+	if !tracked {
+		c.versions[executionID] = version
+	}
+	return c.indexes[executionID], version
+}
+
+func (c *indexCache) store(executionID events.ExecutionID, version uint64, index *WorkspaceIndex) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if current, tracked := c.versions[executionID]; !tracked || current != version {
+		return
+	}
+	if _, cached := c.indexes[executionID]; !cached && len(c.indexes) >= maxCachedIndexes {
+		c.evictOldest()
+	}
+	c.indexes[executionID] = index
+}
+
+// evictOldest drops the index built longest ago. The caller holds the lock.
+func (c *indexCache) evictOldest() {
+	var (
+		oldestID events.ExecutionID
+		oldest   *WorkspaceIndex
+	)
+	for executionID, index := range c.indexes {
+		if oldest == nil || index.BuiltAt.Before(oldest.BuiltAt) {
+			oldestID, oldest = executionID, index
+		}
+	}
+	delete(c.indexes, oldestID)
+	delete(c.versions, oldestID)
+}
+
+func (c *indexCache) invalidate(executionID events.ExecutionID) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, tracked := c.versions[executionID]; tracked {
+		delete(c.indexes, executionID)
+		c.versions[executionID]++
+	}
+}
+
+func (c *indexCache) evict(executionID events.ExecutionID) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.indexes, executionID)
+	delete(c.versions, executionID)
+}
+
+// Index returns the index of an execution's workspace, building it on first use
+// and after the workspace's files changed.
+func (s *Service) Index(ctx context.Context, executionID events.ExecutionID) (*WorkspaceIndex, error) {
+	index, version := s.indexes.get(executionID)
+	if index != nil {
+		return index, nil
+	}
+
+	index, err := buildIndex(ctx, s.GetWorkspacePath(executionID), s.indexSkippedDirs())
+	if err != nil {
+		return nil, err
+	}
+	s.indexes.store(executionID, version, index)
+	return index, nil
+}
+
+// InvalidateIndex discards the index of an execution's workspace so the next use
+// rebuilds it. Service methods that change workspace files call it themselves.
+func (s *Service) InvalidateIndex(executionID events.ExecutionID) {
+	s.indexes.invalidate(executionID)
+}
+
+// indexSkippedDirs returns the names of the directories left out of the index:
+// tooling state and the configured vendored directories.
+func (s *Service) indexSkippedDirs() []string {
+	skipped := slices.Clone(toolDirs)
+	for dir := range strings.SplitSeq(s.cfg.VendoredDirs, ",") {
+		if dir = strings.TrimSpace(dir); dir != "" {
+			skipped = append(skipped, dir)
+		}
+	}
+	return skipped
+}
+
+func buildIndex(ctx context.Context, workspacePath string, skippedDirs []string) (*WorkspaceIndex, error) {
+	structure, err := projectStructure(ctx, workspacePath, skippedDirs)
+	if err != nil {
+		return nil, err
+	}
+
+	index := &WorkspaceIndex{Structure: structure, BuiltAt: time.Now()}
+	walkErr := filepath.WalkDir(workspacePath, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			if path != workspacePath && slices.Contains(skippedDirs, entry.Name()) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !entry.Type().IsRegular() {
+			return nil
+		}
+		rel, relErr := filepath.Rel(workspacePath, path)
+		if relErr != nil {
+			return relErr
+		}
+		rel = filepath.ToSlash(rel)
+		index.Files = append(index.Files, rel)
+		index.Symbols = append(index.Symbols, fileSymbols(path, rel)...)
+		return nil
+	})
+	if walkErr != nil {
+		return nil, fmt.Errorf("index workspace: %w", walkErr)
+	}
+	slices.Sort(index.Files)
+	return index, nil
+}
+
+// fileSymbols returns the top-level declarations of a source file. Files that
+// cannot be read or parsed contribute no symbols.
+func fileSymbols(path, rel string) []Symbol {
+	ext := filepath.Ext(path)
+	pattern := symbolPatterns[ext]
+	if ext != ".go" && pattern == nil {
+		return nil
+	}
+	info, err := os.Stat(path)
+	if err != nil || info.Size() > maxIndexedFileBytes {
+		return nil
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	if ext == ".go" {
+		return goSymbols(rel, content)
+	}
+
+	var symbols []Symbol
+	for _, match := range pattern.FindAllSubmatchIndex(content, -1) {
+		symbols = append(symbols, Symbol{
+			Name:     string(content[match[4]:match[5]]),
+			Kind:     string(content[match[2]:match[3]]),
+			FilePath: rel,
+			Line:     strings.Count(string(content[:match[0]]), "\n") + 1,
+		})
+	}
+	return symbols
+}
+
+func goSymbols(rel string, content []byte) []Symbol {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, rel, content, parser.SkipObjectResolution)
+	if err != nil {
+		return nil
+	}
+
+	var symbols []Symbol
+	for _, decl := range file.Decls {
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			kind := "func"
+			if d.Recv != nil {
+				kind = "method"
+			}
+			symbols = append(symbols, Symbol{
+				Name:     d.Name.Name,
+				Kind:     kind,
+				FilePath: rel,
+				Line:     fset.Position(d.Pos()).Line,
+			})
+		case *ast.GenDecl:
+			if d.Tok != token.TYPE {
+				continue
+			}
+			for _, spec := range d.Specs {
+				if typeSpec, ok := spec.(*ast.TypeSpec); ok {
+					symbols = append(symbols, Symbol{
+						Name:     typeSpec.Name.Name,
+						Kind:     "type",
+						FilePath: rel,
+						Line:     fset.Position(typeSpec.Pos()).Line,
+					})
+				}
+			}
+		}
+	}
+	return symbols
+}
+
+// projectStructure renders the project structure with tree, falling back to find,
+// leaving out the skipped directories.
+func projectStructure(ctx context.Context, workspacePath string, skippedDirs []string) (string, error) {
+	cmd := exec.CommandContext(
+		ctx,
+		"tree",
+		"-L",
+		"4",
+		"--noreport",
+		"-I",
+		strings.Join(skippedDirs, "|"),
+	)
+	cmd.Dir = workspacePath
+
+	output, err := cmd.Output()
+	if err != nil {
+		args := []string{".", "-type", "f"}
+		for _, dir := range skippedDirs {
+			args = append(args, "-not", "-path", "*/"+dir+"/*")
+		}
+		cmd = exec.CommandContext(ctx, "find", args...)
+		cmd.Dir = workspacePath
+		output, err = cmd.Output()
+		if err != nil {
+			return "", fmt.Errorf("get project structure: %w", err)
+		}
+	}
+	return string(output), nil
+}
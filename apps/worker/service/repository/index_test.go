@@ -0,0 +1,130 @@
+package repository_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	appconfig "github.com/antinvestor/builder/apps/worker/config"
+	"github.com/antinvestor/builder/apps/worker/service/repository"
+	"github.com/antinvestor/builder/internal/events"
+)
+
+func TestIndex_BuiltOnceAndReused(t *testing.T) {
+	svc, executionID, workspace := setupGoWorkspace(t, &appconfig.WorkerConfig{})
+	ctx := context.Background()
+
+	index, err := svc.Index(ctx, executionID)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"calc.go", "go.mod"}, index.Files)
+	assert.Equal(t, []repository.Symbol{{Name: "Add", Kind: "func", FilePath: "calc.go", Line: 3}}, index.Symbols)
+
+	// A change made outside the service is not seen until the index is invalidated
+	writeFile(t, workspace, "extra.go", "package calc\n")
+	again, err := svc.Index(ctx, executionID)
+	require.NoError(t, err)
+	assert.Same(t, index, again)
+
+	structure, err := svc.GetProjectStructure(ctx, executionID)
+	require.NoError(t, err)
+	assert.Equal(t, index.Structure, structure)
+	assert.NotContains(t, structure, "extra.go")
+
+	svc.InvalidateIndex(executionID)
+	rebuilt, err := svc.Index(ctx, executionID)
+	require.NoError(t, err)
+	assert.NotSame(t, index, rebuilt)
+	assert.Contains(t, rebuilt.Files, "extra.go")
+}
+
+func TestIndex_InvalidatedAfterApplyPatch(t *testing.T) {
+	svc, executionID, _ := setupGoWorkspace(t, &appconfig.WorkerConfig{})
+	ctx := context.Background()
+
+	index, err := svc.Index(ctx, executionID)
+	require.NoError(t, err)
+	assert.Empty(t, index.SymbolsIn("shapes.go"))
+
+	require.NoError(t, svc.ApplyPatch(ctx, executionID, &events.Patch{
+		FilePath:   "shapes.go",
+		Action:     events.FileActionCreate,
+		NewContent: "package calc\n\ntype Circle struct{}\n\nfunc (Circle) Area() float64 { return 0 }\n",
+	}))
+
+	rebuilt, err := svc.Index(ctx, executionID)
+	require.NoError(t, err)
+	assert.NotSame(t, index, rebuilt)
+	assert.Contains(t, rebuilt.Files, "shapes.go")
+	assert.Equal(t, []repository.Symbol{
+		{Name: "Circle", Kind: "type", FilePath: "shapes.go", Line: 3},
+		{Name: "Area", Kind: "method", FilePath: "shapes.go", Line: 5},
+	}, rebuilt.SymbolsIn("shapes.go"))
+	assert.Contains(t, rebuilt.Structure, "shapes.go")
+}
+
+func TestIndex_OtherLanguageSymbols(t *testing.T) {
+	svc, executionID, workspace := setupGoWorkspace(t, &appconfig.WorkerConfig{})
+	writeFile(t, workspace, "report.py",
+		"import os\n\nclass Report:\n    def render(self):\n        pass\n\ndef main():\n    pass\n")
+	writeFile(t, workspace, "app.ts", "export interface Props {}\nexport default function App() {}\n")
+
+	index, err := svc.Index(context.Background(), executionID)
+	require.NoError(t, err)
+	assert.Equal(t, []repository.Symbol{
+		{Name: "Report", Kind: "class", FilePath: "report.py", Line: 3},
+		{Name: "main", Kind: "def", FilePath: "report.py", Line: 7},
+	}, index.SymbolsIn("report.py"))
+	assert.Equal(t, []repository.Symbol{
+		{Name: "Props", Kind: "interface", FilePath: "app.ts", Line: 1},
+		{Name: "App", Kind: "function", FilePath: "app.ts", Line: 2},
+	}, index.SymbolsIn("app.ts"))
+}
+
+func TestIndex_ExcludesVendoredDirs(t *testing.T) {
+	svc, executionID, workspace := setupGoWorkspace(t, &appconfig.WorkerConfig{VendoredDirs: "vendor, node_modules"})
+	for _, dir := range []string{"vendor/lib", "web/node_modules/left-pad", "third_party"} {
+		require.NoError(t, os.MkdirAll(filepath.Join(workspace, dir), 0o750))
+	}
+	writeFile(t, workspace, "vendor/lib/lib.go", "package lib\n\nfunc Vendored() {}\n")
+	writeFile(t, workspace, "web/node_modules/left-pad/index.js", "function leftPad() {}\n")
+	writeFile(t, workspace, "third_party/tool.go", "package tool\n\nfunc Tool() {}\n")
+
+	index, err := svc.Index(context.Background(), executionID)
+	require.NoError(t, err)
+	// Only the configured directories are vendored, at any depth
+	assert.Equal(t, []string{"calc.go", "go.mod", "third_party/tool.go"}, index.Files)
+	assert.Empty(t, index.SymbolsIn("vendor/lib/lib.go"))
+	assert.NotContains(t, index.Structure, "lib.go")
+	assert.NotContains(t, index.Structure, "left-pad")
+	assert.Contains(t, index.Structure, "tool.go")
+}
+
+func TestIndex_EvictedOnCleanup(t *testing.T) {
+	svc, executionID, _ := setupGoWorkspace(t, &appconfig.WorkerConfig{})
+	ctx := context.Background()
+
+	_, err := svc.Index(ctx, executionID)
+	require.NoError(t, err)
+	require.NoError(t, svc.CleanupWorkspace(ctx, executionID))
+
+	_, err = svc.Index(ctx, executionID)
+	require.Error(t, err, "the index of a removed workspace is not served from the cache")
+}
+
+func TestGetRepositoryContext_ListsDeclarations(t *testing.T) {
+	svc, executionID, _ := setupGoWorkspace(t, &appconfig.WorkerConfig{})
+	ctx := context.Background()
+
+	repoContext, err := svc.GetRepositoryContext(ctx, executionID)
+	require.NoError(t, err)
+
+	structure, err := svc.GetProjectStructure(ctx, executionID)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(repoContext, structure))
+	assert.Contains(t, repoContext, "Top-level declarations:\ncalc.go:3 func Add\n")
+}
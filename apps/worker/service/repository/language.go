@@ -0,0 +1,91 @@
+package repository
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/antinvestor/builder/internal/events"
+)
+
+// ErrLanguageUndetected is returned when no project marker file identifies the
+// language of a workspace.
+var ErrLanguageUndetected = errors.New("project language not detected")
+
+// testLanguages maps a project marker file to the language the executor tests it
+// as and that language's default test command, in detection order.
+var testLanguages = []struct {
+	marker   string
+	language string
+	command  string
+}{
+	{marker: "go.mod", language: "go", command: "go test ./..."},
+	{marker: "package.json", language: "node", command: "npm test"},
+	{marker: "Cargo.toml", language: "rust", command: "cargo test"},
+	{marker: "pyproject.toml", language: "python", command: "python -m pytest"},
+	{marker: "requirements.txt", language: "python", command: "python -m pytest"},
+	{marker: "pom.xml", language: "java", command: "mvn test -B"},
+	{marker: "build.gradle", language: "java", command: "gradle test"},
+	{marker: "build.gradle.kts", language: "java", command: "gradle test"},
+}
+
+// DetectLanguage returns the language of the project in workspacePath and its
+// default test command, or ErrLanguageUndetected when no marker file is present.
+func DetectLanguage(workspacePath string) (string, string, error) {
+	return DetectLanguageWithPriority(workspacePath, nil)
+}
+
+// DetectLanguageWithPriority detects the language like DetectLanguage, preferring
+// the languages in priority, in order, when a workspace has markers of several.
+// Languages left out of priority are detected after those listed.
+func DetectLanguageWithPriority(workspacePath string, priority []string) (string, string, error) {
+	rank := func(language string) int {
+		if i := slices.Index(priority, language); i >= 0 {
+			return i
+		}
+		return len(priority)
+	}
+
+	found := -1
+	for i, candidate := range testLanguages {
+		if _, err := os.Stat(filepath.Join(workspacePath, candidate.marker)); err != nil {
+			continue
+		}
+		if found < 0 || rank(candidate.language) < rank(testLanguages[found].language) {
+			found = i
+		}
+	}
+	if found < 0 {
+		return "", "", ErrLanguageUndetected
+	}
+	return testLanguages[found].language, testLanguages[found].command, nil
+}
+
+// defaultTestCommand returns the default test command of a language, or "" when
+// the language has no marker file and the executor's own command is used.
+func defaultTestCommand(language string) string {
+	for _, candidate := range testLanguages {
+		if candidate.language == language {
+			return candidate.command
+		}
+	}
+	return ""
+}
+
+// DetectWorkspaceLanguage detects the project language of an execution's workspace.
+// A configured TestLanguage is used as is, without inspecting the workspace.
+func (s *Service) DetectWorkspaceLanguage(executionID events.ExecutionID) (string, string, error) {
+	if language := strings.ToLower(strings.TrimSpace(s.cfg.TestLanguage)); language != "" {
+		return language, defaultTestCommand(language), nil
+	}
+
+	var priority []string
+	for language := range strings.SplitSeq(s.cfg.LanguageDetectionPriority, ",") {
+		if language = strings.ToLower(strings.TrimSpace(language)); language != "" {
+			priority = append(priority, language)
+		}
+	}
+	return DetectLanguageWithPriority(s.GetWorkspacePath(executionID), priority)
+}
@@ -0,0 +1,116 @@
+package repository_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	appconfig "github.com/antinvestor/builder/apps/worker/config"
+	"github.com/antinvestor/builder/apps/worker/service/repository"
+	"github.com/antinvestor/builder/internal/events"
+)
+
+func TestDetectLanguage(t *testing.T) {
+	tests := []struct {
+		marker       string
+		wantLanguage string
+		wantCommand  string
+	}{
+		{marker: "go.mod", wantLanguage: "go", wantCommand: "go test ./..."},
+		{marker: "package.json", wantLanguage: "node", wantCommand: "npm test"},
+		{marker: "Cargo.toml", wantLanguage: "rust", wantCommand: "cargo test"},
+		{marker: "pyproject.toml", wantLanguage: "python", wantCommand: "python -m pytest"},
+		{marker: "requirements.txt", wantLanguage: "python", wantCommand: "python -m pytest"},
+		{marker: "pom.xml", wantLanguage: "java", wantCommand: "mvn test -B"},
+		{marker: "build.gradle", wantLanguage: "java", wantCommand: "gradle test"},
+		{marker: "build.gradle.kts", wantLanguage: "java", wantCommand: "gradle test"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.marker, func(t *testing.T) {
+			dir := t.TempDir()
+			require.NoError(t, os.WriteFile(filepath.Join(dir, tt.marker), nil, 0o600))
+
+			language, command, err := repository.DetectLanguage(dir)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantLanguage, language)
+			assert.Equal(t, tt.wantCommand, command)
+		})
+	}
+}
+
+func TestDetectLanguage_Undetected(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "Makefile"), []byte("test:\n"), 0o600))
+
+	_, _, err := repository.DetectLanguage(dir)
+	assert.ErrorIs(t, err, repository.ErrLanguageUndetected)
+}
+
+func writeMarkers(t *testing.T, markers ...string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for _, marker := range markers {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, marker), nil, 0o600))
+	}
+	return dir
+}
+
+func TestDetectLanguageWithPriority_MixedRepository(t *testing.T) {
+	// A Python service with a Node front-end build
+	dir := writeMarkers(t, "package.json", "pyproject.toml")
+
+	language, _, err := repository.DetectLanguage(dir)
+	require.NoError(t, err)
+	assert.Equal(t, "node", language, "the default order prefers node")
+
+	language, command, err := repository.DetectLanguageWithPriority(dir, []string{"python", "node"})
+	require.NoError(t, err)
+	assert.Equal(t, "python", language)
+	assert.Equal(t, "python -m pytest", command)
+
+	// Unlisted languages are still detected, after the listed ones
+	language, _, err = repository.DetectLanguageWithPriority(dir, []string{"java"})
+	require.NoError(t, err)
+	assert.Equal(t, "node", language)
+}
+
+func newLanguageService(t *testing.T, cfg *appconfig.WorkerConfig) (*repository.Service, events.ExecutionID) {
+	t.Helper()
+	cfg.WorkspaceBasePath = t.TempDir()
+	executionID := events.NewExecutionID()
+	workspace := filepath.Join(cfg.WorkspaceBasePath, executionID.String())
+	require.NoError(t, os.MkdirAll(workspace, 0o750))
+	for _, marker := range []string{"go.mod", "pom.xml"} {
+		require.NoError(t, os.WriteFile(filepath.Join(workspace, marker), nil, 0o600))
+	}
+	return repository.NewService(cfg, repository.NewWorkspaceRepository(context.Background(), nil)), executionID
+}
+
+func TestDetectWorkspaceLanguage_ConfiguredPriority(t *testing.T) {
+	svc, executionID := newLanguageService(t, &appconfig.WorkerConfig{
+		MaxConcurrentClones:       1,
+		LanguageDetectionPriority: "java, go",
+	})
+
+	language, command, err := svc.DetectWorkspaceLanguage(executionID)
+	require.NoError(t, err)
+	assert.Equal(t, "java", language)
+	assert.Equal(t, "mvn test -B", command)
+}
+
+func TestDetectWorkspaceLanguage_ConfiguredLanguage(t *testing.T) {
+	svc, executionID := newLanguageService(t, &appconfig.WorkerConfig{
+		MaxConcurrentClones: 1,
+		TestLanguage:        "Rust",
+	})
+
+	language, command, err := svc.DetectWorkspaceLanguage(executionID)
+	require.NoError(t, err)
+	assert.Equal(t, "rust", language)
+	assert.Equal(t, "cargo test", command)
+}
@@ -0,0 +1,116 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+
+	"github.com/antinvestor/builder/internal/events"
+)
+
+// SeedWorkspace copies the template files under each seed directory into an
+// execution's workspace and stages them, so they are committed with the feature.
+// A file in a later directory replaces the same file of an earlier one; files the
+// repository already has are left unchanged, and missing directories are skipped.
+// It returns the seeded paths relative to the workspace, sorted.
+func (s *Service) SeedWorkspace(
+	ctx context.Context,
+	executionID events.ExecutionID,
+	seedDirs []string,
+) ([]string, error) {
+	workspacePath := s.GetWorkspacePath(executionID)
+
+	sources := make(map[string]string)
+	for _, dir := range seedDirs {
+		if err := collectSeedFiles(dir, sources); err != nil {
+			return nil, err
+		}
+	}
+
+	var seeded []string
+	defer s.InvalidateIndex(executionID)
+	for rel, source := range sources {
+		target := filepath.Join(workspacePath, rel)
+		if !isSubPath(workspacePath, target) {
+			return nil, fmt.Errorf("seed path escapes workspace: %s", rel)
+		}
+		if _, err := os.Lstat(target); err == nil {
+			continue
+		} else if !errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("stat seed target %s: %w", rel, err)
+		}
+		if err := copySeedFile(source, target); err != nil {
+			return nil, err
+		}
+		seeded = append(seeded, filepath.ToSlash(rel))
+	}
+	if len(seeded) == 0 {
+		return nil, nil
+	}
+	slices.Sort(seeded)
+
+	addCmd := exec.CommandContext(ctx, "git", append([]string{"add", "--"}, seeded...)...)
+	addCmd.Dir = workspacePath
+	if output, err := addCmd.CombinedOutput(); err != nil {
+		return nil, fmt.Errorf("git add seed files failed: %w: %s", err, string(output))
+	}
+	return seeded, nil
+}
+
+// collectSeedFiles records the regular files under dir by their path relative to it.
+func collectSeedFiles(dir string, sources map[string]string) error {
+	info, err := os.Stat(dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("stat seed directory: %w", err)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("seed path is not a directory: %s", dir)
+	}
+
+	return filepath.WalkDir(dir, func(path string, entry fs.DirEntry, walkErr error) error {
+		if walkErr != nil {
+			return fmt.Errorf("walk seed directory: %w", walkErr)
+		}
+		if entry.IsDir() {
+			if entry.Name() == ".git" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !entry.Type().IsRegular() {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return fmt.Errorf("resolve seed file: %w", err)
+		}
+		sources[rel] = path
+		return nil
+	})
+}
+
+func copySeedFile(source, target string) error {
+	info, err := os.Stat(source)
+	if err != nil {
+		return fmt.Errorf("stat seed file: %w", err)
+	}
+	content, err := os.ReadFile(source)
+	if err != nil {
+		return fmt.Errorf("read seed file: %w", err)
+	}
+	if err = os.MkdirAll(filepath.Dir(target), 0o750); err != nil {
+		return fmt.Errorf("create seed directory: %w", err)
+	}
+	if err = os.WriteFile(target, content, info.Mode().Perm()); err != nil {
+		return fmt.Errorf("write seed file: %w", err)
+	}
+	return nil
+}
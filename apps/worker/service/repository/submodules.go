@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/antinvestor/builder/internal/events"
+)
+
+// resolveCheckoutOption applies a per-request override to a configured checkout option.
+func resolveCheckoutOption(override *bool, configured bool) bool {
+	if override != nil {
+		return *override
+	}
+	return configured
+}
+
+// initSubmodules initializes and checks out all submodules of a workspace recursively.
+func (s *Service) initSubmodules(ctx context.Context, workspacePath string) (*events.SubmoduleStatus, error) {
+	cmd := exec.CommandContext(ctx, "git", "submodule", "update", "--init", "--recursive")
+	cmd.Dir = workspacePath
+	cmd.Env = append(s.buildGitEnv(), "GIT_LFS_SKIP_SMUDGE=1")
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return nil, fmt.Errorf("git submodule update failed: %w: %s", err, string(output))
+	}
+
+	statusCmd := exec.CommandContext(ctx, "git", "submodule", "status", "--recursive")
+	statusCmd.Dir = workspacePath
+	output, err := statusCmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("git submodule status: %w", err)
+	}
+
+	return &events.SubmoduleStatus{
+		Initialized: true,
+		Submodules:  parseSubmoduleStatus(string(output)),
+	}, nil
+}
+
+// parseSubmoduleStatus parses `git submodule status` lines of the form
+// "<flag><sha> <path> (<describe>)", where flag is a space, '-', '+' or 'U'.
+func parseSubmoduleStatus(output string) []events.SubmoduleInfo {
+	submodules := []events.SubmoduleInfo{}
+	for line := range strings.SplitSeq(output, "\n") {
+		if len(line) < 2 {
+			continue
+		}
+		fields := strings.Fields(line[1:])
+		if len(fields) < 2 {
+			continue
+		}
+		submodules = append(submodules, events.SubmoduleInfo{Path: fields[1], CommitSHA: fields[0]})
+	}
+	return submodules
+}
+
+// fetchLFSObjects downloads the Git LFS objects of the checked out revision.
+func (s *Service) fetchLFSObjects(ctx context.Context, workspacePath string) (*events.LFSStatus, error) {
+	for _, args := range [][]string{{"lfs", "install", "--local"}, {"lfs", "pull"}} {
+		cmd := exec.CommandContext(ctx, "git", args...)
+		cmd.Dir = workspacePath
+		cmd.Env = s.buildGitEnv()
+		if output, err := cmd.CombinedOutput(); err != nil {
+			return nil, fmt.Errorf("git %s failed: %w: %s", strings.Join(args, " "), err, string(output))
+		}
+	}
+
+	listCmd := exec.CommandContext(ctx, "git", "lfs", "ls-files", "--name-only")
+	listCmd.Dir = workspacePath
+	output, err := listCmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("git lfs ls-files: %w", err)
+	}
+
+	fileCount := 0
+	for line := range strings.SplitSeq(string(output), "\n") {
+		if strings.TrimSpace(line) != "" {
+			fileCount++
+		}
+	}
+	return &events.LFSStatus{Fetched: true, FileCount: fileCount}, nil
+}
@@ -0,0 +1,113 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/antinvestor/builder/internal/events"
+)
+
+// ErrPatchMismatch is returned when a patch does not match the workspace it is
+// to be applied to.
+var ErrPatchMismatch = errors.New("patch does not match workspace")
+
+// PatchMismatchReason names how a patch disagrees with the workspace.
+type PatchMismatchReason string
+
+const (
+	// PatchMismatchStaleContext means the old content of a modification is not in the file.
+	PatchMismatchStaleContext PatchMismatchReason = "stale_context"
+
+	// PatchMismatchFileExists means a file to be created already exists.
+	PatchMismatchFileExists PatchMismatchReason = "file_exists"
+
+	// PatchMismatchFileMissing means a file to be modified does not exist.
+	PatchMismatchFileMissing PatchMismatchReason = "file_missing"
+)
+
+// PatchMismatchError describes a patch that does not match the workspace. It
+// wraps ErrPatchMismatch.
+type PatchMismatchError struct {
+	FilePath string
+	Action   events.FileAction
+	Reason   PatchMismatchReason
+}
+
+// Error implements error.
+func (e *PatchMismatchError) Error() string {
+	var detail string
+	switch e.Reason {
+	case PatchMismatchStaleContext:
+		detail = "the old content does not occur in the current file"
+	case PatchMismatchFileExists:
+		detail = "the file to create already exists"
+	case PatchMismatchFileMissing:
+		detail = "the file to modify does not exist"
+	default:
+		detail = string(e.Reason)
+	}
+	return fmt.Sprintf("%s: %s %s: %s", ErrPatchMismatch, e.Action, e.FilePath, detail)
+}
+
+// Unwrap returns ErrPatchMismatch.
+func (e *PatchMismatchError) Unwrap() error {
+	return ErrPatchMismatch
+}
+
+// VerifyPatch checks a patch against the current workspace before it is applied:
+// a modification's old content must occur in the file, ignoring differences in
+// whitespace, and a created file must not exist yet. A mismatch is returned as a
+// *PatchMismatchError; other actions are not checked.
+func (s *Service) VerifyPatch(
+	ctx context.Context,
+	executionID events.ExecutionID,
+	patch *events.Patch,
+) error {
+	if patch.Action != events.FileActionModify && patch.Action != events.FileActionCreate {
+		return nil
+	}
+
+	workspace, err := s.workspaceRepo.GetByExecutionID(ctx, executionID.String())
+	if err != nil {
+		return err
+	}
+	filePath := filepath.Join(workspace.LocalPath, patch.FilePath)
+	mismatch := func(reason PatchMismatchReason) error {
+		return &PatchMismatchError{FilePath: patch.FilePath, Action: patch.Action, Reason: reason}
+	}
+
+	if patch.Action == events.FileActionCreate {
+		if _, statErr := os.Lstat(filePath); statErr == nil {
+			return mismatch(PatchMismatchFileExists)
+		} else if !os.IsNotExist(statErr) {
+			return fmt.Errorf("stat file: %w", statErr)
+		}
+		return nil
+	}
+
+	current, err := os.ReadFile(filePath)
+	if os.IsNotExist(err) {
+		return mismatch(PatchMismatchFileMissing)
+	}
+	if err != nil {
+		return fmt.Errorf("read file: %w", err)
+	}
+	if !containsIgnoringWhitespace(string(current), patch.OldContent) {
+		return mismatch(PatchMismatchStaleContext)
+	}
+	return nil
+}
+
+// containsIgnoringWhitespace reports whether content contains part, disregarding
+// all whitespace (indentation, spacing, line endings) and a leading byte order
+// mark. An empty part is contained in any content.
+func containsIgnoringWhitespace(content, part string) bool {
+	normalize := func(text string) string {
+		return strings.Join(strings.Fields(strings.TrimPrefix(text, "\uFEFF")), "")
+	}
+	return strings.Contains(normalize(content), normalize(part))
+}
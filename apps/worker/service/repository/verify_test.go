@@ -0,0 +1,73 @@
+package repository_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/antinvestor/builder/apps/worker/service/repository"
+	"github.com/antinvestor/builder/internal/events"
+)
+
+func requireMismatch(t *testing.T, err error, reason repository.PatchMismatchReason) {
+	t.Helper()
+	require.ErrorIs(t, err, repository.ErrPatchMismatch)
+	var mismatch *repository.PatchMismatchError
+	require.True(t, errors.As(err, &mismatch))
+	assert.Equal(t, reason, mismatch.Reason)
+}
+
+func TestVerifyPatch_RejectsStaleContextModify(t *testing.T) {
+	svc, executionID, _, _ := setupFeatureBranch(t)
+
+	err := svc.VerifyPatch(context.Background(), executionID, &events.Patch{
+		FilePath:   "handler.go",
+		Action:     events.FileActionModify,
+		OldContent: "func Handle() string { return \"v1\" }",
+		NewContent: "package api\n\nfunc Handle() string { return \"v2\" }\n",
+	})
+	requireMismatch(t, err, repository.PatchMismatchStaleContext)
+	assert.Contains(t, err.Error(), "handler.go")
+}
+
+func TestVerifyPatch_MatchesOldContentIgnoringWhitespace(t *testing.T) {
+	svc, executionID, _, _ := setupFeatureBranch(t)
+
+	require.NoError(t, svc.VerifyPatch(context.Background(), executionID, &events.Patch{
+		FilePath:   "handler.go",
+		Action:     events.FileActionModify,
+		OldContent: "func Handle() string {\r\n\treturn \"feature\"\r\n}",
+		NewContent: "package api\n\nfunc Handle() string { return \"v2\" }\n",
+	}))
+}
+
+func TestVerifyPatch_RejectsCreateOverExistingFile(t *testing.T) {
+	svc, executionID, _, _ := setupFeatureBranch(t)
+
+	err := svc.VerifyPatch(context.Background(), executionID, &events.Patch{
+		FilePath:   "README.md",
+		Action:     events.FileActionCreate,
+		NewContent: "new docs\n",
+	})
+	requireMismatch(t, err, repository.PatchMismatchFileExists)
+
+	require.NoError(t, svc.VerifyPatch(context.Background(), executionID, &events.Patch{
+		FilePath:   "docs/guide.md",
+		Action:     events.FileActionCreate,
+		NewContent: "guide\n",
+	}))
+}
+
+func TestVerifyPatch_RejectsModifyOfMissingFile(t *testing.T) {
+	svc, executionID, _, _ := setupFeatureBranch(t)
+
+	err := svc.VerifyPatch(context.Background(), executionID, &events.Patch{
+		FilePath:   "missing.go",
+		Action:     events.FileActionModify,
+		NewContent: "package api\n",
+	})
+	requireMismatch(t, err, repository.PatchMismatchFileMissing)
+}
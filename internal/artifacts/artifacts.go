@@ -0,0 +1,136 @@
+// Package artifacts stores execution artifacts too large to carry on events, shared
+// by the services that produce and serve them.
+package artifacts
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"time"
+
+	"gocloud.dev/blob"
+	"gocloud.dev/gcerrors"
+
+	"github.com/antinvestor/builder/internal/events"
+)
+
+// ErrNotFound is returned when an execution has no artifact of the requested name.
+var ErrNotFound = errors.New("artifact not found")
+
+// Store stores the artifacts of executions, each under its execution and name.
+type Store interface {
+	// Put stores an artifact, replacing one of the same name. The returned
+	// reference has no type; callers set it for the artifact they stored.
+	Put(
+		ctx context.Context,
+		executionID events.ExecutionID,
+		name string,
+		contentType string,
+		data []byte,
+	) (*events.ArtifactReference, error)
+	// Get returns the content of a stored artifact, or ErrNotFound.
+	Get(ctx context.Context, executionID events.ExecutionID, name string) ([]byte, error)
+}
+
+// BucketStore stores artifacts in a blob bucket under one key prefix per
+// execution, so every service opening the same bucket shares them. Artifacts are
+// referenced by their URL in the bucket.
+type BucketStore struct {
+	bucket  *blob.Bucket
+	baseURL string
+}
+
+// OpenBucketStore opens the bucket at a blob URL, such as
+// file:///var/lib/artifacts?create_dir=true or mem://, or the URL of an object
+// store whose driver is registered.
+func OpenBucketStore(ctx context.Context, bucketURL string) (*BucketStore, error) {
+	bucket, err := blob.OpenBucket(ctx, bucketURL)
+	if err != nil {
+		return nil, fmt.Errorf("open artifact bucket: %w", err)
+	}
+	return NewBucketStore(bucket, bucketURL), nil
+}
+
+// NewBucketStore creates an artifact store in bucket, referencing artifacts by
+// their key under the bucket's URL.
+func NewBucketStore(bucket *blob.Bucket, bucketURL string) *BucketStore {
+	baseURL, _, _ := strings.Cut(bucketURL, "?")
+	if !strings.HasSuffix(baseURL, "/") {
+		baseURL += "/"
+	}
+	return &BucketStore{bucket: bucket, baseURL: baseURL}
+}
+
+// Put implements Store.
+func (s *BucketStore) Put(
+	ctx context.Context,
+	executionID events.ExecutionID,
+	name string,
+	contentType string,
+	data []byte,
+) (*events.ArtifactReference, error) {
+	key := artifactKey(executionID, name)
+	if err := s.bucket.WriteAll(ctx, key, data, &blob.WriterOptions{ContentType: contentType}); err != nil {
+		return nil, fmt.Errorf("write artifact: %w", err)
+	}
+	return &events.ArtifactReference{
+		ArtifactID:  key,
+		Name:        name,
+		URL:         s.baseURL + key,
+		SizeBytes:   int64(len(data)),
+		ContentType: contentType,
+	}, nil
+}
+
+// Get implements Store.
+func (s *BucketStore) Get(ctx context.Context, executionID events.ExecutionID, name string) ([]byte, error) {
+	data, err := s.bucket.ReadAll(ctx, artifactKey(executionID, name))
+	if gcerrors.Code(err) == gcerrors.NotFound {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read artifact: %w", err)
+	}
+	return data, nil
+}
+
+// Prune deletes the artifacts last written before cutoff and returns how many
+// it deleted.
+func (s *BucketStore) Prune(ctx context.Context, cutoff time.Time) (int, error) {
+	deleted := 0
+	iter := s.bucket.List(nil)
+	for {
+		object, err := iter.Next(ctx)
+		if errors.Is(err, io.EOF) {
+			return deleted, nil
+		}
+		if err != nil {
+			return deleted, fmt.Errorf("list artifacts: %w", err)
+		}
+		if object.IsDir || !object.ModTime.Before(cutoff) {
+			continue
+		}
+		if err = s.bucket.Delete(ctx, object.Key); err != nil && gcerrors.Code(err) != gcerrors.NotFound {
+			return deleted, fmt.Errorf("delete artifact: %w", err)
+		}
+		deleted++
+	}
+}
+
+// Close closes the bucket.
+func (s *BucketStore) Close() error {
+	return s.bucket.Close()
+}
+
+// artifactKey returns the bucket key of an artifact. Both parts are reduced to
+// their base name so neither can reach into another execution's artifacts.
+func artifactKey(executionID events.ExecutionID, name string) string {
+	return baseName(executionID.String()) + "/" + baseName(name)
+}
+
+func baseName(part string) string {
+	return path.Base(path.Clean("/" + strings.ReplaceAll(part, "\\", "/")))
+}
@@ -0,0 +1,90 @@
+package artifacts_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gocloud.dev/blob/memblob"
+
+	"github.com/antinvestor/builder/internal/artifacts"
+	"github.com/antinvestor/builder/internal/events"
+)
+
+func TestBucketStore_PutAndGet(t *testing.T) {
+	store := artifacts.NewBucketStore(memblob.OpenBucket(nil), "mem://artifacts/")
+	ctx := context.Background()
+	executionID := events.NewExecutionID()
+
+	ref, err := store.Put(ctx, executionID, "test-output.log", "text/plain", []byte("PASS\n"))
+	require.NoError(t, err)
+	assert.Equal(t, executionID.String()+"/test-output.log", ref.ArtifactID)
+	assert.Equal(t, "mem://artifacts/"+executionID.String()+"/test-output.log", ref.URL)
+	assert.Equal(t, int64(5), ref.SizeBytes)
+	assert.Empty(t, ref.Type)
+
+	data, err := store.Get(ctx, executionID, "test-output.log")
+	require.NoError(t, err)
+	assert.Equal(t, "PASS\n", string(data))
+
+	_, err = store.Get(ctx, executionID, "execution-report.json")
+	require.ErrorIs(t, err, artifacts.ErrNotFound)
+}
+
+func TestBucketStore_SharedThroughBucketURL(t *testing.T) {
+	basePath := t.TempDir()
+	bucketURL := "file://" + filepath.ToSlash(basePath) + "?create_dir=true"
+	ctx := context.Background()
+	executionID := events.NewExecutionID()
+
+	writer, err := artifacts.OpenBucketStore(ctx, bucketURL)
+	require.NoError(t, err)
+	defer writer.Close()
+	ref, err := writer.Put(ctx, executionID, "test-output.log", "text/plain", []byte("PASS\n"))
+	require.NoError(t, err)
+	assert.Equal(t, "file://"+filepath.ToSlash(basePath)+"/"+ref.ArtifactID, ref.URL)
+
+	reader, err := artifacts.OpenBucketStore(ctx, bucketURL)
+	require.NoError(t, err)
+	defer reader.Close()
+	data, err := reader.Get(ctx, executionID, "test-output.log")
+	require.NoError(t, err)
+	assert.Equal(t, "PASS\n", string(data))
+}
+
+func TestBucketStore_StaysUnderExecutionPrefix(t *testing.T) {
+	store := artifacts.NewBucketStore(memblob.OpenBucket(nil), "mem://")
+	executionID := events.NewExecutionID()
+
+	ref, err := store.Put(context.Background(), executionID, "../../escape.log", "text/plain", []byte("x"))
+	require.NoError(t, err)
+	assert.Equal(t, executionID.String()+"/escape.log", ref.ArtifactID)
+}
+
+func TestBucketStore_PruneDeletesExpiredArtifacts(t *testing.T) {
+	basePath := t.TempDir()
+	store, err := artifacts.OpenBucketStore(context.Background(), "file://"+filepath.ToSlash(basePath))
+	require.NoError(t, err)
+	defer store.Close()
+	ctx := context.Background()
+	executionID := events.NewExecutionID()
+
+	_, err = store.Put(ctx, executionID, "old.log", "text/plain", []byte("old"))
+	require.NoError(t, err)
+	_, err = store.Put(ctx, executionID, "new.log", "text/plain", []byte("new"))
+	require.NoError(t, err)
+	past := time.Now().Add(-48 * time.Hour)
+	require.NoError(t, os.Chtimes(filepath.Join(basePath, executionID.String(), "old.log"), past, past))
+
+	deleted, err := store.Prune(ctx, time.Now().Add(-24*time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, 1, deleted)
+	_, err = store.Get(ctx, executionID, "old.log")
+	require.ErrorIs(t, err, artifacts.ErrNotFound)
+	_, err = store.Get(ctx, executionID, "new.log")
+	require.NoError(t, err)
+}
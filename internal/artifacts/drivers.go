@@ -0,0 +1,8 @@
+package artifacts
+
+// Blob drivers available to OpenBucketStore. An object store is added by
+// registering its gocloud.dev driver here, e.g. gocloud.dev/blob/s3blob.
+import (
+	_ "gocloud.dev/blob/fileblob"
+	_ "gocloud.dev/blob/memblob"
+)
@@ -0,0 +1,71 @@
+package artifacts
+
+import (
+	"context"
+	"time"
+
+	"github.com/pitabwire/util"
+)
+
+// retentionInterval is how often expired artifacts are deleted.
+const retentionInterval = time.Hour
+
+// Retention deletes the artifacts of a store once they are older than the
+// retention period.
+type Retention struct {
+	store  *BucketStore
+	maxAge time.Duration
+	now    func() time.Time
+
+	stopCh    chan struct{}
+	stoppedCh chan struct{}
+}
+
+// NewRetention creates a retention keeping artifacts for maxAge.
+func NewRetention(store *BucketStore, maxAge time.Duration) *Retention {
+	return &Retention{
+		store:     store,
+		maxAge:    maxAge,
+		now:       time.Now,
+		stopCh:    make(chan struct{}),
+		stoppedCh: make(chan struct{}),
+	}
+}
+
+// Start deletes expired artifacts now and then periodically.
+func (r *Retention) Start(ctx context.Context) {
+	r.prune(ctx)
+	go r.periodicPrune(ctx)
+}
+
+// Stop stops the periodic deletion gracefully.
+func (r *Retention) Stop() {
+	close(r.stopCh)
+	<-r.stoppedCh
+}
+
+func (r *Retention) periodicPrune(ctx context.Context) {
+	defer close(r.stoppedCh)
+
+	ticker := time.NewTicker(retentionInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-r.stopCh:
+			return
+		case <-ticker.C:
+			r.prune(ctx)
+		}
+	}
+}
+
+func (r *Retention) prune(ctx context.Context) {
+	deleted, err := r.store.Prune(ctx, r.now().Add(-r.maxAge))
+	if err != nil {
+		util.Log(ctx).WithError(err).Error("artifact retention failed", "deleted", deleted)
+		return
+	}
+	if deleted > 0 {
+		util.Log(ctx).Info("deleted expired artifacts", "deleted", deleted)
+	}
+}
@@ -130,6 +130,7 @@ type LLMProcessingInfo struct {
 	OutputTokens int    `json:"output_tokens"`
 	LatencyMS    int64  `json:"latency_ms"`
 	Provider     string `json:"provider,omitempty"`
+	ModelVersion string `json:"model_version,omitempty"`
 }
 
 // SpecificationNormalizationFailedPayload is the payload for SpecificationNormalizationFailed.
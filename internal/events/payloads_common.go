@@ -130,6 +130,9 @@ type TestExecutionRequestedPayload struct {
 	// TestFiles are the test files to run.
 	TestFiles []string `json:"test_files"`
 
+	// TestCommand, when set, replaces the language's test command; it is run with sh -c.
+	TestCommand string `json:"test_command,omitempty"`
+
 	// WorkspacePath is the path to the workspace.
 	WorkspacePath string `json:"workspace_path"`
 }
@@ -142,11 +145,27 @@ type TestExecutionCompletedPayload struct {
 	// Success indicates if tests passed.
 	Success bool `json:"success"`
 
+	// Skipped is true when no tests ran, e.g. because the project language was not
+	// detected; Success is then false.
+	Skipped bool `json:"skipped,omitempty"`
+
 	// Result contains detailed test results.
 	Result *TestResult `json:"result,omitempty"`
 
 	// Error contains error information if failed.
 	Error *ExecutionError `json:"error,omitempty"`
+
+	// ResourceUsage is the sandbox resource consumption of the run.
+	ResourceUsage *SandboxResourceUsage `json:"resource_usage,omitempty"`
+
+	// Output is the raw test output, or only its tail when the output was offloaded.
+	Output string `json:"output,omitempty"`
+
+	// OutputArtifact references the full test output when it was too large to inline.
+	OutputArtifact *ArtifactReference `json:"output_artifact,omitempty"`
+
+	// OutputTruncatedBytes is how much test output was dropped by the output cap.
+	OutputTruncatedBytes int64 `json:"output_truncated_bytes,omitempty"`
 }
 
 // TestResult contains test execution results.
@@ -174,6 +193,15 @@ type TestResult struct {
 
 	// Coverage is the code coverage percentage.
 	Coverage float64 `json:"coverage,omitempty"`
+
+	// BaselineCoverage is the coverage percentage of the base commit, 0 when not measured.
+	BaselineCoverage float64 `json:"baseline_coverage,omitempty"`
+
+	// BuildWarnings are compiler and vet warnings reported while building the tests.
+	BuildWarnings []ReviewIssue `json:"build_warnings,omitempty"`
+
+	// QuarantinedTests are known-flaky failing tests excluded from blocking the run.
+	QuarantinedTests []string `json:"quarantined_tests,omitempty"`
 }
 
 // TestCaseResult describes a single test case result.
@@ -191,6 +219,8 @@ type ExecutionError struct {
 	Code    string `json:"code"`
 	Message string `json:"message"`
 	Details string `json:"details,omitempty"`
+	// Retryable marks a failure that did not run the tests, so the request can be retried.
+	Retryable bool `json:"retryable,omitempty"`
 }
 
 
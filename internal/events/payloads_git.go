@@ -64,6 +64,9 @@ type GitPushStartedPayload struct {
 
 // GitPushCompletedPayload is the payload for GitPushCompleted.
 type GitPushCompletedPayload struct {
+	// ExecutionID is the execution whose branch was pushed.
+	ExecutionID ExecutionID `json:"execution_id,omitempty"`
+
 	// BranchName is the pushed branch.
 	BranchName string `json:"branch_name"`
 
@@ -117,6 +120,82 @@ const (
 	GitPushErrorProtected   GitPushErrorCode = "protected"    // Protected branch rules
 )
 
+// ===== GIT MERGE CONFLICT EVENTS =====
+
+// GitMergeConflictDetectedPayload is the payload for GitMergeConflictDetected.
+type GitMergeConflictDetectedPayload struct {
+	// ExecutionID is the feature execution ID.
+	ExecutionID ExecutionID `json:"execution_id"`
+
+	// BranchName is the feature branch that could not be rebased.
+	BranchName string `json:"branch_name"`
+
+	// BaseBranch is the branch the feature branch was rebased onto.
+	BaseBranch string `json:"base_branch"`
+
+	// BaseCommitSHA is the base commit at checkout.
+	BaseCommitSHA string `json:"base_commit_sha"`
+
+	// LatestBaseCommitSHA is the commit the base branch has advanced to.
+	LatestBaseCommitSHA string `json:"latest_base_commit_sha"`
+
+	// ConflictingFiles are the files changed on both branches.
+	ConflictingFiles []string `json:"conflicting_files"`
+
+	// Resolution is how the conflict is being handled.
+	Resolution MergeConflictResolution `json:"resolution"`
+
+	// DetectedAt is when the conflict was detected.
+	DetectedAt time.Time `json:"detected_at"`
+}
+
+// MergeConflictResolution is how a conflict with the base branch is handled.
+type MergeConflictResolution string
+
+const (
+	MergeConflictResolutionRegenerate   MergeConflictResolution = "regenerate"    // Regenerate the change against the new base
+	MergeConflictResolutionManualReview MergeConflictResolution = "manual_review" // Pause for a human to resolve
+)
+
+// BaseSyncStrategy is how the feature branch takes in an advanced base branch.
+type BaseSyncStrategy string
+
+const (
+	BaseSyncRebase BaseSyncStrategy = "rebase" // Replay the feature commits onto the base
+	BaseSyncMerge  BaseSyncStrategy = "merge"  // Merge the base into the feature branch
+)
+
+// DeliveryConflictPayload is the payload for DeliveryConflictDetected.
+type DeliveryConflictPayload struct {
+	// ExecutionID is the feature execution ID.
+	ExecutionID ExecutionID `json:"execution_id"`
+
+	// BranchName is the feature branch that could not be pushed.
+	BranchName string `json:"branch_name"`
+
+	// BaseBranch is the branch the feature branch was synced with.
+	BaseBranch string `json:"base_branch,omitempty"`
+
+	// BaseCommitSHA is the base commit at checkout.
+	BaseCommitSHA string `json:"base_commit_sha,omitempty"`
+
+	// LatestBaseCommitSHA is the commit the base branch has advanced to.
+	LatestBaseCommitSHA string `json:"latest_base_commit_sha,omitempty"`
+
+	// Strategy is how the base branch was taken in.
+	Strategy BaseSyncStrategy `json:"strategy"`
+
+	// ConflictingFiles are the files changed on both branches; empty when the
+	// push was rejected again after a clean sync.
+	ConflictingFiles []string `json:"conflicting_files"`
+
+	// ErrorMessage is the push rejection that led to the sync.
+	ErrorMessage string `json:"error_message"`
+
+	// DetectedAt is when the conflict was detected.
+	DetectedAt time.Time `json:"detected_at"`
+}
+
 // ===== GIT OPERATION HELPERS =====
 
 // GitRef represents a git reference.
@@ -6,6 +6,9 @@ import "time"
 
 // IterationRequiredPayload is the payload for IterationRequired.
 type IterationRequiredPayload struct {
+	// ExecutionID is the feature execution ID.
+	ExecutionID ExecutionID `json:"execution_id"`
+
 	// IterationNumber is the iteration number (1-based).
 	IterationNumber int `json:"iteration_number"`
 
@@ -35,6 +38,7 @@ const (
 	IterationReasonSecurityIssues IterationReason = "security_issues"
 	IterationReasonReviewRejected IterationReason = "review_rejected"
 	IterationReasonValidationFailed IterationReason = "validation_failed"
+	IterationReasonMergeConflict IterationReason = "merge_conflict"
 )
 
 // IterationIssue describes an issue triggering iteration.
@@ -63,8 +63,23 @@ type RepositoryContext struct {
 
 	// FeatureBranchName is the name for feature branch (auto-generated if empty).
 	FeatureBranchName string `json:"feature_branch_name,omitempty"`
+
+	// Submodules overrides whether submodules are initialized during checkout.
+	Submodules *bool `json:"submodules,omitempty"`
+
+	// LFS overrides whether Git LFS objects are fetched during checkout.
+	LFS *bool `json:"lfs,omitempty"`
 }
 
+// TargetEnvironment identifies the deployment environment of a target branch.
+type TargetEnvironment string
+
+const (
+	TargetEnvironmentUnspecified TargetEnvironment = ""
+	TargetEnvironmentProduction  TargetEnvironment = "production"
+	TargetEnvironmentStaging     TargetEnvironment = "staging"
+)
+
 // ExecutionConstraints define execution boundaries.
 type ExecutionConstraints struct {
 	// MaxSteps limits the number of implementation steps.
@@ -114,6 +129,9 @@ type RequestMetadata struct {
 
 // FeatureExecutionCompletedPayload is the payload for FeatureExecutionCompleted.
 type FeatureExecutionCompletedPayload struct {
+	// ExecutionID is the execution that completed.
+	ExecutionID ExecutionID `json:"execution_id,omitempty"`
+
 	// FinalCommit is the final commit on the feature branch.
 	FinalCommit CommitInfo `json:"final_commit"`
 
@@ -143,6 +161,9 @@ type ExecutionSummary struct {
 
 // FeatureDeliveredPayload is the payload for FeatureDelivered.
 type FeatureDeliveredPayload struct {
+	// ExecutionID is the execution that delivered the feature.
+	ExecutionID ExecutionID `json:"execution_id,omitempty"`
+
 	// BranchName is the created feature branch.
 	BranchName string `json:"branch_name"`
 
@@ -157,6 +178,25 @@ type FeatureDeliveredPayload struct {
 
 	// Summary is the final delivery summary.
 	Summary DeliverySummary `json:"summary"`
+
+	// Partial is true when only the clean subset of files was delivered.
+	Partial bool `json:"partial,omitempty"`
+
+	// DeliveredFiles are the files included in a partial delivery.
+	DeliveredFiles []string `json:"delivered_files,omitempty"`
+
+	// HeldFiles are the files held back for iteration in a partial delivery.
+	HeldFiles []string `json:"held_files,omitempty"`
+
+	// Labels are the pull request labels derived from the review findings.
+	Labels []string `json:"labels,omitempty"`
+
+	// PullRequestBody is the pull request description, linking the change back to
+	// its feature specification and execution.
+	PullRequestBody string `json:"pull_request_body,omitempty"`
+
+	// PullRequestURL is the pull request opened for the branch, once one exists.
+	PullRequestURL string `json:"pull_request_url,omitempty"`
 }
 
 // ArtifactReference references a created artifact.
@@ -175,12 +215,62 @@ type DeliverySummary struct {
 	Description string           `json:"description"`
 	Execution   ExecutionSummary `json:"execution"`
 	Tests       *TestResult      `json:"tests,omitempty"`
+	DiffStats   *DiffStats       `json:"diff_stats,omitempty"`
+
+	// AcceptanceAssessment is the generator's self-assessment of the delivered
+	// changes against the acceptance criteria, when one was made.
+	AcceptanceAssessment *AcceptanceSelfAssessment `json:"acceptance_assessment,omitempty"`
+}
+
+// DiffStats breaks the delivered changes down by language and top-level directory.
+type DiffStats struct {
+	ByLanguage  []DiffStatsEntry `json:"by_language"`
+	ByDirectory []DiffStatsEntry `json:"by_directory"`
 }
 
+// DiffStatsEntry counts the files and lines changed within one group.
+type DiffStatsEntry struct {
+	Name         string `json:"name"`
+	Files        int    `json:"files"`
+	LinesAdded   int    `json:"lines_added"`
+	LinesRemoved int    `json:"lines_removed"`
+}
+
+// ===== FEATURE NO-OP =====
+
+// FeatureNoOpPayload is the payload for FeatureNoOp.
+type FeatureNoOpPayload struct {
+	// ExecutionID is the execution that produced no changes.
+	ExecutionID ExecutionID `json:"execution_id"`
+
+	// BranchName is the feature branch, which was not pushed.
+	BranchName string `json:"branch_name"`
+
+	// Reason explains why no changes were produced.
+	Reason string `json:"reason"`
+
+	// PatchesGenerated is the number of patches generated before the diff came out empty.
+	PatchesGenerated int `json:"patches_generated"`
+
+	// CompletedAt is when the no-op outcome was determined.
+	CompletedAt time.Time `json:"completed_at"`
+}
+
+// NoChangesDecision is how an execution that produced an empty diff ends.
+type NoChangesDecision string
+
+const (
+	NoChangesDecisionNoOp NoChangesDecision = "noop" // Complete with no changes
+	NoChangesDecisionFail NoChangesDecision = "fail" // Fail the execution
+)
+
 // ===== FEATURE EXECUTION FAILED =====
 
 // FeatureExecutionFailedPayload is the payload for FeatureExecutionFailed.
 type FeatureExecutionFailedPayload struct {
+	// ExecutionID is the execution that failed.
+	ExecutionID ExecutionID `json:"execution_id,omitempty"`
+
 	// Classification categorizes the failure.
 	Classification FailureClassification `json:"classification"`
 
@@ -211,6 +301,12 @@ type FailureClassification struct {
 	UserActionable bool            `json:"user_actionable"`
 }
 
+// IsOutage reports whether the failure is a retryable infrastructure outage rather
+// than a problem with the change itself, so the execution may be started over.
+func (c FailureClassification) IsOutage() bool {
+	return c.Retryable && (c.Type == FailureTypeInfrastructure || c.Type == FailureTypeTransient)
+}
+
 // FailureType categorizes failure types.
 type FailureType string
 
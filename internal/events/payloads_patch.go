@@ -135,7 +135,62 @@ type PatchGenerationCompletedPayload struct {
 	FinalCommitSHA    string       `json:"final_commit_sha"`
 	TotalDurationMS   int64        `json:"total_duration_ms"`
 	TotalLLMTokens    int          `json:"total_llm_tokens"`
-	CompletedAt       time.Time    `json:"completed_at"`
+	// LLMInfo identifies the provider, model and model version that generated the patches.
+	LLMInfo     LLMProcessingInfo `json:"llm_info"`
+	CompletedAt time.Time         `json:"completed_at"`
+}
+
+// ===== PATCH APPLICATION =====
+
+// PatchApplicationMode is how patches that fail to apply are handled.
+type PatchApplicationMode string
+
+const (
+	PatchApplicationStrict  PatchApplicationMode = "strict"  // The first failure fails the execution
+	PatchApplicationLenient PatchApplicationMode = "lenient" // Failures are collected; the rest still apply
+)
+
+// PatchApplicationResultPayload is the payload for PatchApplicationResult.
+type PatchApplicationResultPayload struct {
+	ExecutionID  ExecutionID             `json:"execution_id"`
+	Mode         PatchApplicationMode    `json:"mode"`
+	TotalPatches int                     `json:"total_patches"`
+	AppliedFiles []string                `json:"applied_files"`
+	FailedFiles  []PatchApplicationError `json:"failed_files,omitempty"`
+	// ThresholdExceeded is set when too many patches failed and the execution fails.
+	ThresholdExceeded bool      `json:"threshold_exceeded"`
+	CompletedAt       time.Time `json:"completed_at"`
+}
+
+// PatchApplicationError records a patch that failed to apply.
+type PatchApplicationError struct {
+	FilePath      string            `json:"file_path"`
+	Action        FileAction        `json:"action"`
+	ErrorMessage  string            `json:"error_message"`
+	ErrorCategory StepErrorCategory `json:"error_category"`
+}
+
+// ===== PATCH PREVIEW =====
+
+// PatchPreviewReadyPayload is the payload for PatchPreviewReady. The patches have
+// been generated but not applied or committed; delivery waits for a decision.
+type PatchPreviewReadyPayload struct {
+	ExecutionID       ExecutionID `json:"execution_id"`
+	BranchName        string      `json:"branch_name"`
+	Patches           []Patch     `json:"patches"`
+	Diff              string      `json:"diff"`
+	TotalLinesAdded   int         `json:"total_lines_added"`
+	TotalLinesRemoved int         `json:"total_lines_removed"`
+	CreatedAt         time.Time   `json:"created_at"`
+}
+
+// PatchPreviewDecidedPayload is the payload for PatchPreviewDecided.
+type PatchPreviewDecidedPayload struct {
+	ExecutionID ExecutionID `json:"execution_id"`
+	Approved    bool        `json:"approved"`
+	DecidedBy   string      `json:"decided_by,omitempty"`
+	Reason      string      `json:"reason,omitempty"`
+	DecidedAt   time.Time   `json:"decided_at"`
 }
 
 // ===== UNIFIED DIFF HELPERS =====
@@ -32,14 +32,40 @@ type RepositoryCheckoutCompletedPayload struct {
 	HeadCommitSHA     string               `json:"head_commit_sha"`
 	HeadCommitMessage string               `json:"head_commit_message"`
 	BranchName        string               `json:"branch_name"`
+	// DefaultBranch is the remote's default branch, set when no branch was requested.
+	DefaultBranch     string               `json:"default_branch,omitempty"`
 	FeatureBranchName string               `json:"feature_branch_name"`
 	Spec              FeatureSpecification `json:"spec"`
 	RepositoryURL     string               `json:"repository_url"`
 	Metrics           RepositoryMetrics    `json:"metrics"`
+	// Submodules reports the submodules initialized during checkout.
+	Submodules        *SubmoduleStatus     `json:"submodules,omitempty"`
+	// LFS reports the Git LFS objects fetched during checkout.
+	LFS               *LFSStatus           `json:"lfs,omitempty"`
+	// SeedFiles are the template files copied into the workspace after checkout.
+	SeedFiles         []string             `json:"seed_files,omitempty"`
 	DurationMS        int64                `json:"duration_ms"`
 	CompletedAt       time.Time            `json:"completed_at"`
 }
 
+// SubmoduleStatus describes the submodules initialized in a workspace.
+type SubmoduleStatus struct {
+	Initialized bool            `json:"initialized"`
+	Submodules  []SubmoduleInfo `json:"submodules"`
+}
+
+// SubmoduleInfo identifies a checked out submodule.
+type SubmoduleInfo struct {
+	Path      string `json:"path"`
+	CommitSHA string `json:"commit_sha"`
+}
+
+// LFSStatus describes the Git LFS objects fetched into a workspace.
+type LFSStatus struct {
+	Fetched   bool `json:"fetched"`
+	FileCount int  `json:"file_count"`
+}
+
 // RepositoryMetrics contains repository statistics.
 type RepositoryMetrics struct {
 	TotalSizeBytes int64 `json:"total_size_bytes"`
@@ -68,6 +94,7 @@ const (
 	CheckoutErrorDiskSpace   CheckoutErrorCode = "disk_space"
 	CheckoutErrorTimeout     CheckoutErrorCode = "timeout"
 	CheckoutErrorCorruption  CheckoutErrorCode = "corruption"
+	CheckoutErrorSeed        CheckoutErrorCode = "seed"
 )
 
 // ===== REPOSITORY INDEXING =====
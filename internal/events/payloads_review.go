@@ -173,6 +173,7 @@ const (
 	ReviewIssueTypeComplexity     ReviewIssueType = "complexity"
 	ReviewIssueTypeDeadCode       ReviewIssueType = "dead_code"
 	ReviewIssueTypeDuplication    ReviewIssueType = "duplication"
+	ReviewIssueTypeMigration      ReviewIssueType = "migration"
 )
 
 // ReviewIssueSeverity indicates issue severity.
@@ -392,6 +393,12 @@ type PatchReference struct {
 
 	// DiffContent is the unified diff.
 	DiffContent string `json:"diff_content,omitempty"`
+
+	// OldContent is the file before the change, the baseline the diff applies to.
+	OldContent string `json:"old_content,omitempty"`
+
+	// NewContent is the file after the change, used when the diff cannot be applied.
+	NewContent string `json:"new_content,omitempty"`
 }
 
 // ReviewReference references a previous review.
@@ -422,6 +429,51 @@ type ReviewContext struct {
 
 	// RepositoryContext provides repo information.
 	RepositoryContext *RepositoryContext `json:"repository_context,omitempty"`
+
+	// AcceptanceAssessment is the generator's self-assessment against the acceptance criteria.
+	AcceptanceAssessment *AcceptanceSelfAssessment `json:"acceptance_assessment,omitempty"`
+}
+
+// AcceptanceSelfAssessment is the generator's own assessment of whether its
+// changes satisfy the acceptance criteria.
+type AcceptanceSelfAssessment struct {
+	// Criteria holds one assessment per acceptance criterion.
+	Criteria []AcceptanceCriterionAssessment `json:"criteria"`
+
+	// TokensUsed is the LLM token cost of the self-check.
+	TokensUsed int `json:"tokens_used,omitempty"`
+
+	// LLMInfo identifies the provider, model and model version that ran the self-check.
+	LLMInfo LLMProcessingInfo `json:"llm_info"`
+
+	// AssessedAt is when the self-check ran.
+	AssessedAt time.Time `json:"assessed_at"`
+}
+
+// AcceptanceCriterionAssessment states whether one acceptance criterion is met.
+type AcceptanceCriterionAssessment struct {
+	// Criterion is the acceptance criterion text.
+	Criterion string `json:"criterion"`
+
+	// Satisfied indicates the changes implement the criterion.
+	Satisfied bool `json:"satisfied"`
+
+	// Rationale is the evidence, or what is missing for unmet criteria.
+	Rationale string `json:"rationale,omitempty"`
+}
+
+// UnmetCriteria returns the criteria assessed as not satisfied.
+func (a *AcceptanceSelfAssessment) UnmetCriteria() []AcceptanceCriterionAssessment {
+	if a == nil {
+		return nil
+	}
+	var unmet []AcceptanceCriterionAssessment
+	for _, c := range a.Criteria {
+		if !c.Satisfied {
+			unmet = append(unmet, c)
+		}
+	}
+	return unmet
 }
 
 // ===== COMPREHENSIVE REVIEW RESULT =====
@@ -467,13 +519,60 @@ type ComprehensiveReviewCompletedPayload struct {
 	// NextActions are the next actions to take.
 	NextActions []ReviewNextAction `json:"next_actions,omitempty"`
 
+	// FileStatuses is the review outcome of each reviewed file.
+	FileStatuses map[string]FileReviewStatus `json:"file_statuses,omitempty"`
+
+	// Partial is true when the change exceeded the review size limits and only a
+	// sample of its files was analyzed.
+	Partial bool `json:"partial,omitempty"`
+
+	// UnreviewedFiles are the files left out of a partial review.
+	UnreviewedFiles []string `json:"unreviewed_files,omitempty"`
+
+	// ScrutinizedFiles are changed high-impact config files (CI, containers, deployment)
+	// that received extra scrutiny.
+	ScrutinizedFiles []string `json:"scrutinized_files,omitempty"`
+
 	// LLMInfo contains LLM processing details.
 	LLMInfo LLMProcessingInfo `json:"llm_info"`
 
+	// AnalyzerMetrics records the duration and finding count of each analyzer run.
+	AnalyzerMetrics []AnalyzerMetrics `json:"analyzer_metrics,omitempty"`
+
 	// CompletedAt is when the review completed.
 	CompletedAt time.Time `json:"completed_at"`
 }
 
+// AnalyzerMetrics records how one analyzer performed during a review.
+type AnalyzerMetrics struct {
+	// Analyzer is the analyzer name (security, architecture or a custom analyzer).
+	Analyzer string `json:"analyzer"`
+
+	// DurationMS is how long the analyzer took, in milliseconds.
+	DurationMS int64 `json:"duration_ms"`
+
+	// FindingCount is the number of findings the analyzer produced.
+	FindingCount int `json:"finding_count"`
+}
+
+// FileReviewStatus is the review outcome of a single file.
+type FileReviewStatus string
+
+const (
+	// FileReviewStatusClean means the file has no blocking issues.
+	FileReviewStatusClean FileReviewStatus = "clean"
+
+	// FileReviewStatusFlagged means the file has blocking issues and needs iteration.
+	FileReviewStatusFlagged FileReviewStatus = "flagged"
+
+	// FileReviewStatusUnreviewed means the file was left out of a partial review.
+	FileReviewStatusUnreviewed FileReviewStatus = "unreviewed"
+
+	// FileReviewStatusSkipped means the file is vendored and was only scanned for
+	// secrets and security issues.
+	FileReviewStatusSkipped FileReviewStatus = "skipped"
+)
+
 // ControlDecision is the decision from the review.
 type ControlDecision string
 
@@ -515,6 +614,36 @@ type ReviewNextAction struct {
 	Priority string `json:"priority"` // immediate, high, medium, low
 }
 
+// ===== MANUAL REVIEW REQUEST =====
+
+// ManualReviewRequestedPayload is the payload for ReviewManualRequested.
+type ManualReviewRequestedPayload struct {
+	// ExecutionID is the execution awaiting review.
+	ExecutionID ExecutionID `json:"execution_id"`
+
+	// Reason is the reviewer's rationale for requiring manual review.
+	Reason string `json:"reason"`
+
+	// ChangedFiles are the files in the change.
+	ChangedFiles []string `json:"changed_files"`
+
+	// RequiredReviewers are the code owners of the changed files, or the fallback
+	// reviewers when no file has an owner.
+	RequiredReviewers []string `json:"required_reviewers,omitempty"`
+
+	// FileOwners are the owners of each owned changed file.
+	FileOwners map[string][]string `json:"file_owners,omitempty"`
+
+	// UnownedFiles are changed files without a code owner.
+	UnownedFiles []string `json:"unowned_files,omitempty"`
+
+	// CodeOwnersSource is the CODEOWNERS file owners were resolved from.
+	CodeOwnersSource string `json:"code_owners_source,omitempty"`
+
+	// RequestedAt is when the review was requested.
+	RequestedAt time.Time `json:"requested_at"`
+}
+
 // ===== RISK ASSESSMENT =====
 
 // RiskAssessment provides a comprehensive risk evaluation.
@@ -588,6 +717,7 @@ const (
 	RiskCategoryDependency     RiskCategory = "dependency"
 	RiskCategoryRegression     RiskCategory = "regression"
 	RiskCategoryDataIntegrity  RiskCategory = "data_integrity"
+	RiskCategoryConfiguration  RiskCategory = "configuration"
 )
 
 // RiskMitigation describes a recommended mitigation.
@@ -644,6 +774,31 @@ type SecurityAssessment struct {
 
 	// SecurityReviewReason explains why security review is needed.
 	SecurityReviewReason string `json:"security_review_reason,omitempty"`
+
+	// ThrottledFindings summarize findings left out once their type reached its cap.
+	ThrottledFindings []ThrottledFindings `json:"throttled_findings,omitempty"`
+
+	// SuppressedCount is how many findings builder:ignore-security comments suppressed.
+	SuppressedCount int `json:"suppressed_count,omitempty"`
+}
+
+// ThrottledFindings summarizes the findings of one type that were not listed
+// individually because the type reached its reporting cap.
+type ThrottledFindings struct {
+	// Category is the assessment list the findings were left out of, e.g. "insecure_pattern".
+	Category string `json:"category"`
+
+	// Type is the finding type.
+	Type string `json:"type"`
+
+	// Severity is the severity of the omitted findings, when the finding has one.
+	Severity string `json:"severity,omitempty"`
+
+	// Reported is the number of findings of the type still listed.
+	Reported int `json:"reported"`
+
+	// Omitted is the number of findings of the type left out.
+	Omitted int `json:"omitted"`
 }
 
 // SecurityStatus indicates overall security status.
@@ -720,6 +875,9 @@ type InsecurePattern struct {
 
 	// CWE maps to CWE category.
 	CWE string `json:"cwe,omitempty"`
+
+	// Severity is the severity of the pattern.
+	Severity VulnerabilitySeverity `json:"severity,omitempty"`
 }
 
 // InsecurePatternType categorizes insecure patterns.
@@ -856,6 +1014,9 @@ type ArchitectureAssessment struct {
 	// PatternViolations are design pattern violations.
 	PatternViolations []PatternViolation `json:"pattern_violations,omitempty"`
 
+	// TestRegressions are tests removed or disabled by the change.
+	TestRegressions []TestRegression `json:"test_regressions,omitempty"`
+
 	// APIContractViolations are API contract violations.
 	APIContractViolations []APIContractViolation `json:"api_contract_violations,omitempty"`
 
@@ -867,6 +1028,9 @@ type ArchitectureAssessment struct {
 
 	// ArchitectureReviewReason explains why review is needed.
 	ArchitectureReviewReason string `json:"architecture_review_reason,omitempty"`
+
+	// ThrottledFindings summarize findings left out once their type reached its cap.
+	ThrottledFindings []ThrottledFindings `json:"throttled_findings,omitempty"`
 }
 
 // ArchitectureStatus indicates architecture compliance status.
@@ -1045,6 +1209,32 @@ type PatternViolation struct {
 	Recommendation string `json:"recommendation"`
 }
 
+// TestRegression describes a test removed or disabled by a change.
+type TestRegression struct {
+	// RegressionType is how the test was weakened.
+	RegressionType TestRegressionType `json:"regression_type"`
+
+	// FilePath is the test file.
+	FilePath string `json:"file_path"`
+
+	// TestName is the affected test, when it can be identified.
+	TestName string `json:"test_name,omitempty"`
+
+	// Description describes the regression.
+	Description string `json:"description"`
+
+	// Severity indicates severity.
+	Severity ReviewIssueSeverity `json:"severity"`
+}
+
+// TestRegressionType categorizes test regressions.
+type TestRegressionType string
+
+const (
+	TestRegressionRemoved TestRegressionType = "removed"
+	TestRegressionSkipped TestRegressionType = "skipped"
+)
+
 // APIContractViolation describes an API contract violation.
 type APIContractViolation struct {
 	// Endpoint is the API endpoint.
@@ -1212,6 +1402,9 @@ type KillSwitchActivatedPayload struct {
 	// ExecutionID is the affected execution (if feature-scoped).
 	ExecutionID ExecutionID `json:"execution_id,omitempty"`
 
+	// RepositoryID is the affected repository (if repository-scoped).
+	RepositoryID string `json:"repository_id,omitempty"`
+
 	// Reason is why the kill switch was activated.
 	Reason KillSwitchReason `json:"reason"`
 
@@ -1221,7 +1414,7 @@ type KillSwitchActivatedPayload struct {
 	// Details provides additional details.
 	Details string `json:"details,omitempty"`
 
-	// AffectedExecutions are affected executions (if global scope).
+	// AffectedExecutions are the in-flight executions affected (if global or repository scope).
 	AffectedExecutions []ExecutionID `json:"affected_executions,omitempty"`
 
 	// RollbackAll indicates if all changes should be rolled back.
@@ -1266,6 +1459,9 @@ type KillSwitchDeactivatedPayload struct {
 	// ExecutionID is the affected execution (if feature-scoped).
 	ExecutionID ExecutionID `json:"execution_id,omitempty"`
 
+	// RepositoryID is the affected repository (if repository-scoped).
+	RepositoryID string `json:"repository_id,omitempty"`
+
 	// DeactivatedBy identifies who deactivated the kill switch.
 	DeactivatedBy string `json:"deactivated_by"`
 
@@ -1337,6 +1533,16 @@ type ReviewThresholds struct {
 	// MaxIterations is max iterations before abort.
 	MaxIterations int `json:"max_iterations"`
 
+	// MaxBuildWarnings is max build warnings allowed (0 = unlimited).
+	MaxBuildWarnings int `json:"max_build_warnings,omitempty"`
+
+	// NoCoverageRegression fails the tests when coverage drops below the baseline
+	// by more than CoverageRegressionTolerance, even above MinTestCoverage.
+	NoCoverageRegression bool `json:"no_coverage_regression,omitempty"`
+
+	// CoverageRegressionTolerance is the coverage drop allowed, in percentage points.
+	CoverageRegressionTolerance float64 `json:"coverage_regression_tolerance,omitempty"`
+
 	// RequireSecurityApproval requires security team approval.
 	RequireSecurityApproval bool `json:"require_security_approval"`
 
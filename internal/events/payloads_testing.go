@@ -130,6 +130,23 @@ type TestExecutionStartedPayload struct {
 	TestFiles      []string  `json:"test_files,omitempty"`
 	TimeoutSeconds int       `json:"timeout_seconds"`
 	StartedAt      time.Time `json:"started_at"`
+	// Warning explains a test command chosen without detecting the project language.
+	Warning string `json:"warning,omitempty"`
+	// ExecutionID is the execution whose tests are running.
+	ExecutionID ExecutionID `json:"execution_id,omitempty"`
+}
+
+// TestProgressPayload is the payload for TestExecutionProgress. The tests of a run
+// are counted as they complete; the final result arrives with TestExecutionCompleted.
+type TestProgressPayload struct {
+	ExecutionID    ExecutionID `json:"execution_id"`
+	TestsCompleted int         `json:"tests_completed"`
+	TestsPassed    int         `json:"tests_passed"`
+	TestsFailed    int         `json:"tests_failed"`
+	TestsSkipped   int         `json:"tests_skipped"`
+	// Failures are the names of the tests that failed so far.
+	Failures   []string  `json:"failures,omitempty"`
+	ReportedAt time.Time `json:"reported_at"`
 }
 
 // TestStatus indicates overall test result.
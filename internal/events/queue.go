@@ -161,6 +161,9 @@ type EventMessage struct {
 	Timestamp time.Time `json:"timestamp,omitempty"`
 }
 
+// HeaderEventType is the message header naming the event type of a queue message.
+const HeaderEventType = "event_type"
+
 // EventToQueuePayload converts an event to a Frame queue payload.
 func EventToQueuePayload(event *Event) (JSONMap, map[string]string, error) {
 	payload := JSONMap{
@@ -174,7 +177,7 @@ func EventToQueuePayload(event *Event) (JSONMap, map[string]string, error) {
 	}
 
 	headers := map[string]string{
-		"event_type":     event.EventType.String(),
+		HeaderEventType:  event.EventType.String(),
 		"event_id":       event.EventID.String(),
 		"execution_id":   event.FeatureExecutionID.String(),
 		"sequence":       fmt.Sprintf("%d", event.SequenceNumber),
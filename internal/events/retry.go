@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"math"
+	"math/rand/v2"
 	"time"
 )
 
@@ -32,6 +33,11 @@ type RetryPolicy struct {
 	// Jitter adds randomness to prevent thundering herd.
 	Jitter float64 `json:"jitter"` // 0.0 to 1.0
 
+	// FullJitter replaces the delay with a random one between zero and the
+	// delay, so retries failing together spread over the whole window. It
+	// takes the place of Jitter.
+	FullJitter bool `json:"full_jitter,omitempty"`
+
 	// RetryableErrors are error codes that should be retried.
 	RetryableErrors []string `json:"retryable_errors,omitempty"`
 }
@@ -65,6 +71,11 @@ func (p RetryPolicy) CalculateDelay(attempt int) time.Duration {
 		delay = float64(p.MaxDelayMS)
 	}
 
+	if p.FullJitter {
+		//nolint:gosec // jitter doesn't need cryptographic randomness
+		return rand.N(time.Duration(max(delay, 0))*time.Millisecond + 1)
+	}
+
 	// Add jitter
 	if p.Jitter > 0 {
 		jitterAmount := delay * p.Jitter
@@ -23,6 +23,15 @@ const (
 	// FeatureExecutionAborted marks user-initiated cancellation.
 	FeatureExecutionAborted EventType = "feature.execution.aborted"
 
+	// FeatureNoOp marks an execution that completed without producing any changes.
+	FeatureNoOp EventType = "feature.execution.noop"
+
+	// KillSwitchActivated marks a kill switch stopping executions in its scope.
+	KillSwitchActivated EventType = "feature.kill_switch.activated"
+
+	// KillSwitchDeactivated marks a kill switch lifted.
+	KillSwitchDeactivated EventType = "feature.kill_switch.deactivated"
+
 	// === REPOSITORY EVENTS ===
 
 	// RepositoryCheckoutStarted indicates clone/fetch beginning.
@@ -96,6 +105,15 @@ const (
 	// PatchGenerationCompleted indicates all patches generated.
 	PatchGenerationCompleted EventType = "patch.generation.completed"
 
+	// PatchApplicationResult summarizes which patches applied and which failed.
+	PatchApplicationResult EventType = "patch.application.result"
+
+	// PatchPreviewReady indicates generated patches await approval before being applied.
+	PatchPreviewReady EventType = "patch.preview.ready"
+
+	// PatchPreviewDecided records the approval or rejection of a patch preview.
+	PatchPreviewDecided EventType = "patch.preview.decided"
+
 	// === TEST EVENTS ===
 
 	// TestGenerationStarted indicates test generation beginning.
@@ -113,6 +131,9 @@ const (
 	// TestExecutionCompleted indicates test run finished.
 	TestExecutionCompleted EventType = "test.execution.completed"
 
+	// TestExecutionProgress reports the tests completed so far in a running test execution.
+	TestExecutionProgress EventType = "test.execution.progress"
+
 	// TestExecutionFailed indicates test execution infrastructure failure.
 	TestExecutionFailed EventType = "test.execution.failed"
 
@@ -138,6 +159,9 @@ const (
 	// ReviewFailed indicates review analysis failed.
 	ReviewFailed EventType = "review.analysis.failed"
 
+	// ReviewManualRequested indicates a change awaits human review by its required reviewers.
+	ReviewManualRequested EventType = "review.manual.requested"
+
 	// SecurityScanStarted indicates security scan beginning.
 	SecurityScanStarted EventType = "review.security.started"
 
@@ -183,6 +207,12 @@ const (
 	// GitPushFailed indicates push failed.
 	GitPushFailed EventType = "git.push.failed"
 
+	// GitMergeConflictDetected indicates the feature branch conflicts with the advanced base branch.
+	GitMergeConflictDetected EventType = "git.merge_conflict.detected"
+
+	// DeliveryConflictDetected indicates a push was blocked by a conflict with the base branch.
+	DeliveryConflictDetected EventType = "git.delivery_conflict.detected"
+
 	// === RESOURCE EVENTS ===
 
 	// ResourcesAcquired indicates locks/credentials obtained.
@@ -256,7 +286,7 @@ func (t EventType) IsFailureEvent() bool {
 // IsTerminalEvent returns true if this event type ends execution.
 func (t EventType) IsTerminalEvent() bool {
 	switch t {
-	case FeatureDelivered, FeatureExecutionFailed, FeatureExecutionAborted:
+	case FeatureDelivered, FeatureExecutionFailed, FeatureExecutionAborted, FeatureNoOp:
 		return true
 	default:
 		return false
@@ -329,6 +359,9 @@ func AllEventTypes() []EventType {
 		FeatureDelivered,
 		FeatureExecutionFailed,
 		FeatureExecutionAborted,
+		FeatureNoOp,
+		KillSwitchActivated,
+		KillSwitchDeactivated,
 		// Repository
 		RepositoryCheckoutStarted,
 		RepositoryCheckoutCompleted,
@@ -355,12 +388,16 @@ func AllEventTypes() []EventType {
 		PatchGenerationStepCompleted,
 		PatchGenerationStepFailed,
 		PatchGenerationCompleted,
+		PatchApplicationResult,
+		PatchPreviewReady,
+		PatchPreviewDecided,
 		// Test
 		TestGenerationStarted,
 		TestGenerationCompleted,
 		TestGenerationFailed,
 		TestExecutionStarted,
 		TestExecutionCompleted,
+		TestExecutionProgress,
 		TestExecutionFailed,
 		// Build
 		BuildStarted,
@@ -370,6 +407,7 @@ func AllEventTypes() []EventType {
 		ReviewStarted,
 		ReviewCompleted,
 		ReviewFailed,
+		ReviewManualRequested,
 		SecurityScanStarted,
 		SecurityScanCompleted,
 		// Iteration
@@ -386,6 +424,8 @@ func AllEventTypes() []EventType {
 		GitPushStarted,
 		GitPushCompleted,
 		GitPushFailed,
+		GitMergeConflictDetected,
+		DeliveryConflictDetected,
 		// Resources
 		ResourcesAcquired,
 		ResourcesReleased,
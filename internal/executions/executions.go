@@ -0,0 +1,547 @@
+// Package executions persists feature executions, shared by the services that
+// create, track and report on them.
+package executions
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pitabwire/frame/datastore/pool"
+	"github.com/pitabwire/util"
+	"gorm.io/gorm"
+)
+
+// ErrExecutionNotFound is returned when no execution has the requested ID.
+var ErrExecutionNotFound = errors.New("execution not found")
+
+// ErrVersionConflict is returned when an execution was modified since it was read.
+// Callers should re-read the execution and reapply their change.
+var ErrVersionConflict = errors.New("execution version conflict")
+
+// ErrSpecHashInFlight is returned when an execution is created with the spec hash
+// of another pending or running execution. Callers should attach to that execution.
+var ErrSpecHashInFlight = errors.New("an execution with the same spec hash is in flight")
+
+// inFlightSpecHashIndex is the unique index allowing one in-flight execution per spec hash.
+const inFlightSpecHashIndex = "idx_executions_spec_hash_in_flight"
+
+// uniqueViolation is the SQLSTATE of a unique constraint violation.
+const uniqueViolation = "23505"
+
+// ExecutionStatus represents the status of an execution.
+type ExecutionStatus string
+
+const (
+	ExecutionStatusPending   ExecutionStatus = "pending"
+	ExecutionStatusRunning   ExecutionStatus = "running"
+	ExecutionStatusCompleted ExecutionStatus = "completed"
+	ExecutionStatusFailed    ExecutionStatus = "failed"
+	ExecutionStatusAborted   ExecutionStatus = "aborted"
+)
+
+// Execution represents a feature execution record.
+type Execution struct {
+	ID             string          `json:"id"                      gorm:"primaryKey"`
+	RepositoryURL  string          `json:"repository_url"`
+	Branch         string          `json:"branch"`
+	Title          string          `json:"title"`
+	Description    string          `json:"description"`
+	Status         ExecutionStatus `json:"status"`
+	RequestedBy    string          `json:"requested_by"`
+	RequestedAt    time.Time       `json:"requested_at"`
+	StartedAt      *time.Time      `json:"started_at,omitempty"`
+	CompletedAt    *time.Time      `json:"completed_at,omitempty"`
+	ErrorMessage   string          `json:"error_message,omitempty"`
+	Phase          string          `json:"phase,omitempty"`          // Phase of the latest event
+	LastEvent      string          `json:"last_event,omitempty"`     // Name of the latest event
+	FeatureBranch  string          `json:"feature_branch,omitempty"` // Branch the feature is delivered on
+	IterationCount int             `json:"iteration_count"`
+	RetryAttempts  int             `json:"retry_attempts"`
+	InitialRequest json.RawMessage `json:"initial_request,omitempty"`                         // Replayed on execution retry
+	SpecHash       string          `json:"spec_hash,omitempty"     gorm:"index"`              // Request deduplication key
+	Requesters     []string        `json:"requesters,omitempty"    gorm:"serializer:json"`    // Duplicate requesters
+	LLMModels      []LLMModel      `json:"llm_models,omitempty"    gorm:"serializer:json"`    // Serving LLM models
+	Version        int64           `json:"version"                 gorm:"not null;default:0"` // Optimistic locking version
+	CreatedAt      time.Time       `json:"created_at"`
+	UpdatedAt      time.Time       `json:"updated_at"`
+}
+
+// LLMModel identifies an LLM that served an execution, down to the exact model
+// version the provider reported.
+type LLMModel struct {
+	Provider string `json:"provider"`
+	Model    string `json:"model"`
+	Version  string `json:"version,omitempty"`
+}
+
+// RecordLLMModel adds a model to those that served the execution, reporting
+// whether it was not already recorded.
+func (e *Execution) RecordLLMModel(model LLMModel) bool {
+	if slices.Contains(e.LLMModels, model) {
+		return false
+	}
+	e.LLMModels = append(e.LLMModels, model)
+	return true
+}
+
+// TableName returns the table name for the Execution model.
+func (Execution) TableName() string {
+	return "executions"
+}
+
+// ExecutionRepository defines the interface for execution persistence.
+type ExecutionRepository interface {
+	Create(ctx context.Context, execution *Execution) error
+	GetByID(ctx context.Context, id string) (*Execution, error)
+	UpdateStatus(ctx context.Context, id string, status ExecutionStatus, errorMsg string) error
+	// IncrementIteration atomically advances the iteration count, returning the new count.
+	IncrementIteration(ctx context.Context, id string) (int, error)
+	// AdvanceIteration raises the iteration count to iteration when it is lower,
+	// returning the resulting count, so starting an iteration again leaves it as is.
+	AdvanceIteration(ctx context.Context, id string, iteration int) (int, error)
+	// GetIterationCount returns the number of iterations the execution has started.
+	GetIterationCount(ctx context.Context, id string) (int, error)
+	// Update saves execution if it is still at the version it was read at,
+	// returning ErrVersionConflict otherwise. On success execution.Version is advanced.
+	Update(ctx context.Context, execution *Execution) error
+	// FindActiveBySpecHash returns the pending or running execution created for
+	// specHash, or nil when there is none.
+	FindActiveBySpecHash(ctx context.Context, specHash string) (*Execution, error)
+	// ListActive returns the pending and running executions, oldest first.
+	ListActive(ctx context.Context) ([]*Execution, error)
+}
+
+// UpdateExecution reads an execution, applies mutate and saves it, re-reading and
+// reapplying mutate when a concurrent writer got there first. It gives up with
+// ErrVersionConflict after maxAttempts conflicting writes.
+func UpdateExecution(
+	ctx context.Context,
+	repo ExecutionRepository,
+	id string,
+	maxAttempts int,
+	mutate func(*Execution) error,
+) (*Execution, error) {
+	for range max(maxAttempts, 1) {
+		execution, err := repo.GetByID(ctx, id)
+		if err != nil {
+			return nil, fmt.Errorf("read execution: %w", err)
+		}
+		if err = mutate(execution); err != nil {
+			return nil, err
+		}
+
+		err = repo.Update(ctx, execution)
+		if err == nil {
+			return execution, nil
+		}
+		if !errors.Is(err, ErrVersionConflict) {
+			return nil, fmt.Errorf("update execution: %w", err)
+		}
+		util.Log(ctx).Debug("execution modified concurrently, retrying update",
+			"execution_id", id,
+			"version", execution.Version,
+		)
+	}
+	return nil, fmt.Errorf("update execution %s after %d attempts: %w", id, max(maxAttempts, 1), ErrVersionConflict)
+}
+
+// PGExecutionRepository is the PostgreSQL implementation of ExecutionRepository.
+type PGExecutionRepository struct {
+	pool pool.Pool
+}
+
+// NewExecutionRepository creates a new execution repository.
+func NewExecutionRepository(_ context.Context, pool pool.Pool) ExecutionRepository {
+	return &PGExecutionRepository{
+		pool: pool,
+	}
+}
+
+func (r *PGExecutionRepository) db(ctx context.Context, readOnly bool) *gorm.DB {
+	if r.pool == nil {
+		return nil
+	}
+	return r.pool.DB(ctx, readOnly)
+}
+
+// Create creates a new execution record.
+func (r *PGExecutionRepository) Create(ctx context.Context, execution *Execution) error {
+	db := r.db(ctx, false)
+	if db == nil {
+		return nil // No database, stub mode
+	}
+
+	execution.CreatedAt = time.Now()
+	execution.UpdatedAt = time.Now()
+	err := db.Create(execution).Error
+	if isInFlightSpecHashConflict(err) {
+		return fmt.Errorf("%w: %s", ErrSpecHashInFlight, execution.SpecHash)
+	}
+	return err
+}
+
+// isInFlightSpecHashConflict reports whether err is a violation of the index
+// allowing one in-flight execution per spec hash.
+func isInFlightSpecHashConflict(err error) bool {
+	var stateErr interface{ SQLState() string }
+	return errors.As(err, &stateErr) && stateErr.SQLState() == uniqueViolation &&
+		strings.Contains(err.Error(), inFlightSpecHashIndex)
+}
+
+// GetByID retrieves an execution by ID.
+func (r *PGExecutionRepository) GetByID(ctx context.Context, id string) (*Execution, error) {
+	db := r.db(ctx, true)
+	if db == nil {
+		return nil, fmt.Errorf("execution not found: %s", id)
+	}
+
+	var exec Execution
+	if err := db.First(&exec, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("%w: %s", ErrExecutionNotFound, id)
+		}
+		return nil, err
+	}
+	return &exec, nil
+}
+
+// UpdateStatus updates the execution status.
+func (r *PGExecutionRepository) UpdateStatus(
+	ctx context.Context,
+	id string,
+	status ExecutionStatus,
+	errorMsg string,
+) error {
+	db := r.db(ctx, false)
+	if db == nil {
+		return nil
+	}
+
+	updates := map[string]interface{}{
+		"status":        status,
+		"error_message": errorMsg,
+		"updated_at":    time.Now(),
+		"version":       gorm.Expr("version + 1"),
+	}
+
+	now := time.Now()
+	if status == ExecutionStatusRunning {
+		updates["started_at"] = &now
+	}
+	if status == ExecutionStatusCompleted || status == ExecutionStatusFailed ||
+		status == ExecutionStatusAborted {
+		updates["completed_at"] = &now
+	}
+
+	return db.Model(&Execution{}).Where("id = ?", id).Updates(updates).Error
+}
+
+// IncrementIteration increments the iteration count and returns the new count.
+func (r *PGExecutionRepository) IncrementIteration(ctx context.Context, id string) (int, error) {
+	db := r.db(ctx, false)
+	if db == nil {
+		return 0, nil
+	}
+
+	var counts []int
+	result := db.Raw(`UPDATE executions
+		SET iteration_count = iteration_count + 1, version = version + 1, updated_at = ?
+		WHERE id = ?
+		RETURNING iteration_count`, time.Now(), id).Scan(&counts)
+	if result.Error != nil {
+		return 0, result.Error
+	}
+	if len(counts) == 0 {
+		return 0, fmt.Errorf("%w: %s", ErrExecutionNotFound, id)
+	}
+	return counts[0], nil
+}
+
+// AdvanceIteration raises the iteration count to iteration and returns the count.
+func (r *PGExecutionRepository) AdvanceIteration(ctx context.Context, id string, iteration int) (int, error) {
+	db := r.db(ctx, false)
+	if db == nil {
+		return 0, nil
+	}
+
+	var counts []int
+	result := db.Raw(`UPDATE executions
+		SET iteration_count = ?, version = version + 1, updated_at = ?
+		WHERE id = ? AND iteration_count < ?
+		RETURNING iteration_count`, iteration, time.Now(), id, iteration).Scan(&counts)
+	if result.Error != nil {
+		return 0, result.Error
+	}
+	if len(counts) == 0 {
+		// Already at or past the iteration, or not found
+		return r.GetIterationCount(ctx, id)
+	}
+	return counts[0], nil
+}
+
+// GetIterationCount returns the iteration count.
+func (r *PGExecutionRepository) GetIterationCount(ctx context.Context, id string) (int, error) {
+	db := r.db(ctx, true)
+	if db == nil {
+		return 0, nil
+	}
+
+	var counts []int
+	if err := db.Model(&Execution{}).Where("id = ?", id).Pluck("iteration_count", &counts).Error; err != nil {
+		return 0, err
+	}
+	if len(counts) == 0 {
+		return 0, fmt.Errorf("%w: %s", ErrExecutionNotFound, id)
+	}
+	return counts[0], nil
+}
+
+// Update saves the mutable execution state if the stored version still matches.
+func (r *PGExecutionRepository) Update(ctx context.Context, execution *Execution) error {
+	db := r.db(ctx, false)
+	if db == nil {
+		return nil
+	}
+
+	// Map updates bypass the field serializer, so JSON columns are encoded here
+	requesters, err := json.Marshal(execution.Requesters)
+	if err != nil {
+		return fmt.Errorf("encode requesters: %w", err)
+	}
+	llmModels, err := json.Marshal(execution.LLMModels)
+	if err != nil {
+		return fmt.Errorf("encode llm models: %w", err)
+	}
+
+	now := time.Now()
+	result := db.Model(&Execution{}).
+		Where("id = ? AND version = ?", execution.ID, execution.Version).
+		UpdateColumns(map[string]interface{}{
+			"status":          execution.Status,
+			"error_message":   execution.ErrorMessage,
+			"started_at":      execution.StartedAt,
+			"completed_at":    execution.CompletedAt,
+			"iteration_count": execution.IterationCount,
+			"retry_attempts":  execution.RetryAttempts,
+			"phase":           execution.Phase,
+			"last_event":      execution.LastEvent,
+			"feature_branch":  execution.FeatureBranch,
+			"requesters":      string(requesters),
+			"llm_models":      string(llmModels),
+			"version":         execution.Version + 1,
+			"updated_at":      now,
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("%w: execution %s is no longer at version %d",
+			ErrVersionConflict, execution.ID, execution.Version)
+	}
+
+	execution.Version++
+	execution.UpdatedAt = now
+	return nil
+}
+
+// FindActiveBySpecHash returns the oldest in-flight execution with the given spec hash.
+func (r *PGExecutionRepository) FindActiveBySpecHash(ctx context.Context, specHash string) (*Execution, error) {
+	db := r.db(ctx, true)
+	if db == nil {
+		return nil, nil //nolint:nilnil // No database, stub mode
+	}
+
+	var executions []Execution
+	err := db.Where("spec_hash = ? AND status IN ?", specHash,
+		[]ExecutionStatus{ExecutionStatusPending, ExecutionStatusRunning}).
+		Order("created_at").Limit(1).Find(&executions).Error
+	if err != nil {
+		return nil, err
+	}
+	if len(executions) == 0 {
+		return nil, nil //nolint:nilnil // No in-flight execution
+	}
+	return &executions[0], nil
+}
+
+// ListActive returns the pending and running executions, oldest first.
+func (r *PGExecutionRepository) ListActive(ctx context.Context) ([]*Execution, error) {
+	db := r.db(ctx, true)
+	if db == nil {
+		return nil, nil // No database, stub mode
+	}
+
+	var executions []*Execution
+	err := db.Where("status IN ?", []ExecutionStatus{ExecutionStatusPending, ExecutionStatusRunning}).
+		Order("created_at").Find(&executions).Error
+	if err != nil {
+		return nil, err
+	}
+	return executions, nil
+}
+
+// MemoryExecutionRepository is an in-memory execution repository for testing.
+type MemoryExecutionRepository struct {
+	mu         sync.Mutex
+	executions map[string]*Execution
+}
+
+// NewMemoryExecutionRepository creates an empty in-memory execution repository.
+func NewMemoryExecutionRepository() *MemoryExecutionRepository {
+	return &MemoryExecutionRepository{executions: make(map[string]*Execution)}
+}
+
+// Create creates a new execution record.
+func (r *MemoryExecutionRepository) Create(_ context.Context, execution *Execution) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if execution.SpecHash != "" && r.inFlight(execution.SpecHash) != nil {
+		return fmt.Errorf("%w: %s", ErrSpecHashInFlight, execution.SpecHash)
+	}
+	execution.CreatedAt = time.Now()
+	execution.UpdatedAt = execution.CreatedAt
+	stored := *execution
+	r.executions[execution.ID] = &stored
+	return nil
+}
+
+// GetByID returns a copy of the stored execution.
+func (r *MemoryExecutionRepository) GetByID(_ context.Context, id string) (*Execution, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stored, ok := r.executions[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrExecutionNotFound, id)
+	}
+	execution := *stored
+	return &execution, nil
+}
+
+// UpdateStatus updates the execution status.
+func (r *MemoryExecutionRepository) UpdateStatus(
+	_ context.Context,
+	id string,
+	status ExecutionStatus,
+	errorMsg string,
+) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if stored, ok := r.executions[id]; ok {
+		stored.Status = status
+		stored.ErrorMessage = errorMsg
+		stored.Version++
+		stored.UpdatedAt = time.Now()
+	}
+	return nil
+}
+
+// IncrementIteration increments the iteration count and returns the new count.
+func (r *MemoryExecutionRepository) IncrementIteration(_ context.Context, id string) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stored, ok := r.executions[id]
+	if !ok {
+		return 0, fmt.Errorf("%w: %s", ErrExecutionNotFound, id)
+	}
+	stored.IterationCount++
+	stored.Version++
+	stored.UpdatedAt = time.Now()
+	return stored.IterationCount, nil
+}
+
+// AdvanceIteration raises the iteration count to iteration and returns the count.
+func (r *MemoryExecutionRepository) AdvanceIteration(_ context.Context, id string, iteration int) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stored, ok := r.executions[id]
+	if !ok {
+		return 0, fmt.Errorf("%w: %s", ErrExecutionNotFound, id)
+	}
+	if stored.IterationCount < iteration {
+		stored.IterationCount = iteration
+		stored.Version++
+		stored.UpdatedAt = time.Now()
+	}
+	return stored.IterationCount, nil
+}
+
+// GetIterationCount returns the iteration count.
+func (r *MemoryExecutionRepository) GetIterationCount(_ context.Context, id string) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stored, ok := r.executions[id]
+	if !ok {
+		return 0, fmt.Errorf("%w: %s", ErrExecutionNotFound, id)
+	}
+	return stored.IterationCount, nil
+}
+
+// Update saves execution if the stored version still matches.
+func (r *MemoryExecutionRepository) Update(_ context.Context, execution *Execution) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stored, ok := r.executions[execution.ID]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrExecutionNotFound, execution.ID)
+	}
+	if stored.Version != execution.Version {
+		return fmt.Errorf("%w: execution %s is no longer at version %d",
+			ErrVersionConflict, execution.ID, execution.Version)
+	}
+
+	execution.Version++
+	execution.UpdatedAt = time.Now()
+	saved := *execution
+	r.executions[execution.ID] = &saved
+	return nil
+}
+
+// FindActiveBySpecHash returns the oldest in-flight execution with the given spec hash.
+func (r *MemoryExecutionRepository) FindActiveBySpecHash(_ context.Context, specHash string) (*Execution, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	oldest := r.inFlight(specHash)
+	if oldest == nil {
+		return nil, nil //nolint:nilnil // No in-flight execution
+	}
+	execution := *oldest
+	return &execution, nil
+}
+
+// inFlight returns the oldest in-flight execution with the given spec hash, or
+// nil. Callers hold mu.
+func (r *MemoryExecutionRepository) inFlight(specHash string) *Execution {
+	var oldest *Execution
+	for _, stored := range r.executions {
+		if stored.SpecHash != specHash ||
+			(stored.Status != ExecutionStatusPending && stored.Status != ExecutionStatusRunning) {
+			continue
+		}
+		if oldest == nil || stored.CreatedAt.Before(oldest.CreatedAt) {
+			oldest = stored
+		}
+	}
+	return oldest
+}
+
+// ListActive returns the pending and running executions, oldest first.
+func (r *MemoryExecutionRepository) ListActive(_ context.Context) ([]*Execution, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var active []*Execution
+	for _, stored := range r.executions {
+		if stored.Status != ExecutionStatusPending && stored.Status != ExecutionStatusRunning {
+			continue
+		}
+		execution := *stored
+		active = append(active, &execution)
+	}
+	slices.SortFunc(active, func(a, b *Execution) int { return a.CreatedAt.Compare(b.CreatedAt) })
+	return active, nil
+}
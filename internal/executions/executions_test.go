@@ -0,0 +1,234 @@
+package executions_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/antinvestor/builder/internal/executions"
+)
+
+func newStoredExecution(t *testing.T, repo executions.ExecutionRepository) string {
+	t.Helper()
+	execution := &executions.Execution{ID: "exec-1", Status: executions.ExecutionStatusPending}
+	require.NoError(t, repo.Create(context.Background(), execution))
+	return execution.ID
+}
+
+func TestMemoryExecutionRepository_StaleWriteConflicts(t *testing.T) {
+	ctx := context.Background()
+	repo := executions.NewMemoryExecutionRepository()
+	id := newStoredExecution(t, repo)
+
+	first, err := repo.GetByID(ctx, id)
+	require.NoError(t, err)
+	second, err := repo.GetByID(ctx, id)
+	require.NoError(t, err)
+
+	first.Status = executions.ExecutionStatusRunning
+	require.NoError(t, repo.Update(ctx, first))
+	assert.Equal(t, int64(1), first.Version)
+
+	second.IterationCount = 1
+	require.ErrorIs(t, repo.Update(ctx, second), executions.ErrVersionConflict)
+
+	stored, err := repo.GetByID(ctx, id)
+	require.NoError(t, err)
+	assert.Equal(t, executions.ExecutionStatusRunning, stored.Status)
+	assert.Equal(t, 0, stored.IterationCount)
+}
+
+func TestMemoryExecutionRepository_UnversionedUpdatesInvalidateReads(t *testing.T) {
+	ctx := context.Background()
+	repo := executions.NewMemoryExecutionRepository()
+	id := newStoredExecution(t, repo)
+
+	stale, err := repo.GetByID(ctx, id)
+	require.NoError(t, err)
+	_, err = repo.IncrementIteration(ctx, id)
+	require.NoError(t, err)
+
+	stale.Status = executions.ExecutionStatusFailed
+	require.ErrorIs(t, repo.Update(ctx, stale), executions.ErrVersionConflict)
+}
+
+// racingExecutionRepository lets another writer update the execution between the
+// first read and write, as a redelivered event handled concurrently would.
+type racingExecutionRepository struct {
+	*executions.MemoryExecutionRepository
+
+	once  sync.Once
+	race  func()
+	reads int
+}
+
+func (r *racingExecutionRepository) GetByID(ctx context.Context, id string) (*executions.Execution, error) {
+	execution, err := r.MemoryExecutionRepository.GetByID(ctx, id)
+	r.reads++
+	r.once.Do(r.race)
+	return execution, err
+}
+
+func TestUpdateExecution_RetriesOnVersionConflict(t *testing.T) {
+	ctx := context.Background()
+	memory := executions.NewMemoryExecutionRepository()
+	id := newStoredExecution(t, memory)
+
+	repo := &racingExecutionRepository{MemoryExecutionRepository: memory}
+	repo.race = func() {
+		_, err := executions.UpdateExecution(ctx, memory, id, 1, func(e *executions.Execution) error {
+			e.IterationCount++
+			return nil
+		})
+		require.NoError(t, err)
+	}
+
+	updated, err := executions.UpdateExecution(ctx, repo, id, 3, func(e *executions.Execution) error {
+		e.Status = executions.ExecutionStatusRunning
+		return nil
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, 2, repo.reads, "the stale write should be retried once")
+	assert.Equal(t, int64(2), updated.Version)
+
+	// Neither writer's change was lost
+	stored, err := memory.GetByID(ctx, id)
+	require.NoError(t, err)
+	assert.Equal(t, executions.ExecutionStatusRunning, stored.Status)
+	assert.Equal(t, 1, stored.IterationCount)
+}
+
+func TestUpdateExecution_GivesUpAfterMaxAttempts(t *testing.T) {
+	ctx := context.Background()
+	memory := executions.NewMemoryExecutionRepository()
+	id := newStoredExecution(t, memory)
+
+	_, err := executions.UpdateExecution(ctx, memory, id, 2, func(e *executions.Execution) error {
+		// Another writer always wins the race
+		_, incErr := memory.IncrementIteration(ctx, id)
+		require.NoError(t, incErr)
+		e.Status = executions.ExecutionStatusFailed
+		return nil
+	})
+	require.ErrorIs(t, err, executions.ErrVersionConflict)
+}
+
+func TestUpdateExecution_ConcurrentWritersLoseNoUpdates(t *testing.T) {
+	ctx := context.Background()
+	repo := executions.NewMemoryExecutionRepository()
+	id := newStoredExecution(t, repo)
+
+	const writers = 20
+	var wg sync.WaitGroup
+	for range writers {
+		wg.Go(func() {
+			_, err := executions.UpdateExecution(ctx, repo, id, writers, func(e *executions.Execution) error {
+				e.IterationCount++
+				return nil
+			})
+			assert.NoError(t, err)
+		})
+	}
+	wg.Wait()
+
+	stored, err := repo.GetByID(ctx, id)
+	require.NoError(t, err)
+	assert.Equal(t, writers, stored.IterationCount)
+	assert.Equal(t, int64(writers), stored.Version)
+}
+
+func TestExecution_RecordLLMModelOnce(t *testing.T) {
+	execution := &executions.Execution{}
+	model := executions.LLMModel{Provider: "openai", Model: "gpt-4o", Version: "gpt-4o-2024-08-06"}
+
+	assert.True(t, execution.RecordLLMModel(model))
+	assert.False(t, execution.RecordLLMModel(model))
+	assert.True(t, execution.RecordLLMModel(executions.LLMModel{Provider: "openai", Model: "gpt-4o"}))
+	assert.Len(t, execution.LLMModels, 2)
+}
+
+func TestMemoryExecutionRepository_IncrementIterationReturnsCount(t *testing.T) {
+	ctx := context.Background()
+	repo := executions.NewMemoryExecutionRepository()
+	id := newStoredExecution(t, repo)
+
+	for want := 1; want <= 3; want++ {
+		count, err := repo.IncrementIteration(ctx, id)
+		require.NoError(t, err)
+		assert.Equal(t, want, count)
+	}
+
+	count, err := repo.GetIterationCount(ctx, id)
+	require.NoError(t, err)
+	assert.Equal(t, 3, count)
+
+	_, err = repo.IncrementIteration(ctx, "missing")
+	require.ErrorIs(t, err, executions.ErrExecutionNotFound)
+	_, err = repo.GetIterationCount(ctx, "missing")
+	require.ErrorIs(t, err, executions.ErrExecutionNotFound)
+}
+
+func TestMemoryExecutionRepository_AdvanceIterationIsIdempotent(t *testing.T) {
+	ctx := context.Background()
+	repo := executions.NewMemoryExecutionRepository()
+	id := newStoredExecution(t, repo)
+
+	for _, iteration := range []int{1, 1, 2, 2, 1} {
+		_, err := repo.AdvanceIteration(ctx, id, iteration)
+		require.NoError(t, err)
+	}
+
+	count, err := repo.GetIterationCount(ctx, id)
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+
+	_, err = repo.AdvanceIteration(ctx, "missing", 1)
+	require.ErrorIs(t, err, executions.ErrExecutionNotFound)
+}
+
+func TestMemoryExecutionRepository_ListActive(t *testing.T) {
+	ctx := context.Background()
+	repo := executions.NewMemoryExecutionRepository()
+	for _, execution := range []*executions.Execution{
+		{ID: "exec-running", Status: executions.ExecutionStatusRunning},
+		{ID: "exec-done", Status: executions.ExecutionStatusCompleted},
+		{ID: "exec-pending", Status: executions.ExecutionStatusPending},
+		{ID: "exec-aborted", Status: executions.ExecutionStatusAborted},
+	} {
+		require.NoError(t, repo.Create(ctx, execution))
+	}
+
+	active, err := repo.ListActive(ctx)
+	require.NoError(t, err)
+
+	var ids []string
+	for _, execution := range active {
+		ids = append(ids, execution.ID)
+	}
+	assert.Equal(t, []string{"exec-running", "exec-pending"}, ids)
+}
+
+func TestMemoryExecutionRepository_OneInFlightExecutionPerSpecHash(t *testing.T) {
+	ctx := context.Background()
+	repo := executions.NewMemoryExecutionRepository()
+	require.NoError(t, repo.Create(ctx, &executions.Execution{
+		ID: "exec-1", Status: executions.ExecutionStatusPending, SpecHash: "hash",
+	}))
+
+	err := repo.Create(ctx, &executions.Execution{
+		ID: "exec-2", Status: executions.ExecutionStatusPending, SpecHash: "hash",
+	})
+	require.ErrorIs(t, err, executions.ErrSpecHashInFlight)
+
+	// Executions without a hash, or once the first has finished, are not constrained
+	require.NoError(t, repo.Create(ctx, &executions.Execution{ID: "exec-3", Status: executions.ExecutionStatusPending}))
+	require.NoError(t, repo.Create(ctx, &executions.Execution{ID: "exec-4", Status: executions.ExecutionStatusPending}))
+	require.NoError(t, repo.UpdateStatus(ctx, "exec-1", executions.ExecutionStatusCompleted, ""))
+	require.NoError(t, repo.Create(ctx, &executions.Execution{
+		ID: "exec-5", Status: executions.ExecutionStatusPending, SpecHash: "hash",
+	}))
+}
@@ -1,4 +1,5 @@
-package middleware
+// Package httpauth authenticates HTTP requests with frame's security manager.
+package httpauth
 
 import (
 	"context"
@@ -65,13 +66,49 @@ func (am *AuthMiddleware) Middleware(next http.Handler) http.Handler {
 	})
 }
 
+// RequireRole creates an HTTP middleware that validates authentication and then
+// admits only callers whose claims carry role.
+func (am *AuthMiddleware) RequireRole(role string, next http.Handler) http.Handler {
+	return am.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !HasRole(r.Context(), role) {
+			util.Log(r.Context()).Info("request denied, missing role",
+				"user_id", UserIDFromContext(r.Context()),
+				"role", role,
+				"path", r.URL.Path,
+			)
+			writeError(w, http.StatusForbidden, "forbidden", "The "+role+" role is required")
+			return
+		}
+		next.ServeHTTP(w, r)
+	}))
+}
+
+// HasRole reports whether the authenticated user of ctx has role.
+func HasRole(ctx context.Context, role string) bool {
+	claims := GetUserFromContext(ctx)
+	if claims == nil {
+		return false
+	}
+	for _, granted := range claims.GetRoles() {
+		if strings.EqualFold(strings.TrimSpace(granted), role) {
+			return true
+		}
+	}
+	return false
+}
+
 // unauthorized writes an unauthorized response.
 func (am *AuthMiddleware) unauthorized(w http.ResponseWriter, message string) {
-	w.Header().Set("Content-Type", "application/json")
 	w.Header().Set("WWW-Authenticate", `Bearer realm="feature-gateway"`)
-	w.WriteHeader(http.StatusUnauthorized)
+	writeError(w, http.StatusUnauthorized, "unauthorized", message)
+}
+
+// writeError writes a JSON error response.
+func writeError(w http.ResponseWriter, status int, code, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
 	response := map[string]string{
-		"error":   "unauthorized",
+		"error":   code,
 		"message": message,
 	}
 	_ = json.NewEncoder(w).Encode(response)
@@ -82,6 +119,12 @@ func GetUserFromContext(ctx context.Context) *security.AuthenticationClaims {
 	return security.ClaimsFromContext(ctx)
 }
 
+// UserIDFromContext returns the subject of the authenticated user, or an empty
+// string for an unauthenticated context.
+func UserIDFromContext(ctx context.Context) string {
+	return getUserIDFromClaims(ctx, GetUserFromContext(ctx))
+}
+
 // OptionalAuthMiddleware creates middleware that attempts authentication but doesn't require it.
 func (am *AuthMiddleware) OptionalAuthMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -1,5 +1,5 @@
 //nolint:testpackage // Tests require access to internal fields and mock authenticator
-package middleware
+package httpauth
 
 import (
 	"context"
@@ -300,3 +300,53 @@ func TestAuthMiddleware_UnauthorizedResponseFormat(t *testing.T) {
 	assert.Equal(t, "application/json", rr.Header().Get("Content-Type"))
 	assert.Contains(t, rr.Body.String(), `"error":"unauthorized"`)
 }
+
+func TestAuthMiddleware_RequireRole(t *testing.T) {
+	tests := []struct {
+		name     string
+		roles    []string
+		wantCode int
+	}{
+		{"granted role", []string{"viewer", "admin"}, http.StatusOK},
+		{"missing role", []string{"viewer"}, http.StatusForbidden},
+		{"no roles", nil, http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			auth := &mockAuthenticator{
+				authenticateFunc: func(
+					ctx context.Context,
+					_ string,
+					_ ...security.AuthOption,
+				) (context.Context, error) {
+					claims := &security.AuthenticationClaims{Roles: tt.roles}
+					claims.Subject = "user-1"
+					return claims.ClaimsToContext(ctx), nil
+				},
+			}
+			handler := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			})
+
+			req := httptest.NewRequest(http.MethodPost, "/test", nil)
+			req.Header.Set("Authorization", "Bearer valid-token")
+			rr := httptest.NewRecorder()
+			NewAuthMiddleware(auth).RequireRole("admin", handler).ServeHTTP(rr, req)
+
+			assert.Equal(t, tt.wantCode, rr.Code)
+		})
+	}
+}
+
+func TestAuthMiddleware_RequireRoleNeedsAuthentication(t *testing.T) {
+	handler := http.HandlerFunc(func(_ http.ResponseWriter, _ *http.Request) {
+		t.Error("handler should not be called")
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/test", nil)
+	rr := httptest.NewRecorder()
+	NewAuthMiddleware(&mockAuthenticator{}).RequireRole("admin", handler).ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+}
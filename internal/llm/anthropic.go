@@ -26,10 +26,9 @@ type AnthropicClient struct {
 func NewAnthropicClient(apiKey string, cfg ClientConfig) *AnthropicClient {
 	return &AnthropicClient{
 		apiKey: apiKey,
-		httpClient: &http.Client{
-			Timeout: time.Duration(cfg.TimeoutSeconds) * time.Second,
-		},
-		config: cfg,
+		// Timeouts are applied per request so they can vary by model
+		httpClient: &http.Client{},
+		config:     cfg,
 	}
 }
 
@@ -109,9 +108,14 @@ func (c *AnthropicClient) Complete(
 		model = string(ModelClaudeSonnet)
 	}
 
+	// Resolve per-provider/model limits for the selected model
+	timeoutSeconds, maxOutputTokens := c.config.ResolveLimits(ProviderAnthropic, Model(model))
+	ctx, cancel := withRequestTimeout(ctx, timeoutSeconds)
+	defer cancel()
+
 	maxTokens := req.MaxTokens
 	if maxTokens == 0 {
-		maxTokens = c.config.MaxOutputTokens
+		maxTokens = maxOutputTokens
 	}
 
 	anthropicReq := anthropicRequest{
@@ -199,6 +203,10 @@ func (c *AnthropicClient) Complete(
 		RequestID:  anthropicResp.ID,
 		LatencyMS:  latencyMS,
 		CacheHit:   anthropicResp.Usage.CacheReadInputTokens > 0,
+
+		Provider:     ProviderAnthropic,
+		Model:        Model(model),
+		ModelVersion: anthropicResp.Model,
 	}, nil
 }
 
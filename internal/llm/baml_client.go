@@ -53,6 +53,15 @@ type GeneratePatchResponse struct {
 	Patches       []Patch
 	CommitMessage string
 	TokensUsed    int
+
+	// Provider, Model and ModelVersion identify the model that generated the code.
+	Provider     Provider
+	Model        Model
+	ModelVersion string
+
+	// Invocations are the LLM calls made for the generation, in order, so their
+	// usage can be attributed to the model that served each.
+	Invocations []*InvocationResult
 }
 
 // Patch represents a code patch.
@@ -85,7 +94,8 @@ func (c *BAMLClient) GeneratePatch(
 
 	// Step 2: Normalize specification
 	log.Debug("normalizing specification")
-	normalizedSpec, _, err := c.client.NormalizeSpec(ctx, NormalizeSpecInput{
+	var invocations []*InvocationResult
+	normalizedSpec, invocation, err := c.client.NormalizeSpec(ctx, NormalizeSpecInput{
 		Spec:            req.Specification,
 		CodebaseContext: codebaseContext,
 		Language:        language,
@@ -93,6 +103,7 @@ func (c *BAMLClient) GeneratePatch(
 	if err != nil {
 		return nil, fmt.Errorf("normalize specification: %w", err)
 	}
+	invocations = appendInvocation(invocations, invocation)
 	log.Debug("specification normalized",
 		"complexity", normalizedSpec.Complexity.Level,
 		"components", len(normalizedSpec.Components),
@@ -104,7 +115,7 @@ func (c *BAMLClient) GeneratePatch(
 	// Step 4: Analyze impact
 	log.Debug("analyzing impact")
 	projectStructure := c.getProjectStructure(req.WorkspacePath)
-	impactAnalysis, _, err := c.client.AnalyzeImpact(ctx, AnalyzeImpactInput{
+	impactAnalysis, invocation, err := c.client.AnalyzeImpact(ctx, AnalyzeImpactInput{
 		NormalizedSpec:   *normalizedSpec,
 		FileContents:     fileContents,
 		ProjectStructure: projectStructure,
@@ -112,6 +123,7 @@ func (c *BAMLClient) GeneratePatch(
 	if err != nil {
 		return nil, fmt.Errorf("analyze impact: %w", err)
 	}
+	invocations = appendInvocation(invocations, invocation)
 	log.Debug("impact analyzed",
 		"direct_impacts", len(impactAnalysis.DirectImpacts),
 		"indirect_impacts", len(impactAnalysis.IndirectImpacts),
@@ -120,7 +132,7 @@ func (c *BAMLClient) GeneratePatch(
 	// Step 5: Generate implementation plan
 	log.Debug("generating implementation plan")
 	projectInfo := c.getProjectInfo(req.WorkspacePath, language)
-	plan, _, err := c.client.GeneratePlan(ctx, GeneratePlanInput{
+	plan, invocation, err := c.client.GeneratePlan(ctx, GeneratePlanInput{
 		NormalizedSpec: *normalizedSpec,
 		ImpactAnalysis: *impactAnalysis,
 		FileContents:   fileContents,
@@ -129,6 +141,7 @@ func (c *BAMLClient) GeneratePatch(
 	if err != nil {
 		return nil, fmt.Errorf("generate plan: %w", err)
 	}
+	invocations = appendInvocation(invocations, invocation)
 	log.Info("plan generated",
 		"title", plan.Title,
 		"steps", len(plan.Steps),
@@ -138,6 +151,7 @@ func (c *BAMLClient) GeneratePatch(
 	// Step 6: Execute plan steps and generate code
 	var patches []Patch
 	var commitMessages []string
+	var served *InvocationResult
 
 	for _, step := range plan.Steps {
 		log.Debug("executing plan step",
@@ -149,7 +163,7 @@ func (c *BAMLClient) GeneratePatch(
 		stepFiles := c.readFilesForStep(ctx, req.WorkspacePath, step)
 
 		// Generate code for this step
-		codeResult, _, genErr := c.client.GenerateCode(ctx, GenerateCodeInput{
+		codeResult, stepInvocation, genErr := c.client.GenerateCode(ctx, GenerateCodeInput{
 			Step:         step,
 			FileContents: stepFiles,
 			Language:     language,
@@ -167,6 +181,8 @@ func (c *BAMLClient) GeneratePatch(
 			)
 			continue
 		}
+		served = stepInvocation
+		invocations = appendInvocation(invocations, stepInvocation)
 
 		// Convert file changes to patches
 		for _, change := range codeResult.FileChanges {
@@ -201,10 +217,79 @@ func (c *BAMLClient) GeneratePatch(
 		"total_tokens", usage.TotalTokens,
 	)
 
-	return &GeneratePatchResponse{
+	resp := &GeneratePatchResponse{
 		Patches:       patches,
 		CommitMessage: commitMessage,
 		TokensUsed:    usage.TotalTokens,
+		Invocations:   invocations,
+	}
+	if served != nil {
+		resp.Provider, resp.Model, resp.ModelVersion = served.Provider, served.Model, served.ModelVersion
+	}
+	return resp, nil
+}
+
+// appendInvocation adds an invocation to invocations, skipping a missing one.
+func appendInvocation(invocations []*InvocationResult, invocation *InvocationResult) []*InvocationResult {
+	if invocation == nil {
+		return invocations
+	}
+	return append(invocations, invocation)
+}
+
+// SelfCheckAcceptanceRequest is the request for an acceptance-criteria self-check.
+type SelfCheckAcceptanceRequest struct {
+	ExecutionID   string
+	Specification FeatureSpecification
+	Patches       []Patch
+}
+
+// SelfCheckAcceptanceResponse is the response from an acceptance-criteria self-check.
+type SelfCheckAcceptanceResponse struct {
+	Criteria   []CriterionAssessment
+	TokensUsed int
+
+	// Provider, Model and ModelVersion identify the model that made the assessment.
+	Provider     Provider
+	Model        Model
+	ModelVersion string
+}
+
+// SelfCheckAcceptance asks the model whether the generated patches satisfy each
+// acceptance criterion of the specification.
+func (c *BAMLClient) SelfCheckAcceptance(
+	ctx context.Context,
+	req *SelfCheckAcceptanceRequest,
+) (*SelfCheckAcceptanceResponse, error) {
+	input := SelfCheckAcceptanceInput{
+		FeatureTitle:       req.Specification.Title,
+		FeatureDescription: req.Specification.Description,
+		AcceptanceCriteria: req.Specification.AcceptanceCriteria,
+	}
+	for _, patch := range req.Patches {
+		input.Changes = append(input.Changes, ChangedFile{
+			Path:    patch.FilePath,
+			Action:  patch.Action,
+			Content: truncateContent(patch.NewContent, maxFileContentLength),
+		})
+	}
+
+	result, invocation, err := c.client.SelfCheckAcceptance(ctx, input)
+	if err != nil {
+		return nil, fmt.Errorf("self-check acceptance: %w", err)
+	}
+
+	util.Log(ctx).Debug("acceptance self-check completed",
+		"execution_id", req.ExecutionID,
+		"criteria", len(result.Criteria),
+	)
+
+	return &SelfCheckAcceptanceResponse{
+		Criteria:     result.Criteria,
+		TokensUsed:   invocation.Usage.TotalTokens,
+		Provider:     invocation.Provider,
+		Model:        invocation.Model,
+		ModelVersion: invocation.ModelVersion,
 	}, nil
 }
 
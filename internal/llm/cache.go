@@ -0,0 +1,100 @@
+package llm
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sync"
+	"time"
+)
+
+// ResponseCache stores completion responses keyed by a content hash of the request.
+type ResponseCache interface {
+	// Get returns the cached response for the key, if present and not expired.
+	Get(key string) (*CompletionResponse, bool)
+
+	// Set stores a response under the key.
+	Set(key string, resp *CompletionResponse)
+}
+
+// cacheEntry is a cached response with its expiry time.
+type cacheEntry struct {
+	response  *CompletionResponse
+	expiresAt time.Time
+}
+
+// MemoryResponseCache is an in-memory ResponseCache with a fixed TTL.
+type MemoryResponseCache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	entries map[string]cacheEntry
+	now     func() time.Time
+}
+
+// NewMemoryResponseCache creates an in-memory response cache with the given TTL.
+func NewMemoryResponseCache(ttl time.Duration) *MemoryResponseCache {
+	return &MemoryResponseCache{
+		ttl:     ttl,
+		entries: make(map[string]cacheEntry),
+		now:     time.Now,
+	}
+}
+
+// Get implements ResponseCache.
+func (c *MemoryResponseCache) Get(key string) (*CompletionResponse, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+
+	if !c.now().Before(entry.expiresAt) {
+		delete(c.entries, key)
+		return nil, false
+	}
+
+	resp := *entry.response
+	return &resp, true
+}
+
+// Set implements ResponseCache.
+func (c *MemoryResponseCache) Set(key string, resp *CompletionResponse) {
+	if resp == nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// Drop expired entries so the cache does not grow without bound.
+	now := c.now()
+	for k, entry := range c.entries {
+		if !now.Before(entry.expiresAt) {
+			delete(c.entries, k)
+		}
+	}
+
+	stored := *resp
+	c.entries[key] = cacheEntry{
+		response:  &stored,
+		expiresAt: now.Add(c.ttl),
+	}
+}
+
+// Len returns the number of entries currently held, including expired ones not yet evicted.
+func (c *MemoryResponseCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.entries)
+}
+
+// CacheKey returns the content-addressed cache key for a completion request.
+// Every field that influences the model output is part of the hash.
+func CacheKey(req *CompletionRequest) string {
+	// CompletionRequest contains only plain fields, so marshalling cannot fail.
+	data, _ := json.Marshal(req) //nolint:errchkjson // plain struct, marshal cannot fail
+	hash := sha256.Sum256(data)
+	return hex.EncodeToString(hash[:])
+}
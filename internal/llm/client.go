@@ -2,7 +2,6 @@ package llm
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
 	"fmt"
 	"time"
@@ -47,6 +46,12 @@ type Client interface {
 		input GenerateCodeInput,
 	) (*CodeGenerationResult, *InvocationResult, error)
 
+	// SelfCheckAcceptance assesses generated changes against the acceptance criteria.
+	SelfCheckAcceptance(
+		ctx context.Context,
+		input SelfCheckAcceptanceInput,
+	) (*AcceptanceSelfCheckResult, *InvocationResult, error)
+
 	// GetUsage returns cumulative usage statistics.
 	GetUsage() Usage
 }
@@ -68,7 +73,7 @@ type CompletionRequest struct {
 	Model          Model
 	SystemPrompt   string
 	UserPrompt     string
-	MaxTokens      int
+	MaxTokens      int // 0 = resolved from the provider/model limits
 	Temperature    float64
 	ResponseFormat string // "json" or "text"
 	Function       Function
@@ -83,6 +88,12 @@ type CompletionResponse struct {
 	RequestID  string
 	LatencyMS  int64
 	CacheHit   bool
+
+	// Provider and Model served the request; ModelVersion is the exact model
+	// version the provider reported, when it reports one.
+	Provider     Provider
+	Model        Model
+	ModelVersion string
 }
 
 // RateLimitedProvider wraps a provider with rate limiting.
@@ -124,6 +135,7 @@ type MultiProviderClient struct {
 	promptBuilder *PromptBuilder
 	config        ClientConfig
 	totalUsage    Usage
+	cache         ResponseCache
 }
 
 // NewMultiProviderClient creates a new multi-provider client.
@@ -176,11 +188,24 @@ func NewMultiProviderClient(cfg ClientConfig) (*MultiProviderClient, error) {
 		return nil, ErrNoAPIKey
 	}
 
-	return &MultiProviderClient{
+	client := &MultiProviderClient{
 		providers:     providers,
 		promptBuilder: pb,
 		config:        cfg,
-	}, nil
+	}
+
+	// Response caching is optional and disabled when no TTL is configured
+	if cfg.CacheTTLSeconds > 0 {
+		client.cache = NewMemoryResponseCache(time.Duration(cfg.CacheTTLSeconds) * time.Second)
+	}
+
+	return client, nil
+}
+
+// SetResponseCache replaces the response cache, e.g. with a shared backend.
+// Passing nil disables caching.
+func (c *MultiProviderClient) SetResponseCache(cache ResponseCache) {
+	c.cache = cache
 }
 
 // NormalizeSpec implements Client.
@@ -201,27 +226,20 @@ func (c *MultiProviderClient) NormalizeSpec(
 		Model:          c.config.DefaultModel,
 		SystemPrompt:   "You are an expert software architect.",
 		UserPrompt:     prompt,
-		MaxTokens:      c.config.MaxOutputTokens,
 		Temperature:    c.config.Temperature,
 		ResponseFormat: "json",
 		Function:       FunctionNormalizeSpec,
 		Purpose:        PurposeNormalization,
 	}
 
-	resp, err := c.completeWithFallback(ctx, req)
+	result, resp, err := completeJSON[NormalizedSpecification](ctx, c, req)
 	if err != nil {
 		log.WithError(err).Error("normalize spec failed")
 		return nil, nil, err
 	}
 
-	var result NormalizedSpecification
-	if parseErr := json.Unmarshal([]byte(resp.Content), &result); parseErr != nil {
-		log.WithError(parseErr).Error("failed to parse normalized spec")
-		return nil, nil, fmt.Errorf("%w: %w", ErrInvalidResponse, parseErr)
-	}
-
 	invocation := c.buildInvocationResult(resp, FunctionNormalizeSpec)
-	return &result, invocation, nil
+	return result, invocation, nil
 }
 
 // AnalyzeImpact implements Client.
@@ -242,27 +260,20 @@ func (c *MultiProviderClient) AnalyzeImpact(
 		Model:          c.config.DefaultModel,
 		SystemPrompt:   "You are an expert code analyst.",
 		UserPrompt:     prompt,
-		MaxTokens:      c.config.MaxOutputTokens,
 		Temperature:    c.config.Temperature,
 		ResponseFormat: "json",
 		Function:       FunctionAnalyzeImpact,
 		Purpose:        PurposeImpactAnalysis,
 	}
 
-	resp, err := c.completeWithFallback(ctx, req)
+	result, resp, err := completeJSON[ImpactAnalysisResult](ctx, c, req)
 	if err != nil {
 		log.WithError(err).Error("analyze impact failed")
 		return nil, nil, err
 	}
 
-	var result ImpactAnalysisResult
-	if parseErr := json.Unmarshal([]byte(resp.Content), &result); parseErr != nil {
-		log.WithError(parseErr).Error("failed to parse impact analysis")
-		return nil, nil, fmt.Errorf("%w: %w", ErrInvalidResponse, parseErr)
-	}
-
 	invocation := c.buildInvocationResult(resp, FunctionAnalyzeImpact)
-	return &result, invocation, nil
+	return result, invocation, nil
 }
 
 // GeneratePlan implements Client.
@@ -287,27 +298,20 @@ func (c *MultiProviderClient) GeneratePlan(
 		Model:          model,
 		SystemPrompt:   "You are an expert software architect.",
 		UserPrompt:     prompt,
-		MaxTokens:      c.config.MaxOutputTokens,
 		Temperature:    c.config.Temperature,
 		ResponseFormat: "json",
 		Function:       FunctionGeneratePlan,
 		Purpose:        PurposePlanning,
 	}
 
-	resp, err := c.completeWithFallback(ctx, req)
+	result, resp, err := completeJSON[ImplementationPlan](ctx, c, req)
 	if err != nil {
 		log.WithError(err).Error("generate plan failed")
 		return nil, nil, err
 	}
 
-	var result ImplementationPlan
-	if parseErr := json.Unmarshal([]byte(resp.Content), &result); parseErr != nil {
-		log.WithError(parseErr).Error("failed to parse implementation plan")
-		return nil, nil, fmt.Errorf("%w: %w", ErrInvalidResponse, parseErr)
-	}
-
 	invocation := c.buildInvocationResult(resp, FunctionGeneratePlan)
-	return &result, invocation, nil
+	return result, invocation, nil
 }
 
 // GenerateCode implements Client.
@@ -328,27 +332,54 @@ func (c *MultiProviderClient) GenerateCode(
 		Model:          c.config.DefaultModel,
 		SystemPrompt:   "You are an expert software engineer.",
 		UserPrompt:     prompt,
-		MaxTokens:      c.config.MaxOutputTokens,
 		Temperature:    c.config.Temperature,
 		ResponseFormat: "json",
 		Function:       FunctionGenerateCode,
 		Purpose:        PurposeCodeGeneration,
 	}
 
-	resp, err := c.completeWithFallback(ctx, req)
+	result, resp, err := completeJSON[CodeGenerationResult](ctx, c, req)
 	if err != nil {
 		log.WithError(err).Error("generate code failed")
 		return nil, nil, err
 	}
 
-	var result CodeGenerationResult
-	if parseErr := json.Unmarshal([]byte(resp.Content), &result); parseErr != nil {
-		log.WithError(parseErr).Error("failed to parse code generation result")
-		return nil, nil, fmt.Errorf("%w: %w", ErrInvalidResponse, parseErr)
+	invocation := c.buildInvocationResult(resp, FunctionGenerateCode)
+	return result, invocation, nil
+}
+
+// SelfCheckAcceptance implements Client.
+//
+//nolint:dupl // Similar pattern to other LLM methods, but with different types
+func (c *MultiProviderClient) SelfCheckAcceptance(
+	ctx context.Context,
+	input SelfCheckAcceptanceInput,
+) (*AcceptanceSelfCheckResult, *InvocationResult, error) {
+	log := util.Log(ctx)
+
+	prompt, err := c.promptBuilder.Build(FunctionSelfCheckAcceptance, input)
+	if err != nil {
+		return nil, nil, fmt.Errorf("build prompt: %w", err)
 	}
 
-	invocation := c.buildInvocationResult(resp, FunctionGenerateCode)
-	return &result, invocation, nil
+	req := &CompletionRequest{
+		Model:          c.config.DefaultModel,
+		SystemPrompt:   "You are an expert software engineer.",
+		UserPrompt:     prompt,
+		Temperature:    c.config.Temperature,
+		ResponseFormat: "json",
+		Function:       FunctionSelfCheckAcceptance,
+		Purpose:        PurposeAcceptance,
+	}
+
+	result, resp, err := completeJSON[AcceptanceSelfCheckResult](ctx, c, req)
+	if err != nil {
+		log.WithError(err).Error("acceptance self-check failed")
+		return nil, nil, err
+	}
+
+	invocation := c.buildInvocationResult(resp, FunctionSelfCheckAcceptance)
+	return result, invocation, nil
 }
 
 // GetUsage implements Client.
@@ -356,12 +387,29 @@ func (c *MultiProviderClient) GetUsage() Usage {
 	return c.totalUsage
 }
 
-// completeWithFallback tries each provider in order until one succeeds.
+// completeWithFallback serves the request from cache when possible, otherwise
+// tries each provider in order until one succeeds. A cached response carries no
+// usage, since serving it spent no tokens; completeJSON caches the responses
+// that decoded.
 func (c *MultiProviderClient) completeWithFallback(
 	ctx context.Context,
 	req *CompletionRequest,
 ) (*CompletionResponse, error) {
 	log := util.Log(ctx)
+
+	if c.cache != nil {
+		cacheKey := CacheKey(req)
+		if cached, ok := c.cache.Get(cacheKey); ok {
+			log.Debug("serving response from cache",
+				"function", req.Function,
+				"cache_key", cacheKey,
+			)
+			cached.CacheHit = true
+			cached.Usage = Usage{}
+			return cached, nil
+		}
+	}
+
 	var lastErr error
 
 	for _, provider := range c.providers {
@@ -444,21 +492,38 @@ func (c *MultiProviderClient) completeWithRetry(
 	return nil, lastErr
 }
 
+// withRequestTimeout bounds ctx by the given timeout; zero or negative means no timeout.
+func withRequestTimeout(ctx context.Context, timeoutSeconds int) (context.Context, context.CancelFunc) {
+	if timeoutSeconds <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, time.Duration(timeoutSeconds)*time.Second)
+}
+
 // buildInvocationResult creates an InvocationResult from a response.
 func (c *MultiProviderClient) buildInvocationResult(
 	resp *CompletionResponse,
 	fn Function,
 ) *InvocationResult {
+	// A fallback provider may have served the request instead of the default
+	provider, model := resp.Provider, resp.Model
+	if provider == "" {
+		provider = c.config.DefaultProvider
+	}
+	if model == "" {
+		model = c.config.DefaultModel
+	}
 	return &InvocationResult{
-		Provider:    c.config.DefaultProvider,
-		Model:       c.config.DefaultModel,
-		Function:    fn,
-		Usage:       resp.Usage,
-		LatencyMS:   resp.LatencyMS,
-		StopReason:  resp.StopReason,
-		RequestID:   resp.RequestID,
-		CacheHit:    resp.CacheHit,
-		CompletedAt: time.Now(),
+		Provider:     provider,
+		Model:        model,
+		ModelVersion: resp.ModelVersion,
+		Function:     fn,
+		Usage:        resp.Usage,
+		LatencyMS:    resp.LatencyMS,
+		StopReason:   resp.StopReason,
+		RequestID:    resp.RequestID,
+		CacheHit:     resp.CacheHit,
+		CompletedAt:  time.Now(),
 	}
 }
 
@@ -7,7 +7,9 @@ import (
 	"errors"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestNewMultiProviderClient_NoAPIKey(t *testing.T) {
@@ -430,6 +432,14 @@ func TestPromptBuilder_BuildAllFunctions(t *testing.T) {
 				Language:     "go",
 			},
 		},
+		{
+			FunctionSelfCheckAcceptance,
+			SelfCheckAcceptanceInput{
+				FeatureTitle:       "Test",
+				AcceptanceCriteria: []string{"Returns 404 for unknown users"},
+				Changes:            []ChangedFile{{Path: "main.go", Action: "modify", Content: "package main"}},
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -483,3 +493,341 @@ func TestGetUsage(t *testing.T) {
 		t.Errorf("expected 0 tokens for new client, got %d", usage.TotalTokens)
 	}
 }
+
+// countingProvider is a ProviderClient that records how many completions it served.
+type countingProvider struct {
+	calls int
+}
+
+func (p *countingProvider) Complete(_ context.Context, req *CompletionRequest) (*CompletionResponse, error) {
+	p.calls++
+	return &CompletionResponse{
+		Content:   `{"prompt": "` + req.UserPrompt + `"}`,
+		Usage:     Usage{InputTokens: 10, OutputTokens: 5, TotalTokens: 15},
+		RequestID: "req-1",
+	}, nil
+}
+
+func (p *countingProvider) Provider() Provider { return ProviderAnthropic }
+
+func (p *countingProvider) IsAvailable() bool { return true }
+
+func newCachingTestClient(provider ProviderClient) *MultiProviderClient {
+	return &MultiProviderClient{
+		providers: []ProviderClient{provider},
+		config:    ClientConfig{MaxRetries: 1},
+		cache:     NewMemoryResponseCache(time.Minute),
+	}
+}
+
+// completeCached completes req through the JSON decoding path that caches responses.
+func completeCached(client *MultiProviderClient, req *CompletionRequest) (*CompletionResponse, error) {
+	_, resp, err := completeJSON[map[string]any](context.Background(), client, req)
+	return resp, err
+}
+
+func TestCompleteJSON_RepeatedRequestServedFromCache(t *testing.T) {
+	provider := &countingProvider{}
+	client := newCachingTestClient(provider)
+
+	req := &CompletionRequest{Model: ModelClaudeSonnet, UserPrompt: "same prompt", Function: FunctionGenerateCode}
+
+	first, err := completeCached(client, req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if first.CacheHit {
+		t.Error("expected first response to come from the provider")
+	}
+
+	second, err := completeCached(client, &CompletionRequest{
+		Model:      ModelClaudeSonnet,
+		UserPrompt: "same prompt",
+		Function:   FunctionGenerateCode,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !second.CacheHit {
+		t.Error("expected repeated request to be served from cache")
+	}
+	if second.Content != first.Content {
+		t.Errorf("expected cached content %q, got %q", first.Content, second.Content)
+	}
+	if second.Usage != (Usage{}) {
+		t.Errorf("expected cached response to carry no usage, got %+v", second.Usage)
+	}
+	if provider.calls != 1 {
+		t.Errorf("expected 1 provider call, got %d", provider.calls)
+	}
+	if client.GetUsage().TotalTokens != 15 {
+		t.Errorf("expected cached response not to add usage, got %d tokens", client.GetUsage().TotalTokens)
+	}
+}
+
+func TestCompleteJSON_ChangedRequestBypassesCache(t *testing.T) {
+	provider := &countingProvider{}
+	client := newCachingTestClient(provider)
+
+	if _, err := completeCached(client, &CompletionRequest{Model: ModelClaudeSonnet, UserPrompt: "first"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	resp, err := completeCached(client, &CompletionRequest{Model: ModelClaudeSonnet, UserPrompt: "second"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.CacheHit {
+		t.Error("expected changed request to bypass cache")
+	}
+	if provider.calls != 2 {
+		t.Errorf("expected 2 provider calls, got %d", provider.calls)
+	}
+}
+
+func TestCompleteJSON_CacheDisabled(t *testing.T) {
+	provider := &countingProvider{}
+	client := newCachingTestClient(provider)
+	client.SetResponseCache(nil)
+
+	req := &CompletionRequest{Model: ModelClaudeSonnet, UserPrompt: "same prompt"}
+	for range 2 {
+		if _, err := completeCached(client, req); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if provider.calls != 2 {
+		t.Errorf("expected 2 provider calls without cache, got %d", provider.calls)
+	}
+}
+
+func TestCompleteJSON_InvalidResponseIsNotCached(t *testing.T) {
+	provider := &scriptedProvider{responses: []string{`{"commit_message": "trunc`, `{"commit_message": "x"}`}}
+	client := newCachingTestClient(provider)
+	client.config.StrictJSONOutput = true
+
+	req := &CompletionRequest{Model: ModelClaudeSonnet, UserPrompt: "same prompt"}
+	if _, err := completeCached(client, req); !errors.Is(err, ErrInvalidResponse) {
+		t.Fatalf("expected ErrInvalidResponse, got %v", err)
+	}
+
+	resp, err := completeCached(client, req)
+	if err != nil {
+		t.Fatalf("expected the request to be asked again, got %v", err)
+	}
+	if resp.CacheHit {
+		t.Error("expected the invalid response not to be served from cache")
+	}
+	if len(provider.prompts) != 2 {
+		t.Errorf("expected 2 provider calls, got %d", len(provider.prompts))
+	}
+}
+
+// pricedProvider is a scriptedProvider whose responses cost costUSD each.
+type pricedProvider struct {
+	scriptedProvider
+	costUSD float64
+}
+
+func (p *pricedProvider) Complete(ctx context.Context, req *CompletionRequest) (*CompletionResponse, error) {
+	resp, err := p.scriptedProvider.Complete(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	resp.Usage.CostUSD = p.costUSD
+	return resp, nil
+}
+
+func TestGenerateCode_CachedResponseChargesNoUsage(t *testing.T) {
+	provider := &pricedProvider{
+		scriptedProvider: scriptedProvider{responses: []string{`{"file_changes": [], "commit_message": "add endpoint"}`}},
+		costUSD:          0.01,
+	}
+	client := newJSONTestClient(t, provider, ClientConfig{})
+	client.SetResponseCache(NewMemoryResponseCache(time.Minute))
+	input := GenerateCodeInput{Step: PlanStep{StepNumber: 1}}
+
+	_, first, err := client.GenerateCode(context.Background(), input)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	_, second, err := client.GenerateCode(context.Background(), input)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if first.Usage.TotalTokens != 10 || first.Usage.CostUSD != 0.01 {
+		t.Errorf("expected the provider call to be charged, got %+v", first.Usage)
+	}
+	if !second.CacheHit {
+		t.Fatal("expected the repeated call to be served from cache")
+	}
+	if second.Usage.TotalTokens != 0 || second.Usage.CostUSD != 0 {
+		t.Errorf("expected a cached call to add no tokens or cost, got %+v", second.Usage)
+	}
+	if len(provider.prompts) != 1 {
+		t.Errorf("expected 1 provider call, got %d", len(provider.prompts))
+	}
+}
+
+func TestMemoryResponseCache_Expiry(t *testing.T) {
+	cache := NewMemoryResponseCache(time.Minute)
+	now := time.Now()
+	cache.now = func() time.Time { return now }
+
+	key := CacheKey(&CompletionRequest{UserPrompt: "prompt"})
+	cache.Set(key, &CompletionResponse{Content: "cached"})
+
+	if _, ok := cache.Get(key); !ok {
+		t.Fatal("expected entry within TTL")
+	}
+
+	now = now.Add(2 * time.Minute)
+	if _, ok := cache.Get(key); ok {
+		t.Error("expected entry to expire after TTL")
+	}
+	if cache.Len() != 0 {
+		t.Errorf("expected expired entry to be evicted, got %d entries", cache.Len())
+	}
+}
+
+func TestClientConfig_ResolveLimits(t *testing.T) {
+	cfg := ClientConfig{
+		TimeoutSeconds:  120,
+		MaxOutputTokens: 16384,
+		ProviderLimits: map[Provider]LimitOverride{
+			ProviderOpenAI: {TimeoutSeconds: 60},
+		},
+		ModelLimits: map[Model]LimitOverride{
+			ModelClaudeOpus: {TimeoutSeconds: 600, MaxOutputTokens: 32000},
+			ModelGPT4o:      {MaxOutputTokens: 8192},
+		},
+	}
+
+	tests := []struct {
+		provider    Provider
+		model       Model
+		wantTimeout int
+		wantTokens  int
+	}{
+		{ProviderAnthropic, ModelClaudeSonnet, 120, 16384},
+		{ProviderAnthropic, ModelClaudeOpus, 600, 32000},
+		{ProviderOpenAI, "gpt-4o-mini", 60, 16384},
+		{ProviderOpenAI, ModelGPT4o, 60, 8192},
+	}
+
+	for _, tt := range tests {
+		timeout, tokens := cfg.ResolveLimits(tt.provider, tt.model)
+		if timeout != tt.wantTimeout || tokens != tt.wantTokens {
+			t.Errorf("ResolveLimits(%s, %s) = (%d, %d), expected (%d, %d)",
+				tt.provider, tt.model, timeout, tokens, tt.wantTimeout, tt.wantTokens)
+		}
+	}
+}
+
+func TestAnthropicClient_Complete_UsesModelLimits(t *testing.T) {
+	var gotMaxTokens int
+	var gotDeadline time.Duration
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body anthropicRequest
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		gotMaxTokens = body.MaxTokens
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(anthropicResponse{
+			ID:      "msg_limits",
+			Content: []anthropicContent{{Type: "text", Text: "{}"}},
+		})
+	}))
+	defer server.Close()
+
+	cfg := ClientConfig{
+		TimeoutSeconds:  30,
+		MaxOutputTokens: 4096,
+		ModelLimits: map[Model]LimitOverride{
+			ModelClaudeOpus: {TimeoutSeconds: 600, MaxOutputTokens: 32000},
+		},
+	}
+	client := NewAnthropicClient("test-key", cfg)
+	client.httpClient.Transport = roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		if deadline, ok := req.Context().Deadline(); ok {
+			gotDeadline = time.Until(deadline)
+		}
+		return (&testTransport{originalURL: anthropicAPIURL, testURL: server.URL}).RoundTrip(req)
+	})
+
+	ctx := context.Background()
+	if _, err := client.Complete(ctx, &CompletionRequest{Model: ModelClaudeOpus, UserPrompt: "p"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if gotMaxTokens != 32000 {
+		t.Errorf("expected model max tokens 32000, got %d", gotMaxTokens)
+	}
+	if gotDeadline <= 30*time.Second {
+		t.Errorf("expected model timeout above the global 30s, got %s", gotDeadline)
+	}
+
+	if _, err := client.Complete(ctx, &CompletionRequest{Model: ModelClaudeSonnet, UserPrompt: "p"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if gotMaxTokens != 4096 {
+		t.Errorf("expected global max tokens 4096, got %d", gotMaxTokens)
+	}
+	if gotDeadline > 30*time.Second {
+		t.Errorf("expected global 30s timeout, got %s", gotDeadline)
+	}
+}
+
+func TestGoogleClient_Complete_KeysModelLimitsByConfiguredModel(t *testing.T) {
+	var gotMaxTokens int
+	var gotPath string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body googleRequest
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		gotMaxTokens = body.GenerationConfig.MaxOutputTokens
+		gotPath = r.URL.Path
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(googleResponse{
+			Candidates: []googleCandidate{{Content: googleContent{Parts: []googlePart{{Text: "{}"}}}}},
+		})
+	}))
+	defer server.Close()
+
+	cfg := ClientConfig{
+		TimeoutSeconds:  30,
+		MaxOutputTokens: 4096,
+		ModelLimits: map[Model]LimitOverride{
+			ModelClaudeOpus: {MaxOutputTokens: 32000},
+		},
+	}
+	client := NewGoogleClient("test-key", cfg)
+	client.httpClient.Transport = &testTransport{testURL: server.URL}
+
+	ctx := context.Background()
+	if _, err := client.Complete(ctx, &CompletionRequest{Model: ModelClaudeOpus, UserPrompt: "p"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(gotPath, "gemini-1.5-pro") {
+		t.Errorf("expected request for the mapped gemini-1.5-pro model, got path %s", gotPath)
+	}
+	if gotMaxTokens != 32000 {
+		t.Errorf("expected configured model max tokens 32000, got %d", gotMaxTokens)
+	}
+
+	if _, err := client.Complete(ctx, &CompletionRequest{Model: ModelClaudeSonnet, UserPrompt: "p"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if gotMaxTokens != 4096 {
+		t.Errorf("expected global max tokens 4096, got %d", gotMaxTokens)
+	}
+}
+
+// roundTripperFunc adapts a function to http.RoundTripper.
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
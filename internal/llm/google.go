@@ -23,10 +23,9 @@ type GoogleClient struct {
 func NewGoogleClient(apiKey string, cfg ClientConfig) *GoogleClient {
 	return &GoogleClient{
 		apiKey: apiKey,
-		httpClient: &http.Client{
-			Timeout: time.Duration(cfg.TimeoutSeconds) * time.Second,
-		},
-		config: cfg,
+		// Timeouts are applied per request so they can vary by model
+		httpClient: &http.Client{},
+		config:     cfg,
 	}
 }
 
@@ -68,6 +67,7 @@ type googleGenerationConfig struct {
 type googleResponse struct {
 	Candidates    []googleCandidate   `json:"candidates"`
 	UsageMetadata googleUsageMetadata `json:"usageMetadata"`
+	ModelVersion  string              `json:"modelVersion"`
 }
 
 // googleCandidate is a candidate response.
@@ -105,9 +105,19 @@ func (c *GoogleClient) Complete(
 	// Map model to Google model
 	model := mapModelToGoogle(req.Model)
 
+	// Resolve per-provider/model limits for the configured model, so overrides
+	// are keyed by the names operators configure rather than the mapped ones
+	configured := req.Model
+	if configured == "" {
+		configured = Model(model)
+	}
+	timeoutSeconds, maxOutputTokens := c.config.ResolveLimits(ProviderGoogle, configured)
+	ctx, cancel := withRequestTimeout(ctx, timeoutSeconds)
+	defer cancel()
+
 	maxTokens := req.MaxTokens
 	if maxTokens == 0 {
-		maxTokens = c.config.MaxOutputTokens
+		maxTokens = maxOutputTokens
 	}
 
 	// Build request
@@ -203,6 +213,10 @@ func (c *GoogleClient) Complete(
 		RequestID:  "", // Google doesn't return a request ID
 		LatencyMS:  latencyMS,
 		CacheHit:   false,
+
+		Provider:     ProviderGoogle,
+		Model:        Model(model),
+		ModelVersion: googleResp.ModelVersion,
 	}, nil
 }
 
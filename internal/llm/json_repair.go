@@ -0,0 +1,193 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/pitabwire/util"
+)
+
+// maxReaskEchoLength bounds how much of an invalid response is echoed back when re-asking.
+const maxReaskEchoLength = 2000
+
+// codeFencePattern matches a markdown code block, optionally tagged with a language.
+var codeFencePattern = regexp.MustCompile("(?s)```[a-zA-Z]*[ \\t]*\\r?\\n?(.*?)```")
+
+// RepairJSON makes a best-effort attempt to turn a model response into valid JSON.
+// It strips markdown code fences and surrounding prose and removes trailing commas.
+// A truncated response is left truncated: closing it could decode a partial answer
+// as a complete one, so it fails to decode and the model is asked again.
+func RepairJSON(content string) string {
+	repaired := strings.TrimSpace(content)
+	if match := codeFencePattern.FindStringSubmatch(repaired); match != nil {
+		repaired = strings.TrimSpace(match[1])
+	}
+	repaired = extractJSONValue(repaired)
+	return removeTrailingCommas(repaired)
+}
+
+// decodeJSONResponse unmarshals a model response into v. Unless strict, a response
+// that is not valid JSON is repaired and decoded again; the original error is
+// returned when the repair does not help.
+func decodeJSONResponse(content string, v any, strict bool) error {
+	err := json.Unmarshal([]byte(content), v)
+	if err == nil || strict {
+		return err
+	}
+	if repairErr := json.Unmarshal([]byte(RepairJSON(content)), v); repairErr != nil {
+		return err
+	}
+	return nil
+}
+
+// completeJSON completes req and decodes the response as T. Malformed responses are
+// repaired and, if still invalid, the model is asked again for valid JSON up to
+// JSONReaskAttempts times before ErrInvalidResponse is returned. The usage of the
+// returned response includes the attempts that were asked again.
+func completeJSON[T any](
+	ctx context.Context,
+	c *MultiProviderClient,
+	req *CompletionRequest,
+) (*T, *CompletionResponse, error) {
+	resp, err := c.completeWithFallback(ctx, req)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	reaskAttempts := c.config.JSONReaskAttempts
+	if c.config.StrictJSONOutput {
+		reaskAttempts = 0
+	}
+
+	result := new(T)
+	parseErr := decodeJSONResponse(resp.Content, result, c.config.StrictJSONOutput)
+	for attempt := 0; parseErr != nil && attempt < reaskAttempts; attempt++ {
+		util.Log(ctx).WithError(parseErr).Warn("LLM returned invalid JSON, asking again",
+			"function", req.Function,
+			"attempt", attempt+1,
+		)
+
+		reask := *req
+		reask.UserPrompt = buildReaskPrompt(req.UserPrompt, resp.Content, parseErr)
+		spent := resp.Usage
+		resp, err = c.completeWithFallback(ctx, &reask)
+		if err != nil {
+			return nil, nil, err
+		}
+		resp.Usage = addUsage(resp.Usage, spent)
+
+		result = new(T)
+		parseErr = decodeJSONResponse(resp.Content, result, c.config.StrictJSONOutput)
+	}
+	if parseErr != nil {
+		return nil, nil, fmt.Errorf("%w: %w", ErrInvalidResponse, parseErr)
+	}
+
+	// Only a response that decoded is cached, so an invalid one is not replayed
+	if c.cache != nil && !resp.CacheHit {
+		c.cache.Set(CacheKey(req), resp)
+	}
+
+	return result, resp, nil
+}
+
+// buildReaskPrompt repeats the original prompt with the invalid response and the
+// parse error, asking for a corrected JSON-only answer.
+func buildReaskPrompt(prompt, invalidResponse string, parseErr error) string {
+	var b strings.Builder
+	b.WriteString(prompt)
+	b.WriteString("\n\nYour previous response could not be parsed as JSON (")
+	b.WriteString(parseErr.Error())
+	b.WriteString("):\n\n")
+	b.WriteString(truncateContent(invalidResponse, maxReaskEchoLength))
+	b.WriteString("\n\nRespond again with only the corrected JSON document. ")
+	b.WriteString("Do not wrap it in markdown or add any commentary.")
+	return b.String()
+}
+
+// extractJSONValue returns the first JSON object or array in s, dropping any prose
+// around it. A value a truncated response left open is returned as it is.
+func extractJSONValue(s string) string {
+	start := strings.IndexAny(s, "{[")
+	if start < 0 {
+		return s
+	}
+
+	var closers []byte
+	inString, escaped := false, false
+	for i := start; i < len(s); i++ {
+		ch := s[i]
+		switch {
+		case escaped:
+			escaped = false
+		case inString:
+			switch ch {
+			case '\\':
+				escaped = true
+			case '"':
+				inString = false
+			}
+		case ch == '"':
+			inString = true
+		case ch == '{':
+			closers = append(closers, '}')
+		case ch == '[':
+			closers = append(closers, ']')
+		case ch == '}' || ch == ']':
+			if len(closers) == 0 || closers[len(closers)-1] != ch {
+				return s[start:i]
+			}
+			closers = closers[:len(closers)-1]
+			if len(closers) == 0 {
+				return s[start : i+1]
+			}
+		}
+	}
+
+	return s[start:]
+}
+
+// addUsage returns the sum of two usages.
+func addUsage(a, b Usage) Usage {
+	return Usage{
+		InputTokens:      a.InputTokens + b.InputTokens,
+		OutputTokens:     a.OutputTokens + b.OutputTokens,
+		TotalTokens:      a.TotalTokens + b.TotalTokens,
+		CacheReadTokens:  a.CacheReadTokens + b.CacheReadTokens,
+		CacheWriteTokens: a.CacheWriteTokens + b.CacheWriteTokens,
+		CostUSD:          a.CostUSD + b.CostUSD,
+	}
+}
+
+// removeTrailingCommas drops commas directly followed by a closing bracket.
+func removeTrailingCommas(s string) string {
+	var b strings.Builder
+	b.Grow(len(s))
+	inString, escaped := false, false
+	for i := 0; i < len(s); i++ {
+		ch := s[i]
+		switch {
+		case escaped:
+			escaped = false
+		case inString:
+			switch ch {
+			case '\\':
+				escaped = true
+			case '"':
+				inString = false
+			}
+		case ch == '"':
+			inString = true
+		case ch == ',':
+			next := strings.TrimLeft(s[i+1:], " \t\r\n")
+			if next != "" && (next[0] == '}' || next[0] == ']') {
+				continue
+			}
+		}
+		b.WriteByte(ch)
+	}
+	return b.String()
+}
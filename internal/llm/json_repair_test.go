@@ -0,0 +1,228 @@
+//nolint:testpackage // Testing internal functions requires same package
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestRepairJSON(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{
+			name:    "markdown fence",
+			content: "```json\n{\"commit_message\": \"add endpoint\"}\n```",
+			want:    `{"commit_message": "add endpoint"}`,
+		},
+		{
+			name:    "untagged fence with prose",
+			content: "Here is the change:\n```\n{\"a\": 1}\n```\nLet me know if you need more.",
+			want:    `{"a": 1}`,
+		},
+		{
+			name:    "trailing commas",
+			content: `{"files": ["a.go", "b.go",], "count": 2,}`,
+			want:    `{"files": ["a.go", "b.go"], "count": 2}`,
+		},
+		{
+			name:    "commas inside strings are kept",
+			content: `{"content": "a,}", "more": [1,],}`,
+			want:    `{"content": "a,}", "more": [1]}`,
+		},
+		{
+			name:    "surrounding prose without fence",
+			content: `Sure! {"a": {"b": [1, 2]}} Hope this helps.`,
+			want:    `{"a": {"b": [1, 2]}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := RepairJSON(tt.content)
+			if got != tt.want {
+				t.Errorf("RepairJSON() = %q, want %q", got, tt.want)
+			}
+			if !json.Valid([]byte(got)) {
+				t.Errorf("repaired JSON is not valid: %q", got)
+			}
+		})
+	}
+}
+
+// scriptedProvider is a ProviderClient that returns a fixed sequence of responses.
+type scriptedProvider struct {
+	responses []string
+	prompts   []string
+}
+
+func (p *scriptedProvider) Complete(_ context.Context, req *CompletionRequest) (*CompletionResponse, error) {
+	p.prompts = append(p.prompts, req.UserPrompt)
+	content := p.responses[min(len(p.prompts), len(p.responses))-1]
+	return &CompletionResponse{Content: content, Usage: Usage{TotalTokens: 10}}, nil
+}
+
+func (p *scriptedProvider) Provider() Provider { return ProviderAnthropic }
+
+func (p *scriptedProvider) IsAvailable() bool { return true }
+
+func newJSONTestClient(t *testing.T, provider ProviderClient, cfg ClientConfig) *MultiProviderClient {
+	t.Helper()
+	pb, err := NewPromptBuilder()
+	if err != nil {
+		t.Fatalf("create prompt builder: %v", err)
+	}
+	cfg.MaxRetries = 1
+	return &MultiProviderClient{
+		providers:     []ProviderClient{provider},
+		promptBuilder: pb,
+		config:        cfg,
+	}
+}
+
+func TestGenerateCode_RepairsFencedMalformedJSON(t *testing.T) {
+	provider := &scriptedProvider{responses: []string{
+		"```json\n{\"file_changes\": [{\"file_path\": \"main.go\", \"action\": \"modify\"," +
+			" \"content\": \"package main\"},], \"commit_message\": \"update main\",}\n```",
+	}}
+	client := newJSONTestClient(t, provider, ClientConfig{})
+
+	result, _, err := client.GenerateCode(context.Background(), GenerateCodeInput{Step: PlanStep{StepNumber: 1}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(result.FileChanges) != 1 || result.FileChanges[0].FilePath != "main.go" {
+		t.Errorf("unexpected file changes: %+v", result.FileChanges)
+	}
+	if result.CommitMessage != "update main" {
+		t.Errorf("expected commit message %q, got %q", "update main", result.CommitMessage)
+	}
+	if len(provider.prompts) != 1 {
+		t.Errorf("expected repair without re-asking, got %d calls", len(provider.prompts))
+	}
+}
+
+func TestGenerateCode_ReasksForValidJSON(t *testing.T) {
+	provider := &scriptedProvider{responses: []string{
+		"I changed main.go to add the endpoint.",
+		`{"file_changes": [], "commit_message": "add endpoint"}`,
+	}}
+	client := newJSONTestClient(t, provider, ClientConfig{JSONReaskAttempts: 1})
+
+	result, _, err := client.GenerateCode(context.Background(), GenerateCodeInput{Step: PlanStep{StepNumber: 1}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.CommitMessage != "add endpoint" {
+		t.Errorf("expected commit message from re-ask, got %q", result.CommitMessage)
+	}
+	if len(provider.prompts) != 2 {
+		t.Fatalf("expected 2 calls, got %d", len(provider.prompts))
+	}
+	if !strings.Contains(provider.prompts[1], "could not be parsed as JSON") {
+		t.Errorf("expected re-ask prompt to explain the parse failure, got %q", provider.prompts[1])
+	}
+}
+
+func TestRepairJSON_LeavesTruncatedResponseInvalid(t *testing.T) {
+	content := `{"file_changes": [{"file_path": "main.go", "content": "package ma`
+
+	got := RepairJSON(content)
+	if got != content {
+		t.Errorf("RepairJSON() = %q, want the truncated response unchanged", got)
+	}
+	if json.Valid([]byte(got)) {
+		t.Errorf("a truncated response must not be repaired into valid JSON: %q", got)
+	}
+}
+
+func TestGenerateCode_ReasksTruncatedResponseCountingEveryAttempt(t *testing.T) {
+	provider := &scriptedProvider{responses: []string{
+		`{"file_changes": [{"file_path": "main.go", "content": "package ma`,
+		`{"file_changes": [], "commit_message": "add endpoint"}`,
+	}}
+	client := newJSONTestClient(t, provider, ClientConfig{JSONReaskAttempts: 1})
+
+	result, invocation, err := client.GenerateCode(context.Background(), GenerateCodeInput{Step: PlanStep{StepNumber: 1}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(provider.prompts) != 2 {
+		t.Fatalf("expected the truncated response to be asked again, got %d calls", len(provider.prompts))
+	}
+	if result.CommitMessage != "add endpoint" {
+		t.Errorf("expected commit message from re-ask, got %q", result.CommitMessage)
+	}
+	if invocation.Usage.TotalTokens != 20 {
+		t.Errorf("expected the tokens of both attempts, got %d", invocation.Usage.TotalTokens)
+	}
+}
+
+func TestGenerateCode_GivesUpAfterReaskAttempts(t *testing.T) {
+	provider := &scriptedProvider{responses: []string{"not json"}}
+	client := newJSONTestClient(t, provider, ClientConfig{JSONReaskAttempts: 2})
+
+	_, _, err := client.GenerateCode(context.Background(), GenerateCodeInput{Step: PlanStep{StepNumber: 1}})
+	if !errors.Is(err, ErrInvalidResponse) {
+		t.Fatalf("expected ErrInvalidResponse, got %v", err)
+	}
+	if len(provider.prompts) != 3 {
+		t.Errorf("expected 1 call and 2 re-asks, got %d calls", len(provider.prompts))
+	}
+}
+
+func TestGenerateCode_StrictJSONOutput(t *testing.T) {
+	provider := &scriptedProvider{responses: []string{"```json\n{\"commit_message\": \"x\"}\n```"}}
+	client := newJSONTestClient(t, provider, ClientConfig{StrictJSONOutput: true, JSONReaskAttempts: 1})
+
+	_, _, err := client.GenerateCode(context.Background(), GenerateCodeInput{Step: PlanStep{StepNumber: 1}})
+	if !errors.Is(err, ErrInvalidResponse) {
+		t.Fatalf("expected ErrInvalidResponse in strict mode, got %v", err)
+	}
+	if len(provider.prompts) != 1 {
+		t.Errorf("expected no re-ask in strict mode, got %d calls", len(provider.prompts))
+	}
+}
+
+// servedByProvider reports the provider, model and model version that served each response.
+type servedByProvider struct {
+	scriptedProvider
+}
+
+func (p *servedByProvider) Complete(ctx context.Context, req *CompletionRequest) (*CompletionResponse, error) {
+	resp, err := p.scriptedProvider.Complete(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	resp.Provider = ProviderOpenAI
+	resp.Model = ModelGPT4o
+	resp.ModelVersion = "gpt-4o-2024-08-06"
+	return resp, nil
+}
+
+func TestGenerateCode_ReportsServingModel(t *testing.T) {
+	provider := &servedByProvider{scriptedProvider{responses: []string{
+		`{"file_changes": [], "commit_message": "noop"}`,
+	}}}
+	client := newJSONTestClient(t, provider, ClientConfig{
+		DefaultProvider: ProviderAnthropic,
+		DefaultModel:    ModelClaudeSonnet,
+	})
+
+	_, invocation, err := client.GenerateCode(context.Background(), GenerateCodeInput{Step: PlanStep{StepNumber: 1}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// The model that served the request is reported, not the configured default
+	if invocation.Provider != ProviderOpenAI || invocation.Model != ModelGPT4o {
+		t.Errorf("expected openai/%s, got %s/%s", ModelGPT4o, invocation.Provider, invocation.Model)
+	}
+	if invocation.ModelVersion != "gpt-4o-2024-08-06" {
+		t.Errorf("expected model version %q, got %q", "gpt-4o-2024-08-06", invocation.ModelVersion)
+	}
+}
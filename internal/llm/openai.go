@@ -23,10 +23,9 @@ type OpenAIClient struct {
 func NewOpenAIClient(apiKey string, cfg ClientConfig) *OpenAIClient {
 	return &OpenAIClient{
 		apiKey: apiKey,
-		httpClient: &http.Client{
-			Timeout: time.Duration(cfg.TimeoutSeconds) * time.Second,
-		},
-		config: cfg,
+		// Timeouts are applied per request so they can vary by model
+		httpClient: &http.Client{},
+		config:     cfg,
 	}
 }
 
@@ -103,9 +102,19 @@ func (c *OpenAIClient) Complete(
 		model = string(ModelGPT4o)
 	}
 
+	// Resolve per-provider/model limits for the configured model, so overrides
+	// are keyed by the names operators configure rather than the mapped ones
+	configured := req.Model
+	if configured == "" {
+		configured = Model(model)
+	}
+	timeoutSeconds, maxOutputTokens := c.config.ResolveLimits(ProviderOpenAI, configured)
+	ctx, cancel := withRequestTimeout(ctx, timeoutSeconds)
+	defer cancel()
+
 	maxTokens := req.MaxTokens
 	if maxTokens == 0 {
-		maxTokens = c.config.MaxOutputTokens
+		maxTokens = maxOutputTokens
 	}
 
 	// Build messages
@@ -196,6 +205,10 @@ func (c *OpenAIClient) Complete(
 		RequestID:  openaiResp.ID,
 		LatencyMS:  latencyMS,
 		CacheHit:   false,
+
+		Provider:     ProviderOpenAI,
+		Model:        Model(model),
+		ModelVersion: openaiResp.Model,
 	}, nil
 }
 
@@ -28,6 +28,8 @@ func NewPromptBuilder() (*PromptBuilder, error) {
 		FunctionGenerateTests:  generateTestsTemplate,
 		FunctionPlanIteration:  planIterationTemplate,
 		FunctionGenerateCommit: generateCommitTemplate,
+
+		FunctionSelfCheckAcceptance: selfCheckAcceptanceTemplate,
 	}
 
 	for fn, tmpl := range templates {
@@ -696,3 +698,57 @@ Respond with a JSON object matching this schema:
   "body": "string" (optional),
   "type": "feat|fix|refactor|test|docs|chore|style|perf"
 }`
+
+// SelfCheckAcceptanceInput is the input for SelfCheckAcceptance.
+type SelfCheckAcceptanceInput struct {
+	FeatureTitle       string
+	FeatureDescription string
+	AcceptanceCriteria []string
+	Changes            []ChangedFile
+}
+
+// ChangedFile is a generated file change presented for self-assessment.
+type ChangedFile struct {
+	Path    string
+	Action  string
+	Content string
+}
+
+const selfCheckAcceptanceTemplate = `You are an expert software engineer verifying your own implementation.
+
+## Task
+Assess whether the generated changes satisfy each acceptance criterion.
+
+## Feature
+Title: {{.FeatureTitle}}
+Description: {{.FeatureDescription}}
+
+## Acceptance Criteria
+{{- range .AcceptanceCriteria}}
+- {{.}}
+{{- end}}
+
+## Generated Changes
+{{- range .Changes}}
+
+### {{.Path}} ({{.Action}})
+` + "```" + `
+{{.Content}}
+` + "```" + `
+{{- end}}
+
+## Instructions
+1. Assess every acceptance criterion, in the order given
+2. Mark a criterion satisfied only if the changes clearly implement it
+3. Explain briefly what is missing for unmet criteria
+
+Respond with a JSON object matching this schema:
+{
+  "criteria": [
+    {
+      "criterion": "string - the criterion as given",
+      "satisfied": boolean,
+      "rationale": "string - evidence or what is missing"
+    }
+  ]
+}`
@@ -46,6 +46,8 @@ const (
 	FunctionGenerateTests  Function = "GenerateTests"
 	FunctionPlanIteration  Function = "PlanIteration"
 	FunctionGenerateCommit Function = "GenerateCommitMessage"
+
+	FunctionSelfCheckAcceptance Function = "SelfCheckAcceptance"
 )
 
 // Purpose categorizes LLM invocation purposes.
@@ -61,6 +63,7 @@ const (
 	PurposeCodeReview     Purpose = "code_review"
 	PurposeIteration      Purpose = "iteration"
 	PurposeCommitMessage  Purpose = "commit_message"
+	PurposeAcceptance     Purpose = "acceptance_check"
 )
 
 // FeatureCategory identifies the type of feature.
@@ -403,6 +406,19 @@ type CodeGenerationResult struct {
 	Notes         string       `json:"notes,omitempty"`
 }
 
+// AcceptanceSelfCheckResult is the model's assessment of its own changes against
+// the acceptance criteria.
+type AcceptanceSelfCheckResult struct {
+	Criteria []CriterionAssessment `json:"criteria"`
+}
+
+// CriterionAssessment states whether one acceptance criterion is satisfied.
+type CriterionAssessment struct {
+	Criterion string `json:"criterion"`
+	Satisfied bool   `json:"satisfied"`
+	Rationale string `json:"rationale,omitempty"`
+}
+
 // FileChange describes a change to a file.
 type FileChange struct {
 	FilePath     string     `json:"file_path"`
@@ -425,15 +441,16 @@ type Usage struct {
 
 // InvocationResult is the result of an LLM invocation.
 type InvocationResult struct {
-	Provider    Provider  `json:"provider"`
-	Model       Model     `json:"model"`
-	Function    Function  `json:"function"`
-	Usage       Usage     `json:"usage"`
-	LatencyMS   int64     `json:"latency_ms"`
-	StopReason  string    `json:"stop_reason"`
-	RequestID   string    `json:"request_id,omitempty"`
-	CacheHit    bool      `json:"cache_hit"`
-	CompletedAt time.Time `json:"completed_at"`
+	Provider     Provider  `json:"provider"`
+	Model        Model     `json:"model"`
+	ModelVersion string    `json:"model_version,omitempty"`
+	Function     Function  `json:"function"`
+	Usage        Usage     `json:"usage"`
+	LatencyMS    int64     `json:"latency_ms"`
+	StopReason   string    `json:"stop_reason"`
+	RequestID    string    `json:"request_id,omitempty"`
+	CacheHit     bool      `json:"cache_hit"`
+	CompletedAt  time.Time `json:"completed_at"`
 }
 
 // Default configuration constants.
@@ -473,6 +490,42 @@ type ClientConfig struct {
 	OpenAIRPS    float64
 	GoogleRPS    float64
 	BurstSize    int
+
+	// Response caching (seconds identical requests are served from cache, 0 = disabled)
+	CacheTTLSeconds int
+
+	// JSON output enforcement: malformed JSON responses are repaired unless
+	// StrictJSONOutput is set, then re-asked up to JSONReaskAttempts times
+	StrictJSONOutput  bool
+	JSONReaskAttempts int
+
+	// Per-provider and per-model limit overrides (model overrides take precedence)
+	ProviderLimits map[Provider]LimitOverride
+	ModelLimits    map[Model]LimitOverride
+}
+
+// LimitOverride overrides the global timeout and output token cap. Zero values inherit.
+type LimitOverride struct {
+	TimeoutSeconds  int
+	MaxOutputTokens int
+}
+
+// ResolveLimits returns the timeout and output token cap for a provider and model.
+// Model overrides win over provider overrides, which win over the global settings.
+func (c ClientConfig) ResolveLimits(provider Provider, model Model) (int, int) {
+	timeoutSeconds := c.TimeoutSeconds
+	maxOutputTokens := c.MaxOutputTokens
+
+	for _, override := range []LimitOverride{c.ProviderLimits[provider], c.ModelLimits[model]} {
+		if override.TimeoutSeconds > 0 {
+			timeoutSeconds = override.TimeoutSeconds
+		}
+		if override.MaxOutputTokens > 0 {
+			maxOutputTokens = override.MaxOutputTokens
+		}
+	}
+
+	return timeoutSeconds, maxOutputTokens
 }
 
 // DefaultClientConfig returns default client configuration.
@@ -30,6 +30,20 @@ CREATE TABLE IF NOT EXISTS executions (
     total_steps INTEGER DEFAULT 0,
     iteration_count INTEGER DEFAULT 0,
 
+    -- Execution-level retry on infrastructure failure
+    retry_attempts INTEGER NOT NULL DEFAULT 0,
+    initial_request JSONB,
+
+    -- Deduplication of identical requests
+    spec_hash VARCHAR(64),
+    requesters JSONB,
+
+    -- LLM provider, model and version that served the execution
+    llm_models JSONB,
+
+    -- Optimistic locking
+    version BIGINT NOT NULL DEFAULT 0,
+
     -- Timestamps
     created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
     updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
@@ -53,6 +67,9 @@ CREATE TABLE IF NOT EXISTS executions (
 CREATE INDEX IF NOT EXISTS idx_executions_status ON executions(status);
 CREATE INDEX IF NOT EXISTS idx_executions_created_at ON executions(created_at DESC);
 CREATE INDEX IF NOT EXISTS idx_executions_repository ON executions(repository_url);
+CREATE INDEX IF NOT EXISTS idx_executions_spec_hash ON executions(spec_hash);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_executions_spec_hash_in_flight ON executions(spec_hash)
+    WHERE spec_hash <> '' AND status IN ('pending', 'running');
 
 -- =============================================================================
 -- Execution Steps Table